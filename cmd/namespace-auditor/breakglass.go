@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	"k8s.io/client-go/kubernetes"
+)
+
+// parseDeleteNowArgs parses the arguments of the delete-now command: the
+// namespace to delete and a required --reason for the audit record.
+func parseDeleteNowArgs(args []string) (name, reason string, err error) {
+	fs := flag.NewFlagSet("delete-now", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&reason, "reason", "", "Why the grace period is skipped, recorded on the namespace and in the run report")
+	if err := fs.Parse(args); err != nil {
+		return "", "", err
+	}
+	if fs.NArg() != 1 {
+		return "", "", errors.New("exactly one namespace is required")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return "", "", auditor.ErrBreakGlassReason
+	}
+	return fs.Arg(0), reason, nil
+}
+
+// deleteNow performs a break-glass deletion of a single namespace, skipping
+// its grace period. The request is refused unless the namespace is within
+// the audit scope and the identity applying changes is authenticated and
+// allowed to delete namespaces. A dry run reports missing permissions instead.
+// Parameters:
+// - ctx: Context for cancellation and timeouts
+// - p: Initialized NamespaceProcessor with configuration
+// - client: Client changes are applied with, identifying the requester
+// - labelSelector: Selector identifying namespaces to audit
+// - name: Name of the namespace to delete
+// - reason: Why the grace period is skipped
+// - dryRun: Whether the deletion is only planned
+// Returns:
+// - *auditor.RunReport: Audit record of the deletion, nil if it was refused
+// before anything was attempted
+func deleteNow(ctx context.Context, p *auditor.NamespaceProcessor, client kubernetes.Interface, labelSelector, name, reason string, dryRun bool) (*auditor.RunReport, error) {
+	requestedBy, err := auditor.WhoAmI(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	if requestedBy == "" {
+		return nil, errors.New("refusing a break-glass deletion by an unauthenticated user")
+	}
+
+	list, err := targetNamespaces(p, labelSelector, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	namespaces := list.Items
+	if len(namespaces) == 0 {
+		return nil, fmt.Errorf("namespace %s does not match selector %q", name, labelSelector)
+	}
+
+	checks, err := auditor.CheckWritePermissions(ctx, client, []auditor.PlannedChange{
+		{Op: auditor.OpUpdate, Namespace: name},
+		{Op: auditor.OpDelete, Namespace: name},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range checks {
+		if c.Allowed {
+			continue
+		}
+		if !dryRun {
+			return nil, fmt.Errorf("%s may not %s", requestedBy, c)
+		}
+		log.Printf("[DRY RUN] Break-glass deletion requires permission to %s", c)
+	}
+
+	report := auditor.NewRunReport(p.RunInfo())
+	defer report.Finish()
+	defer recordAPIUsage(p, report)
+	report.Namespaces = 1
+	report.Permissions = checks
+
+	record, trace, err := p.DeleteNow(ctx, namespaces[0], reason, requestedBy)
+	report.BreakGlass = record
+	if trace != nil {
+		report.AddTrace(trace)
+	}
+	report.PlannedChanges = p.PlannedChanges()
+	return report, err
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+)
+
+// readEnvFile reads a proposed configuration as KEY=VALUE lines. Blank lines
+// and lines starting with '#' are ignored, and values may be quoted.
+func readEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	env := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, i+1)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// overlayEnv returns a getenv function preferring the settings of env and
+// falling back to fallback, so a proposed configuration only needs to list
+// the settings it changes.
+func overlayEnv(env map[string]string, fallback func(string) string) func(string) string {
+	return func(key string) string {
+		if value, ok := env[key]; ok {
+			return value
+		}
+		return fallback(key)
+	}
+}
+
+// comparePolicies evaluates the target namespaces under the current and the
+// proposed processor without modifying them, and writes every namespace
+// decided differently to w.
+// Parameters:
+// - current, proposed: Processors configured with each policy
+// - currentSelector, proposedSelector: Label selectors of each policy
+// - names: Explicit namespaces to compare, all selected namespaces if empty
+// - w: Destination for the human-readable differences
+func comparePolicies(current, proposed *auditor.NamespaceProcessor, currentSelector, proposedSelector string, names []string, w io.Writer) (*auditor.RunReport, error) {
+	report := auditor.NewRunReport(current.RunInfo())
+	defer report.Finish()
+
+	currentList, err := targetNamespaces(current, currentSelector, names)
+	if err != nil {
+		return nil, fmt.Errorf("listing namespaces of the current policy: %w", err)
+	}
+	proposedList, err := targetNamespaces(proposed, proposedSelector, names)
+	if err != nil {
+		return nil, fmt.Errorf("listing namespaces of the proposed policy: %w", err)
+	}
+	currentScope, proposedScope := currentList.Items, proposedList.Items
+	evaluated := map[string]bool{}
+	for _, ns := range append(currentScope, proposedScope...) {
+		evaluated[ns.Name] = true
+	}
+	report.Namespaces = len(evaluated)
+
+	report.PolicyDiff = auditor.ComparePolicies(context.TODO(), current, proposed, currentScope, proposedScope)
+	for _, d := range report.PolicyDiff {
+		fmt.Fprintf(w, "%s: %s -> %s\n  current:  %s\n  proposed: %s\n", d.Namespace, d.Current, d.Proposed, d.CurrentReason, d.ProposedReason)
+	}
+	fmt.Fprintf(w, "%d of %d namespaces decided differently\n", len(report.PolicyDiff), report.Namespaces)
+	return report, nil
+}
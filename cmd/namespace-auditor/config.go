@@ -0,0 +1,1209 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	"github.com/bryanpaget/namespace-auditor/internal/backup"
+	conf "github.com/bryanpaget/namespace-auditor/internal/config"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"github.com/bryanpaget/namespace-auditor/internal/notify"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultClockSkew is the clock-skew tolerance applied when CLOCK_SKEW_TOLERANCE is unset
+const defaultClockSkew = 5 * time.Minute
+
+// defaultInstance names this deployment when AUDITOR_INSTANCE is unset
+const defaultInstance = "cronjob"
+
+// instanceNamePattern restricts instance names to characters valid in ConfigMap keys
+var instanceNamePattern = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+// config contains application configuration parameters loaded from the
+// settings, see loadSettings
+type config struct {
+	settings          *conf.Loader                           // Sources the configuration was loaded from
+	gracePeriod       time.Duration                          // Duration before deleting unclaimed namespaces
+	graceBusinessDays int                                    // Grace period in business days, used instead of gracePeriod when set
+	workWeek          auditor.WorkWeek                       // Days counted as business days
+	graceByDomain     map[string]auditor.GracePeriodStrategy // Grace periods by owner domain, overriding the default
+	gracePolicyFile   string                                 // File of grace-period tiers by label selector
+	graceTiers        []auditor.GraceRule                    // Tiers loaded from gracePolicyFile, overriding the above
+	allowedDomains    []string                               // Permitted email domains for namespace owners
+	ownerAllowlist    []string                               // Owners always treated as valid, e.g. service accounts
+	namespaceInclude  []auditor.NamePattern                  // Names of the namespaces audited, all if empty
+	namespaceExclude  []auditor.NamePattern                  // Names of namespaces never touched
+	ownerListFile     string                                 // File of owners always treated as valid or invalid
+	ownerList         *auditor.OwnerList                     // Owners loaded from ownerListFile, nil without one
+	ownerSource       auditor.OwnerSource                    // Where namespace owners are read from
+	linkSuffixes      []string                               // Name suffixes of companion namespaces, e.g. "-serving"
+	slowStart         []int                                  // Percentages of namespaces processed by the first runs after a change
+	contextKeys       []string                               // Labels or annotations included as context in notifications and reports
+	azureTenantID     string                                 // Azure AD tenant ID for authentication
+	azureClientID     string                                 // Azure application client ID
+	azureClientSecret string                                 // Azure client secret for authentication
+	identityProvider  string                                 // Name of the identity provider validating owners
+	identity          identity.Provider                      // Identity provider validating owners
+	canaryUser        string                                 // Existing user looked up by check-idp, empty for a probe user
+	statusToken       string                                 // Bearer token required by the serve command, empty for none
+	configDir         string                                 // Mounted ConfigMap overriding environment settings, empty for none
+	reloadInterval    time.Duration                          // How often the serve command checks configDir and audit policies for changes
+	labelSelector     string                                 // Selector identifying namespaces to audit
+	clockSkew         time.Duration                          // Tolerance for clock differences on marker expiry
+	pauseWindows      []auditor.PauseWindow                  // Periods during which grace periods are frozen
+	expiryAction      auditor.ExpiryAction                   // Action taken once the grace period expires
+	orphanOwner       string                                 // Fallback owner of orphaned namespaces, empty to keep theirs
+	orphanPeriod      time.Duration                          // How long namespaces stay orphaned before deletion
+	quarantinePeriod  time.Duration                          // How long expired namespaces are quarantined before deletion, none if zero
+
+	invalidDomainPolicy  auditor.OwnerPolicy // Handling of owners with disallowed domains
+	invalidDomainGrace   time.Duration       // Grace period override for disallowed domains
+	ownerlessPolicy      auditor.OwnerPolicy // Handling of namespaces without an owner
+	ownerlessGrace       time.Duration       // Grace period override for ownerless namespaces
+	disabledUserPolicy   auditor.OwnerPolicy // Handling of disabled owners
+	disabledUserGrace    time.Duration       // Grace period override for disabled owners
+	deletedUserGrace     time.Duration       // Grace period override for soft-deleted owners
+	graceOverrideMax     time.Duration       // Longest grace period a namespace annotation may set, 0 to ignore them
+	prefetchConcurrency  int                 // Parallel identity lookups during prefetch
+	identityRateLimit    float64             // Identity lookups per second during prefetch, 0 for unlimited
+	lookupBudget         int                 // Identity lookups allowed per run, 0 for unlimited
+	missingOwnerPercent  float64             // Share of missing owners aborting a run, 0 to disable
+	missingOwnerMinimum  int                 // Resolved owners needed before a run can be aborted for missing owners
+	lookupCacheTTL       time.Duration       // How long resolved identity lookups are reused, 0 to not cache
+	flapDamping          int                 // Consecutive runs a change on a flapping namespace must persist
+	missingConfirmations int                 // Consecutive runs an owner must be missing before marking
+	preDeleteFinalizer   bool                // Hold deleted namespaces until pre-delete steps complete
+	preDeleteTimeout     time.Duration       // Longest a namespace is held by the pre-delete finalizer
+
+	malformedAction auditor.MalformedMarkerAction // Handling of unreadable deletion markers
+	malformedMaxAge time.Duration                 // How long a marker may stay malformed before it expires, 0 for the grace period
+
+	deletionPropagation metav1.DeletionPropagation // Propagation policy for namespace deletion
+	deletionWait        time.Duration              // How long to wait for deleted namespaces to terminate
+	stuckThreshold      time.Duration              // How long a deleted namespace may terminate before it is stuck
+	publishStatus       bool                       // Maintain the cluster-scoped AuditorStatus object
+	auditPolicies       bool                       // Apply NamespaceAuditPolicy objects to the namespaces they select
+	recordEvents        bool                       // Record Kubernetes Events on lifecycle actions
+	stateNamespace      string                     // Namespace of the state ConfigMap, empty to not persist state
+	evaluationCacheTTL  time.Duration              // How long valid-owner evaluations are reused, 0 to always evaluate
+	reportSinks         []auditor.ReportSink       // Destinations of the run report
+	retention           auditor.RetentionPolicy    // How long the auditor's own artifacts are kept
+	instance            string                     // Name of this deployment, for configuration drift detection
+
+	summaryNamespace string // Namespace of the run summary ConfigMap
+	summaryConfigMap string // Name of the run summary ConfigMap, empty to not write a summary
+
+	notifyChannels []notify.Channel      // Webhooks notified by severity, empty to only log notifications
+	notifyTemplate *template.Template    // Text of webhook notifications, nil for notify.DefaultTemplate
+	emailFrom      string                // Sender of owner emails, empty to not email owners
+	mailer         notify.Mailer         // Transport of owner emails
+	emailTemplates notify.EmailTemplates // Subjects and bodies of owner emails by event
+	reminders      bool                  // Remind owners halfway through the grace period and before deletion
+	escalate       bool                  // Record and notify the manager of owners of marked namespaces
+
+	backupStore     backup.Store                  // Destination of namespace backups, nil to delete without a backup
+	backupResources []schema.GroupVersionResource // Resources included in namespace backups
+
+	volumeSnapshots   bool          // Snapshot the volumes of namespaces before deletion
+	snapshotClass     string        // VolumeSnapshotClass of the snapshots, empty for the cluster default
+	snapshotRetention time.Duration // How long snapshots are labeled to be kept, backup.DefaultSnapshotRetention if zero
+	snapshotTimeout   time.Duration // How long to wait for snapshots to be ready, backup.DefaultSnapshotTimeout if zero
+
+	runSlices   int                 // Number of slices namespaces are processed in, all at once if 1 or less
+	sliceBy     auditor.SliceSource // How a run selects its slice
+	slicePeriod time.Duration       // Period covered by all slices with auditor.SliceByTime
+
+	classificationLabel string                             // Label holding a namespace's data classification
+	sensitivePolicies   map[string]auditor.SensitivePolicy // Security contacts and approval by classification
+	twoPersonThreshold  resource.Quantity                  // Requested storage above which deletion needs two approvals
+	canary              *auditor.Canary                    // Synthetic canary namespace, nil if disabled
+}
+
+// loadConfig initializes configuration from flags, environment variables
+// and configuration files, see loadSettings, and validates it. All problems
+// are collected so that a misconfigured deployment reports everything that
+// needs fixing in a single run.
+// Returns:
+// - *config: Populated configuration object
+// - error: Every missing or invalid setting, joined
+func loadConfig() (*config, error) {
+	loader, err := loadSettings()
+	if err != nil {
+		return nil, err
+	}
+	return loadConfigWith(loader)
+}
+
+// loadConfigWith initializes and validates configuration from the settings
+// of loader, see loadConfig.
+func loadConfigWith(loader *conf.Loader) (*config, error) {
+	cfg, err := loadConfigFrom(loader.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	cfg.settings = loader
+	return cfg, nil
+}
+
+// loadConfigFrom initializes and validates configuration from the settings
+// returned by getenv, which must apply the defaults of the settings.
+func loadConfigFrom(getenv func(string) string) (*config, error) {
+	errs := settings.Validate(getenv)
+
+	cfg := &config{
+		azureTenantID:     getenv("AZURE_TENANT_ID"),
+		azureClientID:     getenv("AZURE_CLIENT_ID"),
+		azureClientSecret: getenv("AZURE_CLIENT_SECRET"),
+		identityProvider:  getenv("IDENTITY_PROVIDER"),
+		canaryUser:        getenv("IDP_CANARY_USER"),
+		statusToken:       getenv("STATUS_TOKEN"),
+		configDir:         getenv("CONFIG_DIR"),
+		labelSelector:     getenv("NAMESPACE_SELECTOR"),
+		stateNamespace:    getenv("STATE_NAMESPACE"),
+		instance:          getenv("AUDITOR_INSTANCE"),
+
+		classificationLabel: getenv("CLASSIFICATION_LABEL"),
+	}
+
+	if value := getenv("GRACE_PERIOD"); value != "" {
+		gracePeriod, businessDays, err := parseGracePeriod(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("GRACE_PERIOD: %w", err))
+		}
+		cfg.gracePeriod = gracePeriod
+		cfg.graceBusinessDays = businessDays
+	}
+
+	workWeek, err := auditor.ParseWorkWeek(getenv("WORK_WEEK"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("WORK_WEEK: %w", err))
+	}
+	cfg.workWeek = workWeek
+
+	graceByDomain, err := parseGraceByDomain(getenv("GRACE_PERIOD_BY_DOMAIN"), workWeek)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("GRACE_PERIOD_BY_DOMAIN: %w", err))
+	}
+	cfg.graceByDomain = graceByDomain
+
+	if cfg.gracePolicyFile = getenv("GRACE_POLICY_FILE"); cfg.gracePolicyFile != "" {
+		graceTiers, err := loadGraceTiers(cfg.gracePolicyFile, workWeek)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("GRACE_POLICY_FILE: %w", err))
+		}
+		cfg.graceTiers = graceTiers
+	}
+
+	clockSkew, err := parseClockSkew(getenv("CLOCK_SKEW_TOLERANCE"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("CLOCK_SKEW_TOLERANCE: %w", err))
+	}
+	cfg.clockSkew = clockSkew
+
+	pauseWindows, err := auditor.ParsePauseWindows(getenv("PAUSE_WINDOWS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("PAUSE_WINDOWS: %w", err))
+	}
+	cfg.pauseWindows = pauseWindows
+
+	expiryAction, err := auditor.ParseExpiryAction(getenv("EXPIRY_ACTION"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("EXPIRY_ACTION: %w", err))
+	}
+	cfg.expiryAction = expiryAction
+
+	cfg.orphanOwner = strings.TrimSpace(getenv("ORPHAN_OWNER"))
+	orphanPeriod, err := parseOptionalDuration(getenv("ORPHAN_PERIOD"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("ORPHAN_PERIOD: %w", err))
+	}
+	cfg.orphanPeriod = orphanPeriod
+	if expiryAction != auditor.ExpiryReassign {
+		for _, setting := range []string{"ORPHAN_OWNER", "ORPHAN_PERIOD"} {
+			if getenv(setting) != "" {
+				errs = append(errs, fmt.Errorf("%s: only used with EXPIRY_ACTION=%s", setting, auditor.ExpiryReassign))
+			}
+		}
+	}
+	quarantinePeriod, err := parseOptionalDuration(getenv("QUARANTINE_PERIOD"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("QUARANTINE_PERIOD: %w", err))
+	}
+	if quarantinePeriod > 0 && expiryAction == auditor.ExpiryCordon {
+		errs = append(errs, fmt.Errorf("QUARANTINE_PERIOD: not used with EXPIRY_ACTION=%s, which keeps namespaces", auditor.ExpiryCordon))
+	}
+	cfg.quarantinePeriod = quarantinePeriod
+
+	invalidDomainPolicy, err := auditor.ParseOwnerPolicy(getenv("INVALID_DOMAIN_POLICY"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("INVALID_DOMAIN_POLICY: %w", err))
+	}
+	cfg.invalidDomainPolicy = invalidDomainPolicy
+
+	invalidDomainGrace, err := parseOptionalDuration(getenv("INVALID_DOMAIN_GRACE_PERIOD"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("INVALID_DOMAIN_GRACE_PERIOD: %w", err))
+	}
+	cfg.invalidDomainGrace = invalidDomainGrace
+
+	ownerlessPolicy, err := auditor.ParseOwnerPolicy(getenv("OWNERLESS_POLICY"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("OWNERLESS_POLICY: %w", err))
+	}
+	cfg.ownerlessPolicy = ownerlessPolicy
+
+	ownerlessGrace, err := parseOptionalDuration(getenv("OWNERLESS_GRACE_PERIOD"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("OWNERLESS_GRACE_PERIOD: %w", err))
+	}
+	cfg.ownerlessGrace = ownerlessGrace
+
+	disabledUserPolicy, err := auditor.ParseOwnerPolicy(getenv("DISABLED_USER_POLICY"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("DISABLED_USER_POLICY: %w", err))
+	}
+	cfg.disabledUserPolicy = disabledUserPolicy
+
+	disabledUserGrace, err := parseOptionalDuration(getenv("DISABLED_USER_GRACE_PERIOD"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("DISABLED_USER_GRACE_PERIOD: %w", err))
+	}
+	cfg.disabledUserGrace = disabledUserGrace
+
+	deletedUserGrace, err := parseOptionalDuration(getenv("DELETED_USER_GRACE_PERIOD"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("DELETED_USER_GRACE_PERIOD: %w", err))
+	}
+	cfg.deletedUserGrace = deletedUserGrace
+
+	malformedAction, err := auditor.ParseMalformedMarkerAction(getenv("MALFORMED_MARKER_ACTION"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("MALFORMED_MARKER_ACTION: %w", err))
+	}
+	cfg.malformedAction = malformedAction
+
+	malformedMaxAge, err := parseOptionalDuration(getenv("MALFORMED_MARKER_MAX_AGE"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("MALFORMED_MARKER_MAX_AGE: %w", err))
+	}
+	if malformedMaxAge > 0 && malformedAction != auditor.MalformedMarkerExpire {
+		errs = append(errs, fmt.Errorf("MALFORMED_MARKER_MAX_AGE: only applies with MALFORMED_MARKER_ACTION=%s", auditor.MalformedMarkerExpire))
+	}
+	cfg.malformedMaxAge = malformedMaxAge
+
+	graceOverrideMax, err := parseOptionalDuration(getenv("GRACE_PERIOD_OVERRIDE_MAX"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("GRACE_PERIOD_OVERRIDE_MAX: %w", err))
+	}
+	cfg.graceOverrideMax = graceOverrideMax
+
+	prefetchConcurrency, err := parsePrefetchConcurrency(getenv("PREFETCH_CONCURRENCY"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("PREFETCH_CONCURRENCY: %w", err))
+	}
+	cfg.prefetchConcurrency = prefetchConcurrency
+
+	identityRateLimit, err := parseRateLimit(getenv("IDENTITY_RATE_LIMIT"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("IDENTITY_RATE_LIMIT: %w", err))
+	}
+	cfg.identityRateLimit = identityRateLimit
+
+	preDeleteFinalizer, err := parseOptionalBool(getenv("PRE_DELETE_FINALIZER"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("PRE_DELETE_FINALIZER: %w", err))
+	}
+	cfg.preDeleteFinalizer = preDeleteFinalizer
+
+	preDeleteTimeout, err := parseOptionalDuration(getenv("PRE_DELETE_TIMEOUT"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("PRE_DELETE_TIMEOUT: %w", err))
+	}
+	if preDeleteTimeout == 0 {
+		preDeleteTimeout = auditor.DefaultPreDeleteTimeout
+	}
+	cfg.preDeleteTimeout = preDeleteTimeout
+
+	deletionPropagation, err := auditor.ParsePropagationPolicy(getenv("DELETION_PROPAGATION"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("DELETION_PROPAGATION: %w", err))
+	}
+	cfg.deletionPropagation = deletionPropagation
+
+	deletionWait, err := parseOptionalDuration(getenv("DELETION_WAIT_TIMEOUT"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("DELETION_WAIT_TIMEOUT: %w", err))
+	}
+	cfg.deletionWait = deletionWait
+
+	evaluationCacheTTL, err := parseOptionalDuration(getenv("EVALUATION_CACHE_TTL"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("EVALUATION_CACHE_TTL: %w", err))
+	}
+	if evaluationCacheTTL > 0 && cfg.stateNamespace == "" {
+		errs = append(errs, fmt.Errorf("EVALUATION_CACHE_TTL: requires STATE_NAMESPACE to keep evaluations between runs"))
+	}
+	cfg.evaluationCacheTTL = evaluationCacheTTL
+
+	stuckThreshold, err := parseOptionalDuration(getenv("STUCK_TERMINATING_THRESHOLD"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("STUCK_TERMINATING_THRESHOLD: %w", err))
+	}
+	if stuckThreshold == 0 {
+		stuckThreshold = auditor.DefaultStuckThreshold
+	}
+	cfg.stuckThreshold = stuckThreshold
+
+	reportSinks, err := auditor.ParseReportSinks(getenv("REPORT_SINKS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("REPORT_SINKS: %w", err))
+	}
+	cfg.reportSinks = reportSinks
+
+	reportRetention, err := parseOptionalDuration(getenv("REPORT_RETENTION"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("REPORT_RETENTION: %w", err))
+	}
+	cfg.retention.Reports = reportRetention
+
+	instanceRetention, err := parseOptionalDuration(getenv("INSTANCE_RETENTION"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("INSTANCE_RETENTION: %w", err))
+	}
+	if instanceRetention > 0 && cfg.stateNamespace == "" {
+		errs = append(errs, fmt.Errorf("INSTANCE_RETENTION: requires STATE_NAMESPACE, where deployments are recorded"))
+	}
+	cfg.retention.Instances = instanceRetention
+
+	evaluationRetention, err := parseOptionalDuration(getenv("EVALUATION_RETENTION"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("EVALUATION_RETENTION: %w", err))
+	}
+	if evaluationRetention > 0 && cfg.stateNamespace == "" {
+		errs = append(errs, fmt.Errorf("EVALUATION_RETENTION: requires STATE_NAMESPACE, where evaluations are cached"))
+	}
+	cfg.retention.Evaluations = evaluationRetention
+
+	publishStatus, err := parseOptionalBool(getenv("PUBLISH_STATUS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("PUBLISH_STATUS: %w", err))
+	}
+	cfg.publishStatus = publishStatus
+
+	auditPolicies, err := parseOptionalBool(getenv("AUDIT_POLICIES"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("AUDIT_POLICIES: %w", err))
+	}
+	cfg.auditPolicies = auditPolicies
+
+	cfg.reloadInterval = defaultReloadInterval
+	if value := getenv("CONFIG_RELOAD_INTERVAL"); value != "" {
+		interval, err := parseOptionalDuration(value)
+		if err != nil || interval == 0 {
+			errs = append(errs, fmt.Errorf("CONFIG_RELOAD_INTERVAL: expected a positive duration, got %q", value))
+		}
+		if cfg.configDir == "" && !cfg.auditPolicies {
+			errs = append(errs, fmt.Errorf("CONFIG_RELOAD_INTERVAL: only used with CONFIG_DIR or AUDIT_POLICIES"))
+		}
+		cfg.reloadInterval = interval
+	}
+
+	recordEvents, err := parseOptionalBool(getenv("RECORD_EVENTS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("RECORD_EVENTS: %w", err))
+	}
+	cfg.recordEvents = recordEvents
+
+	summaryNamespace, summaryConfigMap, err := parseConfigMapRef(getenv("SUMMARY_CONFIGMAP"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("SUMMARY_CONFIGMAP: %w", err))
+	}
+	cfg.summaryNamespace, cfg.summaryConfigMap = summaryNamespace, summaryConfigMap
+
+	notifyChannels, err := notify.ParseChannels(getenv("NOTIFY_WEBHOOKS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("NOTIFY_WEBHOOKS: %w", err))
+	}
+	cfg.notifyChannels = notifyChannels
+	if value := getenv("NOTIFY_TEMPLATE"); value != "" {
+		notifyTemplate, err := notify.ParseTemplate(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("NOTIFY_TEMPLATE: %w", err))
+		}
+		cfg.notifyTemplate = notifyTemplate
+	}
+
+	lookupBudget, err := parseLookupBudget(getenv("IDENTITY_LOOKUP_BUDGET"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("IDENTITY_LOOKUP_BUDGET: %w", err))
+	}
+	cfg.lookupBudget = lookupBudget
+
+	missingOwnerPercent, err := parsePercent(getenv("MISSING_OWNER_ABORT_PERCENT"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("MISSING_OWNER_ABORT_PERCENT: %w", err))
+	}
+	cfg.missingOwnerPercent = missingOwnerPercent
+	if value := getenv("MISSING_OWNER_ABORT_MIN_OWNERS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			errs = append(errs, fmt.Errorf("MISSING_OWNER_ABORT_MIN_OWNERS: expected a positive number of owners, got %q", value))
+		}
+		if missingOwnerPercent == 0 {
+			errs = append(errs, fmt.Errorf("MISSING_OWNER_ABORT_MIN_OWNERS: only used with MISSING_OWNER_ABORT_PERCENT"))
+		}
+		cfg.missingOwnerMinimum = n
+	}
+
+	cfg.lookupCacheTTL = auditor.DefaultLookupCacheTTL
+	if value := getenv("IDENTITY_CACHE_TTL"); value != "" {
+		lookupCacheTTL, err := parseOptionalDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("IDENTITY_CACHE_TTL: %w", err))
+		}
+		cfg.lookupCacheTTL = lookupCacheTTL
+	}
+
+	flapDamping, err := parseRunCount(getenv("FLAP_DAMPING_RUNS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("FLAP_DAMPING_RUNS: %w", err))
+	}
+	cfg.flapDamping = flapDamping
+
+	missingConfirmations, err := parseRunCount(getenv("MISSING_CONFIRMATION_RUNS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("MISSING_CONFIRMATION_RUNS: %w", err))
+	}
+	cfg.missingConfirmations = missingConfirmations
+
+	sensitivePolicies, err := auditor.ParseSensitivePolicies(getenv("SECURITY_CONTACTS"), getenv("APPROVAL_CLASSIFICATIONS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("SECURITY_CONTACTS: %w", err))
+	}
+	cfg.sensitivePolicies = sensitivePolicies
+	if cfg.classificationLabel == "" {
+		cfg.classificationLabel = auditor.DefaultClassificationLabel
+	}
+
+	if value := getenv("TWO_PERSON_STORAGE_THRESHOLD"); value != "" {
+		threshold, err := resource.ParseQuantity(value)
+		if err != nil || threshold.Sign() < 0 {
+			errs = append(errs, fmt.Errorf(`TWO_PERSON_STORAGE_THRESHOLD: expected a non-negative quantity such as "500Gi", got %q`, value))
+		}
+		cfg.twoPersonThreshold = threshold
+	}
+
+	var allowedDomains []string
+	if value := getenv("ALLOWED_DOMAINS"); strings.TrimSpace(value) != "" {
+		allowedDomains, err = auditor.ParseAllowedDomains(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ALLOWED_DOMAINS: %w", err))
+		}
+	}
+	cfg.allowedDomains = allowedDomains
+
+	canary, err := parseCanary(getenv, allowedDomains)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.canary = canary
+
+	ownerAllowlist, err := auditor.ParseOwnerAllowlist(getenv("OWNER_ALLOWLIST"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("OWNER_ALLOWLIST: %w", err))
+	}
+	cfg.ownerAllowlist = ownerAllowlist
+
+	namespaceInclude, err := auditor.ParseNamePatterns(getenv("NAMESPACE_INCLUDE"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("NAMESPACE_INCLUDE: %w", err))
+	}
+	cfg.namespaceInclude = namespaceInclude
+	namespaceExclude, err := auditor.ParseNamePatterns(getenv("NAMESPACE_EXCLUDE"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("NAMESPACE_EXCLUDE: %w", err))
+	}
+	cfg.namespaceExclude = namespaceExclude
+
+	if cfg.ownerListFile = getenv("OWNER_LIST_FILE"); cfg.ownerListFile != "" {
+		ownerList, err := auditor.LoadOwnerList(cfg.ownerListFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("OWNER_LIST_FILE: %w", err))
+		}
+		cfg.ownerList = ownerList
+	}
+
+	ownerSource, err := auditor.ParseOwnerSource(getenv("OWNER_SOURCE"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("OWNER_SOURCE: %w", err))
+	}
+	cfg.ownerSource = ownerSource
+
+	linkSuffixes, err := parseLinkSuffixes(getenv("LINK_SUFFIXES"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("LINK_SUFFIXES: %w", err))
+	}
+	cfg.linkSuffixes = linkSuffixes
+
+	slowStart, err := auditor.ParseSlowStart(getenv("SLOW_START"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("SLOW_START: %w", err))
+	}
+	if len(slowStart) > 0 && cfg.stateNamespace == "" {
+		errs = append(errs, fmt.Errorf("SLOW_START: requires STATE_NAMESPACE to detect changes between runs"))
+	}
+	cfg.slowStart = slowStart
+
+	if v := getenv("RUN_SLICES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			errs = append(errs, fmt.Errorf("RUN_SLICES: expected a positive number of slices, got %q", v))
+		}
+		cfg.runSlices = n
+	}
+	sliceBy, err := auditor.ParseSliceSource(getenv("SLICE_BY"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("SLICE_BY: %w", err))
+	}
+	if sliceBy == auditor.SliceByRuns && cfg.runSlices > 1 && cfg.stateNamespace == "" {
+		errs = append(errs, fmt.Errorf("SLICE_BY: %s requires STATE_NAMESPACE to count runs", sliceBy))
+	}
+	cfg.sliceBy = sliceBy
+	slicePeriod, err := parseOptionalDuration(getenv("SLICE_PERIOD"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("SLICE_PERIOD: %w", err))
+	}
+	if slicePeriod == 0 {
+		slicePeriod = 24 * time.Hour
+	}
+	cfg.slicePeriod = slicePeriod
+
+	contextKeys, err := auditor.ParseContextKeys(getenv("CONTEXT_KEYS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("CONTEXT_KEYS: %w", err))
+	}
+	cfg.contextKeys = contextKeys
+
+	provider, err := identity.New(cfg.identityProvider, getenv)
+	switch {
+	case errors.Is(err, identity.ErrUnknownProvider):
+		errs = append(errs, fmt.Errorf("IDENTITY_PROVIDER: %w", err))
+	case err != nil:
+		errs = append(errs, err) // Already names each offending setting
+	}
+	cfg.identity = provider
+
+	if from := getenv("EMAIL_FROM"); from != "" {
+		addr, err := mail.ParseAddress(from)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("EMAIL_FROM: invalid address %q", from))
+		} else {
+			cfg.emailFrom = addr.Address
+		}
+		mailer, err := parseMailer(getenv, provider)
+		if err != nil {
+			errs = append(errs, err) // Already names the offending setting
+		}
+		cfg.mailer = mailer
+		cfg.emailTemplates = notify.DefaultEmailTemplates()
+		if dir := getenv("EMAIL_TEMPLATE_DIR"); dir != "" {
+			templates, err := notify.LoadEmailTemplates(dir)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("EMAIL_TEMPLATE_DIR: %w", err))
+			}
+			cfg.emailTemplates = templates
+		}
+	}
+	cfg.reminders = cfg.emailFrom != ""
+	if value := getenv("SEND_REMINDERS"); value != "" {
+		reminders, err := parseOptionalBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("SEND_REMINDERS: %w", err))
+		}
+		cfg.reminders = reminders
+	}
+	if spec := getenv("BACKUP_STORE"); spec != "" {
+		store, err := backup.ParseStore(spec, getenv)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("BACKUP_STORE: %w", err))
+		}
+		cfg.backupStore = store
+	}
+	backupResources, err := backup.ParseResources(getenv("BACKUP_RESOURCES"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("BACKUP_RESOURCES: %w", err))
+	}
+	cfg.backupResources = backupResources
+
+	volumeSnapshots, err := parseOptionalBool(getenv("VOLUME_SNAPSHOTS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("VOLUME_SNAPSHOTS: %w", err))
+	}
+	cfg.volumeSnapshots = volumeSnapshots
+	cfg.snapshotClass = strings.TrimSpace(getenv("VOLUME_SNAPSHOT_CLASS"))
+	snapshotRetention, err := parseOptionalDuration(getenv("VOLUME_SNAPSHOT_RETENTION"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("VOLUME_SNAPSHOT_RETENTION: %w", err))
+	}
+	cfg.snapshotRetention = snapshotRetention
+	snapshotTimeout, err := parseOptionalDuration(getenv("VOLUME_SNAPSHOT_TIMEOUT"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("VOLUME_SNAPSHOT_TIMEOUT: %w", err))
+	}
+	cfg.snapshotTimeout = snapshotTimeout
+	if !volumeSnapshots {
+		for _, setting := range []string{"VOLUME_SNAPSHOT_CLASS", "VOLUME_SNAPSHOT_RETENTION", "VOLUME_SNAPSHOT_TIMEOUT"} {
+			if getenv(setting) != "" {
+				errs = append(errs, fmt.Errorf("%s: only used with VOLUME_SNAPSHOTS=true", setting))
+			}
+		}
+	}
+
+	if value := getenv("ESCALATE_TO_MANAGER"); value != "" {
+		escalate, err := parseOptionalBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ESCALATE_TO_MANAGER: %w", err))
+		}
+		if _, ok := provider.(auditor.ManagerReporter); escalate && !ok && provider != nil {
+			errs = append(errs, fmt.Errorf("ESCALATE_TO_MANAGER: the %s identity provider cannot look up managers", cfg.identityProvider))
+		}
+		cfg.escalate = escalate
+	}
+
+	if !instanceNamePattern.MatchString(cfg.instance) {
+		errs = append(errs, fmt.Errorf("AUDITOR_INSTANCE: invalid name %q, expected letters, digits, '-', '_' or '.'", cfg.instance))
+	}
+
+	if _, err := labels.Parse(cfg.labelSelector); err != nil {
+		errs = append(errs, fmt.Errorf("NAMESPACE_SELECTOR: invalid label selector %q: %w", cfg.labelSelector, err))
+	}
+
+	if len(errs) > 0 {
+		sortErrors(errs)
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+// hash returns a short, stable fingerprint of the effective configuration:
+// of its snapshot as canonical JSON, without the build and run mode, so
+// that every setting reported also changes the hash. Secrets are excluded
+// so the hash can be published in annotations and reports.
+func (c *config) hash() string {
+	snapshot := c.snapshot(false, false)
+	snapshot.Version, snapshot.Commit = "", ""
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		panic(err) // The snapshot holds only strings, numbers, lists and maps
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// logEffectiveConfig logs the settings in effect and where each came from,
+// so operators can tell which value won where several sources set one.
+// Secrets are redacted.
+func logEffectiveConfig(cfg *config) {
+	log.Printf("Effective configuration (hash %s):", cfg.hash())
+	for _, line := range cfg.settings.Effective() {
+		log.Printf("  %s", line)
+	}
+}
+
+// snapshot returns the effective configuration for inclusion in run reports.
+// Secrets are excluded.
+func (c *config) snapshot(dryRun, readOnly bool) *auditor.ConfigSnapshot {
+	gracePeriod := c.gracePeriod.String()
+	if c.graceBusinessDays > 0 {
+		gracePeriod = fmt.Sprintf("%dbd (%s)", c.graceBusinessDays, c.workWeek)
+	}
+
+	var graceByDomain map[string]string
+	for domain, strategy := range c.graceByDomain {
+		if graceByDomain == nil {
+			graceByDomain = make(map[string]string, len(c.graceByDomain))
+		}
+		graceByDomain[domain] = strategy.Describe(corev1.Namespace{})
+	}
+	var graceTiers []string
+	for _, tier := range c.graceTiers {
+		graceTiers = append(graceTiers, tier.Selector.String()+": "+tier.Strategy.Describe(corev1.Namespace{}))
+	}
+
+	var runSlices int
+	var sliceBy string
+	if c.runSlices > 1 {
+		runSlices, sliceBy = c.runSlices, string(c.sliceBy)
+		if c.sliceBy == auditor.SliceByTime {
+			sliceBy += " over " + c.slicePeriod.String()
+		}
+	}
+
+	var pauseWindows []string
+	for _, w := range c.pauseWindows {
+		pauseWindows = append(pauseWindows, w.String())
+	}
+	var include, exclude []string
+	for _, pattern := range c.namespaceInclude {
+		include = append(include, pattern.String())
+	}
+	for _, pattern := range c.namespaceExclude {
+		exclude = append(exclude, pattern.String())
+	}
+
+	return &auditor.ConfigSnapshot{
+		Version:              version,
+		Commit:               commit,
+		GracePeriod:          gracePeriod,
+		GracePeriodByDomain:  graceByDomain,
+		GraceTiers:           graceTiers,
+		GraceOverrideMax:     optionalDuration(c.graceOverrideMax),
+		WorkWeek:             c.workWeek.String(),
+		ClockSkew:            c.clockSkew.String(),
+		PauseWindows:         pauseWindows,
+		ExpiryAction:         string(c.expiryAction),
+		OrphanOwner:          c.orphanOwner,
+		OrphanPeriod:         optionalDuration(c.orphanPeriod),
+		QuarantinePeriod:     optionalDuration(c.quarantinePeriod),
+		InvalidDomainPolicy:  string(c.invalidDomainPolicy),
+		InvalidDomainGrace:   optionalDuration(c.invalidDomainGrace),
+		OwnerlessPolicy:      string(c.ownerlessPolicy),
+		OwnerlessGrace:       optionalDuration(c.ownerlessGrace),
+		DisabledUserPolicy:   string(c.disabledUserPolicy),
+		DisabledUserGrace:    optionalDuration(c.disabledUserGrace),
+		DeletedUserGrace:     optionalDuration(c.deletedUserGrace),
+		PreDeleteFinalizer:   c.preDeleteFinalizer,
+		PreDeleteTimeout:     optionalDuration(c.preDeleteTimeout),
+		MalformedAction:      string(c.malformedAction),
+		MalformedMaxAge:      optionalDuration(c.malformedMaxAge),
+		FlapDamping:          c.flapDamping,
+		MissingConfirmations: c.missingConfirmations,
+		MissingOwnerAbort:    c.missingOwnerPercent,
+		AllowedDomains:       c.allowedDomains,
+		OwnerAllowlist:       c.ownerAllowlist,
+		OwnerListFile:        c.ownerListFile,
+		OwnerSource:          string(c.ownerSource),
+		LinkSuffixes:         c.linkSuffixes,
+		SlowStart:            c.slowStart,
+		RunSlices:            runSlices,
+		SliceBy:              sliceBy,
+		ContextKeys:          c.contextKeys,
+		LabelSelector:        c.labelSelector,
+		NamespaceInclude:     include,
+		NamespaceExclude:     exclude,
+		AuditPolicies:        c.auditPolicies,
+		Provider:             c.identityProvider,
+		AzureTenantID:        c.azureTenantID,
+		AzureClientID:        c.azureClientID,
+		DryRun:               dryRun,
+		ReadOnly:             readOnly,
+	}
+}
+
+// optionalDuration formats an optional duration for the snapshot, empty if
+// unset
+func optionalDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// linkSuffixPattern matches name suffixes of companion namespaces
+var linkSuffixPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// parseLinkSuffixes parses a comma-separated list of namespace name
+// suffixes, e.g. "-serving,-pipelines", that link a namespace to the one
+// named without the suffix.
+func parseLinkSuffixes(value string) ([]string, error) {
+	var suffixes, invalid []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !linkSuffixPattern.MatchString(entry) {
+			invalid = append(invalid, entry)
+			continue
+		}
+		suffixes = append(suffixes, entry)
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid suffixes %q, expected lower-case letters, digits and '-'", invalid)
+	}
+	return suffixes, nil
+}
+
+// parseGraceByDomain parses a comma-separated list of "domain=period"
+// grace periods, e.g. "partner.org=168h,contractor.ca=5bd". Periods take the
+// same forms as GRACE_PERIOD; business days count days of week.
+func parseGraceByDomain(value string, week auditor.WorkWeek) (map[string]auditor.GracePeriodStrategy, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	byDomain := make(map[string]auditor.GracePeriodStrategy)
+	for _, entry := range strings.Split(value, ",") {
+		domain, period, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			return nil, fmt.Errorf(`invalid entry %q, expected "domain=period"`, entry)
+		}
+		domains, err := auditor.ParseAllowedDomains(domain)
+		if err != nil || len(domains) != 1 {
+			return nil, fmt.Errorf("invalid domain %q", domain)
+		}
+		gracePeriod, businessDays, err := parseGracePeriod(strings.TrimSpace(period))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", domains[0], err)
+		}
+		if _, dup := byDomain[domains[0]]; dup {
+			return nil, fmt.Errorf("duplicate domain %q", domains[0])
+		}
+		byDomain[domains[0]] = graceStrategy(gracePeriod, businessDays, week)
+	}
+	return byDomain, nil
+}
+
+// gracePolicy is the YAML form of a grace policy file
+type gracePolicy struct {
+	Tiers []struct {
+		Selector    string `json:"selector"`    // Label selector of the namespaces in the tier
+		GracePeriod string `json:"gracePeriod"` // As for GRACE_PERIOD
+	} `json:"tiers"`
+}
+
+// loadGraceTiers reads grace-period tiers from a YAML policy file, in the
+// order they are matched, e.g.
+//
+//	tiers:
+//	- selector: env=prod
+//	  gracePeriod: 2160h
+//	- selector: env in (sandbox, scratch)
+//	  gracePeriod: 5bd
+func loadGraceTiers(path string, week auditor.WorkWeek) ([]auditor.GraceRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var policy gracePolicy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(policy.Tiers) == 0 {
+		return nil, fmt.Errorf("%s: no tiers", path)
+	}
+	rules := make([]auditor.GraceRule, 0, len(policy.Tiers))
+	for i, tier := range policy.Tiers {
+		if strings.TrimSpace(tier.Selector) == "" {
+			return nil, fmt.Errorf("%s: tier %d: selector required, use GRACE_PERIOD for all other namespaces", path, i+1)
+		}
+		selector, err := labels.Parse(tier.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("%s: tier %d: invalid selector %q: %w", path, i+1, tier.Selector, err)
+		}
+		gracePeriod, businessDays, err := parseGracePeriod(strings.TrimSpace(tier.GracePeriod))
+		if err != nil {
+			return nil, fmt.Errorf("%s: tier %d (%s): %w", path, i+1, tier.Selector, err)
+		}
+		rules = append(rules, auditor.GraceRule{Selector: selector, Strategy: graceStrategy(gracePeriod, businessDays, week)})
+	}
+	return rules, nil
+}
+
+// graceStrategy returns a fixed grace period, or one in business days of
+// week when businessDays is set
+func graceStrategy(gracePeriod time.Duration, businessDays int, week auditor.WorkWeek) auditor.GracePeriodStrategy {
+	if businessDays > 0 {
+		return auditor.BusinessDayGracePeriod{Days: businessDays, Week: week}
+	}
+	return auditor.FixedGracePeriod(gracePeriod)
+}
+
+// graceStrategy returns the grace-period strategy of the configuration: the
+// default grace period, overridden by owner domain if configured, and by the
+// first matching tier of the grace policy file before that.
+func (c *config) graceStrategy() auditor.GracePeriodStrategy {
+	strategy := graceStrategy(c.gracePeriod, c.graceBusinessDays, c.workWeek)
+	if len(c.graceByDomain) > 0 {
+		strategy = auditor.DomainGracePeriod{Domains: c.graceByDomain, Default: strategy}
+	}
+	if len(c.graceTiers) > 0 {
+		strategy = auditor.PolicyGracePeriod{Rules: c.graceTiers, Default: strategy}
+	}
+	return strategy
+}
+
+// parseGracePeriod parses and validates the grace period, given either as a
+// duration (e.g. "720h") or as a number of business days (e.g. "10bd").
+// Returns an error if the value is missing, malformed, or not positive.
+func parseGracePeriod(value string) (time.Duration, int, error) {
+	if value == "" {
+		return 0, 0, errors.New(`required, e.g. "720h" for 30 days or "10bd" for 10 business days`)
+	}
+
+	days, isBusinessDays, err := auditor.ParseBusinessDays(value)
+	if isBusinessDays {
+		if err != nil {
+			return 0, 0, err
+		}
+		if days <= 0 {
+			return 0, 0, fmt.Errorf("must be positive, got %q", value)
+		}
+		return 0, days, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, 0, fmt.Errorf(`invalid duration %q, expected e.g. "720h" or "10bd": %w`, value, err)
+	}
+	if d <= 0 {
+		return 0, 0, fmt.Errorf("must be positive, got %s", d)
+	}
+	return d, 0, nil
+}
+
+// parseClockSkew parses the clock-skew tolerance, defaulting to
+// defaultClockSkew when unset. Negative values are rejected.
+func parseClockSkew(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultClockSkew, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf(`invalid duration %q, expected e.g. "5m": %w`, value, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative, got %s", d)
+	}
+	return d, nil
+}
+
+// parseOptionalDuration parses a duration that may be left unset (zero).
+// Negative values are rejected.
+func parseOptionalDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf(`invalid duration %q, expected e.g. "2160h": %w`, value, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative, got %s", d)
+	}
+	return d, nil
+}
+
+// parseMailer creates the transport of owner emails selected by
+// EMAIL_TRANSPORT: an SMTP relay, the default, or Microsoft Graph through the
+// azure identity provider.
+func parseMailer(getenv func(string) string, provider identity.Provider) (notify.Mailer, error) {
+	switch transport := getenv("EMAIL_TRANSPORT"); transport {
+	case "", "smtp":
+		host := getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST: required to send email over SMTP")
+		}
+		port := notify.DefaultSMTPPort
+		if value := getenv("SMTP_PORT"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 65535 {
+				return nil, fmt.Errorf("SMTP_PORT: expected a port number, got %q", value)
+			}
+			port = n
+		}
+		return notify.NewSMTPMailer(host, port, getenv("SMTP_USERNAME"), getenv("SMTP_PASSWORD")), nil
+	case "graph":
+		mailer, ok := provider.(notify.Mailer)
+		if !ok {
+			return nil, fmt.Errorf("EMAIL_TRANSPORT: graph requires the azure identity provider")
+		}
+		return mailer, nil
+	default:
+		return nil, fmt.Errorf("EMAIL_TRANSPORT: unknown transport %q, expected smtp or graph", transport)
+	}
+}
+
+// notifier returns where notifications are delivered: the log, plus the
+// configured webhooks and owner emails. Returns nil if they are only logged.
+func (c *config) notifier() auditor.Notifier {
+	notifiers := notify.Fanout{auditor.LogNotifier{}}
+	if len(c.notifyChannels) > 0 {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(c.notifyChannels, c.notifyTemplate))
+	}
+	if c.emailFrom != "" && c.mailer != nil {
+		notifiers = append(notifiers, notify.NewEmailNotifier(c.mailer, c.emailFrom, c.emailTemplates))
+	}
+	if len(notifiers) == 1 {
+		return nil
+	}
+	return notifiers
+}
+
+// parseOptionalBool parses a boolean setting, defaulting to false when unset.
+func parseOptionalBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf(`invalid boolean %q, expected "true" or "false"`, value)
+	}
+	return b, nil
+}
+
+// parsePrefetchConcurrency parses the number of parallel identity lookups,
+// defaulting to auditor.DefaultPrefetchConcurrency when unset.
+func parsePrefetchConcurrency(value string) (int, error) {
+	if value == "" {
+		return auditor.DefaultPrefetchConcurrency, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q: %w", value, err)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("must be positive, got %d", n)
+	}
+	return n, nil
+}
+
+// parseLookupBudget parses the per-run identity lookup cap. Unset or zero
+// means unlimited; negative values are rejected.
+func parseLookupBudget(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q: %w", value, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("must not be negative, got %d", n)
+	}
+	return n, nil
+}
+
+// parseCanary parses the synthetic canary settings. CANARY_NAMESPACE enables
+// the canary; its owner defaults to a made-up address in the first allowed
+// domain, which must not exist in the identity provider.
+func parseCanary(getenv func(string) string, allowedDomains []string) (*auditor.Canary, error) {
+	name := getenv("CANARY_NAMESPACE")
+	if name == "" {
+		return nil, nil
+	}
+	if msgs := validation.IsDNS1123Label(name); len(msgs) > 0 {
+		return nil, fmt.Errorf("CANARY_NAMESPACE: invalid namespace name %q: %s", name, strings.Join(msgs, "; "))
+	}
+
+	canary := &auditor.Canary{Namespace: name, Owner: getenv("CANARY_OWNER"), GracePeriod: auditor.DefaultCanaryGracePeriod}
+	if canary.Owner == "" && len(allowedDomains) > 0 {
+		canary.Owner = "namespace-auditor-canary@" + allowedDomains[0]
+	}
+	if !auditor.IsAllowedOwner(canary.Owner, allowedDomains) {
+		return nil, fmt.Errorf("CANARY_OWNER: %q must be in one of the allowed domains %v", canary.Owner, allowedDomains)
+	}
+	if value := getenv("CANARY_GRACE_PERIOD"); value != "" {
+		grace, err := parseOptionalDuration(value)
+		if err != nil || grace == 0 {
+			return nil, fmt.Errorf("CANARY_GRACE_PERIOD: expected a positive duration, got %q", value)
+		}
+		canary.GracePeriod = grace
+	}
+	return canary, nil
+}
+
+// parseConfigMapRef parses a ConfigMap reference of the form
+// "[<namespace>/]<name>", the namespace defaulting to "default". Unset
+// yields an empty name.
+func parseConfigMapRef(value string) (namespace, name string, err error) {
+	if value == "" {
+		return "", "", nil
+	}
+	namespace, name, found := strings.Cut(value, "/")
+	if !found {
+		namespace, name = metav1.NamespaceDefault, value
+	}
+	if msgs := validation.IsDNS1123Label(namespace); len(msgs) > 0 {
+		return "", "", fmt.Errorf("invalid namespace name %q: %s", namespace, strings.Join(msgs, "; "))
+	}
+	if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+		return "", "", fmt.Errorf("invalid ConfigMap name %q: %s", name, strings.Join(msgs, "; "))
+	}
+	return namespace, name, nil
+}
+
+// parseRunCount parses a number of consecutive runs, such as the runs a
+// state change on a flapping namespace must persist. Unset or zero disables
+// the requirement.
+func parseRunCount(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of runs %q: %w", value, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("must not be negative, got %d", n)
+	}
+	return n, nil
+}
+
+// parseRateLimit parses a rate in requests per second. Unset or zero means
+// unlimited; negative values are rejected.
+func parseRateLimit(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	r, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q, expected requests per second: %w", value, err)
+	}
+	if r < 0 {
+		return 0, fmt.Errorf("must not be negative, got %g", r)
+	}
+	return r, nil
+}
+
+// parsePercent parses a percentage between 0 and 100, with or without a
+// trailing "%". Unset means zero.
+func parsePercent(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	p, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage %q: %w", value, err)
+	}
+	if p < 0 || p > 100 {
+		return 0, fmt.Errorf("must be between 0 and 100, got %g", p)
+	}
+	return p, nil
+}
+
+// sortErrors orders errors by message so aggregated output is stable
+func sortErrors(errs []error) {
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+}
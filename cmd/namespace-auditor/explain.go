@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+)
+
+// explainNamespace re-evaluates a single namespace without modifying it and
+// writes the resulting decision trace to w.
+// Parameters:
+// - ctx: Context for cancellation and timeouts
+// - p: Initialized NamespaceProcessor with configuration
+// - name: Name of the namespace to explain
+// - w: Destination for the human-readable trace
+func explainNamespace(ctx context.Context, p *auditor.NamespaceProcessor, name string, w io.Writer) error {
+	ns, err := p.GetNamespace(ctx, name)
+	if err != nil {
+		return fmt.Errorf("getting namespace %s: %w", name, err)
+	}
+	_, err = fmt.Fprint(w, p.Explain(ctx, *ns).String())
+	return err
+}
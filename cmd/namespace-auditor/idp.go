@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+)
+
+// idpProbeTimeout bounds the lookup made by the check-idp command
+const idpProbeTimeout = 30 * time.Second
+
+// idpProbeUser is looked up by check-idp when no canary user is configured.
+// It cannot exist, so a "not found" answer proves credentials and permissions.
+const idpProbeUser = "namespace-auditor-probe@example.invalid"
+
+// checkIdentityProvider performs a single benign lookup against provider and
+// writes its outcome and latency to w. With canary set the lookup must find
+// that user; otherwise idpProbeUser is looked up, whose absence still proves
+// that credentials and permissions are in order. Errors are classified like
+// those aborting a run, see abortExitCode.
+func checkIdentityProvider(ctx context.Context, name string, provider identity.Provider, canary string, w io.Writer) error {
+	user := canary
+	if user == "" {
+		user = idpProbeUser
+	}
+
+	start := time.Now()
+	var exists bool
+	var status int
+	var err error
+	if sr, ok := provider.(auditor.StatusReporter); ok {
+		exists, status, err = sr.UserExistsWithStatus(ctx, user)
+	} else {
+		exists, err = provider.UserExists(ctx, user)
+	}
+	latency := time.Since(start)
+
+	fmt.Fprintf(w, "provider: %s\nuser: %s\nlatency: %s\n", name, user, latency.Round(time.Millisecond))
+	if status != 0 {
+		fmt.Fprintf(w, "status: %d\n", status)
+	}
+	switch {
+	case errors.Is(err, errs.ErrPermission):
+		fmt.Fprintln(w, "result: permission denied")
+	case errors.Is(err, errs.ErrAuth):
+		fmt.Fprintln(w, "result: authentication failed")
+	case err != nil:
+		fmt.Fprintln(w, "result: lookup failed")
+	case canary != "" && !exists:
+		fmt.Fprintln(w, "result: canary user not found")
+		return fmt.Errorf("canary user %s not found", canary)
+	default:
+		fmt.Fprintln(w, "result: ok")
+	}
+	return err
+}
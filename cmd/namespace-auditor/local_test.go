@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"strings"
 	"testing"
 
 	"github.com/bryanpaget/namespace-auditor/internal/auditor"
@@ -28,6 +27,10 @@ func TestLocalScenario(t *testing.T) {
 	cfg, err := loadTestConfig("../../testdata/config.yaml")
 	require.NoError(t, err, "Should load test config from testdata/config.yaml")
 
+	// Parse allowed domains exactly as the production entrypoint does
+	allowedDomains, err := auditor.ParseAllowedDomains(cfg.AllowedDomains)
+	require.NoError(t, err, "Should parse allowed domains from test config")
+
 	// Load test namespace definitions from YAML
 	testNamespaces, err := loadTestNamespaces("../../testdata/namespaces.yaml")
 	require.NoError(t, err, "Should load test namespaces from testdata/namespaces.yaml")
@@ -65,9 +68,8 @@ func TestLocalScenario(t *testing.T) {
 	processor := auditor.NewNamespaceProcessor(
 		fakeClient,  // Fake Kubernetes client
 		mockChecker, // Mock Azure user checker
-		mustParseDuration(cfg.GracePeriod),
-		strings.Split(cfg.AllowedDomains, ", "), // Split allowed domains
-		false,                                   // Dry-run disabled for main tests
+		auditor.WithGracePeriod(mustParseDuration(cfg.GracePeriod)),
+		auditor.WithAllowedDomains(allowedDomains),
 	)
 
 	// Retrieve and process all namespaces with kubeflow label
@@ -100,9 +102,9 @@ func TestLocalScenario(t *testing.T) {
 		dryRunProcessor := auditor.NewNamespaceProcessor(
 			fakeClient,
 			&MockUserChecker{ExistsMap: map[string]bool{"dryrun@company.com": false}},
-			mustParseDuration(cfg.GracePeriod),
-			strings.Split(cfg.AllowedDomains, ", "),
-			true, // Enable dry-run mode
+			auditor.WithGracePeriod(mustParseDuration(cfg.GracePeriod)),
+			auditor.WithAllowedDomains(allowedDomains),
+			auditor.WithDryRun(true), // Enable dry-run mode
 		)
 
 		// Process namespace in dry-run mode
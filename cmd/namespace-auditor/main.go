@@ -1,25 +1,75 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"net/mail"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/bryanpaget/namespace-auditor/internal/auditor"
-	"github.com/bryanpaget/namespace-auditor/internal/azure"
+	_ "github.com/bryanpaget/namespace-auditor/internal/azure" // Registers the "azure" identity provider
+	"github.com/bryanpaget/namespace-auditor/internal/backup"
+	_ "github.com/bryanpaget/namespace-auditor/internal/chain" // Registers the "chain" identity provider
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	_ "github.com/bryanpaget/namespace-auditor/internal/google" // Registers the "google" identity provider
+	_ "github.com/bryanpaget/namespace-auditor/internal/ldap"   // Registers the "ldap" identity provider
+	_ "github.com/bryanpaget/namespace-auditor/internal/okta"   // Registers the "okta" identity provider
+	_ "github.com/bryanpaget/namespace-auditor/internal/scim"   // Registers the "scim" identity provider
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
-// kubeflowLabel defines the label selector for identifying Kubeflow profile namespaces
+// kubeflowLabel defines the default label selector for identifying Kubeflow profile namespaces
 const kubeflowLabel = "app.kubernetes.io/part-of=kubeflow-profile"
 
+// Exit codes used when a run is aborted, distinct from other failures
+const (
+	exitPermissionDenied = 3 // Identity provider rejected lookups for lack of permissions
+	exitAuthFailed       = 4 // Could not authenticate to the identity provider
+	exitMissingOwners    = 5 // Too many owners were not found, see MISSING_OWNER_ABORT_PERCENT
+)
+
 var (
 	// dry-run flag prevents actual modifications when enabled
 	dryRun = flag.Bool("dry-run", false, "Enable dry-run mode (no modifications will be made)")
+
+	// read-only flag evaluates and reports with get/list permissions only
+	readOnly = flag.Bool("read-only", false, "Evaluate and report using only read permissions; implies --dry-run")
+
+	// trace-decisions flag records a full decision trace per namespace in the run report
+	traceDecisions = flag.Bool("trace-decisions", false, "Record per-namespace decision traces in the run report")
+
+	// namespace flag restricts a run to an explicit list of namespaces
+	namespaceNames = flag.String("namespace", "", "Restrict the run to the given comma-separated namespace names")
+
+	// as and as-group flags apply changes while impersonating a scoped identity
+	impersonateUser   = flag.String("as", "", "Username to impersonate for changes; reads keep using the pod's service account")
+	impersonateGroups = flag.String("as-group", "", "Comma-separated groups to impersonate for changes, requires --as")
+
+	// force-finalize flag removes blocking finalizers from namespaces stuck terminating
+	forceFinalize = flag.Bool("force-finalize", false, "Remove blocking finalizers from namespaces stuck terminating after deletion")
+
+	// max-deletions-per-run and max-marks-per-run flags cap the changes of a
+	// single run, so that a misconfigured identity provider cannot wipe the cluster
+	maxDeletions = flag.Int("max-deletions-per-run", 0, "Delete at most this many namespaces per run, deferring the rest to the next run; 0 for unlimited")
+	maxMarks     = flag.Int("max-marks-per-run", 0, "Mark at most this many namespaces for deletion per run, deferring the rest to the next run; 0 for unlimited")
+
+	// listen flag sets the address the serve command listens on
+	listenAddr = flag.String("listen", ":8080", "Address the serve command listens on")
+
+	// report-format and report-file flags write a per-namespace report for compliance reviews
+	reportFormat = flag.String("report-format", "", "Write a per-namespace report of the run as json, csv or table")
+	reportFile   = flag.String("report-file", "", "Write the per-namespace report to this file instead of standard output; implies --report-format=json")
 )
 
 // main is the entry point for the namespace auditor application.
@@ -28,56 +78,313 @@ var (
 // - Configuration loading
 // - Kubernetes/Azure client initialization
 // - Namespace processing orchestration
+//
+// Subcommands:
+// - explain <namespace>: print the full decision trace for one namespace
+// - delete-now --reason <reason> <namespace>: delete one namespace immediately
+// - compare <proposed.env>: report decisions a proposed configuration would change
+// - check-idp: verify identity-provider credentials and permissions
+// - serve: serve the deletion schedule of namespaces over HTTP
+// - version: print build and configuration identity
 func main() {
 	flag.Parse()
+	if *readOnly {
+		*dryRun = true
+	}
+	if *reportFile != "" && *reportFormat == "" {
+		*reportFormat = string(auditor.NamespaceReportJSON)
+	}
+	var nsReportFormat auditor.NamespaceReportFormat
+	if *reportFormat != "" {
+		format, err := auditor.ParseNamespaceReportFormat(*reportFormat)
+		if err != nil {
+			log.Fatalf("Invalid --report-format: %v", err)
+		}
+		nsReportFormat = format
+	}
 
-	// Load configuration from environment variables
-	cfg := loadConfig()
+	if flag.Arg(0) == "version" {
+		printVersion(os.Stdout)
+		return
+	}
 
-	// Initialize Kubernetes client (will exit on failure)
-	k8sClient := createK8sClientOrDie()
+	// Load configuration from flags, environment variables and files
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	logEffectiveConfig(cfg)
 
-	// Create Azure Graph API client using service principal credentials
-	azureClient := azure.NewGraphClient(
-		cfg.azureTenantID,
-		cfg.azureClientID,
-		cfg.azureClientSecret,
-	)
+	// Probing the identity provider needs no Kubernetes access
+	if flag.Arg(0) == "check-idp" {
+		ctx, cancel := context.WithTimeout(context.Background(), idpProbeTimeout)
+		err := checkIdentityProvider(ctx, cfg.identityProvider, cfg.identity, cfg.canaryUser, os.Stdout)
+		cancel()
+		if err != nil {
+			log.Printf("Identity provider check failed: %v", err)
+			os.Exit(abortExitCode(err))
+		}
+		return
+	}
+
+	// Identify this run in markers, reports and Kubernetes API requests
+	runID := auditor.NewRunID()
+
+	// Initialize Kubernetes client (will exit on failure)
+	k8sClient := createK8sClientOrDie(runID)
+	mutationClient := k8sClient
+	if *impersonateUser != "" || *impersonateGroups != "" {
+		impersonation, err := parseImpersonation(*impersonateUser, *impersonateGroups)
+		if err != nil {
+			log.Fatalf("Invalid impersonation: %v", err)
+		}
+		log.Printf("Applying changes as %s", describeImpersonation(impersonation))
+		mutationClient = createImpersonatingClientOrDie(runID, impersonation)
+	}
 
 	// Create namespace processor with loaded configuration
-	processor := auditor.NewNamespaceProcessor(
-		k8sClient,
-		azureClient,
-		cfg.gracePeriod,
-		cfg.allowedDomains,
-		*dryRun,
-	)
+	dynamicClient := func() dynamic.Interface {
+		return createDynamicClientOrDie(runID)
+	}
+	buildProcessor := func(cfg *config) (*auditor.NamespaceProcessor, error) {
+		return setupProcessor(cfg, k8sClient, mutationClient, dynamicClient, runID, *dryRun)
+	}
+	processor, err := buildProcessor(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up the auditor: %v", err)
+	}
 
-	// Execute main processing workflow
-	processNamespaces(processor)
+	switch flag.Arg(0) {
+	case "":
+		var store auditor.StateStore
+		var state auditor.State
+		if cfg.stateNamespace != "" {
+			store = auditor.NewConfigMapStateStore(k8sClient, cfg.stateNamespace, auditor.DefaultStateConfigMap)
+			state = restoreState(store, processor)
+			processor.SetEvaluationCache(cfg.evaluationCacheTTL, state.Evaluations)
+		}
+		names := parseNamespaceNames(*namespaceNames)
+		slowStart := 100
+		if len(cfg.slowStart) > 0 && len(names) == 0 {
+			state.SlowStart = state.SlowStart.Advance(version + "/" + cfg.hash())
+			slowStart = auditor.SlowStartPercent(cfg.slowStart, state.SlowStart.Runs)
+			processor.SetSlowStart(slowStart)
+			if slowStart < 100 {
+				log.Printf("Slow start after a deployment or configuration change: run %d processes %d%% of namespaces",
+					state.SlowStart.Runs, slowStart)
+			}
+		}
+
+		var slice string
+		if cfg.runSlices > 1 && len(names) == 0 {
+			index := auditor.SliceAt(time.Now(), cfg.runSlices, cfg.slicePeriod)
+			if cfg.sliceBy == auditor.SliceByRuns {
+				state.SliceRuns++
+				index = auditor.SliceOfRun(state.SliceRuns, cfg.runSlices)
+			}
+			processor.SetSlice(index, cfg.runSlices)
+			slice = fmt.Sprintf("%d/%d", index+1, cfg.runSlices)
+			log.Printf("Sliced run: processing slice %s of namespaces", slice)
+		}
+
+		// Execute main processing workflow and publish the run report
+		report := processNamespaces(processor, cfg.labelSelector, names, *traceDecisions)
+		if slowStart < 100 {
+			report.SlowStart = slowStart
+		}
+		report.Slice = slice
+		report.Config = cfg.snapshot(*dryRun, *readOnly)
+		if *readOnly {
+			report.Permissions = checkWritePermissions(mutationClient, report.PlannedChanges)
+		}
+		if store != nil {
+			// Only namespaces audited in this run are kept, dropping those since deleted
+			state.Evaluations = processor.Evaluations()
+		}
+		report.Retention = applyRetention(cfg.retention, cfg.reportSinks, &state, *dryRun)
+		if store != nil {
+			report.ConfigDrift = recordInstance(&state, cfg.instance, report)
+			state.Saved = state.Saved.AddSaved(len(report.Saved), time.Now())
+			report.SavedTotal = &state.Saved
+		}
+		writeReport(cfg.reportSinks, report)
+		if nsReportFormat != "" {
+			writeNamespaceReport(report, nsReportFormat, *reportFile)
+		}
+		if cfg.summaryConfigMap != "" {
+			writeSummary(k8sClient, cfg.summaryNamespace, cfg.summaryConfigMap, report, *dryRun)
+		}
+		if store != nil {
+			saveState(store, processor, state, *dryRun)
+		}
+		if cfg.publishStatus {
+			publishStatus(createDynamicClientOrDie(runID), report, cfg.pauseWindows, *dryRun)
+		}
+		if err := processor.Aborted(); err != nil {
+			log.Printf("Run aborted: %v", err)
+			os.Exit(abortExitCode(err))
+		}
+	case "explain":
+		if flag.NArg() != 2 {
+			log.Fatalf("Usage: namespace-auditor explain <namespace>")
+		}
+		if err := explainNamespace(context.TODO(), processor, flag.Arg(1), os.Stdout); err != nil {
+			log.Fatalf("Failed to explain namespace: %v", err)
+		}
+	case "delete-now":
+		name, reason, err := parseDeleteNowArgs(flag.Args()[1:])
+		if err != nil {
+			log.Fatalf("Usage: namespace-auditor delete-now --reason <reason> <namespace>: %v", err)
+		}
+		report, err := deleteNow(context.TODO(), processor, mutationClient, cfg.labelSelector, name, reason, *dryRun)
+		if report != nil {
+			report.Config = cfg.snapshot(*dryRun, *readOnly)
+			writeReport(cfg.reportSinks, report)
+		}
+		if err != nil {
+			log.Fatalf("Break-glass deletion failed: %v", err)
+		}
+	case "compare":
+		if flag.NArg() != 2 {
+			log.Fatalf("Usage: namespace-auditor compare <proposed.env>")
+		}
+		proposedEnv, err := readEnvFile(flag.Arg(1))
+		if err != nil {
+			log.Fatalf("Failed to read proposed configuration: %v", err)
+		}
+		proposedCfg, err := loadConfigFrom(overlayEnv(proposedEnv, cfg.settings.Getenv))
+		if err != nil {
+			log.Fatalf("Invalid proposed configuration: %v", err)
+		}
+		proposed, err := setupProcessor(proposedCfg, k8sClient, mutationClient, dynamicClient, runID, true)
+		if err != nil {
+			log.Fatalf("Failed to set up the proposed configuration: %v", err)
+		}
+		report, err := comparePolicies(processor, proposed, cfg.labelSelector, proposedCfg.labelSelector, parseNamespaceNames(*namespaceNames), os.Stdout)
+		if err != nil {
+			log.Fatalf("Policy comparison failed: %v", err)
+		}
+		report.Config = cfg.snapshot(true, *readOnly)
+		report.ProposedConfig = proposedCfg.snapshot(true, *readOnly)
+		writeReport(cfg.reportSinks, report)
+	case "serve":
+		if flag.NArg() != 1 {
+			log.Fatalf("Usage: namespace-auditor serve [--listen <address>]")
+		}
+		reloader := newConfigReloader(cfg.configDir, cfg, processor, buildProcessor)
+		if cfg.configDir != "" {
+			log.Printf("Reloading configuration from %s on changes", cfg.configDir)
+		}
+		if cfg.configDir != "" || cfg.auditPolicies {
+			go reloader.watch(context.Background(), cfg.reloadInterval)
+		}
+		log.Printf("Serving namespace status on %s", *listenAddr)
+		if err := runServer(*listenAddr, reloader); err != nil {
+			log.Fatalf("Server failed: %v", err)
+		}
+	case "export-state":
+		if flag.NArg() != 2 {
+			log.Fatalf("Usage: namespace-auditor export-state <file>")
+		}
+		if err := exportState(processor, cfg, parseNamespaceNames(*namespaceNames), flag.Arg(1)); err != nil {
+			log.Fatalf("State export failed: %v", err)
+		}
+	case "import-state":
+		if flag.NArg() != 2 {
+			log.Fatalf("Usage: namespace-auditor import-state <file>")
+		}
+		if err := importState(processor, cfg, flag.Arg(1), *dryRun, os.Stdout); err != nil {
+			log.Fatalf("State import failed: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown command %q", flag.Arg(0))
+	}
 }
 
-// config contains application configuration parameters loaded from environment variables
-type config struct {
-	gracePeriod       time.Duration // Duration before deleting unclaimed namespaces
-	allowedDomains    []string      // Permitted email domains for namespace owners
-	azureTenantID     string        // Azure AD tenant ID for authentication
-	azureClientID     string        // Azure application client ID
-	azureClientSecret string        // Azure client secret for authentication
+// setupProcessor creates the namespace processor of cfg, see newProcessor,
+// together with the collaborators it needs a dynamic client for, created by
+// dynamicClient when needed.
+func setupProcessor(cfg *config, client, mutationClient kubernetes.Interface, dynamicClient func() dynamic.Interface,
+	runID string, dryRun bool) (*auditor.NamespaceProcessor, error) {
+	processor := newProcessor(cfg, client, mutationClient, runID, dryRun)
+	if cfg.ownerSource == auditor.OwnerSourceProfile {
+		processor.SetProfileSource(dynamicClient())
+	}
+	if cfg.backupStore != nil {
+		log.Printf("Backing up namespaces to %s before deletion", cfg.backupStore)
+		processor.SetBackup(backup.NewExporter(dynamicClient(), cfg.backupResources, cfg.backupStore))
+	}
+	if cfg.volumeSnapshots {
+		log.Printf("Snapshotting volumes before deletion")
+		processor.SetVolumeSnapshots(backup.NewSnapshotter(dynamicClient(), cfg.snapshotClass, cfg.snapshotRetention, cfg.snapshotTimeout))
+	}
+	if cfg.auditPolicies {
+		policies, err := loadAuditPolicies(context.TODO(), dynamicClient(), cfg.workWeek)
+		if err != nil {
+			return nil, fmt.Errorf("loading audit policies: %w", err)
+		}
+		log.Printf("Applying %d NamespaceAuditPolicy objects", len(policies))
+		processor.SetAuditPolicies(policies)
+	}
+	return processor, nil
 }
 
-// loadConfig initializes configuration from environment variables.
-// Returns:
-// - *config: Populated configuration object
-// Exits with fatal error if required variables are missing
-func loadConfig() *config {
-	return &config{
-		gracePeriod:       mustParseDuration(os.Getenv("GRACE_PERIOD")),
-		allowedDomains:    strings.Split(os.Getenv("ALLOWED_DOMAINS"), ","),
-		azureTenantID:     os.Getenv("AZURE_TENANT_ID"),
-		azureClientID:     os.Getenv("AZURE_CLIENT_ID"),
-		azureClientSecret: os.Getenv("AZURE_CLIENT_SECRET"),
+// newProcessor creates a namespace processor applying cfg. Reads use client
+// and changes use mutationClient.
+func newProcessor(cfg *config, client, mutationClient kubernetes.Interface, runID string, dryRun bool) *auditor.NamespaceProcessor {
+	processor := auditor.NewNamespaceProcessor(
+		client,
+		cfg.identity,
+		auditor.WithGracePeriod(cfg.gracePeriod),
+		auditor.WithAllowedDomains(cfg.allowedDomains),
+		auditor.WithDryRun(dryRun),
+	)
+
+	processor.SetProviderName(cfg.identityProvider)
+	processor.SetEventRecording(cfg.recordEvents)
+	processor.SetClockSkew(cfg.clockSkew)
+	processor.SetPauseWindows(cfg.pauseWindows)
+	processor.SetExpiryAction(cfg.expiryAction)
+	processor.SetOrphanPolicy(cfg.orphanOwner, cfg.orphanPeriod)
+	processor.SetQuarantine(cfg.quarantinePeriod)
+	processor.SetInvalidDomainPolicy(cfg.invalidDomainPolicy, cfg.invalidDomainGrace)
+	processor.SetOwnerlessPolicy(cfg.ownerlessPolicy, cfg.ownerlessGrace)
+	processor.SetDisabledUserPolicy(cfg.disabledUserPolicy, cfg.disabledUserGrace)
+	processor.SetDeletedUserGracePeriod(cfg.deletedUserGrace)
+	processor.SetMalformedMarkerAction(cfg.malformedAction, cfg.malformedMaxAge)
+	processor.SetGracePeriodOverrideMax(cfg.graceOverrideMax)
+	processor.SetPrefetch(cfg.prefetchConcurrency, cfg.identityRateLimit)
+	processor.SetLookupBudget(cfg.lookupBudget)
+	processor.SetMissingOwnerBreaker(cfg.missingOwnerPercent, cfg.missingOwnerMinimum)
+	processor.SetLookupCache(cfg.lookupCacheTTL)
+	processor.SetFlapDamping(cfg.flapDamping)
+	processor.SetMissingConfirmations(cfg.missingConfirmations)
+	processor.SetPreDeleteFinalizer(cfg.preDeleteFinalizer, cfg.preDeleteTimeout)
+	processor.SetDeletionPropagation(cfg.deletionPropagation)
+	processor.SetStuckRemediation(cfg.stuckThreshold, *forceFinalize)
+	processor.SetChangeBudgets(*maxDeletions, *maxMarks)
+	processor.SetMutationClient(mutationClient)
+	processor.SetOwnerAllowlist(cfg.ownerAllowlist)
+	processor.SetNameFilter(cfg.namespaceInclude, cfg.namespaceExclude)
+	processor.SetOwnerList(cfg.ownerList)
+	processor.SetLinkSuffixes(cfg.linkSuffixes)
+	processor.SetContextKeys(cfg.contextKeys)
+	processor.SetSensitivePolicies(cfg.classificationLabel, cfg.sensitivePolicies)
+	processor.SetTwoPersonThreshold(cfg.twoPersonThreshold)
+	processor.SetCanary(cfg.canary)
+	processor.SetReminders(cfg.reminders)
+	processor.SetManagerEscalation(cfg.escalate)
+	if notifier := cfg.notifier(); notifier != nil {
+		processor.SetNotifier(notifier)
+	}
+	if cfg.deletionWait > 0 {
+		processor.SetWaitForDeletion(cfg.deletionWait)
 	}
+	processor.SetGracePeriodStrategy(cfg.graceStrategy())
+
+	// Stamp markers with this run's identity for traceability
+	processor.SetRunInfo(runID, cfg.hash())
+	return processor
 }
 
 // createK8sClientOrDie creates a Kubernetes client using in-cluster configuration.
@@ -85,11 +392,41 @@ func loadConfig() *config {
 // Returns:
 // - kubernetes.Interface: Initialized Kubernetes client
 // Exits with fatal error if configuration is unavailable
-func createK8sClientOrDie() kubernetes.Interface {
+func createK8sClientOrDie(runID string) kubernetes.Interface {
+	return newClientOrDie(inClusterConfigOrDie(runID))
+}
+
+// createImpersonatingClientOrDie creates a Kubernetes client that acts as
+// the impersonated identity. The pod's service account needs the
+// "impersonate" verb on the corresponding users and groups.
+func createImpersonatingClientOrDie(runID string, impersonation rest.ImpersonationConfig) kubernetes.Interface {
+	config := inClusterConfigOrDie(runID)
+	config.Impersonate = impersonation
+	return newClientOrDie(config)
+}
+
+// inClusterConfigOrDie loads the in-cluster client configuration, identifying
+// requests with userAgent(runID). Exits the process if it is unavailable.
+func inClusterConfigOrDie(runID string) *rest.Config {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		log.Fatalf("Failed to get in-cluster config: %v", err)
 	}
+	config.UserAgent = userAgent(runID)
+	return config
+}
+
+// userAgent describes the auditor build and run, e.g.
+// "namespace-auditor/v1.2.0 (commit 1a2b3c4; run 20240101T000000Z-1a2b3c)".
+// Cluster audit logs record it with every request, so that namespace
+// changes can be attributed to a specific build and run report.
+func userAgent(runID string) string {
+	return fmt.Sprintf("%s/%s (commit %s; run %s)", auditor.FieldManager, version, commit, runID)
+}
+
+// newClientOrDie creates a Kubernetes clientset for config.
+// Exits the process on failure.
+func newClientOrDie(config *rest.Config) kubernetes.Interface {
 	client, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		log.Fatalf("Failed to create Kubernetes client: %v", err)
@@ -97,20 +434,343 @@ func createK8sClientOrDie() kubernetes.Interface {
 	return client
 }
 
+// parseImpersonation builds the identity to impersonate from the --as and
+// --as-group flags. Groups can only be impersonated together with a user.
+func parseImpersonation(user, groups string) (rest.ImpersonationConfig, error) {
+	impersonation := rest.ImpersonationConfig{
+		UserName: strings.TrimSpace(user),
+		Groups:   parseNamespaceNames(groups),
+	}
+	if impersonation.UserName == "" {
+		return rest.ImpersonationConfig{}, fmt.Errorf("--as-group requires --as")
+	}
+	return impersonation, nil
+}
+
+// describeImpersonation renders an impersonated identity for logs
+func describeImpersonation(impersonation rest.ImpersonationConfig) string {
+	if len(impersonation.Groups) == 0 {
+		return impersonation.UserName
+	}
+	return fmt.Sprintf("%s (groups %s)", impersonation.UserName, strings.Join(impersonation.Groups, ", "))
+}
+
+// writeReport delivers the run report to every sink. A failing sink is
+// logged and does not prevent delivery to the others.
+func writeReport(sinks []auditor.ReportSink, report *auditor.RunReport) {
+	for _, sink := range sinks {
+		if err := sink.WriteReport(context.TODO(), report); err != nil {
+			log.Printf("Failed to write run report to %s: %v", sink, err)
+		}
+	}
+}
+
+// writeNamespaceReport writes the per-namespace report of the run to path,
+// or to standard output if path is empty. Failures are logged without
+// failing the run.
+func writeNamespaceReport(report *auditor.RunReport, format auditor.NamespaceReportFormat, path string) {
+	if path == "" {
+		if err := report.WriteNamespaceReport(os.Stdout, format); err != nil {
+			log.Printf("Failed to write per-namespace report: %v", err)
+		}
+		return
+	}
+	var buf bytes.Buffer
+	if err := report.WriteNamespaceReport(&buf, format); err != nil {
+		log.Printf("Failed to write per-namespace report: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		log.Printf("Failed to write per-namespace report to %s: %v", path, err)
+	}
+}
+
+// writeSummary writes the summary of the run report to the named ConfigMap.
+// Failures are logged without failing the run.
+func writeSummary(client kubernetes.Interface, namespace, name string, report *auditor.RunReport, dryRun bool) {
+	if dryRun {
+		log.Printf("[DRY RUN] Would write run summary to ConfigMap %s/%s", namespace, name)
+		return
+	}
+	if err := auditor.WriteSummaryConfigMap(context.TODO(), client, namespace, name, report.Summary()); err != nil {
+		log.Printf("Failed to write run summary to ConfigMap %s/%s: %v", namespace, name, err)
+	}
+}
+
+// createDynamicClientOrDie creates a dynamic Kubernetes client using in-cluster
+// configuration, for custom resources such as AuditorStatus.
+// Exits with fatal error if configuration is unavailable
+func createDynamicClientOrDie(runID string) dynamic.Interface {
+	client, err := dynamic.NewForConfig(inClusterConfigOrDie(runID))
+	if err != nil {
+		log.Fatalf("Failed to create dynamic Kubernetes client: %v", err)
+	}
+	return client
+}
+
+// publishStatus records the run summary in the AuditorStatus object.
+// Dry runs leave it untouched and failures are logged, never fatal.
+func publishStatus(client dynamic.Interface, report *auditor.RunReport, windows []auditor.PauseWindow, dryRun bool) {
+	if dryRun {
+		log.Printf("[DRY RUN] Would update AuditorStatus %s", auditor.AuditorStatusName)
+		return
+	}
+	status := auditor.NewAuditorStatus(report, windows)
+	if err := auditor.PublishStatus(context.TODO(), client, status); err != nil {
+		log.Printf("Failed to update AuditorStatus %s: %v", auditor.AuditorStatusName, err)
+	}
+}
+
+// loadAuditPolicies reads and validates the NamespaceAuditPolicy objects of
+// the cluster. An invalid policy fails the run rather than being skipped,
+// since its namespaces would otherwise follow the broader default
+// configuration.
+func loadAuditPolicies(ctx context.Context, client dynamic.Interface, week auditor.WorkWeek) ([]auditor.AuditPolicy, error) {
+	objects, err := auditor.ListAuditPolicies(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	policies := make([]auditor.AuditPolicy, 0, len(objects))
+	for _, object := range objects {
+		policy, err := auditPolicy(object, week)
+		if err != nil {
+			return nil, fmt.Errorf("NamespaceAuditPolicy %s: %w", object.Name, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// auditPolicy validates a NamespaceAuditPolicy object
+func auditPolicy(object auditor.NamespaceAuditPolicy, week auditor.WorkWeek) (auditor.AuditPolicy, error) {
+	spec := object.Spec
+	policy := auditor.AuditPolicy{Name: object.Name, Generation: object.Generation, Priority: spec.Priority, Selector: labels.Everything()}
+	if spec.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(spec.NamespaceSelector)
+		if err != nil {
+			return policy, fmt.Errorf("namespaceSelector: %w", err)
+		}
+		policy.Selector = selector
+	}
+	if len(spec.AllowedDomains) > 0 {
+		domains, err := auditor.ParseAllowedDomains(strings.Join(spec.AllowedDomains, ","))
+		if err != nil {
+			return policy, fmt.Errorf("allowedDomains: %w", err)
+		}
+		policy.AllowedDomains = domains
+	}
+	if spec.GracePeriod != "" {
+		gracePeriod, businessDays, err := parseGracePeriod(spec.GracePeriod)
+		if err != nil {
+			return policy, fmt.Errorf("gracePeriod: %w", err)
+		}
+		policy.Grace = graceStrategy(gracePeriod, businessDays, week)
+	}
+	exemptions, err := auditor.ParseNamePatterns(strings.Join(spec.Exemptions, ","))
+	if err != nil {
+		return policy, fmt.Errorf("exemptions: %w", err)
+	}
+	policy.Exemptions = exemptions
+	if contact := spec.Notifications.Contact; contact != "" {
+		if _, err := mail.ParseAddress(contact); err != nil {
+			return policy, fmt.Errorf("notifications.contact: invalid email address %q", contact)
+		}
+		policy.Contact = contact
+	}
+	for _, recipient := range spec.Notifications.Recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return policy, fmt.Errorf("notifications.recipients: invalid email address %q", recipient)
+		}
+		policy.Recipients = append(policy.Recipients, recipient)
+	}
+	return policy, nil
+}
+
+// checkWritePermissions determines which write permissions the changes
+// planned by a read-only run would need, logging those not yet granted.
+// Failures are logged and leave the report without permission checks.
+func checkWritePermissions(client kubernetes.Interface, planned []auditor.PlannedChange) []auditor.PermissionCheck {
+	checks, err := auditor.CheckWritePermissions(context.TODO(), client, planned)
+	if err != nil {
+		log.Printf("Failed to check write permissions: %v", err)
+	}
+	for _, c := range checks {
+		if !c.Allowed {
+			log.Printf("[READ ONLY] Applying planned changes requires permission to %s", c)
+		}
+	}
+	return checks
+}
+
 // processNamespaces executes the main auditor workflow:
-// 1. List all namespaces with Kubeflow profile label
-// 2. Process each namespace according to audit rules
+//  1. List all namespaces matching the configured label selector, or fetch
+//     the explicitly named ones
+//  2. Resolve the distinct owner identities concurrently
+//  3. Process each namespace according to audit rules
+//
 // Parameters:
 // - p: Initialized NamespaceProcessor with configuration
+// - labelSelector: Selector identifying namespaces to audit
+// - names: Namespaces to restrict the run to, all matching namespaces if empty
+// - trace: Whether to record per-namespace decision traces
+// Returns:
+// - *auditor.RunReport: Summary of the run
 // Exits with fatal error if namespace listing fails
-func processNamespaces(p *auditor.NamespaceProcessor) {
-	namespaces, err := p.ListNamespaces(context.TODO(), kubeflowLabel)
+func processNamespaces(p *auditor.NamespaceProcessor, labelSelector string, names []string, trace bool) *auditor.RunReport {
+	report := auditor.NewRunReport(p.RunInfo())
+	defer report.Finish()
+	defer recordAPIUsage(p, report)
+	log.Printf("Starting audit run %s (config %s)", report.RunID, report.ConfigHash)
+
+	list, err := targetNamespaces(p, labelSelector, names)
 	if err != nil {
 		log.Fatalf("Failed to list namespaces: %v", err)
 	}
+	namespaces := list.Items
+	report.SnapshotVersion = list.ResourceVersion
+
+	// Find companion namespaces, resolve owner identities up front, then
+	// apply decisions
+	p.Link(namespaces)
+	p.Prefetch(context.TODO(), namespaces)
 
 	// Process each namespace sequentially
-	for _, ns := range namespaces.Items {
-		p.ProcessNamespace(context.TODO(), ns)
+	for _, ns := range namespaces {
+		if p.Aborted() != nil {
+			break
+		}
+		report.Namespaces++
+		if auditor.IsOwnerless(ns) {
+			report.AddOwnerless(ns.Name)
+		}
+		if trace {
+			tr := p.ProcessNamespaceTraced(context.TODO(), ns)
+			report.AddTrace(tr)
+			report.AddDecision(tr.Decision())
+			continue
+		}
+		report.AddDecision(p.ProcessNamespace(context.TODO(), ns))
+	}
+	if p.Aborted() == nil {
+		if canary, ok := p.RunCanary(context.TODO()); ok {
+			report.Canary = &canary
+		}
+	}
+	if err := p.Aborted(); err != nil {
+		report.Aborted = err.Error()
+	}
+	report.StuckTerminating = p.StuckTerminating()
+	report.Marked = p.Marked()
+	report.Saved = p.Saved()
+	if len(report.Saved) > 0 {
+		log.Printf("Kept %d marked namespaces whose owners were validated again", len(report.Saved))
+	}
+	report.PlannedChanges = p.PlannedChanges()
+	report.ForeignMarkers = p.ForeignMarkers()
+	for _, m := range report.ForeignMarkers {
+		log.Printf("Foreign deletion marker on %s (%s) left in place", m.Namespace, m.DeleteAt)
+	}
+	report.Cached = p.CacheHits()
+	if report.Cached > 0 {
+		log.Printf("Skipped %d unchanged namespaces with a fresh valid-owner evaluation", report.Cached)
+	}
+	report.Flapping = p.Flapping()
+	for _, f := range report.Flapping {
+		if f.Damped {
+			log.Printf("Flapping namespace %s (%d flaps): change held back until stable", f.Name, f.Flaps)
+		}
+	}
+	report.ChangedDuringRun = p.ChangedDuringRun()
+	for _, name := range report.ChangedDuringRun {
+		log.Printf("Namespace %s changed during the run, left for the next run", name)
+	}
+	report.ExpiredExemptions = p.ExpiredExemptions()
+	report.MalformedMarkers = p.MalformedMarkers()
+	for _, m := range report.MalformedMarkers {
+		log.Printf("Unreadable deletion marker %q on %s, action %s", m.Value, m.Name, m.Action)
+	}
+	report.AwaitingApproval = p.AwaitingApproval()
+	for _, name := range report.AwaitingApproval {
+		log.Printf("Deletion of %s waits for approval (%s)", name, auditor.DeletionApprovedAnnotation)
+	}
+	report.IdentityLookups = p.IdentityLookups()
+	report.IdentityCacheHits = p.LookupCacheHits()
+	report.Deferred = p.Deferred()
+	if report.Deferred > 0 {
+		log.Printf("Identity lookups unavailable: %d namespaces deferred to the next run", report.Deferred)
+	}
+	report.OverBudget = p.OverBudget()
+	if len(report.OverBudget) > 0 {
+		log.Printf("Change budget reached: %d deletions or marks deferred to the next run", len(report.OverBudget))
+	}
+	return report
+}
+
+// abortExitCode maps the error that aborted a run to the process exit code
+func abortExitCode(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrPermission):
+		return exitPermissionDenied
+	case errors.Is(err, errs.ErrAuth):
+		return exitAuthFailed
+	case errors.Is(err, auditor.ErrMissingOwnerRate):
+		return exitMissingOwners
+	default:
+		return 1
+	}
+}
+
+// targetNamespaces returns the namespaces a run should evaluate. Without
+// explicit names this is every namespace matching labelSelector, listed once
+// so that the list's resourceVersion identifies the snapshot the run is
+// evaluated against. Named namespaces are fetched individually and skipped
+// unless they also match labelSelector, so a typo cannot reach a namespace
+// outside the audit scope; their list has no resourceVersion.
+func targetNamespaces(p *auditor.NamespaceProcessor, labelSelector string, names []string) (*corev1.NamespaceList, error) {
+	if len(names) == 0 {
+		return p.ListNamespaces(context.TODO(), labelSelector)
+	}
+
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", labelSelector, err)
+	}
+
+	var namespaces []corev1.Namespace
+	for _, name := range names {
+		ns, err := p.GetNamespace(context.TODO(), name)
+		if err != nil {
+			return nil, err
+		}
+		if !selector.Matches(labels.Set(ns.Labels)) {
+			log.Printf("Skipping %s: does not match selector %q", name, labelSelector)
+			continue
+		}
+		namespaces = append(namespaces, *ns)
+	}
+	return &corev1.NamespaceList{Items: namespaces}, nil
+}
+
+// parseNamespaceNames splits a comma-separated list of namespace names,
+// ignoring whitespace and empty entries.
+func parseNamespaceNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// recordAPIUsage copies the processor's Kubernetes API usage into the run
+// report and logs a per-operation summary.
+func recordAPIUsage(p *auditor.NamespaceProcessor, report *auditor.RunReport) {
+	stats := p.APIStats()
+	report.APIUsage = stats.Snapshot()
+	for _, op := range stats.Operations() {
+		st := report.APIUsage[op]
+		log.Printf("Kubernetes API usage: %s calls=%d errors=%d avg=%s max=%s",
+			op, st.Count, st.Errors, st.TotalLatency/time.Duration(st.Count), st.MaxLatency)
 	}
 }
@@ -2,16 +2,37 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	"github.com/bryanpaget/namespace-auditor/internal/azure"
+	"github.com/bryanpaget/namespace-auditor/internal/backup"
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"github.com/bryanpaget/namespace-auditor/internal/notify"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 // mockAzureClient simulates Azure user existence checks for testing purposes.
@@ -95,9 +116,8 @@ func TestNamespaceProcessing(t *testing.T) {
 			processor := auditor.NewNamespaceProcessor(
 				k8sClient,
 				azureClient,
-				tc.config.gracePeriod,
-				tc.config.allowedDomains,
-				false, // Dry-run disabled
+				auditor.WithGracePeriod(tc.config.gracePeriod),
+				auditor.WithAllowedDomains(tc.config.allowedDomains),
 			)
 
 			// Execute namespace processing
@@ -139,25 +159,1486 @@ func TestNamespaceProcessing(t *testing.T) {
 func TestConfigLoading(t *testing.T) {
 	// Set test environment variables
 	t.Setenv("GRACE_PERIOD", "24h")
-	t.Setenv("ALLOWED_DOMAINS", "company.com,example.com")
+	t.Setenv("ALLOWED_DOMAINS", "company.com, example.com,")
 	t.Setenv("AZURE_TENANT_ID", "test-tenant")
 	t.Setenv("AZURE_CLIENT_ID", "test-client")
 	t.Setenv("AZURE_CLIENT_SECRET", "test-secret")
 
 	// Load and validate configuration
-	cfg := loadConfig()
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+
+	// Verify grace period parsing
+	if cfg.gracePeriod != 24*time.Hour {
+		t.Errorf("Grace period mismatch:\nExpected: 24h\nActual: %v", cfg.gracePeriod)
+	}
+
+	// Verify domain parsing
+	expectedDomains := []string{"company.com", "example.com"}
+	if !equalStringSlices(cfg.allowedDomains, expectedDomains) {
+		t.Errorf("Domain parsing error:\nExpected: %v\nActual: %v",
+			expectedDomains, cfg.allowedDomains)
+	}
+}
+
+// TestConfigLoadingInvalidDomains ensures bad ALLOWED_DOMAINS entries fail at startup
+func TestConfigLoadingInvalidDomains(t *testing.T) {
+	for _, domains := range []string{"", " , ", "company.com, user@example.com"} {
+		setValidConfigEnv(t)
+		t.Setenv("ALLOWED_DOMAINS", domains)
+
+		if _, err := loadConfig(); err == nil {
+			t.Errorf("Expected error for ALLOWED_DOMAINS=%q", domains)
+		}
+	}
+}
+
+// TestConfigValidationAggregatesErrors ensures all problems are reported at once
+func TestConfigValidationAggregatesErrors(t *testing.T) {
+	t.Setenv("GRACE_PERIOD", "-1h")
+	t.Setenv("ALLOWED_DOMAINS", "")
+	t.Setenv("AZURE_TENANT_ID", "")
+	t.Setenv("AZURE_CLIENT_ID", "test-client")
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+	t.Setenv("NAMESPACE_SELECTOR", "app in (")
+
+	_, err := loadConfig()
+	if err == nil {
+		t.Fatal("Expected configuration error")
+	}
+	for _, want := range []string{
+		"GRACE_PERIOD: must be positive",
+		"ALLOWED_DOMAINS: required",
+		"AZURE_TENANT_ID: required",
+		"AZURE_CLIENT_SECRET: required",
+		"NAMESPACE_SELECTOR: invalid label selector",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Error missing %q:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "AZURE_CLIENT_ID") {
+		t.Errorf("Valid settings should not be reported:\n%v", err)
+	}
+}
+
+// TestConfigIdentityProvider validates selecting the identity provider
+func TestConfigIdentityProvider(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil || cfg.identityProvider != "azure" || cfg.identity == nil {
+		t.Fatalf("Expected the azure provider by default, got %v / %v", cfg, err)
+	}
+
+	t.Setenv("IDENTITY_PROVIDER", "okta")
+	t.Setenv("AZURE_TENANT_ID", "")
+	t.Setenv("OKTA_ORG_URL", "https://example.okta.com")
+	t.Setenv("OKTA_API_TOKEN", "token")
+	t.Setenv("OKTA_CLIENT_ID", "")
+	t.Setenv("OKTA_PRIVATE_KEY", "")
+	cfg, err = loadConfig()
+	if err != nil || cfg.snapshot(false, false).Provider != "okta" {
+		t.Fatalf("Expected the okta provider without Azure settings, got %v / %v", cfg, err)
+	}
+
+	t.Setenv("IDENTITY_PROVIDER", "carrier-pigeon")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "IDENTITY_PROVIDER: unknown identity provider") ||
+		!strings.Contains(err.Error(), "azure, chain, google, ldap, okta, scim") {
+		t.Errorf("Expected unknown provider error listing providers, got %v", err)
+	}
+}
+
+// TestParseGracePeriod validates grace period checks
+func TestParseGracePeriod(t *testing.T) {
+	for value, wantErr := range map[string]bool{
+		"720h": false,
+		"10bd": false,
+		"":     true,
+		"30d":  true,
+		"0s":   true,
+		"-24h": true,
+		"0bd":  true,
+		"xbd":  true,
+	} {
+		_, _, err := parseGracePeriod(value)
+		if (err != nil) != wantErr {
+			t.Errorf("parseGracePeriod(%q) error = %v, wantErr %v", value, err, wantErr)
+		}
+	}
+}
+
+// TestConfigGraceByDomain validates per-domain grace periods
+func TestConfigGraceByDomain(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("GRACE_PERIOD", "720h")
+	t.Setenv("GRACE_PERIOD_BY_DOMAIN", "Partner.org=168h, contractor.ca=5bd")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	partner := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{auditor.OwnerAnnotation: "user@partner.org"}}}
+	staff := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{auditor.OwnerAnnotation: "user@company.com"}}}
+	if got := cfg.graceStrategy().Describe(partner); got != "168h0m0s" {
+		t.Errorf("Partner grace period = %q", got)
+	}
+	if got := cfg.graceStrategy().Describe(staff); got != "720h0m0s" {
+		t.Errorf("Default grace period = %q", got)
+	}
+	want := map[string]string{"partner.org": "168h0m0s", "contractor.ca": "5 business days (Mon,Tue,Wed,Thu,Fri)"}
+	if got := cfg.snapshot(false, false).GracePeriodByDomain; !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot = %v, want %v", got, want)
+	}
+
+	for _, value := range []string{"partner.org", "partner.org=soon", "not a domain=1h", "a.org=1h,A.org=2h"} {
+		t.Setenv("GRACE_PERIOD_BY_DOMAIN", value)
+		if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "GRACE_PERIOD_BY_DOMAIN") {
+			t.Errorf("%q: expected GRACE_PERIOD_BY_DOMAIN error, got %v", value, err)
+		}
+	}
+}
+
+// TestConfigGraceTiers validates grace-period tiers read from a policy file
+func TestConfigGraceTiers(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("GRACE_PERIOD", "720h")
+	t.Setenv("GRACE_PERIOD_BY_DOMAIN", "partner.org=168h")
+	path := filepath.Join(t.TempDir(), "grace.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("tiers:\n- selector: env=prod\n  gracePeriod: 2160h\n- selector: env in (sandbox, scratch)\n  gracePeriod: 5bd\n- selector: env\n  gracePeriod: 24h\n")
+	t.Setenv("GRACE_POLICY_FILE", path)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	namespace := func(env, owner string) corev1.Namespace {
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{auditor.OwnerAnnotation: owner}}}
+		if env != "" {
+			ns.Labels = map[string]string{"env": env}
+		}
+		return ns
+	}
+	for _, tc := range []struct {
+		ns   corev1.Namespace
+		want string
+	}{
+		{namespace("prod", "user@partner.org"), "2160h0m0s"},
+		{namespace("sandbox", "user@company.com"), "5 business days (Mon,Tue,Wed,Thu,Fri)"},
+		{namespace("dev", "user@company.com"), "24h0m0s"},
+		{namespace("", "user@partner.org"), "168h0m0s"},
+		{namespace("", "user@company.com"), "720h0m0s"},
+	} {
+		if got := cfg.graceStrategy().Describe(tc.ns); got != tc.want {
+			t.Errorf("Grace period of %v = %q, want %q", tc.ns.ObjectMeta, got, tc.want)
+		}
+	}
+	want := []string{"env=prod: 2160h0m0s", "env in (sandbox,scratch): 5 business days (Mon,Tue,Wed,Thu,Fri)", "env: 24h0m0s"}
+	if got := cfg.snapshot(false, false).GraceTiers; !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot = %q, want %q", got, want)
+	}
+
+	// Editing the policy file changes the config hash, restarting slow start
+	write("tiers:\n- selector: env=prod\n  gracePeriod: 1440h\n")
+	edited, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if edited.hash() == cfg.hash() {
+		t.Error("Editing GRACE_POLICY_FILE should change the config hash")
+	}
+
+	for _, content := range []string{
+		"tiers: []\n",
+		"tiers:\n- gracePeriod: 24h\n",
+		"tiers:\n- selector: env in prod\n  gracePeriod: 24h\n",
+		"tiers:\n- selector: env=prod\n  gracePeriod: 90d\n",
+		"tiers:\n- selector: env=prod\n  grace: 24h\n",
+	} {
+		write(content)
+		if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "GRACE_POLICY_FILE") {
+			t.Errorf("%q: expected GRACE_POLICY_FILE error, got %v", content, err)
+		}
+	}
+	t.Setenv("GRACE_POLICY_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "GRACE_POLICY_FILE") {
+		t.Errorf("Expected GRACE_POLICY_FILE error, got %v", err)
+	}
+}
+
+// TestConfigBusinessDays validates business-day grace period configuration
+func TestConfigBusinessDays(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("GRACE_PERIOD", "10bd")
+	t.Setenv("WORK_WEEK", "sun-thu")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.graceBusinessDays != 10 || cfg.gracePeriod != 0 {
+		t.Errorf("Expected 10 business days, got %d days / %s", cfg.graceBusinessDays, cfg.gracePeriod)
+	}
+	if cfg.workWeek[time.Friday] || !cfg.workWeek[time.Sunday] {
+		t.Errorf("Work week mismatch: %s", cfg.workWeek)
+	}
+
+	t.Setenv("WORK_WEEK", "mon-funday")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "WORK_WEEK") {
+		t.Errorf("Expected WORK_WEEK error, got %v", err)
+	}
+}
+
+// TestConfigPauseWindows validates pause window configuration
+func TestConfigPauseWindows(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("PAUSE_WINDOWS", "2024-12-20/2025-01-06, 2025-12-19/2026-01-05")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if len(cfg.pauseWindows) != 2 {
+		t.Errorf("Expected 2 pause windows, got %v", cfg.pauseWindows)
+	}
+
+	t.Setenv("PAUSE_WINDOWS", "2025-01-06/2024-12-20")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "PAUSE_WINDOWS") {
+		t.Errorf("Expected PAUSE_WINDOWS error, got %v", err)
+	}
+}
+
+// TestConfigExpiryAction validates expiry action configuration
+func TestConfigExpiryAction(t *testing.T) {
+	setValidConfigEnv(t)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.expiryAction != auditor.ExpiryDelete {
+		t.Errorf("Expected default expiry action %q, got %q", auditor.ExpiryDelete, cfg.expiryAction)
+	}
+
+	t.Setenv("EXPIRY_ACTION", "archive")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "EXPIRY_ACTION") {
+		t.Errorf("Expected EXPIRY_ACTION error, got %v", err)
+	}
+}
+
+// TestConfigOrphanPolicy validates the settings of the reassign expiry action
+func TestConfigOrphanPolicy(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("EXPIRY_ACTION", "reassign")
+	t.Setenv("ORPHAN_OWNER", "platform-team@company.com")
+	t.Setenv("ORPHAN_PERIOD", "720h")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.expiryAction != auditor.ExpiryReassign || cfg.orphanOwner != "platform-team@company.com" || cfg.orphanPeriod != 720*time.Hour {
+		t.Errorf("Expiry action = %q, orphan owner = %q, orphan period = %s", cfg.expiryAction, cfg.orphanOwner, cfg.orphanPeriod)
+	}
+
+	t.Setenv("ORPHAN_PERIOD", "a month")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "ORPHAN_PERIOD") {
+		t.Errorf("Expected ORPHAN_PERIOD error, got %v", err)
+	}
+
+	t.Setenv("ORPHAN_PERIOD", "")
+	t.Setenv("EXPIRY_ACTION", "delete")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "ORPHAN_OWNER: only used with EXPIRY_ACTION=reassign") {
+		t.Errorf("Expected ORPHAN_OWNER to require the reassign action, got %v", err)
+	}
+}
+
+// TestConfigNameFilter validates the namespace include and exclude lists
+func TestConfigNameFilter(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("NAMESPACE_INCLUDE", "team-*, /user-[a-z]+/")
+	t.Setenv("NAMESPACE_EXCLUDE", "kube-*,kubeflow,monitoring")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if len(cfg.namespaceInclude) != 2 || len(cfg.namespaceExclude) != 3 {
+		t.Errorf("Include = %v, exclude = %v", cfg.namespaceInclude, cfg.namespaceExclude)
+	}
+	if got := cfg.snapshot(false, false).NamespaceExclude; strings.Join(got, ",") != "kube-*,kubeflow,monitoring" {
+		t.Errorf("Snapshot exclude = %v", got)
+	}
+
+	t.Setenv("NAMESPACE_EXCLUDE", "/team-(/")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "NAMESPACE_EXCLUDE") {
+		t.Errorf("Expected NAMESPACE_EXCLUDE error, got %v", err)
+	}
+}
+
+// TestConfigQuarantine validates the quarantine period
+func TestConfigQuarantine(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("QUARANTINE_PERIOD", "168h")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.quarantinePeriod != 168*time.Hour {
+		t.Errorf("Quarantine period = %s, want 168h", cfg.quarantinePeriod)
+	}
+
+	t.Setenv("EXPIRY_ACTION", "cordon")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "QUARANTINE_PERIOD: not used with EXPIRY_ACTION=cordon") {
+		t.Errorf("Expected QUARANTINE_PERIOD to be rejected with cordon, got %v", err)
+	}
+	t.Setenv("EXPIRY_ACTION", "")
+	t.Setenv("QUARANTINE_PERIOD", "-1h")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "QUARANTINE_PERIOD") {
+		t.Errorf("Expected QUARANTINE_PERIOD error, got %v", err)
+	}
+}
+
+// TestConfigInvalidDomainPolicy validates invalid-domain owner policy configuration
+func TestConfigInvalidDomainPolicy(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("INVALID_DOMAIN_POLICY", "expire")
+	t.Setenv("INVALID_DOMAIN_GRACE_PERIOD", "2160h")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.invalidDomainPolicy != auditor.OwnerPolicyExpire || cfg.invalidDomainGrace != 2160*time.Hour {
+		t.Errorf("Unexpected policy: %q / %s", cfg.invalidDomainPolicy, cfg.invalidDomainGrace)
+	}
+
+	t.Setenv("INVALID_DOMAIN_POLICY", "ignore")
+	t.Setenv("INVALID_DOMAIN_GRACE_PERIOD", "-1h")
+	_, err = loadConfig()
+	for _, want := range []string{"INVALID_DOMAIN_POLICY", "INVALID_DOMAIN_GRACE_PERIOD"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %s error, got %v", want, err)
+		}
+	}
+}
+
+// TestConfigOwnerlessPolicy validates ownerless namespace policy configuration
+func TestConfigOwnerlessPolicy(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("OWNERLESS_POLICY", "expire")
+	t.Setenv("OWNERLESS_GRACE_PERIOD", "168h")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.ownerlessPolicy != auditor.OwnerPolicyExpire || cfg.ownerlessGrace != 168*time.Hour {
+		t.Errorf("Unexpected policy: %q / %s", cfg.ownerlessPolicy, cfg.ownerlessGrace)
+	}
+
+	t.Setenv("OWNERLESS_POLICY", "archive")
+	t.Setenv("OWNERLESS_GRACE_PERIOD", "soon")
+	_, err = loadConfig()
+	for _, want := range []string{"OWNERLESS_POLICY", "OWNERLESS_GRACE_PERIOD"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %s error, got %v", want, err)
+		}
+	}
+}
+
+// TestConfigUserStatePolicies validates disabled and soft-deleted owner configuration
+func TestConfigUserStatePolicies(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.disabledUserPolicy != auditor.OwnerPolicySkip || cfg.disabledUserGrace != 0 || cfg.deletedUserGrace != 0 {
+		t.Errorf("Unexpected defaults: %q / %s / %s", cfg.disabledUserPolicy, cfg.disabledUserGrace, cfg.deletedUserGrace)
+	}
+
+	t.Setenv("DISABLED_USER_POLICY", "expire")
+	t.Setenv("DISABLED_USER_GRACE_PERIOD", "24h")
+	t.Setenv("DELETED_USER_GRACE_PERIOD", "1440h")
+	if cfg, err = loadConfig(); err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.disabledUserPolicy != auditor.OwnerPolicyExpire || cfg.disabledUserGrace != 24*time.Hour || cfg.deletedUserGrace != 1440*time.Hour {
+		t.Errorf("Unexpected policy: %q / %s / %s", cfg.disabledUserPolicy, cfg.disabledUserGrace, cfg.deletedUserGrace)
+	}
+
+	t.Setenv("DISABLED_USER_POLICY", "delete")
+	t.Setenv("DELETED_USER_GRACE_PERIOD", "-1h")
+	_, err = loadConfig()
+	for _, want := range []string{"DISABLED_USER_POLICY", "DELETED_USER_GRACE_PERIOD"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %s error, got %v", want, err)
+		}
+	}
+}
+
+// TestConfigPrefetch validates identity prefetch configuration
+func TestConfigPrefetch(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.prefetchConcurrency != auditor.DefaultPrefetchConcurrency || cfg.identityRateLimit != 0 {
+		t.Errorf("Unexpected defaults: %d / %g", cfg.prefetchConcurrency, cfg.identityRateLimit)
+	}
+
+	t.Setenv("IDENTITY_LOOKUP_BUDGET", "5000")
+	if cfg, err = loadConfig(); err != nil || cfg.lookupBudget != 5000 {
+		t.Errorf("Unexpected lookup budget: %v / %v", cfg, err)
+	}
+
+	if cfg.lookupCacheTTL != auditor.DefaultLookupCacheTTL {
+		t.Errorf("Unexpected default lookup cache TTL: %s", cfg.lookupCacheTTL)
+	}
+	t.Setenv("IDENTITY_CACHE_TTL", "0")
+	if cfg, err = loadConfig(); err != nil || cfg.lookupCacheTTL != 0 {
+		t.Errorf("Lookup cache should be disabled: %v / %v", cfg, err)
+	}
+
+	t.Setenv("FLAP_DAMPING_RUNS", "3")
+	if cfg, err = loadConfig(); err != nil || cfg.flapDamping != 3 {
+		t.Errorf("Unexpected flap damping: %v / %v", cfg, err)
+	}
+
+	t.Setenv("MISSING_CONFIRMATION_RUNS", "2")
+	if cfg, err = loadConfig(); err != nil || cfg.missingConfirmations != 2 {
+		t.Errorf("Unexpected missing confirmations: %v / %v", cfg, err)
+	}
+
+	t.Setenv("PREFETCH_CONCURRENCY", "0")
+	t.Setenv("IDENTITY_RATE_LIMIT", "-5")
+	t.Setenv("IDENTITY_LOOKUP_BUDGET", "lots")
+	t.Setenv("FLAP_DAMPING_RUNS", "-1")
+	t.Setenv("MISSING_CONFIRMATION_RUNS", "twice")
+	_, err = loadConfig()
+	for _, want := range []string{"PREFETCH_CONCURRENCY", "IDENTITY_RATE_LIMIT", "IDENTITY_LOOKUP_BUDGET", "FLAP_DAMPING_RUNS", "MISSING_CONFIRMATION_RUNS"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %s error, got %v", want, err)
+		}
+	}
+}
+
+// TestConfigMissingOwnerAbort validates the missing-owner breaker settings
+func TestConfigMissingOwnerAbort(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("MISSING_OWNER_ABORT_PERCENT", "20%")
+	t.Setenv("MISSING_OWNER_ABORT_MIN_OWNERS", "50")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.missingOwnerPercent != 20 || cfg.missingOwnerMinimum != 50 {
+		t.Errorf("Breaker = %g%% of at least %d owners, want 20%% of 50", cfg.missingOwnerPercent, cfg.missingOwnerMinimum)
+	}
+
+	t.Setenv("MISSING_OWNER_ABORT_PERCENT", "")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "MISSING_OWNER_ABORT_MIN_OWNERS: only used with MISSING_OWNER_ABORT_PERCENT") {
+		t.Errorf("Expected MISSING_OWNER_ABORT_MIN_OWNERS to require a percentage, got %v", err)
+	}
+	t.Setenv("MISSING_OWNER_ABORT_PERCENT", "120")
+	t.Setenv("MISSING_OWNER_ABORT_MIN_OWNERS", "0")
+	_, err = loadConfig()
+	for _, want := range []string{"MISSING_OWNER_ABORT_PERCENT", "MISSING_OWNER_ABORT_MIN_OWNERS"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %s error, got %v", want, err)
+		}
+	}
+}
+
+// TestConfigSnapshot validates the configuration recorded in run reports
+func TestConfigSnapshot(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("GRACE_PERIOD", "10bd")
+	t.Setenv("PAUSE_WINDOWS", "2024-12-20/2025-01-06")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	snap := cfg.snapshot(true, false)
+	if snap.GracePeriod != "10bd (Mon,Tue,Wed,Thu,Fri)" || len(snap.PauseWindows) != 1 || !snap.DryRun || snap.Provider != "azure" {
+		t.Errorf("Unexpected snapshot: %+v", snap)
+	}
+	if snap.Version != version || snap.LabelSelector != kubeflowLabel {
+		t.Errorf("Snapshot missing version or selector: %+v", snap)
+	}
+
+	data, _ := json.Marshal(snap)
+	if strings.Contains(string(data), cfg.azureClientSecret) {
+		t.Errorf("Snapshot must not contain secrets: %s", data)
+	}
+}
+
+// TestPrintVersion validates build and configuration identity output
+func TestPrintVersion(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+
+	var out strings.Builder
+	printVersion(&out)
+	for _, want := range []string{"version: " + version, "commit: " + commit, "config: " + cfg.hash()} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Version output missing %q: %s", want, out.String())
+		}
+	}
+
+	t.Setenv("AZURE_TENANT_ID", "")
+	out.Reset()
+	printVersion(&out)
+	if !strings.Contains(out.String(), "config: unavailable") {
+		t.Errorf("Expected unavailable config hash: %s", out.String())
+	}
+}
+
+// TestServeVersion checks the build and configuration served over HTTP
+func TestServeVersion(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	client := fake.NewSimpleClientset()
+	processor, err := setupProcessor(cfg, client, client, nil, "test", true)
+	if err != nil {
+		t.Fatalf("setupProcessor: %v", err)
+	}
+	mux := serveMux(newConfigReloader("", cfg, processor, nil))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	var got map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Decoding /version: %v", err)
+	}
+	want := map[string]string{"version": version, "commit": commit, "config": cfg.hash()}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("/version = %v, want %v", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	info := fmt.Sprintf("namespace_auditor_build_info{version=%q,commit=%q} 1", version, commit)
+	if !strings.Contains(rec.Body.String(), info) {
+		t.Errorf("Metrics missing %q:\n%s", info, rec.Body.String())
+	}
+}
+
+// TestConfigPreDeleteFinalizer validates pre-delete finalizer configuration
+func TestConfigPreDeleteFinalizer(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.preDeleteFinalizer || cfg.preDeleteTimeout != auditor.DefaultPreDeleteTimeout {
+		t.Errorf("Unexpected defaults: %t / %s", cfg.preDeleteFinalizer, cfg.preDeleteTimeout)
+	}
+
+	t.Setenv("PRE_DELETE_FINALIZER", "true")
+	t.Setenv("PRE_DELETE_TIMEOUT", "2h")
+	cfg, err = loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if !cfg.preDeleteFinalizer || cfg.preDeleteTimeout != 2*time.Hour {
+		t.Errorf("Unexpected settings: %t / %s", cfg.preDeleteFinalizer, cfg.preDeleteTimeout)
+	}
+
+	t.Setenv("PRE_DELETE_FINALIZER", "maybe")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "PRE_DELETE_FINALIZER") {
+		t.Errorf("Expected PRE_DELETE_FINALIZER error, got %v", err)
+	}
+}
+
+// TestConfigDeletion validates deletion propagation and wait configuration
+func TestConfigDeletion(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("DELETION_PROPAGATION", "foreground")
+	t.Setenv("DELETION_WAIT_TIMEOUT", "10m")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.deletionPropagation != metav1.DeletePropagationForeground || cfg.deletionWait != 10*time.Minute {
+		t.Errorf("Unexpected settings: %q / %s", cfg.deletionPropagation, cfg.deletionWait)
+	}
+
+	if cfg.stuckThreshold != auditor.DefaultStuckThreshold {
+		t.Errorf("Unexpected default stuck threshold: %s", cfg.stuckThreshold)
+	}
+
+	t.Setenv("DELETION_PROPAGATION", "orphan")
+	t.Setenv("STUCK_TERMINATING_THRESHOLD", "-1h")
+	_, err = loadConfig()
+	for _, want := range []string{"DELETION_PROPAGATION", "STUCK_TERMINATING_THRESHOLD"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %s error, got %v", want, err)
+		}
+	}
+}
+
+// TestConfigPublishStatus validates the AuditorStatus publishing toggle
+func TestConfigPublishStatus(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("PUBLISH_STATUS", "true")
+
+	cfg, err := loadConfig()
+	if err != nil || !cfg.publishStatus {
+		t.Fatalf("Expected status publishing enabled, got %v", err)
+	}
+
+	t.Setenv("PUBLISH_STATUS", "sometimes")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "PUBLISH_STATUS") {
+		t.Errorf("Expected PUBLISH_STATUS error, got %v", err)
+	}
+}
+
+// TestAuditPolicy validates NamespaceAuditPolicy objects
+func TestAuditPolicy(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("AUDIT_POLICIES", "true")
+	if cfg, err := loadConfig(); err != nil || !cfg.auditPolicies {
+		t.Fatalf("Expected audit policies enabled, got %v", err)
+	}
+
+	policy, err := auditPolicy(auditor.NamespaceAuditPolicy{Name: "sandboxes", Spec: auditor.NamespaceAuditPolicySpec{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "sandbox"}},
+		AllowedDomains:    []string{"Company.com", "partner.org"},
+		GracePeriod:       "5bd",
+		Exemptions:        []string{"scratch-*"},
+		Notifications:     auditor.PolicyNotifications{Contact: "Lead <lead@company.com>"},
+	}}, auditor.DefaultWorkWeek)
+	if err != nil {
+		t.Fatalf("Unexpected policy error: %v", err)
+	}
+	if policy.Selector.String() != "env=sandbox" || !equalStringSlices(policy.AllowedDomains, []string{"company.com", "partner.org"}) ||
+		policy.Grace.Describe(corev1.Namespace{}) != "5 business days (Mon,Tue,Wed,Thu,Fri)" || len(policy.Exemptions) != 1 {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+	if policy, err := auditPolicy(auditor.NamespaceAuditPolicy{Name: "all"}, auditor.DefaultWorkWeek); err != nil || !policy.Selector.Empty() {
+		t.Errorf("A policy without a selector should select all namespaces: %+v / %v", policy, err)
+	}
+
+	for field, spec := range map[string]auditor.NamespaceAuditPolicySpec{
+		"namespaceSelector":        {NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Near"}}}},
+		"allowedDomains":           {AllowedDomains: []string{"not a domain"}},
+		"gracePeriod":              {GracePeriod: "90d"},
+		"exemptions":               {Exemptions: []string{"/[/"}},
+		"notifications.contact":    {Notifications: auditor.PolicyNotifications{Contact: "lead"}},
+		"notifications.recipients": {Notifications: auditor.PolicyNotifications{Recipients: []string{"sre@"}}},
+	} {
+		if _, err := auditPolicy(auditor.NamespaceAuditPolicy{Name: "bad", Spec: spec}, auditor.DefaultWorkWeek); err == nil || !strings.HasPrefix(err.Error(), field+":") {
+			t.Errorf("Expected %s error, got %v", field, err)
+		}
+	}
+}
+
+// TestConfigEvaluationCache validates that cached evaluations need a place to persist
+func TestConfigEvaluationCache(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("EVALUATION_CACHE_TTL", "24h")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "requires STATE_NAMESPACE") {
+		t.Errorf("Expected STATE_NAMESPACE error, got %v", err)
+	}
+
+	t.Setenv("STATE_NAMESPACE", "auditor")
+	cfg, err := loadConfig()
+	if err != nil || cfg.evaluationCacheTTL != 24*time.Hour {
+		t.Fatalf("Unexpected evaluation cache TTL: %v / %v", cfg, err)
+	}
+}
+
+// TestConfigOwnerAllowlist validates loading the owner allowlist
+func TestConfigOwnerAllowlist(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("OWNER_ALLOWLIST", "mlops-svc@company.com, Pipeline-Bot@partner.org")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := []string{"mlops-svc@company.com", "pipeline-bot@partner.org"}; !equalStringSlices(cfg.ownerAllowlist, want) {
+		t.Errorf("Allowlist = %v, want %v", cfg.ownerAllowlist, want)
+	}
+	if got := cfg.snapshot(false, false).OwnerAllowlist; !equalStringSlices(got, cfg.ownerAllowlist) {
+		t.Errorf("Snapshot allowlist = %v", got)
+	}
+
+	t.Setenv("OWNER_ALLOWLIST", "mlops-svc")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "OWNER_ALLOWLIST") {
+		t.Errorf("Expected OWNER_ALLOWLIST error, got %v", err)
+	}
+}
+
+// TestConfigLinkSuffixes validates loading companion namespace suffixes
+func TestConfigLinkSuffixes(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("LINK_SUFFIXES", "-serving, -pipelines")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := []string{"-serving", "-pipelines"}; !equalStringSlices(cfg.linkSuffixes, want) {
+		t.Errorf("Suffixes = %v, want %v", cfg.linkSuffixes, want)
+	}
+	if got := cfg.snapshot(false, false).LinkSuffixes; !equalStringSlices(got, cfg.linkSuffixes) {
+		t.Errorf("Snapshot suffixes = %v", got)
+	}
+
+	t.Setenv("LINK_SUFFIXES", "-Serving")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "LINK_SUFFIXES") {
+		t.Errorf("Expected LINK_SUFFIXES error, got %v", err)
+	}
+}
+
+// TestConfigGraceOverrideMax validates the maximum grace period override
+func TestConfigGraceOverrideMax(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("GRACE_PERIOD_OVERRIDE_MAX", "2160h")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := cfg.snapshot(false, false).GraceOverrideMax; got != "2160h0m0s" {
+		t.Errorf("Snapshot maximum = %q", got)
+	}
+
+	t.Setenv("GRACE_PERIOD_OVERRIDE_MAX", "90d")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "GRACE_PERIOD_OVERRIDE_MAX") {
+		t.Errorf("Expected GRACE_PERIOD_OVERRIDE_MAX error, got %v", err)
+	}
+}
+
+// TestConfigSlowStart validates the slow-start ramp setting
+func TestConfigSlowStart(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("SLOW_START", "10, 25%,50")
+	t.Setenv("STATE_NAMESPACE", "auditor")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := []int{10, 25, 50}; !reflect.DeepEqual(cfg.slowStart, want) {
+		t.Errorf("Slow start = %v, want %v", cfg.slowStart, want)
+	}
+
+	t.Setenv("STATE_NAMESPACE", "")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "SLOW_START: requires STATE_NAMESPACE") {
+		t.Errorf("Expected STATE_NAMESPACE error, got %v", err)
+	}
+}
+
+// TestConfigRunSlices validates the sliced-run settings
+func TestConfigRunSlices(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("RUN_SLICES", "4")
+	t.Setenv("SLICE_PERIOD", "12h")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.runSlices != 4 || cfg.sliceBy != auditor.SliceByTime || cfg.slicePeriod != 12*time.Hour {
+		t.Errorf("Slices = %d by %q over %s", cfg.runSlices, cfg.sliceBy, cfg.slicePeriod)
+	}
+	if got := cfg.snapshot(false, false); got.RunSlices != 4 || got.SliceBy != "time over 12h0m0s" {
+		t.Errorf("Snapshot = %d, %q", got.RunSlices, got.SliceBy)
+	}
+
+	t.Setenv("SLICE_BY", "runs")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "SLICE_BY: runs requires STATE_NAMESPACE") {
+		t.Errorf("Expected STATE_NAMESPACE error, got %v", err)
+	}
+
+	t.Setenv("RUN_SLICES", "0")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "RUN_SLICES") {
+		t.Errorf("Expected RUN_SLICES error, got %v", err)
+	}
+}
+
+// TestConfigOwnerList validates loading the owner list file
+func TestConfigOwnerList(t *testing.T) {
+	setValidConfigEnv(t)
+	path := filepath.Join(t.TempDir(), "owners.yaml")
+	if err := os.WriteFile(path, []byte("valid: [shared@company.com]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OWNER_LIST_FILE", path)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := cfg.ownerList.Verdict("shared@company.com"); got != auditor.OwnerAlwaysValid {
+		t.Errorf("Verdict = %q, want %q", got, auditor.OwnerAlwaysValid)
+	}
+	if got := cfg.snapshot(false, false).OwnerListFile; got != path {
+		t.Errorf("Snapshot owner list file = %q, want %q", got, path)
+	}
+
+	t.Setenv("OWNER_LIST_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "OWNER_LIST_FILE") {
+		t.Errorf("Expected OWNER_LIST_FILE error, got %v", err)
+	}
+}
+
+// TestConfigRetention validates the retention settings
+func TestConfigRetention(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("STATE_NAMESPACE", "auditor")
+	t.Setenv("REPORT_RETENTION", "720h")
+	t.Setenv("INSTANCE_RETENTION", "2160h")
+	t.Setenv("EVALUATION_RETENTION", "168h")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := auditor.RetentionPolicy{Reports: 720 * time.Hour, Instances: 2160 * time.Hour, Evaluations: 168 * time.Hour}
+	if cfg.retention != want {
+		t.Errorf("Retention = %+v, want %+v", cfg.retention, want)
+	}
+
+	t.Setenv("STATE_NAMESPACE", "")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "INSTANCE_RETENTION") {
+		t.Errorf("Expected INSTANCE_RETENTION error without STATE_NAMESPACE, got %v", err)
+	}
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "EVALUATION_RETENTION") {
+		t.Errorf("Expected EVALUATION_RETENTION error without STATE_NAMESPACE, got %v", err)
+	}
+
+	t.Setenv("REPORT_RETENTION", "a month")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "REPORT_RETENTION") {
+		t.Errorf("Expected REPORT_RETENTION error, got %v", err)
+	}
+}
+
+// TestConfigOwnerSource validates the owner source setting
+func TestConfigOwnerSource(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.ownerSource != auditor.OwnerSourceAnnotation {
+		t.Errorf("Default owner source = %q, want %q", cfg.ownerSource, auditor.OwnerSourceAnnotation)
+	}
+
+	t.Setenv("OWNER_SOURCE", "profile")
+	cfg, err = loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := cfg.snapshot(false, false).OwnerSource; got != "profile" {
+		t.Errorf("Snapshot owner source = %q, want profile", got)
+	}
+
+	t.Setenv("OWNER_SOURCE", "label")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "OWNER_SOURCE") {
+		t.Errorf("Expected OWNER_SOURCE error, got %v", err)
+	}
+}
+
+// TestConfigSummaryConfigMap validates the run summary ConfigMap reference
+func TestConfigSummaryConfigMap(t *testing.T) {
+	setValidConfigEnv(t)
+	for value, want := range map[string][2]string{
+		"":                           {"", ""},
+		"audit-summary":              {"default", "audit-summary"},
+		"monitoring/audit.summary-1": {"monitoring", "audit.summary-1"},
+	} {
+		t.Setenv("SUMMARY_CONFIGMAP", value)
+		cfg, err := loadConfig()
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", value, err)
+		}
+		if got := [2]string{cfg.summaryNamespace, cfg.summaryConfigMap}; got != want {
+			t.Errorf("%q: got %v, want %v", value, got, want)
+		}
+	}
+
+	for _, value := range []string{"Summary", "monitoring/", "a/b/c"} {
+		t.Setenv("SUMMARY_CONFIGMAP", value)
+		if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "SUMMARY_CONFIGMAP") {
+			t.Errorf("%q: expected SUMMARY_CONFIGMAP error, got %v", value, err)
+		}
+	}
+}
+
+// TestConfigMalformedMarker validates the malformed-marker settings
+func TestConfigMalformedMarker(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.malformedAction != auditor.MalformedMarkerReset {
+		t.Errorf("Default action = %q, want %q", cfg.malformedAction, auditor.MalformedMarkerReset)
+	}
+
+	t.Setenv("MALFORMED_MARKER_MAX_AGE", "168h")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "MALFORMED_MARKER_MAX_AGE") {
+		t.Errorf("Expected MALFORMED_MARKER_MAX_AGE error without the expire action, got %v", err)
+	}
+
+	t.Setenv("MALFORMED_MARKER_ACTION", "expire")
+	cfg, err = loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	snapshot := cfg.snapshot(false, false)
+	if snapshot.MalformedAction != "expire" || snapshot.MalformedMaxAge != "168h0m0s" {
+		t.Errorf("Snapshot = %q, %q", snapshot.MalformedAction, snapshot.MalformedMaxAge)
+	}
+
+	t.Setenv("MALFORMED_MARKER_ACTION", "delete")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "MALFORMED_MARKER_ACTION") {
+		t.Errorf("Expected MALFORMED_MARKER_ACTION error, got %v", err)
+	}
+}
+
+// TestConfigContextKeys validates the namespace context keys setting
+func TestConfigContextKeys(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("CONTEXT_KEYS", "cost-center, example.com/project-code")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := []string{"cost-center", "example.com/project-code"}; !equalStringSlices(cfg.contextKeys, want) {
+		t.Errorf("Context keys = %v, want %v", cfg.contextKeys, want)
+	}
+
+	t.Setenv("CONTEXT_KEYS", "cost center")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "CONTEXT_KEYS") {
+		t.Errorf("Expected CONTEXT_KEYS error, got %v", err)
+	}
+}
+
+// TestConfigSensitivePolicies validates loading security contacts and
+// approval requirements by data classification
+func TestConfigSensitivePolicies(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("SECURITY_CONTACTS", "protected-b=security@company.com")
+	t.Setenv("APPROVAL_CLASSIFICATIONS", "protected-b")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := auditor.SensitivePolicy{Contact: "security@company.com", RequireApproval: true}
+	if cfg.classificationLabel != auditor.DefaultClassificationLabel || cfg.sensitivePolicies["protected-b"] != want {
+		t.Errorf("Unexpected policies %q: %+v", cfg.classificationLabel, cfg.sensitivePolicies)
+	}
+
+	t.Setenv("SECURITY_CONTACTS", "protected-b")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "SECURITY_CONTACTS") {
+		t.Errorf("Expected SECURITY_CONTACTS error, got %v", err)
+	}
+}
+
+// TestConfigTwoPersonThreshold validates the storage threshold for two-person approval
+func TestConfigTwoPersonThreshold(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil || !cfg.twoPersonThreshold.IsZero() {
+		t.Fatalf("Two-person rule should be disabled by default: %v / %v", cfg, err)
+	}
+
+	t.Setenv("TWO_PERSON_STORAGE_THRESHOLD", "500Gi")
+	if cfg, err = loadConfig(); err != nil || cfg.twoPersonThreshold.String() != "500Gi" {
+		t.Errorf("Unexpected threshold: %v / %v", cfg, err)
+	}
+
+	t.Setenv("TWO_PERSON_STORAGE_THRESHOLD", "lots")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "TWO_PERSON_STORAGE_THRESHOLD") {
+		t.Errorf("Expected TWO_PERSON_STORAGE_THRESHOLD error, got %v", err)
+	}
+}
+
+// TestConfigCanary validates the synthetic canary settings
+func TestConfigCanary(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil || cfg.canary != nil {
+		t.Fatalf("Canary should be disabled by default: %v / %v", cfg, err)
+	}
+
+	t.Setenv("CANARY_NAMESPACE", "auditor-canary")
+	if cfg, err = loadConfig(); err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	want := auditor.Canary{Namespace: "auditor-canary", Owner: "namespace-auditor-canary@" + cfg.allowedDomains[0], GracePeriod: auditor.DefaultCanaryGracePeriod}
+	if *cfg.canary != want {
+		t.Errorf("Canary = %+v, want %+v", *cfg.canary, want)
+	}
+
+	t.Setenv("CANARY_OWNER", "canary@elsewhere.org")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "CANARY_OWNER") {
+		t.Errorf("Expected CANARY_OWNER error, got %v", err)
+	}
+
+	t.Setenv("CANARY_OWNER", "")
+	t.Setenv("CANARY_GRACE_PERIOD", "0s")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "CANARY_GRACE_PERIOD") {
+		t.Errorf("Expected CANARY_GRACE_PERIOD error, got %v", err)
+	}
+
+	t.Setenv("CANARY_NAMESPACE", "Not_A_Namespace")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "CANARY_NAMESPACE") {
+		t.Errorf("Expected CANARY_NAMESPACE error, got %v", err)
+	}
+}
+
+// TestPublishStatusDryRun validates that dry runs leave the AuditorStatus untouched
+func TestPublishStatusDryRun(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{auditor.AuditorStatusResource: "AuditorStatusList"})
+	report := auditor.NewRunReport("run-1", "abc")
+
+	publishStatus(client, report, nil, true)
+	if len(client.Actions()) != 0 {
+		t.Errorf("Dry run should not touch AuditorStatus, got %v", client.Actions())
+	}
 
-	// Verify grace period parsing
-	if cfg.gracePeriod != 24*time.Hour {
-		t.Errorf("Grace period mismatch:\nExpected: 24h\nActual: %v", cfg.gracePeriod)
+	publishStatus(client, report, nil, false)
+	if _, err := client.Resource(auditor.AuditorStatusResource).Get(context.TODO(), auditor.AuditorStatusName, metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected AuditorStatus to be created: %v", err)
 	}
+}
 
-	// Verify domain parsing
-	expectedDomains := []string{"company.com", "example.com"}
-	if !equalStringSlices(cfg.allowedDomains, expectedDomains) {
-		t.Errorf("Domain parsing error:\nExpected: %v\nActual: %v",
-			expectedDomains, cfg.allowedDomains)
+// TestCheckWritePermissionsReadOnly validates that a read-only run reports
+// the write permissions its planned changes need
+func TestCheckWritePermissionsReadOnly(t *testing.T) {
+	p := auditor.NewNamespaceProcessor(
+		fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "orphaned",
+			Labels:      map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+			Annotations: map[string]string{auditor.OwnerAnnotation: "gone@company.com"},
+		}}),
+		&mockAzureClient{},
+		auditor.WithGracePeriod(time.Hour*24),
+		auditor.WithAllowedDomains([]string{"company.com"}),
+		auditor.WithDryRun(true),
+	)
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	report := processNamespaces(p, kubeflowLabel, nil, false)
+	if len(report.PlannedChanges) == 0 {
+		t.Fatal("Expected the marker update to be planned")
+	}
+
+	checks := checkWritePermissions(p.GetClient(), report.PlannedChanges)
+	if len(checks) != 1 || checks[0].String() != "update namespaces" || checks[0].Allowed {
+		t.Errorf("Checks = %+v, want a denied update namespaces", checks)
+	}
+	if !strings.Contains(logs.String(), "requires permission to update namespaces") {
+		t.Errorf("Missing permission not logged: %s", logs.String())
+	}
+}
+
+// TestParseDeleteNowArgs validates the arguments of the delete-now command
+func TestParseDeleteNowArgs(t *testing.T) {
+	name, reason, err := parseDeleteNowArgs([]string{"--reason", "incident 42", "team-a"})
+	if err != nil || name != "team-a" || reason != "incident 42" {
+		t.Errorf("Got %q, %q, %v", name, reason, err)
+	}
+
+	for _, args := range [][]string{
+		{"team-a"},
+		{"--reason", " ", "team-a"},
+		{"--reason", "incident"},
+		{"--reason", "incident", "team-a", "team-b"},
+	} {
+		if _, _, err := parseDeleteNowArgs(args); err == nil {
+			t.Errorf("Expected an error for %q", args)
+		}
+	}
+}
+
+// breakGlassClient returns a fake client authenticating requests as user
+// and allowing only the given verbs on namespaces
+func breakGlassClient(user string, allowed map[string]bool, objects ...runtime.Object) *fake.Clientset {
+	client := fake.NewSimpleClientset(objects...)
+	client.PrependReactor("create", "selfsubjectreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		review := &authenticationv1.SelfSubjectReview{}
+		review.Status.UserInfo.Username = user
+		return true, review, nil
+	})
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = allowed[review.Spec.ResourceAttributes.Verb]
+		return true, review, nil
+	})
+	return client
+}
+
+// TestDeleteNow validates the checks and audit record of a break-glass deletion
+func TestDeleteNow(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"}
+	owner := map[string]string{auditor.OwnerAnnotation: "user@company.com"}
+	namespaces := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: labels, Annotations: owner}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", Annotations: owner}},
+	}
+	allowed := map[string]bool{"update": true, "delete": true}
+
+	tests := []struct {
+		name      string
+		namespace string
+		user      string
+		allowed   map[string]bool
+		wantErr   bool
+	}{
+		{name: "allowed", namespace: "team-a", user: "admin", allowed: allowed},
+		{name: "outside audit scope", namespace: "kube-system", user: "admin", allowed: allowed, wantErr: true},
+		{name: "unauthenticated", namespace: "team-a", allowed: allowed, wantErr: true},
+		{name: "not allowed to delete", namespace: "team-a", user: "admin", allowed: map[string]bool{"update": true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := breakGlassClient(tt.user, tt.allowed, namespaces...)
+			p := auditor.NewNamespaceProcessor(client, &mockAzureClient{}, auditor.WithGracePeriod(time.Hour*24), auditor.WithAllowedDomains([]string{"company.com"}))
+
+			var logs strings.Builder
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			report, err := deleteNow(context.TODO(), p, client, kubeflowLabel, tt.namespace, "incident 42", false)
+
+			_, getErr := client.CoreV1().Namespaces().Get(context.TODO(), tt.namespace, metav1.GetOptions{})
+			if tt.wantErr {
+				if err == nil || report != nil {
+					t.Errorf("Expected the deletion to be refused, got %+v", report)
+				}
+				if getErr != nil {
+					t.Errorf("Refused deletion must leave the namespace: %v", getErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !apierrors.IsNotFound(getErr) {
+				t.Errorf("Namespace should be deleted, got %v", getErr)
+			}
+			if report.BreakGlass == nil || report.BreakGlass.RequestedBy != "admin" || report.BreakGlass.Reason != "incident 42" {
+				t.Errorf("Unexpected audit record %+v", report.BreakGlass)
+			}
+			if len(report.Traces) != 1 || report.Traces[0].Action != auditor.ActionDelete {
+				t.Errorf("Unexpected traces %+v", report.Traces)
+			}
+		})
+	}
+}
+
+// TestDeleteNowDryRun ensures a dry run reports missing permissions and
+// leaves the namespace in place
+func TestDeleteNowDryRun(t *testing.T) {
+	client := breakGlassClient("admin", nil, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "team-a",
+		Labels: map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+	}})
+	p := auditor.NewNamespaceProcessor(client, &mockAzureClient{}, auditor.WithGracePeriod(time.Hour*24), auditor.WithAllowedDomains([]string{"company.com"}), auditor.WithDryRun(true))
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	report, err := deleteNow(context.TODO(), p, client, kubeflowLabel, "team-a", "incident 42", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !report.BreakGlass.DryRun || len(report.PlannedChanges) == 0 {
+		t.Errorf("Expected a planned break-glass deletion, got %+v", report)
+	}
+	if !strings.Contains(logs.String(), "requires permission to delete namespaces") {
+		t.Errorf("Missing permission not logged: %s", logs.String())
+	}
+	if _, err := client.CoreV1().Namespaces().Get(context.TODO(), "team-a", metav1.GetOptions{}); err != nil {
+		t.Errorf("Dry run must not delete: %v", err)
+	}
+}
+
+// TestParseImpersonation validates the --as and --as-group flags
+func TestParseImpersonation(t *testing.T) {
+	impersonation, err := parseImpersonation("system:serviceaccount:auditor:scoped", "auditors, cleanup")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if impersonation.UserName != "system:serviceaccount:auditor:scoped" || !equalStringSlices(impersonation.Groups, []string{"auditors", "cleanup"}) {
+		t.Errorf("Unexpected impersonation: %+v", impersonation)
+	}
+	if got := describeImpersonation(impersonation); got != "system:serviceaccount:auditor:scoped (groups auditors, cleanup)" {
+		t.Errorf("describeImpersonation = %q", got)
+	}
+
+	if _, err := parseImpersonation("", "auditors"); err == nil {
+		t.Error("Expected an error for groups without a user")
+	}
+}
+
+// TestUserAgent validates that API requests identify the build and run
+func TestUserAgent(t *testing.T) {
+	want := "namespace-auditor/dev (commit unknown; run 20240101T000000Z-1a2b3c)"
+	if got := userAgent("20240101T000000Z-1a2b3c"); got != want {
+		t.Errorf("userAgent = %q, want %q", got, want)
+	}
+}
+
+// TestStateRoundTrip validates that throttle state survives between runs
+// and that dry runs do not save it
+func TestStateRoundTrip(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	store := auditor.NewConfigMapStateStore(client, "auditor", auditor.DefaultStateConfigMap)
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	backoff := auditor.ThrottleState{LastThrottled: until.Add(-time.Minute), Backoff: time.Minute, Until: until}
+	if err := store.Save(context.TODO(), auditor.State{Throttle: backoff}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	p := auditor.NewNamespaceProcessor(client, &mockAzureClient{}, auditor.WithGracePeriod(time.Hour), auditor.WithAllowedDomains([]string{"example.com"}))
+	state := restoreState(store, p)
+	if got := p.ThrottleState(); !got.Until.Equal(until) {
+		t.Errorf("Restored backoff until %s, want %s", got.Until, until)
+	}
+
+	if err := store.Save(context.TODO(), auditor.State{}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saveState(store, p, state, true)
+	if state, _ := store.Load(context.TODO()); !state.Throttle.Until.IsZero() {
+		t.Errorf("Dry run should not save state, got %+v", state)
+	}
+	saveState(store, p, state, false)
+	if state, _ := store.Load(context.TODO()); !state.Throttle.Until.Equal(until) {
+		t.Errorf("Expected backoff saved, got %+v", state)
+	}
+}
+
+// TestConfigReportSinks validates report sink configuration
+func TestConfigReportSinks(t *testing.T) {
+	setValidConfigEnv(t)
+
+	cfg, err := loadConfig()
+	if err != nil || len(cfg.reportSinks) != 1 || cfg.reportSinks[0].String() != "stdout" {
+		t.Fatalf("Expected stdout sink by default, got %v, %v", cfg.reportSinks, err)
+	}
+
+	t.Setenv("REPORT_SINKS", "stdout,ftp://reports")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "REPORT_SINKS") {
+		t.Errorf("Expected REPORT_SINKS error, got %v", err)
+	}
+}
+
+// failingSink always fails to write reports
+type failingSink struct{}
+
+func (failingSink) WriteReport(context.Context, *auditor.RunReport) error {
+	return fmt.Errorf("unreachable")
+}
+func (failingSink) String() string { return "failing" }
+
+// TestWriteReport validates that a failing sink does not stop the others
+func TestWriteReport(t *testing.T) {
+	var buf strings.Builder
+	sinks := []auditor.ReportSink{failingSink{}, auditor.WriterSink{Name: "buffer", W: &buf}}
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	writeReport(sinks, auditor.NewRunReport("run-1", "abc"))
+
+	if !strings.Contains(buf.String(), `"runId": "run-1"`) {
+		t.Errorf("Report not written to second sink: %q", buf.String())
+	}
+	if !strings.Contains(logs.String(), "Failed to write run report to failing") {
+		t.Errorf("Sink failure not logged: %q", logs.String())
+	}
+}
+
+// TestRecordInstance validates drift detection between deployments
+func TestRecordInstance(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.instance != defaultInstance {
+		t.Errorf("Instance = %q, want %q", cfg.instance, defaultInstance)
+	}
+
+	other := *cfg.snapshot(false, false)
+	other.GracePeriod = "1h0m0s"
+	state := auditor.State{Instances: map[string]auditor.InstanceConfig{
+		"controller": {ConfigHash: "other", SeenAt: time.Now(), Config: other},
+	}}
+	report := auditor.NewRunReport("run-1", cfg.hash())
+	report.Config = cfg.snapshot(false, false)
+
+	drift := recordInstance(&state, cfg.instance, report)
+	if len(drift) != 1 || drift[0].Instance != "controller" || drift[0].Setting != "gracePeriod" {
+		t.Errorf("Unexpected drift: %+v", drift)
+	}
+	if own, ok := state.Instances[defaultInstance]; !ok || own.ConfigHash != cfg.hash() {
+		t.Errorf("Own configuration not recorded: %+v", state.Instances)
+	}
+
+	t.Setenv("AUDITOR_INSTANCE", "bad name")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "AUDITOR_INSTANCE") {
+		t.Errorf("Expected AUDITOR_INSTANCE error, got %v", err)
+	}
+}
+
+// TestParseClockSkew validates clock-skew tolerance parsing
+func TestParseClockSkew(t *testing.T) {
+	if d, err := parseClockSkew(""); err != nil || d != defaultClockSkew {
+		t.Errorf("Expected default %s, got %s (%v)", defaultClockSkew, d, err)
+	}
+	if d, err := parseClockSkew("0s"); err != nil || d != 0 {
+		t.Errorf("Expected zero tolerance to be allowed, got %s (%v)", d, err)
 	}
+	for _, value := range []string{"-1m", "five minutes"} {
+		if _, err := parseClockSkew(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+// TestConfigDefaultSelector ensures the Kubeflow selector is used by default
+func TestConfigDefaultSelector(t *testing.T) {
+	setValidConfigEnv(t)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.labelSelector != kubeflowLabel {
+		t.Errorf("Selector mismatch:\nExpected: %s\nActual: %s", kubeflowLabel, cfg.labelSelector)
+	}
+}
+
+// TestConfigHash validates the configuration fingerprint
+func TestConfigHash(t *testing.T) {
+	base := config{
+		gracePeriod:       24 * time.Hour,
+		allowedDomains:    []string{"company.com"},
+		azureTenantID:     "tenant",
+		azureClientID:     "client",
+		azureClientSecret: "secret",
+		labelSelector:     kubeflowLabel,
+	}
+
+	changedSecret := base
+	changedSecret.azureClientSecret = "rotated"
+	if base.hash() != changedSecret.hash() {
+		t.Error("Secret rotation should not change the config hash")
+	}
+
+	for name, change := range map[string]func(*config){
+		"grace period":          func(c *config) { c.gracePeriod = 48 * time.Hour },
+		"allowed domains":       func(c *config) { c.allowedDomains = []string{"company.com", "partner.org"} },
+		"label selector":        func(c *config) { c.labelSelector = "team=data" },
+		"tenant":                func(c *config) { c.azureTenantID = "other-tenant" },
+		"client":                func(c *config) { c.azureClientID = "other-client" },
+		"clock skew":            func(c *config) { c.clockSkew = time.Minute },
+		"business days":         func(c *config) { c.graceBusinessDays = 10 },
+		"work week":             func(c *config) { c.workWeek, _ = auditor.ParseWorkWeek("sun-thu") },
+		"pause windows":         func(c *config) { c.pauseWindows, _ = auditor.ParsePauseWindows("2024-12-24/2025-01-02") },
+		"expiry action":         func(c *config) { c.expiryAction = auditor.ExpiryCordon },
+		"invalid domain policy": func(c *config) { c.invalidDomainPolicy = auditor.OwnerPolicyExpire },
+		"invalid domain grace":  func(c *config) { c.invalidDomainGrace = time.Hour },
+		"ownerless policy":      func(c *config) { c.ownerlessPolicy = auditor.OwnerPolicyExpire },
+		"ownerless grace":       func(c *config) { c.ownerlessGrace = time.Hour },
+		"pre-delete finalizer":  func(c *config) { c.preDeleteFinalizer = true },
+		"pre-delete timeout":    func(c *config) { c.preDeleteTimeout = time.Hour },
+		"flap damping":          func(c *config) { c.flapDamping = 3 },
+		"identity provider":     func(c *config) { c.identityProvider = "okta" },
+		"owner allowlist":       func(c *config) { c.ownerAllowlist = []string{"bot@company.com"} },
+		"disabled user policy":  func(c *config) { c.disabledUserPolicy = auditor.OwnerPolicyExpire },
+		"disabled user grace":   func(c *config) { c.disabledUserGrace = time.Hour },
+		"deleted user grace":    func(c *config) { c.deletedUserGrace = time.Hour },
+		"context keys":          func(c *config) { c.contextKeys = []string{"team"} },
+		"missing confirmations": func(c *config) { c.missingConfirmations = 2 },
+		"owner list file":       func(c *config) { c.ownerListFile = "/etc/owners.csv" },
+		"grace by domain": func(c *config) {
+			c.graceByDomain = map[string]auditor.GracePeriodStrategy{"partner.org": auditor.FixedGracePeriod(time.Hour)}
+		},
+		"link suffixes":           func(c *config) { c.linkSuffixes = []string{"-serving"} },
+		"grace override maximum":  func(c *config) { c.graceOverrideMax = time.Hour },
+		"malformed marker action": func(c *config) { c.malformedAction = auditor.MalformedMarkerExpire },
+		"malformed marker age":    func(c *config) { c.malformedAction, c.malformedMaxAge = auditor.MalformedMarkerExpire, time.Hour },
+		"run slices":              func(c *config) { c.runSlices = 4 },
+		"orphan owner":            func(c *config) { c.orphanOwner = "platform@example.com" },
+		"orphan period":           func(c *config) { c.orphanPeriod = time.Hour },
+		"quarantine period":       func(c *config) { c.quarantinePeriod = time.Hour },
+		"exclude list": func(c *config) {
+			exclude, err := auditor.ParseNamePatterns("kube-*")
+			if err != nil {
+				t.Fatalf("ParseNamePatterns: %v", err)
+			}
+			c.namespaceExclude = exclude
+		},
+		"audit policies": func(c *config) { c.auditPolicies = true },
+	} {
+		changed := base
+		change(&changed)
+		if base.hash() == changed.hash() {
+			t.Errorf("Changing the %s should change the config hash", name)
+		}
+	}
+}
+
+// setValidConfigEnv sets a complete, valid configuration environment
+func setValidConfigEnv(t *testing.T) {
+	t.Setenv("GRACE_PERIOD", "24h")
+	t.Setenv("ALLOWED_DOMAINS", "company.com")
+	t.Setenv("AZURE_TENANT_ID", "test-tenant")
+	t.Setenv("AZURE_CLIENT_ID", "test-client")
+	t.Setenv("AZURE_CLIENT_SECRET", "test-secret")
+	t.Setenv("IDENTITY_PROVIDER", "")
+	t.Setenv("NAMESPACE_SELECTOR", "")
+	t.Setenv("CLOCK_SKEW_TOLERANCE", "")
+	t.Setenv("WORK_WEEK", "")
+	t.Setenv("PAUSE_WINDOWS", "")
+	t.Setenv("EXPIRY_ACTION", "")
+	t.Setenv("INVALID_DOMAIN_POLICY", "")
+	t.Setenv("INVALID_DOMAIN_GRACE_PERIOD", "")
+	t.Setenv("OWNERLESS_POLICY", "")
+	t.Setenv("OWNERLESS_GRACE_PERIOD", "")
+	t.Setenv("DISABLED_USER_POLICY", "")
+	t.Setenv("DISABLED_USER_GRACE_PERIOD", "")
+	t.Setenv("DELETED_USER_GRACE_PERIOD", "")
+	t.Setenv("PREFETCH_CONCURRENCY", "")
+	t.Setenv("IDENTITY_RATE_LIMIT", "")
+	t.Setenv("IDENTITY_LOOKUP_BUDGET", "")
+	t.Setenv("FLAP_DAMPING_RUNS", "")
+	t.Setenv("EVALUATION_CACHE_TTL", "")
+	t.Setenv("PRE_DELETE_FINALIZER", "")
+	t.Setenv("PRE_DELETE_TIMEOUT", "")
+	t.Setenv("DELETION_PROPAGATION", "")
+	t.Setenv("DELETION_WAIT_TIMEOUT", "")
+	t.Setenv("STUCK_TERMINATING_THRESHOLD", "")
+	t.Setenv("PUBLISH_STATUS", "")
+	t.Setenv("STATE_NAMESPACE", "")
+	t.Setenv("REPORT_SINKS", "")
+	t.Setenv("AUDITOR_INSTANCE", "")
+	t.Setenv("OWNER_ALLOWLIST", "")
+	t.Setenv("CLASSIFICATION_LABEL", "")
+	t.Setenv("SECURITY_CONTACTS", "")
+	t.Setenv("APPROVAL_CLASSIFICATIONS", "")
 }
 
 // equalStringSlices compares two string slices for equality
@@ -173,3 +1654,497 @@ func equalStringSlices(a, b []string) bool {
 	}
 	return true
 }
+
+// TestExplainNamespace validates the explain subcommand output
+func TestExplainNamespace(t *testing.T) {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "explain-me",
+			Annotations: map[string]string{
+				auditor.OwnerAnnotation: "gone@company.com",
+			},
+		},
+	}
+	k8sClient := fake.NewSimpleClientset(ns)
+	processor := auditor.NewNamespaceProcessor(
+		k8sClient,
+		&mockAzureClient{validUsers: map[string]bool{}},
+		auditor.WithGracePeriod(time.Hour*24),
+		auditor.WithAllowedDomains([]string{"company.com"}),
+	)
+
+	var out strings.Builder
+	if err := explainNamespace(context.Background(), processor, "explain-me", &out); err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	for _, want := range []string{"Namespace: explain-me", "[identity]", "Action: mark"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Explain output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if err := explainNamespace(context.Background(), processor, "does-not-exist", &out); err == nil {
+		t.Error("Expected error for unknown namespace")
+	}
+}
+
+// TestProcessNamespacesTraceDecisions validates trace collection in the run report
+func TestProcessNamespacesTraceDecisions(t *testing.T) {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "traced",
+			Labels:      map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+			Annotations: map[string]string{auditor.OwnerAnnotation: "user@company.com"},
+		},
+	}
+	processor := auditor.NewNamespaceProcessor(
+		fake.NewSimpleClientset(ns),
+		&mockAzureClient{validUsers: map[string]bool{"user@company.com": true}},
+		auditor.WithGracePeriod(time.Hour*24),
+		auditor.WithAllowedDomains([]string{"company.com"}),
+	)
+
+	report := processNamespaces(processor, kubeflowLabel, nil, false)
+	if report.Namespaces != 1 || len(report.Traces) != 0 {
+		t.Errorf("Untraced run should record no traces: %+v", report)
+	}
+
+	if report.APIUsage["list"].Count != 1 {
+		t.Errorf("Run report should record the namespace list call: %+v", report.APIUsage)
+	}
+
+	report = processNamespaces(processor, kubeflowLabel, nil, true)
+	if len(report.Traces) != 1 || report.Traces[0].Namespace != "traced" {
+		t.Fatalf("Traced run should record one trace: %+v", report.Traces)
+	}
+	if report.Traces[0].Action != auditor.ActionNone {
+		t.Errorf("Unexpected action: %q", report.Traces[0].Action)
+	}
+}
+
+// TestProcessNamespacesOwnerless validates the ownerless section of the run report
+func TestProcessNamespacesOwnerless(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"}
+	processor := auditor.NewNamespaceProcessor(
+		fake.NewSimpleClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "orphan", Labels: labels}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "owned",
+				Labels:      labels,
+				Annotations: map[string]string{auditor.OwnerAnnotation: "user@company.com"},
+			}},
+		),
+		&mockAzureClient{validUsers: map[string]bool{"user@company.com": true}},
+		auditor.WithGracePeriod(time.Hour*24),
+		auditor.WithAllowedDomains([]string{"company.com"}),
+	)
+
+	report := processNamespaces(processor, kubeflowLabel, nil, false)
+	if len(report.Ownerless) != 1 || report.Ownerless[0] != "orphan" {
+		t.Errorf("Expected only orphan to be reported as ownerless: %v", report.Ownerless)
+	}
+}
+
+// TestProcessNamespacesByName validates restricting a run to named namespaces
+func TestProcessNamespacesByName(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"}
+	owner := map[string]string{auditor.OwnerAnnotation: "gone@company.com"}
+	processor := auditor.NewNamespaceProcessor(
+		fake.NewSimpleClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "target", Labels: labels, Annotations: owner}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: labels, Annotations: owner}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", Annotations: owner}},
+		),
+		&mockAzureClient{validUsers: map[string]bool{}},
+		auditor.WithGracePeriod(time.Hour*24),
+		auditor.WithAllowedDomains([]string{"company.com"}),
+		auditor.WithDryRun(true),
+	)
+
+	report := processNamespaces(processor, kubeflowLabel, parseNamespaceNames(" target, kube-system ,"), true)
+	if report.Namespaces != 1 || report.Traces[0].Namespace != "target" {
+		t.Errorf("Only the matching named namespace should be processed: %+v", report.Traces)
+	}
+	if report.APIUsage["list"].Count != 0 || report.APIUsage["get"].Count != 2 {
+		t.Errorf("Named namespaces should be fetched individually: %+v", report.APIUsage)
+	}
+}
+
+// TestAbortExitCode validates exit codes for aborted runs
+func TestAbortExitCode(t *testing.T) {
+	tests := map[error]int{
+		&azure.PermissionError{StatusCode: 403}:                exitPermissionDenied,
+		fmt.Errorf("token: %w", errs.ErrAuth):                  exitAuthFailed,
+		fmt.Errorf("%w: 3 of 10", auditor.ErrMissingOwnerRate): exitMissingOwners,
+		fmt.Errorf("unexpected: %w", errs.ErrThrottled):        1,
+	}
+	for err, want := range tests {
+		if got := abortExitCode(err); got != want {
+			t.Errorf("abortExitCode(%v) = %d, want %d", err, got, want)
+		}
+	}
+}
+
+// forbiddenAzureClient rejects every lookup as Graph does without User.Read.All
+type forbiddenAzureClient struct{}
+
+func (forbiddenAzureClient) UserExists(context.Context, string) (bool, error) {
+	return false, &azure.PermissionError{StatusCode: 403}
+}
+
+// TestProcessNamespacesPermissionDenied validates that a Graph 403 aborts the run
+func TestProcessNamespacesPermissionDenied(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"}
+	owner := map[string]string{auditor.OwnerAnnotation: "user@company.com"}
+	processor := auditor.NewNamespaceProcessor(
+		fake.NewSimpleClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: labels, Annotations: owner}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: labels, Annotations: owner}},
+		),
+		forbiddenAzureClient{},
+		auditor.WithGracePeriod(time.Hour*24),
+		auditor.WithAllowedDomains([]string{"company.com"}),
+	)
+
+	report := processNamespaces(processor, kubeflowLabel, nil, false)
+	if !strings.Contains(report.Aborted, "User.Read.All") {
+		t.Errorf("Report should explain the missing permission: %q", report.Aborted)
+	}
+	if report.Namespaces != 0 {
+		t.Errorf("No namespace should be evaluated after prefetch aborts, got %d", report.Namespaces)
+	}
+}
+
+// TestProposedConfig validates reading a proposed configuration over the environment
+func TestProposedConfig(t *testing.T) {
+	setValidConfigEnv(t)
+	path := t.TempDir() + "/proposed.env"
+	content := "# Shorter grace period\nexport GRACE_PERIOD=\"48h\"\n\nALLOWED_DOMAINS='company.com, example.com'\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env, err := readEnvFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	current, err := loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings: %v", err)
+	}
+	cfg, err := loadConfigFrom(overlayEnv(env, current.Getenv))
+	if err != nil {
+		t.Fatalf("Unexpected configuration error: %v", err)
+	}
+	if cfg.gracePeriod != 48*time.Hour || !equalStringSlices(cfg.allowedDomains, []string{"company.com", "example.com"}) {
+		t.Errorf("Proposed settings not applied: %v %v", cfg.gracePeriod, cfg.allowedDomains)
+	}
+	if cfg.azureTenantID != "test-tenant" {
+		t.Errorf("Unset settings should fall back to the environment, got tenant %q", cfg.azureTenantID)
+	}
+
+	if err := os.WriteFile(path, []byte("GRACE_PERIOD 48h\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readEnvFile(path); err == nil || !strings.Contains(err.Error(), "proposed.env:1: expected KEY=VALUE") {
+		t.Errorf("Expected a line error, got %v", err)
+	}
+}
+
+// TestComparePolicies validates the compare command's output and report
+func TestComparePolicies(t *testing.T) {
+	selected := map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"}
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: selected,
+			Annotations: map[string]string{auditor.OwnerAnnotation: "alice@company.com"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: selected,
+			Annotations: map[string]string{auditor.OwnerAnnotation: "bob@example.com"}}},
+	)
+	users := &mockAzureClient{validUsers: map[string]bool{"alice@company.com": true, "bob@example.com": true}}
+	current := auditor.NewNamespaceProcessor(client, users, auditor.WithGracePeriod(time.Hour), auditor.WithAllowedDomains([]string{"company.com"}))
+	proposed := auditor.NewNamespaceProcessor(client, users, auditor.WithGracePeriod(time.Hour), auditor.WithAllowedDomains([]string{"company.com", "example.com"}), auditor.WithDryRun(true))
+
+	var logs, out strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	report, err := comparePolicies(current, proposed, kubeflowLabel, kubeflowLabel, nil, &out)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Namespaces != 2 || len(report.PolicyDiff) != 1 || report.PolicyDiff[0].Namespace != "team-b" {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if !strings.Contains(out.String(), "team-b: skip -> none") || !strings.Contains(out.String(), "1 of 2 namespaces decided differently") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	ns, _ := client.CoreV1().Namespaces().Get(context.TODO(), "team-b", metav1.GetOptions{})
+	if _, marked := ns.Annotations[auditor.GracePeriodAnnotation]; marked {
+		t.Error("Comparison must not modify namespaces")
+	}
+}
+
+// TestCompareSameConfiguration validates that comparing a configuration
+// with audit policies to itself reports no differences
+func TestCompareSameConfiguration(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("AUDIT_POLICIES", "true")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	proposedCfg, err := loadConfigFrom(overlayEnv(map[string]string{}, cfg.settings.Getenv))
+	if err != nil {
+		t.Fatalf("loadConfigFrom: %v", err)
+	}
+	users := &mockAzureClient{}
+	cfg.identity, proposedCfg.identity = users, users
+
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "sandbox",
+		Labels: map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile", "env": "sandbox"},
+		Annotations: map[string]string{
+			auditor.OwnerAnnotation:       "gone@company.com",
+			auditor.GracePeriodAnnotation: time.Now().Add(-3 * time.Hour).Format(time.RFC3339),
+		},
+	}})
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "namespace-auditor.io/v1alpha1",
+		"kind":       "NamespaceAuditPolicy",
+		"metadata":   map[string]interface{}{"name": "sandboxes"},
+		"spec": map[string]interface{}{
+			"namespaceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"env": "sandbox"}},
+			"gracePeriod":       "2h",
+		},
+	}}
+	dynamicClient := func() dynamic.Interface {
+		return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{auditor.NamespaceAuditPolicyResource: "NamespaceAuditPolicyList"}, policy.DeepCopy())
+	}
+
+	var logs, out strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	current, err := setupProcessor(cfg, client, client, dynamicClient, "test", true)
+	if err != nil {
+		t.Fatalf("setupProcessor: %v", err)
+	}
+	proposed, err := setupProcessor(proposedCfg, client, client, dynamicClient, "test", true)
+	if err != nil {
+		t.Fatalf("setupProcessor: %v", err)
+	}
+	report, err := comparePolicies(current, proposed, cfg.labelSelector, proposedCfg.labelSelector, nil, &out)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Namespaces != 1 || len(report.PolicyDiff) != 0 {
+		t.Errorf("Expected no differences, got %+v\n%s", report.PolicyDiff, out.String())
+	}
+}
+
+// TestCheckIdentityProvider validates the check-idp probe outcomes
+func TestCheckIdentityProvider(t *testing.T) {
+	present := &mockAzureClient{validUsers: map[string]bool{"canary@company.com": true}}
+	tests := []struct {
+		name     string
+		provider identity.Provider
+		canary   string
+		want     string
+		wantErr  error
+	}{
+		{name: "canary found", provider: present, canary: "canary@company.com", want: "result: ok"},
+		{name: "probe user absent", provider: present, want: "user: " + idpProbeUser},
+		{name: "canary missing", provider: present, canary: "gone@company.com", want: "result: canary user not found"},
+		{name: "permission denied", provider: forbiddenAzureClient{}, want: "result: permission denied", wantErr: errs.ErrPermission},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := checkIdentityProvider(context.TODO(), "mock", tt.provider, tt.canary, &out)
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("Output %q should contain %q", out.String(), tt.want)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Error = %v, want %v", err, tt.wantErr)
+			}
+			if (err == nil) != strings.Contains(out.String(), "result: ok") {
+				t.Errorf("Error %v does not match output %q", err, out.String())
+			}
+		})
+	}
+}
+
+// TestConfigNotifyWebhooks validates the webhook notification settings
+func TestConfigNotifyWebhooks(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("NOTIFY_WEBHOOKS", "warning=slack:https://hooks.slack.com/services/T/B/X, critical=teams:https://example.webhook.office.com/x")
+	t.Setenv("NOTIFY_TEMPLATE", "{{.Namespace}}: {{.Message}}")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.notifyChannels) != 2 || cfg.notifyChannels[1].MinSeverity != notify.SeverityCritical || cfg.notifyTemplate == nil {
+		t.Errorf("Channels = %+v, template = %v", cfg.notifyChannels, cfg.notifyTemplate)
+	}
+
+	for setting, value := range map[string]string{
+		"NOTIFY_WEBHOOKS": "urgent=slack:https://hooks.slack.com/services/T/B/X",
+		"NOTIFY_TEMPLATE": "{{.Deadline}}",
+	} {
+		t.Setenv("NOTIFY_WEBHOOKS", "")
+		t.Setenv("NOTIFY_TEMPLATE", "")
+		t.Setenv(setting, value)
+		if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), setting) {
+			t.Errorf("%s=%q: expected an error, got %v", setting, value, err)
+		}
+	}
+}
+
+// TestConfigEmail validates the owner email settings
+func TestConfigEmail(t *testing.T) {
+	resetEmailEnv := func() {
+		for _, key := range []string{"EMAIL_FROM", "EMAIL_TRANSPORT", "EMAIL_TEMPLATE_DIR", "SMTP_HOST", "SMTP_PORT", "SEND_REMINDERS"} {
+			t.Setenv(key, "")
+		}
+	}
+	setValidConfigEnv(t)
+	resetEmailEnv()
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.emailFrom != "" || cfg.reminders || cfg.notifier() != nil {
+		t.Errorf("Email should be disabled by default: %+v", cfg)
+	}
+
+	t.Setenv("EMAIL_FROM", "Namespace Auditor <auditor@company.com>")
+	t.Setenv("SMTP_HOST", "smtp.company.com")
+	if cfg, err = loadConfig(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := cfg.mailer.(*notify.SMTPMailer); !ok || cfg.emailFrom != "auditor@company.com" || !cfg.reminders || len(cfg.emailTemplates) != 3 {
+		t.Errorf("Mailer = %T, from = %q, reminders = %t, templates = %d", cfg.mailer, cfg.emailFrom, cfg.reminders, len(cfg.emailTemplates))
+	}
+	if fanout, ok := cfg.notifier().(notify.Fanout); !ok || len(fanout) != 2 {
+		t.Errorf("Notifier = %#v, want the log and email", cfg.notifier())
+	}
+
+	t.Setenv("EMAIL_TRANSPORT", "graph")
+	t.Setenv("SEND_REMINDERS", "false")
+	if cfg, err = loadConfig(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := cfg.mailer.(*azure.GraphClient); !ok || cfg.reminders {
+		t.Errorf("Mailer = %T, reminders = %t, want Graph without reminders", cfg.mailer, cfg.reminders)
+	}
+
+	for setting, value := range map[string]string{
+		"EMAIL_FROM":         "not an address",
+		"SMTP_HOST":          "",
+		"SMTP_PORT":          "smtp",
+		"EMAIL_TRANSPORT":    "pigeon",
+		"EMAIL_TEMPLATE_DIR": filepath.Join(t.TempDir(), "missing"),
+		"SEND_REMINDERS":     "sometimes",
+	} {
+		resetEmailEnv()
+		t.Setenv("EMAIL_FROM", "auditor@company.com")
+		t.Setenv("SMTP_HOST", "smtp.company.com")
+		t.Setenv(setting, value)
+		if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), setting) {
+			t.Errorf("%s=%q: expected an error, got %v", setting, value, err)
+		}
+	}
+}
+
+// TestConfigManagerEscalation validates enabling manager escalation, which
+// needs an identity provider knowing managers
+func TestConfigManagerEscalation(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("ESCALATE_TO_MANAGER", "")
+	cfg, err := loadConfig()
+	if err != nil || cfg.escalate {
+		t.Fatalf("Expected escalation to be disabled by default, got %t / %v", cfg.escalate, err)
+	}
+
+	t.Setenv("ESCALATE_TO_MANAGER", "true")
+	if cfg, err = loadConfig(); err != nil || !cfg.escalate {
+		t.Errorf("Expected escalation with the azure provider, got %t / %v", cfg.escalate, err)
+	}
+
+	t.Setenv("ESCALATE_TO_MANAGER", "sometimes")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "ESCALATE_TO_MANAGER") {
+		t.Errorf("Expected an invalid setting error, got %v", err)
+	}
+
+	t.Setenv("ESCALATE_TO_MANAGER", "true")
+	t.Setenv("IDENTITY_PROVIDER", "okta")
+	t.Setenv("OKTA_ORG_URL", "https://example.okta.com")
+	t.Setenv("OKTA_API_TOKEN", "token")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "cannot look up managers") {
+		t.Errorf("Expected the okta provider to be rejected, got %v", err)
+	}
+}
+
+// TestConfigBackup validates the destination and contents of namespace
+// backups
+func TestConfigBackup(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("BACKUP_STORE", "")
+	t.Setenv("BACKUP_RESOURCES", "")
+	cfg, err := loadConfig()
+	if err != nil || cfg.backupStore != nil {
+		t.Fatalf("Expected backups to be disabled by default, got %v / %v", cfg.backupStore, err)
+	}
+
+	t.Setenv("BACKUP_STORE", "blob:https://account.blob.core.windows.net/backups?sig=secret")
+	t.Setenv("BACKUP_RESOURCES", "v1/configmaps,v1/secrets")
+	if cfg, err = loadConfig(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := cfg.backupStore.(*backup.AzureBlobStore); !ok || len(cfg.backupResources) != 2 {
+		t.Errorf("Store = %T, resources = %v", cfg.backupStore, cfg.backupResources)
+	}
+
+	for setting, value := range map[string]string{
+		"BACKUP_STORE":     "s3://backups",
+		"BACKUP_RESOURCES": "Deployment",
+	} {
+		t.Setenv("BACKUP_STORE", "blob:https://account.blob.core.windows.net/backups")
+		t.Setenv("BACKUP_RESOURCES", "")
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv(setting, value)
+		if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), setting) {
+			t.Errorf("%s=%q: expected an error, got %v", setting, value, err)
+		}
+	}
+}
+
+// TestConfigVolumeSnapshots validates the volume snapshot settings
+func TestConfigVolumeSnapshots(t *testing.T) {
+	setValidConfigEnv(t)
+	for _, setting := range []string{"VOLUME_SNAPSHOTS", "VOLUME_SNAPSHOT_CLASS", "VOLUME_SNAPSHOT_RETENTION", "VOLUME_SNAPSHOT_TIMEOUT"} {
+		t.Setenv(setting, "")
+	}
+	cfg, err := loadConfig()
+	if err != nil || cfg.volumeSnapshots {
+		t.Fatalf("Expected volume snapshots to be disabled by default, got %v / %v", cfg.volumeSnapshots, err)
+	}
+
+	t.Setenv("VOLUME_SNAPSHOTS", "true")
+	t.Setenv("VOLUME_SNAPSHOT_CLASS", "csi-retain")
+	t.Setenv("VOLUME_SNAPSHOT_RETENTION", "2160h")
+	if cfg, err = loadConfig(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.snapshotClass != "csi-retain" || cfg.snapshotRetention != 2160*time.Hour || cfg.snapshotTimeout != 0 {
+		t.Errorf("Class = %q, retention = %s, timeout = %s", cfg.snapshotClass, cfg.snapshotRetention, cfg.snapshotTimeout)
+	}
+
+	t.Setenv("VOLUME_SNAPSHOT_TIMEOUT", "soon")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "VOLUME_SNAPSHOT_TIMEOUT") {
+		t.Errorf("Expected an invalid timeout to be reported, got %v", err)
+	}
+	t.Setenv("VOLUME_SNAPSHOT_TIMEOUT", "")
+	t.Setenv("VOLUME_SNAPSHOTS", "false")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "VOLUME_SNAPSHOT_CLASS: only used with VOLUME_SNAPSHOTS=true") {
+		t.Errorf("Expected the class to require VOLUME_SNAPSHOTS, got %v", err)
+	}
+}
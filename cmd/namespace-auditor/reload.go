@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	conf "github.com/bryanpaget/namespace-auditor/internal/config"
+)
+
+// defaultReloadInterval is how often the serve command checks CONFIG_DIR and
+// NamespaceAuditPolicy objects for changes unless configured otherwise
+const defaultReloadInterval = 30 * time.Second
+
+// configDir holds the settings read from a mounted ConfigMap, by setting
+// name, and a digest of the directory contents to detect changes.
+type configDir struct {
+	values map[string]string
+	digest string
+}
+
+// readConfigDir reads the settings of a directory with one file per
+// setting, such as a mounted ConfigMap. File names are the ConfigMap keys:
+// "grace-period" sets GRACE_PERIOD. Hidden entries, such as the "..data"
+// links Kubernetes uses to swap ConfigMap contents atomically, are skipped.
+func readConfigDir(dir string) (configDir, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return configDir{}, err
+	}
+	values := make(map[string]string, len(entries))
+	hash := sha256.New()
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return configDir{}, err
+		}
+		if info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return configDir{}, err
+		}
+		name := strings.ToUpper(strings.ReplaceAll(entry.Name(), "-", "_"))
+		values[name] = strings.TrimSpace(string(data))
+		fmt.Fprintf(hash, "%s=%q\n", name, values[name])
+	}
+	return configDir{values: values, digest: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// source returns the settings of the directory as a source of settings,
+// see loadSettings
+func (d configDir) source() conf.Source {
+	return conf.Values("config-dir", d.values)
+}
+
+// configReloader keeps the configuration of a long-running command current
+// with CONFIG_DIR. Each change is validated like the configuration at
+// startup; a valid one replaces the processor, an invalid one is logged and
+// the previous configuration kept. It is safe for concurrent use.
+type configReloader struct {
+	dir   string
+	build func(*config) (*auditor.NamespaceProcessor, error)
+
+	mu         sync.RWMutex
+	cfg        *config
+	processor  *auditor.NamespaceProcessor
+	digest     string    // Digest of the directory contents last read
+	valid      bool      // Whether the contents last read were valid
+	reloads    int       // Successful reloads
+	failures   int       // Reloads rejected as invalid
+	lastReload time.Time // When the configuration was last replaced
+}
+
+// newConfigReloader returns a reloader serving cfg and processor until dir
+// changes. Reloaded configurations keep the other sources of cfg's
+// settings. build creates the processor of a reloaded configuration.
+func newConfigReloader(dir string, cfg *config, processor *auditor.NamespaceProcessor,
+	build func(*config) (*auditor.NamespaceProcessor, error)) *configReloader {
+	r := &configReloader{dir: dir, build: build, cfg: cfg, processor: processor, valid: true, lastReload: time.Now()}
+	if dir != "" {
+		if contents, err := readConfigDir(dir); err == nil {
+			r.digest = contents.digest
+		}
+	}
+	return r
+}
+
+// current returns the configuration and processor in effect
+func (r *configReloader) current() (*config, *auditor.NamespaceProcessor) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cfg, r.processor
+}
+
+// watch checks the directory, if any, and the audit policies for changes
+// every interval until ctx is done
+func (r *configReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if r.dir != "" && r.check() {
+				continue
+			}
+			r.refreshPolicies()
+		}
+	}
+}
+
+// refreshPolicies rebuilds the processor of the configuration in effect if
+// it applies NamespaceAuditPolicy objects, which are only listed when a
+// processor is built, reporting whether the processor was replaced
+func (r *configReloader) refreshPolicies() bool {
+	r.mu.RLock()
+	cfg := r.cfg
+	r.mu.RUnlock()
+	if !cfg.auditPolicies {
+		return false
+	}
+	processor, err := r.build(cfg)
+	if err != nil {
+		log.Printf("Failed to refresh audit policies, keeping the previous ones: %v", err)
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cfg != cfg {
+		return false // Reloaded meanwhile, with its own policies
+	}
+	r.processor = processor
+	return true
+}
+
+// check reloads the configuration if the directory changed, reporting
+// whether it was replaced
+func (r *configReloader) check() bool {
+	contents, err := readConfigDir(r.dir)
+	if err != nil {
+		log.Printf("Failed to read configuration from %s, keeping the previous one: %v", r.dir, err)
+		return false
+	}
+	r.mu.RLock()
+	unchanged := contents.digest == r.digest
+	loader := r.cfg.settings
+	r.mu.RUnlock()
+	if unchanged {
+		return false
+	}
+
+	cfg, err := loadConfigWith(loader.With(contents.source()))
+	var processor *auditor.NamespaceProcessor
+	if err == nil {
+		processor, err = r.build(cfg)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.digest = contents.digest
+	if err != nil {
+		r.valid = false
+		r.failures++
+		log.Printf("Invalid configuration in %s, keeping the previous one: %v", r.dir, err)
+		return false
+	}
+	log.Printf("Reloaded configuration from %s (hash %s, was %s)", r.dir, cfg.hash(), r.cfg.hash())
+	r.cfg, r.processor = cfg, processor
+	r.valid = true
+	r.reloads++
+	r.lastReload = time.Now()
+	return true
+}
+
+// writeMetrics writes the reload counters in the Prometheus text format
+func (r *configReloader) writeMetrics(w io.Writer) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	valid := 0
+	if r.valid {
+		valid = 1
+	}
+	metrics := []struct {
+		name, kind, help string
+		samples          map[string]float64
+	}{
+		{"namespace_auditor_config_reloads_total", "counter", "Configuration changes read from CONFIG_DIR, by result.",
+			map[string]float64{`{result="success"}`: float64(r.reloads), `{result="invalid"}`: float64(r.failures)}},
+		{"namespace_auditor_config_valid", "gauge", "Whether the configuration last read from CONFIG_DIR was valid.",
+			map[string]float64{"": float64(valid)}},
+		{"namespace_auditor_config_last_reload_timestamp_seconds", "gauge", "When the configuration in effect was loaded.",
+			map[string]float64{"": float64(r.lastReload.Unix())}},
+	}
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		labels := make([]string, 0, len(m.samples))
+		for l := range m.samples {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			fmt.Fprintf(w, "%s%s %g\n", m.name, l, m.samples[l])
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// writeConfigFile writes a ConfigMap key into dir
+func writeConfigFile(t *testing.T, dir, key, value string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, key), []byte(value), 0o644); err != nil {
+		t.Fatalf("Writing %s: %v", key, err)
+	}
+}
+
+// TestReadConfigDir validates reading settings from a mounted ConfigMap
+func TestReadConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "grace-period", "720h\n")
+	writeConfigFile(t, dir, "allowed-domains", "example.com")
+	writeConfigFile(t, dir, ".hidden", "ignored")
+	if err := os.Mkdir(filepath.Join(dir, "..data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := readConfigDir(dir)
+	if err != nil {
+		t.Fatalf("readConfigDir: %v", err)
+	}
+	if len(contents.values) != 2 {
+		t.Errorf("Values = %v, want GRACE_PERIOD and ALLOWED_DOMAINS only", contents.values)
+	}
+	for name, want := range map[string]string{
+		"GRACE_PERIOD":    "720h",
+		"ALLOWED_DOMAINS": "example.com",
+		"AZURE_TENANT_ID": "",
+	} {
+		if got, _ := contents.source().Lookup(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	again, _ := readConfigDir(dir)
+	writeConfigFile(t, dir, "grace-period", "168h")
+	changed, _ := readConfigDir(dir)
+	if again.digest != contents.digest || changed.digest == contents.digest {
+		t.Error("Digest should change with the directory contents only")
+	}
+
+	if _, err := readConfigDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
+
+// TestConfigReloader validates that valid changes replace the configuration
+// and invalid ones are rejected
+func TestConfigReloader(t *testing.T) {
+	setValidConfigEnv(t)
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "grace-period", "720h")
+	t.Setenv("CONFIG_DIR", dir)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if cfg.gracePeriod != 720*time.Hour {
+		t.Errorf("Grace period = %v, want the 720h of CONFIG_DIR over the environment", cfg.gracePeriod)
+	}
+	build := func(cfg *config) (*auditor.NamespaceProcessor, error) {
+		client := fake.NewSimpleClientset()
+		return setupProcessor(cfg, client, client, nil, "test", true)
+	}
+	processor, err := build(cfg)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	reloader := newConfigReloader(dir, cfg, processor, build)
+
+	if reloader.check() {
+		t.Error("Expected no reload without changes")
+	}
+
+	writeConfigFile(t, dir, "grace-period", "168h")
+	if !reloader.check() {
+		t.Fatal("Expected a reload after a change")
+	}
+	current, p := reloader.current()
+	if current.gracePeriod != 168*time.Hour || p == processor {
+		t.Errorf("Grace period = %v after reload, want 168h with a new processor", current.gracePeriod)
+	}
+	if current.azureTenantID != "test-tenant" {
+		t.Errorf("Tenant = %q after reload, want the environment's", current.azureTenantID)
+	}
+
+	writeConfigFile(t, dir, "grace-period", "soon")
+	if reloader.check() {
+		t.Error("Expected an invalid configuration to be rejected")
+	}
+	if current, _ := reloader.current(); current.gracePeriod != 168*time.Hour {
+		t.Errorf("Grace period = %v after an invalid change, want the previous 168h", current.gracePeriod)
+	}
+
+	var metrics bytes.Buffer
+	reloader.writeMetrics(&metrics)
+	for _, want := range []string{
+		`namespace_auditor_config_reloads_total{result="invalid"} 1`,
+		`namespace_auditor_config_reloads_total{result="success"} 1`,
+		"namespace_auditor_config_valid 0",
+		"# TYPE namespace_auditor_config_last_reload_timestamp_seconds gauge",
+	} {
+		if !strings.Contains(metrics.String(), want) {
+			t.Errorf("Metrics missing %q:\n%s", want, metrics.String())
+		}
+	}
+
+	writeConfigFile(t, dir, "grace-period", "48h")
+	if !reloader.check() {
+		t.Error("Expected a reload after fixing the configuration")
+	}
+	metrics.Reset()
+	reloader.writeMetrics(&metrics)
+	if !strings.Contains(metrics.String(), "namespace_auditor_config_valid 1") {
+		t.Errorf("Expected the configuration to be valid again:\n%s", metrics.String())
+	}
+}
+
+// TestConfigReloaderPolicies validates that the processor is rebuilt to
+// follow NamespaceAuditPolicy changes only when audit policies are applied
+func TestConfigReloaderPolicies(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	builds := 0
+	build := func(cfg *config) (*auditor.NamespaceProcessor, error) {
+		builds++
+		client := fake.NewSimpleClientset()
+		return newProcessor(cfg, client, client, "test", true), nil
+	}
+	processor, _ := build(cfg)
+	reloader := newConfigReloader("", cfg, processor, build)
+
+	if reloader.refreshPolicies() || builds != 1 {
+		t.Errorf("Expected no rebuild without AUDIT_POLICIES, got %d builds", builds)
+	}
+
+	cfg.auditPolicies = true
+	if !reloader.refreshPolicies() {
+		t.Fatal("Expected a rebuild with AUDIT_POLICIES")
+	}
+	if current, p := reloader.current(); current != cfg || p == processor {
+		t.Error("Expected a new processor for the same configuration")
+	}
+}
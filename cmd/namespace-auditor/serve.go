@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// runServer runs the serve command on addr until the server fails, see
+// serveMux.
+func runServer(addr string, reloader *configReloader) error {
+	server := &http.Server{Addr: addr, Handler: serveMux(reloader), ReadHeaderTimeout: 10 * time.Second}
+	return server.ListenAndServe()
+}
+
+// serveMux routes the serve command: the deletion schedule of namespaces,
+// see NamespaceProcessor.StatusHandler, following the configuration of
+// reloader; the build at /version; and build and reload metrics at
+// /metrics.
+func serveMux(reloader *configReloader) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status/", func(w http.ResponseWriter, r *http.Request) {
+		cfg, p := reloader.current()
+		selector, err := labels.Parse(cfg.labelSelector)
+		if err != nil { // Rejected by loadConfig
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		p.StatusHandler(cfg.statusToken, selector).ServeHTTP(w, r)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/version", func(w http.ResponseWriter, _ *http.Request) {
+		cfg, _ := reloader.current()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"version": version, "commit": commit, "config": cfg.hash()})
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeBuildInfo(w)
+		reloader.writeMetrics(w)
+	})
+	return mux
+}
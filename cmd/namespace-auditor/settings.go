@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	conf "github.com/bryanpaget/namespace-auditor/internal/config"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+)
+
+// settings declares every setting of the auditor. Each can be set as an
+// environment variable, a command-line flag or a key of CONFIG_FILE, see
+// loadSettings; loadConfigFrom parses and validates the values.
+var settings = conf.Settings{
+	// Configuration sources
+	{Name: "CONFIG_FILE", Usage: "YAML file of settings, overridden by environment variables and flags"},
+	{Name: "CONFIG_DIR", Usage: "Mounted ConfigMap of settings, overriding environment variables and CONFIG_FILE"},
+	{Name: "CONFIG_RELOAD_INTERVAL", Usage: "How often the serve command checks CONFIG_DIR and NamespaceAuditPolicy objects for changes (default 30s)"},
+
+	// Grace periods
+	{Name: "GRACE_PERIOD", Usage: `Grace period before deleting namespaces with invalid owners, e.g. "720h" or "10bd"`, Required: true},
+	{Name: "GRACE_PERIOD_BY_DOMAIN", Usage: `Grace periods by owner domain, e.g. "partner.org=168h"`},
+	{Name: "GRACE_POLICY_FILE", Usage: "YAML file of grace-period tiers by label selector"},
+	{Name: "GRACE_PERIOD_OVERRIDE_MAX", Usage: "Longest grace period a namespace annotation may set; annotations are ignored if unset"},
+	{Name: "WORK_WEEK", Usage: `Days counted as business days, e.g. "Mon-Fri"`},
+	{Name: "CLOCK_SKEW_TOLERANCE", Usage: "Tolerance for clock differences on marker expiry", Default: defaultClockSkew.String()},
+	{Name: "PAUSE_WINDOWS", Usage: "Periods during which grace periods are frozen"},
+
+	// Owners and namespaces
+	{Name: "ALLOWED_DOMAINS", Usage: "Comma-separated email domains permitted for namespace owners", Required: true},
+	{Name: "OWNER_ALLOWLIST", Usage: "Owners always treated as valid, e.g. service accounts"},
+	{Name: "OWNER_LIST_FILE", Usage: "File of owners always treated as valid or invalid"},
+	{Name: "OWNER_SOURCE", Usage: "Where namespace owners are read from"},
+	{Name: "NAMESPACE_SELECTOR", Usage: "Label selector of the namespaces to audit", Default: kubeflowLabel},
+	{Name: "NAMESPACE_INCLUDE", Usage: "Name patterns of the namespaces audited, all if unset"},
+	{Name: "NAMESPACE_EXCLUDE", Usage: "Name patterns of namespaces never touched"},
+	{Name: "LINK_SUFFIXES", Usage: `Name suffixes of companion namespaces, e.g. "-serving"`},
+	{Name: "CONTEXT_KEYS", Usage: "Labels or annotations included as context in notifications and reports"},
+	{Name: "AUDIT_POLICIES", Usage: "Apply NamespaceAuditPolicy objects to the namespaces they select"},
+	{Name: "AUDITOR_INSTANCE", Usage: "Name of this deployment, for configuration drift detection", Default: defaultInstance},
+
+	// Owner policies
+	{Name: "EXPIRY_ACTION", Usage: "Action taken once the grace period expires"},
+	{Name: "ORPHAN_OWNER", Usage: "Fallback owner of orphaned namespaces, with EXPIRY_ACTION=reassign"},
+	{Name: "ORPHAN_PERIOD", Usage: "How long namespaces stay orphaned before deletion, with EXPIRY_ACTION=reassign"},
+	{Name: "QUARANTINE_PERIOD", Usage: "How long expired namespaces are quarantined before deletion"},
+	{Name: "INVALID_DOMAIN_POLICY", Usage: "Handling of owners with disallowed domains"},
+	{Name: "INVALID_DOMAIN_GRACE_PERIOD", Usage: "Grace period for owners with disallowed domains"},
+	{Name: "OWNERLESS_POLICY", Usage: "Handling of namespaces without an owner"},
+	{Name: "OWNERLESS_GRACE_PERIOD", Usage: "Grace period for namespaces without an owner"},
+	{Name: "DISABLED_USER_POLICY", Usage: "Handling of disabled owners"},
+	{Name: "DISABLED_USER_GRACE_PERIOD", Usage: "Grace period for disabled owners"},
+	{Name: "DELETED_USER_GRACE_PERIOD", Usage: "Grace period for soft-deleted owners"},
+	{Name: "MALFORMED_MARKER_ACTION", Usage: "Handling of unreadable deletion markers"},
+	{Name: "MALFORMED_MARKER_MAX_AGE", Usage: "How long a marker may stay malformed before it expires"},
+
+	// Safeguards
+	{Name: "FLAP_DAMPING_RUNS", Usage: "Consecutive runs a change on a flapping namespace must persist"},
+	{Name: "MISSING_CONFIRMATION_RUNS", Usage: "Consecutive runs an owner must be missing before marking"},
+	{Name: "MISSING_OWNER_ABORT_PERCENT", Usage: "Share of missing owners aborting a run, e.g. 20%"},
+	{Name: "MISSING_OWNER_ABORT_MIN_OWNERS", Usage: "Resolved owners needed before a run can be aborted for missing owners"},
+	{Name: "SLOW_START", Usage: `Percentages of namespaces processed by the first runs after a change, e.g. "10,25,50"`},
+	{Name: "RUN_SLICES", Usage: "Number of slices namespaces are processed in"},
+	{Name: "SLICE_BY", Usage: "How a run selects its slice"},
+	{Name: "SLICE_PERIOD", Usage: "Period covered by all slices when slicing by time"},
+	{Name: "CLASSIFICATION_LABEL", Usage: "Label holding a namespace's data classification"},
+	{Name: "SECURITY_CONTACTS", Usage: "Security contacts by data classification"},
+	{Name: "APPROVAL_CLASSIFICATIONS", Usage: "Data classifications whose deletion needs approval"},
+	{Name: "TWO_PERSON_STORAGE_THRESHOLD", Usage: "Requested storage above which deletion needs two approvals"},
+	{Name: "CANARY_NAMESPACE", Usage: "Synthetic canary namespace checked by every run"},
+	{Name: "CANARY_OWNER", Usage: "Owner of the canary namespace"},
+	{Name: "CANARY_GRACE_PERIOD", Usage: "Grace period of the canary namespace"},
+
+	// Deletion
+	{Name: "DELETION_PROPAGATION", Usage: "Propagation policy for namespace deletion"},
+	{Name: "DELETION_WAIT_TIMEOUT", Usage: "How long to wait for deleted namespaces to terminate"},
+	{Name: "STUCK_TERMINATING_THRESHOLD", Usage: "How long a deleted namespace may terminate before it is stuck"},
+	{Name: "PRE_DELETE_FINALIZER", Usage: "Hold deleted namespaces until pre-delete steps complete"},
+	{Name: "PRE_DELETE_TIMEOUT", Usage: "Longest a namespace is held by the pre-delete finalizer"},
+	{Name: "BACKUP_STORE", Usage: "Destination of namespace backups"},
+	{Name: "BACKUP_RESOURCES", Usage: "Resources included in namespace backups"},
+	{Name: "BACKUP_S3_ENDPOINT", Usage: "Endpoint of S3-compatible backup stores"},
+	{Name: "AWS_REGION", Usage: "Region of S3 backup stores"},
+	{Name: "AWS_ACCESS_KEY_ID", Usage: "Access key of S3 backup stores"},
+	{Name: "AWS_SECRET_ACCESS_KEY", Usage: "Secret key of S3 backup stores", Secret: true},
+	{Name: "AWS_SESSION_TOKEN", Usage: "Session token of S3 backup stores", Secret: true},
+	{Name: "GOOGLE_APPLICATION_CREDENTIALS", Usage: "Service account key file for Google Cloud Storage and Google Workspace"},
+	{Name: "VOLUME_SNAPSHOTS", Usage: "Snapshot the volumes of namespaces before deletion"},
+	{Name: "VOLUME_SNAPSHOT_CLASS", Usage: "VolumeSnapshotClass of the snapshots"},
+	{Name: "VOLUME_SNAPSHOT_RETENTION", Usage: "How long snapshots are labeled to be kept"},
+	{Name: "VOLUME_SNAPSHOT_TIMEOUT", Usage: "How long to wait for snapshots to be ready"},
+
+	// Identity providers
+	{Name: "IDENTITY_PROVIDER", Usage: "Identity provider validating owners", Default: identity.DefaultProvider},
+	{Name: "IDENTITY_CHAIN", Usage: `Members of the chain provider, e.g. "staff:azure,guests:azure"`},
+	{Name: "IDENTITY_RATE_LIMIT", Usage: "Identity lookups per second during prefetch"},
+	{Name: "IDENTITY_LOOKUP_BUDGET", Usage: "Identity lookups allowed per run"},
+	{Name: "IDENTITY_CACHE_TTL", Usage: "How long resolved identity lookups are reused"},
+	{Name: "PREFETCH_CONCURRENCY", Usage: "Parallel identity lookups during prefetch"},
+	{Name: "EVALUATION_CACHE_TTL", Usage: "How long valid-owner evaluations are reused"},
+	{Name: "IDP_CANARY_USER", Usage: "Existing user looked up by check-idp"},
+	{Name: "AZURE_TENANT_ID", Usage: "Azure AD tenant ID"},
+	{Name: "AZURE_CLIENT_ID", Usage: "Azure application client ID"},
+	{Name: "AZURE_CLIENT_SECRET", Usage: "Azure client secret", Secret: true},
+	{Name: "AZURE_ENVIRONMENT", Usage: "National cloud of the Azure tenant"},
+	{Name: "AZURE_GRAPH_ENDPOINT", Usage: "Microsoft Graph endpoint of the Azure tenant"},
+	{Name: "AZURE_DOMAIN_TENANTS", Usage: `Azure tenants by owner domain, e.g. "partner.org=partner"`},
+	{Name: "AZURE_REQUIRED_GROUPS", Usage: "Azure groups owners must be members of"},
+	{Name: "AZURE_MAX_RETRIES", Usage: "Retries of throttled Microsoft Graph requests"},
+	{Name: "AZURE_RETRY_TIMEOUT", Usage: "Longest time spent retrying a Microsoft Graph request"},
+	{Name: "AZURE_PROXY_URL", Usage: "Proxy for Azure requests"},
+	{Name: "AZURE_CA_FILE", Usage: "CA certificates trusted for Azure requests"},
+	{Name: "AZURE_CONNECT_TIMEOUT", Usage: "Bound of connecting to Azure and of the TLS handshake each (default 10s)"},
+	{Name: "AZURE_REQUEST_TIMEOUT", Usage: "Bound of a whole Azure request (default 60s)"},
+	{Name: "GOOGLE_ADMIN_EMAIL", Usage: "Google Workspace administrator impersonated for lookups"},
+	{Name: "LDAP_URL", Usage: "LDAP server URL"},
+	{Name: "LDAP_BIND_DN", Usage: "LDAP bind DN"},
+	{Name: "LDAP_BIND_PASSWORD", Usage: "LDAP bind password", Secret: true},
+	{Name: "LDAP_BASE_DN", Usage: "LDAP search base DN"},
+	{Name: "LDAP_USER_FILTER", Usage: "LDAP filter finding a user by email"},
+	{Name: "LDAP_START_TLS", Usage: "Upgrade LDAP connections with StartTLS"},
+	{Name: "LDAP_CA_FILE", Usage: "CA certificates trusted for LDAP connections"},
+	{Name: "OKTA_ORG_URL", Usage: "Okta organization URL"},
+	{Name: "OKTA_API_TOKEN", Usage: "Okta API token", Secret: true},
+	{Name: "OKTA_CLIENT_ID", Usage: "Okta service application client ID"},
+	{Name: "OKTA_PRIVATE_KEY", Usage: "Okta service application private key", Secret: true},
+	{Name: "OKTA_PRIVATE_KEY_ID", Usage: "Okta service application key ID"},
+	{Name: "SCIM_BASE_URL", Usage: "SCIM service base URL"},
+	{Name: "SCIM_TOKEN", Usage: "SCIM bearer token", Secret: true},
+	{Name: "SCIM_USER_ATTRIBUTE", Usage: "SCIM attribute holding owner emails"},
+
+	// Notifications
+	{Name: "NOTIFY_WEBHOOKS", Usage: "Webhooks notified by severity", Secret: true},
+	{Name: "NOTIFY_TEMPLATE", Usage: "Text template of webhook notifications"},
+	{Name: "EMAIL_FROM", Usage: "Sender of owner emails; owners are not emailed if unset"},
+	{Name: "EMAIL_TRANSPORT", Usage: "Transport of owner emails, smtp or graph"},
+	{Name: "EMAIL_TEMPLATE_DIR", Usage: "Directory of owner email templates"},
+	{Name: "SMTP_HOST", Usage: "SMTP server of owner emails"},
+	{Name: "SMTP_PORT", Usage: "SMTP server port"},
+	{Name: "SMTP_USERNAME", Usage: "SMTP user name"},
+	{Name: "SMTP_PASSWORD", Usage: "SMTP password", Secret: true},
+	{Name: "SEND_REMINDERS", Usage: "Remind owners halfway through the grace period and before deletion"},
+	{Name: "ESCALATE_TO_MANAGER", Usage: "Record and notify the manager of owners of marked namespaces"},
+	{Name: "RECORD_EVENTS", Usage: "Record Kubernetes Events on lifecycle actions"},
+
+	// State and reports
+	{Name: "STATE_NAMESPACE", Usage: "Namespace of the state ConfigMap; state is not kept if unset"},
+	{Name: "SUMMARY_CONFIGMAP", Usage: `Run summary ConfigMap as "namespace/name"`},
+	{Name: "REPORT_SINKS", Usage: "Destinations of the run report"},
+	{Name: "REPORT_RETENTION", Usage: "How long run reports are kept"},
+	{Name: "INSTANCE_RETENTION", Usage: "How long the state of other deployments is kept"},
+	{Name: "EVALUATION_RETENTION", Usage: "How long cached evaluations are kept"},
+	{Name: "PUBLISH_STATUS", Usage: "Maintain the cluster-scoped AuditorStatus object"},
+	{Name: "STATUS_TOKEN", Usage: "Bearer token required by the serve command", Secret: true},
+}
+
+// settingFlags holds the settings set on the command line
+var settingFlags = conf.Flags(flag.CommandLine, settings)
+
+// loadSettings returns the sources of the settings, in order of precedence:
+// command-line flags, the mounted ConfigMap named by CONFIG_DIR, environment
+// variables, the YAML file named by CONFIG_FILE and the defaults of the
+// settings. CONFIG_DIR and CONFIG_FILE themselves are read from flags and
+// environment variables only.
+func loadSettings() (*conf.Loader, error) {
+	sources := []conf.Source{settingFlags}
+	env := conf.Env(os.LookupEnv)
+	locations := conf.NewLoader(settings, settingFlags, env)
+	if dir := locations.Getenv("CONFIG_DIR"); dir != "" {
+		contents, err := readConfigDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("CONFIG_DIR: %w", err)
+		}
+		sources = append(sources, contents.source())
+	}
+	sources = append(sources, env)
+	if path := locations.Getenv("CONFIG_FILE"); path != "" {
+		file, err := conf.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("CONFIG_FILE: %w", err)
+		}
+		sources = append(sources, file)
+	}
+	return conf.NewLoader(settings, sources...), nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	conf "github.com/bryanpaget/namespace-auditor/internal/config"
+)
+
+// TestSettingsDeclared validates that every setting the configuration reads
+// is declared, so it can be set as a flag or in CONFIG_FILE
+func TestSettingsDeclared(t *testing.T) {
+	setValidConfigEnv(t)
+	t.Setenv("EXPIRY_ACTION", "reassign")
+	t.Setenv("EMAIL_FROM", "auditor@company.com")
+	t.Setenv("SMTP_HOST", "smtp.company.com")
+	declared := map[string]bool{}
+	for _, setting := range settings {
+		if declared[setting.Name] {
+			t.Errorf("Setting %s declared twice", setting.Name)
+		}
+		declared[setting.Name] = true
+	}
+
+	loader, err := loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings: %v", err)
+	}
+	read := map[string]bool{}
+	if _, err := loadConfigFrom(func(name string) string {
+		read[name] = true
+		return loader.Getenv(name)
+	}); err != nil {
+		t.Fatalf("loadConfigFrom: %v", err)
+	}
+	for name := range read {
+		if !declared[name] {
+			t.Errorf("Setting %s is read but not declared", name)
+		}
+	}
+}
+
+// TestConfigFile validates reading settings from CONFIG_FILE below the
+// environment, and the effective configuration printed at startup
+func TestConfigFile(t *testing.T) {
+	setValidConfigEnv(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "grace-period: 720h\nstate-namespace: auditor\ncontext-keys: [team, cost-center]\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if cfg.gracePeriod != 24*time.Hour {
+		t.Errorf("Grace period = %v, want the environment's 24h over the file", cfg.gracePeriod)
+	}
+	if cfg.stateNamespace != "auditor" || !equalStringSlices(cfg.contextKeys, []string{"team", "cost-center"}) {
+		t.Errorf("File settings not applied: %q %v", cfg.stateNamespace, cfg.contextKeys)
+	}
+
+	effective := strings.Join(cfg.settings.Effective(), "\n")
+	for _, want := range []string{
+		"GRACE_PERIOD=24h (env)",
+		"STATE_NAMESPACE=auditor (file)",
+		"NAMESPACE_SELECTOR=" + kubeflowLabel + " (default)",
+		"AZURE_CLIENT_SECRET=<redacted> (env)",
+	} {
+		if !strings.Contains(effective, want) {
+			t.Errorf("Effective configuration missing %q:\n%s", want, effective)
+		}
+	}
+	if strings.Contains(effective, "test-secret") {
+		t.Errorf("Effective configuration leaks a secret:\n%s", effective)
+	}
+
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "CONFIG_FILE") {
+		t.Errorf("Expected a CONFIG_FILE error, got %v", err)
+	}
+}
+
+// TestSettingDefaults validates that defaults apply to settings no source
+// sets
+func TestSettingDefaults(t *testing.T) {
+	setValidConfigEnv(t)
+	cfg, err := loadConfigWith(conf.NewLoader(settings, conf.Env(os.LookupEnv)))
+	if err != nil {
+		t.Fatalf("loadConfigWith: %v", err)
+	}
+	if cfg.labelSelector != kubeflowLabel || cfg.instance != defaultInstance || cfg.clockSkew != defaultClockSkew {
+		t.Errorf("Defaults not applied: %q %q %v", cfg.labelSelector, cfg.instance, cfg.clockSkew)
+	}
+	if cfg.identityProvider != "azure" {
+		t.Errorf("Identity provider = %q, want the default azure", cfg.identityProvider)
+	}
+}
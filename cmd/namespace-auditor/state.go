@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+)
+
+// restoreState applies the state saved by the previous run and returns it.
+// Failures are logged and the run continues with empty state.
+func restoreState(store auditor.StateStore, p *auditor.NamespaceProcessor) auditor.State {
+	state, err := store.Load(context.TODO())
+	if err != nil {
+		log.Printf("Failed to load auditor state: %v", err)
+		return auditor.State{}
+	}
+	p.SetThrottleState(state.Throttle)
+	if time.Now().Before(state.Throttle.Until) {
+		log.Printf("Identity provider backoff from an earlier run in effect until %s", state.Throttle.Until.Format(time.RFC3339))
+	}
+	return state
+}
+
+// applyRetention removes the auditor's own artifacts that policy no longer
+// keeps and logs what was removed.
+func applyRetention(policy auditor.RetentionPolicy, sinks []auditor.ReportSink, state *auditor.State, dryRun bool) *auditor.RetentionResult {
+	result := auditor.ApplyRetention(policy, sinks, state, time.Now(), dryRun)
+	if result == nil {
+		return nil
+	}
+	switch {
+	case len(result.Reports) == 0:
+	case dryRun:
+		log.Printf("[DRY RUN] Would remove %d reports older than %s", len(result.Reports), policy.Reports)
+	default:
+		log.Printf("Removed %d reports older than %s", len(result.Reports), policy.Reports)
+	}
+	switch {
+	case len(result.Instances) == 0:
+	case dryRun:
+		log.Printf("[DRY RUN] Would forget auditor instances not seen for %s: %v", policy.Instances, result.Instances)
+	default:
+		log.Printf("Forgetting auditor instances not seen for %s: %v", policy.Instances, result.Instances)
+	}
+	switch {
+	case len(result.Evaluations) == 0:
+	case dryRun:
+		log.Printf("[DRY RUN] Would drop %d cached evaluations older than %s", len(result.Evaluations), policy.Evaluations)
+	default:
+		log.Printf("Dropping %d cached evaluations older than %s", len(result.Evaluations), policy.Evaluations)
+	}
+	for _, e := range result.Errors {
+		log.Printf("Failed to remove expired reports: %s", e)
+	}
+	return result
+}
+
+// recordInstance registers the configuration of this deployment in state
+// and returns where it differs from other deployments sharing the cluster.
+func recordInstance(state *auditor.State, instance string, report *auditor.RunReport) []auditor.ConfigDrift {
+	own := auditor.InstanceConfig{ConfigHash: report.ConfigHash, SeenAt: time.Now().UTC()}
+	if report.Config != nil {
+		own.Config = *report.Config
+	}
+
+	drift := auditor.DetectDrift(instance, own, state.Instances, own.SeenAt)
+	for _, d := range drift {
+		log.Printf("WARNING: configuration drift with auditor instance %s: %s is %q here but %q there",
+			d.Instance, d.Setting, d.Ours, d.Theirs)
+	}
+
+	if state.Instances == nil {
+		state.Instances = make(map[string]auditor.InstanceConfig)
+	}
+	state.Instances[instance] = own
+	return drift
+}
+
+// saveState persists the state for the next run. Dry runs leave it
+// untouched and failures are logged, never fatal.
+func saveState(store auditor.StateStore, p *auditor.NamespaceProcessor, state auditor.State, dryRun bool) {
+	if dryRun {
+		log.Printf("[DRY RUN] Would save auditor state")
+		return
+	}
+	state.Throttle = p.ThrottleState()
+	if err := store.Save(context.TODO(), state); err != nil {
+		log.Printf("Failed to save auditor state: %v", err)
+	}
+}
+
+// exportState writes the auditor state of the audited namespaces, and the
+// identity-provider backoff kept in the state ConfigMap, to path as JSON.
+// A path of "-" writes to standard output.
+func exportState(p *auditor.NamespaceProcessor, cfg *config, names []string, path string) error {
+	list, err := targetNamespaces(p, cfg.labelSelector, names)
+	if err != nil {
+		return fmt.Errorf("listing namespaces: %w", err)
+	}
+	var throttle auditor.ThrottleState
+	if cfg.stateNamespace != "" {
+		state, err := auditor.NewConfigMapStateStore(p.GetClient(), cfg.stateNamespace, auditor.DefaultStateConfigMap).Load(context.TODO())
+		if err != nil {
+			return fmt.Errorf("loading auditor state: %w", err)
+		}
+		throttle = state.Throttle
+	}
+
+	export := auditor.ExportState(list.Items, throttle, time.Now())
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(path, data, 0o600)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Exported auditor state of %d namespaces", len(export.Namespaces))
+	return nil
+}
+
+// importState applies a state export read from path, see exportState, and
+// writes the outcome to w. The identity-provider backoff is merged into the
+// state ConfigMap, keeping whichever backoff lasts longer. Dry runs change
+// nothing.
+func importState(p *auditor.NamespaceProcessor, cfg *config, path string, dryRun bool, w io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var export auditor.StateExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+
+	result, err := p.ImportState(context.TODO(), export)
+	if err != nil {
+		return err
+	}
+	if export.Throttle != nil && cfg.stateNamespace != "" {
+		store := auditor.NewConfigMapStateStore(p.GetClient(), cfg.stateNamespace, auditor.DefaultStateConfigMap)
+		state, err := store.Load(context.TODO())
+		if err != nil {
+			return fmt.Errorf("loading auditor state: %w", err)
+		}
+		if export.Throttle.Until.After(state.Throttle.Until) {
+			state.Throttle = *export.Throttle
+			if dryRun {
+				log.Printf("[DRY RUN] Would save identity-provider backoff until %s", export.Throttle.Until.Format(time.RFC3339))
+			} else if err := store.Save(context.TODO(), state); err != nil {
+				return fmt.Errorf("saving auditor state: %w", err)
+			}
+		}
+	}
+
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", out); err != nil {
+		return err
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d namespaces could not be updated", len(result.Failed))
+	}
+	return nil
+}
@@ -107,12 +107,18 @@ func runTestScenario(cfg TestConfig, namespaces []TestNamespace, dryRun bool) {
 		}
 	}
 
+	allowedDomains, err := auditor.ParseAllowedDomains(cfg.AllowedDomains)
+	if err != nil {
+		log.Printf("Invalid allowed domains in test config: %v", err)
+		return
+	}
+
 	// Generate mock user existence mapping
 	existsMap := make(map[string]bool)
 	for _, ns := range namespaces {
 		if email, ok := ns.Annotations[auditor.OwnerAnnotation]; ok {
 			// Simulate user existence based on domain validity
-			domainValid := isValidDomain(email, strings.Split(cfg.AllowedDomains, ","))
+			domainValid := isValidDomain(email, allowedDomains)
 			existsMap[email] = domainValid
 		}
 	}
@@ -121,9 +127,9 @@ func runTestScenario(cfg TestConfig, namespaces []TestNamespace, dryRun bool) {
 	processor := auditor.NewNamespaceProcessor(
 		fakeClient,
 		&MockUserChecker{ExistsMap: existsMap},
-		mustParseDuration(cfg.GracePeriod),
-		strings.Split(cfg.AllowedDomains, ","),
-		dryRun,
+		auditor.WithGracePeriod(mustParseDuration(cfg.GracePeriod)),
+		auditor.WithAllowedDomains(allowedDomains),
+		auditor.WithDryRun(dryRun),
 	)
 
 	// Process all kubeflow-labeled namespaces
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Build information, set at build time with
+// -ldflags "-X main.version=... -X main.commit=..."
+var (
+	version = "dev"
+	commit  = "unknown"
+)
+
+// printVersion writes the build version, git commit and, if the environment
+// holds a valid configuration, its hash, so operators can confirm which build
+// and configuration made a given decision.
+func printVersion(w io.Writer) {
+	configHash := "unavailable (invalid configuration)"
+	if cfg, err := loadConfig(); err == nil {
+		configHash = cfg.hash()
+	}
+	fmt.Fprintf(w, "version: %s\ncommit: %s\nconfig: %s\n", version, commit, configHash)
+}
+
+// writeBuildInfo writes the info-style build metric in the Prometheus text
+// format
+func writeBuildInfo(w io.Writer) {
+	fmt.Fprintf(w, "# HELP namespace_auditor_build_info Build of the running auditor.\n# TYPE namespace_auditor_build_info gauge\n")
+	fmt.Fprintf(w, "namespace_auditor_build_info{version=%q,commit=%q} 1\n", version, commit)
+}
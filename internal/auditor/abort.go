@@ -0,0 +1,52 @@
+package auditor
+
+import (
+	"sync"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+)
+
+// abortState records the error that ended a run early. It is shared by all
+// copies of a processor. A nil *abortState never aborts.
+type abortState struct {
+	mu  sync.Mutex
+	err error
+}
+
+// set records the first fatal error of the run
+func (a *abortState) set(err error) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err == nil {
+		a.err = err
+	}
+}
+
+// get returns the fatal error, if any
+func (a *abortState) get() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// Aborted returns the error that made the run stop early, or nil. Once set,
+// no further namespaces are evaluated and the caller should end the run.
+func (p *NamespaceProcessor) Aborted() error {
+	return p.abort.get()
+}
+
+// checkAbort aborts the run if err shows the identity provider cannot be
+// used at all (see errs.Fatal), reporting whether it did.
+func (p *NamespaceProcessor) checkAbort(err error) bool {
+	if !errs.Fatal(err) {
+		return false
+	}
+	p.abort.set(err)
+	return true
+}
@@ -0,0 +1,74 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// forbiddenChecker rejects every lookup with a permission error
+type forbiddenChecker struct{ calls int }
+
+func (f *forbiddenChecker) UserExists(context.Context, string) (bool, error) {
+	f.calls++
+	return false, fmt.Errorf("lookup failed: %w", errs.ErrPermission)
+}
+
+// TestPermissionErrorAbortsRun validates that a permission error stops the run
+func TestPermissionErrorAbortsRun(t *testing.T) {
+	checker := &forbiddenChecker{}
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+
+	var actions []Action
+	captureLogs(func() {
+		for _, owner := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			tr := processor.ProcessNamespaceTraced(context.TODO(), ownedNamespace(owner, owner))
+			actions = append(actions, tr.Action)
+		}
+	})
+
+	if checker.calls != 1 {
+		t.Errorf("Expected a single lookup before aborting, got %d", checker.calls)
+	}
+	if !errors.Is(processor.Aborted(), errs.ErrPermission) {
+		t.Errorf("Expected permission error, got %v", processor.Aborted())
+	}
+	for i, a := range actions {
+		if a != ActionError {
+			t.Errorf("Namespace %d: expected error action, got %q", i, a)
+		}
+	}
+}
+
+// TestPermissionErrorAbortsPrefetch validates that prefetch stops on permission errors
+func TestPermissionErrorAbortsPrefetch(t *testing.T) {
+	checker := &forbiddenChecker{}
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+	processor.SetPrefetch(1, 0)
+
+	namespaces := []corev1.Namespace{
+		ownedNamespace("a", "a@example.com"),
+		ownedNamespace("b", "b@example.com"),
+		ownedNamespace("c", "c@example.com"),
+	}
+	captureLogs(func() {
+		processor.Prefetch(context.TODO(), namespaces)
+	})
+	if checker.calls != 1 || processor.Aborted() == nil {
+		t.Errorf("Expected prefetch to abort after one lookup, got %d calls", checker.calls)
+	}
+}
+
+// TestOtherErrorsDoNotAbort ensures transient lookup errors stay per-namespace
+func TestOtherErrorsDoNotAbort(t *testing.T) {
+	processor := newTestProcessor(true, nil, true)
+	if processor.checkAbort(fmt.Errorf("graph: %w", errs.ErrThrottled)) || processor.Aborted() != nil {
+		t.Error("Ordinary errors must not abort the run")
+	}
+}
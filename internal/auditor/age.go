@@ -0,0 +1,94 @@
+package auditor
+
+import (
+	"context"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// AccountStateReporter is optionally implemented by UserExistenceChecker
+// implementations that know when a user's account last changed state (e.g.
+// when it was deleted). It is used to show reviewers how long an owner has
+// been gone.
+type AccountStateReporter interface {
+	UserStateChangedAt(ctx context.Context, email string) (time.Time, bool, error)
+}
+
+// MarkedNamespace describes how stale a namespace marked for deletion is.
+type MarkedNamespace struct {
+	Name                string            `json:"name"`                          // Namespace name
+	Owner               string            `json:"owner,omitempty"`               // Owner email, empty if ownerless
+	CreatedAt           time.Time         `json:"createdAt"`                     // When the namespace was created
+	MarkedAt            time.Time         `json:"markedAt"`                      // When the deletion marker was set
+	MarkedFor           string            `json:"markedFor"`                     // Time since marking
+	DeleteAt            time.Time         `json:"deleteAt"`                      // When the grace period expires, pauses and clock skew included
+	Context             map[string]string `json:"context,omitempty"`             // Selected labels or annotations, see SetContextKeys
+	OwnerStateChangedAt *time.Time        `json:"ownerStateChangedAt,omitempty"` // When the owner's account changed state, if known
+	LinkedTo            string            `json:"linkedTo,omitempty"`            // Primary namespace of a companion, see LinkedToLabel
+	GraceOverride       string            `json:"graceOverride,omitempty"`       // Grace period set with GracePeriodOverrideAnnotation, if applied
+	GraceOverrideError  string            `json:"graceOverrideError,omitempty"`  // Why the namespace's grace period override was ignored
+	VerdictSource       string            `json:"verdictSource,omitempty"`       // Provenance of the verdict the owner is missing, see VerdictSourceAnnotation
+	Manager             string            `json:"manager,omitempty"`             // Manager of the owner, see ManagerAnnotation
+}
+
+// Marked returns the namespaces found marked for deletion during this run,
+// in processing order.
+func (p *NamespaceProcessor) Marked() []MarkedNamespace {
+	return p.marked.list()
+}
+
+// recordMarked adds a marked namespace to the run report
+func (p *NamespaceProcessor) recordMarked(ns corev1.Namespace, markedAt, now time.Time) {
+	entry := MarkedNamespace{
+		Name:      ns.Name,
+		Owner:     ns.Annotations[OwnerAnnotation],
+		CreatedAt: ns.CreationTimestamp.UTC(),
+		MarkedAt:  markedAt.UTC(),
+		MarkedFor: now.Sub(markedAt).Round(time.Second).String(),
+		DeleteAt:  p.graceExpiry(ns, markedAt).Add(p.accumulatedPause(ns, markedAt, now) + p.clockSkew).UTC(),
+		Context:   p.namespaceContext(ns),
+
+		VerdictSource: ns.Annotations[VerdictSourceAnnotation],
+		Manager:       ns.Annotations[ManagerAnnotation],
+	}
+	if d, ok, err := p.graceOverride(ns); ok {
+		entry.GraceOverride = d.String()
+	} else if err != nil {
+		entry.GraceOverrideError = err.Error()
+	}
+	if changed, err := parseMarkerTime(ns.Annotations[OwnerStateChangedAnnotation]); err == nil {
+		changed = changed.UTC()
+		entry.OwnerStateChangedAt = &changed
+	}
+	p.marked.add(entry)
+	for _, c := range p.links.companionsOf(ns.Name) {
+		linked := entry
+		linked.Name, linked.CreatedAt, linked.Context, linked.LinkedTo = c.Name, c.CreationTimestamp.UTC(), p.namespaceContext(c), ns.Name
+		p.marked.add(linked)
+	}
+}
+
+// stampOwnerState records when the owner's account changed state, if the
+// checker can tell. Failures are logged and leave the annotation unset.
+func (p *NamespaceProcessor) stampOwnerState(ctx context.Context, annotations map[string]string) {
+	reporter, ok := p.azureClient.(AccountStateReporter)
+	owner := annotations[OwnerAnnotation]
+	if _, backoff := p.throttle.active(time.Now()); backoff {
+		return
+	}
+	if !ok || owner == "" || !p.budget.take() {
+		return
+	}
+
+	changed, known, err := reporter.UserStateChangedAt(ctx, owner)
+	p.throttle.observe(err, time.Now())
+	if err != nil {
+		log.Printf("Error looking up account state of %s: %v", owner, err)
+		return
+	}
+	if known {
+		annotations[OwnerStateChangedAnnotation] = formatMarkerTime(changed)
+	}
+}
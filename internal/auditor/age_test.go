@@ -0,0 +1,69 @@
+package auditor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// stateReportingChecker reports every user as missing and deleted at changedAt
+type stateReportingChecker struct {
+	MockUserChecker
+	changedAt time.Time
+}
+
+// UserStateChangedAt implements AccountStateReporter for testing
+func (c *stateReportingChecker) UserStateChangedAt(ctx context.Context, email string) (time.Time, bool, error) {
+	return c.changedAt, true, nil
+}
+
+// TestMarkedReport validates age fields recorded for newly and previously marked namespaces
+func TestMarkedReport(t *testing.T) {
+	created := time.Now().Add(-90 * 24 * time.Hour).Truncate(time.Second)
+	deletedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fresh := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              "fresh",
+		CreationTimestamp: metav1.NewTime(created),
+		Annotations:       map[string]string{OwnerAnnotation: "gone@example.com"},
+	}}
+	markedAt := time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Second)
+	waiting := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              "waiting",
+		CreationTimestamp: metav1.NewTime(created),
+		Annotations: map[string]string{
+			OwnerAnnotation:       "gone@example.com",
+			GracePeriodAnnotation: formatMarkerTime(markedAt),
+		},
+	}}
+
+	processor := newTestProcessor(false, []*corev1.Namespace{fresh, waiting}, false)
+	processor.azureClient = &stateReportingChecker{changedAt: deletedAt}
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *fresh.DeepCopy())
+		processor.ProcessNamespace(context.TODO(), *waiting.DeepCopy())
+	})
+
+	marked := processor.Marked()
+	if len(marked) != 2 {
+		t.Fatalf("Expected 2 marked namespaces, got %+v", marked)
+	}
+	if marked[0].Name != "fresh" || marked[0].OwnerStateChangedAt == nil || !marked[0].OwnerStateChangedAt.Equal(deletedAt) {
+		t.Errorf("Unexpected entry for newly marked namespace: %+v", marked[0])
+	}
+	if !marked[0].CreatedAt.Equal(created) || marked[0].MarkedFor != "0s" {
+		t.Errorf("Unexpected age of newly marked namespace: %+v", marked[0])
+	}
+	if marked[1].Name != "waiting" || !marked[1].MarkedAt.Equal(markedAt) || !strings.HasPrefix(marked[1].MarkedFor, "2h0m") {
+		t.Errorf("Unexpected entry for waiting namespace: %+v", marked[1])
+	}
+
+	got, _ := processor.GetNamespace(context.TODO(), "fresh")
+	if got.Annotations[OwnerStateChangedAnnotation] != formatMarkerTime(deletedAt) {
+		t.Errorf("Owner state annotation = %q", got.Annotations[OwnerStateChangedAnnotation])
+	}
+}
@@ -0,0 +1,61 @@
+package auditor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseOwnerAllowlist parses a comma-separated list of owner emails, such as
+// bot or service accounts, that are always treated as valid. Whitespace
+// around entries is ignored and empty entries are dropped. Each email is
+// returned in normalized form.
+func ParseOwnerAllowlist(list string) ([]string, error) {
+	var emails, invalid []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		email, ok := normalizeEmail(entry)
+		if !ok {
+			invalid = append(invalid, entry)
+			continue
+		}
+		emails = append(emails, email)
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid owner emails: %q", invalid)
+	}
+	return emails, nil
+}
+
+// SetOwnerAllowlist treats namespaces owned by any of emails as having a
+// valid owner, without consulting the identity provider or the allowed
+// domains, so that automation-owned namespaces do not depend on their
+// service accounts existing in the directory.
+func (p *NamespaceProcessor) SetOwnerAllowlist(emails []string) {
+	p.allowlist = make(map[string]bool, len(emails))
+	for _, email := range emails {
+		if normalized, ok := normalizeEmail(email); ok {
+			p.allowlist[normalized] = true
+		}
+	}
+}
+
+// allowlisted reports whether email is on the owner allowlist
+func (p *NamespaceProcessor) allowlisted(email string) bool {
+	normalized, ok := normalizeEmail(email)
+	return ok && p.allowlist[normalized]
+}
+
+// normalizeEmail lower-cases the local part of an email address and
+// normalizes its domain (see normalizeDomain). Returns false if the address
+// is malformed.
+func normalizeEmail(email string) (string, bool) {
+	local, _, found := strings.Cut(strings.TrimSpace(email), "@")
+	domain, ok := emailDomain(email)
+	if !found || !ok || strings.ContainsAny(local, " \t") {
+		return "", false
+	}
+	return strings.ToLower(local) + "@" + domain, true
+}
@@ -0,0 +1,58 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParseOwnerAllowlist validates parsing and normalizing allowlisted owners
+func TestParseOwnerAllowlist(t *testing.T) {
+	emails, err := ParseOwnerAllowlist(" MLOps-svc@StatCan.gc.ca., ,bot@example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := []string{"mlops-svc@statcan.gc.ca", "bot@example.com"}; !reflect.DeepEqual(emails, want) {
+		t.Errorf("Allowlist = %v, want %v", emails, want)
+	}
+
+	if _, err := ParseOwnerAllowlist("bot@example.com,not-an-email,@example.com"); err == nil {
+		t.Error("Expected an error for malformed emails")
+	}
+}
+
+// TestAllowlistedOwner validates that allowlisted owners are valid without a
+// lookup, even outside the allowed domains, and that their markers are cleared
+func TestAllowlistedOwner(t *testing.T) {
+	marked := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "automation",
+		Annotations: map[string]string{
+			OwnerAnnotation:       "Pipeline-Bot@partner.org",
+			GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+		},
+	}}
+	checker := &countingChecker{calls: map[string]int{}}
+	processor := newTestProcessor(false, []*corev1.Namespace{marked}, false)
+	processor.azureClient = checker
+	processor.SetOwnerAllowlist([]string{"pipeline-bot@partner.org"})
+
+	processor.Prefetch(context.TODO(), []corev1.Namespace{*marked})
+	var tr *Trace
+	captureLogs(func() {
+		tr = processor.ProcessNamespaceTraced(context.TODO(), *marked.DeepCopy())
+	})
+	if tr.Action != ActionUnmark {
+		t.Errorf("Action = %q, want %q", tr.Action, ActionUnmark)
+	}
+	if len(checker.calls) != 0 {
+		t.Errorf("Allowlisted owners must not be looked up: %v", checker.calls)
+	}
+
+	updated, _ := processor.GetNamespace(context.TODO(), marked.Name)
+	if _, ok := updated.Annotations[GracePeriodAnnotation]; ok {
+		t.Error("Marker should be removed from an allowlisted namespace")
+	}
+}
@@ -0,0 +1,137 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Kubernetes API operations tracked by APIStats
+const (
+	OpList     = "list"
+	OpCreate   = "create"
+	OpGet      = "get"
+	OpUpdate   = "update"
+	OpDelete   = "delete"
+	OpFinalize = "finalize"
+
+	OpRoleBindingList   = "rolebinding-list"
+	OpRoleBindingDelete = "rolebinding-delete"
+	OpPVCList           = "pvc-list"
+	OpEventCreate       = "event-create"
+
+	OpDeploymentList      = "deployment-list"
+	OpDeploymentUpdate    = "deployment-update"
+	OpStatefulSetList     = "statefulset-list"
+	OpStatefulSetUpdate   = "statefulset-update"
+	OpNetworkPolicyCreate = "networkpolicy-create"
+	OpNetworkPolicyDelete = "networkpolicy-delete"
+	OpResourceQuotaCreate = "resourcequota-create"
+	OpResourceQuotaDelete = "resourcequota-delete"
+)
+
+// OpStats summarizes calls of a single Kubernetes API operation.
+type OpStats struct {
+	Count        int           `json:"count"`        // Number of calls made
+	Errors       int           `json:"errors"`       // Number of calls that failed
+	TotalLatency time.Duration `json:"totalLatency"` // Sum of call latencies
+	MaxLatency   time.Duration `json:"maxLatency"`   // Slowest single call
+}
+
+// APIStats records Kubernetes API usage of a processor so that runs can be
+// shown to stay within agreed API budgets. It is safe for concurrent use.
+type APIStats struct {
+	mu  sync.Mutex
+	ops map[string]*OpStats
+}
+
+// observe records one call of op that started at start. A nil *APIStats
+// discards the observation.
+func (s *APIStats) observe(op string, start time.Time, err error) {
+	if s == nil {
+		return
+	}
+	elapsed := time.Since(start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ops == nil {
+		s.ops = make(map[string]*OpStats)
+	}
+	st, ok := s.ops[op]
+	if !ok {
+		st = &OpStats{}
+		s.ops[op] = st
+	}
+	st.Count++
+	if err != nil {
+		st.Errors++
+	}
+	st.TotalLatency += elapsed
+	if elapsed > st.MaxLatency {
+		st.MaxLatency = elapsed
+	}
+}
+
+// Snapshot returns a copy of the statistics gathered so far, keyed by operation.
+func (s *APIStats) Snapshot() map[string]OpStats {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]OpStats, len(s.ops))
+	for op, st := range s.ops {
+		out[op] = *st
+	}
+	return out
+}
+
+// Operations returns the names of all operations observed, sorted.
+func (s *APIStats) Operations() []string {
+	snapshot := s.Snapshot()
+	ops := make([]string, 0, len(snapshot))
+	for op := range snapshot {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// APIStats returns the Kubernetes API usage recorded by this processor.
+func (p *NamespaceProcessor) APIStats() *APIStats {
+	return p.apiStats
+}
+
+// updateNamespace writes a namespace back to the API server, or records
+// the update in a dry run. A namespace changed since it was read is flagged
+// instead, see flagChanged; other failures fail the decision.
+func (p *NamespaceProcessor) updateNamespace(ctx context.Context, ns *corev1.Namespace) error {
+	err := p.mutations().updateNamespace(ctx, ns)
+	p.observeChange(ns.Name, err)
+	return err
+}
+
+// removeNamespace deletes a namespace through the API server, or records
+// the deletion in a dry run. The deletion is preconditioned on the namespace
+// being unchanged since it was read; one changed since is flagged instead.
+func (p *NamespaceProcessor) removeNamespace(ctx context.Context, ns corev1.Namespace) error {
+	err := p.mutations().deleteNamespace(ctx, ns.Name, p.deleteOptions(ns))
+	p.observeChange(ns.Name, err)
+	return err
+}
+
+// observeChange records the outcome of a change to a namespace in its decision
+func (p *NamespaceProcessor) observeChange(name string, err error) {
+	switch {
+	case errors.Is(err, errs.ErrConflict):
+		p.flagChanged(name)
+	case err != nil:
+		p.trace.fail(err)
+	}
+}
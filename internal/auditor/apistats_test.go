@@ -0,0 +1,92 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestAPIStatsObserve validates aggregation of call counts and latency
+func TestAPIStatsObserve(t *testing.T) {
+	stats := &APIStats{}
+	stats.observe(OpGet, time.Now().Add(-2*time.Second), nil)
+	stats.observe(OpGet, time.Now().Add(-time.Second), errors.New("boom"))
+	stats.observe(OpList, time.Now(), nil)
+
+	snapshot := stats.Snapshot()
+	get := snapshot[OpGet]
+	if get.Count != 2 || get.Errors != 1 {
+		t.Errorf("Unexpected get stats: %+v", get)
+	}
+	if get.MaxLatency < 2*time.Second || get.TotalLatency < 3*time.Second {
+		t.Errorf("Latency not accumulated: %+v", get)
+	}
+	if ops := stats.Operations(); len(ops) != 2 || ops[0] != OpGet || ops[1] != OpList {
+		t.Errorf("Unexpected operations: %v", ops)
+	}
+
+	var nilStats *APIStats
+	nilStats.observe(OpGet, time.Now(), nil) // Must not panic
+	if nilStats.Snapshot() != nil {
+		t.Error("Nil stats should have an empty snapshot")
+	}
+}
+
+// TestProcessorAPIStats validates that processor operations are counted
+func TestProcessorAPIStats(t *testing.T) {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "counted",
+			Annotations: map[string]string{
+				OwnerAnnotation:       "gone@example.com",
+				GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+			},
+		},
+	}
+	processor := NewNamespaceProcessor(
+		fake.NewSimpleClientset(ns),
+		&MockUserChecker{exists: false},
+		WithGracePeriod(time.Hour),
+		WithAllowedDomains([]string{"example.com"}),
+	)
+
+	captureLogs(func() {
+		_, _ = processor.ListNamespaces(context.TODO(), "")
+		got, _ := processor.GetNamespace(context.TODO(), "counted")
+		processor.ProcessNamespace(context.TODO(), *got)
+	})
+
+	snapshot := processor.APIStats().Snapshot()
+	for _, op := range []string{OpList, OpGet, OpDelete} {
+		if snapshot[op].Count != 1 {
+			t.Errorf("Expected one %s call, got %+v", op, snapshot[op])
+		}
+	}
+	if _, ok := snapshot[OpUpdate]; ok {
+		t.Errorf("No update expected, got %+v", snapshot[OpUpdate])
+	}
+}
+
+// TestUpdateNamespaceConflict validates classification of update conflicts
+func TestUpdateNamespaceConflict(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "raced"}}
+	client := fake.NewSimpleClientset(ns)
+	client.PrependReactor("update", "namespaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(corev1.Resource("namespaces"), "raced", errors.New("object was modified"))
+	})
+	processor := NewNamespaceProcessor(client, &MockUserChecker{}, WithGracePeriod(time.Hour), WithAllowedDomains([]string{"example.com"}))
+
+	err := processor.updateNamespace(context.TODO(), ns)
+	if !errors.Is(err, errs.ErrConflict) || !apierrors.IsConflict(err) {
+		t.Errorf("Expected conflict classified as errs.ErrConflict, got %v", err)
+	}
+}
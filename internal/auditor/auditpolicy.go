@@ -0,0 +1,174 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// NamespaceAuditPolicyResource identifies the cluster-scoped
+// NamespaceAuditPolicy custom resource defined in
+// deploy/crd-namespaceauditpolicy.yaml.
+var NamespaceAuditPolicyResource = schema.GroupVersionResource{
+	Group:    "namespace-auditor.io",
+	Version:  "v1alpha1",
+	Resource: "namespaceauditpolicies",
+}
+
+// NamespaceAuditPolicySpec is the spec of a NamespaceAuditPolicy object.
+// Unset fields keep the auditor's configuration.
+type NamespaceAuditPolicySpec struct {
+	// Priority orders policies matching the same namespace, highest first;
+	// ties are broken by name.
+	Priority int `json:"priority,omitempty"`
+	// NamespaceSelector selects the namespaces the policy applies to; empty
+	// selects all.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// AllowedDomains replaces the permitted owner email domains.
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+	// GracePeriod replaces the grace period, as a duration (e.g. "720h") or
+	// in business days (e.g. "10bd").
+	GracePeriod string `json:"gracePeriod,omitempty"`
+	// Exemptions lists name patterns of selected namespaces that are exempt
+	// from auditing, see NamePattern.
+	Exemptions []string `json:"exemptions,omitempty"`
+	// Notifications adds recipients to notifications about selected
+	// namespaces.
+	Notifications PolicyNotifications `json:"notifications,omitempty"`
+}
+
+// PolicyNotifications holds the notification settings of a
+// NamespaceAuditPolicy.
+type PolicyNotifications struct {
+	// Contact is emailed like SecondaryContactAnnotation for namespaces
+	// without one.
+	Contact string `json:"contact,omitempty"`
+	// Recipients are told in addition to the owner and contributors.
+	Recipients []string `json:"recipients,omitempty"`
+}
+
+// NamespaceAuditPolicy is a NamespaceAuditPolicy object as read from the
+// cluster.
+type NamespaceAuditPolicy struct {
+	Name       string
+	Generation int64
+	Spec       NamespaceAuditPolicySpec
+}
+
+// ListAuditPolicies reads all NamespaceAuditPolicy objects, by name.
+func ListAuditPolicies(ctx context.Context, client dynamic.Interface) ([]NamespaceAuditPolicy, error) {
+	list, err := client.Resource(NamespaceAuditPolicyResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing NamespaceAuditPolicy objects: %w", err)
+	}
+	policies := make([]NamespaceAuditPolicy, 0, len(list.Items))
+	for _, item := range list.Items {
+		policy := NamespaceAuditPolicy{Name: item.GetName(), Generation: item.GetGeneration()}
+		if spec, ok := item.Object["spec"].(map[string]interface{}); ok {
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(spec, &policy.Spec, true); err != nil {
+				return nil, fmt.Errorf("NamespaceAuditPolicy %s: %w", policy.Name, err)
+			}
+		}
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies, nil
+}
+
+// AuditPolicy is a validated NamespaceAuditPolicy. Nil or empty fields keep
+// the processor's configuration.
+type AuditPolicy struct {
+	Name           string
+	Generation     int64
+	Priority       int
+	Selector       labels.Selector
+	AllowedDomains []string
+	Grace          GracePeriodStrategy
+	Exemptions     []NamePattern
+	Contact        string
+	Recipients     []string
+}
+
+// SetAuditPolicies applies policies to the namespaces they select. Each
+// namespace follows the matching policy with the highest priority, ties
+// broken by name; namespaces matching none follow the processor's
+// configuration. Evaluations cached under other policies, see
+// SetEvaluationCache, are not used.
+func (p *NamespaceProcessor) SetAuditPolicies(policies []AuditPolicy) {
+	sorted := append([]AuditPolicy(nil), policies...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	p.auditPolicies = sorted
+	generations := make([]string, len(sorted))
+	for i, policy := range sorted {
+		generations[i] = fmt.Sprintf("%s:%d", policy.Name, policy.Generation)
+	}
+	p.policyGeneration = strings.Join(generations, ",")
+}
+
+// withAuditPolicy returns a copy of the processor following the audit
+// policy matching ns, or the processor itself if none does or one was
+// already applied.
+func (p *NamespaceProcessor) withAuditPolicy(ns corev1.Namespace) *NamespaceProcessor {
+	if p.auditPolicy != nil {
+		return p
+	}
+	for i := range p.auditPolicies {
+		policy := &p.auditPolicies[i]
+		if !policy.Selector.Matches(labels.Set(ns.Labels)) {
+			continue
+		}
+		q := *p
+		q.auditPolicy = policy
+		if len(policy.AllowedDomains) > 0 {
+			q.allowedDomains = policy.AllowedDomains
+		}
+		if policy.Grace != nil {
+			q.grace = policy.Grace
+		}
+		return &q
+	}
+	return p
+}
+
+// policyExemption returns the exemption pattern of the applied audit policy
+// matching ns, if any
+func (p *NamespaceProcessor) policyExemption(ns corev1.Namespace) (NamePattern, bool) {
+	if p.auditPolicy == nil {
+		return NamePattern{}, false
+	}
+	for _, pattern := range p.auditPolicy.Exemptions {
+		if pattern.Match(ns.Name) {
+			return pattern, true
+		}
+	}
+	return NamePattern{}, false
+}
+
+// addPolicyRecipients adds the contact and recipients of the applied audit
+// policy to a notification
+func (p *NamespaceProcessor) addPolicyRecipients(n *Notification) {
+	if p.auditPolicy == nil {
+		return
+	}
+	if n.Contact == "" {
+		n.Contact = p.auditPolicy.Contact
+	}
+	for _, recipient := range p.auditPolicy.Recipients {
+		if !contains(n.Recipients, recipient) {
+			n.Recipients = append(n.Recipients, recipient)
+		}
+	}
+}
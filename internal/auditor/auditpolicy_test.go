@@ -0,0 +1,130 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// labeledNamespace builds a namespace with the given labels, owned by owner
+func labeledNamespace(name, owner string, nsLabels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Labels:      nsLabels,
+		Annotations: map[string]string{OwnerAnnotation: owner},
+	}}
+}
+
+// TestAuditPolicies validates that namespaces follow the matching policy
+// with the highest priority
+func TestAuditPolicies(t *testing.T) {
+	partner := labeledNamespace("partner", "user@partner.org", map[string]string{"org": "partner"})
+	sandbox := labeledNamespace("sandbox", "gone@example.com", map[string]string{"env": "sandbox"})
+	scratch := labeledNamespace("scratch-1", "gone@example.com", map[string]string{"env": "sandbox"})
+	other := labeledNamespace("other", "gone@example.com", nil)
+	processor := newTestProcessor(false, []*corev1.Namespace{partner, sandbox, scratch, other}, false)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	processor.clock = func() time.Time { return now }
+	processor.SetAuditPolicies([]AuditPolicy{
+		{Name: "catch-all", Selector: labels.Everything(), Recipients: []string{"platform@example.com"}},
+		{Name: "partners", Priority: 10, Selector: labels.SelectorFromSet(labels.Set{"org": "partner"}), AllowedDomains: []string{"partner.org"}},
+		{Name: "sandboxes", Priority: 10, Selector: labels.SelectorFromSet(labels.Set{"env": "sandbox"}),
+			Grace: FixedGracePeriod(2 * time.Hour), Exemptions: mustParseNamePatterns(t, "scratch-*"), Contact: "lead@example.com"},
+	})
+	notifier := &recordingNotifier{}
+	processor.SetNotifier(notifier)
+
+	traces := map[string]*Trace{}
+	captureLogs(func() {
+		for _, ns := range []*corev1.Namespace{partner, sandbox, scratch, other} {
+			traces[ns.Name] = processor.ProcessNamespaceTraced(context.TODO(), *ns)
+		}
+	})
+
+	// The partner domain is allowed for partner namespaces only
+	if traces["partner"].Action != ActionMark {
+		t.Errorf("Partner action = %q, want %q", traces["partner"].Action, ActionMark)
+	}
+	if traces["scratch-1"].Action != ActionExempt {
+		t.Errorf("Scratch action = %q, want %q", traces["scratch-1"].Action, ActionExempt)
+	}
+
+	// Sandboxes expire after the grace period of their policy
+	now = now.Add(3 * time.Hour)
+	for name, want := range map[string]Action{"sandbox": ActionDelete, "other": ActionWait} {
+		ns, _ := processor.GetNamespace(context.TODO(), name)
+		var tr *Trace
+		captureLogs(func() { tr = processor.ProcessNamespaceTraced(context.TODO(), *ns) })
+		if tr.Action != want {
+			t.Errorf("%s action after 3h = %q, want %q", name, tr.Action, want)
+		}
+	}
+
+	notified := map[string]Notification{}
+	for _, n := range notifier.sent {
+		notified[n.Namespace] = n
+	}
+	if n := notified["sandbox"]; n.Contact != "lead@example.com" || len(n.Recipients) != 0 {
+		t.Errorf("Sandbox notification = %+v, want the policy contact", n)
+	}
+	if n := notified["other"]; !reflect.DeepEqual(n.Recipients, []string{"platform@example.com"}) {
+		t.Errorf("Other notification recipients = %v, want the catch-all recipients", n.Recipients)
+	}
+}
+
+// mustParseNamePatterns parses name patterns, failing the test on error
+func mustParseNamePatterns(t *testing.T, list string) []NamePattern {
+	t.Helper()
+	patterns, err := ParseNamePatterns(list)
+	if err != nil {
+		t.Fatalf("ParseNamePatterns(%q): %v", list, err)
+	}
+	return patterns
+}
+
+// TestListAuditPolicies validates reading NamespaceAuditPolicy objects
+func TestListAuditPolicies(t *testing.T) {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "namespace-auditor.io/v1alpha1",
+		"kind":       "NamespaceAuditPolicy",
+		"metadata":   map[string]interface{}{"name": "prod"},
+		"spec": map[string]interface{}{
+			"priority":          int64(5),
+			"namespaceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"env": "prod"}},
+			"gracePeriod":       "2160h",
+			"notifications":     map[string]interface{}{"recipients": []interface{}{"sre@example.com"}},
+		},
+	}}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{NamespaceAuditPolicyResource: "NamespaceAuditPolicyList"}, object)
+
+	policies, err := ListAuditPolicies(context.TODO(), client)
+	if err != nil {
+		t.Fatalf("ListAuditPolicies: %v", err)
+	}
+	want := []NamespaceAuditPolicy{{Name: "prod", Spec: NamespaceAuditPolicySpec{
+		Priority:          5,
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+		GracePeriod:       "2160h",
+		Notifications:     PolicyNotifications{Recipients: []string{"sre@example.com"}},
+	}}}
+	if !reflect.DeepEqual(policies, want) {
+		t.Errorf("Policies = %+v, want %+v", policies, want)
+	}
+
+	object.Object["spec"].(map[string]interface{})["gracePeriods"] = "typo"
+	client = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{NamespaceAuditPolicyResource: "NamespaceAuditPolicyList"}, object)
+	if _, err := ListAuditPolicies(context.TODO(), client); err == nil {
+		t.Error("Expected unknown spec fields to be rejected")
+	}
+}
@@ -0,0 +1,54 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Backuper archives the contents of a namespace and returns where the
+// archive was stored. Implemented by backup.Exporter.
+type Backuper interface {
+	Backup(ctx context.Context, namespace string) (string, error)
+}
+
+// SetBackup archives every namespace with b before it is deleted. A
+// namespace that cannot be backed up is not deleted.
+func (p *NamespaceProcessor) SetBackup(b Backuper) {
+	p.backup = b
+}
+
+// backupNamespace archives ns if backups are enabled, and records the
+// archive's location in BackupAnnotation. Dry runs only trace the backup.
+func (p *NamespaceProcessor) backupNamespace(ctx context.Context, ns *corev1.Namespace) error {
+	if p.backup == nil {
+		return nil
+	}
+	if p.dryRun {
+		p.trace.add("backup", "contents would be archived before deletion")
+		return nil
+	}
+
+	location, err := p.backup.Backup(ctx, ns.Name)
+	if err != nil {
+		return fmt.Errorf("backing up: %w", err)
+	}
+	log.Printf("Backed up namespace %s to %s", ns.Name, location)
+	p.trace.add("backup", "contents archived to %s", location)
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	ns.Annotations[BackupAnnotation] = location
+	return p.updateNamespace(ctx, ns)
+}
+
+// withBackup appends the location of the namespace's backup, if any, to a
+// notification message
+func withBackup(ns corev1.Namespace, message string) string {
+	if location := ns.Annotations[BackupAnnotation]; location != "" {
+		return message + ", contents archived to " + location
+	}
+	return message
+}
@@ -0,0 +1,77 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordingBackuper records the namespaces it backs up
+type recordingBackuper struct {
+	namespaces []string
+	err        error
+}
+
+// Backup implements Backuper
+func (b *recordingBackuper) Backup(_ context.Context, namespace string) (string, error) {
+	b.namespaces = append(b.namespaces, namespace)
+	if b.err != nil {
+		return "", b.err
+	}
+	return "s3://backups/" + namespace + ".yaml.gz", nil
+}
+
+// TestBackupBeforeDeletion validates that namespaces are backed up before
+// deletion, and kept if the backup fails
+func TestBackupBeforeDeletion(t *testing.T) {
+	expired := func() []*corev1.Namespace {
+		return []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{
+			OwnerAnnotation:       "alice@example.com",
+			GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+		}}}}
+	}
+	process := func(p *NamespaceProcessor) *Trace {
+		ns, err := p.GetNamespace(context.TODO(), "alice")
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		var tr *Trace
+		captureLogs(func() { tr = p.ProcessNamespaceTraced(context.TODO(), *ns) })
+		return tr
+	}
+
+	backuper := &recordingBackuper{err: errors.New("bucket unavailable")}
+	p := newTestProcessor(false, expired(), false)
+	p.SetBackup(backuper)
+	if tr := process(p); tr.Action != ActionError || !strings.Contains(tr.Error, "bucket unavailable") {
+		t.Errorf("Trace = %+v, want a failed backup", tr)
+	}
+	if _, err := p.GetNamespace(context.TODO(), "alice"); err != nil {
+		t.Fatalf("A namespace that could not be backed up should be kept: %v", err)
+	}
+
+	backuper.err = nil
+	notifier := &recordingNotifier{}
+	p.SetNotifier(notifier)
+	if tr := process(p); tr.Action != ActionDelete {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionDelete)
+	}
+	if len(backuper.namespaces) != 2 {
+		t.Errorf("Backups = %v, want two attempts", backuper.namespaces)
+	}
+	if len(notifier.sent) != 1 || !strings.HasSuffix(notifier.sent[0].Message, "contents archived to s3://backups/alice.yaml.gz") {
+		t.Errorf("Expected the deleted notification to name the backup, got %+v", notifier.sent)
+	}
+
+	// Dry runs do not upload anything
+	dry := newTestProcessor(false, expired(), true)
+	dry.SetBackup(backuper)
+	process(dry)
+	if len(backuper.namespaces) != 2 {
+		t.Errorf("Dry runs should not back up namespaces: %v", backuper.namespaces)
+	}
+}
@@ -0,0 +1,62 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrMissingOwnerRate aborts a run in which too many owners were reported
+// missing at once, see SetMissingOwnerBreaker.
+var ErrMissingOwnerRate = errors.New("too many owners not found")
+
+// DefaultMissingOwnerMinimum is the number of owners a run must resolve
+// before the missing-owner breaker can trip, unless configured otherwise.
+const DefaultMissingOwnerMinimum = 10
+
+// SetMissingOwnerBreaker aborts runs in which more than percent of the
+// resolved owners are not found, since a misconfigured tenant or a directory
+// outage makes every owner appear missing at once. The breaker only trips
+// once at least minimum owners were resolved, so that a few departures on a
+// small cluster do not stop the auditor; minimum defaults to
+// DefaultMissingOwnerMinimum. A percent of zero disables the breaker.
+func (p *NamespaceProcessor) SetMissingOwnerBreaker(percent float64, minimum int) {
+	p.missingOwnerPercent = percent
+	p.missingOwnerMinimum = minimum
+}
+
+// checkMissingOwners trips the missing-owner breaker on the owners resolved
+// by Prefetch, aborting the run before any namespace is changed and alerting
+// administrators. Returns whether it tripped.
+func (p *NamespaceProcessor) checkMissingOwners(ctx context.Context, resolved map[string]lookupResult) bool {
+	if p.missingOwnerPercent <= 0 || p.Aborted() != nil {
+		return false
+	}
+	minimum := p.missingOwnerMinimum
+	if minimum <= 0 {
+		minimum = DefaultMissingOwnerMinimum
+	}
+	if len(resolved) < minimum {
+		return false
+	}
+	missing := 0
+	for _, r := range resolved {
+		if !r.state.Exists() {
+			missing++
+		}
+	}
+	rate := 100 * float64(missing) / float64(len(resolved))
+	if rate <= p.missingOwnerPercent {
+		return false
+	}
+
+	err := fmt.Errorf("%w: %d of %d owners (%.0f%%, limit %g%%)", ErrMissingOwnerRate, missing, len(resolved), rate, p.missingOwnerPercent)
+	log.Printf("Aborting run before any change: %v", err)
+	p.abort.set(err)
+	p.notify(ctx, Notification{
+		Event:   EventMissingOwners,
+		Message: fmt.Sprintf("run aborted without changes, %d of %d owners were not found (limit %g%%); check the identity provider configuration", missing, len(resolved), p.missingOwnerPercent),
+	})
+	return true
+}
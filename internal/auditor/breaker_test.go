@@ -0,0 +1,89 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// missingOwnerNamespaces returns ten namespaces with distinct owners, the
+// first missing of them not found by the returned checker
+func missingOwnerNamespaces(missing int) ([]corev1.Namespace, *countingChecker) {
+	checker := &countingChecker{calls: map[string]int{}, existing: map[string]bool{}}
+	var namespaces []corev1.Namespace
+	for i := 0; i < 10; i++ {
+		owner := fmt.Sprintf("user%d@example.com", i)
+		checker.existing[owner] = i >= missing
+		namespaces = append(namespaces, ownedNamespace(fmt.Sprintf("team-%d", i), owner))
+	}
+	return namespaces, checker
+}
+
+// TestMissingOwnerBreaker validates that a run in which too many owners are
+// missing is aborted before any namespace is changed
+func TestMissingOwnerBreaker(t *testing.T) {
+	namespaces, checker := missingOwnerNamespaces(3)
+	var existing []*corev1.Namespace
+	for i := range namespaces {
+		existing = append(existing, &namespaces[i])
+	}
+	processor := newTestProcessor(true, existing, false)
+	processor.azureClient = checker
+	processor.SetMissingOwnerBreaker(20, 0)
+	notifier := &recordingNotifier{}
+	processor.SetNotifier(notifier)
+
+	captureLogs(func() {
+		processor.Prefetch(context.TODO(), namespaces)
+		for _, ns := range namespaces {
+			processor.ProcessNamespace(context.TODO(), ns)
+		}
+	})
+
+	if err := processor.Aborted(); !errors.Is(err, ErrMissingOwnerRate) {
+		t.Fatalf("Aborted = %v, want %v", err, ErrMissingOwnerRate)
+	}
+	for _, ns := range namespaces {
+		got, err := processor.GetNamespace(context.TODO(), ns.Name)
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		if _, marked := got.Annotations[GracePeriodAnnotation]; marked {
+			t.Errorf("Namespace %s should not be marked after the breaker tripped", ns.Name)
+		}
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Event != EventMissingOwners {
+		t.Errorf("Expected a single %s notification, got %+v", EventMissingOwners, notifier.sent)
+	}
+}
+
+// TestMissingOwnerBreakerThresholds validates when the breaker trips
+func TestMissingOwnerBreakerThresholds(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		missing     int
+		percent     float64
+		minimum     int
+		expectAbort bool
+	}{
+		{"disabled", 10, 0, 0, false},
+		{"below the threshold", 2, 20, 0, false},
+		{"above the threshold", 3, 20, 0, true},
+		{"too few owners", 10, 20, 11, false},
+		{"custom minimum", 5, 20, 5, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			namespaces, checker := missingOwnerNamespaces(tc.missing)
+			processor := newTestProcessor(true, nil, true)
+			processor.azureClient = checker
+			processor.SetMissingOwnerBreaker(tc.percent, tc.minimum)
+			captureLogs(func() { processor.Prefetch(context.TODO(), namespaces) })
+			if aborted := processor.Aborted() != nil; aborted != tc.expectAbort {
+				t.Errorf("Aborted = %v, want %v", processor.Aborted(), tc.expectAbort)
+			}
+		})
+	}
+}
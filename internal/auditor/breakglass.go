@@ -0,0 +1,113 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// BreakGlassRecord is the audit record of a namespace deleted immediately
+// with DeleteNow, skipping its grace period.
+type BreakGlassRecord struct {
+	Namespace   string    `json:"namespace"`             // Namespace deleted
+	Owner       string    `json:"owner,omitempty"`       // Owner annotation at the time of deletion
+	Reason      string    `json:"reason"`                // Why the grace period was skipped
+	RequestedBy string    `json:"requestedBy,omitempty"` // Authenticated identity that requested the deletion
+	RequestedAt time.Time `json:"requestedAt"`           // When the deletion was requested
+	DryRun      bool      `json:"dryRun,omitempty"`      // Whether the deletion was only planned
+}
+
+// ErrBreakGlassReason is returned by DeleteNow when no reason is given
+var ErrBreakGlassReason = errors.New("a reason is required to delete a namespace immediately")
+
+// DeleteNow deletes a namespace immediately, for incidents that cannot wait
+// for the grace period. It runs the same pipeline as an expired namespace:
+// the namespace is annotated with the reason and requester, administrators
+// are notified, and the pre-delete finalizer holds it until the pre-delete
+// steps (owner notification, exports) have completed, whether or not the
+// finalizer is enabled for regular runs.
+//
+// Parameters:
+// - ctx: Context for cancellation and timeouts
+// - ns: Namespace to delete
+// - reason: Why the grace period is skipped, required
+// - requestedBy: Authenticated identity requesting the deletion, see WhoAmI
+func (p *NamespaceProcessor) DeleteNow(ctx context.Context, ns corev1.Namespace, reason, requestedBy string) (*BreakGlassRecord, *Trace, error) {
+	if reason == "" {
+		return nil, nil, ErrBreakGlassReason
+	}
+	if ns.DeletionTimestamp != nil {
+		return nil, nil, fmt.Errorf("namespace %s is already terminating", ns.Name)
+	}
+	if why, excluded := p.excludedByName(ns.Name); excluded {
+		return nil, nil, fmt.Errorf("namespace %s is excluded by policy: %s", ns.Name, why)
+	}
+
+	record := &BreakGlassRecord{
+		Namespace:   ns.Name,
+		Owner:       ns.Annotations[OwnerAnnotation],
+		Reason:      reason,
+		RequestedBy: requestedBy,
+		RequestedAt: p.now().UTC(),
+		DryRun:      p.dryRun,
+	}
+
+	tr := &Trace{Namespace: ns.Name}
+	breaker := *p
+	breaker.trace = tr
+	breaker.preDeleteFinalizer = true
+	breaker.deletions = nil // Requested explicitly, not a candidate of the run
+	tr.add("break-glass", "requested by %q: %s", requestedBy, reason)
+
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	ns.Annotations[BreakGlassAnnotation] = reason
+	if requestedBy != "" {
+		ns.Annotations[BreakGlassByAnnotation] = requestedBy
+	}
+	breaker.stampRunInfo(ns.Annotations)
+	if err := breaker.updateNamespace(ctx, &ns); err != nil {
+		tr.setAction(ActionError)
+		return record, tr, fmt.Errorf("recording break-glass reason on %s: %w", ns.Name, err)
+	}
+
+	log.Printf("Break-glass deletion of %s requested by %q: %s", ns.Name, requestedBy, reason)
+	n := Notification{
+		Event:     EventBreakGlass,
+		Namespace: ns.Name,
+		Owner:     record.Owner,
+		Message:   fmt.Sprintf("deleted immediately at the request of %s: %s", describeRequester(requestedBy), reason),
+		Context:   breaker.namespaceContext(ns),
+	}
+	breaker.addSecurityContact(ns, &n)
+	breaker.notify(ctx, n)
+
+	breaker.deleteNamespace(ns)
+	return record, tr, nil
+}
+
+// describeRequester names the requester of a break-glass deletion in messages
+func describeRequester(requestedBy string) string {
+	if requestedBy == "" {
+		return "an unidentified user"
+	}
+	return requestedBy
+}
+
+// WhoAmI returns the username the API server authenticates client as, so
+// that break-glass deletions record who requested them.
+func WhoAmI(ctx context.Context, client kubernetes.Interface) (string, error) {
+	review, err := client.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to identify the current user: %w", err)
+	}
+	return review.Status.UserInfo.Username, nil
+}
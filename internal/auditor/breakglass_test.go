@@ -0,0 +1,131 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestDeleteNow validates that a break-glass deletion skips the grace period
+// of a namespace whose owner is still valid, and leaves an audit trail
+func TestDeleteNow(t *testing.T) {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "compromised",
+		Annotations: map[string]string{OwnerAnnotation: "user@example.com"},
+	}}
+	notifier := &recordingNotifier{}
+	processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+	processor.SetNotifier(notifier)
+	processor.SetRunInfo("run-1", "hash")
+
+	var record *BreakGlassRecord
+	var tr *Trace
+	var err error
+	captureLogs(func() {
+		record, tr, err = processor.DeleteNow(context.TODO(), *ns.DeepCopy(), "incident 42", "admin@example.com")
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tr.Action != ActionDelete {
+		t.Errorf("Action = %q, want %q", tr.Action, ActionDelete)
+	}
+	if record.Reason != "incident 42" || record.RequestedBy != "admin@example.com" || record.Owner != "user@example.com" {
+		t.Errorf("Unexpected record %+v", record)
+	}
+
+	_, err = processor.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Namespace should be deleted, got %v", err)
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Event != EventBreakGlass || !strings.Contains(notifier.sent[0].Message, "incident 42") {
+		t.Errorf("Expected one break-glass notification, got %+v", notifier.sent)
+	}
+	if processor.preDeleteFinalizer {
+		t.Error("DeleteNow must not enable the pre-delete finalizer for regular runs")
+	}
+}
+
+// TestDeleteNowDryRun ensures a dry run only plans the break-glass deletion
+func TestDeleteNowDryRun(t *testing.T) {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "compromised"}}
+	processor := newTestProcessor(true, []*corev1.Namespace{&ns}, true)
+
+	var err error
+	captureLogs(func() {
+		_, _, err = processor.DeleteNow(context.TODO(), *ns.DeepCopy(), "incident 42", "")
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := processor.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{}); err != nil {
+		t.Errorf("Dry run must not delete: %v", err)
+	}
+
+	var ops []string
+	for _, c := range processor.PlannedChanges() {
+		ops = append(ops, c.Op)
+	}
+	if strings.Join(ops, ",") != "update,notify,update,delete" {
+		t.Errorf("Planned changes = %v", ops)
+	}
+}
+
+// TestDeleteNowRefuses validates the preconditions of a break-glass deletion
+func TestDeleteNowRefuses(t *testing.T) {
+	processor := newTestProcessor(true, nil, false)
+
+	_, _, err := processor.DeleteNow(context.TODO(), corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}, "", "admin")
+	if !errors.Is(err, ErrBreakGlassReason) {
+		t.Errorf("Expected missing reason error, got %v", err)
+	}
+
+	terminating := terminatingNamespace(time.Now(), nil)
+	if _, _, err := processor.DeleteNow(context.TODO(), terminating, "incident", "admin"); err == nil {
+		t.Error("Expected an error for a terminating namespace")
+	}
+}
+
+// TestNotifyDeletingBreakGlass validates the pre-delete notification of a
+// break-glass deletion mentions its reason
+func TestNotifyDeletingBreakGlass(t *testing.T) {
+	ns := terminatingNamespace(time.Now(), map[string]string{BreakGlassAnnotation: "incident 42"})
+	notifier := &recordingNotifier{}
+	processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+	processor.SetNotifier(notifier)
+
+	if err := processor.notifyDeleting(context.TODO(), ns); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(notifier.sent) != 1 || !strings.Contains(notifier.sent[0].Message, "immediately: incident 42") {
+		t.Errorf("Unexpected notifications %+v", notifier.sent)
+	}
+}
+
+// TestWhoAmI validates that the authenticated username is returned
+func TestWhoAmI(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		review := &authenticationv1.SelfSubjectReview{}
+		review.Status.UserInfo.Username = "system:serviceaccount:auditor:break-glass"
+		return true, review, nil
+	})
+
+	user, err := WhoAmI(context.TODO(), client)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if user != "system:serviceaccount:auditor:break-glass" {
+		t.Errorf("User = %q", user)
+	}
+}
@@ -0,0 +1,125 @@
+package auditor
+
+import (
+	"errors"
+	"log"
+	"sync/atomic"
+)
+
+// ErrLookupBudgetExhausted is returned when an identity lookup would exceed
+// the configured per-run budget.
+var ErrLookupBudgetExhausted = errors.New("identity lookup budget exhausted")
+
+// ErrChangeBudgetExhausted is reported for namespaces whose deletion or
+// marking was deferred because the run reached its cap, see SetChangeBudgets.
+var ErrChangeBudgetExhausted = errors.New("per-run change budget exhausted")
+
+// runBudget caps something done per run, such as identity-provider calls or
+// deletions. It is shared by all copies of a processor. A nil *runBudget is
+// unlimited.
+type runBudget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+// newRunBudget returns a budget of limit, unlimited if zero or negative
+func newRunBudget(limit int) *runBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &runBudget{limit: int64(limit)}
+}
+
+// take reserves one use, reporting false if the budget is exhausted
+func (b *runBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	if b.used.Add(1) > b.limit {
+		b.used.Add(-1)
+		return false
+	}
+	return true
+}
+
+// renewed returns an unused budget with the same limit, nil if b is nil
+func (b *runBudget) renewed() *runBudget {
+	if b == nil {
+		return nil
+	}
+	return &runBudget{limit: b.limit}
+}
+
+// SetLookupBudget caps the number of identity-provider calls made per run,
+// including prefetch. Namespaces that would need a lookup once the budget is
+// spent are deferred to the next run. Zero or negative means unlimited.
+func (p *NamespaceProcessor) SetLookupBudget(limit int) {
+	p.budget = newRunBudget(limit)
+}
+
+// IdentityLookups returns the number of budgeted identity-provider calls
+// made so far, or zero if no budget is configured.
+func (p *NamespaceProcessor) IdentityLookups() int {
+	if p.budget == nil {
+		return 0
+	}
+	return int(p.budget.used.Load())
+}
+
+// Deferred returns the number of namespaces deferred to the next run
+// because identity lookups were not possible, e.g. for lack of budget.
+func (p *NamespaceProcessor) Deferred() int {
+	return len(p.deferred.list())
+}
+
+// deferNamespace records a namespace left for the next run because its
+// owner could not be looked up, with the reason
+func (p *NamespaceProcessor) deferNamespace(name string, reason error) {
+	log.Printf("Deferring %s to the next run: %v", name, reason)
+	p.trace.add("identity", "lookup deferred: %v", reason)
+	p.trace.setAction(ActionDefer)
+	p.deferred.add(name)
+}
+
+// Changes held back by SetChangeBudgets
+const (
+	ChangeDelete = "delete"
+	ChangeMark   = "mark"
+)
+
+// BudgetDeferral is a namespace left for the next run because the run had
+// already deleted or marked as many namespaces as allowed.
+type BudgetDeferral struct {
+	Name   string `json:"name"`   // Namespace name
+	Change string `json:"change"` // ChangeDelete or ChangeMark
+}
+
+// SetChangeBudgets caps the number of namespaces deleted and marked per
+// run, so that a misconfigured identity provider reporting every owner as
+// missing cannot remove more than maxDeletions namespaces in one pass.
+// Excess candidates are logged and left for the next run. Linked
+// namespaces count as deletions of their own but are marked with their
+// primary. Zero or negative means unlimited.
+func (p *NamespaceProcessor) SetChangeBudgets(maxDeletions, maxMarks int) {
+	p.deletions = newRunBudget(maxDeletions)
+	p.marks = newRunBudget(maxMarks)
+}
+
+// OverBudget returns the namespaces whose deletion or marking was deferred
+// to the next run by the change budgets.
+func (p *NamespaceProcessor) OverBudget() []BudgetDeferral {
+	return p.overBudget.list()
+}
+
+// withinBudget takes one change of kind from budget, deferring the
+// namespace if the budget is spent
+func (p *NamespaceProcessor) withinBudget(budget *runBudget, name, change string) bool {
+	if budget.take() {
+		return true
+	}
+	log.Printf("Deferring %s of %s to the next run: %v (limit %d)", change, name, ErrChangeBudgetExhausted, budget.limit)
+	p.trace.add("budget", "%s deferred, this run reached its limit of %d", change, budget.limit)
+	p.trace.setAction(ActionDefer)
+	p.overBudget.add(BudgetDeferral{Name: name, Change: change})
+	return false
+}
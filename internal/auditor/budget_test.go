@@ -0,0 +1,116 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestLookupBudget validates that namespaces beyond the budget are deferred
+func TestLookupBudget(t *testing.T) {
+	checker := &countingChecker{calls: map[string]int{}}
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+	processor.SetLookupBudget(2)
+
+	var actions []Action
+	captureLogs(func() {
+		for _, owner := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"} {
+			tr := processor.ProcessNamespaceTraced(context.TODO(), ownedNamespace(owner, owner))
+			actions = append(actions, tr.Action)
+		}
+	})
+
+	if len(checker.calls) != 2 || processor.IdentityLookups() != 2 {
+		t.Errorf("Expected 2 lookups, got %v (counted %d)", checker.calls, processor.IdentityLookups())
+	}
+	if processor.Deferred() != 2 || actions[2] != ActionDefer || actions[3] != ActionDefer {
+		t.Errorf("Expected last two namespaces deferred, got %v (deferred %d)", actions, processor.Deferred())
+	}
+}
+
+// TestLookupBudgetPrefetch validates that prefetch draws from the same budget
+func TestLookupBudgetPrefetch(t *testing.T) {
+	checker := &countingChecker{calls: map[string]int{}}
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+	processor.SetLookupBudget(1)
+
+	namespaces := []corev1.Namespace{ownedNamespace("a", "a@example.com"), ownedNamespace("b", "b@example.com")}
+	captureLogs(func() {
+		processor.Prefetch(context.TODO(), namespaces)
+		for _, ns := range namespaces {
+			processor.ProcessNamespace(context.TODO(), ns)
+		}
+	})
+
+	if len(checker.calls) != 1 || processor.Deferred() != 1 {
+		t.Errorf("Expected one lookup and one deferral, got %v / %d", checker.calls, processor.Deferred())
+	}
+}
+
+// TestLookupBudgetUnlimited ensures no budget means no deferrals
+func TestLookupBudgetUnlimited(t *testing.T) {
+	processor := newTestProcessor(true, nil, true)
+	processor.SetLookupBudget(0)
+	for i := 0; i < 3; i++ {
+		if !processor.budget.take() {
+			t.Fatal("Unlimited budget should never be exhausted")
+		}
+	}
+	if processor.IdentityLookups() != 0 || processor.Deferred() != 0 {
+		t.Error("Unlimited budget should not count lookups")
+	}
+}
+
+// TestChangeBudgets validates that deletions and marks beyond the per-run
+// caps are deferred to the next run
+func TestChangeBudgets(t *testing.T) {
+	namespace := func(name string, marked bool) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{
+			OwnerAnnotation: name + "@example.com",
+		}}}
+		if marked {
+			ns.Annotations[GracePeriodAnnotation] = "2020-01-01T00:00:00Z"
+		}
+		return ns
+	}
+	namespaces := []*corev1.Namespace{
+		namespace("expired-a", true), namespace("expired-b", true), namespace("expired-c", true),
+		namespace("new-a", false), namespace("new-b", false),
+	}
+	processor := newTestProcessor(false, namespaces, false)
+	processor.SetChangeBudgets(2, 1)
+
+	var actions []Action
+	captureLogs(func() {
+		for _, ns := range namespaces {
+			tr := processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+			actions = append(actions, tr.Action)
+		}
+	})
+	want := []Action{ActionDelete, ActionDelete, ActionDefer, ActionMark, ActionDefer}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Errorf("%s: action = %q, want %q", namespaces[i].Name, actions[i], want[i])
+		}
+	}
+	deferred := processor.OverBudget()
+	if len(deferred) != 2 || deferred[0] != (BudgetDeferral{Name: "expired-c", Change: ChangeDelete}) ||
+		deferred[1] != (BudgetDeferral{Name: "new-b", Change: ChangeMark}) {
+		t.Errorf("OverBudget() = %+v", deferred)
+	}
+	if _, err := processor.GetNamespace(context.TODO(), "expired-c"); err != nil {
+		t.Errorf("A namespace beyond the deletion budget should be kept: %v", err)
+	}
+	if ns, _ := processor.GetNamespace(context.TODO(), "new-b"); ns.Annotations[GracePeriodAnnotation] != "" {
+		t.Error("A namespace beyond the mark budget should not be marked")
+	}
+
+	// Break-glass deletions are requested explicitly and not capped
+	if _, tr, err := processor.DeleteNow(context.TODO(), *namespace("expired-c", true), "incident", "admin"); err != nil || tr.Action != ActionDelete {
+		t.Errorf("DeleteNow = %v / %v, want a deletion", tr, err)
+	}
+}
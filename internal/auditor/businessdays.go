@@ -0,0 +1,106 @@
+package auditor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkWeek is the set of weekdays counted as business days, indexed by
+// time.Weekday.
+type WorkWeek [7]bool
+
+// DefaultWorkWeek counts Monday through Friday as business days.
+var DefaultWorkWeek = WorkWeek{
+	time.Monday:    true,
+	time.Tuesday:   true,
+	time.Wednesday: true,
+	time.Thursday:  true,
+	time.Friday:    true,
+}
+
+// weekdayNames maps three-letter day abbreviations to weekdays
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWorkWeek parses a comma-separated list of weekdays and weekday
+// ranges, e.g. "mon-fri" or "sun-thu" or "mon,tue,thu". Ranges may wrap
+// around the end of the week. An empty value yields DefaultWorkWeek.
+func ParseWorkWeek(value string) (WorkWeek, error) {
+	if strings.TrimSpace(value) == "" {
+		return DefaultWorkWeek, nil
+	}
+
+	var week WorkWeek
+	for _, entry := range strings.Split(value, ",") {
+		from, to, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(entry)), "-")
+		if !isRange {
+			to = from
+		}
+		start, ok := weekdayNames[strings.TrimSpace(from)]
+		if !ok {
+			return WorkWeek{}, fmt.Errorf("unknown weekday %q", from)
+		}
+		end, ok := weekdayNames[strings.TrimSpace(to)]
+		if !ok {
+			return WorkWeek{}, fmt.Errorf("unknown weekday %q", to)
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			week[d] = true
+			if d == end {
+				break
+			}
+		}
+	}
+	return week, nil
+}
+
+// String renders the work week as a comma-separated list of day abbreviations.
+func (w WorkWeek) String() string {
+	var days []string
+	for d, working := range w {
+		if working {
+			days = append(days, time.Weekday(d).String()[:3])
+		}
+	}
+	return strings.Join(days, ",")
+}
+
+// ParseBusinessDays parses a grace period expressed in business days, such
+// as "10bd". Returns false if the value is not in business-day form.
+func ParseBusinessDays(value string) (int, bool, error) {
+	count, ok := strings.CutSuffix(strings.TrimSpace(value), "bd")
+	if !ok {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid business day count %q", value)
+	}
+	return n, true, nil
+}
+
+// AddBusinessDays returns t advanced by n business days of the given work
+// week, keeping the time of day. Weekdays are evaluated in UTC. A marker set
+// on a non-working day starts counting from the next business day.
+func AddBusinessDays(t time.Time, n int, week WorkWeek) time.Time {
+	t = t.UTC()
+	if week == (WorkWeek{}) {
+		return t // No working days configured; avoid looping forever
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, 1)
+		if week[t.Weekday()] {
+			n--
+		}
+	}
+	return t
+}
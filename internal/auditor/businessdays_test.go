@@ -0,0 +1,82 @@
+package auditor
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseWorkWeek validates weekday list and range parsing
+func TestParseWorkWeek(t *testing.T) {
+	tests := []struct {
+		value   string // Raw WORK_WEEK value
+		want    string // Expected rendered work week
+		wantErr bool   // Whether parsing should fail
+	}{
+		{value: "", want: "Mon,Tue,Wed,Thu,Fri"},
+		{value: "mon-fri", want: "Mon,Tue,Wed,Thu,Fri"},
+		{value: "Sun-Thu", want: "Sun,Mon,Tue,Wed,Thu"},
+		{value: "fri-mon", want: "Sun,Mon,Fri,Sat"},
+		{value: "mon, wed ,fri", want: "Mon,Wed,Fri"},
+		{value: "mon-funday", wantErr: true},
+		{value: "weekdays", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseWorkWeek(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %s", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", tt.value, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Work week mismatch for %q:\nExpected: %s\nGot: %s", tt.value, tt.want, got)
+			}
+		})
+	}
+}
+
+// TestParseBusinessDays validates the "<n>bd" grace period form
+func TestParseBusinessDays(t *testing.T) {
+	if n, ok, err := ParseBusinessDays("10bd"); n != 10 || !ok || err != nil {
+		t.Errorf("Expected 10 business days, got %d %t %v", n, ok, err)
+	}
+	if _, ok, _ := ParseBusinessDays("720h"); ok {
+		t.Error("Durations should not be treated as business days")
+	}
+	if _, ok, err := ParseBusinessDays("tenbd"); !ok || err == nil {
+		t.Error("Malformed business day count should be an error")
+	}
+}
+
+// TestAddBusinessDays validates expiry computation across weekends
+func TestAddBusinessDays(t *testing.T) {
+	friday := time.Date(2024, 5, 17, 15, 0, 0, 0, time.UTC)
+	saturday := friday.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name  string    // Test scenario description
+		start time.Time // Marker timestamp
+		days  int       // Business days to add
+		week  WorkWeek  // Work week in use
+		want  time.Time // Expected expiry
+	}{
+		{name: "friday plus one", start: friday, days: 1, week: DefaultWorkWeek, want: friday.AddDate(0, 0, 3)},
+		{name: "friday plus ten", start: friday, days: 10, week: DefaultWorkWeek, want: friday.AddDate(0, 0, 14)},
+		{name: "weekend start", start: saturday, days: 1, week: DefaultWorkWeek, want: friday.AddDate(0, 0, 3)},
+		{name: "zero days", start: friday, days: 0, week: DefaultWorkWeek, want: friday},
+		{name: "empty work week", start: friday, days: 5, week: WorkWeek{}, want: friday},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddBusinessDays(tt.start, tt.days, tt.week); !got.Equal(tt.want) {
+				t.Errorf("Expiry mismatch:\nExpected: %v\nGot: %v", tt.want, got)
+			}
+		})
+	}
+}
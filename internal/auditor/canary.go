@@ -0,0 +1,173 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultCanaryGracePeriod is the compressed grace period of the canary
+// namespace unless configured otherwise. It is shorter than any sensible
+// run interval, so that the canary is deleted on the run after it is marked.
+const DefaultCanaryGracePeriod = 10 * time.Minute
+
+// CanaryLabel identifies the synthetic canary namespace maintained by the
+// auditor.
+const CanaryLabel = "namespace-auditor/canary"
+
+// Stages of the canary namespace reported in CanaryResult
+const (
+	CanaryCreated     = "created"     // Canary created and marked for deletion
+	CanaryMarked      = "marked"      // Existing canary marked for deletion
+	CanaryWaiting     = "waiting"     // Canary marked, grace period still running
+	CanaryDeleted     = "deleted"     // Grace period expired, canary deleted
+	CanaryTerminating = "terminating" // Canary deleted by an earlier run, still terminating
+)
+
+// Canary configures the synthetic canary namespace. Owner must be an
+// address in an allowed domain that the identity provider does not know.
+type Canary struct {
+	Namespace   string        // Name of the canary namespace
+	Owner       string        // Known-invalid owner of the canary
+	GracePeriod time.Duration // Compressed grace period of the canary
+}
+
+// CanaryResult records whether the canary namespace progressed through its
+// mark and delete cycle as expected during a run.
+type CanaryResult struct {
+	Namespace string `json:"namespace"`        // Name of the canary namespace
+	Stage     string `json:"stage"`            // Stage reached during the run
+	Passed    bool   `json:"passed"`           // Whether the stage was the expected one
+	Detail    string `json:"detail,omitempty"` // What went wrong, if the canary failed
+}
+
+// SetCanary makes the processor maintain a canary namespace, see RunCanary.
+// The canary is left out of regular auditing. A nil canary disables it.
+func (p *NamespaceProcessor) SetCanary(c *Canary) {
+	p.canary = c
+}
+
+// RunCanary checks the canary namespace, creating it if needed, and evaluates
+// it with its compressed grace period. A run creates and marks the canary;
+// the next run after its grace period deletes it, and a later run creates it
+// again. Any other outcome fails the canary, proving that the identity
+// lookup, the marking or the deletion is broken. Reports false if no canary
+// is configured.
+func (p *NamespaceProcessor) RunCanary(ctx context.Context) (CanaryResult, bool) {
+	if p.canary == nil {
+		return CanaryResult{}, false
+	}
+	result := p.checkCanary(ctx)
+	if result.Passed {
+		log.Printf("Canary %s passed: %s", result.Namespace, result.Stage)
+	} else {
+		log.Printf("Canary %s FAILED at stage %q: %s", result.Namespace, result.Stage, result.Detail)
+	}
+	return result, true
+}
+
+// checkCanary evaluates the canary namespace, see RunCanary
+func (p *NamespaceProcessor) checkCanary(ctx context.Context) CanaryResult {
+	c := p.canary
+	result := CanaryResult{Namespace: c.Namespace}
+	now := p.now()
+
+	ns, err := p.GetNamespace(ctx, c.Namespace)
+	switch {
+	case apierrors.IsNotFound(err):
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        c.Namespace,
+			Labels:      map[string]string{CanaryLabel: "true"},
+			Annotations: map[string]string{OwnerAnnotation: c.Owner},
+		}}
+		if err := p.mutations().createNamespace(ctx, ns); err != nil {
+			result.Detail = fmt.Sprintf("creating canary: %v", err)
+			return result
+		}
+		result.Stage = CanaryCreated
+	case err != nil:
+		result.Detail = fmt.Sprintf("reading canary: %v", err)
+		return result
+	case ns.DeletionTimestamp != nil:
+		result.Stage = CanaryTerminating
+		threshold := p.stuckThreshold
+		if threshold <= 0 {
+			threshold = DefaultStuckThreshold
+		}
+		if since := now.Sub(ns.DeletionTimestamp.Time); since >= threshold {
+			result.Detail = fmt.Sprintf("terminating for %s", since.Round(time.Second))
+			return result
+		}
+		result.Passed = true
+		return result
+	}
+
+	expected := ActionMark
+	if markedAt, marked := ns.Annotations[GracePeriodAnnotation]; marked {
+		t, err := parseMarkerTime(markedAt)
+		if err != nil {
+			result.Detail = fmt.Sprintf("unreadable deletion marker %q", markedAt)
+			return result
+		}
+		expected = ActionWait
+		if now.After(t.Add(c.GracePeriod)) {
+			expected = ActionDelete
+		}
+	}
+
+	tr := p.canaryProcessor().ProcessNamespaceTraced(ctx, *ns)
+	switch {
+	case tr.Action == expected, expected == ActionWait && tr.Action == ActionDelete:
+		result.Passed = true
+	case tr.Error != "":
+		result.Detail = fmt.Sprintf("expected %s, got %s: %s", expected, tr.Action, tr.Error)
+	default:
+		result.Detail = fmt.Sprintf("expected %s, got %s", expected, tr.Action)
+	}
+	if result.Stage == "" || tr.Action != ActionMark {
+		result.Stage = canaryStage(tr.Action)
+	}
+	return result
+}
+
+// canaryProcessor returns a copy of the processor that evaluates the canary
+// through the regular pipeline, with a real identity lookup, its compressed
+// grace period and none of the policies meant to slow down or hold back
+// decisions on real namespaces.
+func (p *NamespaceProcessor) canaryProcessor() *NamespaceProcessor {
+	q := *p
+	q.canary = nil
+	q.grace = FixedGracePeriod(p.canary.GracePeriod)
+	q.pauseWindows = nil
+	q.clockSkew = 0
+	q.expiryAction = ExpiryDelete
+	q.preDeleteFinalizer = false
+	q.notifier = LogNotifier{}
+	q.resolved = nil
+	q.lookups = nil
+	q.evaluations = nil
+	q.flapDamping = 0
+	q.missingConfirmations = 0
+	q.twoPersonThreshold = resource.Quantity{}
+	q.marked = nil // The canary is not a namespace anyone needs to act on
+	return &q
+}
+
+// canaryStage maps the action taken on the canary to its stage
+func canaryStage(action Action) string {
+	switch action {
+	case ActionMark:
+		return CanaryMarked
+	case ActionWait:
+		return CanaryWaiting
+	case ActionDelete:
+		return CanaryDeleted
+	}
+	return string(action)
+}
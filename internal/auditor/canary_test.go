@@ -0,0 +1,105 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newCanaryProcessor returns a processor maintaining a canary
+func newCanaryProcessor(ownerExists bool, namespaces ...*corev1.Namespace) *NamespaceProcessor {
+	p := newTestProcessor(ownerExists, namespaces, false)
+	p.SetCanary(&Canary{Namespace: "auditor-canary", Owner: "canary@example.com", GracePeriod: time.Hour})
+	return p
+}
+
+// runCanary runs the canary check with logs captured
+func runCanary(t *testing.T, p *NamespaceProcessor) CanaryResult {
+	t.Helper()
+	var result CanaryResult
+	var ok bool
+	captureLogs(func() {
+		result, ok = p.RunCanary(context.TODO())
+	})
+	if !ok {
+		t.Fatal("Canary should be configured")
+	}
+	return result
+}
+
+// TestCanaryCycle validates that the canary is created and marked, deleted
+// once its grace period expires, and created again
+func TestCanaryCycle(t *testing.T) {
+	p := newCanaryProcessor(false)
+
+	for run, want := range []string{CanaryCreated, CanaryDeleted, CanaryCreated} {
+		result := runCanary(t, p)
+		if !result.Passed || result.Stage != want {
+			t.Fatalf("Run %d: %+v, want passed at stage %q", run, result, want)
+		}
+		if run == 1 {
+			continue
+		}
+		ns, err := p.GetNamespace(context.TODO(), "auditor-canary")
+		if err != nil {
+			t.Fatalf("Run %d: canary should exist: %v", run, err)
+		}
+		if ns.Labels[CanaryLabel] != "true" || ns.Annotations[GracePeriodAnnotation] == "" {
+			t.Errorf("Run %d: canary should be labeled and marked: %+v", run, ns.ObjectMeta)
+		}
+		ns.Annotations[GracePeriodAnnotation] = "2020-01-01T00:00:00Z" // Let the grace period expire
+		if _, err := p.k8sClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("Run %d: backdating marker: %v", run, err)
+		}
+	}
+}
+
+// TestCanaryFailsWhenOwnerFound validates that a canary whose known-invalid
+// owner is reported as existing fails
+func TestCanaryFailsWhenOwnerFound(t *testing.T) {
+	p := newCanaryProcessor(true)
+
+	result := runCanary(t, p)
+	if result.Passed || result.Detail == "" {
+		t.Errorf("Canary with a valid owner should fail: %+v", result)
+	}
+}
+
+// TestCanaryStuckTerminating validates that a canary stuck terminating fails
+func TestCanaryStuckTerminating(t *testing.T) {
+	deleted := metav1.NewTime(time.Now().Add(-2 * DefaultStuckThreshold))
+	p := newCanaryProcessor(false, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              "auditor-canary",
+		DeletionTimestamp: &deleted,
+		Finalizers:        []string{"kubernetes"},
+	}})
+
+	result := runCanary(t, p)
+	if result.Passed || result.Stage != CanaryTerminating {
+		t.Errorf("Stuck canary should fail: %+v", result)
+	}
+}
+
+// TestCanarySkippedByRegularAudit ensures only the canary check acts on the canary
+func TestCanarySkippedByRegularAudit(t *testing.T) {
+	canary := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "auditor-canary",
+		Annotations: map[string]string{OwnerAnnotation: "canary@example.com", GracePeriodAnnotation: "2020-01-01T00:00:00Z"},
+	}}
+	p := newCanaryProcessor(false, canary)
+
+	var d Decision
+	captureLogs(func() {
+		d = p.ProcessNamespace(context.TODO(), *canary.DeepCopy())
+	})
+	if d.Action != ActionSkip {
+		t.Errorf("Action = %q, want %q", d.Action, ActionSkip)
+	}
+	if _, err := p.GetNamespace(context.TODO(), canary.Name); apierrors.IsNotFound(err) {
+		t.Error("Regular audit must not delete the canary")
+	}
+}
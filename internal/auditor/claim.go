@@ -0,0 +1,80 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// handleClaim applies a pending ownership claim. A claim is only honored for
+// orphaned namespaces (marked for deletion or without an owner), and only if
+// the claimant has an allowed domain, exists in the identity provider and is
+// already a contributor of the namespace. On success the owner annotation is
+// rewritten and the deletion marker cleared. Returns whether the claim was
+// applied; rejected claims are left in place and processing continues.
+func (p *NamespaceProcessor) handleClaim(ctx context.Context, ns corev1.Namespace, claimant string) bool {
+	if err := p.validateClaim(ctx, ns, claimant); err != nil {
+		log.Printf("Rejecting claim of %s by %s: %v", ns.Name, claimant, err)
+		p.trace.add("claim", "claim by %q rejected: %v", claimant, err)
+		return false
+	}
+
+	log.Printf("Transferring ownership of %s to %s", ns.Name, claimant)
+	p.trace.add("claim", "claim by %q accepted, previous owner %q", claimant, ns.Annotations[OwnerAnnotation])
+	p.trace.setAction(ActionClaim)
+
+	ns.Annotations[OwnerAnnotation] = claimant
+	delete(ns.Annotations, ClaimAnnotation)
+	if p.ownsMarker(ns) {
+		clearMarker(ns.Annotations)
+	}
+	clearOrphan(ns.Annotations)
+	if err := p.updateNamespace(ctx, &ns); err != nil {
+		log.Printf("Error applying claim on %s: %v", ns.Name, err)
+	}
+	return true
+}
+
+// validateClaim checks whether claimant may take over the namespace
+func (p *NamespaceProcessor) validateClaim(ctx context.Context, ns corev1.Namespace, claimant string) error {
+	_, marked := ns.Annotations[GracePeriodAnnotation]
+	if !marked && !IsOwnerless(ns) && !isOrphaned(ns) {
+		return fmt.Errorf("namespace is not orphaned")
+	}
+	if !isValidDomain(claimant, p.allowedDomains) {
+		return fmt.Errorf("domain not in allowed domains %v", p.allowedDomains)
+	}
+
+	contributors, err := p.contributors(ctx, ns)
+	if err != nil {
+		return err
+	}
+	if !contains(contributors, claimant) {
+		return fmt.Errorf("not a contributor of the namespace")
+	}
+
+	if !p.budget.take() {
+		return ErrLookupBudgetExhausted
+	}
+	exists, err := p.azureClient.UserExists(ctx, claimant)
+	if err != nil {
+		return fmt.Errorf("checking user: %w", err)
+	}
+	if !exists {
+		return errs.ErrUserNotFound
+	}
+	return nil
+}
+
+// contains reports whether list includes s
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package auditor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestHandleClaim validates the ownership claim workflow
+func TestHandleClaim(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		contributor bool
+		dryRun      bool
+		wantAction  Action
+		wantOwner   string
+	}{
+		{
+			name: "accepted on marked namespace",
+			annotations: map[string]string{
+				OwnerAnnotation:       "gone@example.com",
+				GracePeriodAnnotation: "2099-01-01T00:00:00Z",
+				ClaimAnnotation:       "new@example.com",
+			},
+			contributor: true,
+			wantAction:  ActionClaim,
+			wantOwner:   "new@example.com",
+		},
+		{
+			name:        "accepted on ownerless namespace",
+			annotations: map[string]string{ClaimAnnotation: "new@example.com"},
+			contributor: true,
+			wantAction:  ActionClaim,
+			wantOwner:   "new@example.com",
+		},
+		{
+			name: "rejected for non-contributor",
+			annotations: map[string]string{
+				OwnerAnnotation:       "gone@example.com",
+				GracePeriodAnnotation: "2099-01-01T00:00:00Z",
+				ClaimAnnotation:       "new@example.com",
+			},
+			wantAction: ActionUnmark, // processing continues; the mock reports every user as existing
+			wantOwner:  "gone@example.com",
+		},
+		{
+			name: "rejected on namespace that is not orphaned",
+			annotations: map[string]string{
+				OwnerAnnotation: "owner@example.com",
+				ClaimAnnotation: "new@example.com",
+			},
+			contributor: true,
+			wantAction:  ActionNone,
+			wantOwner:   "owner@example.com",
+		},
+		{
+			name: "dry run leaves namespace unchanged",
+			annotations: map[string]string{
+				OwnerAnnotation:       "gone@example.com",
+				GracePeriodAnnotation: "2099-01-01T00:00:00Z",
+				ClaimAnnotation:       "new@example.com",
+			},
+			contributor: true,
+			dryRun:      true,
+			wantAction:  ActionClaim,
+			wantOwner:   "gone@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "claimed", Annotations: tt.annotations}}
+			processor := newTestProcessor(true, []*corev1.Namespace{&ns}, tt.dryRun)
+			if tt.contributor {
+				createBinding(processor, ns.Name, "user-new", rbacv1.Subject{Kind: rbacv1.UserKind, Name: "new@example.com"})
+			}
+
+			var tr *Trace
+			logOutput := captureLogs(func() {
+				tr = processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+			})
+			if tr.Action != tt.wantAction {
+				t.Errorf("Action = %q, want %q", tr.Action, tt.wantAction)
+			}
+			if tt.wantAction != ActionClaim && !strings.Contains(logOutput, "Rejecting claim") {
+				t.Errorf("Rejection not logged: %q", logOutput)
+			}
+
+			updatedNs, _ := processor.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+			if got := updatedNs.Annotations[OwnerAnnotation]; got != tt.wantOwner {
+				t.Errorf("Owner = %q, want %q", got, tt.wantOwner)
+			}
+			if tt.wantAction == ActionClaim && !tt.dryRun {
+				if _, exists := updatedNs.Annotations[GracePeriodAnnotation]; exists {
+					t.Error("Deletion marker should be cleared")
+				}
+				if _, exists := updatedNs.Annotations[ClaimAnnotation]; exists {
+					t.Error("Claim annotation should be removed")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package auditor
+
+import "sync"
+
+// collector gathers report entries during a run. It is shared by all copies
+// of a processor and safe for concurrent use. A nil *collector discards
+// additions.
+type collector[T any] struct {
+	mu    sync.Mutex
+	items []T
+}
+
+// add records an entry
+func (c *collector[T]) add(item T) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = append(c.items, item)
+}
+
+// list returns a copy of the recorded entries in insertion order
+func (c *collector[T]) list() []T {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]T(nil), c.items...)
+}
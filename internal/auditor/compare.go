@@ -0,0 +1,84 @@
+package auditor
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PolicyDifference is a namespace on which a proposed policy would decide
+// differently from the current one.
+type PolicyDifference struct {
+	Namespace      string `json:"namespace"`      // Namespace evaluated under both policies
+	Current        Action `json:"current"`        // Action of the current policy
+	Proposed       Action `json:"proposed"`       // Action of the proposed policy
+	CurrentReason  string `json:"currentReason"`  // Last trace step of the current policy
+	ProposedReason string `json:"proposedReason"` // Last trace step of the proposed policy
+}
+
+// ComparePolicies evaluates namespaces under the current and the proposed
+// processor without modifying them, as Explain does, and returns the
+// namespaces whose actions differ, sorted by name. currentScope and
+// proposedScope are the namespaces each policy selects; a namespace selected
+// by only one of them is skipped by the other. The evaluations use neither
+// processor's collectors, lookup budget or change budgets.
+func ComparePolicies(ctx context.Context, current, proposed *NamespaceProcessor, currentScope, proposedScope []corev1.Namespace) []PolicyDifference {
+	current, proposed = current.forComparison(), proposed.forComparison()
+	current.Prefetch(ctx, currentScope)
+	proposed.Prefetch(ctx, proposedScope)
+
+	namespaces := map[string]corev1.Namespace{}
+	inCurrent := map[string]bool{}
+	inProposed := map[string]bool{}
+	for _, ns := range currentScope {
+		namespaces[ns.Name] = ns
+		inCurrent[ns.Name] = true
+	}
+	for _, ns := range proposedScope {
+		namespaces[ns.Name] = ns
+		inProposed[ns.Name] = true
+	}
+	var names []string
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diffs []PolicyDifference
+	for _, name := range names {
+		ns := namespaces[name]
+		cur := compareTrace(ctx, current, ns, inCurrent[name])
+		prop := compareTrace(ctx, proposed, ns, inProposed[name])
+		if cur.Action == prop.Action {
+			continue
+		}
+		diffs = append(diffs, PolicyDifference{
+			Namespace:      name,
+			Current:        cur.Action,
+			Proposed:       prop.Action,
+			CurrentReason:  lastStep(cur),
+			ProposedReason: lastStep(prop),
+		})
+	}
+	return diffs
+}
+
+// compareTrace explains ns under p, or records a skip if p does not select it
+func compareTrace(ctx context.Context, p *NamespaceProcessor, ns corev1.Namespace, selected bool) *Trace {
+	if selected {
+		return p.Explain(ctx, ns)
+	}
+	tr := &Trace{Namespace: ns.Name}
+	tr.add("scope", "namespace does not match the label selector")
+	tr.setAction(ActionSkip)
+	return tr
+}
+
+// lastStep returns the detail of the final step of a trace
+func lastStep(t *Trace) string {
+	if len(t.Steps) == 0 {
+		return ""
+	}
+	return t.Steps[len(t.Steps)-1].Detail
+}
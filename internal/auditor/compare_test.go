@@ -0,0 +1,63 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TestComparePolicies validates that only namespaces decided differently are
+// reported, and that neither policy plans any change
+func TestComparePolicies(t *testing.T) {
+	current := newTestProcessor(true, nil, false)
+	proposed := newTestProcessor(true, nil, false)
+	proposed.allowedDomains = []string{"example.org"}
+
+	same := ownedNamespace("same", "one@example.net")
+	narrowed := ownedNamespace("narrowed", "two@example.com")
+	widened := ownedNamespace("widened", "three@example.org")
+	selected := ownedNamespace("selected", "four@example.org")
+
+	var diffs []PolicyDifference
+	captureLogs(func() {
+		diffs = ComparePolicies(context.TODO(), current, proposed,
+			[]corev1.Namespace{same, narrowed, widened},
+			[]corev1.Namespace{same, narrowed, widened, selected})
+	})
+
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Namespace+":"+string(d.Current)+"->"+string(d.Proposed))
+	}
+	want := []string{"narrowed:none->skip", "selected:skip->none", "widened:skip->none"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Differences = %v, want %v", got, want)
+	}
+	if diffs[1].CurrentReason != "namespace does not match the label selector" {
+		t.Errorf("CurrentReason = %q", diffs[1].CurrentReason)
+	}
+	if len(current.PlannedChanges()) != 0 || len(proposed.PlannedChanges()) != 0 {
+		t.Errorf("Comparison must not plan changes, got %v and %v", current.PlannedChanges(), proposed.PlannedChanges())
+	}
+}
+
+// TestComparePoliciesIsolated validates that comparisons leave the budgets
+// and collectors of the compared processors untouched
+func TestComparePoliciesIsolated(t *testing.T) {
+	current := newTestProcessor(true, nil, false)
+	current.SetLookupBudget(1)
+	proposed := newTestProcessor(true, nil, false)
+	proposed.allowedDomains = []string{"example.org"}
+	namespaces := []corev1.Namespace{ownedNamespace("one", "one@example.com"), ownedNamespace("two", "two@example.com")}
+
+	var diffs []PolicyDifference
+	captureLogs(func() { diffs = ComparePolicies(context.TODO(), current, proposed, namespaces, namespaces) })
+	if len(diffs) != 2 || diffs[1].Current != ActionDefer {
+		t.Errorf("Differences = %+v, want the second lookup deferred by the budget", diffs)
+	}
+	if current.IdentityLookups() != 0 || current.Deferred() != 0 {
+		t.Errorf("Comparison used the budget of the run: %d lookups, %d deferred", current.IdentityLookups(), current.Deferred())
+	}
+}
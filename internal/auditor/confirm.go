@@ -0,0 +1,69 @@
+package auditor
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SetMissingConfirmations requires an owner to be found missing on runs
+// consecutive runs before the namespace is marked, so that a transient
+// lookup failure or a misconfigured tenant cannot mark every namespace at
+// once. Zero or one marks on the first run.
+func (p *NamespaceProcessor) SetMissingConfirmations(runs int) {
+	if runs < 0 {
+		runs = 0
+	}
+	p.missingConfirmations = runs
+}
+
+// missingRuns returns the number of consecutive runs that found the owner
+// of a namespace missing, as recorded in MissingConfirmationsAnnotation
+func missingRuns(annotations map[string]string) int {
+	n, err := strconv.Atoi(annotations[MissingConfirmationsAnnotation])
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// unconfirmed reports whether marking ns must wait for the owner to be found
+// missing on more runs. The run is counted on the namespace. Otherwise the
+// count is dropped from ns, to be written with the marker itself.
+func (p *NamespaceProcessor) unconfirmed(ns corev1.Namespace) bool {
+	runs := missingRuns(ns.Annotations) + 1
+	if p.missingConfirmations <= 1 || runs >= p.missingConfirmations {
+		if p.missingConfirmations > 1 {
+			p.trace.add("confirm", "owner missing on %d consecutive runs, confirmed", runs)
+		}
+		delete(ns.Annotations, MissingConfirmationsAnnotation)
+		return false
+	}
+
+	log.Printf("Not marking %s yet: owner missing on %d of %d required runs", ns.Name, runs, p.missingConfirmations)
+	p.trace.add("confirm", "owner missing on %d of %d required consecutive runs", runs, p.missingConfirmations)
+	p.trace.setAction(ActionConfirm)
+
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	ns.Annotations[MissingConfirmationsAnnotation] = strconv.Itoa(runs)
+	if err := p.updateNamespace(context.TODO(), &ns); err != nil {
+		log.Printf("Error recording missing owner on %s: %v", ns.Name, err)
+	}
+	return true
+}
+
+// resetConfirmations drops the missing-owner count of a namespace whose
+// owner was found, so that only consecutive runs count. Reports whether the
+// caller must write ns.
+func (p *NamespaceProcessor) resetConfirmations(ns corev1.Namespace) bool {
+	if _, ok := ns.Annotations[MissingConfirmationsAnnotation]; !ok {
+		return false
+	}
+	p.trace.add("confirm", "owner found again after %d missing runs, count reset", missingRuns(ns.Annotations))
+	delete(ns.Annotations, MissingConfirmationsAnnotation)
+	return true
+}
@@ -0,0 +1,69 @@
+package auditor
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestMissingConfirmations validates that a namespace is only marked once its
+// owner was missing on the required number of consecutive runs
+func TestMissingConfirmations(t *testing.T) {
+	p := newTestProcessor(false, []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team",
+		Annotations: map[string]string{OwnerAnnotation: "user@example.com"},
+	}}}, false)
+	p.SetMissingConfirmations(3)
+
+	for run, want := range []string{"1", "2"} {
+		tr, ns := runOnce(t, p, "team", false)
+		if tr.Action != ActionConfirm || ns.Annotations[MissingConfirmationsAnnotation] != want {
+			t.Fatalf("Run %d: Action = %q, count %q, want %q / %q", run, tr.Action, ns.Annotations[MissingConfirmationsAnnotation], ActionConfirm, want)
+		}
+		if _, marked := ns.Annotations[GracePeriodAnnotation]; marked {
+			t.Fatalf("Run %d: namespace marked before confirmation", run)
+		}
+	}
+
+	tr, ns := runOnce(t, p, "team", false)
+	if tr.Action != ActionMark {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionMark)
+	}
+	if _, ok := ns.Annotations[MissingConfirmationsAnnotation]; ok {
+		t.Errorf("Confirmation count should be removed once marked: %v", ns.Annotations)
+	}
+}
+
+// TestMissingConfirmationsReset validates that only consecutive runs count
+func TestMissingConfirmationsReset(t *testing.T) {
+	p := newTestProcessor(false, []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team",
+		Annotations: map[string]string{OwnerAnnotation: "user@example.com"},
+	}}}, false)
+	p.SetMissingConfirmations(2)
+
+	if tr, _ := runOnce(t, p, "team", false); tr.Action != ActionConfirm {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionConfirm)
+	}
+	if _, ns := runOnce(t, p, "team", true); ns.Annotations[MissingConfirmationsAnnotation] != "" {
+		t.Fatalf("Count should be reset once the owner is found: %v", ns.Annotations)
+	}
+	if tr, _ := runOnce(t, p, "team", false); tr.Action != ActionConfirm {
+		t.Errorf("Action = %q, want %q after the reset", tr.Action, ActionConfirm)
+	}
+}
+
+// TestMissingConfirmationsDisabled ensures namespaces are marked on the first
+// run by default
+func TestMissingConfirmationsDisabled(t *testing.T) {
+	p := newTestProcessor(false, []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team",
+		Annotations: map[string]string{OwnerAnnotation: "user@example.com"},
+	}}}, false)
+	p.SetMissingConfirmations(1)
+
+	if tr, _ := runOnce(t, p, "team", false); tr.Action != ActionMark {
+		t.Errorf("Action = %q, want %q", tr.Action, ActionMark)
+	}
+}
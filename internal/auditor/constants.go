@@ -11,10 +11,186 @@ const (
 	OwnerAnnotation = "owner"
 
 	// GracePeriodAnnotation defines the annotation key for deletion timestamps.
-	// Format: RFC3339 timestamp (e.g., "2006-01-02T15:04:05Z07:00")
+	// Format: RFC3339 timestamp (e.g., "2006-01-02T15:04:05Z07:00"), always written in UTC.
+	// Values with other offsets are accepted and normalized when read.
 	// Set when a namespace is marked for deletion, used to track grace period expiration.
 	GracePeriodAnnotation = "namespace-auditor/delete-at"
 
+	// RunIDAnnotation records the ID of the audit run that last added or refreshed
+	// the deletion marker. Matches the runId field of that run's report.
+	// Written and removed together with GracePeriodAnnotation.
+	RunIDAnnotation = "namespace-auditor/run-id"
+
+	// ConfigHashAnnotation records the hash of the effective configuration used by
+	// the run that last added or refreshed the deletion marker.
+	// Written and removed together with GracePeriodAnnotation.
+	ConfigHashAnnotation = "namespace-auditor/config-hash"
+
+	// PausedAnnotation records the grace-period time accumulated inside configured
+	// pause windows since the namespace was marked, as a Go duration (e.g. "336h0m0s").
+	// It extends the grace period and is removed together with GracePeriodAnnotation.
+	PausedAnnotation = "namespace-auditor/paused-for"
+
+	// OwnerStateChangedAnnotation records when the owner's account changed state
+	// (e.g. was deleted), as reported by the identity provider when the namespace
+	// was marked. Format: RFC3339 timestamp in UTC. Removed together with
+	// GracePeriodAnnotation.
+	OwnerStateChangedAnnotation = "namespace-auditor/owner-state-changed-at"
+
+	// VerdictSourceAnnotation records which identity provider, directory endpoint
+	// and lookup method reported the owner missing when the namespace was marked,
+	// formatted like "provider=azure endpoint=https://graph.microsoft.com method=upn"
+	// (see identity.Provenance). Removed together with GracePeriodAnnotation.
+	VerdictSourceAnnotation = "namespace-auditor/verdict-source"
+
+	// ManagerAnnotation records the manager of the owner when the namespace was
+	// marked, with manager escalation enabled (see SetManagerEscalation), so
+	// that operators know whom to reassign it to. Removed together with
+	// GracePeriodAnnotation.
+	ManagerAnnotation = "namespace-auditor/owner-manager"
+
+	// MalformedSinceAnnotation records when the auditor first found the deletion
+	// marker of a namespace unreadable, with the "expire" malformed-marker action.
+	// Format: RFC3339 timestamp in UTC. Removed together with GracePeriodAnnotation.
+	MalformedSinceAnnotation = "namespace-auditor/malformed-since"
+
+	// FlapCountAnnotation counts how often the auditor removed its deletion marker
+	// because the owner was found again. It outlives the marker so that namespaces
+	// oscillating between marked and cleared can be detected across runs.
+	FlapCountAnnotation = "namespace-auditor/flap-count"
+
+	// LastFlapAnnotation records when FlapCountAnnotation was last incremented.
+	// Format: RFC3339 timestamp in UTC.
+	LastFlapAnnotation = "namespace-auditor/last-flap-at"
+
+	// DampingAnnotation holds back a state change on a flapping namespace until it
+	// has been observed on enough consecutive runs. Format: "<state>:<runs>", where
+	// state is "missing" (owner not found) or "valid" (owner found again).
+	DampingAnnotation = "namespace-auditor/damping"
+
+	// MissingConfirmationsAnnotation counts the consecutive runs that found the owner
+	// of an unmarked namespace missing, while marking waits for confirmation. Removed
+	// once the namespace is marked or its owner is found again.
+	MissingConfirmationsAnnotation = "namespace-auditor/missing-confirmations"
+
+	// DecommissionedAnnotation records when a namespace was cordoned instead of deleted
+	// after its grace period expired. Format: RFC3339 timestamp in UTC.
+	DecommissionedAnnotation = "namespace-auditor/decommissioned-at"
+
+	// QuarantinedAtAnnotation records when a namespace whose grace period
+	// expired was quarantined; it is deleted once the quarantine period has
+	// passed (see SetQuarantine). Removed when the quarantine is lifted.
+	// Format: RFC3339 timestamp in UTC.
+	QuarantinedAtAnnotation = "namespace-auditor/quarantined-at"
+
+	// QuarantineReplicasAnnotation records, on Deployments and StatefulSets
+	// scaled to zero by a quarantine, their replicas beforehand, restored
+	// when the quarantine is lifted. Format: integer.
+	QuarantineReplicasAnnotation = "namespace-auditor/quarantine-replicas"
+
+	// OrphanedAnnotation is set to "true" on namespaces kept as orphaned after
+	// their grace period expired, with the reassign expiry action. Removed once
+	// the namespace is claimed or its owner annotation is changed.
+	OrphanedAnnotation = "namespace-auditor/orphaned"
+
+	// OrphanedAtAnnotation records when a namespace was orphaned; it is deleted
+	// once the orphan period has passed. Format: RFC3339 timestamp in UTC.
+	// Removed together with OrphanedAnnotation.
+	OrphanedAtAnnotation = "namespace-auditor/orphaned-at"
+
+	// PreviousOwnerAnnotation records the owner of an orphaned namespace before
+	// it was handed to the fallback owner. Removed together with
+	// OrphanedAnnotation.
+	PreviousOwnerAnnotation = "namespace-auditor/previous-owner"
+
+	// BackupAnnotation records where the contents of a namespace were archived
+	// just before the auditor deleted it, with backups enabled (see SetBackup).
+	// Format: the archive URL, e.g. "s3://bucket/prefix/<namespace>/<time>.yaml.gz".
+	BackupAnnotation = "namespace-auditor/backup-url"
+
+	// VolumeSnapshotsAnnotation lists the VolumeSnapshotContents taken of the
+	// volumes of a namespace just before the auditor deleted it, with volume
+	// snapshots enabled (see SetVolumeSnapshots).
+	// Format: comma-separated names, e.g. "snapcontent-1234,snapcontent-5678".
+	VolumeSnapshotsAnnotation = "namespace-auditor/volume-snapshots"
+
+	// ClaimAnnotation is set by a contributor to take over ownership of an orphaned
+	// namespace. Expected format: "user@domain.com". Removed once the claim is applied.
+	ClaimAnnotation = "namespace-auditor/claim-by"
+
+	// BreakGlassAnnotation records why a namespace was deleted immediately, without
+	// a grace period, by the delete-now command. Set just before deletion so that
+	// pre-delete steps and the audit record can tell a break-glass deletion apart.
+	BreakGlassAnnotation = "namespace-auditor/break-glass-reason"
+
+	// BreakGlassByAnnotation records the authenticated identity that requested a
+	// break-glass deletion. Written together with BreakGlassAnnotation.
+	BreakGlassByAnnotation = "namespace-auditor/break-glass-by"
+
+	// ApprovalRequestedAnnotation records when approval to delete an expired
+	// sensitive namespace was requested from its security contact. Format: RFC3339
+	// timestamp in UTC. Removed together with GracePeriodAnnotation.
+	ApprovalRequestedAnnotation = "namespace-auditor/approval-requested-at"
+
+	// DeletionApprovedAnnotation is set by the security team to the approver's
+	// identity to allow deleting an expired sensitive namespace that requires
+	// approval. Removed together with GracePeriodAnnotation, so that every marker
+	// needs its own approval.
+	DeletionApprovedAnnotation = "namespace-auditor/deletion-approved-by"
+
+	// SecondApprovalAnnotation is set to the identity of a second approver, distinct
+	// from DeletionApprovedAnnotation, to allow deleting an expired namespace whose
+	// storage exceeds the two-person threshold. Removed together with
+	// GracePeriodAnnotation.
+	SecondApprovalAnnotation = "namespace-auditor/deletion-second-approved-by"
+
+	// ReminderAnnotation records the last reminder sent about a marked namespace,
+	// "halfway" or "final", so that each is sent once per marker. Removed
+	// together with GracePeriodAnnotation.
+	ReminderAnnotation = "namespace-auditor/reminded"
+
+	// SecondaryContactAnnotation names someone to notify about the namespace
+	// besides its owner, e.g. a team lead. Set by owners; notifications carry
+	// it as Notification.Contact.
+	SecondaryContactAnnotation = "namespace-auditor/secondary-contact"
+
+	// ExemptAnnotation set to "true" exempts a namespace from auditing until the
+	// annotation is removed, e.g. to protect critical shared namespaces. Takes
+	// precedence over ExemptUntilAnnotation.
+	ExemptAnnotation = "namespace-auditor/exempt"
+
+	// ExemptUntilAnnotation exempts a namespace from auditing until the given time.
+	// Format: RFC3339 timestamp, or a date (e.g. "2025-12-31") to exempt it through
+	// the end of that day in UTC. Once expired, the namespace is audited again and
+	// the expired exemption is listed in the run report until the annotation is
+	// removed or extended.
+	ExemptUntilAnnotation = "namespace-auditor/exempt-until"
+
+	// GracePeriodOverrideAnnotation lets cluster admins set the grace period of a
+	// single namespace, overriding the global or policy grace period.
+	// Format: Go duration (e.g. "1440h"), positive and at most the configured
+	// maximum (see NamespaceProcessor.SetGracePeriodOverrideMax), otherwise it
+	// is ignored and reported.
+	GracePeriodOverrideAnnotation = "namespace-auditor/grace-period"
+
+	// LinkedToLabel, set on a companion namespace, names the primary namespace it
+	// belongs to, e.g. "alice" on "alice-serving". Linked namespaces are marked,
+	// reported and removed together with their primary.
+	LinkedToLabel = "namespace-auditor/linked-to"
+
+	// SkipPreDeleteAnnotation, set to "true" on a terminating namespace, releases the
+	// pre-delete finalizer without waiting for pre-delete steps to complete.
+	SkipPreDeleteAnnotation = "namespace-auditor/skip-pre-delete"
+
+	// PreDeleteFinalizer is added to namespaces before deletion when the pre-delete
+	// finalizer is enabled. It holds the namespace in Terminating state until
+	// pre-delete steps have completed.
+	PreDeleteFinalizer = "namespace-auditor/pre-delete"
+
+	// KubeflowLabelKey is the label key identifying Kubeflow profile namespaces.
+	// Removed from namespaces that are cordoned.
+	KubeflowLabelKey = "app.kubernetes.io/part-of"
+
 	// KubeflowLabel defines the label selector identifying Kubeflow profile namespaces.
 	// Follows Kubernetes recommended label format:
 	// "app.kubernetes.io/part-of=kubeflow-profile"
@@ -0,0 +1,70 @@
+package auditor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ParseContextKeys parses a comma-separated list of label or annotation
+// keys, such as a cost center or project code, copied from each namespace
+// into its notifications and report entries. Whitespace around entries is
+// ignored and empty entries are dropped.
+func ParseContextKeys(list string) ([]string, error) {
+	var keys, invalid []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if len(validation.IsQualifiedName(entry)) > 0 {
+			invalid = append(invalid, entry)
+			continue
+		}
+		keys = append(keys, entry)
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid label or annotation keys: %q", invalid)
+	}
+	return keys, nil
+}
+
+// SetContextKeys configures the namespace labels or annotations included as
+// context in notifications and report entries, so that their consumers know
+// which team or budget is affected without querying the cluster.
+func (p *NamespaceProcessor) SetContextKeys(keys []string) {
+	p.contextKeys = keys
+}
+
+// namespaceContext returns the configured context keys set on ns, preferring
+// labels over annotations, or nil if none is set
+func (p *NamespaceProcessor) namespaceContext(ns corev1.Namespace) map[string]string {
+	var context map[string]string
+	for _, key := range p.contextKeys {
+		value, ok := ns.Labels[key]
+		if !ok {
+			value, ok = ns.Annotations[key]
+		}
+		if !ok {
+			continue
+		}
+		if context == nil {
+			context = make(map[string]string, len(p.contextKeys))
+		}
+		context[key] = value
+	}
+	return context
+}
+
+// formatContext renders namespace context as sorted key=value pairs
+func formatContext(context map[string]string) string {
+	pairs := make([]string, 0, len(context))
+	for key, value := range context {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,55 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParseContextKeys validates label and annotation key lists
+func TestParseContextKeys(t *testing.T) {
+	keys, err := ParseContextKeys(" cost-center, example.com/project-code ,,")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := []string{"cost-center", "example.com/project-code"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("ParseContextKeys() = %v, want %v", keys, want)
+	}
+	if _, err := ParseContextKeys("cost center,ok"); err == nil || !strings.Contains(err.Error(), "cost center") {
+		t.Errorf("Expected invalid key to be reported, got %v", err)
+	}
+}
+
+// TestNamespaceContext validates that selected labels and annotations
+// accompany notifications and report entries
+func TestNamespaceContext(t *testing.T) {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team",
+		Labels:      map[string]string{"cost-center": "CC-42", "tier": "label"},
+		Annotations: map[string]string{OwnerAnnotation: "gone@example.com", "project-code": "P-7", "tier": "annotation"},
+	}}
+	processor := newTestProcessor(false, []*corev1.Namespace{&ns}, false)
+	processor.SetContextKeys([]string{"cost-center", "project-code", "tier", "unset"})
+	notifier := &recordingNotifier{}
+	processor.SetNotifier(notifier)
+
+	var tr *Trace
+	captureLogs(func() {
+		tr = processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+	})
+
+	want := map[string]string{"cost-center": "CC-42", "project-code": "P-7", "tier": "label"}
+	if len(notifier.sent) != 1 || !reflect.DeepEqual(notifier.sent[0].Context, want) {
+		t.Errorf("Notifications = %+v, want one with context %v", notifier.sent, want)
+	}
+	if marked := processor.Marked(); len(marked) != 1 || !reflect.DeepEqual(marked[0].Context, want) {
+		t.Errorf("Marked() = %+v, want context %v", marked, want)
+	}
+	if !reflect.DeepEqual(tr.Context, want) {
+		t.Errorf("Trace context = %v, want %v", tr.Context, want)
+	}
+}
@@ -0,0 +1,48 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// listRoleBindings retrieves all RoleBindings in a namespace.
+func (p *NamespaceProcessor) listRoleBindings(ctx context.Context, namespace string) ([]rbacv1.RoleBinding, error) {
+	start := time.Now()
+	bindings, err := p.k8sClient.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+	p.apiStats.observe(OpRoleBindingList, start, err)
+	if err != nil {
+		return nil, fmt.Errorf("listing role bindings: %w", err)
+	}
+	return bindings.Items, nil
+}
+
+// contributors returns the users other than the owner that have access to a
+// namespace. Kubeflow grants contributors access through RoleBindings with a
+// User subject, so every such subject is included, sorted and deduplicated.
+func (p *NamespaceProcessor) contributors(ctx context.Context, ns corev1.Namespace) ([]string, error) {
+	bindings, err := p.listRoleBindings(ctx, ns.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := ns.Annotations[OwnerAnnotation]
+	seen := make(map[string]bool)
+	var users []string
+	for _, rb := range bindings {
+		for _, s := range rb.Subjects {
+			if s.Kind != rbacv1.UserKind || s.Name == owner || seen[s.Name] {
+				continue
+			}
+			seen[s.Name] = true
+			users = append(users, s.Name)
+		}
+	}
+	sort.Strings(users)
+	return users, nil
+}
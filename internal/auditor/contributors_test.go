@@ -0,0 +1,65 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// createBinding adds a RoleBinding with the given subjects to a test processor
+func createBinding(p *NamespaceProcessor, namespace, name string, subjects ...rbacv1.Subject) {
+	p.k8sClient.RbacV1().RoleBindings(namespace).Create(context.TODO(), &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Subjects:   subjects,
+	}, metav1.CreateOptions{})
+}
+
+// TestContributors validates contributor lookup from role bindings
+func TestContributors(t *testing.T) {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team",
+		Annotations: map[string]string{OwnerAnnotation: "owner@example.com"},
+	}}
+	processor := newTestProcessor(false, []*corev1.Namespace{&ns}, false)
+	createBinding(processor, ns.Name, "namespaceAdmin", rbacv1.Subject{Kind: rbacv1.UserKind, Name: "owner@example.com"})
+	createBinding(processor, ns.Name, "user-b", rbacv1.Subject{Kind: rbacv1.UserKind, Name: "b@example.com"})
+	createBinding(processor, ns.Name, "user-a", rbacv1.Subject{Kind: rbacv1.UserKind, Name: "a@example.com"},
+		rbacv1.Subject{Kind: rbacv1.UserKind, Name: "b@example.com"})
+	createBinding(processor, ns.Name, "default-editor", rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "default-editor"})
+
+	got, err := processor.contributors(context.TODO(), ns)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := []string{"a@example.com", "b@example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("contributors() = %v, want %v", got, want)
+	}
+}
+
+// TestMarkNotifiesContributors validates that marking notifies contributors
+func TestMarkNotifiesContributors(t *testing.T) {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team",
+		Annotations: map[string]string{OwnerAnnotation: "gone@example.com"},
+	}}
+	notifier := &recordingNotifier{}
+	processor := newTestProcessor(false, []*corev1.Namespace{&ns}, false)
+	processor.SetNotifier(notifier)
+	createBinding(processor, ns.Name, "user-a", rbacv1.Subject{Kind: rbacv1.UserKind, Name: "a@example.com"})
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+	})
+
+	if len(notifier.sent) != 1 {
+		t.Fatalf("Expected one notification, got %+v", notifier.sent)
+	}
+	n := notifier.sent[0]
+	if n.Event != EventMarked || n.Owner != "gone@example.com" || !reflect.DeepEqual(n.Recipients, []string{"a@example.com"}) {
+		t.Errorf("Unexpected notification: %+v", n)
+	}
+}
@@ -0,0 +1,138 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// ExpiryAction selects what happens to a namespace once its grace period expires.
+type ExpiryAction string
+
+const (
+	// ExpiryDelete deletes the namespace and all of its contents.
+	ExpiryDelete ExpiryAction = "delete"
+
+	// ExpiryCordon keeps the namespace and its data but removes access: the
+	// Kubeflow profile label is stripped, the owner's RoleBindings are
+	// revoked, and the namespace is annotated as decommissioned.
+	ExpiryCordon ExpiryAction = "cordon"
+
+	// ExpiryReassign keeps the namespace and marks it orphaned, handing it to
+	// a fallback owner if configured (see SetOrphanPolicy). It is deleted if
+	// it is still orphaned once the orphan period has passed.
+	ExpiryReassign ExpiryAction = "reassign"
+)
+
+// ParseExpiryAction parses an expiry action name. An empty value selects
+// ExpiryDelete.
+func ParseExpiryAction(value string) (ExpiryAction, error) {
+	switch ExpiryAction(value) {
+	case "", ExpiryDelete:
+		return ExpiryDelete, nil
+	case ExpiryCordon, ExpiryReassign:
+		return ExpiryAction(value), nil
+	default:
+		return "", fmt.Errorf("unknown action %q, expected %q, %q or %q", value, ExpiryDelete, ExpiryCordon, ExpiryReassign)
+	}
+}
+
+// SetExpiryAction configures what happens to namespaces whose grace period
+// has expired. The default is ExpiryDelete.
+func (p *NamespaceProcessor) SetExpiryAction(action ExpiryAction) {
+	p.expiryAction = action
+}
+
+// expiryActionOrDefault returns the configured expiry action, defaulting to delete
+func (p *NamespaceProcessor) expiryActionOrDefault() ExpiryAction {
+	if p.expiryAction == "" {
+		return ExpiryDelete
+	}
+	return p.expiryAction
+}
+
+// expire applies the configured expiry action to a namespace. Orphaned
+// namespaces whose orphan period expired are deleted.
+func (p *NamespaceProcessor) expire(ns corev1.Namespace) {
+	if p.expiryAction == ExpiryReassign && !isOrphaned(ns) {
+		p.orphanNamespace(ns)
+		return
+	}
+	if p.awaitingApproval(context.TODO(), ns, p.now()) {
+		return
+	}
+	if p.expiryAction != ExpiryCordon && p.quarantining(&ns) {
+		return
+	}
+	if err := p.expireLinked(ns); err != nil {
+		log.Printf("Leaving %s for the next run: %v", ns.Name, err)
+		p.trace.fail(err)
+		return
+	}
+	if p.expiryAction == ExpiryCordon {
+		p.cordonNamespace(ns)
+		return
+	}
+	p.deleteNamespace(ns)
+}
+
+// cordonNamespace removes access to a namespace while preserving its data
+func (p *NamespaceProcessor) cordonNamespace(ns corev1.Namespace) {
+	if at, done := ns.Annotations[DecommissionedAnnotation]; done {
+		p.trace.add("cordon", "already decommissioned at %s", at)
+		p.trace.setAction(ActionNone)
+		return
+	}
+
+	log.Printf("Cordoning namespace %s after grace period", ns.Name)
+	p.trace.setAction(ActionCordon)
+
+	owner := ns.Annotations[OwnerAnnotation]
+	if err := p.revokeOwnerBindings(context.TODO(), ns.Name, owner); err != nil {
+		log.Printf("Error revoking access of %s to %s: %v", owner, ns.Name, err)
+		p.trace.fail(err)
+		return
+	}
+
+	delete(ns.Labels, KubeflowLabelKey)
+	ns.Annotations[DecommissionedAnnotation] = formatMarkerTime(p.now())
+	if err := p.updateNamespace(context.TODO(), &ns); err != nil {
+		log.Printf("Error cordoning %s: %v", ns.Name, err)
+		return
+	}
+	p.recordEvent(context.TODO(), ns, corev1.EventTypeWarning, EventReasonCordoned,
+		"Access removed after its grace period expired: %s is not a valid user", describeOwner(ns))
+}
+
+// revokeOwnerBindings deletes every RoleBinding in the namespace that grants
+// access to the owner as a User subject.
+func (p *NamespaceProcessor) revokeOwnerBindings(ctx context.Context, namespace, owner string) error {
+	bindings, err := p.listRoleBindings(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, rb := range bindings {
+		if !bindsUser(rb, owner) {
+			continue
+		}
+		log.Printf("Revoking role binding %s/%s for %s", namespace, rb.Name, owner)
+		if err := p.mutations().deleteRoleBinding(ctx, namespace, rb.Name); err != nil {
+			return fmt.Errorf("deleting role binding %s: %w", rb.Name, err)
+		}
+	}
+	return nil
+}
+
+// bindsUser reports whether a RoleBinding has the given user as a subject
+func bindsUser(rb rbacv1.RoleBinding, user string) bool {
+	for _, s := range rb.Subjects {
+		if s.Kind == rbacv1.UserKind && s.Name == user {
+			return true
+		}
+	}
+	return false
+}
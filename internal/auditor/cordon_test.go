@@ -0,0 +1,107 @@
+package auditor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParseExpiryAction validates expiry action names
+func TestParseExpiryAction(t *testing.T) {
+	for value, want := range map[string]ExpiryAction{"": ExpiryDelete, "delete": ExpiryDelete, "cordon": ExpiryCordon, "reassign": ExpiryReassign} {
+		got, err := ParseExpiryAction(value)
+		if err != nil || got != want {
+			t.Errorf("ParseExpiryAction(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseExpiryAction("archive"); err == nil {
+		t.Error("Expected error for unknown action")
+	}
+}
+
+// TestCordonNamespace validates that cordoning keeps the namespace but removes access
+func TestCordonNamespace(t *testing.T) {
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cordon-me",
+			Labels: map[string]string{KubeflowLabelKey: "kubeflow-profile"},
+			Annotations: map[string]string{
+				OwnerAnnotation:       "gone@example.com",
+				GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+			},
+		},
+	}
+	processor := newTestProcessor(false, []*corev1.Namespace{&ns}, false)
+	processor.SetExpiryAction(ExpiryCordon)
+
+	for name, user := range map[string]string{"namespaceAdmin": "gone@example.com", "contributor": "other@example.com"} {
+		processor.k8sClient.RbacV1().RoleBindings(ns.Name).Create(context.TODO(), &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns.Name},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: user}},
+		}, metav1.CreateOptions{})
+	}
+
+	logOutput := captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+	})
+	if !strings.Contains(logOutput, "Cordoning namespace cordon-me") {
+		t.Errorf("Cordon not logged: %q", logOutput)
+	}
+
+	updatedNs, err := processor.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Cordoned namespace should not be deleted: %v", err)
+	}
+	if _, exists := updatedNs.Labels[KubeflowLabelKey]; exists {
+		t.Error("Kubeflow profile label should be removed")
+	}
+	if _, exists := updatedNs.Annotations[DecommissionedAnnotation]; !exists {
+		t.Error("Decommissioned annotation should be added")
+	}
+
+	bindings, _ := processor.k8sClient.RbacV1().RoleBindings(ns.Name).List(context.TODO(), metav1.ListOptions{})
+	if len(bindings.Items) != 1 || bindings.Items[0].Name != "contributor" {
+		t.Errorf("Only the owner's role binding should be revoked, remaining: %v", bindings.Items)
+	}
+
+	// A second pass leaves an already cordoned namespace alone
+	tr := processor.ProcessNamespaceTraced(context.TODO(), *updatedNs)
+	if tr.Action != ActionNone {
+		t.Errorf("Expected no action for decommissioned namespace, got %q", tr.Action)
+	}
+}
+
+// TestCordonDryRun ensures dry-run cordoning makes no changes
+func TestCordonDryRun(t *testing.T) {
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cordon-dry",
+			Labels: map[string]string{KubeflowLabelKey: "kubeflow-profile"},
+			Annotations: map[string]string{
+				OwnerAnnotation:       "gone@example.com",
+				GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+			},
+		},
+	}
+	processor := newTestProcessor(false, []*corev1.Namespace{&ns}, true)
+	processor.SetExpiryAction(ExpiryCordon)
+
+	logOutput := captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+	})
+	if !strings.Contains(logOutput, "[DRY RUN] Would update cordon-dry") {
+		t.Errorf("Dry-run cordon not logged: %q", logOutput)
+	}
+	if planned := processor.PlannedChanges(); len(planned) != 1 || planned[0].Op != OpUpdate {
+		t.Errorf("Expected one planned update, got %+v", planned)
+	}
+
+	updatedNs, _ := processor.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+	if _, exists := updatedNs.Labels[KubeflowLabelKey]; !exists {
+		t.Error("Dry run should not remove labels")
+	}
+}
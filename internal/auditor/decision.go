@@ -0,0 +1,43 @@
+package auditor
+
+// Decision is the outcome of evaluating a namespace, returned by
+// ProcessNamespace so that callers can consume results without parsing
+// the log.
+type Decision struct {
+	Namespace string // Name of the evaluated namespace
+	Action    Action // What was done, or would be done in a dry run
+	Reason    string // Final evaluation step, explaining the action
+	Err       error  // Why the evaluation or its change failed, nil on success
+
+	Owner    string // Owner checked, empty if the namespace has none
+	Domain   string // Outcome of the domain check, empty if not checked
+	Identity string // Verdict on the owner, empty if not checked
+}
+
+// DecisionError records a namespace whose evaluation or change failed.
+type DecisionError struct {
+	Namespace string `json:"namespace"` // Name of the namespace
+	Error     string `json:"error"`     // What failed
+}
+
+// Decision returns the outcome recorded in the trace.
+func (t *Trace) Decision() Decision {
+	d := Decision{Namespace: t.Namespace, Action: t.Action, Err: t.err, Owner: t.Owner, Domain: t.Domain, Identity: t.Identity}
+	if len(t.Steps) > 0 {
+		d.Reason = t.Steps[len(t.Steps)-1].Detail
+	}
+	return d
+}
+
+// AddDecision counts a namespace decision in the report, listing it under
+// errors if it failed.
+func (r *RunReport) AddDecision(d Decision) {
+	if r.Actions == nil {
+		r.Actions = map[Action]int{}
+	}
+	r.Actions[d.Action]++
+	r.decided = append(r.decided, d)
+	if d.Err != nil {
+		r.Errors = append(r.Errors, DecisionError{Namespace: d.Namespace, Error: d.Err.Error()})
+	}
+}
@@ -0,0 +1,72 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestProcessNamespaceDecision validates the decisions returned by ProcessNamespace
+func TestProcessNamespaceDecision(t *testing.T) {
+	lookupErr := errors.New("directory unavailable")
+	updateErr := errors.New("etcd unavailable")
+	tests := []struct {
+		name       string
+		userExists bool
+		checkErr   error
+		updateErr  error
+		want       Action
+		wantErr    error
+	}{
+		{name: "valid owner", userExists: true, want: ActionNone},
+		{name: "missing owner", want: ActionMark},
+		{name: "failed lookup", checkErr: lookupErr, want: ActionError, wantErr: lookupErr},
+		{name: "failed marker", updateErr: updateErr, want: ActionError, wantErr: updateErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := ownedNamespace("team", "user@example.com")
+			processor := newTestProcessor(tt.userExists, []*corev1.Namespace{&ns}, false)
+			processor.azureClient = &MockUserChecker{exists: tt.userExists, err: tt.checkErr}
+			if tt.updateErr != nil {
+				processor.k8sClient.(*fake.Clientset).PrependReactor("update", "namespaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tt.updateErr
+				})
+			}
+
+			var d Decision
+			captureLogs(func() {
+				d = processor.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+			})
+			if d.Namespace != "team" || d.Action != tt.want {
+				t.Errorf("Decision = %+v, want action %q for team", d, tt.want)
+			}
+			if !errors.Is(d.Err, tt.wantErr) || (tt.wantErr == nil) != (d.Err == nil) {
+				t.Errorf("Decision error = %v, want %v", d.Err, tt.wantErr)
+			}
+			if d.Reason == "" {
+				t.Error("Decision should explain its action")
+			}
+		})
+	}
+}
+
+// TestReportAddDecision validates counting decisions in the run report
+func TestReportAddDecision(t *testing.T) {
+	report := NewRunReport("run", "hash")
+	report.AddDecision(Decision{Namespace: "a", Action: ActionMark})
+	report.AddDecision(Decision{Namespace: "b", Action: ActionMark})
+	report.AddDecision(Decision{Namespace: "c", Action: ActionError, Err: errors.New("boom")})
+
+	if report.Actions[ActionMark] != 2 || report.Actions[ActionError] != 1 {
+		t.Errorf("Actions = %v, want 2 marked and 1 error", report.Actions)
+	}
+	if len(report.Errors) != 1 || report.Errors[0] != (DecisionError{Namespace: "c", Error: "boom"}) {
+		t.Errorf("Errors = %+v, want the failure of c", report.Errors)
+	}
+}
@@ -0,0 +1,97 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// deletionPollInterval is how often a deleted namespace is checked while
+// waiting for it to finish terminating. Shortened in tests.
+var deletionPollInterval = 2 * time.Second
+
+// ParsePropagationPolicy parses a deletion propagation policy name
+// ("background" or "foreground"). An empty value leaves the choice to the
+// API server, which defaults to background for namespaces.
+func ParsePropagationPolicy(value string) (metav1.DeletionPropagation, error) {
+	switch value {
+	case "":
+		return "", nil
+	case "background":
+		return metav1.DeletePropagationBackground, nil
+	case "foreground":
+		return metav1.DeletePropagationForeground, nil
+	default:
+		return "", fmt.Errorf(`unknown propagation policy %q, expected "background" or "foreground"`, value)
+	}
+}
+
+// SetDeletionPropagation configures the propagation policy used when
+// deleting namespaces. An empty policy uses the API server default.
+func (p *NamespaceProcessor) SetDeletionPropagation(policy metav1.DeletionPropagation) {
+	p.propagationPolicy = policy
+}
+
+// SetWaitForDeletion makes each deletion wait up to timeout for the
+// namespace to finish terminating. Namespaces still present afterwards are
+// reported by StuckTerminating. Zero disables waiting.
+func (p *NamespaceProcessor) SetWaitForDeletion(timeout time.Duration) {
+	p.deletionWait = timeout
+}
+
+// deleteOptions returns the options used for deleting ns, preconditioned on
+// the UID and resourceVersion it was read with
+func (p *NamespaceProcessor) deleteOptions(ns corev1.Namespace) metav1.DeleteOptions {
+	var opts metav1.DeleteOptions
+	if ns.UID != "" || ns.ResourceVersion != "" {
+		opts.Preconditions = &metav1.Preconditions{}
+		if ns.UID != "" {
+			opts.Preconditions.UID = &ns.UID
+		}
+		if ns.ResourceVersion != "" {
+			opts.Preconditions.ResourceVersion = &ns.ResourceVersion
+		}
+	}
+	if p.propagationPolicy != "" {
+		policy := p.propagationPolicy
+		opts.PropagationPolicy = &policy
+	}
+	return opts
+}
+
+// waitForDeletion polls until a deleted namespace is gone, recording it as
+// stuck if it is still present after the configured timeout.
+func (p *NamespaceProcessor) waitForDeletion(ctx context.Context, name string) {
+	if p.deletionWait <= 0 {
+		return
+	}
+
+	var last *corev1.Namespace
+	err := wait.PollUntilContextTimeout(ctx, deletionPollInterval, p.deletionWait, true, func(ctx context.Context) (bool, error) {
+		ns, err := p.GetNamespace(ctx, name)
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err == nil {
+			last = ns
+		}
+		return false, nil // Still terminating, or a transient error: keep polling
+	})
+	if err != nil {
+		log.Printf("Namespace %s still terminating after %s", name, p.deletionWait)
+		p.trace.add("delete", "still terminating after %s", p.deletionWait)
+		entry := StuckNamespace{Name: name}
+		if last != nil {
+			entry = stuckEntry(*last)
+		}
+		p.stuck.add(entry)
+		return
+	}
+	p.trace.add("delete", "namespace finished terminating")
+}
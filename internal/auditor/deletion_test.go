@@ -0,0 +1,87 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// expiredNamespace builds a namespace whose grace period has long passed
+func expiredNamespace(name string) corev1.Namespace {
+	return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: name,
+		Annotations: map[string]string{
+			OwnerAnnotation:       "gone@example.com",
+			GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+		},
+	}}
+}
+
+// TestParsePropagationPolicy validates propagation policy names
+func TestParsePropagationPolicy(t *testing.T) {
+	for value, want := range map[string]metav1.DeletionPropagation{
+		"":           "",
+		"background": metav1.DeletePropagationBackground,
+		"foreground": metav1.DeletePropagationForeground,
+	} {
+		got, err := ParsePropagationPolicy(value)
+		if err != nil || got != want {
+			t.Errorf("ParsePropagationPolicy(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParsePropagationPolicy("orphan"); err == nil {
+		t.Error("Expected error for unsupported policy")
+	}
+}
+
+// TestDeletionPropagation validates that the policy is sent with the delete request
+func TestDeletionPropagation(t *testing.T) {
+	ns := expiredNamespace("expired")
+	processor := newTestProcessor(false, []*corev1.Namespace{&ns}, false)
+	processor.SetDeletionPropagation(metav1.DeletePropagationForeground)
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+	})
+
+	for _, action := range processor.k8sClient.(*fake.Clientset).Actions() {
+		if del, ok := action.(k8stesting.DeleteAction); ok {
+			policy := del.GetDeleteOptions().PropagationPolicy
+			if policy == nil || *policy != metav1.DeletePropagationForeground {
+				t.Errorf("Unexpected propagation policy: %v", policy)
+			}
+			return
+		}
+	}
+	t.Error("No delete request sent")
+}
+
+// TestWaitForDeletion validates reporting of namespaces stuck terminating
+func TestWaitForDeletion(t *testing.T) {
+	defer func(d time.Duration) { deletionPollInterval = d }(deletionPollInterval)
+	deletionPollInterval = 5 * time.Millisecond
+
+	gone, stuck := expiredNamespace("gone"), expiredNamespace("stuck")
+	processor := newTestProcessor(false, []*corev1.Namespace{&gone, &stuck}, false)
+	processor.SetWaitForDeletion(50 * time.Millisecond)
+
+	// Keep "stuck" around after deletion, as if held by a finalizer
+	processor.k8sClient.(*fake.Clientset).PrependReactor("delete", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return action.(k8stesting.DeleteAction).GetName() == "stuck", nil, nil
+	})
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *gone.DeepCopy())
+		processor.ProcessNamespace(context.TODO(), *stuck.DeepCopy())
+	})
+
+	if got := processor.StuckTerminating(); len(got) != 1 || got[0].Name != "stuck" {
+		t.Errorf("StuckTerminating() = %v, want [stuck]", got)
+	}
+}
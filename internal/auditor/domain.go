@@ -0,0 +1,103 @@
+package auditor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+// ParseAllowedDomains parses a comma-separated list of email domains such as
+// the ALLOWED_DOMAINS setting. Whitespace around entries is ignored and empty
+// entries are dropped. Each domain is returned in normalized form.
+//
+// Returns an error listing every entry that is not a valid domain name, or
+// if the list contains no domains at all.
+func ParseAllowedDomains(list string) ([]string, error) {
+	var domains, invalid []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.ContainsAny(entry, "@/: \t") {
+			invalid = append(invalid, entry)
+			continue
+		}
+		ascii, err := idna.Lookup.ToASCII(strings.TrimSuffix(entry, "."))
+		if err != nil || !strings.Contains(ascii, ".") || strings.Contains(ascii, "..") {
+			invalid = append(invalid, entry)
+			continue
+		}
+		domains = append(domains, normalizeDomain(ascii))
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid allowed domains: %q", invalid)
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("no allowed domains configured")
+	}
+	return domains, nil
+}
+
+// IsAllowedOwner reports whether email is a well-formed address in one of
+// the allowed domains, exactly as the auditor decides it for owner
+// annotations.
+func IsAllowedOwner(email string, allowedDomains []string) bool {
+	return isValidDomain(email, allowedDomains)
+}
+
+// isValidDomain verifies if an email address belongs to an allowed domain.
+// Domains are compared in their normalized form (see normalizeDomain), so
+// Unicode and punycode spellings, letter case and trailing dots are all
+// treated as equivalent.
+func isValidDomain(email string, allowedDomains []string) bool {
+	domain, ok := emailDomain(email)
+	if !ok {
+		return false
+	}
+
+	for _, d := range allowedDomains {
+		if allowed := normalizeDomain(d); allowed != "" && allowed == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// emailDomain extracts and normalizes the domain part of an email address.
+// Returns false if the address is malformed or the domain is empty.
+func emailDomain(email string) (string, bool) {
+	parts := strings.Split(email, "@")
+	if len(parts) != 2 || parts[0] == "" {
+		return "", false
+	}
+	domain := normalizeDomain(parts[1])
+	return domain, domain != ""
+}
+
+// normalizeDomain converts a domain name to a canonical comparable form:
+// surrounding whitespace and trailing dots are removed, and the name is
+// converted to its lower-case ASCII (punycode) representation.
+// Names that cannot be converted fall back to a lower-cased copy so that
+// plain ASCII comparisons keep working; an empty string means no domain.
+func normalizeDomain(domain string) string {
+	domain = trimDomain(domain)
+	if domain == "" {
+		return ""
+	}
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return trimDomain(strings.ToLower(domain))
+	}
+	return trimDomain(strings.ToLower(ascii))
+}
+
+// trimDomain strips surrounding whitespace and any trailing dots
+func trimDomain(domain string) string {
+	return strings.TrimRightFunc(strings.TrimSpace(domain), func(r rune) bool {
+		return r == '.' || unicode.IsSpace(r)
+	})
+}
@@ -0,0 +1,192 @@
+package auditor
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestIsValidDomainNormalization covers IDN, case and trailing-dot handling
+func TestIsValidDomainNormalization(t *testing.T) {
+	tests := []struct {
+		name    string   // Test scenario description
+		email   string   // Input email address
+		domains []string // Allowed domains
+		want    bool     // Expected validation result
+	}{
+		{
+			name:    "mixed case",
+			email:   "User@Example.COM",
+			domains: []string{"example.com"},
+			want:    true,
+		},
+		{
+			name:    "trailing dot in email",
+			email:   "user@example.com.",
+			domains: []string{"example.com"},
+			want:    true,
+		},
+		{
+			name:    "trailing dot in allowed domain",
+			email:   "user@example.com",
+			domains: []string{"example.com."},
+			want:    true,
+		},
+		{
+			name:    "unicode email, punycode allowed",
+			email:   "user@bücher.example",
+			domains: []string{"xn--bcher-kva.example"},
+			want:    true,
+		},
+		{
+			name:    "punycode email, unicode allowed",
+			email:   "user@xn--bcher-kva.example",
+			domains: []string{"Bücher.example"},
+			want:    true,
+		},
+		{
+			name:    "whitespace around allowed domain",
+			email:   "user@example.com",
+			domains: []string{" example.com "},
+			want:    true,
+		},
+		{
+			name:    "empty allowed domain never matches",
+			email:   "user@",
+			domains: []string{""},
+			want:    false,
+		},
+		{
+			name:    "missing local part",
+			email:   "@example.com",
+			domains: []string{"example.com"},
+			want:    false,
+		},
+		{
+			name:    "lookalike domain",
+			email:   "user@exаmple.com", // Cyrillic "а"
+			domains: []string{"example.com"},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isValidDomain(tt.email, tt.domains)
+			if got != tt.want {
+				t.Errorf("Validation mismatch for %q:\nExpected: %v\nGot: %v", tt.email, tt.want, got)
+			}
+		})
+	}
+}
+
+// FuzzNormalizeDomain checks normalization is idempotent and case-insensitive
+func FuzzNormalizeDomain(f *testing.F) {
+	for _, seed := range []string{"example.com", "Example.COM.", "bücher.example", "xn--bcher-kva.example", "", "."} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, domain string) {
+		norm := normalizeDomain(domain)
+		if again := normalizeDomain(norm); again != norm {
+			t.Errorf("Normalization not idempotent: %q -> %q -> %q", domain, norm, again)
+		}
+		if norm != strings.ToLower(norm) {
+			t.Errorf("Normalized domain %q is not lower case", norm)
+		}
+	})
+}
+
+// FuzzIsValidDomain checks that an email always matches its own domain
+// regardless of case or trailing dot variations
+func FuzzIsValidDomain(f *testing.F) {
+	for _, seed := range []string{"example.com", "bücher.example", "xn--bcher-kva.example", "a.b.c"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, domain string) {
+		if strings.Contains(domain, "@") || normalizeDomain(domain) == "" {
+			t.Skip()
+		}
+		email := "user@" + domain
+		for _, allowed := range []string{domain, strings.ToUpper(domain), domain + "."} {
+			if normalizeDomain(allowed) != normalizeDomain(domain) {
+				continue // Upper-casing may change non-ASCII names
+			}
+			if !isValidDomain(email, []string{allowed}) {
+				t.Errorf("Email %q should match allowed domain %q", email, allowed)
+			}
+		}
+	})
+}
+
+// TestParseAllowedDomains validates tolerant parsing and startup rejection
+func TestParseAllowedDomains(t *testing.T) {
+	tests := []struct {
+		name    string   // Test scenario description
+		input   string   // Raw ALLOWED_DOMAINS value
+		want    []string // Expected normalized domains
+		wantErr bool     // Whether parsing should fail
+	}{
+		{
+			name:  "comma separated",
+			input: "a.com,b.com",
+			want:  []string{"a.com", "b.com"},
+		},
+		{
+			name:  "comma and space separated",
+			input: "a.com, b.com ,  c.org",
+			want:  []string{"a.com", "b.com", "c.org"},
+		},
+		{
+			name:  "empty entries dropped",
+			input: ",a.com,,b.com,",
+			want:  []string{"a.com", "b.com"},
+		},
+		{
+			name:  "normalized forms",
+			input: "Statcan.GC.ca., Bücher.example",
+			want:  []string{"statcan.gc.ca", "xn--bcher-kva.example"},
+		},
+		{
+			name:    "empty list",
+			input:   " , ",
+			wantErr: true,
+		},
+		{
+			name:    "email instead of domain",
+			input:   "a.com, user@b.com",
+			wantErr: true,
+		},
+		{
+			name:    "embedded whitespace",
+			input:   "exa mple.com",
+			wantErr: true,
+		},
+		{
+			name:    "single label",
+			input:   "localhost",
+			wantErr: true,
+		},
+		{
+			name:    "empty label",
+			input:   "a..com",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAllowedDomains(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", tt.input, err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Parse mismatch for %q:\nExpected: %v\nGot: %v", tt.input, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package auditor
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// DriftMaxAge is how recently another auditor deployment must have run for
+// its configuration to be compared. Older entries are assumed retired.
+const DriftMaxAge = 7 * 24 * time.Hour
+
+// InstanceConfig is the effective configuration last used by an auditor
+// deployment, shared through the state store so that deployments running in
+// the same cluster can detect diverging settings.
+type InstanceConfig struct {
+	ConfigHash string         `json:"configHash"` // Hash of the effective configuration
+	SeenAt     time.Time      `json:"seenAt"`     // When the deployment last ran
+	Config     ConfigSnapshot `json:"config"`     // Effective configuration
+}
+
+// ConfigDrift is a decision-affecting setting on which another auditor
+// deployment disagrees with this one.
+type ConfigDrift struct {
+	Instance string `json:"instance"` // Name of the other deployment
+	Setting  string `json:"setting"`  // Setting that differs
+	Ours     string `json:"ours"`     // Value used by this deployment
+	Theirs   string `json:"theirs"`   // Value used by the other deployment
+}
+
+// DetectDrift compares the configuration of instance self with the other
+// instances seen within DriftMaxAge of now. Differences are returned sorted by
+// instance; settings that do not affect decisions (version, dry run) are
+// ignored.
+func DetectDrift(self string, own InstanceConfig, instances map[string]InstanceConfig, now time.Time) []ConfigDrift {
+	var names []string
+	for name := range instances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var drift []ConfigDrift
+	for _, name := range names {
+		other := instances[name]
+		if name == self || other.ConfigHash == own.ConfigHash || now.Sub(other.SeenAt) > DriftMaxAge {
+			continue
+		}
+		for _, s := range comparedSettings(own.Config, other.Config) {
+			if s.ours != s.theirs {
+				drift = append(drift, ConfigDrift{Instance: name, Setting: s.name, Ours: s.ours, Theirs: s.theirs})
+			}
+		}
+	}
+	return drift
+}
+
+// comparedSetting pairs the values of one setting in two configurations
+type comparedSetting struct {
+	name, ours, theirs string
+}
+
+// comparedSettings lists the decision-affecting settings of two configurations
+func comparedSettings(ours, theirs ConfigSnapshot) []comparedSetting {
+	return []comparedSetting{
+		{"gracePeriod", ours.GracePeriod, theirs.GracePeriod},
+		{"clockSkew", ours.ClockSkew, theirs.ClockSkew},
+		{"pauseWindows", strings.Join(ours.PauseWindows, ","), strings.Join(theirs.PauseWindows, ",")},
+		{"allowedDomains", strings.Join(ours.AllowedDomains, ","), strings.Join(theirs.AllowedDomains, ",")},
+		{"expiryAction", ours.ExpiryAction, theirs.ExpiryAction},
+		{"invalidDomainPolicy", ours.InvalidDomainPolicy, theirs.InvalidDomainPolicy},
+		{"ownerlessPolicy", ours.OwnerlessPolicy, theirs.OwnerlessPolicy},
+		{"disabledUserPolicy", ours.DisabledUserPolicy, theirs.DisabledUserPolicy},
+		{"labelSelector", ours.LabelSelector, theirs.LabelSelector},
+	}
+}
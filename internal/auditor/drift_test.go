@@ -0,0 +1,35 @@
+package auditor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestDetectDrift validates comparison of deployment configurations
+func TestDetectDrift(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	base := ConfigSnapshot{GracePeriod: "720h0m0s", AllowedDomains: []string{"example.com"}, ExpiryAction: "delete"}
+	own := InstanceConfig{ConfigHash: "own", SeenAt: now, Config: base}
+
+	diverged := base
+	diverged.GracePeriod = "168h0m0s"
+	diverged.AllowedDomains = []string{"example.com", "example.org"}
+	diverged.Version = "v2" // Not decision-affecting
+
+	instances := map[string]InstanceConfig{
+		"cronjob":    own,
+		"controller": {ConfigHash: "other", SeenAt: now.Add(-time.Hour), Config: diverged},
+		"same":       {ConfigHash: "own", SeenAt: now, Config: base},
+		"retired":    {ConfigHash: "old", SeenAt: now.Add(-DriftMaxAge - time.Hour), Config: diverged},
+	}
+
+	got := DetectDrift("cronjob", own, instances, now)
+	want := []ConfigDrift{
+		{Instance: "controller", Setting: "gracePeriod", Ours: "720h0m0s", Theirs: "168h0m0s"},
+		{Instance: "controller", Setting: "allowedDomains", Ours: "example.com", Theirs: "example.com,example.org"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectDrift = %+v, want %+v", got, want)
+	}
+}
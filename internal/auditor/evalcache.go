@@ -0,0 +1,140 @@
+package auditor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Evaluation records a namespace found to have a valid owner and nothing to
+// do. Until the namespace or the configuration changes or the entry expires,
+// later runs skip it.
+type Evaluation struct {
+	ResourceVersion  string    `json:"resourceVersion"`            // Namespace resourceVersion when evaluated
+	Owner            string    `json:"owner"`                      // Owner annotation when evaluated
+	ConfigHash       string    `json:"configHash,omitempty"`       // Configuration hash when evaluated
+	PolicyGeneration string    `json:"policyGeneration,omitempty"` // Audit policy generation when evaluated
+	EvaluatedAt      time.Time `json:"evaluatedAt"`                // When the owner was last confirmed valid
+}
+
+// evaluationScope identifies the configuration evaluations are made under
+type evaluationScope struct {
+	configHash       string
+	policyGeneration string
+}
+
+// evaluationScope returns the configuration the processor evaluates under
+func (p *NamespaceProcessor) evaluationScope() evaluationScope {
+	return evaluationScope{configHash: p.configHash, policyGeneration: p.policyGeneration}
+}
+
+// evaluationCache holds the evaluations of the previous run and those to
+// carry into the next. It is shared by all copies of a processor. A nil
+// *evaluationCache never skips a namespace.
+type evaluationCache struct {
+	ttl      time.Duration
+	previous map[string]Evaluation
+
+	mu      sync.Mutex
+	current map[string]Evaluation
+	hits    int
+}
+
+// fresh reports whether ns is unchanged since an evaluation under scope
+// younger than the cache TTL. Fresh evaluations are carried into the next run
+// unchanged, so that a namespace is still re-evaluated once its TTL has
+// passed.
+func (c *evaluationCache) fresh(ns corev1.Namespace, scope evaluationScope, now time.Time) (Evaluation, bool) {
+	if c == nil {
+		return Evaluation{}, false
+	}
+	e, ok := c.previous[ns.Name]
+	if !ok || e.ResourceVersion == "" || e.ResourceVersion != ns.ResourceVersion ||
+		e.Owner != ns.Annotations[OwnerAnnotation] || e.ConfigHash != scope.configHash ||
+		e.PolicyGeneration != scope.policyGeneration || now.Sub(e.EvaluatedAt) > c.ttl {
+		return Evaluation{}, false
+	}
+	return e, true
+}
+
+// hit records that ns was skipped because of its evaluation e
+func (c *evaluationCache) hit(name string, e Evaluation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current[name] = e
+	c.hits++
+}
+
+// record stores a valid-owner evaluation of ns made under scope at now
+func (c *evaluationCache) record(ns corev1.Namespace, scope evaluationScope, now time.Time) {
+	if c == nil || ns.ResourceVersion == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current[ns.Name] = Evaluation{
+		ResourceVersion:  ns.ResourceVersion,
+		Owner:            ns.Annotations[OwnerAnnotation],
+		ConfigHash:       scope.configHash,
+		PolicyGeneration: scope.policyGeneration,
+		EvaluatedAt:      now.UTC(),
+	}
+}
+
+// SetEvaluationCache skips namespaces whose resourceVersion and owner are
+// unchanged since the previous run found their owner valid, for up to ttl
+// after that evaluation. Evaluations made under another configuration hash,
+// see SetRunInfo, or other audit policies are not used. previous holds the
+// evaluations saved by the last run, see Evaluations. A zero or negative ttl
+// disables the cache.
+func (p *NamespaceProcessor) SetEvaluationCache(ttl time.Duration, previous map[string]Evaluation) {
+	if ttl <= 0 {
+		p.evaluations = nil
+		return
+	}
+	p.evaluations = &evaluationCache{ttl: ttl, previous: previous, current: make(map[string]Evaluation)}
+}
+
+// Evaluations returns the evaluations to save for the next run: namespaces
+// confirmed valid during this run and those skipped as still fresh.
+func (p *NamespaceProcessor) Evaluations() map[string]Evaluation {
+	if p.evaluations == nil {
+		return nil
+	}
+	p.evaluations.mu.Lock()
+	defer p.evaluations.mu.Unlock()
+	evaluations := make(map[string]Evaluation, len(p.evaluations.current))
+	for name, e := range p.evaluations.current {
+		evaluations[name] = e
+	}
+	return evaluations
+}
+
+// CacheHits returns the number of namespaces skipped during this run because
+// their previous evaluation was still fresh.
+func (p *NamespaceProcessor) CacheHits() int {
+	if p.evaluations == nil {
+		return 0
+	}
+	p.evaluations.mu.Lock()
+	defer p.evaluations.mu.Unlock()
+	return p.evaluations.hits
+}
+
+// uncached returns the namespaces that need evaluating at now, comparing
+// their profile owner, if any, like the mutation phase does
+func (p *NamespaceProcessor) uncached(ctx context.Context, namespaces []corev1.Namespace, now time.Time) []corev1.Namespace {
+	if p.evaluations == nil {
+		return namespaces
+	}
+	scope := p.evaluationScope()
+	var stale []corev1.Namespace
+	for _, ns := range namespaces {
+		if _, ok := p.evaluations.fresh(p.withProfileOwner(ctx, ns), scope, now); !ok {
+			stale = append(stale, ns)
+		}
+	}
+	return stale
+}
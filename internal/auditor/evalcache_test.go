@@ -0,0 +1,149 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// cachedNamespace builds a namespace with a valid owner at resourceVersion rv
+func cachedNamespace(rv string) corev1.Namespace {
+	return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:            "stable",
+		ResourceVersion: rv,
+		Annotations:     map[string]string{OwnerAnnotation: "user@example.com"},
+	}}
+}
+
+// TestEvaluationCache validates that unchanged namespaces with a fresh
+// valid-owner evaluation under the same configuration are skipped, and
+// re-evaluated otherwise
+func TestEvaluationCache(t *testing.T) {
+	now := time.Now()
+	previous := map[string]Evaluation{
+		"stable": {ResourceVersion: "7", Owner: "user@example.com", ConfigHash: "0123456789ab", EvaluatedAt: now.Add(-time.Hour)},
+	}
+
+	tests := []struct {
+		name        string
+		ns          corev1.Namespace
+		ttl         time.Duration
+		configure   func(p *NamespaceProcessor)
+		wantAction  Action
+		wantLookups int
+	}{
+		{name: "unchanged", ns: cachedNamespace("7"), ttl: 24 * time.Hour, wantAction: ActionCached},
+		{name: "resource version changed", ns: cachedNamespace("8"), ttl: 24 * time.Hour, wantAction: ActionNone, wantLookups: 1},
+		{
+			name: "owner changed",
+			ns: func() corev1.Namespace {
+				ns := cachedNamespace("7")
+				ns.Annotations[OwnerAnnotation] = "other@example.com"
+				return ns
+			}(),
+			ttl:         24 * time.Hour,
+			wantAction:  ActionNone,
+			wantLookups: 1,
+		},
+		{name: "expired", ns: cachedNamespace("7"), ttl: time.Minute, wantAction: ActionNone, wantLookups: 1},
+		{
+			name:        "configuration changed",
+			ns:          cachedNamespace("7"),
+			ttl:         24 * time.Hour,
+			configure:   func(p *NamespaceProcessor) { p.SetRunInfo("run-2", "ba9876543210") },
+			wantAction:  ActionNone,
+			wantLookups: 1,
+		},
+		{
+			name: "audit policies changed",
+			ns:   cachedNamespace("7"),
+			ttl:  24 * time.Hour,
+			configure: func(p *NamespaceProcessor) {
+				p.SetAuditPolicies([]AuditPolicy{{Name: "all", Generation: 2, Selector: labels.Everything()}})
+			},
+			wantAction:  ActionNone,
+			wantLookups: 1,
+		},
+		{name: "disabled", ns: cachedNamespace("7"), wantAction: ActionNone, wantLookups: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := newTestProcessor(true, []*corev1.Namespace{tt.ns.DeepCopy()}, false)
+			checker := &countingChecker{calls: map[string]int{}, existing: map[string]bool{"user@example.com": true, "other@example.com": true}}
+			processor.azureClient = checker
+			processor.SetRunInfo("run-2", "0123456789ab")
+			if tt.configure != nil {
+				tt.configure(processor)
+			}
+			processor.SetEvaluationCache(tt.ttl, previous)
+
+			var tr *Trace
+			captureLogs(func() {
+				processor.Prefetch(context.TODO(), []corev1.Namespace{tt.ns})
+				tr = processor.ProcessNamespaceTraced(context.TODO(), tt.ns)
+			})
+			if tr.Action != tt.wantAction {
+				t.Errorf("Action = %q, want %q", tr.Action, tt.wantAction)
+			}
+			lookups := 0
+			for _, n := range checker.calls {
+				lookups += n
+			}
+			if lookups != tt.wantLookups {
+				t.Errorf("Lookups = %d, want %d", lookups, tt.wantLookups)
+			}
+			if tt.ttl == 0 {
+				return
+			}
+
+			saved, ok := processor.Evaluations()["stable"]
+			if !ok {
+				t.Fatal("Evaluation not carried into the next run")
+			}
+			if saved.ResourceVersion != tt.ns.ResourceVersion || saved.Owner != tt.ns.Annotations[OwnerAnnotation] ||
+				processor.evaluationScope() != (evaluationScope{saved.ConfigHash, saved.PolicyGeneration}) {
+				t.Errorf("Saved evaluation %+v does not match the namespace", saved)
+			}
+			if tt.wantAction == ActionCached && (!saved.EvaluatedAt.Equal(previous["stable"].EvaluatedAt) || processor.CacheHits() != 1) {
+				t.Errorf("Cache hit should carry the original evaluation: %+v, hits %d", saved, processor.CacheHits())
+			}
+		})
+	}
+}
+
+// TestEvaluationsDropGoneNamespaces validates that evaluations of namespaces
+// not audited in a run, such as those deleted since, are not saved again
+func TestEvaluationsDropGoneNamespaces(t *testing.T) {
+	ns := cachedNamespace("7")
+	processor := newTestProcessor(true, []*corev1.Namespace{ns.DeepCopy()}, false)
+	processor.SetEvaluationCache(time.Hour, map[string]Evaluation{
+		"gone": {ResourceVersion: "3", Owner: "user@example.com", EvaluatedAt: time.Now()},
+	})
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), ns)
+	})
+	if _, ok := processor.Evaluations()["gone"]; ok || len(processor.Evaluations()) != 1 {
+		t.Errorf("Evaluations = %+v, want only stable", processor.Evaluations())
+	}
+}
+
+// TestEvaluationCacheSkipsInvalidOwners validates that only valid-owner
+// outcomes are cached
+func TestEvaluationCacheSkipsInvalidOwners(t *testing.T) {
+	ns := cachedNamespace("7")
+	processor := newTestProcessor(false, []*corev1.Namespace{ns.DeepCopy()}, false)
+	processor.SetEvaluationCache(time.Hour, nil)
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), ns)
+	})
+	if len(processor.Evaluations()) != 0 {
+		t.Errorf("Marked namespace should not be cached: %+v", processor.Evaluations())
+	}
+}
@@ -0,0 +1,75 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reasons of the Kubernetes Events recorded on audited namespaces
+const (
+	EventReasonMarked      = "MarkedForDeletion" // Deletion marker added
+	EventReasonUnmarked    = "Unmarked"          // Deletion marker removed
+	EventReasonDeleted     = "Deleted"           // Namespace deleted after its grace period
+	EventReasonCordoned    = "Cordoned"          // Access removed after its grace period
+	EventReasonOrphaned    = "Orphaned"          // Kept as orphaned after its grace period
+	EventReasonQuarantined = "Quarantined"       // Workloads stopped and traffic blocked before deletion
+	EventReasonReleased    = "Released"          // Quarantine lifted
+)
+
+// EventNamespace holds the Events recorded on namespaces. Namespaces are
+// cluster-scoped, so their Events live in the default namespace, where
+// `kubectl describe namespace` finds them and where they outlive deleted
+// namespaces.
+const EventNamespace = metav1.NamespaceDefault
+
+// SetEventRecording enables Kubernetes Events on namespaces the auditor
+// marks, unmarks, deletes or cordons, so that their history shows in
+// `kubectl describe namespace`.
+func (p *NamespaceProcessor) SetEventRecording(enabled bool) {
+	p.recordEvents = enabled
+}
+
+// recordEvent records a Kubernetes Event on ns, or plans it in a dry run.
+// Failures are logged but do not fail the decision the Event describes.
+func (p *NamespaceProcessor) recordEvent(ctx context.Context, ns corev1.Namespace, eventType, reason, format string, args ...interface{}) {
+	if !p.recordEvents {
+		return
+	}
+	now := metav1.NewTime(p.now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", ns.Name, now.UnixNano()),
+			Namespace: EventNamespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:            "Namespace",
+			APIVersion:      "v1",
+			Name:            ns.Name,
+			UID:             ns.UID,
+			ResourceVersion: ns.ResourceVersion,
+		},
+		Reason:              reason,
+		Message:             fmt.Sprintf(format, args...),
+		Type:                eventType,
+		Source:              corev1.EventSource{Component: FieldManager},
+		ReportingController: FieldManager,
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+	}
+	if err := p.mutations().createEvent(ctx, event); err != nil {
+		log.Printf("Error recording %s event on %s: %v", reason, ns.Name, err)
+	}
+}
+
+// describeOwner renders the owner checked for ns in Event messages
+func describeOwner(ns corev1.Namespace) string {
+	if owner := ns.Annotations[OwnerAnnotation]; owner != "" {
+		return fmt.Sprintf("owner %q", owner)
+	}
+	return "no owner"
+}
@@ -0,0 +1,108 @@
+package auditor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// listEvents returns the Events recorded on namespaces, and fails the test
+// if one was not recorded by the auditor on a namespace
+func listEvents(t *testing.T, p *NamespaceProcessor) []corev1.Event {
+	t.Helper()
+	events, err := p.k8sClient.CoreV1().Events(EventNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List events: %v", err)
+	}
+	for _, e := range events.Items {
+		if e.InvolvedObject.Kind != "Namespace" || e.Source.Component != FieldManager {
+			t.Errorf("Event %s: involved %+v, source %+v", e.Name, e.InvolvedObject, e.Source)
+		}
+	}
+	return events.Items
+}
+
+// processAlice processes the stored alice namespace
+func processAlice(t *testing.T, p *NamespaceProcessor) Action {
+	t.Helper()
+	ns, err := p.GetNamespace(context.TODO(), "alice")
+	if err != nil {
+		t.Fatalf("GetNamespace: %v", err)
+	}
+	var tr *Trace
+	captureLogs(func() { tr = p.ProcessNamespaceTraced(context.TODO(), *ns) })
+	return tr.Action
+}
+
+// TestEventsLifecycle validates that marking, unmarking and deleting a
+// namespace each record an Event naming the owner checked
+func TestEventsLifecycle(t *testing.T) {
+	p := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{OwnerAnnotation: "alice@example.com"}}},
+	}, false)
+	p.SetEventRecording(true)
+
+	if action := processAlice(t, p); action != ActionMark {
+		t.Fatalf("Action = %q, want %q", action, ActionMark)
+	}
+	p.azureClient = &MockUserChecker{exists: true}
+	if action := processAlice(t, p); action != ActionUnmark {
+		t.Fatalf("Action = %q, want %q", action, ActionUnmark)
+	}
+	p.azureClient = &MockUserChecker{exists: false}
+	ns, _ := p.GetNamespace(context.TODO(), "alice")
+	ns.Annotations[GracePeriodAnnotation] = time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	ns.Annotations[RunIDAnnotation] = "run-1"
+	if _, err := p.k8sClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if action := processAlice(t, p); action != ActionDelete {
+		t.Fatalf("Action = %q, want %q", action, ActionDelete)
+	}
+
+	events := listEvents(t, p)
+	want := map[string]string{
+		EventReasonMarked:   corev1.EventTypeWarning,
+		EventReasonUnmarked: corev1.EventTypeNormal,
+		EventReasonDeleted:  corev1.EventTypeWarning,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Events = %+v, want one per action", events)
+	}
+	for _, e := range events {
+		if want[e.Reason] != e.Type || e.InvolvedObject.Name != "alice" || !strings.Contains(e.Message, "alice@example.com") {
+			t.Errorf("Unexpected event %s %s on %s: %q", e.Type, e.Reason, e.InvolvedObject.Name, e.Message)
+		}
+	}
+}
+
+// TestEventsDisabledAndDryRun validates that no Events are recorded unless
+// enabled, and that a dry run only plans them
+func TestEventsDisabledAndDryRun(t *testing.T) {
+	owned := map[string]string{OwnerAnnotation: "alice@example.com"}
+	p := newTestProcessor(false, []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: owned}}}, false)
+	processAlice(t, p)
+	if events := listEvents(t, p); len(events) != 0 {
+		t.Errorf("Events recorded while disabled: %+v", events)
+	}
+
+	p = newTestProcessor(false, []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: owned}}}, true)
+	p.SetEventRecording(true)
+	processAlice(t, p)
+	if events := listEvents(t, p); len(events) != 0 {
+		t.Errorf("Events recorded in a dry run: %+v", events)
+	}
+	var planned []PlannedChange
+	for _, c := range p.PlannedChanges() {
+		if c.Op == OpEventCreate {
+			planned = append(planned, c)
+		}
+	}
+	if len(planned) != 1 || planned[0].Namespace != "alice" || !strings.HasPrefix(planned[0].Detail, EventReasonMarked+": ") {
+		t.Errorf("Planned events = %+v", planned)
+	}
+}
@@ -0,0 +1,102 @@
+package auditor
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// dateLayout is the layout of date-only exemption expiries
+const dateLayout = "2006-01-02"
+
+// ExpiredExemption describes an exemption that no longer applies. The
+// namespace is audited again; the annotation is left for its owner to remove
+// or extend.
+type ExpiredExemption struct {
+	Name    string `json:"name"`              // Namespace name
+	Until   string `json:"until"`             // Value of ExemptUntilAnnotation
+	Invalid bool   `json:"invalid,omitempty"` // Whether the value was not a recognized date, rather than in the past
+}
+
+// ExpiredExemptions returns the namespaces whose exemption had expired, or
+// could not be read, when they were evaluated during this run.
+func (p *NamespaceProcessor) ExpiredExemptions() []ExpiredExemption {
+	return p.expiredExemptions.list()
+}
+
+// parseExemptUntil parses the expiry of an exemption. A date without a time
+// exempts the namespace through the end of that day, in UTC.
+func parseExemptUntil(value string) (time.Time, error) {
+	if day, err := time.Parse(dateLayout, value); err == nil {
+		return day.AddDate(0, 0, 1), nil
+	}
+	return parseMarkerTime(value)
+}
+
+// handleExemption honors the exemptions of the applied audit policy,
+// ExemptAnnotation and an unexpired ExemptUntilAnnotation. An exempt namespace is not audited; a deletion
+// marker written by the auditor is removed, so that the grace period starts
+// over once the exemption ends. Expired or unreadable exemptions are
+// reported and auditing resumes. Returns whether the namespace is exempt.
+func (p *NamespaceProcessor) handleExemption(ctx context.Context, ns corev1.Namespace, now time.Time) bool {
+	if pattern, ok := p.policyExemption(ns); ok {
+		log.Printf("Skipping %s: exempt by NamespaceAuditPolicy %s", ns.Name, p.auditPolicy.Name)
+		p.trace.add("exemption", "exempt by NamespaceAuditPolicy %s, name matches %q", p.auditPolicy.Name, pattern)
+		p.exempt(ctx, ns)
+		return true
+	}
+	if value, ok := ns.Annotations[ExemptAnnotation]; ok {
+		exempt, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("Ignoring %s=%q on %s, expected \"true\" or \"false\"", ExemptAnnotation, value, ns.Name)
+			p.trace.add("exemption", "%s %q is not a boolean, ignored", ExemptAnnotation, value)
+		}
+		if exempt {
+			log.Printf("Skipping %s: exempt", ns.Name)
+			p.trace.add("exemption", "exempt until %s is removed", ExemptAnnotation)
+			p.exempt(ctx, ns)
+			return true
+		}
+	}
+
+	value, ok := ns.Annotations[ExemptUntilAnnotation]
+	if !ok {
+		return false
+	}
+	until, err := parseExemptUntil(value)
+	if err != nil {
+		log.Printf("Ignoring exemption of %s: %v", ns.Name, err)
+		p.trace.add("exemption", "exemption %q is not a recognized date, auditing", value)
+		p.expiredExemptions.add(ExpiredExemption{Name: ns.Name, Until: value, Invalid: true})
+		return false
+	}
+	if !now.Before(until) {
+		log.Printf("Exemption of %s expired at %s, auditing", ns.Name, formatMarkerTime(until))
+		p.trace.add("exemption", "exemption expired at %s, auditing", formatMarkerTime(until))
+		p.expiredExemptions.add(ExpiredExemption{Name: ns.Name, Until: value})
+		return false
+	}
+
+	log.Printf("Skipping %s: exempt until %s", ns.Name, formatMarkerTime(until))
+	p.trace.add("exemption", "exempt until %s", formatMarkerTime(until))
+	p.exempt(ctx, ns)
+	return true
+}
+
+// exempt leaves an exempt namespace alone, removing a deletion marker
+// written by the auditor
+func (p *NamespaceProcessor) exempt(ctx context.Context, ns corev1.Namespace) {
+	if _, marked := ns.Annotations[GracePeriodAnnotation]; !marked || !p.ownsMarker(ns) {
+		p.trace.setAction(ActionExempt)
+		return
+	}
+	log.Printf("Removing deletion marker from exempt namespace %s", ns.Name)
+	p.trace.setAction(ActionUnmark)
+	clearMarker(ns.Annotations)
+	if err := p.updateNamespace(ctx, &ns); err != nil {
+		log.Printf("Error updating %s: %v", ns.Name, err)
+	}
+}
@@ -0,0 +1,105 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParseExemptUntil validates exemption expiry formats
+func TestParseExemptUntil(t *testing.T) {
+	for value, want := range map[string]time.Time{
+		"2025-12-31":           time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		"2025-12-31T12:00:00Z": time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC),
+	} {
+		got, err := parseExemptUntil(value)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseExemptUntil(%q) = %s, %v, want %s", value, got, err, want)
+		}
+	}
+	if _, err := parseExemptUntil("next year"); err == nil {
+		t.Error("Expected error for an unrecognized date")
+	}
+}
+
+// TestExemption validates that exemptions are honored until they expire
+func TestExemption(t *testing.T) {
+	now := time.Now()
+	exempt := func(name, until string, marked bool) corev1.Namespace {
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{
+			OwnerAnnotation:       "gone@example.com",
+			ExemptUntilAnnotation: until,
+		}}}
+		if marked {
+			ns.Annotations[GracePeriodAnnotation] = "2020-01-01T00:00:00Z"
+		}
+		return ns
+	}
+	tests := []struct {
+		ns      corev1.Namespace
+		want    Action
+		expired bool
+	}{
+		{ns: exempt("exempt", formatMarkerTime(now.Add(time.Hour)), false), want: ActionExempt},
+		{ns: exempt("exempt-marked", now.AddDate(0, 0, 1).Format(dateLayout), true), want: ActionUnmark},
+		{ns: exempt("expired", formatMarkerTime(now.Add(-time.Hour)), false), want: ActionMark, expired: true},
+		{ns: exempt("invalid", "someday", false), want: ActionMark, expired: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ns.Name, func(t *testing.T) {
+			processor := newTestProcessor(false, []*corev1.Namespace{&tt.ns}, false)
+			var d Decision
+			captureLogs(func() {
+				d = processor.ProcessNamespace(context.TODO(), *tt.ns.DeepCopy())
+			})
+			if d.Action != tt.want {
+				t.Errorf("Action = %q, want %q", d.Action, tt.want)
+			}
+			if expired := len(processor.ExpiredExemptions()) == 1; expired != tt.expired {
+				t.Errorf("ExpiredExemptions() = %v, want expired %t", processor.ExpiredExemptions(), tt.expired)
+			}
+		})
+	}
+}
+
+// TestPermanentExemption validates that namespace-auditor/exempt protects a
+// namespace regardless of its exemption expiry
+func TestPermanentExemption(t *testing.T) {
+	exempt := func(name, value string, marked bool) corev1.Namespace {
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{
+			OwnerAnnotation:  "gone@example.com",
+			ExemptAnnotation: value,
+		}}}
+		if marked {
+			ns.Annotations[GracePeriodAnnotation] = "2020-01-01T00:00:00Z"
+		}
+		return ns
+	}
+	expiredUntil := exempt("expired-until", "true", false)
+	expiredUntil.Annotations[ExemptUntilAnnotation] = "2020-01-01"
+	tests := []struct {
+		ns   corev1.Namespace
+		want Action
+	}{
+		{ns: exempt("exempt", "true", false), want: ActionExempt},
+		{ns: exempt("exempt-marked", "true", true), want: ActionUnmark},
+		{ns: expiredUntil, want: ActionExempt},
+		{ns: exempt("not-exempt", "false", false), want: ActionMark},
+		{ns: exempt("invalid", "yes please", false), want: ActionMark},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ns.Name, func(t *testing.T) {
+			processor := newTestProcessor(false, []*corev1.Namespace{&tt.ns}, false)
+			var d Decision
+			captureLogs(func() {
+				d = processor.ProcessNamespace(context.TODO(), *tt.ns.DeepCopy())
+			})
+			if d.Action != tt.want {
+				t.Errorf("Action = %q, want %q", d.Action, tt.want)
+			}
+		})
+	}
+}
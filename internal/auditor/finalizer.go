@@ -0,0 +1,172 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// DefaultPreDeleteTimeout bounds how long the pre-delete finalizer may hold
+// a namespace in Terminating state unless configured otherwise.
+const DefaultPreDeleteTimeout = 24 * time.Hour
+
+// PreDeleteStep is run for a namespace after its deletion was requested
+// but before the pre-delete finalizer is released. Returning an error keeps
+// the finalizer in place so the step is retried on the next run.
+type PreDeleteStep func(ctx context.Context, ns corev1.Namespace) error
+
+// namedStep pairs a pre-delete step with a name for logs and traces
+type namedStep struct {
+	name string
+	run  PreDeleteStep
+}
+
+// SetPreDeleteFinalizer enables the pre-delete finalizer. Namespaces are
+// given PreDeleteFinalizer before deletion, and it is only removed once all
+// pre-delete steps have succeeded, timeout has passed since deletion was
+// requested, or SkipPreDeleteAnnotation is set to "true".
+func (p *NamespaceProcessor) SetPreDeleteFinalizer(enabled bool, timeout time.Duration) {
+	p.preDeleteFinalizer = enabled
+	p.preDeleteTimeout = timeout
+}
+
+// AddPreDeleteStep registers an additional step that must complete before
+// the pre-delete finalizer is released. Steps run in registration order,
+// after the built-in owner notification.
+func (p *NamespaceProcessor) AddPreDeleteStep(name string, step PreDeleteStep) {
+	p.preDeleteSteps = append(p.preDeleteSteps, namedStep{name: name, run: step})
+}
+
+// hasFinalizer reports whether the namespace carries PreDeleteFinalizer
+func hasFinalizer(ns corev1.Namespace) bool {
+	return contains(ns.Finalizers, PreDeleteFinalizer)
+}
+
+// addFinalizer adds PreDeleteFinalizer to a namespace about to be deleted
+func (p *NamespaceProcessor) addFinalizer(ctx context.Context, ns *corev1.Namespace) error {
+	if hasFinalizer(*ns) {
+		return nil
+	}
+	ns.Finalizers = append(ns.Finalizers, PreDeleteFinalizer)
+	return p.updateNamespace(ctx, ns)
+}
+
+// removeFinalizer releases PreDeleteFinalizer, letting deletion complete
+func (p *NamespaceProcessor) removeFinalizer(ctx context.Context, ns corev1.Namespace) error {
+	var kept []string
+	for _, f := range ns.Finalizers {
+		if f != PreDeleteFinalizer {
+			kept = append(kept, f)
+		}
+	}
+	ns.Finalizers = kept
+	return p.updateNamespace(ctx, &ns)
+}
+
+// finalizeDeleted runs the pre-delete steps for a namespace right after its
+// deletion was requested.
+func (p *NamespaceProcessor) finalizeDeleted(ctx context.Context, name string) {
+	ns, err := p.GetNamespace(ctx, name)
+	if apierrors.IsNotFound(err) {
+		return // Already gone, nothing left to hold
+	}
+	if err != nil {
+		log.Printf("Error fetching %s for pre-delete steps: %v", name, err)
+		return
+	}
+
+	// The deletion itself remains the traced action of this run
+	untraced := *p
+	untraced.trace = nil
+	untraced.handleTerminating(ctx, *ns)
+}
+
+// handleTerminating completes the pre-delete steps of a namespace whose
+// deletion has been requested and releases the finalizer once allowed.
+func (p *NamespaceProcessor) handleTerminating(ctx context.Context, ns corev1.Namespace) {
+	if !hasFinalizer(ns) {
+		if p.handleStuck(ctx, ns) {
+			return
+		}
+		p.trace.add("finalizer", "namespace is terminating")
+		p.trace.setAction(ActionSkip)
+		return
+	}
+
+	release, reason := p.preDeleteOutcome(ctx, ns)
+	if !release {
+		log.Printf("Holding %s in pre-delete: %s", ns.Name, reason)
+		p.trace.add("finalizer", "holding: %s", reason)
+		p.trace.setAction(ActionWait)
+		return
+	}
+
+	log.Printf("Releasing pre-delete finalizer of %s: %s", ns.Name, reason)
+	p.trace.add("finalizer", "releasing: %s", reason)
+	p.trace.setAction(ActionFinalize)
+	if err := p.removeFinalizer(ctx, ns); err != nil {
+		log.Printf("Error removing finalizer from %s: %v", ns.Name, err)
+	}
+}
+
+// preDeleteOutcome runs the pre-delete steps and decides whether the
+// finalizer may be released, with a reason for logs and traces.
+func (p *NamespaceProcessor) preDeleteOutcome(ctx context.Context, ns corev1.Namespace) (bool, string) {
+	if ns.Annotations[SkipPreDeleteAnnotation] == "true" {
+		return true, "manual override"
+	}
+	if p.dryRun {
+		return true, "dry run, pre-delete steps not run"
+	}
+
+	steps := append([]namedStep{{name: "notify", run: p.notifyDeleting}}, p.preDeleteSteps...)
+	var failed error
+	for _, step := range steps {
+		if err := step.run(ctx, ns); err != nil {
+			failed = fmt.Errorf("step %s: %w", step.name, err)
+			break
+		}
+	}
+	if failed == nil {
+		return true, "pre-delete steps completed"
+	}
+
+	timeout := p.preDeleteTimeout
+	if timeout <= 0 {
+		timeout = DefaultPreDeleteTimeout
+	}
+	if ns.DeletionTimestamp != nil && time.Since(ns.DeletionTimestamp.Time) > timeout {
+		log.Printf("Pre-delete steps of %s did not complete within %s: %v", ns.Name, timeout, failed)
+		return true, fmt.Sprintf("timed out after %s", timeout)
+	}
+	return false, failed.Error()
+}
+
+// notifyDeleting is the built-in pre-delete step telling the owner and
+// contributors that the namespace is being deleted.
+func (p *NamespaceProcessor) notifyDeleting(ctx context.Context, ns corev1.Namespace) error {
+	contributors, err := p.contributors(ctx, ns)
+	if err != nil {
+		return err
+	}
+	message := "grace period expired, namespace is being deleted"
+	if reason, ok := ns.Annotations[BreakGlassAnnotation]; ok {
+		message = fmt.Sprintf("namespace is being deleted immediately: %s", reason)
+	}
+	n := Notification{
+		Event:      EventDeleting,
+		Namespace:  ns.Name,
+		Owner:      ns.Annotations[OwnerAnnotation],
+		Message:    withBackup(ns, message),
+		Context:    p.namespaceContext(ns),
+		Contact:    ns.Annotations[SecondaryContactAnnotation],
+		Manager:    ns.Annotations[ManagerAnnotation],
+		Recipients: contributors,
+	}
+	p.addSecurityContact(ns, &n)
+	return p.deliver(ctx, n)
+}
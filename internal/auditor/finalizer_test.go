@@ -0,0 +1,142 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// terminatingNamespace builds a namespace whose deletion was requested at deletedAt
+func terminatingNamespace(deletedAt time.Time, annotations map[string]string) corev1.Namespace {
+	ts := metav1.NewTime(deletedAt)
+	return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              "terminating",
+		Annotations:       annotations,
+		Finalizers:        []string{PreDeleteFinalizer, "other/finalizer"},
+		DeletionTimestamp: &ts,
+	}}
+}
+
+// TestHandleTerminating validates when the pre-delete finalizer is released
+func TestHandleTerminating(t *testing.T) {
+	failing := func(context.Context, corev1.Namespace) error { return errors.New("backup failed") }
+
+	tests := []struct {
+		name        string
+		deletedAt   time.Time
+		annotations map[string]string
+		step        PreDeleteStep
+		wantAction  Action
+	}{
+		{name: "steps complete", deletedAt: time.Now(), wantAction: ActionFinalize},
+		{name: "step fails", deletedAt: time.Now(), step: failing, wantAction: ActionWait},
+		{name: "timeout passed", deletedAt: time.Now().Add(-2 * time.Hour), step: failing, wantAction: ActionFinalize},
+		{
+			name:        "manual override",
+			deletedAt:   time.Now(),
+			annotations: map[string]string{SkipPreDeleteAnnotation: "true"},
+			step:        failing,
+			wantAction:  ActionFinalize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := terminatingNamespace(tt.deletedAt, tt.annotations)
+			notifier := &recordingNotifier{}
+			processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+			processor.SetPreDeleteFinalizer(true, time.Hour)
+			processor.SetNotifier(notifier)
+			if tt.step != nil {
+				processor.AddPreDeleteStep("backup", tt.step)
+			}
+
+			var tr *Trace
+			captureLogs(func() {
+				tr = processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+			})
+			if tr.Action != tt.wantAction {
+				t.Errorf("Action = %q, want %q", tr.Action, tt.wantAction)
+			}
+
+			updatedNs, _ := processor.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+			released := !hasFinalizer(*updatedNs)
+			if released != (tt.wantAction == ActionFinalize) {
+				t.Errorf("Finalizer released = %t, finalizers %v", released, updatedNs.Finalizers)
+			}
+			if !contains(updatedNs.Finalizers, "other/finalizer") {
+				t.Error("Other finalizers must be preserved")
+			}
+		})
+	}
+}
+
+// TestHandleTerminatingNotifies validates the built-in deletion notification
+func TestHandleTerminatingNotifies(t *testing.T) {
+	ns := terminatingNamespace(time.Now(), map[string]string{OwnerAnnotation: "gone@example.com"})
+	notifier := &recordingNotifier{}
+	processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+	processor.SetPreDeleteFinalizer(true, time.Hour)
+	processor.SetNotifier(notifier)
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+	})
+	if len(notifier.sent) != 1 || notifier.sent[0].Event != EventDeleting || notifier.sent[0].Owner != "gone@example.com" {
+		t.Errorf("Expected one deleting notification, got %+v", notifier.sent)
+	}
+
+	// A failed notification holds the finalizer
+	failing := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+	failing.SetPreDeleteFinalizer(true, time.Hour)
+	failing.SetNotifier(&recordingNotifier{err: errors.New("mail server down")})
+	captureLogs(func() {
+		failing.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+	})
+	updatedNs, _ := failing.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+	if !hasFinalizer(*updatedNs) {
+		t.Error("Finalizer should be held while notification fails")
+	}
+}
+
+// TestTerminatingWithoutFinalizer ensures other terminating namespaces are skipped
+func TestTerminatingWithoutFinalizer(t *testing.T) {
+	ns := terminatingNamespace(time.Now(), nil)
+	ns.Finalizers = []string{"kubernetes"}
+	processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+
+	tr := processor.ProcessNamespaceTraced(context.TODO(), ns)
+	if tr.Action != ActionSkip {
+		t.Errorf("Expected skip, got %q", tr.Action)
+	}
+}
+
+// TestDeleteAddsFinalizer validates that deletion adds the finalizer first
+func TestDeleteAddsFinalizer(t *testing.T) {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "expired",
+		Annotations: map[string]string{
+			OwnerAnnotation:       "gone@example.com",
+			GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+		},
+	}}
+	processor := newTestProcessor(false, []*corev1.Namespace{&ns}, false)
+	processor.apiStats = &APIStats{}
+	processor.SetPreDeleteFinalizer(true, time.Hour)
+
+	var tr *Trace
+	captureLogs(func() {
+		tr = processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+	})
+	if tr.Action != ActionDelete {
+		t.Errorf("Expected delete, got %q", tr.Action)
+	}
+	stats := processor.APIStats().Snapshot()
+	if stats[OpUpdate].Count != 1 || stats[OpDelete].Count != 1 {
+		t.Errorf("Expected finalizer update before delete: %+v", stats)
+	}
+}
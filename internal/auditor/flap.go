@@ -0,0 +1,147 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// FlapThreshold is the number of times a deletion marker must have been
+	// removed because the owner reappeared before a namespace is flapping.
+	FlapThreshold = 2
+
+	// FlapWindow is how long a flap counts towards FlapThreshold. Namespaces
+	// that have been stable for longer are no longer considered flapping.
+	FlapWindow = 30 * 24 * time.Hour
+)
+
+// Observed owner states recorded in DampingAnnotation
+const (
+	flapStateMissing = "missing" // Owner not found, namespace would be marked
+	flapStateValid   = "valid"   // Owner found again, marker would be removed
+)
+
+// FlappingNamespace describes a namespace that oscillated between marked and
+// cleared, typically because of directory replication delays or alias
+// mismatches in the identity provider.
+type FlappingNamespace struct {
+	Name       string    `json:"name"`       // Namespace name
+	Flaps      int       `json:"flaps"`      // Markers removed because the owner reappeared, within FlapWindow
+	LastFlapAt time.Time `json:"lastFlapAt"` // When the last marker was removed
+	Damped     bool      `json:"damped"`     // Whether a state change was held back during this run
+}
+
+// SetFlapDamping requires a state change on a flapping namespace (marking it,
+// or removing its marker) to be observed on runs consecutive runs before it
+// is applied. Zero or one applies changes immediately.
+func (p *NamespaceProcessor) SetFlapDamping(runs int) {
+	if runs < 0 {
+		runs = 0
+	}
+	p.flapDamping = runs
+}
+
+// Flapping returns the flapping namespaces seen during this run.
+func (p *NamespaceProcessor) Flapping() []FlappingNamespace {
+	return p.flapping.list()
+}
+
+// recentFlaps returns the flap count of ns and when it last flapped, or zero
+// if it has not flapped within FlapWindow of now
+func recentFlaps(ns corev1.Namespace, now time.Time) (int, time.Time) {
+	count, err := strconv.Atoi(ns.Annotations[FlapCountAnnotation])
+	if err != nil || count <= 0 {
+		return 0, time.Time{}
+	}
+	last, err := parseMarkerTime(ns.Annotations[LastFlapAnnotation])
+	if err != nil || now.Sub(last) > FlapWindow {
+		return 0, time.Time{}
+	}
+	return count, last
+}
+
+// recordFlap counts the removal of a deletion marker because the owner
+// reappeared. Flaps older than FlapWindow are forgotten.
+func (p *NamespaceProcessor) recordFlap(ns corev1.Namespace, now time.Time) {
+	count, _ := recentFlaps(ns, now)
+	count++
+	ns.Annotations[FlapCountAnnotation] = strconv.Itoa(count)
+	ns.Annotations[LastFlapAnnotation] = formatMarkerTime(now)
+	if count >= FlapThreshold {
+		log.Printf("Namespace %s is flapping: marker removed %d times within %s", ns.Name, count, FlapWindow)
+		p.trace.add("flap", "marker removed %d times within %s, namespace is flapping", count, FlapWindow)
+		p.flapping.add(FlappingNamespace{Name: ns.Name, Flaps: count, LastFlapAt: now.UTC()})
+	}
+}
+
+// dampedRuns returns the number of consecutive runs that observed state, as
+// recorded in the damping annotation
+func dampedRuns(annotations map[string]string, state string) int {
+	observed, runs, ok := strings.Cut(annotations[DampingAnnotation], ":")
+	if !ok || observed != state {
+		return 0
+	}
+	n, err := strconv.Atoi(runs)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// damped reports whether a change to state on a flapping namespace must be
+// held back because it has not yet been observed on enough consecutive runs.
+// A held-back change is counted on the namespace. Otherwise the damping
+// annotation is dropped from ns, to be written with the change itself.
+func (p *NamespaceProcessor) damped(ns corev1.Namespace, state string, now time.Time) bool {
+	flaps, last := recentFlaps(ns, now)
+	flapping := FlappingNamespace{Name: ns.Name, Flaps: flaps, LastFlapAt: last}
+	if flaps < FlapThreshold {
+		delete(ns.Annotations, DampingAnnotation)
+		return false
+	}
+
+	runs := dampedRuns(ns.Annotations, state) + 1
+	if p.flapDamping <= 1 || runs >= p.flapDamping {
+		if p.flapDamping > 1 {
+			p.trace.add("flap", "namespace flapped %d times, owner %s on %d consecutive runs, applying change", flaps, state, runs)
+		}
+		delete(ns.Annotations, DampingAnnotation)
+		if state == flapStateMissing {
+			p.flapping.add(flapping) // Removing a marker is reported by recordFlap
+		}
+		return false
+	}
+
+	log.Printf("Damping %s: flapped %d times, owner %s on %d of %d required runs", ns.Name, flaps, state, runs, p.flapDamping)
+	p.trace.add("flap", "namespace flapped %d times, owner %s on %d of %d required consecutive runs", flaps, state, runs, p.flapDamping)
+	p.trace.setAction(ActionDamp)
+	flapping.Damped = true
+	p.flapping.add(flapping)
+
+	ns.Annotations[DampingAnnotation] = fmt.Sprintf("%s:%d", state, runs)
+	if err := p.updateNamespace(context.TODO(), &ns); err != nil {
+		log.Printf("Error damping %s: %v", ns.Name, err)
+	}
+	return true
+}
+
+// settle notes that the owner state of ns matches its marker, so any change
+// held back by damping did not persist. The stale damping annotation is
+// dropped from ns; reports whether the caller must write ns.
+func (p *NamespaceProcessor) settle(ns corev1.Namespace, now time.Time) bool {
+	if flaps, last := recentFlaps(ns, now); flaps >= FlapThreshold {
+		p.flapping.add(FlappingNamespace{Name: ns.Name, Flaps: flaps, LastFlapAt: last})
+	}
+	if _, ok := ns.Annotations[DampingAnnotation]; !ok {
+		return false
+	}
+	p.trace.add("flap", "owner state %q did not persist, damping reset", ns.Annotations[DampingAnnotation])
+	delete(ns.Annotations, DampingAnnotation)
+	return true
+}
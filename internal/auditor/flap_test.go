@@ -0,0 +1,135 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// flappingNamespace builds an unmarked namespace that already flapped count times
+func flappingNamespace(count string, lastFlap time.Time) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "flappy",
+		Annotations: map[string]string{
+			OwnerAnnotation:     "user@example.com",
+			FlapCountAnnotation: count,
+			LastFlapAnnotation:  formatMarkerTime(lastFlap),
+		},
+	}}
+}
+
+// runOnce processes the stored namespace as a new run would, with the owner
+// reported as exists, and returns the resulting trace and namespace
+func runOnce(t *testing.T, p *NamespaceProcessor, name string, exists bool) (*Trace, *corev1.Namespace) {
+	t.Helper()
+	p.azureClient = &MockUserChecker{exists: exists}
+	ns, err := p.GetNamespace(context.TODO(), name)
+	if err != nil {
+		t.Fatalf("GetNamespace: %v", err)
+	}
+	var tr *Trace
+	captureLogs(func() {
+		tr = p.ProcessNamespaceTraced(context.TODO(), *ns)
+	})
+	got, err := p.GetNamespace(context.TODO(), name)
+	if err != nil {
+		t.Fatalf("GetNamespace: %v", err)
+	}
+	return tr, got
+}
+
+// TestFlapCounting validates that removing a marker because the owner
+// reappeared is counted, and that old flaps are forgotten
+func TestFlapCounting(t *testing.T) {
+	p := newTestProcessor(false, []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{
+		Name:        "flappy",
+		Annotations: map[string]string{OwnerAnnotation: "user@example.com"},
+	}}}, false)
+
+	for i, want := range []string{"1", "2"} {
+		if tr, _ := runOnce(t, p, "flappy", false); tr.Action != ActionMark {
+			t.Fatalf("Cycle %d: Action = %q, want %q", i, tr.Action, ActionMark)
+		}
+		tr, ns := runOnce(t, p, "flappy", true)
+		if tr.Action != ActionUnmark {
+			t.Fatalf("Cycle %d: Action = %q, want %q", i, tr.Action, ActionUnmark)
+		}
+		if got := ns.Annotations[FlapCountAnnotation]; got != want {
+			t.Errorf("Cycle %d: flap count = %q, want %q", i, got, want)
+		}
+	}
+	if flapping := p.Flapping(); len(flapping) == 0 || flapping[len(flapping)-1].Flaps != 2 {
+		t.Errorf("Flapping = %+v, want the namespace with 2 flaps", flapping)
+	}
+
+	if n, _ := recentFlaps(*flappingNamespace("5", time.Now().Add(-FlapWindow-time.Hour)), time.Now()); n != 0 {
+		t.Errorf("recentFlaps outside window = %d, want 0", n)
+	}
+}
+
+// TestFlapDamping validates that state changes on flapping namespaces are
+// only applied once observed on enough consecutive runs
+func TestFlapDamping(t *testing.T) {
+	p := newTestProcessor(false, []*corev1.Namespace{flappingNamespace("2", time.Now().Add(-time.Hour))}, false)
+	p.SetFlapDamping(2)
+
+	// Owner missing once, then found again: never marked, damping reset
+	tr, ns := runOnce(t, p, "flappy", false)
+	if tr.Action != ActionDamp {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionDamp)
+	}
+	if got := ns.Annotations[DampingAnnotation]; got != "missing:1" {
+		t.Errorf("Damping = %q, want missing:1", got)
+	}
+	if tr, ns = runOnce(t, p, "flappy", true); tr.Action != ActionNone {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionNone)
+	}
+	if _, ok := ns.Annotations[DampingAnnotation]; ok {
+		t.Errorf("Damping annotation not reset: %v", ns.Annotations)
+	}
+
+	// Owner missing on two consecutive runs: marked on the second
+	runOnce(t, p, "flappy", false)
+	if tr, ns = runOnce(t, p, "flappy", false); tr.Action != ActionMark {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionMark)
+	}
+	if _, ok := ns.Annotations[GracePeriodAnnotation]; !ok {
+		t.Error("Namespace not marked after stable runs")
+	}
+	if _, ok := ns.Annotations[DampingAnnotation]; ok {
+		t.Errorf("Damping annotation kept after change: %v", ns.Annotations)
+	}
+
+	// Owner found once: marker held back
+	if tr, ns = runOnce(t, p, "flappy", true); tr.Action != ActionDamp {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionDamp)
+	}
+	if _, ok := ns.Annotations[GracePeriodAnnotation]; !ok {
+		t.Error("Marker removed while damped")
+	}
+
+	var damped bool
+	for _, f := range p.Flapping() {
+		damped = damped || f.Damped
+	}
+	if !damped {
+		t.Errorf("Flapping = %+v, want a damped entry", p.Flapping())
+	}
+}
+
+// TestFlapDampingDisabled validates that flapping namespaces are reported
+// but not damped by default
+func TestFlapDampingDisabled(t *testing.T) {
+	p := newTestProcessor(false, []*corev1.Namespace{flappingNamespace("3", time.Now())}, false)
+
+	if tr, _ := runOnce(t, p, "flappy", false); tr.Action != ActionMark {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionMark)
+	}
+	flapping := p.Flapping()
+	if len(flapping) != 1 || flapping[0].Flaps != 3 || flapping[0].Damped {
+		t.Errorf("Flapping = %+v, want one undamped entry with 3 flaps", flapping)
+	}
+}
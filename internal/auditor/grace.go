@@ -0,0 +1,218 @@
+package auditor
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// GracePeriodStrategy decides how long a marked namespace is kept before its
+// grace period expires. Pause windows and clock skew are applied by the
+// processor on top of the expiry a strategy computes.
+type GracePeriodStrategy interface {
+	// Expiry returns when the grace period of ns, marked at markedAt, ends.
+	Expiry(ns corev1.Namespace, markedAt time.Time) time.Time
+	// Describe renders the grace period applying to ns for traces and
+	// notifications.
+	Describe(ns corev1.Namespace) string
+}
+
+// FixedGracePeriod keeps every marked namespace for the same duration.
+type FixedGracePeriod time.Duration
+
+// Expiry implements GracePeriodStrategy
+func (g FixedGracePeriod) Expiry(_ corev1.Namespace, markedAt time.Time) time.Time {
+	return markedAt.Add(time.Duration(g))
+}
+
+// Describe implements GracePeriodStrategy
+func (g FixedGracePeriod) Describe(corev1.Namespace) string {
+	return time.Duration(g).String()
+}
+
+// BusinessDayGracePeriod keeps marked namespaces for a number of business
+// days, so that weekends and other non-working days do not consume the grace
+// period.
+type BusinessDayGracePeriod struct {
+	Days int      // Business days until expiry
+	Week WorkWeek // Days counted as business days
+}
+
+// Expiry implements GracePeriodStrategy
+func (g BusinessDayGracePeriod) Expiry(_ corev1.Namespace, markedAt time.Time) time.Time {
+	return AddBusinessDays(markedAt, g.Days, g.Week)
+}
+
+// Describe implements GracePeriodStrategy
+func (g BusinessDayGracePeriod) Describe(corev1.Namespace) string {
+	return fmt.Sprintf("%d business days (%s)", g.Days, g.Week)
+}
+
+// DomainGracePeriod chooses the grace period by the domain of the owner's
+// email, e.g. a shorter one for partner organizations. Namespaces whose
+// owner domain is not listed, or that have no owner, use Default.
+type DomainGracePeriod struct {
+	Domains map[string]GracePeriodStrategy // By normalized domain, see ParseAllowedDomains
+	Default GracePeriodStrategy
+}
+
+// Expiry implements GracePeriodStrategy
+func (g DomainGracePeriod) Expiry(ns corev1.Namespace, markedAt time.Time) time.Time {
+	return g.choose(ns).Expiry(ns, markedAt)
+}
+
+// Describe implements GracePeriodStrategy
+func (g DomainGracePeriod) Describe(ns corev1.Namespace) string {
+	return g.choose(ns).Describe(ns)
+}
+
+// choose returns the strategy for the owner domain of ns
+func (g DomainGracePeriod) choose(ns corev1.Namespace) GracePeriodStrategy {
+	if domain, ok := emailDomain(ns.Annotations[OwnerAnnotation]); ok {
+		if strategy, listed := g.Domains[domain]; listed {
+			return strategy
+		}
+	}
+	return g.Default
+}
+
+// TierGracePeriod chooses the grace period by the value of a namespace
+// label, e.g. a tier or environment label. Namespaces without the label,
+// or with an unlisted value, use Default.
+type TierGracePeriod struct {
+	Label   string                         // Label holding the tier
+	Tiers   map[string]GracePeriodStrategy // By label value
+	Default GracePeriodStrategy
+}
+
+// Expiry implements GracePeriodStrategy
+func (g TierGracePeriod) Expiry(ns corev1.Namespace, markedAt time.Time) time.Time {
+	return g.choose(ns).Expiry(ns, markedAt)
+}
+
+// Describe implements GracePeriodStrategy
+func (g TierGracePeriod) Describe(ns corev1.Namespace) string {
+	return g.choose(ns).Describe(ns)
+}
+
+// choose returns the strategy for the tier of ns
+func (g TierGracePeriod) choose(ns corev1.Namespace) GracePeriodStrategy {
+	if tier, ok := ns.Labels[g.Label]; ok {
+		if strategy, listed := g.Tiers[tier]; listed {
+			return strategy
+		}
+	}
+	return g.Default
+}
+
+// GraceRule applies a grace-period strategy to namespaces matching a label
+// selector.
+type GraceRule struct {
+	Selector labels.Selector
+	Strategy GracePeriodStrategy
+}
+
+// PolicyGracePeriod chooses the grace period by the first rule whose
+// selector matches the namespace labels. Namespaces matching no rule use
+// Default.
+type PolicyGracePeriod struct {
+	Rules   []GraceRule
+	Default GracePeriodStrategy
+}
+
+// Expiry implements GracePeriodStrategy
+func (g PolicyGracePeriod) Expiry(ns corev1.Namespace, markedAt time.Time) time.Time {
+	return g.choose(ns).Expiry(ns, markedAt)
+}
+
+// Describe implements GracePeriodStrategy
+func (g PolicyGracePeriod) Describe(ns corev1.Namespace) string {
+	return g.choose(ns).Describe(ns)
+}
+
+// choose returns the strategy of the first rule matching ns
+func (g PolicyGracePeriod) choose(ns corev1.Namespace) GracePeriodStrategy {
+	for _, rule := range g.Rules {
+		if rule.Selector.Matches(labels.Set(ns.Labels)) {
+			return rule.Strategy
+		}
+	}
+	return g.Default
+}
+
+// SetGracePeriodStrategy replaces how grace periods are computed, e.g. to
+// vary them by owner domain or namespace tier. A nil strategy restores the
+// fixed grace period the processor was created with.
+func (p *NamespaceProcessor) SetGracePeriodStrategy(strategy GracePeriodStrategy) {
+	p.grace = strategy
+}
+
+// SetGracePeriodOverrideMax honors GracePeriodOverrideAnnotation on
+// namespaces up to max. Overrides that are longer, malformed or not positive
+// are ignored and reported with the marked namespace. Zero, the default,
+// ignores all overrides.
+func (p *NamespaceProcessor) SetGracePeriodOverrideMax(max time.Duration) {
+	p.graceOverrideMax = max
+}
+
+// graceOverride returns the grace period set on ns with
+// GracePeriodOverrideAnnotation. It reports false if there is none or it is
+// ignored, with the reason in the error.
+func (p *NamespaceProcessor) graceOverride(ns corev1.Namespace) (time.Duration, bool, error) {
+	value, set := ns.Annotations[GracePeriodOverrideAnnotation]
+	if !set {
+		return 0, false, nil
+	}
+	d, err := time.ParseDuration(value)
+	switch {
+	case p.graceOverrideMax <= 0:
+		return 0, false, fmt.Errorf("grace period overrides are disabled")
+	case err != nil || d <= 0:
+		return 0, false, fmt.Errorf("expected a positive duration, got %q", value)
+	case d > p.graceOverrideMax:
+		return 0, false, fmt.Errorf("%s exceeds the maximum of %s", d, p.graceOverrideMax)
+	}
+	return d, true, nil
+}
+
+// traceGraceOverride records whether the grace period override of ns, if
+// any, applies
+func (p *NamespaceProcessor) traceGraceOverride(ns corev1.Namespace) {
+	d, ok, err := p.graceOverride(ns)
+	switch {
+	case ok:
+		p.trace.add("grace-override", "grace period overridden to %s by %s", d, GracePeriodOverrideAnnotation)
+	case err != nil:
+		log.Printf("Ignoring grace period override of %s: %v", ns.Name, err)
+		p.trace.add("grace-override", "%s ignored: %v", GracePeriodOverrideAnnotation, err)
+	}
+}
+
+// graceStrategy returns the grace-period strategy in effect
+func (p *NamespaceProcessor) graceStrategy() GracePeriodStrategy {
+	if p.grace == nil {
+		return FixedGracePeriod(p.gracePeriod)
+	}
+	return p.grace
+}
+
+// graceExpiry computes when the grace period for a marker set on ns at
+// markedAt ends
+func (p *NamespaceProcessor) graceExpiry(ns corev1.Namespace, markedAt time.Time) time.Time {
+	if d, ok, _ := p.graceOverride(ns); ok {
+		return markedAt.Add(d)
+	}
+	return p.graceStrategy().Expiry(ns, markedAt)
+}
+
+// describeGracePeriod renders the grace period applying to ns for traces
+// and notifications
+func (p *NamespaceProcessor) describeGracePeriod(ns corev1.Namespace) string {
+	if d, ok, _ := p.graceOverride(ns); ok {
+		return d.String() + " (namespace override)"
+	}
+	return p.graceStrategy().Describe(ns)
+}
@@ -0,0 +1,147 @@
+package auditor
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// TestGracePeriodStrategies validates the expiry and description each
+// strategy chooses for a namespace
+func TestGracePeriodStrategies(t *testing.T) {
+	friday := time.Date(2024, 5, 17, 9, 0, 0, 0, time.UTC)
+	week := FixedGracePeriod(7 * 24 * time.Hour)
+	day := FixedGracePeriod(24 * time.Hour)
+	businessDays := BusinessDayGracePeriod{Days: 2, Week: DefaultWorkWeek}
+
+	namespace := func(owner string, nsLabels map[string]string) corev1.Namespace {
+		return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "team",
+			Labels:      nsLabels,
+			Annotations: map[string]string{OwnerAnnotation: owner},
+		}}
+	}
+	byDomain := DomainGracePeriod{Domains: map[string]GracePeriodStrategy{"partner.org": day}, Default: week}
+	byTier := TierGracePeriod{Label: "tier", Tiers: map[string]GracePeriodStrategy{"sandbox": businessDays}, Default: week}
+	byPolicy := PolicyGracePeriod{
+		Rules: []GraceRule{
+			{Selector: labels.SelectorFromSet(labels.Set{"env": "prod"}), Strategy: week},
+			{Selector: labels.Everything(), Strategy: day},
+		},
+		Default: businessDays,
+	}
+
+	tests := []struct {
+		name         string
+		strategy     GracePeriodStrategy
+		ns           corev1.Namespace
+		want         time.Time
+		wantDescribe string
+	}{
+		{"fixed", day, namespace("user@example.com", nil), friday.Add(24 * time.Hour), "24h0m0s"},
+		{"business days", businessDays, namespace("user@example.com", nil), friday.AddDate(0, 0, 4), "2 business days (Mon,Tue,Wed,Thu,Fri)"},
+		{"listed domain", byDomain, namespace("User@Partner.org", nil), friday.Add(24 * time.Hour), "24h0m0s"},
+		{"other domain", byDomain, namespace("user@example.com", nil), friday.AddDate(0, 0, 7), "168h0m0s"},
+		{"no owner", byDomain, namespace("", nil), friday.AddDate(0, 0, 7), "168h0m0s"},
+		{"listed tier", byTier, namespace("user@example.com", map[string]string{"tier": "sandbox"}), friday.AddDate(0, 0, 4), "2 business days (Mon,Tue,Wed,Thu,Fri)"},
+		{"other tier", byTier, namespace("user@example.com", map[string]string{"tier": "gold"}), friday.AddDate(0, 0, 7), "168h0m0s"},
+		{"first rule", byPolicy, namespace("user@example.com", map[string]string{"env": "prod"}), friday.AddDate(0, 0, 7), "168h0m0s"},
+		{"catch-all rule", byPolicy, namespace("user@example.com", nil), friday.Add(24 * time.Hour), "24h0m0s"},
+		{"no rules", PolicyGracePeriod{Default: day}, namespace("user@example.com", nil), friday.Add(24 * time.Hour), "24h0m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.strategy.Expiry(tt.ns, friday); !got.Equal(tt.want) {
+				t.Errorf("Expiry = %v, want %v", got, tt.want)
+			}
+			if got := tt.strategy.Describe(tt.ns); got != tt.wantDescribe {
+				t.Errorf("Describe = %q, want %q", got, tt.wantDescribe)
+			}
+		})
+	}
+}
+
+// TestGracePeriodStrategyProcessing validates that the processor expires
+// markers according to its strategy
+func TestGracePeriodStrategyProcessing(t *testing.T) {
+	markedAt := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	p := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "partner", Annotations: map[string]string{
+			OwnerAnnotation:       "user@partner.org",
+			GracePeriodAnnotation: markedAt,
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "staff", Annotations: map[string]string{
+			OwnerAnnotation:       "user@example.com",
+			GracePeriodAnnotation: markedAt,
+		}}},
+	}, true)
+	p.allowedDomains = []string{"example.com", "partner.org"}
+	p.SetGracePeriodStrategy(DomainGracePeriod{
+		Domains: map[string]GracePeriodStrategy{"partner.org": FixedGracePeriod(24 * time.Hour)},
+		Default: FixedGracePeriod(7 * 24 * time.Hour),
+	})
+
+	if tr, _ := runOnce(t, p, "partner", false); tr.Action != ActionDelete {
+		t.Errorf("partner: Action = %q, want %q", tr.Action, ActionDelete)
+	}
+	if tr, _ := runOnce(t, p, "staff", false); tr.Action != ActionWait {
+		t.Errorf("staff: Action = %q, want %q", tr.Action, ActionWait)
+	}
+
+	p.SetGracePeriodStrategy(nil) // Back to the fixed 24h of newTestProcessor
+	if tr, _ := runOnce(t, p, "staff", false); tr.Action != ActionDelete {
+		t.Errorf("staff without a strategy: Action = %q, want %q", tr.Action, ActionDelete)
+	}
+}
+
+// TestGracePeriodOverride validates per-namespace grace period overrides and
+// their maximum
+func TestGracePeriodOverride(t *testing.T) {
+	markedAt := time.Now().Add(-30 * time.Hour).UTC().Format(time.RFC3339)
+	namespace := func(name, override string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{
+			OwnerAnnotation:               "user@example.com",
+			GracePeriodAnnotation:         markedAt,
+			GracePeriodOverrideAnnotation: override,
+		}}}
+	}
+	p := newTestProcessor(false, []*corev1.Namespace{
+		namespace("longer", "48h"),
+		namespace("shorter", "12h"),
+		namespace("too-long", "1440h"),
+		namespace("malformed", "60 days"),
+	}, true)
+	p.SetGracePeriodOverrideMax(72 * time.Hour)
+
+	tests := []struct {
+		name       string
+		wantAction Action
+		wantMarked MarkedNamespace
+	}{
+		{"longer", ActionWait, MarkedNamespace{GraceOverride: "48h0m0s"}},
+		{"shorter", ActionDelete, MarkedNamespace{GraceOverride: "12h0m0s"}},
+		{"too-long", ActionDelete, MarkedNamespace{GraceOverrideError: "1440h0m0s exceeds the maximum of 72h0m0s"}},
+		{"malformed", ActionDelete, MarkedNamespace{GraceOverrideError: `expected a positive duration, got "60 days"`}},
+	}
+	for i, tt := range tests {
+		tr, _ := runOnce(t, p, tt.name, false)
+		if tr.Action != tt.wantAction {
+			t.Errorf("%s: Action = %q, want %q", tt.name, tr.Action, tt.wantAction)
+		}
+		marked := p.Marked()[i]
+		if marked.GraceOverride != tt.wantMarked.GraceOverride || marked.GraceOverrideError != tt.wantMarked.GraceOverrideError {
+			t.Errorf("%s: Marked = %+v", tt.name, marked)
+		}
+	}
+	if got := p.describeGracePeriod(*namespace("longer", "48h")); got != "48h0m0s (namespace override)" {
+		t.Errorf("Describe = %q", got)
+	}
+
+	p.SetGracePeriodOverrideMax(0)
+	if tr, _ := runOnce(t, p, "longer", false); tr.Action != ActionDelete {
+		t.Errorf("Overrides disabled: Action = %q, want %q", tr.Action, ActionDelete)
+	}
+}
@@ -0,0 +1,58 @@
+package auditor
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+//go:embed report.html
+var htmlReportSource string
+
+// htmlReport renders run reports for stakeholders without cluster access
+var htmlReport = template.Must(template.New("report").Parse(htmlReportSource))
+
+// htmlMarked is a marked namespace as shown in the HTML report
+type htmlMarked struct {
+	MarkedNamespace
+	Remaining string // Time left until deletion, rendered
+	Overdue   bool   // Whether the grace period has already expired
+	Labels    string // Namespace context, rendered
+}
+
+// WriteHTML renders the report as a self-contained static HTML page listing
+// the namespaces marked for deletion, their owners, context and the time
+// remaining as of now, soonest deletion first.
+func (r *RunReport) WriteHTML(w io.Writer, now time.Time) error {
+	marked := make([]htmlMarked, 0, len(r.Marked))
+	hasContext := false
+	for _, m := range r.Marked {
+		left := m.DeleteAt.Sub(now)
+		marked = append(marked, htmlMarked{MarkedNamespace: m, Remaining: formatRemaining(left), Overdue: left <= 0, Labels: formatContext(m.Context)})
+		hasContext = hasContext || len(m.Context) > 0
+	}
+	sort.SliceStable(marked, func(i, j int) bool { return marked[i].DeleteAt.Before(marked[j].DeleteAt) })
+
+	return htmlReport.Execute(w, struct {
+		*RunReport
+		GeneratedAt time.Time
+		Marked      []htmlMarked
+		HasContext  bool
+	}{r, now.UTC(), marked, hasContext})
+}
+
+// formatRemaining renders the time left until a deletion in days and hours,
+// or hours and minutes once less than a day remains
+func formatRemaining(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "due"
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd %dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+	default:
+		return fmt.Sprintf("%dh %dm", d/time.Hour, d%time.Hour/time.Minute)
+	}
+}
@@ -0,0 +1,51 @@
+package auditor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWriteHTML validates the countdown view of marked namespaces
+func TestWriteHTML(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	report := NewRunReport("run-1", "abc")
+	report.Namespaces = 3
+	report.Marked = []MarkedNamespace{
+		{Name: "later", Owner: "a@example.com", MarkedAt: now, DeleteAt: now.Add(50 * time.Hour)},
+		{Name: "overdue", Owner: "<script>@example.com", MarkedAt: now.Add(-48 * time.Hour), DeleteAt: now.Add(-time.Hour)},
+		{Name: "soon", MarkedAt: now, DeleteAt: now.Add(90 * time.Minute)},
+	}
+	report.Saved = []SavedNamespace{{Name: "restored", Owner: "b@example.com"}}
+	report.SavedTotal = &SavedTotals{Total: 12, Since: now.AddDate(0, -1, 0)}
+
+	var b strings.Builder
+	if err := report.WriteHTML(&b, now); err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{"run-1", "2d 2h", "1h 30m", `class="overdue">due`, "&lt;script&gt;", "1 kept because", "12 kept since 2024-02-01"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML report should contain %q:\n%s", want, out)
+		}
+	}
+	overdue, soon, later := strings.Index(out, "<td>overdue</td>"), strings.Index(out, "<td>soon</td>"), strings.Index(out, "<td>later</td>")
+	if overdue < 0 || overdue > soon || soon > later {
+		t.Errorf("Namespaces should be listed soonest deletion first:\n%s", out)
+	}
+}
+
+// TestFormatRemaining validates rendering of the time left until deletion
+func TestFormatRemaining(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		-time.Minute:                  "due",
+		0:                             "due",
+		45 * time.Minute:              "0h 45m",
+		26*time.Hour + 10*time.Minute: "1d 2h",
+	} {
+		if got := formatRemaining(d); got != want {
+			t.Errorf("formatRemaining(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
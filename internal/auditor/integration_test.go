@@ -54,10 +54,10 @@ func TestNamespaceLifecycle(t *testing.T) {
 			// Create processor with test configuration
 			processor := auditor.NewNamespaceProcessor(
 				client,
-				&MockUserChecker{exists: false}, // Simulate missing user
-				time.Hour,                       // Grace period (irrelevant for this test)
-				[]string{"example.com"},         // Allowed domains
-				tc.dryRun,
+				&MockUserChecker{exists: false},    // Simulate missing user
+				auditor.WithGracePeriod(time.Hour), // Grace period (irrelevant for this test)
+				auditor.WithAllowedDomains([]string{"example.com"}),
+				auditor.WithDryRun(tc.dryRun),
 			)
 
 			// Execute namespace processing
@@ -0,0 +1,157 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// linkSet records which namespaces of a run are companions of another
+// namespace, their primary.
+type linkSet struct {
+	primary    map[string]string             // Primary name by companion name
+	companions map[string][]corev1.Namespace // Companions by primary name, in listing order
+}
+
+// primaryOf returns the primary of a companion namespace
+func (l *linkSet) primaryOf(name string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	primary, ok := l.primary[name]
+	return primary, ok
+}
+
+// companionsOf returns the companions of a primary namespace
+func (l *linkSet) companionsOf(name string) []corev1.Namespace {
+	if l == nil {
+		return nil
+	}
+	return l.companions[name]
+}
+
+// SetLinkSuffixes links a namespace named after another one plus one of
+// suffixes, e.g. "alice-serving" for "alice" with the suffix "-serving", to
+// that namespace, in addition to links declared with LinkedToLabel.
+func (p *NamespaceProcessor) SetLinkSuffixes(suffixes []string) {
+	p.linkSuffixes = suffixes
+}
+
+// Link determines which of the namespaces of a run are companions of
+// another, see LinkedToLabel and SetLinkSuffixes. A companion is not
+// evaluated on its own: it is marked, unmarked, reported and removed
+// together with its primary. Both must be among namespaces, and a companion
+// cannot have companions of its own. Call it before processing the
+// namespaces.
+func (p *NamespaceProcessor) Link(namespaces []corev1.Namespace) {
+	names := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		names[ns.Name] = true
+	}
+
+	links := &linkSet{primary: make(map[string]string), companions: make(map[string][]corev1.Namespace)}
+	for _, ns := range namespaces {
+		if primary, ok := p.declaredPrimary(ns, names); ok {
+			links.primary[ns.Name] = primary
+		}
+	}
+	var nested []string
+	for companion, primary := range links.primary {
+		if _, isCompanion := links.primary[primary]; isCompanion {
+			nested = append(nested, companion)
+		}
+	}
+	for _, companion := range nested {
+		log.Printf("Not linking %s to %s: %s is itself linked", companion, links.primary[companion], links.primary[companion])
+		delete(links.primary, companion)
+	}
+	for _, ns := range namespaces {
+		if primary, ok := links.primary[ns.Name]; ok {
+			links.companions[primary] = append(links.companions[primary], ns)
+		}
+	}
+	p.links = links
+}
+
+// declaredPrimary returns the primary ns is linked to by label or name
+func (p *NamespaceProcessor) declaredPrimary(ns corev1.Namespace, names map[string]bool) (string, bool) {
+	if primary := ns.Labels[LinkedToLabel]; primary != "" && primary != ns.Name && names[primary] {
+		return primary, true
+	}
+	for _, suffix := range p.linkSuffixes {
+		if primary, ok := strings.CutSuffix(ns.Name, suffix); ok && primary != "" && names[primary] {
+			return primary, true
+		}
+	}
+	return "", false
+}
+
+// markLinked carries the deletion marker of primary over to its companions
+func (p *NamespaceProcessor) markLinked(ctx context.Context, primary corev1.Namespace) {
+	for _, c := range p.links.companionsOf(primary.Name) {
+		if c.Annotations[GracePeriodAnnotation] == primary.Annotations[GracePeriodAnnotation] {
+			continue
+		}
+		if !p.ownsMarker(c) {
+			p.trace.add("link", "linked namespace %s carries a foreign deletion marker, left in place", c.Name)
+			continue
+		}
+		if c.Annotations == nil {
+			c.Annotations = make(map[string]string)
+		}
+		for _, key := range []string{GracePeriodAnnotation, RunIDAnnotation, ConfigHashAnnotation} {
+			if value, ok := primary.Annotations[key]; ok {
+				c.Annotations[key] = value
+			}
+		}
+		log.Printf("Marking namespace %s for deletion together with %s", c.Name, primary.Name)
+		p.trace.add("link", "marked linked namespace %s", c.Name)
+		if err := p.updateNamespace(ctx, &c); err != nil {
+			log.Printf("Error marking %s: %v", c.Name, err)
+		}
+	}
+}
+
+// unmarkLinked removes the deletion markers of the companions of primary
+func (p *NamespaceProcessor) unmarkLinked(ctx context.Context, primary corev1.Namespace) {
+	for _, c := range p.links.companionsOf(primary.Name) {
+		if _, marked := c.Annotations[GracePeriodAnnotation]; !marked || !p.ownsMarker(c) {
+			continue
+		}
+		clearMarker(c.Annotations)
+		log.Printf("Cleaning up grace period annotation from %s together with %s", c.Name, primary.Name)
+		p.trace.add("link", "unmarked linked namespace %s", c.Name)
+		if err := p.updateNamespace(ctx, &c); err != nil {
+			log.Printf("Error updating %s: %v", c.Name, err)
+		}
+	}
+}
+
+// expireLinked applies the expiry action to the companions of primary, which
+// must happen before primary itself is removed so that nothing is left
+// depending on it. Returns an error if a companion could not be removed.
+func (p *NamespaceProcessor) expireLinked(primary corev1.Namespace) error {
+	var failed []string
+	for _, c := range p.links.companionsOf(primary.Name) {
+		q := *p
+		q.trace = &Trace{Namespace: c.Name}
+		if p.expiryAction == ExpiryCordon {
+			q.cordonNamespace(c)
+		} else {
+			q.deleteNamespace(c)
+		}
+		p.trace.add("link", "linked namespace %s: %s", c.Name, q.trace.Action)
+		switch q.trace.Action {
+		case ActionDelete, ActionCordon, ActionNone:
+		default:
+			failed = append(failed, c.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("linked namespaces %v of %s could not be removed", failed, primary.Name)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestLink validates which namespaces are linked by label and by name
+func TestLink(t *testing.T) {
+	namespace := func(name string, nsLabels map[string]string) corev1.Namespace {
+		return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nsLabels}}
+	}
+	p := newTestProcessor(true, nil, false)
+	p.SetLinkSuffixes([]string{"-serving"})
+	p.Link([]corev1.Namespace{
+		namespace("alice", nil),
+		namespace("alice-serving", nil),                                    // By name
+		namespace("alice-data", map[string]string{LinkedToLabel: "alice"}), // By label
+		namespace("bob-serving", nil),                                      // No primary in the run
+		namespace("carol", map[string]string{LinkedToLabel: "missing"}),    // No primary in the run
+		namespace("dave", map[string]string{LinkedToLabel: "alice-data"}),  // Companions cannot have companions
+	})
+
+	want := map[string]string{"alice-serving": "alice", "alice-data": "alice"}
+	if !reflect.DeepEqual(p.links.primary, want) {
+		t.Errorf("Links = %v, want %v", p.links.primary, want)
+	}
+	var companions []string
+	for _, c := range p.links.companionsOf("alice") {
+		companions = append(companions, c.Name)
+	}
+	if !reflect.DeepEqual(companions, []string{"alice-serving", "alice-data"}) {
+		t.Errorf("Companions of alice = %v", companions)
+	}
+}
+
+// runLinked lists the stored namespaces, links them and processes them as a
+// run would, returning the decisions by namespace
+func runLinked(t *testing.T, p *NamespaceProcessor) map[string]Action {
+	t.Helper()
+	list, err := p.ListNamespaces(context.TODO(), "")
+	if err != nil {
+		t.Fatalf("ListNamespaces: %v", err)
+	}
+	p.Link(list.Items)
+	actions := make(map[string]Action)
+	captureLogs(func() {
+		for _, ns := range list.Items {
+			actions[ns.Name] = p.ProcessNamespace(context.TODO(), ns).Action
+		}
+	})
+	return actions
+}
+
+// TestLinkedLifecycle validates that a companion is marked, reported and
+// unmarked together with its primary
+func TestLinkedLifecycle(t *testing.T) {
+	p := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{OwnerAnnotation: "alice@example.com"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice-serving", Annotations: map[string]string{OwnerAnnotation: "someone@example.com"}}},
+	}, false)
+	p.SetRunInfo("run-1", "hash")
+	p.SetLinkSuffixes([]string{"-serving"})
+
+	actions := runLinked(t, p)
+	if actions["alice"] != ActionMark || actions["alice-serving"] != ActionLinked {
+		t.Fatalf("Actions = %v", actions)
+	}
+	primary, _ := p.GetNamespace(context.TODO(), "alice")
+	companion, _ := p.GetNamespace(context.TODO(), "alice-serving")
+	if companion.Annotations[GracePeriodAnnotation] == "" ||
+		companion.Annotations[GracePeriodAnnotation] != primary.Annotations[GracePeriodAnnotation] {
+		t.Errorf("Companion marker %q, want the primary's %q", companion.Annotations[GracePeriodAnnotation], primary.Annotations[GracePeriodAnnotation])
+	}
+	marked := p.Marked()
+	if len(marked) != 2 || marked[1].Name != "alice-serving" || marked[1].LinkedTo != "alice" || !marked[1].DeleteAt.Equal(marked[0].DeleteAt) {
+		t.Errorf("Marked = %+v", marked)
+	}
+
+	p.azureClient = &MockUserChecker{exists: true}
+	if actions := runLinked(t, p); actions["alice"] != ActionUnmark {
+		t.Fatalf("Actions = %v", actions)
+	}
+	if companion, _ := p.GetNamespace(context.TODO(), "alice-serving"); companion.Annotations[GracePeriodAnnotation] != "" {
+		t.Errorf("Companion should be unmarked with its primary: %v", companion.Annotations)
+	}
+}
+
+// TestLinkedDeletionOrder validates that companions are deleted before their
+// primary, and that the primary is kept if a companion cannot be deleted
+func TestLinkedDeletionOrder(t *testing.T) {
+	expired := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	marked := map[string]string{OwnerAnnotation: "alice@example.com", GracePeriodAnnotation: expired, RunIDAnnotation: "run-1"}
+	p := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: marked}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice-serving", Annotations: marked}},
+	}, false)
+	p.SetLinkSuffixes([]string{"-serving"})
+
+	client := p.k8sClient.(*fake.Clientset)
+	var deleted []string
+	failDeletes := true
+	client.PrependReactor("delete", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.DeleteAction).GetName()
+		if failDeletes && name == "alice-serving" {
+			return true, nil, context.DeadlineExceeded
+		}
+		deleted = append(deleted, name)
+		return false, nil, nil
+	})
+
+	if actions := runLinked(t, p); actions["alice"] != ActionError {
+		t.Errorf("Primary with an undeletable companion: Action = %q, want %q", actions["alice"], ActionError)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("Nothing should be deleted, got %v", deleted)
+	}
+
+	failDeletes = false
+	if actions := runLinked(t, p); actions["alice"] != ActionDelete {
+		t.Errorf("Action = %q, want %q", actions["alice"], ActionDelete)
+	}
+	if !reflect.DeepEqual(deleted, []string{"alice-serving", "alice"}) {
+		t.Errorf("Deletion order = %v, want the companion first", deleted)
+	}
+}
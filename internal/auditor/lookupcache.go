@@ -0,0 +1,88 @@
+package auditor
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultLookupCacheTTL is how long a resolved identity lookup is reused
+// unless configured otherwise.
+const DefaultLookupCacheTTL = time.Hour
+
+// lookupCache remembers resolved identity lookups, so that an owner of
+// several namespaces is looked up once per TTL window. It is shared by all
+// copies of a processor and safe for concurrent use. A nil *lookupCache
+// never remembers a lookup.
+type lookupCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedLookup
+	hits    int
+}
+
+// cachedLookup is a resolved identity lookup and when it was made
+type cachedLookup struct {
+	result     lookupResult
+	resolvedAt time.Time
+}
+
+// get returns the lookup of email if it was resolved less than the TTL
+// before now. Expired entries are dropped.
+func (c *lookupCache) get(email string, now time.Time) (lookupResult, bool) {
+	if c == nil {
+		return lookupResult{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[email]
+	if !ok {
+		return lookupResult{}, false
+	}
+	if now.Sub(e.resolvedAt) >= c.ttl {
+		delete(c.entries, email)
+		return lookupResult{}, false
+	}
+	c.hits++
+	return e.result, true
+}
+
+// put remembers a successful lookup of email made at now
+func (c *lookupCache) put(email string, r lookupResult, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[email] = cachedLookup{result: r, resolvedAt: now}
+}
+
+// renewed returns an empty cache with the same TTL, nil if c is nil
+func (c *lookupCache) renewed() *lookupCache {
+	if c == nil {
+		return nil
+	}
+	return &lookupCache{ttl: c.ttl, entries: make(map[string]cachedLookup)}
+}
+
+// SetLookupCache reuses successful identity lookups for up to ttl, so that
+// owners of several namespaces are resolved once rather than per namespace.
+// Failed lookups are never cached. A zero or negative ttl disables the cache.
+func (p *NamespaceProcessor) SetLookupCache(ttl time.Duration) {
+	if ttl <= 0 {
+		p.lookups = nil
+		return
+	}
+	p.lookups = &lookupCache{ttl: ttl, entries: make(map[string]cachedLookup)}
+}
+
+// LookupCacheHits returns the number of identity lookups answered from the
+// lookup cache so far.
+func (p *NamespaceProcessor) LookupCacheHits() int {
+	if p.lookups == nil {
+		return 0
+	}
+	p.lookups.mu.Lock()
+	defer p.lookups.mu.Unlock()
+	return p.lookups.hits
+}
@@ -0,0 +1,84 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TestLookupCache validates that an owner of several namespaces is looked up once
+func TestLookupCache(t *testing.T) {
+	checker := &countingChecker{calls: map[string]int{}, existing: map[string]bool{"owner@example.com": true}}
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+	processor.SetLookupCache(time.Hour)
+
+	captureLogs(func() {
+		for _, name := range []string{"profile", "contributor", "scratch"} {
+			if tr := processor.ProcessNamespaceTraced(context.TODO(), ownedNamespace(name, "owner@example.com")); tr.Action != ActionNone {
+				t.Errorf("%s: Action = %q, want %q", name, tr.Action, ActionNone)
+			}
+		}
+	})
+
+	if checker.calls["owner@example.com"] != 1 || processor.LookupCacheHits() != 2 {
+		t.Errorf("Expected one lookup and two cache hits, got %v / %d", checker.calls, processor.LookupCacheHits())
+	}
+}
+
+// TestLookupCacheExpiry validates that lookups are repeated once the TTL has passed
+func TestLookupCacheExpiry(t *testing.T) {
+	cache := &lookupCache{ttl: time.Hour, entries: map[string]cachedLookup{}}
+	now := time.Now()
+	cache.put("owner@example.com", lookupResult{state: stateOf(true)}, now)
+
+	if _, ok := cache.get("owner@example.com", now.Add(59*time.Minute)); !ok {
+		t.Error("Lookup within the TTL should be cached")
+	}
+	if _, ok := cache.get("owner@example.com", now.Add(time.Hour)); ok {
+		t.Error("Lookup past the TTL should have expired")
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("Expired entry should be dropped, got %v", cache.entries)
+	}
+}
+
+// TestLookupCachePrefetch validates that prefetch reuses and fills the cache
+func TestLookupCachePrefetch(t *testing.T) {
+	checker := &countingChecker{calls: map[string]int{}}
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+	processor.SetLookupCache(time.Hour)
+
+	namespaces := []corev1.Namespace{ownedNamespace("a", "a@example.com"), ownedNamespace("b", "b@example.com")}
+	captureLogs(func() {
+		processor.Prefetch(context.TODO(), namespaces)
+		processor.Prefetch(context.TODO(), namespaces)
+	})
+
+	if checker.calls["a@example.com"] != 1 || checker.calls["b@example.com"] != 1 {
+		t.Errorf("Expected each owner looked up once, got %v", checker.calls)
+	}
+	if len(processor.resolved) != 2 {
+		t.Errorf("Cached lookups should be resolved, got %v", processor.resolved)
+	}
+}
+
+// TestLookupCacheDisabled ensures a zero TTL never caches
+func TestLookupCacheDisabled(t *testing.T) {
+	checker := &countingChecker{calls: map[string]int{}}
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+	processor.SetLookupCache(0)
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), ownedNamespace("a", "owner@example.com"))
+		processor.ProcessNamespace(context.TODO(), ownedNamespace("b", "owner@example.com"))
+	})
+
+	if checker.calls["owner@example.com"] != 2 {
+		t.Errorf("Expected two lookups without a cache, got %v", checker.calls)
+	}
+}
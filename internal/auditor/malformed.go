@@ -0,0 +1,133 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// MalformedMarkerAction selects what happens to a namespace of a missing
+// owner whose deletion marker cannot be read.
+type MalformedMarkerAction string
+
+const (
+	// MalformedMarkerReset removes the marker, so that the namespace is marked
+	// afresh and its grace period restarts.
+	MalformedMarkerReset MalformedMarkerAction = "reset"
+
+	// MalformedMarkerExpire treats the marker as expired once it has been
+	// malformed for longer than a maximum age, counted from when the auditor
+	// first found it, see MalformedSinceAnnotation.
+	MalformedMarkerExpire MalformedMarkerAction = "expire"
+
+	// MalformedMarkerHold leaves the namespace untouched until the marker is
+	// fixed, reporting it on every run.
+	MalformedMarkerHold MalformedMarkerAction = "hold"
+)
+
+// ParseMalformedMarkerAction parses a malformed-marker action name. An empty
+// value selects MalformedMarkerReset.
+func ParseMalformedMarkerAction(value string) (MalformedMarkerAction, error) {
+	switch action := MalformedMarkerAction(value); action {
+	case "":
+		return MalformedMarkerReset, nil
+	case MalformedMarkerReset, MalformedMarkerExpire, MalformedMarkerHold:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown action %q, expected %q, %q or %q",
+			value, MalformedMarkerReset, MalformedMarkerExpire, MalformedMarkerHold)
+	}
+}
+
+// MalformedMarker is a deletion marker found unreadable during a run.
+type MalformedMarker struct {
+	Name      string                `json:"name"`                // Namespace name
+	Value     string                `json:"value"`               // Unreadable marker value
+	Action    MalformedMarkerAction `json:"action"`              // Action configured for malformed markers
+	Since     *time.Time            `json:"since,omitempty"`     // When the auditor first found the marker malformed, with MalformedMarkerExpire
+	ExpiresAt *time.Time            `json:"expiresAt,omitempty"` // When the marker is treated as expired, with MalformedMarkerExpire
+}
+
+// SetMalformedMarkerAction configures the handling of deletion markers that
+// cannot be read. maxAge is how long a marker may stay malformed before it
+// is treated as expired with MalformedMarkerExpire; zero selects the grace
+// period.
+func (p *NamespaceProcessor) SetMalformedMarkerAction(action MalformedMarkerAction, maxAge time.Duration) {
+	p.malformedAction = action
+	p.malformedMaxAge = maxAge
+}
+
+// MalformedMarkers returns the namespaces found with unreadable deletion
+// markers during this run, in processing order.
+func (p *NamespaceProcessor) MalformedMarkers() []MalformedMarker {
+	return p.malformed.list()
+}
+
+// handleInvalidTimestamp applies the malformed-marker action to a namespace
+// whose deletion marker cannot be read
+func (p *NamespaceProcessor) handleInvalidTimestamp(ns corev1.Namespace) {
+	value := ns.Annotations[GracePeriodAnnotation]
+	log.Printf("Invalid timestamp in %s", ns.Name)
+	p.trace.add("grace", "deletion marker %q is not a recognized timestamp", value)
+	if !p.ownsMarker(ns) {
+		p.trace.setAction(ActionForeign)
+		return
+	}
+
+	action := p.malformedAction
+	if action == "" {
+		action = MalformedMarkerReset
+	}
+	entry := MalformedMarker{Name: ns.Name, Value: value, Action: action}
+	switch action {
+	case MalformedMarkerHold:
+		p.malformed.add(entry)
+		p.trace.add("malformed", "malformed-marker action %q, left in place until fixed", action)
+		p.trace.setAction(ActionHold)
+	case MalformedMarkerExpire:
+		p.expireMalformed(ns, entry, p.now())
+	default:
+		p.malformed.add(entry)
+		p.trace.setAction(ActionReset)
+		clearMarker(ns.Annotations)
+		err := p.updateNamespace(context.TODO(), &ns)
+		if err != nil {
+			log.Printf("Error cleaning %s: %v", ns.Name, err)
+		}
+	}
+}
+
+// expireMalformed applies the expiry action to ns once its marker has been
+// malformed for longer than the maximum age, recording when the auditor
+// first found it malformed otherwise
+func (p *NamespaceProcessor) expireMalformed(ns corev1.Namespace, entry MalformedMarker, now time.Time) {
+	maxAge := p.malformedMaxAge
+	if maxAge <= 0 {
+		maxAge = p.gracePeriod
+	}
+	since, err := parseMarkerTime(ns.Annotations[MalformedSinceAnnotation])
+	first := err != nil
+	if first {
+		since = now.UTC().Truncate(time.Second)
+	}
+	expiresAt := since.Add(maxAge + p.clockSkew).UTC()
+	entry.Since, entry.ExpiresAt = &since, &expiresAt
+	p.malformed.add(entry)
+
+	p.trace.add("malformed", "malformed since %s, treated as expired at %s", formatMarkerTime(since), formatMarkerTime(expiresAt))
+	if now.After(expiresAt) {
+		p.expire(ns)
+		return
+	}
+	p.trace.setAction(ActionHold)
+	if !first {
+		return
+	}
+	ns.Annotations[MalformedSinceAnnotation] = formatMarkerTime(since)
+	if err := p.updateNamespace(context.TODO(), &ns); err != nil {
+		log.Printf("Error recording malformed marker on %s: %v", ns.Name, err)
+	}
+}
@@ -0,0 +1,112 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// processMalformed processes a namespace of a missing owner carrying an
+// unreadable deletion marker under action, returning the trace and the
+// stored namespace afterwards
+func processMalformed(t *testing.T, action MalformedMarkerAction, extra map[string]string) (*NamespaceProcessor, *Trace, *corev1.Namespace) {
+	t.Helper()
+	annotations := map[string]string{OwnerAnnotation: "alice@example.com", GracePeriodAnnotation: "next tuesday"}
+	for key, value := range extra {
+		annotations[key] = value
+	}
+	p := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: annotations}},
+	}, false)
+	p.SetMalformedMarkerAction(action, time.Hour)
+
+	ns, err := p.GetNamespace(context.TODO(), "alice")
+	if err != nil {
+		t.Fatalf("GetNamespace: %v", err)
+	}
+	var tr *Trace
+	captureLogs(func() { tr = p.ProcessNamespaceTraced(context.TODO(), *ns) })
+	stored, err := p.GetNamespace(context.TODO(), "alice")
+	if err != nil {
+		stored = nil
+	}
+	return p, tr, stored
+}
+
+// TestMalformedMarkerReset validates that by default a malformed marker is
+// removed so that the namespace is marked afresh
+func TestMalformedMarkerReset(t *testing.T) {
+	p, tr, ns := processMalformed(t, "", nil)
+	if tr.Action != ActionReset {
+		t.Errorf("Action = %q, want %q", tr.Action, ActionReset)
+	}
+	if _, ok := ns.Annotations[GracePeriodAnnotation]; ok {
+		t.Errorf("Marker should be removed: %v", ns.Annotations)
+	}
+	if got := p.MalformedMarkers(); len(got) != 1 || got[0].Value != "next tuesday" || got[0].Action != MalformedMarkerReset {
+		t.Errorf("MalformedMarkers = %+v", got)
+	}
+}
+
+// TestMalformedMarkerHold validates that a held namespace is left untouched
+// and reported
+func TestMalformedMarkerHold(t *testing.T) {
+	p, tr, ns := processMalformed(t, MalformedMarkerHold, nil)
+	if tr.Action != ActionHold {
+		t.Errorf("Action = %q, want %q", tr.Action, ActionHold)
+	}
+	if ns.Annotations[GracePeriodAnnotation] != "next tuesday" || ns.Annotations[MalformedSinceAnnotation] != "" {
+		t.Errorf("Namespace should be untouched: %v", ns.Annotations)
+	}
+	if got := p.MalformedMarkers(); len(got) != 1 || got[0].Action != MalformedMarkerHold || got[0].ExpiresAt != nil {
+		t.Errorf("MalformedMarkers = %+v", got)
+	}
+}
+
+// TestMalformedMarkerExpire validates that a malformed marker is held from
+// when it is first found until the maximum age has passed, and expired after
+func TestMalformedMarkerExpire(t *testing.T) {
+	p, tr, ns := processMalformed(t, MalformedMarkerExpire, nil)
+	if tr.Action != ActionHold {
+		t.Errorf("First sight: Action = %q, want %q", tr.Action, ActionHold)
+	}
+	since, err := parseMarkerTime(ns.Annotations[MalformedSinceAnnotation])
+	if err != nil {
+		t.Fatalf("First sight should be recorded: %v", ns.Annotations)
+	}
+	got := p.MalformedMarkers()
+	if len(got) != 1 || got[0].ExpiresAt == nil || !got[0].ExpiresAt.Equal(since.Add(time.Hour)) {
+		t.Errorf("MalformedMarkers = %+v, want expiry an hour after %s", got, since)
+	}
+
+	recent := time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339)
+	if _, tr, _ := processMalformed(t, MalformedMarkerExpire, map[string]string{MalformedSinceAnnotation: recent}); tr.Action != ActionHold {
+		t.Errorf("Within the maximum age: Action = %q, want %q", tr.Action, ActionHold)
+	}
+
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	_, tr, ns = processMalformed(t, MalformedMarkerExpire, map[string]string{MalformedSinceAnnotation: old})
+	if tr.Action != ActionDelete || ns != nil {
+		t.Errorf("Past the maximum age: Action = %q, want %q and the namespace gone", tr.Action, ActionDelete)
+	}
+}
+
+// TestParseMalformedMarkerAction validates malformed-marker action names
+func TestParseMalformedMarkerAction(t *testing.T) {
+	for value, want := range map[string]MalformedMarkerAction{
+		"":       MalformedMarkerReset,
+		"reset":  MalformedMarkerReset,
+		"expire": MalformedMarkerExpire,
+		"hold":   MalformedMarkerHold,
+	} {
+		if got, err := ParseMalformedMarkerAction(value); err != nil || got != want {
+			t.Errorf("ParseMalformedMarkerAction(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseMalformedMarkerAction("delete"); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+}
@@ -0,0 +1,50 @@
+package auditor
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ManagerReporter is optionally implemented by UserExistenceChecker
+// implementations that know a user's manager, e.g. from the organizational
+// hierarchy of the directory. It returns the manager's email address, empty
+// if the user has none or the directory no longer knows the user.
+type ManagerReporter interface {
+	UserManager(ctx context.Context, email string) (string, error)
+}
+
+// SetManagerEscalation enables escalating marked namespaces to the manager
+// of their owner: the manager is recorded in ManagerAnnotation when the
+// namespace is marked and included in its notifications, so that someone
+// can claim or reassign it. Requires a checker implementing ManagerReporter.
+func (p *NamespaceProcessor) SetManagerEscalation(enabled bool) {
+	p.escalate = enabled
+}
+
+// stampOwnerManager records the manager of the owner, if escalation is
+// enabled and the checker knows it. Failures are logged and leave the
+// annotation unset.
+func (p *NamespaceProcessor) stampOwnerManager(ctx context.Context, annotations map[string]string) {
+	reporter, ok := p.azureClient.(ManagerReporter)
+	owner := annotations[OwnerAnnotation]
+	if !p.escalate || !ok || owner == "" {
+		return
+	}
+	if _, backoff := p.throttle.active(time.Now()); backoff || !p.budget.take() {
+		return
+	}
+
+	manager, err := reporter.UserManager(ctx, owner)
+	p.throttle.observe(err, time.Now())
+	if err != nil {
+		log.Printf("Error looking up the manager of %s: %v", owner, err)
+		return
+	}
+	if manager == "" {
+		p.trace.add("escalate", "no manager known for %s", owner)
+		return
+	}
+	p.trace.add("escalate", "manager of %s is %s", owner, manager)
+	annotations[ManagerAnnotation] = manager
+}
@@ -0,0 +1,73 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// managedChecker reports users as missing or present, with fixed managers
+type managedChecker struct {
+	exists   bool
+	managers map[string]string
+	lookups  int
+}
+
+// UserExists implements UserExistenceChecker
+func (c *managedChecker) UserExists(ctx context.Context, email string) (bool, error) {
+	return c.exists, nil
+}
+
+// UserManager implements ManagerReporter
+func (c *managedChecker) UserManager(ctx context.Context, email string) (string, error) {
+	c.lookups++
+	return c.managers[email], nil
+}
+
+// TestManagerEscalation validates that the manager of the owner is recorded
+// when a namespace is marked, notified, and removed with the marker
+func TestManagerEscalation(t *testing.T) {
+	p := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{OwnerAnnotation: "alice@example.com"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "bob", Annotations: map[string]string{OwnerAnnotation: "bob@example.com"}}},
+	}, false)
+	checker := &managedChecker{managers: map[string]string{"alice@example.com": "boss@example.com"}}
+	p.azureClient = checker
+	notifier := &recordingNotifier{}
+	p.SetNotifier(notifier)
+
+	process := func(name string) {
+		ns, err := p.GetNamespace(context.TODO(), name)
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		captureLogs(func() { p.ProcessNamespace(context.TODO(), *ns) })
+	}
+
+	// Escalation is opt-in
+	process("bob")
+	if checker.lookups != 0 {
+		t.Errorf("Managers should not be looked up unless escalation is enabled")
+	}
+
+	p.SetManagerEscalation(true)
+	process("alice")
+	ns, _ := p.GetNamespace(context.TODO(), "alice")
+	if got := ns.Annotations[ManagerAnnotation]; got != "boss@example.com" {
+		t.Errorf("Manager = %q, want boss@example.com", got)
+	}
+	if n := notifier.sent[len(notifier.sent)-1]; n.Event != EventMarked || n.Manager != "boss@example.com" {
+		t.Errorf("Notification = %+v, want a marked notification naming the manager", n)
+	}
+	if marked := p.Marked(); len(marked) == 0 || marked[len(marked)-1].Manager != "boss@example.com" {
+		t.Errorf("Marked = %+v, want the manager reported", marked)
+	}
+
+	checker.exists = true
+	process("alice")
+	if ns, _ := p.GetNamespace(context.TODO(), "alice"); ns.Annotations[ManagerAnnotation] != "" {
+		t.Errorf("Manager should be removed with the marker: %v", ns.Annotations)
+	}
+}
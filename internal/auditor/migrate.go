@@ -0,0 +1,143 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// StateExportVersion is the format version of StateExport written by this
+// build. Imports of other versions are rejected.
+const StateExportVersion = 1
+
+// migratedAnnotations are the auditor annotations carried over by a state
+// export: deletion markers with their grace-period bookkeeping, failure and
+// flap counts, notification and approval history, and exemptions.
+var migratedAnnotations = []string{
+	GracePeriodAnnotation,
+	RunIDAnnotation,
+	ConfigHashAnnotation,
+	PausedAnnotation,
+	OwnerStateChangedAnnotation,
+	VerdictSourceAnnotation,
+	ManagerAnnotation,
+	MalformedSinceAnnotation,
+	FlapCountAnnotation,
+	LastFlapAnnotation,
+	DampingAnnotation,
+	MissingConfirmationsAnnotation,
+	DecommissionedAnnotation,
+	OrphanedAnnotation,
+	OrphanedAtAnnotation,
+	PreviousOwnerAnnotation,
+	ClaimAnnotation,
+	ApprovalRequestedAnnotation,
+	DeletionApprovedAnnotation,
+	SecondApprovalAnnotation,
+	ReminderAnnotation,
+	ExemptAnnotation,
+	ExemptUntilAnnotation,
+}
+
+// StateExport is the auditor state of a cluster in a portable form, so that
+// it can be moved to another cluster or instance without resetting every
+// grace period.
+type StateExport struct {
+	Version    int              `json:"version"`            // Format version, see StateExportVersion
+	ExportedAt time.Time        `json:"exportedAt"`         // When the state was exported
+	Throttle   *ThrottleState   `json:"throttle,omitempty"` // Identity-provider backoff, if any
+	Namespaces []NamespaceState `json:"namespaces"`         // Namespaces carrying auditor state, by name
+}
+
+// NamespaceState is the auditor state of a single namespace.
+type NamespaceState struct {
+	Name        string            `json:"name"`        // Namespace name
+	Owner       string            `json:"owner"`       // Owner annotation, checked on import
+	Annotations map[string]string `json:"annotations"` // Auditor annotations, see migratedAnnotations
+}
+
+// ImportResult summarizes the import of a StateExport.
+type ImportResult struct {
+	Imported     []string `json:"imported"`               // Namespaces whose state was applied
+	Missing      []string `json:"missing,omitempty"`      // Namespaces not found in the target cluster
+	OwnerChanged []string `json:"ownerChanged,omitempty"` // Namespaces whose owner differs, left untouched
+	Failed       []string `json:"failed,omitempty"`       // Namespaces that could not be updated
+}
+
+// ExportState collects the auditor state of the given namespaces. Namespaces
+// without any auditor annotation are left out.
+func ExportState(namespaces []corev1.Namespace, throttle ThrottleState, now time.Time) StateExport {
+	export := StateExport{Version: StateExportVersion, ExportedAt: now.UTC(), Namespaces: []NamespaceState{}}
+	if !throttle.LastThrottled.IsZero() {
+		export.Throttle = &throttle
+	}
+	for _, ns := range namespaces {
+		annotations := make(map[string]string)
+		for _, key := range migratedAnnotations {
+			if value, ok := ns.Annotations[key]; ok {
+				annotations[key] = value
+			}
+		}
+		if len(annotations) == 0 {
+			continue
+		}
+		export.Namespaces = append(export.Namespaces, NamespaceState{
+			Name:        ns.Name,
+			Owner:       ns.Annotations[OwnerAnnotation],
+			Annotations: annotations,
+		})
+	}
+	sort.Slice(export.Namespaces, func(i, j int) bool { return export.Namespaces[i].Name < export.Namespaces[j].Name })
+	return export
+}
+
+// ImportState applies exported namespace state to the namespaces of the
+// same name in this cluster, overwriting their auditor annotations. A
+// namespace whose owner differs from the exported one is a different
+// workspace and is left untouched. Dry runs record the updates instead.
+func (p *NamespaceProcessor) ImportState(ctx context.Context, export StateExport) (ImportResult, error) {
+	var result ImportResult
+	if export.Version != StateExportVersion {
+		return result, fmt.Errorf("unsupported state export version %d, expected %d", export.Version, StateExportVersion)
+	}
+
+	for _, exported := range export.Namespaces {
+		ns, err := p.GetNamespace(ctx, exported.Name)
+		if apierrors.IsNotFound(err) {
+			log.Printf("Not importing state of %s: namespace not found", exported.Name)
+			result.Missing = append(result.Missing, exported.Name)
+			continue
+		}
+		if err != nil {
+			log.Printf("Error reading %s: %v", exported.Name, err)
+			result.Failed = append(result.Failed, exported.Name)
+			continue
+		}
+		if owner := ns.Annotations[OwnerAnnotation]; owner != exported.Owner {
+			log.Printf("Not importing state of %s: owner is %q here but was %q", exported.Name, owner, exported.Owner)
+			result.OwnerChanged = append(result.OwnerChanged, exported.Name)
+			continue
+		}
+
+		if ns.Annotations == nil {
+			ns.Annotations = make(map[string]string)
+		}
+		for _, key := range migratedAnnotations {
+			if value, ok := exported.Annotations[key]; ok {
+				ns.Annotations[key] = value
+			}
+		}
+		if err := p.updateNamespace(ctx, ns); err != nil {
+			log.Printf("Error importing state of %s: %v", exported.Name, err)
+			result.Failed = append(result.Failed, exported.Name)
+			continue
+		}
+		result.Imported = append(result.Imported, exported.Name)
+	}
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestExportState validates that only auditor state is exported
+func TestExportState(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Annotations: map[string]string{
+			OwnerAnnotation:       "b@example.com",
+			GracePeriodAnnotation: "2024-01-02T00:00:00Z",
+			FlapCountAnnotation:   "2",
+			"unrelated":           "value",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "clean", Annotations: map[string]string{OwnerAnnotation: "c@example.com"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Annotations: map[string]string{
+			OwnerAnnotation:       "a@example.com",
+			ExemptUntilAnnotation: "2024-06-01T00:00:00Z",
+		}}},
+	}
+
+	export := ExportState(namespaces, ThrottleState{}, now)
+	if export.Version != StateExportVersion || !export.ExportedAt.Equal(now) || export.Throttle != nil {
+		t.Errorf("Unexpected header %+v", export)
+	}
+	want := []NamespaceState{
+		{Name: "team-a", Owner: "a@example.com", Annotations: map[string]string{ExemptUntilAnnotation: "2024-06-01T00:00:00Z"}},
+		{Name: "team-b", Owner: "b@example.com", Annotations: map[string]string{
+			GracePeriodAnnotation: "2024-01-02T00:00:00Z",
+			FlapCountAnnotation:   "2",
+		}},
+	}
+	if !reflect.DeepEqual(export.Namespaces, want) {
+		t.Errorf("Exported %+v, want %+v", export.Namespaces, want)
+	}
+
+	throttle := ThrottleState{LastThrottled: now, Backoff: time.Minute, Until: now.Add(time.Minute)}
+	if export := ExportState(nil, throttle, now); export.Throttle == nil || *export.Throttle != throttle {
+		t.Errorf("Throttle = %+v, want %+v", export.Throttle, throttle)
+	}
+}
+
+// TestImportState validates that exported state is applied to matching
+// namespaces only
+func TestImportState(t *testing.T) {
+	p := newTestProcessor(true, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Annotations: map[string]string{
+			OwnerAnnotation:     "a@example.com",
+			FlapCountAnnotation: "5",
+			"unrelated":         "value",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Annotations: map[string]string{OwnerAnnotation: "new@example.com"}}},
+	}, false)
+	export := StateExport{Version: StateExportVersion, Namespaces: []NamespaceState{
+		{Name: "team-a", Owner: "a@example.com", Annotations: map[string]string{GracePeriodAnnotation: "2024-01-02T00:00:00Z"}},
+		{Name: "team-b", Owner: "b@example.com", Annotations: map[string]string{GracePeriodAnnotation: "2024-01-02T00:00:00Z"}},
+		{Name: "gone", Owner: "c@example.com", Annotations: map[string]string{GracePeriodAnnotation: "2024-01-02T00:00:00Z"}},
+	}}
+
+	result, err := p.ImportState(context.TODO(), export)
+	if err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+	want := ImportResult{Imported: []string{"team-a"}, Missing: []string{"gone"}, OwnerChanged: []string{"team-b"}}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Result = %+v, want %+v", result, want)
+	}
+
+	ns, _ := p.GetNamespace(context.TODO(), "team-a")
+	wantAnnotations := map[string]string{
+		OwnerAnnotation:       "a@example.com",
+		GracePeriodAnnotation: "2024-01-02T00:00:00Z",
+		FlapCountAnnotation:   "5",
+		"unrelated":           "value",
+	}
+	if !reflect.DeepEqual(ns.Annotations, wantAnnotations) {
+		t.Errorf("Annotations = %v, want %v", ns.Annotations, wantAnnotations)
+	}
+	if ns, _ := p.GetNamespace(context.TODO(), "team-b"); ns.Annotations[GracePeriodAnnotation] != "" {
+		t.Errorf("Namespace with a new owner should be left untouched: %v", ns.Annotations)
+	}
+}
+
+// TestImportStateDryRun ensures dry runs leave namespaces untouched
+func TestImportStateDryRun(t *testing.T) {
+	p := newTestProcessor(true, []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team",
+		Annotations: map[string]string{OwnerAnnotation: "user@example.com"},
+	}}}, true)
+	export := StateExport{Version: StateExportVersion, Namespaces: []NamespaceState{
+		{Name: "team", Owner: "user@example.com", Annotations: map[string]string{GracePeriodAnnotation: "2024-01-02T00:00:00Z"}},
+	}}
+
+	if _, err := p.ImportState(context.TODO(), export); err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+	if ns, _ := p.GetNamespace(context.TODO(), "team"); ns.Annotations[GracePeriodAnnotation] != "" {
+		t.Errorf("Dry run should not change the namespace: %v", ns.Annotations)
+	}
+}
+
+// TestImportStateVersion ensures exports of unknown versions are rejected
+func TestImportStateVersion(t *testing.T) {
+	p := newTestProcessor(true, nil, false)
+	if _, err := p.ImportState(context.TODO(), StateExport{Version: StateExportVersion + 1}); err == nil {
+		t.Error("Expected an error for an unsupported version")
+	}
+}
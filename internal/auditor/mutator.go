@@ -0,0 +1,292 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ChangeNotify identifies a notification in planned changes
+const ChangeNotify = "notify"
+
+// mutator applies the side effects of audit decisions. Dry and real runs
+// share every decision; only the mutator differs, so a dry run reports
+// exactly the changes a real run would make.
+type mutator interface {
+	createNamespace(ctx context.Context, ns *corev1.Namespace) error
+	updateNamespace(ctx context.Context, ns *corev1.Namespace) error
+	deleteNamespace(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	finalizeNamespace(ctx context.Context, ns *corev1.Namespace) error
+	deleteRoleBinding(ctx context.Context, namespace, name string) error
+	updateDeployment(ctx context.Context, d *appsv1.Deployment) error
+	updateStatefulSet(ctx context.Context, s *appsv1.StatefulSet) error
+	createNetworkPolicy(ctx context.Context, np *networkingv1.NetworkPolicy) error
+	deleteNetworkPolicy(ctx context.Context, namespace, name string) error
+	createResourceQuota(ctx context.Context, quota *corev1.ResourceQuota) error
+	deleteResourceQuota(ctx context.Context, namespace, name string) error
+	createEvent(ctx context.Context, event *corev1.Event) error
+	send(ctx context.Context, notifier Notifier, n Notification) error
+}
+
+// PlannedChange is a change a dry run would have made.
+type PlannedChange struct {
+	Op        string `json:"op"`               // Kubernetes API operation, or ChangeNotify
+	Namespace string `json:"namespace"`        // Namespace affected
+	Object    string `json:"object,omitempty"` // Object within the namespace, if not the namespace itself
+	Detail    string `json:"detail,omitempty"` // Human-readable summary of the change
+}
+
+// PlannedChanges returns the changes recorded instead of applied during a
+// dry run, in the order they were planned.
+func (p *NamespaceProcessor) PlannedChanges() []PlannedChange {
+	return p.planned.list()
+}
+
+// SetMutationClient applies changes through client instead of the client
+// used for reads, e.g. one impersonating a narrowly scoped identity. Reads
+// keep using the processor's client. A nil client restores the default.
+func (p *NamespaceProcessor) SetMutationClient(client kubernetes.Interface) {
+	p.mutationClient = client
+}
+
+// mutations returns the mutator for this run
+func (p *NamespaceProcessor) mutations() mutator {
+	if p.dryRun {
+		return changeRecorder{changes: p.planned}
+	}
+	client := p.k8sClient
+	if p.mutationClient != nil {
+		client = p.mutationClient
+	}
+	return liveMutator{client: client, stats: p.apiStats}
+}
+
+// liveMutator applies changes through the Kubernetes API and the configured
+// notifier, recording API usage.
+type liveMutator struct {
+	client kubernetes.Interface
+	stats  *APIStats
+}
+
+// createNamespace creates a namespace through the API server
+func (m liveMutator) createNamespace(ctx context.Context, ns *corev1.Namespace) error {
+	start := time.Now()
+	created, err := m.client.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{FieldManager: FieldManager})
+	m.stats.observe(OpCreate, start, err)
+	if err == nil && created != nil {
+		*ns = *created
+	}
+	return err
+}
+
+// updateNamespace writes a namespace back to the API server and refreshes
+// its resourceVersion, so that later changes in the run build on the update.
+// Update conflicts are classified as errs.ErrConflict.
+func (m liveMutator) updateNamespace(ctx context.Context, ns *corev1.Namespace) error {
+	start := time.Now()
+	updated, err := m.client.CoreV1().Namespaces().Update(ctx, ns, metav1.UpdateOptions{FieldManager: FieldManager})
+	m.stats.observe(OpUpdate, start, err)
+	if apierrors.IsConflict(err) {
+		return fmt.Errorf("%w: %w", errs.ErrConflict, err)
+	}
+	if err == nil && updated != nil {
+		ns.ResourceVersion = updated.ResourceVersion
+	}
+	return err
+}
+
+// deleteNamespace deletes a namespace through the API server. Failed
+// preconditions are classified as errs.ErrConflict.
+func (m liveMutator) deleteNamespace(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	start := time.Now()
+	err := m.client.CoreV1().Namespaces().Delete(ctx, name, opts)
+	m.stats.observe(OpDelete, start, err)
+	if apierrors.IsConflict(err) {
+		return fmt.Errorf("%w: %w", errs.ErrConflict, err)
+	}
+	return err
+}
+
+// finalizeNamespace writes the spec finalizers of a terminating namespace
+func (m liveMutator) finalizeNamespace(ctx context.Context, ns *corev1.Namespace) error {
+	start := time.Now()
+	_, err := m.client.CoreV1().Namespaces().Finalize(ctx, ns, metav1.UpdateOptions{FieldManager: FieldManager})
+	m.stats.observe(OpFinalize, start, err)
+	return err
+}
+
+// deleteRoleBinding deletes a RoleBinding through the API server
+func (m liveMutator) deleteRoleBinding(ctx context.Context, namespace, name string) error {
+	start := time.Now()
+	err := m.client.RbacV1().RoleBindings(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	m.stats.observe(OpRoleBindingDelete, start, err)
+	return err
+}
+
+// updateDeployment writes a Deployment back to the API server
+func (m liveMutator) updateDeployment(ctx context.Context, d *appsv1.Deployment) error {
+	start := time.Now()
+	_, err := m.client.AppsV1().Deployments(d.Namespace).Update(ctx, d, metav1.UpdateOptions{FieldManager: FieldManager})
+	m.stats.observe(OpDeploymentUpdate, start, err)
+	return err
+}
+
+// updateStatefulSet writes a StatefulSet back to the API server
+func (m liveMutator) updateStatefulSet(ctx context.Context, s *appsv1.StatefulSet) error {
+	start := time.Now()
+	_, err := m.client.AppsV1().StatefulSets(s.Namespace).Update(ctx, s, metav1.UpdateOptions{FieldManager: FieldManager})
+	m.stats.observe(OpStatefulSetUpdate, start, err)
+	return err
+}
+
+// createNetworkPolicy creates a NetworkPolicy through the API server
+func (m liveMutator) createNetworkPolicy(ctx context.Context, np *networkingv1.NetworkPolicy) error {
+	start := time.Now()
+	_, err := m.client.NetworkingV1().NetworkPolicies(np.Namespace).Create(ctx, np, metav1.CreateOptions{FieldManager: FieldManager})
+	m.stats.observe(OpNetworkPolicyCreate, start, err)
+	return err
+}
+
+// deleteNetworkPolicy deletes a NetworkPolicy through the API server
+func (m liveMutator) deleteNetworkPolicy(ctx context.Context, namespace, name string) error {
+	start := time.Now()
+	err := m.client.NetworkingV1().NetworkPolicies(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	m.stats.observe(OpNetworkPolicyDelete, start, err)
+	return err
+}
+
+// createResourceQuota creates a ResourceQuota through the API server
+func (m liveMutator) createResourceQuota(ctx context.Context, quota *corev1.ResourceQuota) error {
+	start := time.Now()
+	_, err := m.client.CoreV1().ResourceQuotas(quota.Namespace).Create(ctx, quota, metav1.CreateOptions{FieldManager: FieldManager})
+	m.stats.observe(OpResourceQuotaCreate, start, err)
+	return err
+}
+
+// deleteResourceQuota deletes a ResourceQuota through the API server
+func (m liveMutator) deleteResourceQuota(ctx context.Context, namespace, name string) error {
+	start := time.Now()
+	err := m.client.CoreV1().ResourceQuotas(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	m.stats.observe(OpResourceQuotaDelete, start, err)
+	return err
+}
+
+// createEvent records a Kubernetes Event through the API server
+func (m liveMutator) createEvent(ctx context.Context, event *corev1.Event) error {
+	start := time.Now()
+	_, err := m.client.CoreV1().Events(event.Namespace).Create(ctx, event, metav1.CreateOptions{FieldManager: FieldManager})
+	m.stats.observe(OpEventCreate, start, err)
+	return err
+}
+
+// send delivers a notification
+func (m liveMutator) send(ctx context.Context, notifier Notifier, n Notification) error {
+	return notifier.Notify(ctx, n)
+}
+
+// changeRecorder records changes instead of applying them. Every call
+// succeeds.
+type changeRecorder struct {
+	changes *collector[PlannedChange]
+}
+
+// record logs and stores a planned change
+func (r changeRecorder) record(c PlannedChange) {
+	target := c.Namespace
+	if c.Object != "" {
+		target += "/" + c.Object
+	}
+	log.Printf("[DRY RUN] Would %s %s: %s", c.Op, target, c.Detail)
+	r.changes.add(c)
+}
+
+// createNamespace records a namespace creation
+func (r changeRecorder) createNamespace(ctx context.Context, ns *corev1.Namespace) error {
+	r.record(PlannedChange{Op: OpCreate, Namespace: ns.Name, Detail: fmt.Sprintf("labels %v, annotations %v", ns.Labels, ns.Annotations)})
+	return nil
+}
+
+// updateNamespace records a namespace update
+func (r changeRecorder) updateNamespace(ctx context.Context, ns *corev1.Namespace) error {
+	r.record(PlannedChange{Op: OpUpdate, Namespace: ns.Name, Detail: fmt.Sprintf("annotations %v, finalizers %v", ns.Annotations, ns.Finalizers)})
+	return nil
+}
+
+// deleteNamespace records a namespace deletion
+func (r changeRecorder) deleteNamespace(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	detail := "default propagation"
+	if opts.PropagationPolicy != nil {
+		detail = string(*opts.PropagationPolicy) + " propagation"
+	}
+	r.record(PlannedChange{Op: OpDelete, Namespace: name, Detail: detail})
+	return nil
+}
+
+// finalizeNamespace records a spec finalizer update
+func (r changeRecorder) finalizeNamespace(ctx context.Context, ns *corev1.Namespace) error {
+	r.record(PlannedChange{Op: OpFinalize, Namespace: ns.Name, Detail: fmt.Sprintf("spec finalizers %v", ns.Spec.Finalizers)})
+	return nil
+}
+
+// deleteRoleBinding records a RoleBinding deletion
+func (r changeRecorder) deleteRoleBinding(ctx context.Context, namespace, name string) error {
+	r.record(PlannedChange{Op: OpRoleBindingDelete, Namespace: namespace, Object: name})
+	return nil
+}
+
+// updateDeployment records a Deployment update
+func (r changeRecorder) updateDeployment(ctx context.Context, d *appsv1.Deployment) error {
+	r.record(PlannedChange{Op: OpDeploymentUpdate, Namespace: d.Namespace, Object: d.Name, Detail: fmt.Sprintf("replicas %d", replicasOf(d.Spec.Replicas))})
+	return nil
+}
+
+// updateStatefulSet records a StatefulSet update
+func (r changeRecorder) updateStatefulSet(ctx context.Context, s *appsv1.StatefulSet) error {
+	r.record(PlannedChange{Op: OpStatefulSetUpdate, Namespace: s.Namespace, Object: s.Name, Detail: fmt.Sprintf("replicas %d", replicasOf(s.Spec.Replicas))})
+	return nil
+}
+
+// createNetworkPolicy records a NetworkPolicy creation
+func (r changeRecorder) createNetworkPolicy(ctx context.Context, np *networkingv1.NetworkPolicy) error {
+	r.record(PlannedChange{Op: OpNetworkPolicyCreate, Namespace: np.Namespace, Object: np.Name, Detail: fmt.Sprintf("policy types %v", np.Spec.PolicyTypes)})
+	return nil
+}
+
+// deleteNetworkPolicy records a NetworkPolicy deletion
+func (r changeRecorder) deleteNetworkPolicy(ctx context.Context, namespace, name string) error {
+	r.record(PlannedChange{Op: OpNetworkPolicyDelete, Namespace: namespace, Object: name})
+	return nil
+}
+
+// createResourceQuota records a ResourceQuota creation
+func (r changeRecorder) createResourceQuota(ctx context.Context, quota *corev1.ResourceQuota) error {
+	r.record(PlannedChange{Op: OpResourceQuotaCreate, Namespace: quota.Namespace, Object: quota.Name, Detail: fmt.Sprintf("hard %v", quota.Spec.Hard)})
+	return nil
+}
+
+// deleteResourceQuota records a ResourceQuota deletion
+func (r changeRecorder) deleteResourceQuota(ctx context.Context, namespace, name string) error {
+	r.record(PlannedChange{Op: OpResourceQuotaDelete, Namespace: namespace, Object: name})
+	return nil
+}
+
+// createEvent records a Kubernetes Event on a namespace
+func (r changeRecorder) createEvent(ctx context.Context, event *corev1.Event) error {
+	r.record(PlannedChange{Op: OpEventCreate, Namespace: event.InvolvedObject.Name, Detail: event.Reason + ": " + event.Message})
+	return nil
+}
+
+// send records a notification
+func (r changeRecorder) send(ctx context.Context, notifier Notifier, n Notification) error {
+	r.record(PlannedChange{Op: ChangeNotify, Namespace: n.Namespace, Detail: string(n.Event) + ": " + n.Message})
+	return nil
+}
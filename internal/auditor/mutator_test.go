@@ -0,0 +1,96 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// parityNamespaces covers marking, waiting, deletion and unmarking
+func parityNamespaces() []*corev1.Namespace {
+	return []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "new", Annotations: map[string]string{OwnerAnnotation: "gone@example.com"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "expired", Annotations: map[string]string{
+			OwnerAnnotation:       "gone@example.com",
+			GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "broken", Annotations: map[string]string{
+			OwnerAnnotation:       "gone@example.com",
+			GracePeriodAnnotation: "not-a-time",
+		}}},
+	}
+}
+
+// TestDryRunParity validates that dry and real runs take the same decisions,
+// with the dry run recording each change the real run applies
+func TestDryRunParity(t *testing.T) {
+	run := func(dryRun bool) (*NamespaceProcessor, []Action) {
+		processor := newTestProcessor(false, parityNamespaces(), dryRun)
+		processor.apiStats = &APIStats{}
+		var actions []Action
+		captureLogs(func() {
+			for _, ns := range parityNamespaces() {
+				actions = append(actions, processor.ProcessNamespaceTraced(context.TODO(), *ns).Action)
+			}
+		})
+		return processor, actions
+	}
+
+	dry, dryActions := run(true)
+	real, realActions := run(false)
+
+	for i := range realActions {
+		if dryActions[i] != realActions[i] {
+			t.Errorf("Namespace %d: dry run chose %q, real run %q", i, dryActions[i], realActions[i])
+		}
+	}
+
+	counts := map[string]int{}
+	for _, c := range dry.PlannedChanges() {
+		counts[c.Op]++
+	}
+	applied := real.APIStats().Snapshot()
+	for _, op := range []string{OpUpdate, OpDelete} {
+		if counts[op] != applied[op].Count {
+			t.Errorf("%s: dry run planned %d, real run applied %d", op, counts[op], applied[op].Count)
+		}
+	}
+	if counts[ChangeNotify] != 2 {
+		t.Errorf("Expected planned marked and deleted notifications, got %d", counts[ChangeNotify])
+	}
+	if len(real.PlannedChanges()) != 0 {
+		t.Errorf("Real run should not record planned changes, got %+v", real.PlannedChanges())
+	}
+
+	if stats := dry.APIStats().Snapshot(); stats[OpUpdate].Count != 0 || stats[OpDelete].Count != 0 {
+		t.Errorf("Dry run should not call mutating APIs, got %+v", stats)
+	}
+}
+
+// TestMutationClient validates that changes go through the mutation client
+// while namespaces are still read through the processor's client
+func TestMutationClient(t *testing.T) {
+	processor := newTestProcessor(false, parityNamespaces(), false)
+	scoped := fake.NewSimpleClientset(parityNamespaces()[0])
+	processor.SetMutationClient(scoped)
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *parityNamespaces()[0])
+	})
+
+	for _, action := range processor.GetClient().(*fake.Clientset).Actions() {
+		if action.GetVerb() != "create" && action.GetVerb() != "get" && action.GetVerb() != "list" {
+			t.Errorf("Unexpected %s through the read client", action.GetVerb())
+		}
+	}
+	ns, err := scoped.CoreV1().Namespaces().Get(context.TODO(), "new", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, marked := ns.Annotations[GracePeriodAnnotation]; !marked {
+		t.Error("Marker not written through the mutation client")
+	}
+}
@@ -0,0 +1,82 @@
+package auditor
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// NamePattern matches namespace names, either as a glob (see path.Match,
+// e.g. "kube-*") or, written between slashes, as a regular expression that
+// must match the whole name (e.g. "/team-[0-9]+/").
+type NamePattern struct {
+	text string
+	re   *regexp.Regexp // Nil for globs
+}
+
+// ParseNamePatterns parses a comma-separated list of name patterns.
+// Whitespace around entries is ignored and empty entries are dropped.
+func ParseNamePatterns(list string) ([]NamePattern, error) {
+	var patterns []NamePattern
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern := NamePattern{text: entry}
+		if len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+			re, err := regexp.Compile("^(?:" + entry[1:len(entry)-1] + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %w", entry, err)
+			}
+			pattern.re = re
+		} else if _, err := path.Match(entry, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", entry, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// Match reports whether name matches the pattern.
+func (n NamePattern) Match(name string) bool {
+	if n.re != nil {
+		return n.re.MatchString(name)
+	}
+	matched, _ := path.Match(n.text, name)
+	return matched
+}
+
+// String returns the pattern as written.
+func (n NamePattern) String() string {
+	return n.text
+}
+
+// SetNameFilter restricts auditing to namespaces whose name matches one of
+// include, or any name if include is empty, and none of exclude. Other
+// namespaces are never touched: the filter is applied before any other
+// decision.
+func (p *NamespaceProcessor) SetNameFilter(include, exclude []NamePattern) {
+	p.include = include
+	p.exclude = exclude
+}
+
+// excludedByName returns why a namespace is excluded by the name filter, or
+// false if it is audited
+func (p *NamespaceProcessor) excludedByName(name string) (string, bool) {
+	for _, pattern := range p.exclude {
+		if pattern.Match(name) {
+			return fmt.Sprintf("name matches excluded pattern %q", pattern), true
+		}
+	}
+	if len(p.include) == 0 {
+		return "", false
+	}
+	for _, pattern := range p.include {
+		if pattern.Match(name) {
+			return "", false
+		}
+	}
+	return "name matches no included pattern", true
+}
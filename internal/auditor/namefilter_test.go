@@ -0,0 +1,82 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParseNamePatterns validates globs and regular expressions
+func TestParseNamePatterns(t *testing.T) {
+	patterns, err := ParseNamePatterns(" kube-*, kubeflow ,, /team-[0-9]+/")
+	if err != nil {
+		t.Fatalf("ParseNamePatterns: %v", err)
+	}
+	if len(patterns) != 3 {
+		t.Fatalf("Patterns = %v, want 3", patterns)
+	}
+	tests := []struct {
+		name string
+		want []bool
+	}{
+		{name: "kube-system", want: []bool{true, false, false}},
+		{name: "kubeflow", want: []bool{false, true, false}},
+		{name: "kubeflow-user", want: []bool{false, false, false}},
+		{name: "team-42", want: []bool{false, false, true}},
+		{name: "my-team-42", want: []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		for i, pattern := range patterns {
+			if got := pattern.Match(tt.name); got != tt.want[i] {
+				t.Errorf("%q.Match(%q) = %t, want %t", pattern, tt.name, got, tt.want[i])
+			}
+		}
+	}
+
+	for _, list := range []string{"team-[", "/team-(/"} {
+		if _, err := ParseNamePatterns(list); err == nil {
+			t.Errorf("ParseNamePatterns(%q): expected an error", list)
+		}
+	}
+}
+
+// TestNameFilter validates that excluded namespaces are never touched and
+// only included namespaces are audited
+func TestNameFilter(t *testing.T) {
+	expired := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{
+			OwnerAnnotation:       "gone@example.com",
+			GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+		}}}
+	}
+	include, _ := ParseNamePatterns("team-*")
+	exclude, _ := ParseNamePatterns("kube-*,team-platform")
+	tests := []struct {
+		name string
+		want Action
+	}{
+		{name: "kube-system", want: ActionExcluded},
+		{name: "team-platform", want: ActionExcluded},
+		{name: "monitoring", want: ActionExcluded},
+		{name: "team-a", want: ActionDelete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := newTestProcessor(false, []*corev1.Namespace{expired(tt.name)}, false)
+			processor.SetNameFilter(include, exclude)
+			var d Decision
+			captureLogs(func() { d = processor.ProcessNamespace(context.TODO(), *expired(tt.name)) })
+			if d.Action != tt.want {
+				t.Errorf("Action = %q, want %q", d.Action, tt.want)
+			}
+		})
+	}
+
+	processor := newTestProcessor(false, []*corev1.Namespace{expired("kube-system")}, false)
+	processor.SetNameFilter(nil, exclude)
+	if _, _, err := processor.DeleteNow(context.TODO(), *expired("kube-system"), "incident", "admin"); err == nil {
+		t.Error("Break-glass deletion of an excluded namespace should be refused")
+	}
+}
@@ -0,0 +1,184 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NotificationEvent identifies why a notification was sent.
+type NotificationEvent string
+
+const (
+	// EventMarked is sent when a namespace whose owner could not be
+	// validated is marked for deletion.
+	EventMarked NotificationEvent = "marked"
+
+	// EventDeleting is sent as a pre-delete step when a namespace is
+	// deleted with the pre-delete finalizer enabled.
+	EventDeleting NotificationEvent = "deleting"
+
+	// EventReminder is sent halfway through the grace period of a marked
+	// namespace, see SetReminders.
+	EventReminder NotificationEvent = "reminder"
+
+	// EventFinalReminder is sent FinalReminderLead before a marked
+	// namespace is deleted, see SetReminders.
+	EventFinalReminder NotificationEvent = "final-reminder"
+
+	// EventDeleted is sent when a namespace is deleted after its grace
+	// period, unless the pre-delete finalizer already sent EventDeleting.
+	EventDeleted NotificationEvent = "deleted"
+
+	// EventStuckTerminating is sent when a namespace deleted by the auditor
+	// remains terminating for longer than the configured threshold.
+	EventStuckTerminating NotificationEvent = "stuck-terminating"
+
+	// EventBreakGlass is sent when a namespace is deleted immediately with the
+	// delete-now command, skipping its grace period.
+	EventBreakGlass NotificationEvent = "break-glass"
+
+	// EventApprovalRequired is sent to the security contact when the grace
+	// period of a sensitive namespace requiring approval has expired.
+	EventApprovalRequired NotificationEvent = "approval-required"
+
+	// EventOwnerlessMarked is sent when a namespace without an owner
+	// annotation is marked for deletion.
+	EventOwnerlessMarked NotificationEvent = "ownerless-marked"
+
+	// EventOrphaned is sent when the grace period of a namespace expires
+	// with ExpiryReassign and it is kept as orphaned.
+	EventOrphaned NotificationEvent = "orphaned"
+
+	// EventQuarantined is sent when the grace period of a namespace expires
+	// and it is quarantined before deletion, see SetQuarantine.
+	EventQuarantined NotificationEvent = "quarantined"
+
+	// EventMissingOwners is sent when a run is aborted because too many
+	// owners were not found, see SetMissingOwnerBreaker. It names no
+	// namespace.
+	EventMissingOwners NotificationEvent = "missing-owners"
+)
+
+// Notification describes an event that administrators should be told about.
+type Notification struct {
+	Event     NotificationEvent // What happened
+	Namespace string            // Affected namespace
+	Owner     string            // Owner email, empty if unknown
+	Message   string            // Human-readable description
+	Context   map[string]string // Selected labels or annotations of the namespace, see SetContextKeys
+	Contact   string            // Secondary contact of the namespace, see SecondaryContactAnnotation
+	Manager   string            // Manager of the owner, see ManagerAnnotation
+	DeleteAt  time.Time         // When the namespace is deleted, zero unless it is marked and waiting
+
+	// Recipients lists contributors of the namespace who should be told
+	// in addition to the owner, so that someone still present can claim it.
+	Recipients []string
+}
+
+// Notifier delivers notifications to administrators.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier writes notifications to the standard logger. It is used when
+// no other notifier is configured.
+type LogNotifier struct{}
+
+// Notify logs the notification.
+func (LogNotifier) Notify(_ context.Context, n Notification) error {
+	var details []string
+	if len(n.Context) > 0 {
+		details = append(details, "context: "+formatContext(n.Context))
+	}
+	if len(n.Recipients) > 0 {
+		details = append(details, "recipients: "+strings.Join(n.Recipients, ", "))
+	}
+	if n.Manager != "" {
+		details = append(details, "manager: "+n.Manager)
+	}
+	if len(details) > 0 {
+		log.Printf("Notification [%s] %s: %s (%s)", n.Event, n.Namespace, n.Message, strings.Join(details, "; "))
+		return nil
+	}
+	log.Printf("Notification [%s] %s: %s", n.Event, n.Namespace, n.Message)
+	return nil
+}
+
+// SetNotifier configures where administrator notifications are sent.
+func (p *NamespaceProcessor) SetNotifier(n Notifier) {
+	p.notifier = n
+}
+
+// notify sends a notification. Delivery failures are logged but never
+// abort processing.
+func (p *NamespaceProcessor) notify(ctx context.Context, n Notification) {
+	p.addPolicyRecipients(&n)
+	p.trace.add("notify", "%s: %s", n.Event, n.Message)
+	if err := p.deliver(ctx, n); err != nil {
+		log.Printf("Error sending %s notification for %s: %v", n.Event, n.Namespace, err)
+	}
+}
+
+// deliver sends a notification through the configured notifier, falling
+// back to LogNotifier.
+func (p *NamespaceProcessor) deliver(ctx context.Context, n Notification) error {
+	notifier := p.notifier
+	if notifier == nil {
+		notifier = LogNotifier{}
+	}
+	return p.mutations().send(ctx, notifier, n)
+}
+
+// notifyMarked tells the owner and contributors of a namespace that it was
+// marked for deletion at deleteAt.
+func (p *NamespaceProcessor) notifyMarked(ctx context.Context, ns corev1.Namespace, deleteAt time.Time) {
+	owner := ns.Annotations[OwnerAnnotation]
+	n := Notification{
+		Event:     EventMarked,
+		Namespace: ns.Name,
+		Owner:     owner,
+		Message:   fmt.Sprintf("owner %s could not be validated, namespace marked for deletion (grace period %s)", owner, p.describeGracePeriod(ns)),
+		Context:   p.namespaceContext(ns),
+		Contact:   ns.Annotations[SecondaryContactAnnotation],
+		Manager:   ns.Annotations[ManagerAnnotation],
+		DeleteAt:  deleteAt,
+	}
+	if owner == "" {
+		n.Event = EventOwnerlessMarked
+		n.Message = fmt.Sprintf("namespace has no owner and was marked for deletion (grace period %s)", p.describeGracePeriod(ns))
+	}
+
+	contributors, err := p.contributors(ctx, ns)
+	if err != nil {
+		log.Printf("Error looking up contributors of %s: %v", ns.Name, err)
+	}
+	n.Recipients = contributors
+	p.addSecurityContact(ns, &n)
+	p.notify(ctx, n)
+}
+
+// notifyDeleted tells the owner of a namespace that it was deleted after its
+// grace period. With the pre-delete finalizer, the owner and contributors
+// were already told by notifyDeleting.
+func (p *NamespaceProcessor) notifyDeleted(ctx context.Context, ns corev1.Namespace) {
+	if p.preDeleteFinalizer {
+		return
+	}
+	owner := ns.Annotations[OwnerAnnotation]
+	n := Notification{
+		Event:     EventDeleted,
+		Namespace: ns.Name,
+		Owner:     owner,
+		Message:   withBackup(ns, fmt.Sprintf("grace period expired, namespace deleted (%s)", describeOwner(ns))),
+		Context:   p.namespaceContext(ns),
+		Contact:   ns.Annotations[SecondaryContactAnnotation],
+		Manager:   ns.Annotations[ManagerAnnotation],
+	}
+	p.addSecurityContact(ns, &n)
+	p.notify(ctx, n)
+}
@@ -0,0 +1,90 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordingNotifier collects notifications for test validation
+type recordingNotifier struct {
+	sent []Notification
+	err  error
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, n Notification) error {
+	r.sent = append(r.sent, n)
+	return r.err
+}
+
+// TestNotifyDefaultsToLog validates the fallback to LogNotifier
+func TestNotifyDefaultsToLog(t *testing.T) {
+	processor := newTestProcessor(true, nil, false)
+	logOutput := captureLogs(func() {
+		processor.notify(context.TODO(), Notification{Event: EventOwnerlessMarked, Namespace: "ns", Message: "hello"})
+	})
+	if !strings.Contains(logOutput, "Notification [ownerless-marked] ns: hello") {
+		t.Errorf("Notification not logged: %q", logOutput)
+	}
+}
+
+// TestNotifyErrorsAreLogged ensures delivery failures do not abort processing
+func TestNotifyErrorsAreLogged(t *testing.T) {
+	processor := newTestProcessor(true, nil, false)
+	processor.SetNotifier(&recordingNotifier{err: errors.New("unreachable")})
+	logOutput := captureLogs(func() {
+		processor.notify(context.TODO(), Notification{Event: EventOwnerlessMarked, Namespace: "ns"})
+	})
+	if !strings.Contains(logOutput, "unreachable") {
+		t.Errorf("Delivery error not logged: %q", logOutput)
+	}
+}
+
+// TestNotifyDryRun ensures no notifications are delivered in dry-run mode
+func TestNotifyDryRun(t *testing.T) {
+	notifier := &recordingNotifier{}
+	processor := newTestProcessor(true, nil, true)
+	processor.SetNotifier(notifier)
+	captureLogs(func() {
+		processor.notify(context.TODO(), Notification{Event: EventOwnerlessMarked, Namespace: "ns"})
+	})
+	if len(notifier.sent) != 0 {
+		t.Errorf("Dry run should not send notifications: %+v", notifier.sent)
+	}
+}
+
+// TestNotifyMarkedAndDeleted validates that marking notifies the deletion
+// deadline and that deletion after the grace period is notified
+func TestNotifyMarkedAndDeleted(t *testing.T) {
+	processor := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{OwnerAnnotation: "alice@example.com"}}},
+	}, false)
+	notifier := &recordingNotifier{}
+	processor.SetNotifier(notifier)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	processor.clock = func() time.Time { return now }
+
+	process := func() {
+		ns, err := processor.GetNamespace(context.TODO(), "alice")
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		captureLogs(func() { processor.ProcessNamespace(context.TODO(), *ns) })
+	}
+
+	process()
+	if len(notifier.sent) != 1 || notifier.sent[0].Event != EventMarked || !notifier.sent[0].DeleteAt.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("Expected a marked notification with the deadline, got %+v", notifier.sent)
+	}
+
+	now = now.Add(48 * time.Hour)
+	process()
+	if len(notifier.sent) != 2 || notifier.sent[1].Event != EventDeleted || notifier.sent[1].Owner != "alice@example.com" {
+		t.Errorf("Expected a deleted notification, got %+v", notifier.sent)
+	}
+}
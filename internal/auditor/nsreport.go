@@ -0,0 +1,124 @@
+package auditor
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// NamespaceReportFormat selects the encoding of a per-namespace report, see
+// WriteNamespaceReport.
+type NamespaceReportFormat string
+
+const (
+	NamespaceReportJSON  NamespaceReportFormat = "json"  // Indented JSON array of NamespaceReportRow
+	NamespaceReportCSV   NamespaceReportFormat = "csv"   // CSV with a header row
+	NamespaceReportTable NamespaceReportFormat = "table" // Aligned columns for terminals
+)
+
+// ParseNamespaceReportFormat parses a per-namespace report format name.
+func ParseNamespaceReportFormat(value string) (NamespaceReportFormat, error) {
+	switch format := NamespaceReportFormat(value); format {
+	case NamespaceReportJSON, NamespaceReportCSV, NamespaceReportTable:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown report format %q, expected %q, %q or %q",
+			value, NamespaceReportJSON, NamespaceReportCSV, NamespaceReportTable)
+	}
+}
+
+// NamespaceReportRow is the outcome of a run for one namespace, in the
+// per-namespace report given to compliance reviews.
+type NamespaceReportRow struct {
+	Namespace string     `json:"namespace"`          // Namespace name
+	Owner     string     `json:"owner,omitempty"`    // Owner checked, empty if the namespace has none
+	Domain    string     `json:"domain,omitempty"`   // Outcome of the domain check, DomainAllowed or DomainDisallowed
+	Identity  string     `json:"identity,omitempty"` // Verdict of the identity provider, or how it was reached without a lookup
+	Action    Action     `json:"action"`             // What was done, or would be done in a dry run
+	Reason    string     `json:"reason,omitempty"`   // Final evaluation step, explaining the action
+	DeleteAt  *time.Time `json:"deleteAt,omitempty"` // When the grace period expires, for marked namespaces
+	Error     string     `json:"error,omitempty"`    // Why the evaluation or its change failed
+}
+
+// NamespaceRows returns a row per namespace decided in the run, in
+// processing order.
+func (r *RunReport) NamespaceRows() []NamespaceReportRow {
+	deadlines := make(map[string]time.Time, len(r.Marked))
+	for _, m := range r.Marked {
+		deadlines[m.Name] = m.DeleteAt
+	}
+	rows := make([]NamespaceReportRow, 0, len(r.decided))
+	for _, d := range r.decided {
+		row := NamespaceReportRow{
+			Namespace: d.Namespace,
+			Owner:     d.Owner,
+			Domain:    d.Domain,
+			Identity:  d.Identity,
+			Action:    d.Action,
+			Reason:    d.Reason,
+		}
+		if deleteAt, ok := deadlines[d.Namespace]; ok {
+			row.DeleteAt = &deleteAt
+		}
+		if d.Err != nil {
+			row.Error = d.Err.Error()
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// namespaceReportHeader names the columns of the CSV and table formats
+var namespaceReportHeader = []string{"namespace", "owner", "domain", "identity", "action", "deleteAt", "reason", "error"}
+
+// tableColumns is the number of leading columns shown by the table format;
+// reasons and errors are too long to align
+const tableColumns = 6
+
+// columns renders the row in the order of namespaceReportHeader
+func (row NamespaceReportRow) columns() []string {
+	var deleteAt string
+	if row.DeleteAt != nil {
+		deleteAt = formatMarkerTime(*row.DeleteAt)
+	}
+	return []string{row.Namespace, row.Owner, row.Domain, row.Identity, string(row.Action), deleteAt, row.Reason, row.Error}
+}
+
+// WriteNamespaceReport writes the per-namespace report of the run in the
+// given format.
+func (r *RunReport) WriteNamespaceReport(w io.Writer, format NamespaceReportFormat) error {
+	rows := r.NamespaceRows()
+	switch format {
+	case NamespaceReportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case NamespaceReportCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(namespaceReportHeader)
+		for _, row := range rows {
+			cw.Write(row.columns())
+		}
+		cw.Flush()
+		return cw.Error()
+	case NamespaceReportTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(namespaceReportHeader[:tableColumns], "\t"))
+		for _, row := range rows {
+			cols := row.columns()[:tableColumns]
+			for i, col := range cols {
+				if col == "" {
+					cols[i] = "-"
+				}
+			}
+			fmt.Fprintln(tw, strings.Join(cols, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
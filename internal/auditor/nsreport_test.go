@@ -0,0 +1,114 @@
+package auditor
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// namespaceReport processes namespaces owned by the given owners and returns
+// the run report
+func namespaceReport(t *testing.T, owners map[string]string) *RunReport {
+	t.Helper()
+	var namespaces []*corev1.Namespace
+	for name, owner := range owners {
+		namespaces = append(namespaces, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: name, Annotations: map[string]string{OwnerAnnotation: owner},
+		}})
+	}
+	p := newTestProcessor(false, namespaces, false)
+	p.SetOwnerAllowlist([]string{"bot@partner.org"})
+
+	report := &RunReport{}
+	for _, name := range []string{"alice", "bot", "outsider"} {
+		ns, err := p.GetNamespace(context.TODO(), name)
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		captureLogs(func() { report.AddDecision(p.ProcessNamespace(context.TODO(), *ns)) })
+	}
+	report.Marked = p.Marked()
+	return report
+}
+
+// TestNamespaceRows validates the owner, domain check, identity verdict and
+// deadline reported per namespace
+func TestNamespaceRows(t *testing.T) {
+	rows := namespaceReport(t, map[string]string{
+		"alice":    "alice@example.com",
+		"bot":      "bot@partner.org",
+		"outsider": "someone@other.org",
+	}).NamespaceRows()
+	if len(rows) != 3 {
+		t.Fatalf("Rows = %+v", rows)
+	}
+
+	alice, bot, outsider := rows[0], rows[1], rows[2]
+	if alice.Owner != "alice@example.com" || alice.Domain != DomainAllowed || alice.Identity != "not-found" ||
+		alice.Action != ActionMark || alice.DeleteAt == nil {
+		t.Errorf("alice = %+v", alice)
+	}
+	if bot.Identity != IdentityAllowlisted || bot.Domain != "" || bot.Action != ActionNone || bot.DeleteAt != nil {
+		t.Errorf("bot = %+v", bot)
+	}
+	if outsider.Domain != DomainDisallowed || outsider.Identity != "" || outsider.Action != ActionSkip {
+		t.Errorf("outsider = %+v", outsider)
+	}
+}
+
+// TestWriteNamespaceReport validates the JSON, CSV and table encodings
+func TestWriteNamespaceReport(t *testing.T) {
+	report := namespaceReport(t, map[string]string{
+		"alice":    "alice@example.com",
+		"bot":      "bot@partner.org",
+		"outsider": "someone@other.org",
+	})
+
+	var buf bytes.Buffer
+	if err := report.WriteNamespaceReport(&buf, NamespaceReportJSON); err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	var rows []NamespaceReportRow
+	if err := json.Unmarshal(buf.Bytes(), &rows); err != nil || len(rows) != 3 {
+		t.Errorf("JSON rows = %+v, %v", rows, err)
+	}
+
+	buf.Reset()
+	if err := report.WriteNamespaceReport(&buf, NamespaceReportCSV); err != nil {
+		t.Fatalf("CSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(records) != 4 || strings.Join(records[0], ",") != strings.Join(namespaceReportHeader, ",") {
+		t.Fatalf("CSV records = %v, %v", records, err)
+	}
+	if records[1][0] != "alice" || records[1][4] != string(ActionMark) || records[1][5] == "" {
+		t.Errorf("CSV alice = %v", records[1])
+	}
+
+	buf.Reset()
+	if err := report.WriteNamespaceReport(&buf, NamespaceReportTable); err != nil {
+		t.Fatalf("Table: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "namespace ") || !strings.Contains(lines[3], "disallowed") {
+		t.Errorf("Table = %q", buf.String())
+	}
+}
+
+// TestParseNamespaceReportFormat validates report format names
+func TestParseNamespaceReportFormat(t *testing.T) {
+	for _, value := range []string{"json", "csv", "table"} {
+		if got, err := ParseNamespaceReportFormat(value); err != nil || string(got) != value {
+			t.Errorf("ParseNamespaceReportFormat(%q) = %q, %v", value, got, err)
+		}
+	}
+	if _, err := ParseNamespaceReportFormat("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,71 @@
+package auditor
+
+import (
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// Option configures a NamespaceProcessor at construction, see
+// NewNamespaceProcessor. Settings without an option are changed with the
+// processor's Set methods.
+type Option func(*NamespaceProcessor)
+
+// WithGracePeriod sets how long namespaces of missing owners are kept after
+// they are marked. Without it, marked namespaces expire immediately.
+func WithGracePeriod(d time.Duration) Option {
+	return func(p *NamespaceProcessor) { p.gracePeriod = d }
+}
+
+// WithAllowedDomains sets the email domains owners may belong to.
+func WithAllowedDomains(domains []string) Option {
+	return func(p *NamespaceProcessor) { p.allowedDomains = domains }
+}
+
+// WithDryRun records changes instead of applying them, see PlannedChanges.
+func WithDryRun(dryRun bool) Option {
+	return func(p *NamespaceProcessor) { p.dryRun = dryRun }
+}
+
+// WithClock reads the current time from now instead of the system clock when
+// deciding on namespaces, e.g. to replay a run at a given time in tests.
+// API latencies, identity lookup caching and backoff keep using the system
+// clock.
+func WithClock(now func() time.Time) Option {
+	return func(p *NamespaceProcessor) { p.clock = now }
+}
+
+// WithNotifier sends administrator notifications to n, see SetNotifier.
+func WithNotifier(n Notifier) Option {
+	return func(p *NamespaceProcessor) { p.SetNotifier(n) }
+}
+
+// WithLookupCache reuses identity lookups for up to ttl, see SetLookupCache.
+func WithLookupCache(ttl time.Duration) Option {
+	return func(p *NamespaceProcessor) { p.SetLookupCache(ttl) }
+}
+
+// WithClockSkew tolerates clock differences before acting on an expired
+// marker, see SetClockSkew.
+func WithClockSkew(skew time.Duration) Option {
+	return func(p *NamespaceProcessor) { p.SetClockSkew(skew) }
+}
+
+// WithRunInfo stamps the run ID and configuration hash alongside deletion
+// markers, see SetRunInfo.
+func WithRunInfo(runID, configHash string) Option {
+	return func(p *NamespaceProcessor) { p.SetRunInfo(runID, configHash) }
+}
+
+// WithMutationClient applies changes through client, see SetMutationClient.
+func WithMutationClient(client kubernetes.Interface) Option {
+	return func(p *NamespaceProcessor) { p.SetMutationClient(client) }
+}
+
+// now returns the current time of the processor's clock
+func (p *NamespaceProcessor) now() time.Time {
+	if p.clock != nil {
+		return p.clock()
+	}
+	return time.Now()
+}
@@ -0,0 +1,59 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestOptions validates that options configure the processor at construction
+func TestOptions(t *testing.T) {
+	notifier := LogNotifier{}
+	p := NewNamespaceProcessor(fake.NewSimpleClientset(), &MockUserChecker{},
+		WithGracePeriod(time.Hour),
+		WithAllowedDomains([]string{"example.com"}),
+		WithDryRun(true),
+		WithNotifier(notifier),
+		WithLookupCache(time.Minute),
+		WithClockSkew(time.Second),
+		WithRunInfo("run-1", "hash"),
+	)
+	if p.gracePeriod != time.Hour || len(p.allowedDomains) != 1 || !p.dryRun || p.notifier != notifier ||
+		p.lookups == nil || p.clockSkew != time.Second || p.runID != "run-1" || p.configHash != "hash" {
+		t.Errorf("Options not applied: %+v", p)
+	}
+	if p.marked == nil || p.planned == nil {
+		t.Error("Collectors should be created regardless of options")
+	}
+}
+
+// TestWithClock validates that decisions are taken at the time of the
+// processor's clock
+func TestWithClock(t *testing.T) {
+	at := time.Date(2030, time.March, 1, 12, 0, 0, 0, time.UTC)
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "alice",
+		Annotations: map[string]string{OwnerAnnotation: "alice@example.com"},
+	}})
+	p := NewNamespaceProcessor(client, &MockUserChecker{exists: false},
+		WithGracePeriod(24*time.Hour),
+		WithAllowedDomains([]string{"example.com"}),
+		WithClock(func() time.Time { return at }),
+	)
+
+	ns, _ := p.GetNamespace(context.TODO(), "alice")
+	captureLogs(func() { p.ProcessNamespace(context.TODO(), *ns) })
+	ns, _ = p.GetNamespace(context.TODO(), "alice")
+	if got := ns.Annotations[GracePeriodAnnotation]; got != formatMarkerTime(at) {
+		t.Fatalf("Marker = %q, want %q", got, formatMarkerTime(at))
+	}
+
+	at = at.Add(25 * time.Hour)
+	if action := p.ProcessNamespaceTraced(context.TODO(), *ns).Action; action != ActionDelete {
+		t.Errorf("Action a day later = %q, want %q", action, ActionDelete)
+	}
+}
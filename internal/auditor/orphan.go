@@ -0,0 +1,136 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultOrphanPeriod is how long a namespace stays orphaned before it is
+// deleted, with ExpiryReassign, unless configured otherwise.
+const DefaultOrphanPeriod = 90 * 24 * time.Hour
+
+// SetOrphanPolicy configures ExpiryReassign: namespaces whose grace period
+// expires are handed to fallbackOwner, a user or group, or keep their
+// owner if it is empty, and are deleted once they have been orphaned for
+// period, DefaultOrphanPeriod if zero.
+func (p *NamespaceProcessor) SetOrphanPolicy(fallbackOwner string, period time.Duration) {
+	p.orphanOwner = fallbackOwner
+	p.orphanPeriod = period
+}
+
+// orphanPeriodOrDefault returns the configured orphan period, defaulting to
+// DefaultOrphanPeriod
+func (p *NamespaceProcessor) orphanPeriodOrDefault() time.Duration {
+	if p.orphanPeriod <= 0 {
+		return DefaultOrphanPeriod
+	}
+	return p.orphanPeriod
+}
+
+// isOrphaned reports whether a namespace was orphaned by ExpiryReassign
+func isOrphaned(ns corev1.Namespace) bool {
+	return ns.Annotations[OrphanedAnnotation] == "true"
+}
+
+// clearOrphan removes the orphan state together with any deletion approval
+// requested for it
+func clearOrphan(annotations map[string]string) {
+	delete(annotations, OrphanedAnnotation)
+	delete(annotations, OrphanedAtAnnotation)
+	delete(annotations, PreviousOwnerAnnotation)
+	delete(annotations, ApprovalRequestedAnnotation)
+	delete(annotations, DeletionApprovedAnnotation)
+	delete(annotations, SecondApprovalAnnotation)
+}
+
+// orphanNamespace replaces the deletion marker of an expired namespace with
+// the orphan state, handing the namespace to the fallback owner if one is
+// configured.
+func (p *NamespaceProcessor) orphanNamespace(ns corev1.Namespace) {
+	now := p.now()
+	owner, described := ns.Annotations[OwnerAnnotation], describeOwner(ns)
+	log.Printf("Orphaning namespace %s after grace period", ns.Name)
+	p.trace.setAction(ActionOrphan)
+
+	clearMarker(ns.Annotations)
+	ns.Annotations[OrphanedAnnotation] = "true"
+	ns.Annotations[OrphanedAtAnnotation] = formatMarkerTime(now)
+	ns.Annotations[PreviousOwnerAnnotation] = owner
+	reassigned := "keeps its owner"
+	if p.orphanOwner != "" {
+		ns.Annotations[OwnerAnnotation] = p.orphanOwner
+		reassigned = "reassigned to " + p.orphanOwner
+	}
+	p.trace.add("orphan", "grace period expired, namespace orphaned and %s", reassigned)
+	if err := p.updateNamespace(context.TODO(), &ns); err != nil {
+		log.Printf("Error orphaning %s: %v", ns.Name, err)
+		return
+	}
+
+	deleteAt := now.Add(p.orphanPeriodOrDefault())
+	p.recordEvent(context.TODO(), ns, corev1.EventTypeWarning, EventReasonOrphaned,
+		"Orphaned after its grace period expired: %s is not a valid user, namespace %s, deletion after %s unless claimed",
+		described, reassigned, formatMarkerTime(deleteAt))
+	n := Notification{
+		Event:     EventOrphaned,
+		Namespace: ns.Name,
+		Owner:     owner,
+		Message:   fmt.Sprintf("grace period expired, namespace orphaned and %s until claimed (%s)", reassigned, described),
+		Context:   p.namespaceContext(ns),
+		Contact:   ns.Annotations[SecondaryContactAnnotation],
+		DeleteAt:  deleteAt,
+	}
+	p.addSecurityContact(ns, &n)
+	p.notify(context.TODO(), n)
+}
+
+// handleOrphaned follows an orphaned namespace: it is deleted once it has
+// been orphaned for the orphan period, unless its owner annotation was
+// changed to someone other than the fallback and previous owner, which ends
+// the orphan state. Returns whether the namespace was handled; namespaces
+// that are not or no longer orphaned are audited as usual, continuing from
+// ns as updated.
+func (p *NamespaceProcessor) handleOrphaned(ns *corev1.Namespace) bool {
+	if !isOrphaned(*ns) {
+		return false
+	}
+	now := p.now()
+	owner, previous := ns.Annotations[OwnerAnnotation], ns.Annotations[PreviousOwnerAnnotation]
+	if owner != previous && owner != p.orphanOwner {
+		log.Printf("Namespace %s was reassigned to %s, no longer orphaned", ns.Name, owner)
+		p.trace.add("orphan", "owner changed from %q to %q, orphan state removed", previous, owner)
+		clearOrphan(ns.Annotations)
+		if err := p.updateNamespace(context.TODO(), ns); err != nil {
+			log.Printf("Error updating %s: %v", ns.Name, err)
+			return true
+		}
+		return false
+	}
+
+	orphanedAt, err := parseMarkerTime(ns.Annotations[OrphanedAtAnnotation])
+	if err != nil {
+		// Restart the orphan period rather than delete on an unreadable time
+		log.Printf("Resetting unreadable %s of %s: %v", OrphanedAtAnnotation, ns.Name, err)
+		p.trace.add("orphan", "orphaned at %q is not a valid timestamp, orphan period restarted", ns.Annotations[OrphanedAtAnnotation])
+		p.trace.setAction(ActionReset)
+		ns.Annotations[OrphanedAtAnnotation] = formatMarkerTime(now)
+		if err := p.updateNamespace(context.TODO(), ns); err != nil {
+			log.Printf("Error updating %s: %v", ns.Name, err)
+		}
+		return true
+	}
+
+	deleteAt := orphanedAt.Add(p.orphanPeriodOrDefault() + p.clockSkew)
+	if now.After(deleteAt) {
+		p.trace.add("orphan", "orphaned at %s, unclaimed since, orphan period expired at %s", formatMarkerTime(orphanedAt), formatMarkerTime(deleteAt))
+		p.expire(*ns)
+		return true
+	}
+	p.trace.add("orphan", "orphaned at %s, deletion after %s unless claimed", formatMarkerTime(orphanedAt), formatMarkerTime(deleteAt))
+	p.trace.setAction(ActionWait)
+	return true
+}
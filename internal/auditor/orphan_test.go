@@ -0,0 +1,103 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestOrphanLifecycle validates that an expired namespace is orphaned and
+// handed to the fallback owner, and deleted once the orphan period expires
+func TestOrphanLifecycle(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	processor := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{
+			OwnerAnnotation:       "alice@example.com",
+			GracePeriodAnnotation: formatMarkerTime(now.Add(-48 * time.Hour)),
+		}}},
+	}, false)
+	processor.clock = func() time.Time { return now }
+	processor.SetExpiryAction(ExpiryReassign)
+	processor.SetOrphanPolicy("platform-team", 30*24*time.Hour)
+	notifier := &recordingNotifier{}
+	processor.SetNotifier(notifier)
+
+	process := func() Action {
+		ns, err := processor.GetNamespace(context.TODO(), "alice")
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		var tr *Trace
+		captureLogs(func() { tr = processor.ProcessNamespaceTraced(context.TODO(), *ns) })
+		return tr.Action
+	}
+
+	if action := process(); action != ActionOrphan {
+		t.Fatalf("Action = %q, want %q", action, ActionOrphan)
+	}
+	ns, _ := processor.GetNamespace(context.TODO(), "alice")
+	for key, want := range map[string]string{
+		OrphanedAnnotation:      "true",
+		OrphanedAtAnnotation:    formatMarkerTime(now),
+		PreviousOwnerAnnotation: "alice@example.com",
+		OwnerAnnotation:         "platform-team",
+	} {
+		if got := ns.Annotations[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if _, marked := ns.Annotations[GracePeriodAnnotation]; marked {
+		t.Error("The deletion marker should be replaced by the orphan state")
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Event != EventOrphaned || !notifier.sent[0].DeleteAt.Equal(now.Add(30*24*time.Hour)) {
+		t.Errorf("Expected an orphaned notification with the deletion time, got %+v", notifier.sent)
+	}
+
+	// The fallback owner is not validated while the namespace is orphaned
+	now = now.Add(29 * 24 * time.Hour)
+	if action := process(); action != ActionWait {
+		t.Errorf("Action = %q, want %q within the orphan period", action, ActionWait)
+	}
+
+	now = now.Add(2 * 24 * time.Hour)
+	if action := process(); action != ActionDelete {
+		t.Errorf("Action = %q, want %q once the orphan period expired", action, ActionDelete)
+	}
+	if _, err := processor.GetNamespace(context.TODO(), "alice"); err == nil {
+		t.Error("The namespace should be deleted")
+	}
+}
+
+// TestOrphanReassigned validates that changing the owner of an orphaned
+// namespace ends the orphan state
+func TestOrphanReassigned(t *testing.T) {
+	processor := newTestProcessor(true, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{
+			OwnerAnnotation:         "bob@example.com",
+			OrphanedAnnotation:      "true",
+			OrphanedAtAnnotation:    "2020-01-01T00:00:00Z",
+			PreviousOwnerAnnotation: "alice@example.com",
+		}}},
+	}, false)
+	processor.SetExpiryAction(ExpiryReassign)
+	processor.SetOrphanPolicy("platform-team", 0)
+
+	ns, _ := processor.GetNamespace(context.TODO(), "alice")
+	var tr *Trace
+	captureLogs(func() { tr = processor.ProcessNamespaceTraced(context.TODO(), *ns) })
+	if tr.Action != ActionNone {
+		t.Errorf("Action = %q, want the new owner audited as usual", tr.Action)
+	}
+	ns, err := processor.GetNamespace(context.TODO(), "alice")
+	if err != nil {
+		t.Fatalf("A reassigned namespace should not be deleted: %v", err)
+	}
+	for _, key := range []string{OrphanedAnnotation, OrphanedAtAnnotation, PreviousOwnerAnnotation} {
+		if _, ok := ns.Annotations[key]; ok {
+			t.Errorf("%s should be removed: %v", key, ns.Annotations)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package auditor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// OwnerVerdict is the fixed verdict an owner list gives for an owner.
+type OwnerVerdict string
+
+// Owner list verdicts
+const (
+	OwnerUnlisted      OwnerVerdict = ""        // Not on the list, the identity provider decides
+	OwnerAlwaysValid   OwnerVerdict = "valid"   // Always treated as a valid owner
+	OwnerAlwaysInvalid OwnerVerdict = "invalid" // Always treated as an invalid owner
+)
+
+// ownerListFile is the YAML form of an owner list
+type ownerListFile struct {
+	Valid   []string `json:"valid"`
+	Invalid []string `json:"invalid"`
+}
+
+// OwnerList is a file of owner emails that are always treated as valid,
+// such as service accounts and shared mailboxes, or always as invalid. It
+// is checked before the identity provider and reloaded whenever the file
+// changes. It is safe for concurrent use.
+//
+// Files ending in .csv hold one "email,valid" or "email,invalid" record per
+// line; other files are YAML with "valid" and "invalid" lists of emails.
+type OwnerList struct {
+	path string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	size     int64
+	verdicts map[string]OwnerVerdict // By normalized email
+}
+
+// LoadOwnerList reads the owner list at path. Unlike later reloads, which
+// keep the previous list when the file cannot be read, a malformed list is
+// an error here.
+func LoadOwnerList(path string) (*OwnerList, error) {
+	l := &OwnerList{path: path}
+	if err := l.reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Verdict returns the listed verdict for email, reloading the list first if
+// the file changed. Unlisted owners return OwnerUnlisted.
+func (l *OwnerList) Verdict(email string) OwnerVerdict {
+	if l == nil {
+		return OwnerUnlisted
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if info, err := os.Stat(l.path); err == nil && (!info.ModTime().Equal(l.modTime) || info.Size() != l.size) {
+		if err := l.reload(); err != nil {
+			log.Printf("Keeping previous owner list: %v", err)
+		} else {
+			log.Printf("Reloaded owner list %s", l.path)
+		}
+	}
+	normalized, ok := normalizeEmail(email)
+	if !ok {
+		return OwnerUnlisted
+	}
+	return l.verdicts[normalized]
+}
+
+// reload replaces the listed verdicts with the current file contents.
+// Callers other than LoadOwnerList must hold l.mu.
+func (l *OwnerList) reload() error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return err
+	}
+	verdicts, err := parseOwnerList(data, strings.EqualFold(filepath.Ext(l.path), ".csv"))
+	if err != nil {
+		return fmt.Errorf("%s: %w", l.path, err)
+	}
+	l.verdicts, l.modTime, l.size = verdicts, info.ModTime(), info.Size()
+	return nil
+}
+
+// parseOwnerList parses an owner list in CSV or YAML form. Malformed
+// emails, unknown verdicts and emails listed both ways are errors.
+func parseOwnerList(data []byte, isCSV bool) (map[string]OwnerVerdict, error) {
+	var list ownerListFile
+	if isCSV {
+		r := csv.NewReader(bytes.NewReader(data))
+		r.Comment = '#'
+		r.FieldsPerRecord = 2
+		r.TrimLeadingSpace = true
+		for {
+			record, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			switch OwnerVerdict(strings.ToLower(strings.TrimSpace(record[1]))) {
+			case OwnerAlwaysValid:
+				list.Valid = append(list.Valid, record[0])
+			case OwnerAlwaysInvalid:
+				list.Invalid = append(list.Invalid, record[0])
+			default:
+				return nil, fmt.Errorf("unknown verdict %q for %s, expected %q or %q", record[1], record[0], OwnerAlwaysValid, OwnerAlwaysInvalid)
+			}
+		}
+	} else if err := yaml.UnmarshalStrict(data, &list); err != nil {
+		return nil, err
+	}
+
+	verdicts := make(map[string]OwnerVerdict, len(list.Valid)+len(list.Invalid))
+	var invalid, conflicting []string
+	add := func(emails []string, verdict OwnerVerdict) {
+		for _, entry := range emails {
+			email, ok := normalizeEmail(entry)
+			if !ok {
+				invalid = append(invalid, entry)
+				continue
+			}
+			if previous, seen := verdicts[email]; seen && previous != verdict {
+				conflicting = append(conflicting, email)
+			}
+			verdicts[email] = verdict
+		}
+	}
+	add(list.Valid, OwnerAlwaysValid)
+	add(list.Invalid, OwnerAlwaysInvalid)
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid owner emails: %q", invalid)
+	}
+	if len(conflicting) > 0 {
+		return nil, fmt.Errorf("owners listed as both valid and invalid: %q", conflicting)
+	}
+	return verdicts, nil
+}
+
+// SetOwnerList checks owners against list before the identity provider.
+// Owners listed as valid are treated like allowlisted owners, regardless of
+// the allowed domains; owners listed as invalid are treated like users the
+// identity provider does not know. Listed owners are never looked up. A nil
+// list disables the check.
+func (p *NamespaceProcessor) SetOwnerList(list *OwnerList) {
+	p.ownerList = list
+}
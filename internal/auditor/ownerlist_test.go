@@ -0,0 +1,124 @@
+package auditor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParseOwnerList validates both file formats and their errors
+func TestParseOwnerList(t *testing.T) {
+	want := map[string]OwnerVerdict{
+		"mlops-svc@example.com": OwnerAlwaysValid,
+		"shared@example.com":    OwnerAlwaysValid,
+		"intruder@example.com":  OwnerAlwaysInvalid,
+	}
+	tests := []struct {
+		name    string
+		data    string
+		isCSV   bool
+		wantErr bool
+	}{
+		{"yaml", "valid:\n- MLOps-Svc@example.com\n- shared@example.com\ninvalid:\n- intruder@example.com\n", false, false},
+		{"csv", "# email,verdict\nMLOps-Svc@example.com,valid\nshared@example.com, Valid\nintruder@example.com,invalid\n", true, false},
+		{"unknown yaml key", "allowed:\n- shared@example.com\n", false, true},
+		{"unknown csv verdict", "shared@example.com,maybe\n", true, true},
+		{"missing csv field", "shared@example.com\n", true, true},
+		{"malformed email", "valid:\n- shared\n", false, true},
+		{"conflict", "valid:\n- shared@example.com\ninvalid:\n- Shared@example.com\n", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOwnerList([]byte(tt.data), tt.isCSV)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("parseOwnerList() = %v, %v, want %v", got, err, want)
+			}
+		})
+	}
+}
+
+// TestOwnerListReload validates that changes to the file are picked up and
+// that a broken file keeps the previous list
+func TestOwnerListReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.csv")
+	write := func(data string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write("bot@example.com,valid\n", start)
+
+	list, err := LoadOwnerList(path)
+	if err != nil {
+		t.Fatalf("LoadOwnerList failed: %v", err)
+	}
+	if got := list.Verdict("Bot@example.com"); got != OwnerAlwaysValid {
+		t.Errorf("Verdict = %q, want %q", got, OwnerAlwaysValid)
+	}
+
+	write("bot@example.com,invalid\n", start.Add(time.Minute))
+	if got := list.Verdict("bot@example.com"); got != OwnerAlwaysInvalid {
+		t.Errorf("Verdict after change = %q, want %q", got, OwnerAlwaysInvalid)
+	}
+
+	write("bot@example.com,perhaps\n", start.Add(2*time.Minute))
+	if got := list.Verdict("bot@example.com"); got != OwnerAlwaysInvalid {
+		t.Errorf("Verdict after a broken change = %q, want the previous %q", got, OwnerAlwaysInvalid)
+	}
+	if got := list.Verdict("other@example.com"); got != OwnerUnlisted {
+		t.Errorf("Verdict of an unlisted owner = %q", got)
+	}
+
+	if _, err := LoadOwnerList(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+// TestOwnerListProcessing validates that listed owners are decided without
+// a lookup
+func TestOwnerListProcessing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.yaml")
+	if err := os.WriteFile(path, []byte("valid: [bot@partner.org]\ninvalid: [gone@example.com]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadOwnerList(path)
+	if err != nil {
+		t.Fatalf("LoadOwnerList failed: %v", err)
+	}
+
+	tests := []struct {
+		owner      string
+		userExists bool
+		want       Action
+	}{
+		{"bot@partner.org", false, ActionNone},  // Valid regardless of domain and directory
+		{"gone@example.com", true, ActionMark},  // Invalid although the directory knows the user
+		{"user@example.com", false, ActionMark}, // Unlisted, the directory decides
+		{"user@example.com", true, ActionNone},  // Unlisted, the directory decides
+	}
+	for _, tt := range tests {
+		p := newTestProcessor(tt.userExists, []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{
+			Name:        "team",
+			Annotations: map[string]string{OwnerAnnotation: tt.owner},
+		}}}, false)
+		p.SetOwnerList(list)
+		if tr, _ := runOnce(t, p, "team", tt.userExists); tr.Action != tt.want {
+			t.Errorf("Owner %s (exists %t): Action = %q, want %q", tt.owner, tt.userExists, tr.Action, tt.want)
+		}
+	}
+}
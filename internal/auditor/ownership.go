@@ -0,0 +1,72 @@
+package auditor
+
+import (
+	"encoding/json"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// FieldManager is the field manager the auditor writes namespaces as. It
+// matches the client-go default for the binary, so markers written by
+// earlier versions are recognized too.
+const FieldManager = "namespace-auditor"
+
+// ForeignMarker describes a deletion marker the auditor did not write.
+type ForeignMarker struct {
+	Namespace string `json:"namespace"`         // Namespace carrying the marker
+	DeleteAt  string `json:"deleteAt"`          // Value of the marker
+	Manager   string `json:"manager,omitempty"` // Field manager that wrote it, if known
+}
+
+// ForeignMarkers returns the deletion markers left in place during this run
+// because they were not written by the auditor.
+func (p *NamespaceProcessor) ForeignMarkers() []ForeignMarker {
+	return p.foreign.list()
+}
+
+// ownsMarker reports whether the deletion marker of ns, if any, was written
+// by the auditor: either it carries the run-ID companion annotation or the
+// auditor's field manager owns it. Without managed fields ownership cannot be
+// determined and the marker is trusted. Foreign markers are reported so that
+// they are never silently removed.
+func (p *NamespaceProcessor) ownsMarker(ns corev1.Namespace) bool {
+	value, marked := ns.Annotations[GracePeriodAnnotation]
+	if !marked {
+		return true
+	}
+	if _, ok := ns.Annotations[RunIDAnnotation]; ok {
+		return true
+	}
+	manager := markerManager(ns)
+	if len(ns.ManagedFields) == 0 || manager == FieldManager {
+		return true
+	}
+
+	log.Printf("Leaving deletion marker on %s in place: not written by the auditor (manager %q)", ns.Name, manager)
+	p.trace.add("marker", "deletion marker %q was not written by the auditor (manager %q), left in place", value, manager)
+	p.foreign.add(ForeignMarker{Namespace: ns.Name, DeleteAt: value, Manager: manager})
+	return false
+}
+
+// markerManager returns the field manager owning the deletion marker
+// according to the namespace's managed fields, or "" if none does.
+func markerManager(ns corev1.Namespace) string {
+	for _, entry := range ns.ManagedFields {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Metadata struct {
+				Annotations map[string]json.RawMessage `json:"f:annotations"`
+			} `json:"f:metadata"`
+		}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if _, ok := fields.Metadata.Annotations["f:"+GracePeriodAnnotation]; ok {
+			return entry.Manager
+		}
+	}
+	return ""
+}
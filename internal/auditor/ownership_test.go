@@ -0,0 +1,86 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// managedMarker builds a marked namespace whose marker is owned by manager
+func managedMarker(manager string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "managed",
+		Annotations: map[string]string{
+			OwnerAnnotation:       "valid@example.com",
+			GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+		},
+		ManagedFields: []metav1.ManagedFieldsEntry{{
+			Manager:   manager,
+			Operation: metav1.ManagedFieldsOperationUpdate,
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(
+				`{"f:metadata":{"f:annotations":{"f:namespace-auditor/delete-at":{}}}}`,
+			)},
+		}},
+	}}
+}
+
+// TestMarkerOwnership validates that only markers written by the auditor are removed
+func TestMarkerOwnership(t *testing.T) {
+	tests := []struct {
+		name        string
+		ns          *corev1.Namespace
+		wantAction  Action
+		wantForeign bool
+	}{
+		{name: "written by auditor", ns: managedMarker(FieldManager), wantAction: ActionUnmark},
+		{name: "written by kubectl", ns: managedMarker("kubectl-annotate"), wantAction: ActionForeign, wantForeign: true},
+		{
+			name: "run ID companion",
+			ns: func() *corev1.Namespace {
+				ns := managedMarker("kubectl-annotate")
+				ns.Annotations[RunIDAnnotation] = "run-1"
+				return ns
+			}(),
+			wantAction: ActionUnmark,
+		},
+		{
+			name: "no managed fields",
+			ns: func() *corev1.Namespace {
+				ns := managedMarker("")
+				ns.ManagedFields = nil
+				return ns
+			}(),
+			wantAction: ActionUnmark,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := newTestProcessor(true, []*corev1.Namespace{tt.ns}, false)
+
+			var tr *Trace
+			captureLogs(func() {
+				tr = processor.ProcessNamespaceTraced(context.TODO(), *tt.ns.DeepCopy())
+			})
+			if tr.Action != tt.wantAction {
+				t.Errorf("Action = %q, want %q", tr.Action, tt.wantAction)
+			}
+
+			got, _ := processor.GetNamespace(context.TODO(), tt.ns.Name)
+			_, kept := got.Annotations[GracePeriodAnnotation]
+			if kept != tt.wantForeign {
+				t.Errorf("Marker kept = %t, want %t", kept, tt.wantForeign)
+			}
+
+			foreign := processor.ForeignMarkers()
+			if tt.wantForeign != (len(foreign) == 1) {
+				t.Fatalf("Unexpected foreign markers: %+v", foreign)
+			}
+			if tt.wantForeign && foreign[0].Manager != "kubectl-annotate" {
+				t.Errorf("Manager = %q, want kubectl-annotate", foreign[0].Manager)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PauseWindow is a period during which grace-period countdowns are frozen,
+// e.g. an end-of-year shutdown. Start is inclusive, End exclusive.
+type PauseWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// String renders the window in the same form accepted by ParsePauseWindows.
+func (w PauseWindow) String() string {
+	return formatMarkerTime(w.Start) + "/" + formatMarkerTime(w.End)
+}
+
+// ParsePauseWindows parses a comma-separated list of "start/end" pause
+// windows, e.g. "2024-12-20/2025-01-06". Each bound accepts the same
+// formats as deletion markers; dates without a time mean midnight UTC.
+func ParsePauseWindows(value string) ([]PauseWindow, error) {
+	var windows []PauseWindow
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(entry, "/")
+		if !ok {
+			return nil, fmt.Errorf("pause window %q must be in start/end form", entry)
+		}
+		start, err := parseMarkerTime(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("pause window %q: %w", entry, err)
+		}
+		end, err := parseMarkerTime(strings.TrimSpace(to))
+		if err != nil {
+			return nil, fmt.Errorf("pause window %q: %w", entry, err)
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("pause window %q ends before it starts", entry)
+		}
+		windows = append(windows, PauseWindow{Start: start, End: end})
+	}
+	return windows, nil
+}
+
+// pausedBetween returns the total time between from and to that falls
+// inside any of the pause windows.
+func pausedBetween(windows []PauseWindow, from, to time.Time) time.Duration {
+	var total time.Duration
+	for _, w := range windows {
+		start, end := w.Start, w.End
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// accumulatedPause returns the pause time credited to a marked namespace.
+// The value recorded in PausedAnnotation is kept as a lower bound so that
+// credit already earned survives later changes to the configured windows.
+func (p *NamespaceProcessor) accumulatedPause(ns corev1.Namespace, markedAt, now time.Time) time.Duration {
+	paused := pausedBetween(p.pauseWindows, markedAt, now)
+	if recorded, err := time.ParseDuration(ns.Annotations[PausedAnnotation]); err == nil && recorded > paused {
+		paused = recorded
+	}
+	return paused
+}
+
+// recordPause stores the accumulated pause time on the namespace when it
+// has grown since the last run. If changed is set, ns has other pending
+// changes and is written regardless. Reports whether ns was written.
+func (p *NamespaceProcessor) recordPause(ns corev1.Namespace, paused time.Duration, changed bool) bool {
+	recorded, err := time.ParseDuration(ns.Annotations[PausedAnnotation])
+	grown := err != nil || recorded < paused
+	if !grown && !changed {
+		return false
+	}
+	if grown {
+		log.Printf("Recording %s of paused grace period on %s", paused, ns.Name)
+		ns.Annotations[PausedAnnotation] = paused.String()
+	}
+
+	err = p.updateNamespace(context.TODO(), &ns)
+	if err != nil {
+		log.Printf("Error recording pause on %s: %v", ns.Name, err)
+		return false
+	}
+	return true
+}
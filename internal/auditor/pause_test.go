@@ -0,0 +1,99 @@
+package auditor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParsePauseWindows validates pause window configuration parsing
+func TestParsePauseWindows(t *testing.T) {
+	windows, err := ParsePauseWindows("2024-12-20/2025-01-06, 2025-07-01T00:00:00Z/2025-07-02T00:00:00Z,")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("Expected 2 windows, got %d", len(windows))
+	}
+	if got := windows[0].End.Sub(windows[0].Start); got != 17*24*time.Hour {
+		t.Errorf("Unexpected first window length: %s", got)
+	}
+
+	for _, value := range []string{"2024-12-20", "2025-01-06/2024-12-20", "later/2025-01-06"} {
+		if _, err := ParsePauseWindows(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+// TestPausedBetween validates overlap accumulation
+func TestPausedBetween(t *testing.T) {
+	day := 24 * time.Hour
+	base := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	windows := []PauseWindow{
+		{Start: base.Add(10 * day), End: base.Add(20 * day)},
+		{Start: base.Add(30 * day), End: base.Add(31 * day)},
+	}
+
+	tests := []struct {
+		name     string        // Test scenario description
+		from, to time.Time     // Evaluated interval
+		want     time.Duration // Expected paused time
+	}{
+		{name: "before windows", from: base, to: base.Add(5 * day), want: 0},
+		{name: "inside window", from: base.Add(12 * day), to: base.Add(15 * day), want: 3 * day},
+		{name: "partial overlap", from: base.Add(15 * day), to: base.Add(25 * day), want: 5 * day},
+		{name: "spans both", from: base, to: base.Add(40 * day), want: 11 * day},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pausedBetween(windows, tt.from, tt.to); got != tt.want {
+				t.Errorf("Paused mismatch:\nExpected: %s\nGot: %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestPauseExtendsGracePeriod validates that paused time delays deletion
+// and is recorded on the namespace
+func TestPauseExtendsGracePeriod(t *testing.T) {
+	now := time.Now().UTC()
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "paused",
+			Annotations: map[string]string{
+				GracePeriodAnnotation: formatMarkerTime(now.Add(-36 * time.Hour)),
+			},
+		},
+	}
+	processor := newTestProcessor(false, []*corev1.Namespace{&ns}, false)
+	processor.SetPauseWindows([]PauseWindow{{Start: now.Add(-30 * time.Hour), End: now.Add(-6 * time.Hour)}})
+
+	logOutput := captureLogs(func() {
+		processor.handleInvalidUser(*ns.DeepCopy())
+	})
+	if strings.Contains(logOutput, "Deleting") {
+		t.Fatalf("Namespace should not be deleted while paused time extends the grace period: %q", logOutput)
+	}
+
+	updatedNs, _ := processor.k8sClient.CoreV1().Namespaces().Get(
+		context.TODO(), ns.Name, metav1.GetOptions{},
+	)
+	if got := updatedNs.Annotations[PausedAnnotation]; got != "24h0m0s" {
+		t.Errorf("Expected recorded pause of 24h, got %q", got)
+	}
+
+	// Recorded credit survives removal of the configured windows
+	processor.SetPauseWindows(nil)
+	logOutput = captureLogs(func() {
+		processor.handleInvalidUser(*updatedNs)
+	})
+	if strings.Contains(logOutput, "Deleting") {
+		t.Errorf("Recorded pause should still extend the grace period: %q", logOutput)
+	}
+}
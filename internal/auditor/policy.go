@@ -0,0 +1,75 @@
+package auditor
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// OwnerPolicy selects how namespaces whose owner cannot be validated for
+// reasons other than a missing user (e.g. a disallowed domain) are handled.
+type OwnerPolicy string
+
+const (
+	// OwnerPolicySkip leaves such namespaces untouched.
+	OwnerPolicySkip OwnerPolicy = "skip"
+
+	// OwnerPolicyExpire treats such namespaces like those of missing users:
+	// they are marked and expire once their grace period has passed.
+	OwnerPolicyExpire OwnerPolicy = "expire"
+)
+
+// ParseOwnerPolicy parses an owner policy name. An empty value selects
+// OwnerPolicySkip.
+func ParseOwnerPolicy(value string) (OwnerPolicy, error) {
+	switch OwnerPolicy(value) {
+	case "", OwnerPolicySkip:
+		return OwnerPolicySkip, nil
+	case OwnerPolicyExpire:
+		return OwnerPolicyExpire, nil
+	default:
+		return "", fmt.Errorf("unknown policy %q, expected %q or %q", value, OwnerPolicySkip, OwnerPolicyExpire)
+	}
+}
+
+// SetInvalidDomainPolicy configures how namespaces whose owner has a
+// disallowed email domain are handled. With OwnerPolicyExpire, gracePeriod
+// overrides the regular grace period for these namespaces; zero keeps the
+// regular grace period.
+func (p *NamespaceProcessor) SetInvalidDomainPolicy(policy OwnerPolicy, gracePeriod time.Duration) {
+	p.invalidDomainPolicy = policy
+	p.invalidDomainGrace = gracePeriod
+}
+
+// SetOwnerlessPolicy configures how namespaces without an owner annotation
+// are handled. With OwnerPolicyExpire, gracePeriod overrides the regular
+// grace period for these namespaces; zero keeps the regular grace period.
+func (p *NamespaceProcessor) SetOwnerlessPolicy(policy OwnerPolicy, gracePeriod time.Duration) {
+	p.ownerlessPolicy = policy
+	p.ownerlessGrace = gracePeriod
+}
+
+// IsOwnerless reports whether a namespace lacks an owner annotation.
+func IsOwnerless(ns corev1.Namespace) bool {
+	return ns.Annotations[OwnerAnnotation] == ""
+}
+
+// handleOwnerless marks and expires a namespace without an owner.
+func (p *NamespaceProcessor) handleOwnerless(ns corev1.Namespace) {
+	p.trace.add("policy", "ownerless policy %q, grace period override %s", p.ownerlessPolicy, p.ownerlessGrace)
+	log.Printf("Treating %s as unowned: missing owner annotation", ns.Name)
+	p.withGracePeriod(p.ownerlessGrace).handleInvalidUser(ns)
+}
+
+// withGracePeriod returns a processor using a fixed grace period override,
+// or p itself if no override is given.
+func (p *NamespaceProcessor) withGracePeriod(gracePeriod time.Duration) *NamespaceProcessor {
+	if gracePeriod <= 0 {
+		return p
+	}
+	q := *p
+	q.grace = FixedGracePeriod(gracePeriod)
+	return &q
+}
@@ -0,0 +1,152 @@
+package auditor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParseOwnerPolicy validates owner policy names
+func TestParseOwnerPolicy(t *testing.T) {
+	for value, want := range map[string]OwnerPolicy{"": OwnerPolicySkip, "skip": OwnerPolicySkip, "expire": OwnerPolicyExpire} {
+		got, err := ParseOwnerPolicy(value)
+		if err != nil || got != want {
+			t.Errorf("ParseOwnerPolicy(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseOwnerPolicy("delete"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+// TestInvalidDomainPolicy validates marking and expiry of namespaces owned
+// by users with disallowed domains
+func TestInvalidDomainPolicy(t *testing.T) {
+	owner := map[string]string{OwnerAnnotation: "user@external.org"}
+
+	t.Run("skip leaves namespace untouched", func(t *testing.T) {
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "skipped", Annotations: owner}}
+		processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+
+		tr := processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+		if tr.Action != ActionSkip {
+			t.Errorf("Expected skip, got %q", tr.Action)
+		}
+	})
+
+	t.Run("expire marks namespace", func(t *testing.T) {
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "marked", Annotations: owner}}
+		processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+		processor.SetInvalidDomainPolicy(OwnerPolicyExpire, 0)
+
+		logOutput := captureLogs(func() {
+			processor.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+		})
+		if !strings.Contains(logOutput, "Treating marked as unowned") {
+			t.Errorf("Policy not logged: %q", logOutput)
+		}
+
+		updatedNs, _ := processor.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+		if _, exists := updatedNs.Annotations[GracePeriodAnnotation]; !exists {
+			t.Error("Grace period annotation should be added")
+		}
+	})
+
+	t.Run("grace period override", func(t *testing.T) {
+		// Marked two hours ago: beyond the override, within the regular grace period
+		marked := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "expired",
+			Annotations: map[string]string{
+				OwnerAnnotation:       "user@external.org",
+				GracePeriodAnnotation: marked,
+			},
+		}}
+		processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+		processor.SetInvalidDomainPolicy(OwnerPolicyExpire, time.Hour)
+
+		tr := processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+		if tr.Action != ActionDelete {
+			t.Errorf("Expected delete after override grace period, got %q", tr.Action)
+		}
+		if processor.gracePeriod != 24*time.Hour {
+			t.Errorf("Override must not change the regular grace period, got %s", processor.gracePeriod)
+		}
+	})
+}
+
+// TestOwnerlessPolicy validates marking of namespaces without an owner
+func TestOwnerlessPolicy(t *testing.T) {
+	t.Run("skip by default", func(t *testing.T) {
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "orphan"}}
+		processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+
+		tr := processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+		if tr.Action != ActionSkip {
+			t.Errorf("Expected skip, got %q", tr.Action)
+		}
+	})
+
+	t.Run("skip clears stale marker", func(t *testing.T) {
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "orphan",
+			Annotations: map[string]string{
+				GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+				RunIDAnnotation:       "20200101T000000Z-abcdef",
+			},
+		}}
+		processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+
+		tr := processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+		if tr.Action != ActionUnmark {
+			t.Errorf("Expected unmark, got %q", tr.Action)
+		}
+		updatedNs, _ := processor.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+		if len(updatedNs.Annotations) != 0 {
+			t.Errorf("Marker annotations should be removed: %v", updatedNs.Annotations)
+		}
+	})
+
+	t.Run("expire marks and notifies once", func(t *testing.T) {
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "orphan"}}
+		notifier := &recordingNotifier{}
+		processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+		processor.SetOwnerlessPolicy(OwnerPolicyExpire, 0)
+		processor.SetNotifier(notifier)
+
+		tr := processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+		if tr.Action != ActionMark {
+			t.Errorf("Expected mark, got %q", tr.Action)
+		}
+		if len(notifier.sent) != 1 || notifier.sent[0].Event != EventOwnerlessMarked || notifier.sent[0].Namespace != "orphan" {
+			t.Fatalf("Expected one ownerless notification, got %+v", notifier.sent)
+		}
+
+		updatedNs, _ := processor.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+		tr = processor.ProcessNamespaceTraced(context.TODO(), *updatedNs)
+		if tr.Action != ActionWait {
+			t.Errorf("Expected wait on second pass, got %q", tr.Action)
+		}
+		if len(notifier.sent) != 1 {
+			t.Errorf("Already marked namespace should not notify again, got %+v", notifier.sent)
+		}
+	})
+
+	t.Run("grace period override", func(t *testing.T) {
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "orphan",
+			Annotations: map[string]string{GracePeriodAnnotation: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)},
+		}}
+		processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+		processor.SetOwnerlessPolicy(OwnerPolicyExpire, time.Hour)
+
+		tr := processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+		if tr.Action != ActionDelete {
+			t.Errorf("Expected delete after override grace period, got %q", tr.Action)
+		}
+	})
+}
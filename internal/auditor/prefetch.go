@@ -0,0 +1,238 @@
+package auditor
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultPrefetchConcurrency is the number of identity lookups run in
+// parallel during prefetch unless configured otherwise.
+const DefaultPrefetchConcurrency = 8
+
+// lookupResult is the outcome of a prefetched identity lookup
+type lookupResult struct {
+	state  identity.UserState
+	status int                 // Raw lookup status, 0 if the checker does not report one
+	source identity.Provenance // Where the verdict came from
+}
+
+// BatchChecker is optionally implemented by UserExistenceChecker
+// implementations able to look up many users in one request. Users whose
+// lookup failed are absent from the returned map, alongside the first error.
+type BatchChecker interface {
+	UsersExist(ctx context.Context, emails []string) (map[string]bool, error)
+}
+
+// SetPrefetch configures the identity prefetch phase. concurrency bounds the
+// number of parallel lookups; ratePerSecond caps the lookup rate, zero
+// meaning unlimited.
+func (p *NamespaceProcessor) SetPrefetch(concurrency int, ratePerSecond float64) {
+	p.prefetchConcurrency = concurrency
+	p.prefetchRate = ratePerSecond
+}
+
+// Prefetch resolves the distinct owner emails of the given namespaces
+// concurrently, so that the following mutation phase does not wait on the
+// identity provider. Owners with disallowed domains are not looked up.
+// Failed lookups are not cached and are retried when the namespace is
+// processed. Owners with a cached lookup are not looked up again, see
+// SetLookupCache. Checkers implementing BatchChecker are asked in bulk first,
+// see prefetchBatch. Too many missing owners abort the run, see
+// SetMissingOwnerBreaker.
+func (p *NamespaceProcessor) Prefetch(ctx context.Context, namespaces []corev1.Namespace) {
+	emails := p.distinctOwners(ctx, p.uncached(ctx, namespaces, time.Now()))
+	if len(emails) == 0 {
+		return
+	}
+	total := len(emails)
+	start := time.Now()
+	resolved := make(map[string]lookupResult, len(emails))
+	emails = p.fromCache(emails, resolved, start)
+	if checker, ok := p.batchChecker(); ok && len(emails) > 0 {
+		emails = p.prefetchBatch(ctx, checker, emails, resolved)
+	}
+
+	concurrency := p.prefetchConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultPrefetchConcurrency
+	}
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if p.prefetchRate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(p.prefetchRate), 1)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	work := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for email := range work {
+				if err := limiter.Wait(ctx); err != nil {
+					continue
+				}
+				if _, ok := p.throttle.active(time.Now()); ok {
+					continue // Backing off, the mutation phase defers the namespace
+				}
+				if !p.budget.take() {
+					continue // Left for the mutation phase, which defers the namespace
+				}
+				if p.Aborted() != nil {
+					continue
+				}
+				result, err := p.resolveUser(ctx, email)
+				if p.checkAbort(err) {
+					log.Printf("Aborting prefetch: %v", err)
+					continue
+				}
+				if err != nil {
+					log.Printf("Prefetch of user %s failed: %v", email, err)
+					continue
+				}
+				p.lookups.put(email, result, time.Now())
+				mu.Lock()
+				resolved[email] = result
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, email := range emails {
+		work <- email
+	}
+	close(work)
+	wg.Wait()
+
+	p.resolved = resolved
+	log.Printf("Prefetched %d of %d owner identities in %s", len(resolved), total, time.Since(start).Round(time.Millisecond))
+	p.checkMissingOwners(ctx, resolved)
+}
+
+// batchChecker returns the checker as a BatchChecker, unless batched
+// existence checks would lose the disabled-account state the disabled-user
+// policy acts on.
+func (p *NamespaceProcessor) batchChecker() (BatchChecker, bool) {
+	checker, ok := p.azureClient.(BatchChecker)
+	if !ok {
+		return nil, false
+	}
+	if _, reportsState := p.azureClient.(UserStateReporter); reportsState && p.disabledUserPolicy == OwnerPolicyExpire {
+		return nil, false
+	}
+	return checker, true
+}
+
+// prefetchBatch resolves emails in bulk, adding the results to resolved. It
+// returns the emails that still need an individual lookup: owners reported
+// missing, when the checker can tell soft-deleted accounts apart. Each email
+// counts against the lookup budget; those beyond it are left for the
+// mutation phase, which defers their namespaces.
+func (p *NamespaceProcessor) prefetchBatch(ctx context.Context, checker BatchChecker, emails []string, resolved map[string]lookupResult) []string {
+	if _, ok := p.throttle.active(time.Now()); ok {
+		return nil // Backing off, the mutation phase defers the namespaces
+	}
+	var batch []string
+	for _, email := range emails {
+		if !p.budget.take() {
+			break
+		}
+		batch = append(batch, email)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	found, err := checker.UsersExist(ctx, batch)
+	p.throttle.observe(err, time.Now())
+	if p.checkAbort(err) {
+		log.Printf("Aborting prefetch: %v", err)
+		return nil
+	}
+	if err != nil {
+		log.Printf("Batched prefetch resolved %d of %d users: %v", len(found), len(batch), err)
+	}
+
+	_, reportsState := p.azureClient.(UserStateReporter)
+	var missing []string
+	for _, email := range batch {
+		exists, ok := found[email]
+		switch {
+		case !ok:
+			// Failed, retried when the namespace is processed
+		case !exists && reportsState:
+			missing = append(missing, email)
+		default:
+			resolved[email] = lookupResult{state: stateOf(exists), source: p.defaultProvenance()}
+			p.lookups.put(email, resolved[email], time.Now())
+		}
+	}
+	return missing
+}
+
+// fromCache adds the cached lookups of emails to resolved and returns the
+// emails that still need looking up.
+func (p *NamespaceProcessor) fromCache(emails []string, resolved map[string]lookupResult, now time.Time) []string {
+	if p.lookups == nil {
+		return emails
+	}
+	var uncached []string
+	for _, email := range emails {
+		if r, ok := p.lookups.get(email, now); ok {
+			resolved[email] = r
+			continue
+		}
+		uncached = append(uncached, email)
+	}
+	return uncached
+}
+
+// resolveUser performs a single identity lookup, including the account state
+// or raw status when the checker is able to report them.
+func (p *NamespaceProcessor) resolveUser(ctx context.Context, email string) (lookupResult, error) {
+	if pr, ok := p.azureClient.(ProvenanceReporter); ok {
+		state, source, err := pr.UserStateWithProvenance(ctx, email)
+		p.throttle.observe(err, time.Now())
+		return lookupResult{state: state, source: source}, err
+	}
+	if sr, ok := p.azureClient.(UserStateReporter); ok {
+		state, err := sr.UserState(ctx, email)
+		p.throttle.observe(err, time.Now())
+		return lookupResult{state: state, source: p.defaultProvenance()}, err
+	}
+	if sr, ok := p.azureClient.(StatusReporter); ok {
+		exists, status, err := sr.UserExistsWithStatus(ctx, email)
+		p.throttle.observe(err, time.Now())
+		return lookupResult{state: stateOf(exists), status: status, source: p.defaultProvenance()}, err
+	}
+	exists, err := p.azureClient.UserExists(ctx, email)
+	p.throttle.observe(err, time.Now())
+	return lookupResult{state: stateOf(exists), source: p.defaultProvenance()}, err
+}
+
+// distinctOwners returns the unique owner emails with allowed domains that
+// need a lookup, in order of first appearance. Allowlisted owners, those on
+// the owner list and owners of namespaces excluded by name never do.
+func (p *NamespaceProcessor) distinctOwners(ctx context.Context, namespaces []corev1.Namespace) []string {
+	seen := make(map[string]bool)
+	var emails []string
+	for _, ns := range namespaces {
+		if _, excluded := p.excludedByName(ns.Name); excluded {
+			continue
+		}
+		email := p.withProfileOwner(ctx, ns).Annotations[OwnerAnnotation]
+		allowedDomains := p.withAuditPolicy(ns).allowedDomains
+		if email == "" || seen[email] || !isValidDomain(email, allowedDomains) || p.allowlisted(email) ||
+			p.ownerList.Verdict(email) != OwnerUnlisted {
+			continue
+		}
+		seen[email] = true
+		emails = append(emails, email)
+	}
+	return emails
+}
@@ -0,0 +1,166 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// countingChecker records concurrent and total identity lookups
+type countingChecker struct {
+	mu       sync.Mutex
+	calls    map[string]int
+	active   int32
+	peak     int32
+	existing map[string]bool
+}
+
+func (c *countingChecker) UserExists(_ context.Context, email string) (bool, error) {
+	n := atomic.AddInt32(&c.active, 1)
+	defer atomic.AddInt32(&c.active, -1)
+	for {
+		peak := atomic.LoadInt32(&c.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&c.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[email]++
+	return c.existing[email], nil
+}
+
+// ownedNamespace builds a namespace owned by the given email
+func ownedNamespace(name, owner string) corev1.Namespace {
+	return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Annotations: map[string]string{OwnerAnnotation: owner},
+	}}
+}
+
+// TestDistinctOwners validates owner deduplication, domain and allowlist filtering
+func TestDistinctOwners(t *testing.T) {
+	namespaces := []corev1.Namespace{
+		ownedNamespace("a", "one@example.com"),
+		ownedNamespace("b", "two@example.com"),
+		ownedNamespace("c", "one@example.com"),
+		ownedNamespace("d", "three@external.org"),
+		{ObjectMeta: metav1.ObjectMeta{Name: "e"}},
+		ownedNamespace("f", "Bot@Example.com"),
+	}
+	processor := newTestProcessor(true, nil, false)
+	processor.SetOwnerAllowlist([]string{"bot@example.com"})
+	got := processor.distinctOwners(context.TODO(), namespaces)
+	if want := []string{"one@example.com", "two@example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("distinctOwners() = %v, want %v", got, want)
+	}
+}
+
+// TestPrefetch validates that each owner is resolved once, concurrently,
+// and that processing uses the prefetched results
+func TestPrefetch(t *testing.T) {
+	checker := &countingChecker{calls: map[string]int{}, existing: map[string]bool{"keep@example.com": true}}
+	var namespaces []corev1.Namespace
+	for i, owner := range []string{"keep@example.com", "gone@example.com", "a@example.com", "b@example.com", "keep@example.com"} {
+		namespaces = append(namespaces, ownedNamespace(string(rune('a'+i)), owner))
+	}
+
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+	processor.SetPrefetch(4, 0)
+	captureLogs(func() {
+		processor.Prefetch(context.TODO(), namespaces)
+	})
+
+	if len(checker.calls) != 4 {
+		t.Fatalf("Expected 4 distinct lookups, got %v", checker.calls)
+	}
+	if checker.peak < 2 {
+		t.Errorf("Expected concurrent lookups, peak was %d", checker.peak)
+	}
+
+	for _, ns := range namespaces {
+		processor.ProcessNamespaceTraced(context.TODO(), ns)
+	}
+	for email, n := range checker.calls {
+		if n != 1 {
+			t.Errorf("User %s looked up %d times, expected once", email, n)
+		}
+	}
+}
+
+// TestPrefetchRateLimit validates that the lookup rate is capped
+func TestPrefetchRateLimit(t *testing.T) {
+	checker := &countingChecker{calls: map[string]int{}}
+	namespaces := []corev1.Namespace{
+		ownedNamespace("a", "a@example.com"),
+		ownedNamespace("b", "b@example.com"),
+		ownedNamespace("c", "c@example.com"),
+	}
+
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+	processor.SetPrefetch(3, 20)
+
+	start := time.Now()
+	captureLogs(func() {
+		processor.Prefetch(context.TODO(), namespaces)
+	})
+	// Burst of one at 20/s: the third lookup waits at least 2 * 50ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Rate limit not applied, prefetch took %s", elapsed)
+	}
+}
+
+// batchChecker answers lookups in bulk, recording the batches it was asked
+type batchChecker struct {
+	countingChecker
+	batches [][]string
+}
+
+func (c *batchChecker) UsersExist(_ context.Context, emails []string) (map[string]bool, error) {
+	c.batches = append(c.batches, emails)
+	found := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		found[email] = c.existing[email]
+	}
+	return found, nil
+}
+
+// TestPrefetchBatch validates that owners are resolved in bulk when the
+// checker supports it, without individual lookups
+func TestPrefetchBatch(t *testing.T) {
+	checker := &batchChecker{countingChecker: countingChecker{calls: map[string]int{}, existing: map[string]bool{"keep@example.com": true}}}
+	namespaces := []corev1.Namespace{
+		ownedNamespace("a", "keep@example.com"),
+		ownedNamespace("b", "gone@example.com"),
+		ownedNamespace("c", "keep@example.com"),
+	}
+
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+	captureLogs(func() {
+		processor.Prefetch(context.TODO(), namespaces)
+		for _, ns := range namespaces {
+			processor.ProcessNamespaceTraced(context.TODO(), ns)
+		}
+	})
+
+	if want := [][]string{{"keep@example.com", "gone@example.com"}}; !reflect.DeepEqual(checker.batches, want) {
+		t.Errorf("Batches = %v, want %v", checker.batches, want)
+	}
+	if len(checker.calls) != 0 {
+		t.Errorf("Expected no individual lookups, got %v", checker.calls)
+	}
+	if got := processor.resolved["gone@example.com"].state; got != stateOf(false) {
+		t.Errorf("gone@example.com resolved as %q, want %q", got, stateOf(false))
+	}
+}
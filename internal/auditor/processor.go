@@ -3,11 +3,14 @@ package auditor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
-	"strings"
 	"time"
 
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -16,16 +19,122 @@ import (
 // including validation, grace period enforcement, and cleanup.
 type NamespaceProcessor struct {
 	k8sClient      kubernetes.Interface // Kubernetes API client
+	mutationClient kubernetes.Interface // Client for changes, k8sClient if nil
 	azureClient    UserExistenceChecker // User validation client
 	gracePeriod    time.Duration        // Allowed grace period duration
+	grace          GracePeriodStrategy  // How long marked namespaces are kept, the fixed gracePeriod if nil
 	allowedDomains []string             // Permitted email domains
 	dryRun         bool                 // Safety flag to prevent mutations
+	trace          *Trace               // Decision trace being recorded, nil unless tracing
+	runID          string               // ID of the current audit run
+	configHash     string               // Hash of the effective configuration
+	clockSkew      time.Duration        // Extra allowance before acting on an expired marker
+	pauseWindows   []PauseWindow        // Periods during which grace periods are frozen
+	apiStats       *APIStats            // Kubernetes API usage of this processor
+	expiryAction   ExpiryAction         // What to do once the grace period expires
+
+	invalidDomainPolicy OwnerPolicy   // Handling of owners with disallowed domains
+	invalidDomainGrace  time.Duration // Grace period override for disallowed domains
+	ownerlessPolicy     OwnerPolicy   // Handling of namespaces without an owner annotation
+	ownerlessGrace      time.Duration // Grace period override for ownerless namespaces
+	disabledUserPolicy  OwnerPolicy   // Handling of disabled owners, when the checker reports user states
+	disabledUserGrace   time.Duration // Grace period override for disabled owners
+	deletedUserGrace    time.Duration // Grace period override for soft-deleted owners
+	notifier            Notifier      // Destination of administrator notifications
+	prefetchConcurrency int           // Parallel identity lookups during prefetch
+	prefetchRate        float64       // Identity lookups per second during prefetch, 0 for unlimited
+	missingOwnerPercent float64       // Share of missing owners aborting a run, 0 to disable
+	missingOwnerMinimum int           // Resolved owners needed before the missing-owner breaker trips
+
+	auditPolicies    []AuditPolicy // NamespaceAuditPolicy objects, in order of precedence
+	policyGeneration string        // Names and generations of auditPolicies
+	auditPolicy      *AuditPolicy  // Policy applied to the namespace being processed, nil for none
+
+	preDeleteFinalizer bool          // Hold deleted namespaces until pre-delete steps complete
+	preDeleteTimeout   time.Duration // Longest a namespace is held by the finalizer
+	preDeleteSteps     []namedStep   // Additional steps run before the finalizer is released
+
+	propagationPolicy metav1.DeletionPropagation // Propagation policy for namespace deletion, empty for server default
+	deletionWait      time.Duration              // How long to wait for deleted namespaces to terminate, 0 to not wait
+	stuck             *collector[StuckNamespace] // Namespaces found stuck terminating
+	stuckThreshold    time.Duration              // How long a deleted namespace may terminate before it is stuck
+	forceFinalize     bool                       // Remove blocking finalizers from stuck namespaces
+
+	resolved map[string]lookupResult     // Identity lookups resolved by Prefetch
+	lookups  *lookupCache                // Identity lookups reused across namespaces, nil to always look up
+	budget   *runBudget                  // Cap on identity lookups per run, nil for unlimited
+	abort    *abortState                 // Fatal error ending the run early
+	marked   *collector[MarkedNamespace] // Namespaces found marked during the run
+	saved    *collector[SavedNamespace]  // Namespaces unmarked because their owner is valid again
+	planned  *collector[PlannedChange]   // Changes recorded instead of applied in a dry run
+	foreign  *collector[ForeignMarker]   // Deletion markers not written by the auditor
+	deferred *collector[string]          // Namespaces left for the next run
+	throttle *throttleTracker            // Identity-provider backoff, nil to never back off
+	changed  *collector[string]          // Namespaces changed by others since they were read
+
+	expiredExemptions *collector[ExpiredExemption] // Exemptions found expired during the run
+	malformed         *collector[MalformedMarker]  // Unreadable deletion markers found during the run
+	malformedAction   MalformedMarkerAction        // Handling of unreadable deletion markers, reset if empty
+	malformedMaxAge   time.Duration                // How long a marker may stay malformed with MalformedMarkerExpire, the grace period if zero
+
+	flapDamping          int                           // Consecutive runs a change on a flapping namespace must persist
+	missingConfirmations int                           // Consecutive runs an owner must be missing before marking
+	flapping             *collector[FlappingNamespace] // Flapping namespaces seen during the run
+	evaluations          *evaluationCache              // Valid-owner evaluations carried between runs, nil to always evaluate
+
+	classificationLabel string                     // Label holding a namespace's data classification
+	sensitive           map[string]SensitivePolicy // Policies by data classification
+	awaiting            *collector[string]         // Expired namespaces waiting for deletion approval
+	twoPersonThreshold  resource.Quantity          // Requested storage above which deletion needs two approvals, zero to disable
+
+	allowlist map[string]bool // Normalized owner emails always treated as valid
+	ownerList *OwnerList      // Owners always treated as valid or invalid, nil for none
+
+	linkSuffixes []string // Name suffixes linking a namespace to another, see SetLinkSuffixes
+	links        *linkSet // Companion namespaces of the run, nil before Link
+	contextKeys  []string // Labels or annotations included as context in notifications and reports
+	canary       *Canary  // Synthetic canary namespace checked by RunCanary, nil for none
+	slowStart    int      // Percentage of namespaces processed in this run, all if zero
+
+	graceOverrideMax time.Duration  // Longest grace period a namespace may set for itself, zero to ignore overrides
+	profiles         *profileOwners // Owners of Kubeflow profiles, nil to read owner annotations only
+	providerName     string         // Identity provider named in verdict provenance, see SetProviderName
+
+	recordEvents bool             // Record Kubernetes Events on lifecycle actions, see SetEventRecording
+	clock        func() time.Time // Source of the current time for decisions, time.Now if nil
+
+	sliceIndex int // Slice of namespaces processed by this run, see SetSlice
+	sliceCount int // Number of slices, all namespaces are processed if 1 or less
+
+	reminders bool // Remind owners halfway through the grace period and before deletion, see SetReminders
+	escalate  bool // Record and notify the manager of owners of marked namespaces, see SetManagerEscalation
+
+	orphanOwner  string        // Owner of namespaces orphaned by ExpiryReassign, empty to keep theirs
+	orphanPeriod time.Duration // How long namespaces stay orphaned before deletion, DefaultOrphanPeriod if zero
+
+	quarantinePeriod time.Duration // How long expired namespaces are quarantined before deletion, none if zero
+
+	include []NamePattern // Names of the namespaces audited, all if empty, see SetNameFilter
+	exclude []NamePattern // Names of namespaces never touched
+
+	deletions  *runBudget                 // Cap on deletions per run, nil for unlimited, see SetChangeBudgets
+	marks      *runBudget                 // Cap on marks per run, nil for unlimited
+	overBudget *collector[BudgetDeferral] // Namespaces deferred by the change budgets
+
+	backup    Backuper          // Archives namespaces before deletion, nil to delete without a backup
+	snapshots VolumeSnapshotter // Snapshots volumes before deletion, nil to delete without snapshots
 }
 
 // UserExistenceChecker defines the interface for validating user existence
-// in external identity systems (e.g., Azure AD).
-type UserExistenceChecker interface {
-	UserExists(ctx context.Context, email string) (bool, error)
+// in external identity systems (e.g., Azure AD). Any registered
+// identity.Provider satisfies it.
+type UserExistenceChecker = identity.Provider
+
+// StatusReporter is optionally implemented by UserExistenceChecker
+// implementations that can expose the raw response status of a lookup.
+// It is used when explaining a decision.
+type StatusReporter interface {
+	UserExistsWithStatus(ctx context.Context, email string) (bool, int, error)
 }
 
 // NewNamespaceProcessor creates a new processor instance with configured dependencies.
@@ -33,23 +142,56 @@ type UserExistenceChecker interface {
 // Parameters:
 // - k8sClient: Kubernetes client for API interactions
 // - azureClient: User validation client implementation
-// - gracePeriod: Duration before deleting unclaimed namespaces
-// - allowedDomains: List of permitted email domains
-// - dryRun: Safety mode flag to disable mutations
+// - opts: Optional settings such as WithGracePeriod, WithAllowedDomains and WithDryRun
 func NewNamespaceProcessor(
 	k8sClient kubernetes.Interface,
 	azureClient UserExistenceChecker,
-	gracePeriod time.Duration,
-	allowedDomains []string,
-	dryRun bool,
+	opts ...Option,
 ) *NamespaceProcessor {
-	return &NamespaceProcessor{
-		k8sClient:      k8sClient,
-		azureClient:    azureClient,
-		gracePeriod:    gracePeriod,
-		allowedDomains: allowedDomains,
-		dryRun:         dryRun,
+	p := &NamespaceProcessor{
+		k8sClient:   k8sClient,
+		azureClient: azureClient,
+		throttle:    &throttleTracker{},
+	}
+	p.resetRun()
+	for _, opt := range opts {
+		opt(p)
 	}
+	return p
+}
+
+// resetRun gives the processor empty collectors of its own for the results
+// of a run
+func (p *NamespaceProcessor) resetRun() {
+	p.apiStats = &APIStats{}
+	p.stuck = &collector[StuckNamespace]{}
+	p.abort = &abortState{}
+	p.marked = &collector[MarkedNamespace]{}
+	p.saved = &collector[SavedNamespace]{}
+	p.planned = &collector[PlannedChange]{}
+	p.foreign = &collector[ForeignMarker]{}
+	p.deferred = &collector[string]{}
+	p.flapping = &collector[FlappingNamespace]{}
+	p.awaiting = &collector[string]{}
+	p.changed = &collector[string]{}
+	p.expiredExemptions = &collector[ExpiredExemption]{}
+	p.malformed = &collector[MalformedMarker]{}
+	p.overBudget = &collector[BudgetDeferral]{}
+}
+
+// forComparison returns a copy of the processor whose evaluations are not
+// counted in the run of p: it has collectors, identity lookups and budgets
+// of its own and no evaluation cache, see ComparePolicies.
+func (p *NamespaceProcessor) forComparison() *NamespaceProcessor {
+	q := *p
+	q.resetRun()
+	q.resolved = nil
+	q.evaluations = nil
+	q.lookups = p.lookups.renewed()
+	q.budget = p.budget.renewed()
+	q.deletions = p.deletions.renewed()
+	q.marks = p.marks.renewed()
+	return &q
 }
 
 // GetClient provides access to the Kubernetes client for testing purposes.
@@ -57,164 +199,526 @@ func (p *NamespaceProcessor) GetClient() kubernetes.Interface {
 	return p.k8sClient
 }
 
+// SetRunInfo configures the run ID and configuration hash stamped alongside
+// every deletion marker this processor adds or refreshes.
+func (p *NamespaceProcessor) SetRunInfo(runID, configHash string) {
+	p.runID = runID
+	p.configHash = configHash
+}
+
+// SetBusinessDayGracePeriod expresses the grace period in business days of
+// the given work week instead of a fixed duration, so that weekends and other
+// non-working days do not consume the grace period.
+func (p *NamespaceProcessor) SetBusinessDayGracePeriod(days int, week WorkWeek) {
+	p.grace = BusinessDayGracePeriod{Days: days, Week: week}
+}
+
+// SetPauseWindows configures periods during which grace-period countdowns are
+// frozen. Time spent inside a window is added to each marker's grace period.
+func (p *NamespaceProcessor) SetPauseWindows(windows []PauseWindow) {
+	p.pauseWindows = windows
+}
+
+// RunInfo returns the run ID and configuration hash set by SetRunInfo.
+func (p *NamespaceProcessor) RunInfo() (runID, configHash string) {
+	return p.runID, p.configHash
+}
+
+// SetClockSkew configures an additional allowance added to the grace period
+// before an expired marker is acted on, tolerating clock differences between
+// the auditor and whatever wrote the marker.
+func (p *NamespaceProcessor) SetClockSkew(skew time.Duration) {
+	p.clockSkew = skew
+}
+
 // ListNamespaces retrieves namespaces matching the specified label selector.
 //
 // Parameters:
 // - ctx: Context for cancellation and timeouts
 // - labelSelector: Kubernetes label selector syntax string
 func (p *NamespaceProcessor) ListNamespaces(ctx context.Context, labelSelector string) (*corev1.NamespaceList, error) {
-	return p.k8sClient.CoreV1().Namespaces().List(
+	start := time.Now()
+	list, err := p.k8sClient.CoreV1().Namespaces().List(
 		ctx,
 		metav1.ListOptions{LabelSelector: labelSelector},
 	)
+	p.apiStats.observe(OpList, start, err)
+	return list, err
 }
 
-// ProcessNamespace executes the complete namespace audit workflow:
+// GetNamespace retrieves a single namespace by name.
+func (p *NamespaceProcessor) GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	start := time.Now()
+	ns, err := p.k8sClient.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	p.apiStats.observe(OpGet, start, err)
+	return ns, err
+}
+
+// ProcessNamespace executes the complete namespace audit workflow and
+// returns the resulting decision:
+// 0. Pending pre-delete steps and ownership claims
 // 1. Owner annotation validation
 // 2. Domain permission check
 // 3. User existence verification
 // 4. Grace period enforcement
-func (p *NamespaceProcessor) ProcessNamespace(ctx context.Context, ns corev1.Namespace) {
+func (p *NamespaceProcessor) ProcessNamespace(ctx context.Context, ns corev1.Namespace) Decision {
+	p = p.withAuditPolicy(ns)
+	tr := p.trace
+	if tr == nil {
+		tr = &Trace{Namespace: ns.Name}
+		recorder := *p
+		recorder.trace = tr
+		p = &recorder
+	}
+	if p.auditPolicy != nil {
+		p.trace.add("audit-policy", "following NamespaceAuditPolicy %s", p.auditPolicy.Name)
+	}
+	p.process(ctx, ns)
+	return tr.Decision()
+}
+
+// process evaluates a namespace and applies the decision, see ProcessNamespace
+func (p *NamespaceProcessor) process(ctx context.Context, ns corev1.Namespace) {
+	if reason, excluded := p.excludedByName(ns.Name); excluded {
+		log.Printf("Skipping %s: excluded by policy, %s", ns.Name, reason)
+		p.trace.add("name-filter", "%s", reason)
+		p.trace.setAction(ActionExcluded)
+		return
+	}
+
+	if err := p.Aborted(); err != nil {
+		p.trace.add("abort", "run aborted: %v", err)
+		p.trace.fail(err)
+		return
+	}
+
+	if p.canary != nil && ns.Name == p.canary.Namespace {
+		p.trace.add("canary", "synthetic canary namespace, checked separately")
+		p.trace.setAction(ActionSkip)
+		return
+	}
+
+	if ns.DeletionTimestamp != nil {
+		p.handleTerminating(ctx, ns)
+		return
+	}
+
+	if primary, linked := p.links.primaryOf(ns.Name); linked {
+		p.trace.add("link", "linked to %s, follows its decisions", primary)
+		p.trace.setAction(ActionLinked)
+		return
+	}
+
+	if p.slicedOut(ns) {
+		p.trace.add("slice", "in slice %d of %d, this run processes slice %d", sliceOf(ns.Name, p.sliceCount)+1, p.sliceCount, p.sliceIndex+1)
+		p.trace.setAction(ActionSliced)
+		return
+	}
+
+	if p.rampedOut(ns) {
+		p.trace.add("slow-start", "outside the %d%% of namespaces processed while ramping up", p.slowStart)
+		p.trace.setAction(ActionRamp)
+		return
+	}
+
+	ns = p.withProfileOwner(ctx, ns)
+	p.liftQuarantine(ctx, &ns)
+
+	if claimant, claimed := ns.Annotations[ClaimAnnotation]; claimed && p.handleClaim(ctx, ns, claimant) {
+		return
+	}
+
+	if p.handleExemption(ctx, ns, p.now()) {
+		return
+	}
+
+	if p.handleOrphaned(&ns) {
+		return
+	}
+
 	email, exists := ns.Annotations[OwnerAnnotation]
 	if !exists || email == "" {
+		p.trace.add("owner", "no %q annotation", OwnerAnnotation)
+		if p.ownerlessPolicy == OwnerPolicyExpire {
+			p.handleOwnerless(ns)
+			return
+		}
+		if _, marked := ns.Annotations[GracePeriodAnnotation]; marked {
+			p.clearStaleMarker(ns)
+			return
+		}
 		log.Printf("Skipping %s: missing owner annotation", ns.Name)
+		p.trace.setAction(ActionSkip)
+		return
+	}
+	p.trace.add("owner", "owner annotation is %q", email)
+	p.trace.setOwner(email)
+
+	switch p.ownerList.Verdict(email) {
+	case OwnerAlwaysValid:
+		p.trace.add("owner-list", "%q is listed as valid, treated as valid without a lookup", email)
+		p.trace.setIdentity(IdentityListedValid)
+		p.handleValidUser(ns)
+		return
+	case OwnerAlwaysInvalid:
+		p.trace.add("owner-list", "%q is listed as invalid, treated as not found without a lookup", email)
+		p.trace.setIdentity(IdentityListedInvalid)
+		log.Printf("Owner %s of %s is listed as invalid", email, ns.Name)
+		p.handleInvalidUser(ns)
+		return
+	}
+
+	if e, ok := p.evaluations.fresh(ns, p.evaluationScope(), p.now()); ok {
+		p.trace.add("cache", "unchanged (resourceVersion %s) since owner was confirmed valid at %s",
+			e.ResourceVersion, formatMarkerTime(e.EvaluatedAt))
+		p.trace.setAction(ActionCached)
+		p.trace.setIdentity(IdentityCached)
+		p.evaluations.hit(ns.Name, e)
+		return
+	}
+
+	if p.allowlisted(email) {
+		p.trace.add("allowlist", "%q is allowlisted, treated as valid without a lookup", email)
+		p.trace.setIdentity(IdentityAllowlisted)
+		p.handleValidUser(ns)
 		return
 	}
 
 	if !isValidDomain(email, p.allowedDomains) {
+		p.trace.add("domain", "domain of %q not in allowed domains %v", email, p.allowedDomains)
+		p.trace.setDomain(false)
+		if p.invalidDomainPolicy == OwnerPolicyExpire {
+			p.trace.add("policy", "invalid-domain policy %q, grace period override %s", p.invalidDomainPolicy, p.invalidDomainGrace)
+			log.Printf("Treating %s as unowned: invalid domain for email %s", ns.Name, email)
+			p.withGracePeriod(p.invalidDomainGrace).handleInvalidUser(ns)
+			return
+		}
 		log.Printf("Skipping %s: invalid domain for email %s", ns.Name, email)
+		p.trace.setAction(ActionSkip)
 		return
 	}
+	p.trace.add("domain", "domain of %q is allowed", email)
+	p.trace.setDomain(true)
 
-	existsInAzure, err := p.azureClient.UserExists(ctx, email)
+	state, err := p.lookupUser(ctx, email)
+	if err != nil {
+		p.trace.setIdentity(IdentityError)
+	}
+	if errors.Is(err, ErrLookupBudgetExhausted) || errors.Is(err, ErrIdentityBackoff) {
+		p.deferNamespace(ns.Name, err)
+		return
+	}
+	if p.checkAbort(err) {
+		log.Printf("Aborting run: %v", err)
+		p.trace.fail(err)
+		return
+	}
 	if err != nil {
 		log.Printf("Error checking user %s: %v", email, err)
+		p.trace.fail(err)
 		return
 	}
 
-	if existsInAzure {
-		p.handleValidUser(ns)
-	} else {
-		p.handleInvalidUser(ns)
+	p.trace.setIdentity(string(state))
+	p.handleUserState(ns, email, state)
+}
+
+// ProcessNamespaceTraced behaves exactly like ProcessNamespace but also
+// records and returns the full decision trace, including the policy
+// values that applied.
+func (p *NamespaceProcessor) ProcessNamespaceTraced(ctx context.Context, ns corev1.Namespace) *Trace {
+	p = p.withAuditPolicy(ns)
+	tr := &Trace{Namespace: ns.Name, Context: p.namespaceContext(ns)}
+	tr.add("policy", "grace period %s, clock skew %s, pause windows %v, expiry action %s, allowed domains %v, dry-run %t",
+		p.describeGracePeriod(ns), p.clockSkew, p.pauseWindows, p.expiryActionOrDefault(), p.allowedDomains, p.dryRun)
+
+	tracer := *p
+	tracer.trace = tr
+	tracer.ProcessNamespace(ctx, ns)
+	return tr
+}
+
+// Explain evaluates a namespace without modifying it and returns the full
+// decision trace.
+func (p *NamespaceProcessor) Explain(ctx context.Context, ns corev1.Namespace) *Trace {
+	explainer := *p
+	explainer.dryRun = true
+	explainer.planned = nil // Explanations are not part of the run's planned changes
+	return explainer.ProcessNamespaceTraced(ctx, *ns.DeepCopy())
+}
+
+// lookupUser checks the account state of a user, preferring results
+// resolved by Prefetch or cached from an earlier lookup, and recording the
+// raw lookup status in the trace when the checker is able to report it.
+func (p *NamespaceProcessor) lookupUser(ctx context.Context, email string) (identity.UserState, error) {
+	if r, ok := p.resolved[email]; ok {
+		p.trace.add("identity", "prefetched lookup of %q returned state %s (status %d)%s", email, r.state, r.status, fromSource(r.source))
+		p.trace.setSource(r.source)
+		return r.state, nil
+	}
+	if r, ok := p.lookups.get(email, time.Now()); ok {
+		p.trace.add("identity", "cached lookup of %q returned state %s (status %d)%s", email, r.state, r.status, fromSource(r.source))
+		p.trace.setSource(r.source)
+		return r.state, nil
+	}
+
+	if until, ok := p.throttle.active(time.Now()); ok {
+		return "", fmt.Errorf("%w until %s", ErrIdentityBackoff, formatMarkerTime(until))
+	}
+	if !p.budget.take() {
+		return "", fmt.Errorf("%w (limit %d)", ErrLookupBudgetExhausted, p.budget.limit)
+	}
+
+	if pr, ok := p.azureClient.(ProvenanceReporter); ok {
+		state, source, err := pr.UserStateWithProvenance(ctx, email)
+		p.throttle.observe(err, time.Now())
+		if err != nil {
+			p.trace.add("identity", "lookup of %q failed: %v", email, err)
+			return "", err
+		}
+		p.trace.add("identity", "lookup of %q returned state %s%s", email, state, fromSource(source))
+		p.trace.setSource(source)
+		p.lookups.put(email, lookupResult{state: state, source: source}, time.Now())
+		return state, nil
+	}
+
+	source := p.defaultProvenance()
+	if sr, ok := p.azureClient.(UserStateReporter); ok {
+		state, err := sr.UserState(ctx, email)
+		p.throttle.observe(err, time.Now())
+		if err != nil {
+			p.trace.add("identity", "lookup of %q failed: %v", email, err)
+			return "", err
+		}
+		p.trace.add("identity", "lookup of %q returned state %s", email, state)
+		p.trace.setSource(source)
+		p.lookups.put(email, lookupResult{state: state, source: source}, time.Now())
+		return state, nil
+	}
+
+	if sr, ok := p.azureClient.(StatusReporter); ok && p.trace != nil {
+		exists, status, err := sr.UserExistsWithStatus(ctx, email)
+		p.throttle.observe(err, time.Now())
+		if err != nil {
+			p.trace.add("identity", "lookup of %q failed (status %d): %v", email, status, err)
+			return "", err
+		}
+		p.trace.add("identity", "lookup of %q returned exists=%t (status %d)", email, exists, status)
+		p.trace.setSource(source)
+		p.lookups.put(email, lookupResult{state: stateOf(exists), status: status, source: source}, time.Now())
+		return stateOf(exists), nil
+	}
+
+	exists, err := p.azureClient.UserExists(ctx, email)
+	p.throttle.observe(err, time.Now())
+	if err != nil {
+		p.trace.add("identity", "lookup of %q failed: %v", email, err)
+		return "", err
 	}
+	p.trace.add("identity", "lookup of %q returned exists=%t", email, exists)
+	p.trace.setSource(source)
+	p.lookups.put(email, lookupResult{state: stateOf(exists), source: source}, time.Now())
+	return stateOf(exists), nil
 }
 
 // handleValidUser cleans up deletion markers for active users
 func (p *NamespaceProcessor) handleValidUser(ns corev1.Namespace) {
 	if _, exists := ns.Annotations[GracePeriodAnnotation]; exists {
 		log.Printf("Cleaning up grace period annotation from %s", ns.Name)
-
-		if p.dryRun {
-			log.Printf("[DRY RUN] Would remove annotation from %s", ns.Name)
+		p.trace.add("marker", "owner is valid, deletion marker present")
+		if !p.ownsMarker(ns) {
+			p.trace.setAction(ActionForeign)
+			return
+		}
+		now := p.now()
+		if p.damped(ns, flapStateValid, now) {
 			return
 		}
+		p.trace.setAction(ActionUnmark)
+		saved := p.savedEntry(ns, now)
 
-		delete(ns.Annotations, GracePeriodAnnotation)
-		_, err := p.k8sClient.CoreV1().Namespaces().Update(
-			context.TODO(),
-			&ns,
-			metav1.UpdateOptions{},
-		)
+		clearMarker(ns.Annotations)
+		p.recordFlap(ns, now)
+		err := p.updateNamespace(context.TODO(), &ns)
 		if err != nil {
 			log.Printf("Error updating %s: %v", ns.Name, err)
+		} else {
+			p.saved.add(saved)
+			p.recordEvent(context.TODO(), ns, corev1.EventTypeNormal, EventReasonUnmarked,
+				"Deletion marker removed: %s is a valid user", describeOwner(ns))
+			p.liftQuarantine(context.TODO(), &ns)
 		}
+		p.unmarkLinked(context.TODO(), ns)
+		return
 	}
+	p.trace.add("marker", "owner is valid, no deletion marker present")
+	p.trace.setAction(ActionNone)
+	p.unmarkLinked(context.TODO(), ns)
+	settled := p.settle(ns, p.now())
+	if p.resetConfirmations(ns) || settled {
+		if err := p.updateNamespace(context.TODO(), &ns); err != nil {
+			log.Printf("Error updating %s: %v", ns.Name, err)
+		}
+		return
+	}
+	p.evaluations.record(ns, p.evaluationScope(), p.now())
+}
+
+// clearStaleMarker removes the deletion marker from a namespace whose owner
+// annotation was removed after it was marked. Without an owner the namespace
+// is no longer audited, so a leftover marker would never be acted on.
+func (p *NamespaceProcessor) clearStaleMarker(ns corev1.Namespace) {
+	log.Printf("Removing stale deletion marker from %s: owner annotation removed", ns.Name)
+	p.trace.add("marker", "deletion marker present but namespace is no longer audited")
+	if !p.ownsMarker(ns) {
+		p.trace.setAction(ActionForeign)
+		return
+	}
+	p.trace.setAction(ActionUnmark)
+
+	clearMarker(ns.Annotations)
+	if err := p.updateNamespace(context.TODO(), &ns); err != nil {
+		log.Printf("Error updating %s: %v", ns.Name, err)
+	} else {
+		p.recordEvent(context.TODO(), ns, corev1.EventTypeNormal, EventReasonUnmarked,
+			"Deletion marker removed: owner annotation removed, namespace no longer audited")
+	}
+	p.unmarkLinked(context.TODO(), ns)
 }
 
 // handleInvalidUser manages namespaces with unverified users
 func (p *NamespaceProcessor) handleInvalidUser(ns corev1.Namespace) {
-	now := time.Now()
+	now := p.now()
+	p.traceGraceOverride(ns)
 
 	if existingTime, exists := ns.Annotations[GracePeriodAnnotation]; exists {
-		deleteTime, err := time.Parse(time.RFC3339, existingTime)
+		deleteTime, err := parseMarkerTime(existingTime)
 		if err != nil {
 			p.handleInvalidTimestamp(ns)
 			return
 		}
 
-		if now.After(deleteTime.Add(p.gracePeriod)) {
-			p.deleteNamespace(ns)
+		p.recordMarked(ns, deleteTime, now)
+		paused := p.accumulatedPause(ns, deleteTime, now)
+		expiry := p.graceExpiry(ns, deleteTime).Add(paused + p.clockSkew)
+		if now.After(expiry) {
+			p.trace.add("grace", "marked at %s, grace period (plus %s paused, %s clock skew) expired at %s",
+				formatMarkerTime(deleteTime), paused, p.clockSkew, formatMarkerTime(expiry))
+			p.expire(ns)
 			return
 		}
+		p.trace.add("grace", "marked at %s, grace period (plus %s paused, %s clock skew) expires at %s",
+			formatMarkerTime(deleteTime), paused, p.clockSkew, formatMarkerTime(expiry))
+		p.trace.setAction(ActionWait)
+		reminder := p.dueReminder(ns, deleteTime, expiry, now)
+		if p.recordPause(ns, paused, p.settle(ns, now) || reminder != "") && reminder != "" {
+			p.notifyReminder(context.TODO(), ns, reminder, expiry)
+		}
+		p.markLinked(context.TODO(), ns)
 		return
 	}
+	p.trace.add("grace", "owner not found and no deletion marker present")
 	p.markForDeletion(ns, now)
 }
 
-// isValidDomain verifies if an email address belongs to an allowed domain
-func isValidDomain(email string, allowedDomains []string) bool {
-	parts := strings.Split(email, "@")
-	if len(parts) != 2 {
-		return false
+// deleteNamespace permanently removes a namespace after grace period expiration
+func (p *NamespaceProcessor) deleteNamespace(ns corev1.Namespace) {
+	if !p.withinBudget(p.deletions, ns.Name, ChangeDelete) {
+		return
 	}
-	domain := strings.ToLower(parts[1])
+	log.Printf("Deleting namespace %s after grace period", ns.Name)
+	p.trace.setAction(ActionDelete)
 
-	for _, d := range allowedDomains {
-		if strings.EqualFold(domain, d) {
-			return true
-		}
+	if err := p.backupNamespace(context.TODO(), &ns); err != nil {
+		log.Printf("Not deleting %s: %v", ns.Name, err)
+		p.trace.fail(err)
+		return
 	}
-	return false
-}
-
-// handleInvalidTimestamp cleans up namespaces with malformed timestamps
-func (p *NamespaceProcessor) handleInvalidTimestamp(ns corev1.Namespace) {
-	log.Printf("Invalid timestamp in %s", ns.Name)
-
-	if p.dryRun {
-		log.Printf("[DRY RUN] Would remove invalid annotation from %s", ns.Name)
+	if err := p.snapshotVolumes(context.TODO(), &ns); err != nil {
+		log.Printf("Not deleting %s: %v", ns.Name, err)
+		p.trace.fail(err)
 		return
 	}
+	if p.preDeleteFinalizer {
+		if err := p.addFinalizer(context.TODO(), &ns); err != nil {
+			log.Printf("Error adding finalizer to %s: %v", ns.Name, err)
+			return
+		}
+	}
 
-	delete(ns.Annotations, GracePeriodAnnotation)
-	_, err := p.k8sClient.CoreV1().Namespaces().Update(
-		context.TODO(),
-		&ns,
-		metav1.UpdateOptions{},
-	)
+	err := p.removeNamespace(context.TODO(), ns)
 	if err != nil {
-		log.Printf("Error cleaning %s: %v", ns.Name, err)
+		log.Printf("Error deleting %s: %v", ns.Name, err)
+		return
 	}
-}
-
-// deleteNamespace permanently removes a namespace after grace period expiration
-func (p *NamespaceProcessor) deleteNamespace(ns corev1.Namespace) {
-	log.Printf("Deleting namespace %s after grace period", ns.Name)
-
+	p.recordEvent(context.TODO(), ns, corev1.EventTypeWarning, EventReasonDeleted,
+		"Deleted after its grace period expired: %s is not a valid user", describeOwner(ns))
+	p.notifyDeleted(context.TODO(), ns)
 	if p.dryRun {
-		log.Printf("[DRY RUN] Would delete namespace %s", ns.Name)
-		return
+		return // Nothing was deleted, so there is no termination to follow
 	}
-
-	err := p.k8sClient.CoreV1().Namespaces().Delete(
-		context.TODO(),
-		ns.Name,
-		metav1.DeleteOptions{},
-	)
-	if err != nil {
-		log.Printf("Error deleting %s: %v", ns.Name, err)
+	if p.preDeleteFinalizer {
+		p.finalizeDeleted(context.TODO(), ns.Name)
 	}
+	p.waitForDeletion(context.TODO(), ns.Name)
 }
 
 // markForDeletion annotates a namespace with a deletion timestamp
 func (p *NamespaceProcessor) markForDeletion(ns corev1.Namespace, now time.Time) {
-	log.Printf("Marking namespace %s for deletion", ns.Name)
-	if p.dryRun {
-		log.Printf("[DRY RUN] Would add deletion annotation to %s", ns.Name)
+	if p.unconfirmed(ns) || p.damped(ns, flapStateMissing, now) {
 		return
 	}
+	if !p.withinBudget(p.marks, ns.Name, ChangeMark) {
+		return
+	}
+	log.Printf("Marking namespace %s for deletion", ns.Name)
+	p.trace.setAction(ActionMark)
 
 	if ns.Annotations == nil {
 		ns.Annotations = make(map[string]string)
 	}
 
-	ns.Annotations[GracePeriodAnnotation] = now.Format(time.RFC3339)
-	_, err := p.k8sClient.CoreV1().Namespaces().Update(
-		context.TODO(),
-		&ns,
-		metav1.UpdateOptions{},
-	)
+	ns.Annotations[GracePeriodAnnotation] = formatMarkerTime(now)
+	p.stampRunInfo(ns.Annotations)
+	p.stampOwnerState(context.TODO(), ns.Annotations)
+	p.stampVerdictSource(ns.Annotations)
+	p.stampOwnerManager(context.TODO(), ns.Annotations)
+	err := p.updateNamespace(context.TODO(), &ns)
 	if err != nil {
 		log.Printf("Error marking %s: %v", ns.Name, err)
+		return
 	}
+	p.recordMarked(ns, now, now)
+	deleteAt := p.graceExpiry(ns, now)
+	p.recordEvent(context.TODO(), ns, corev1.EventTypeWarning, EventReasonMarked,
+		"Marked for deletion: %s is not a valid user, deletion after %s",
+		describeOwner(ns), formatMarkerTime(deleteAt))
+	p.markLinked(context.TODO(), ns)
+	p.notifyMarked(context.TODO(), ns, deleteAt)
+}
+
+// stampRunInfo records the current run alongside a deletion marker
+func (p *NamespaceProcessor) stampRunInfo(annotations map[string]string) {
+	if p.runID != "" {
+		annotations[RunIDAnnotation] = p.runID
+	}
+	if p.configHash != "" {
+		annotations[ConfigHashAnnotation] = p.configHash
+	}
+}
+
+// clearMarker removes a deletion marker together with its companion annotations
+func clearMarker(annotations map[string]string) {
+	delete(annotations, GracePeriodAnnotation)
+	delete(annotations, RunIDAnnotation)
+	delete(annotations, ConfigHashAnnotation)
+	delete(annotations, PausedAnnotation)
+	delete(annotations, OwnerStateChangedAnnotation)
+	delete(annotations, VerdictSourceAnnotation)
+	delete(annotations, ManagerAnnotation)
+	delete(annotations, MalformedSinceAnnotation)
+	delete(annotations, ApprovalRequestedAnnotation)
+	delete(annotations, DeletionApprovedAnnotation)
+	delete(annotations, SecondApprovalAnnotation)
+	delete(annotations, ReminderAnnotation)
 }
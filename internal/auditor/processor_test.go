@@ -3,6 +3,7 @@ package auditor
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
 	"strings"
 	"testing"
@@ -39,6 +40,20 @@ func newTestProcessor(userExists bool, k8sNamespaces []*corev1.Namespace, dryRun
 		gracePeriod:    24 * time.Hour,
 		allowedDomains: []string{"example.com"},
 		dryRun:         dryRun,
+		stuck:          &collector[StuckNamespace]{},
+		abort:          &abortState{},
+		marked:         &collector[MarkedNamespace]{},
+		saved:          &collector[SavedNamespace]{},
+		planned:        &collector[PlannedChange]{},
+		foreign:        &collector[ForeignMarker]{},
+		deferred:       &collector[string]{},
+		flapping:       &collector[FlappingNamespace]{},
+		awaiting:       &collector[string]{},
+		changed:        &collector[string]{},
+		overBudget:     &collector[BudgetDeferral]{},
+
+		expiredExemptions: &collector[ExpiredExemption]{},
+		malformed:         &collector[MalformedMarker]{},
 	}
 }
 
@@ -369,3 +384,237 @@ func TestIsValidDomain(t *testing.T) {
 		})
 	}
 }
+
+// mockStatusChecker extends MockUserChecker with raw status code reporting
+type mockStatusChecker struct {
+	MockUserChecker
+	status int // Mocked response status code
+}
+
+// UserExistsWithStatus implements StatusReporter for testing
+func (m *mockStatusChecker) UserExistsWithStatus(ctx context.Context, email string) (bool, int, error) {
+	return m.exists, m.status, m.err
+}
+
+// TestExplain validates decision traces and ensures explaining never mutates
+func TestExplain(t *testing.T) {
+	testCases := []struct {
+		name         string           // Test scenario description
+		ns           corev1.Namespace // Namespace configuration
+		userExists   bool             // Mock user existence status
+		expectAction Action           // Expected resulting action
+		expectDetail string           // Expected fragment in the trace
+	}{
+		{
+			name: "missing owner",
+			ns: corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "no-owner"},
+			},
+			expectAction: ActionSkip,
+			expectDetail: "no \"owner\" annotation",
+		},
+		{
+			name: "missing user is marked",
+			ns: corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "to-mark",
+					Annotations: map[string]string{OwnerAnnotation: "gone@example.com"},
+				},
+			},
+			expectAction: ActionMark,
+			expectDetail: "exists=false (status 404)",
+		},
+		{
+			name: "expired grace period",
+			ns: corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "expired",
+					Annotations: map[string]string{
+						OwnerAnnotation:       "gone@example.com",
+						GracePeriodAnnotation: time.Now().Add(-48 * time.Hour).Format(time.RFC3339),
+					},
+				},
+			},
+			expectAction: ActionDelete,
+			expectDetail: "clock skew) expired at",
+		},
+		{
+			name: "valid user",
+			ns: corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "valid",
+					Annotations: map[string]string{OwnerAnnotation: "user@example.com"},
+				},
+			},
+			userExists:   true,
+			expectAction: ActionNone,
+			expectDetail: "exists=true (status 200)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			processor := newTestProcessor(tc.userExists, []*corev1.Namespace{&tc.ns}, false)
+			status := http.StatusNotFound
+			if tc.userExists {
+				status = http.StatusOK
+			}
+			processor.azureClient = &mockStatusChecker{
+				MockUserChecker: MockUserChecker{exists: tc.userExists},
+				status:          status,
+			}
+
+			var tr *Trace
+			captureLogs(func() {
+				tr = processor.Explain(context.TODO(), tc.ns)
+			})
+
+			if tr.Action != tc.expectAction {
+				t.Errorf("Action mismatch: expected %q, got %q", tc.expectAction, tr.Action)
+			}
+			if !strings.Contains(tr.String(), tc.expectDetail) {
+				t.Errorf("Trace missing %q:\n%s", tc.expectDetail, tr.String())
+			}
+			if processor.trace != nil {
+				t.Error("Explain should not leave tracing enabled on the processor")
+			}
+
+			updatedNs, err := processor.k8sClient.CoreV1().Namespaces().Get(
+				context.TODO(), tc.ns.Name, metav1.GetOptions{},
+			)
+			if err != nil {
+				t.Fatalf("Explain should never delete namespaces: %v", err)
+			}
+			if updatedNs.Annotations[GracePeriodAnnotation] != tc.ns.Annotations[GracePeriodAnnotation] {
+				t.Error("Explain should never modify annotations")
+			}
+		})
+	}
+}
+
+// TestProcessNamespaceTraced ensures traced processing still applies changes
+func TestProcessNamespaceTraced(t *testing.T) {
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "traced",
+			Annotations: map[string]string{OwnerAnnotation: "gone@example.com"},
+		},
+	}
+	processor := newTestProcessor(false, []*corev1.Namespace{&ns}, false)
+
+	var tr *Trace
+	captureLogs(func() {
+		tr = processor.ProcessNamespaceTraced(context.TODO(), ns)
+	})
+
+	if tr.Action != ActionMark {
+		t.Errorf("Action mismatch: expected %q, got %q", ActionMark, tr.Action)
+	}
+	if len(tr.Steps) == 0 || tr.Steps[0].Step != "policy" {
+		t.Errorf("Trace should start with policy values: %+v", tr.Steps)
+	}
+
+	updatedNs, _ := processor.k8sClient.CoreV1().Namespaces().Get(
+		context.TODO(), ns.Name, metav1.GetOptions{},
+	)
+	if _, exists := updatedNs.Annotations[GracePeriodAnnotation]; !exists {
+		t.Error("Traced processing should still mark the namespace")
+	}
+}
+
+// TestMarkerRunInfo validates companion annotations on marking and cleanup
+func TestMarkerRunInfo(t *testing.T) {
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "stamped",
+			Annotations: map[string]string{OwnerAnnotation: "gone@example.com"},
+		},
+	}
+	processor := newTestProcessor(false, []*corev1.Namespace{&ns}, false)
+	processor.SetRunInfo("20240101T000000Z-abcdef", "0123456789ab")
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+	})
+
+	marked, _ := processor.k8sClient.CoreV1().Namespaces().Get(
+		context.TODO(), ns.Name, metav1.GetOptions{},
+	)
+	if got := marked.Annotations[RunIDAnnotation]; got != "20240101T000000Z-abcdef" {
+		t.Errorf("Run ID annotation mismatch: %q", got)
+	}
+	if got := marked.Annotations[ConfigHashAnnotation]; got != "0123456789ab" {
+		t.Errorf("Config hash annotation mismatch: %q", got)
+	}
+
+	processor.azureClient = &MockUserChecker{exists: true}
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *marked)
+	})
+
+	cleared, _ := processor.k8sClient.CoreV1().Namespaces().Get(
+		context.TODO(), ns.Name, metav1.GetOptions{},
+	)
+	for _, key := range []string{GracePeriodAnnotation, RunIDAnnotation, ConfigHashAnnotation} {
+		if _, exists := cleared.Annotations[key]; exists {
+			t.Errorf("Annotation %s should be removed with the marker", key)
+		}
+	}
+}
+
+// TestClockSkewTolerance validates that expiry honors the skew allowance
+func TestClockSkewTolerance(t *testing.T) {
+	// Marked just over the 24h grace period ago, written with a local offset
+	markedAt := time.Now().Add(-24*time.Hour - time.Minute).In(time.FixedZone("EST", -5*60*60))
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "skewed",
+			Annotations: map[string]string{
+				GracePeriodAnnotation: markedAt.Format(time.RFC3339),
+			},
+		},
+	}
+
+	testCases := []struct {
+		name           string        // Test scenario description
+		skew           time.Duration // Configured clock-skew allowance
+		expectedAction string        // Expected log message pattern
+	}{
+		{name: "no tolerance", skew: 0, expectedAction: "Deleting namespace skewed"},
+		{name: "within tolerance", skew: 5 * time.Minute, expectedAction: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			processor := newTestProcessor(false, []*corev1.Namespace{&ns}, false)
+			processor.SetClockSkew(tc.skew)
+			logOutput := captureLogs(func() {
+				processor.handleInvalidUser(ns)
+			})
+
+			if tc.expectedAction == "" {
+				if strings.Contains(logOutput, "Deleting") {
+					t.Errorf("Namespace should not be deleted within skew tolerance: %q", logOutput)
+				}
+				return
+			}
+			if !strings.Contains(logOutput, tc.expectedAction) {
+				t.Errorf("Action not performed:\nExpected: %q\nIn logs: %q", tc.expectedAction, logOutput)
+			}
+		})
+	}
+}
+
+// TestBusinessDayGracePeriod validates expiry using business days
+func TestBusinessDayGracePeriod(t *testing.T) {
+	processor := newTestProcessor(false, nil, false)
+	processor.SetBusinessDayGracePeriod(2, DefaultWorkWeek)
+
+	friday := time.Date(2024, 5, 17, 9, 0, 0, 0, time.UTC)
+	if got, want := processor.graceExpiry(corev1.Namespace{}, friday), friday.AddDate(0, 0, 4); !got.Equal(want) {
+		t.Errorf("Expiry mismatch:\nExpected: %v\nGot: %v", want, got)
+	}
+	if got := processor.describeGracePeriod(corev1.Namespace{}); got != "2 business days (Mon,Tue,Wed,Thu,Fri)" {
+		t.Errorf("Unexpected grace period description: %q", got)
+	}
+}
@@ -0,0 +1,124 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// ProfileResource identifies the cluster-scoped Kubeflow Profile custom
+// resource. Each profile owns the namespace of the same name.
+var ProfileResource = schema.GroupVersionResource{
+	Group:    "kubeflow.org",
+	Version:  "v1",
+	Resource: "profiles",
+}
+
+// OwnerSource selects where the owner of a namespace is read from.
+type OwnerSource string
+
+const (
+	// OwnerSourceAnnotation reads owners from OwnerAnnotation only.
+	OwnerSourceAnnotation OwnerSource = "annotation"
+	// OwnerSourceProfile reads owners from the spec.owner.name of the
+	// Kubeflow Profile of the namespace, falling back to OwnerAnnotation for
+	// namespaces without a profile.
+	OwnerSourceProfile OwnerSource = "profile"
+)
+
+// ParseOwnerSource parses an owner source, OwnerSourceAnnotation if empty.
+func ParseOwnerSource(value string) (OwnerSource, error) {
+	switch source := OwnerSource(value); source {
+	case "":
+		return OwnerSourceAnnotation, nil
+	case OwnerSourceAnnotation, OwnerSourceProfile:
+		return source, nil
+	default:
+		return "", fmt.Errorf("expected %q or %q, got %q", OwnerSourceAnnotation, OwnerSourceProfile, value)
+	}
+}
+
+// profileOwners holds the owners of Kubeflow profiles, listed once on first
+// use. It is shared by all copies of a processor. A nil *profileOwners has
+// no profiles.
+type profileOwners struct {
+	client dynamic.Interface
+	once   sync.Once
+	owners map[string]string // Owner email by profile name
+}
+
+// owner returns the owner of the profile named name
+func (o *profileOwners) owner(ctx context.Context, name string) (string, bool) {
+	if o == nil {
+		return "", false
+	}
+	o.once.Do(func() {
+		owners, err := ListProfileOwners(ctx, o.client)
+		if err != nil {
+			log.Printf("Failed to list Kubeflow profiles, using owner annotations: %v", err)
+		}
+		o.owners = owners
+	})
+	owner, ok := o.owners[name]
+	return owner, ok
+}
+
+// ListProfileOwners returns the spec.owner.name of every Kubeflow profile
+// owned by a user, by profile name.
+func ListProfileOwners(ctx context.Context, client dynamic.Interface) (map[string]string, error) {
+	list, err := client.Resource(ProfileResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[string]string, len(list.Items))
+	for _, profile := range list.Items {
+		if kind, _, _ := unstructured.NestedString(profile.Object, "spec", "owner", "kind"); kind != "" && kind != "User" {
+			continue
+		}
+		if owner, _, _ := unstructured.NestedString(profile.Object, "spec", "owner", "name"); owner != "" {
+			owners[profile.GetName()] = owner
+		}
+	}
+	return owners, nil
+}
+
+// SetProfileSource makes the owner recorded in the Kubeflow Profile of a
+// namespace authoritative over OwnerAnnotation, so that decisions do not
+// depend on the profile controller propagating the annotation. Profiles are
+// listed with client once, on first use. Namespaces without a profile keep
+// their annotation. A nil client reads annotations only.
+func (p *NamespaceProcessor) SetProfileSource(client dynamic.Interface) {
+	if client == nil {
+		p.profiles = nil
+		return
+	}
+	p.profiles = &profileOwners{client: client}
+}
+
+// withProfileOwner returns ns with the owner of its profile, if it has one,
+// as OwnerAnnotation. Changes the auditor writes to ns carry that owner.
+func (p *NamespaceProcessor) withProfileOwner(ctx context.Context, ns corev1.Namespace) corev1.Namespace {
+	owner, ok := p.profiles.owner(ctx, ns.Name)
+	if !ok || ns.Annotations[OwnerAnnotation] == owner {
+		return ns
+	}
+	if current := ns.Annotations[OwnerAnnotation]; current != "" {
+		p.trace.add("profile", "profile owner %q replaces owner annotation %q", owner, current)
+	} else {
+		p.trace.add("profile", "owner %q read from the profile", owner)
+	}
+	annotations := make(map[string]string, len(ns.Annotations)+1)
+	for key, value := range ns.Annotations {
+		annotations[key] = value
+	}
+	annotations[OwnerAnnotation] = owner
+	ns.Annotations = annotations
+	return ns
+}
@@ -0,0 +1,110 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// newProfileClient returns a dynamic client holding a profile per name, owned
+// by the given kind and name
+func newProfileClient(owners map[string][2]string) *dynamicfake.FakeDynamicClient {
+	var objects []runtime.Object
+	for name, owner := range owners {
+		objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "kubeflow.org/v1",
+			"kind":       "Profile",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec": map[string]interface{}{
+				"owner": map[string]interface{}{"kind": owner[0], "name": owner[1]},
+			},
+		}})
+	}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{ProfileResource: "ProfileList"}, objects...)
+}
+
+// TestListProfileOwners validates that only profiles owned by users are read
+func TestListProfileOwners(t *testing.T) {
+	client := newProfileClient(map[string][2]string{
+		"alice": {"User", "alice@example.com"},
+		"bob":   {"", "bob@example.com"},
+		"team":  {"Group", "team@example.com"},
+	})
+	owners, err := ListProfileOwners(context.TODO(), client)
+	if err != nil {
+		t.Fatalf("ListProfileOwners: %v", err)
+	}
+	want := map[string]string{"alice": "alice@example.com", "bob": "bob@example.com"}
+	if !reflect.DeepEqual(owners, want) {
+		t.Errorf("Owners = %v, want %v", owners, want)
+	}
+}
+
+// TestProfileOwnerSource validates that the profile owner is authoritative
+// and that namespaces without a profile keep their annotation
+func TestProfileOwnerSource(t *testing.T) {
+	namespace := func(name, owner string) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if owner != "" {
+			ns.Annotations = map[string]string{OwnerAnnotation: owner}
+		}
+		return ns
+	}
+	p := newTestProcessor(false, []*corev1.Namespace{
+		namespace("alice", "former@example.com"), // Stale annotation
+		namespace("bob", ""),                     // Annotation not propagated yet
+		namespace("carol", "carol@example.com"),  // No profile
+	}, false)
+	p.SetProfileSource(newProfileClient(map[string][2]string{
+		"alice": {"User", "alice@example.com"},
+		"bob":   {"User", "bob@example.com"},
+	}))
+
+	checker := &countingChecker{calls: make(map[string]int), existing: map[string]bool{
+		"alice@example.com": true,
+		"bob@example.com":   true,
+	}}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		ns, err := p.GetNamespace(context.TODO(), name)
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		p.azureClient = checker
+		captureLogs(func() { p.ProcessNamespace(context.TODO(), *ns) })
+	}
+
+	want := map[string]int{"alice@example.com": 1, "bob@example.com": 1, "carol@example.com": 1}
+	if !reflect.DeepEqual(checker.calls, want) {
+		t.Errorf("Lookups = %v, want %v", checker.calls, want)
+	}
+	for name, marked := range map[string]bool{"alice": false, "bob": false, "carol": true} {
+		ns, _ := p.GetNamespace(context.TODO(), name)
+		if _, got := ns.Annotations[GracePeriodAnnotation]; got != marked {
+			t.Errorf("%s: marked = %t, want %t", name, got, marked)
+		}
+	}
+}
+
+// TestParseOwnerSource validates owner source names
+func TestParseOwnerSource(t *testing.T) {
+	for value, want := range map[string]OwnerSource{
+		"":           OwnerSourceAnnotation,
+		"annotation": OwnerSourceAnnotation,
+		"profile":    OwnerSourceProfile,
+	} {
+		if got, err := ParseOwnerSource(value); err != nil || got != want {
+			t.Errorf("ParseOwnerSource(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseOwnerSource("label"); err == nil {
+		t.Error("Expected an error for an unknown source")
+	}
+}
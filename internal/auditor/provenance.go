@@ -0,0 +1,54 @@
+package auditor
+
+import (
+	"context"
+
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+)
+
+// ProvenanceReporter is optionally implemented by UserExistenceChecker
+// implementations able to tell which directory and lookup method produced
+// a verdict, e.g. which member of a chain or which tenant answered. When
+// available it replaces UserState and UserExists for individual lookups.
+type ProvenanceReporter interface {
+	UserStateWithProvenance(ctx context.Context, email string) (identity.UserState, identity.Provenance, error)
+}
+
+// SetProviderName names the identity provider in the provenance of verdicts
+// whose checker does not implement ProvenanceReporter, and of batched
+// lookups.
+func (p *NamespaceProcessor) SetProviderName(name string) {
+	p.providerName = name
+}
+
+// defaultProvenance is the provenance of verdicts the checker does not
+// describe itself
+func (p *NamespaceProcessor) defaultProvenance() identity.Provenance {
+	return identity.Provenance{Provider: p.providerName}
+}
+
+// setSource records the provenance of the owner verdict in the trace
+func (t *Trace) setSource(source identity.Provenance) {
+	if t == nil || source == (identity.Provenance{}) {
+		return
+	}
+	t.Source = &source
+}
+
+// fromSource describes the provenance of a verdict in a trace step, nothing
+// if it is unknown
+func fromSource(source identity.Provenance) string {
+	if source == (identity.Provenance{}) {
+		return ""
+	}
+	return " from " + source.String()
+}
+
+// stampVerdictSource records the provenance of the verdict that the owner is
+// missing in VerdictSourceAnnotation, if it is known
+func (p *NamespaceProcessor) stampVerdictSource(annotations map[string]string) {
+	if p.trace == nil || p.trace.Source == nil {
+		return
+	}
+	annotations[VerdictSourceAnnotation] = p.trace.Source.String()
+}
@@ -0,0 +1,75 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// sourcedChecker reports a fixed state and provenance for every user
+type sourcedChecker struct {
+	state  identity.UserState
+	source identity.Provenance
+}
+
+// UserExists implements UserExistenceChecker
+func (c *sourcedChecker) UserExists(ctx context.Context, email string) (bool, error) {
+	return c.state.Exists(), nil
+}
+
+// UserStateWithProvenance implements ProvenanceReporter
+func (c *sourcedChecker) UserStateWithProvenance(ctx context.Context, email string) (identity.UserState, identity.Provenance, error) {
+	return c.state, c.source, nil
+}
+
+// TestVerdictSource validates that the provenance of the verdict an owner is
+// missing is stored with the marker, and reported when the owner is found
+// again
+func TestVerdictSource(t *testing.T) {
+	p := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{OwnerAnnotation: "alice@example.com"}}},
+	}, false)
+	staff := identity.Provenance{Provider: "staff/azure", Endpoint: "https://graph.microsoft.com", Method: identity.LookupUPN}
+	guests := identity.Provenance{Provider: "guests"}
+
+	process := func(checker UserExistenceChecker) *Trace {
+		p.azureClient = checker
+		ns, err := p.GetNamespace(context.TODO(), "alice")
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		var tr *Trace
+		captureLogs(func() { tr = p.ProcessNamespaceTraced(context.TODO(), *ns) })
+		return tr
+	}
+
+	tr := process(&sourcedChecker{state: identity.UserNotFound, source: staff})
+	if tr.Action != ActionMark || tr.Source == nil || *tr.Source != staff {
+		t.Fatalf("Trace = %+v, want a mark with source %+v", tr, staff)
+	}
+	ns, _ := p.GetNamespace(context.TODO(), "alice")
+	if got, want := ns.Annotations[VerdictSourceAnnotation], "provider=staff/azure endpoint=https://graph.microsoft.com method=upn"; got != want {
+		t.Errorf("Verdict source = %q, want %q", got, want)
+	}
+
+	if tr := process(&sourcedChecker{state: identity.UserActive, source: guests}); tr.Action != ActionUnmark {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionUnmark)
+	}
+	saved := p.Saved()
+	if len(saved) != 1 || saved[0].MarkedBy != staff.String() || saved[0].FoundBy != "provider=guests" {
+		t.Errorf("Saved = %+v, want marked by staff and found by guests", saved)
+	}
+	if ns, _ := p.GetNamespace(context.TODO(), "alice"); ns.Annotations[VerdictSourceAnnotation] != "" {
+		t.Errorf("Verdict source should be removed with the marker: %v", ns.Annotations)
+	}
+
+	// Checkers unable to describe their verdicts are named after the provider
+	p.SetProviderName("ldap")
+	process(&MockUserChecker{exists: false})
+	if ns, _ := p.GetNamespace(context.TODO(), "alice"); ns.Annotations[VerdictSourceAnnotation] != "provider=ldap" {
+		t.Errorf("Verdict source = %q, want provider=ldap", ns.Annotations[VerdictSourceAnnotation])
+	}
+}
@@ -0,0 +1,293 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// QuarantineObjectName names the NetworkPolicy and ResourceQuota created in
+// quarantined namespaces
+const QuarantineObjectName = "namespace-auditor-quarantine"
+
+// quarantineQuota is the hard limit of the ResourceQuota of quarantined
+// namespaces: nothing new can be scheduled or provisioned
+var quarantineQuota = corev1.ResourceList{
+	corev1.ResourcePods:                   resource.MustParse("0"),
+	corev1.ResourceRequestsCPU:            resource.MustParse("0"),
+	corev1.ResourceRequestsMemory:         resource.MustParse("0"),
+	corev1.ResourceRequestsStorage:        resource.MustParse("0"),
+	corev1.ResourcePersistentVolumeClaims: resource.MustParse("0"),
+}
+
+// SetQuarantine inserts a quarantine phase of period between grace period
+// expiry and deletion: Deployments and StatefulSets are scaled to zero, a
+// deny-all NetworkPolicy and a zero ResourceQuota are applied, and the
+// namespace is deleted once it has been quarantined for period. A zero
+// period deletes namespaces as soon as their grace period expires.
+func (p *NamespaceProcessor) SetQuarantine(period time.Duration) {
+	p.quarantinePeriod = period
+}
+
+// isQuarantined reports whether a namespace was quarantined
+func isQuarantined(ns corev1.Namespace) bool {
+	_, quarantined := ns.Annotations[QuarantinedAtAnnotation]
+	return quarantined
+}
+
+// quarantining holds an expired namespace in quarantine before deletion,
+// quarantining it on the first call. Returns whether deletion must wait;
+// false once the quarantine period has passed, or without a quarantine
+// phase.
+func (p *NamespaceProcessor) quarantining(ns *corev1.Namespace) bool {
+	if p.quarantinePeriod <= 0 {
+		return false
+	}
+	now := p.now()
+	if !isQuarantined(*ns) {
+		p.quarantineNamespace(ns, now)
+		return true
+	}
+
+	quarantinedAt, err := parseMarkerTime(ns.Annotations[QuarantinedAtAnnotation])
+	if err != nil {
+		// Restart the quarantine rather than delete on an unreadable time
+		log.Printf("Resetting unreadable %s of %s: %v", QuarantinedAtAnnotation, ns.Name, err)
+		p.trace.add("quarantine", "quarantined at %q is not a valid timestamp, quarantine restarted", ns.Annotations[QuarantinedAtAnnotation])
+		p.trace.setAction(ActionReset)
+		ns.Annotations[QuarantinedAtAnnotation] = formatMarkerTime(now)
+		if err := p.updateNamespace(context.TODO(), ns); err != nil {
+			log.Printf("Error updating %s: %v", ns.Name, err)
+		}
+		return true
+	}
+
+	deleteAt := quarantinedAt.Add(p.quarantinePeriod + p.clockSkew)
+	if now.After(deleteAt) {
+		p.trace.add("quarantine", "quarantined at %s, quarantine period expired at %s", formatMarkerTime(quarantinedAt), formatMarkerTime(deleteAt))
+		return false
+	}
+	p.trace.add("quarantine", "quarantined at %s, deletion after %s", formatMarkerTime(quarantinedAt), formatMarkerTime(deleteAt))
+	p.trace.setAction(ActionWait)
+	return true
+}
+
+// quarantineNamespace stops the workloads of an expired namespace and its
+// linked namespaces and blocks their traffic, then records the quarantine.
+// Every step is idempotent, so a failed quarantine is retried by the next
+// run.
+func (p *NamespaceProcessor) quarantineNamespace(ns *corev1.Namespace, now time.Time) {
+	log.Printf("Quarantining namespace %s after grace period", ns.Name)
+	p.trace.setAction(ActionQuarantine)
+
+	for _, name := range append([]string{ns.Name}, namespaceNames(p.links.companionsOf(ns.Name))...) {
+		if err := p.isolate(context.TODO(), name); err != nil {
+			log.Printf("Error quarantining %s: %v", name, err)
+			p.trace.fail(err)
+			return
+		}
+	}
+	ns.Annotations[QuarantinedAtAnnotation] = formatMarkerTime(now)
+	if err := p.updateNamespace(context.TODO(), ns); err != nil {
+		log.Printf("Error quarantining %s: %v", ns.Name, err)
+		return
+	}
+
+	deleteAt := now.Add(p.quarantinePeriod)
+	p.trace.add("quarantine", "workloads scaled to zero and traffic blocked, deletion after %s", formatMarkerTime(deleteAt))
+	p.recordEvent(context.TODO(), *ns, corev1.EventTypeWarning, EventReasonQuarantined,
+		"Quarantined after its grace period expired: %s is not a valid user, deletion after %s", describeOwner(*ns), formatMarkerTime(deleteAt))
+	n := Notification{
+		Event:     EventQuarantined,
+		Namespace: ns.Name,
+		Owner:     ns.Annotations[OwnerAnnotation],
+		Message:   fmt.Sprintf("grace period expired, workloads stopped and traffic blocked until deletion (%s)", describeOwner(*ns)),
+		Context:   p.namespaceContext(*ns),
+		Contact:   ns.Annotations[SecondaryContactAnnotation],
+		Manager:   ns.Annotations[ManagerAnnotation],
+		DeleteAt:  deleteAt,
+	}
+	p.addSecurityContact(*ns, &n)
+	p.notify(context.TODO(), n)
+}
+
+// liftQuarantine restores a quarantined namespace, and its linked
+// namespaces, that is no longer marked for deletion or orphaned, e.g. after
+// its owner was found again or it was claimed. Auditing continues from ns
+// as updated.
+func (p *NamespaceProcessor) liftQuarantine(ctx context.Context, ns *corev1.Namespace) {
+	if !isQuarantined(*ns) || isOrphaned(*ns) {
+		return
+	}
+	if _, marked := ns.Annotations[GracePeriodAnnotation]; marked {
+		return
+	}
+	log.Printf("Lifting quarantine of %s", ns.Name)
+	for _, name := range append([]string{ns.Name}, namespaceNames(p.links.companionsOf(ns.Name))...) {
+		if err := p.restore(ctx, name); err != nil {
+			log.Printf("Error lifting quarantine of %s: %v", name, err)
+			p.trace.add("quarantine", "lifting quarantine failed: %v", err)
+			return
+		}
+	}
+	delete(ns.Annotations, QuarantinedAtAnnotation)
+	if err := p.updateNamespace(ctx, ns); err != nil {
+		log.Printf("Error updating %s: %v", ns.Name, err)
+		return
+	}
+	p.trace.add("quarantine", "no longer marked for deletion, workloads and traffic restored")
+	p.recordEvent(ctx, *ns, corev1.EventTypeNormal, EventReasonReleased,
+		"Quarantine lifted: namespace no longer marked for deletion")
+}
+
+// isolate scales the Deployments and StatefulSets of a namespace to zero,
+// remembering their replicas, and applies the quarantine NetworkPolicy and
+// ResourceQuota
+func (p *NamespaceProcessor) isolate(ctx context.Context, namespace string) error {
+	deployments, statefulSets, err := p.listWorkloads(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	for i := range deployments {
+		d := &deployments[i]
+		if scaleDown(&d.ObjectMeta, &d.Spec.Replicas) {
+			if err := p.mutations().updateDeployment(ctx, d); err != nil {
+				return fmt.Errorf("scaling down deployment %s: %w", d.Name, err)
+			}
+		}
+	}
+	for i := range statefulSets {
+		s := &statefulSets[i]
+		if scaleDown(&s.ObjectMeta, &s.Spec.Replicas) {
+			if err := p.mutations().updateStatefulSet(ctx, s); err != nil {
+				return fmt.Errorf("scaling down statefulset %s: %w", s.Name, err)
+			}
+		}
+	}
+
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: QuarantineObjectName, Namespace: namespace},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{}, // Every pod, with no rules allowing traffic
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}
+	if err := p.mutations().createNetworkPolicy(ctx, policy); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("creating network policy: %w", err)
+	}
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: QuarantineObjectName, Namespace: namespace},
+		Spec:       corev1.ResourceQuotaSpec{Hard: quarantineQuota},
+	}
+	if err := p.mutations().createResourceQuota(ctx, quota); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("creating resource quota: %w", err)
+	}
+	return nil
+}
+
+// restore undoes isolate: workloads are scaled back to their replicas before
+// the quarantine and the quarantine NetworkPolicy and ResourceQuota removed
+func (p *NamespaceProcessor) restore(ctx context.Context, namespace string) error {
+	deployments, statefulSets, err := p.listWorkloads(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	for i := range deployments {
+		d := &deployments[i]
+		if scaleUp(&d.ObjectMeta, &d.Spec.Replicas) {
+			if err := p.mutations().updateDeployment(ctx, d); err != nil {
+				return fmt.Errorf("scaling up deployment %s: %w", d.Name, err)
+			}
+		}
+	}
+	for i := range statefulSets {
+		s := &statefulSets[i]
+		if scaleUp(&s.ObjectMeta, &s.Spec.Replicas) {
+			if err := p.mutations().updateStatefulSet(ctx, s); err != nil {
+				return fmt.Errorf("scaling up statefulset %s: %w", s.Name, err)
+			}
+		}
+	}
+
+	if err := p.mutations().deleteNetworkPolicy(ctx, namespace, QuarantineObjectName); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting network policy: %w", err)
+	}
+	if err := p.mutations().deleteResourceQuota(ctx, namespace, QuarantineObjectName); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting resource quota: %w", err)
+	}
+	return nil
+}
+
+// listWorkloads lists the Deployments and StatefulSets of a namespace
+func (p *NamespaceProcessor) listWorkloads(ctx context.Context, namespace string) ([]appsv1.Deployment, []appsv1.StatefulSet, error) {
+	start := time.Now()
+	deployments, err := p.k8sClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	p.apiStats.observe(OpDeploymentList, start, err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing deployments: %w", err)
+	}
+	start = time.Now()
+	statefulSets, err := p.k8sClient.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	p.apiStats.observe(OpStatefulSetList, start, err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing statefulsets: %w", err)
+	}
+	return deployments.Items, statefulSets.Items, nil
+}
+
+// scaleDown sets a workload's replicas to zero, recording its replicas in
+// QuarantineReplicasAnnotation. Returns whether the workload changed;
+// workloads already scaled down by a quarantine are left alone.
+func scaleDown(meta *metav1.ObjectMeta, replicas **int32) bool {
+	if _, done := meta.Annotations[QuarantineReplicasAnnotation]; done {
+		return false
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[QuarantineReplicasAnnotation] = strconv.Itoa(int(replicasOf(*replicas)))
+	zero := int32(0)
+	*replicas = &zero
+	return true
+}
+
+// scaleUp restores the replicas recorded by scaleDown. Returns whether the
+// workload changed.
+func scaleUp(meta *metav1.ObjectMeta, replicas **int32) bool {
+	value, scaled := meta.Annotations[QuarantineReplicasAnnotation]
+	if !scaled {
+		return false
+	}
+	delete(meta.Annotations, QuarantineReplicasAnnotation)
+	if n, err := strconv.ParseInt(value, 10, 32); err == nil {
+		restored := int32(n)
+		*replicas = &restored
+	}
+	return true
+}
+
+// replicasOf returns the replicas of a workload, where unset means one
+func replicasOf(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// namespaceNames returns the names of namespaces
+func namespaceNames(namespaces []corev1.Namespace) []string {
+	names := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		names = append(names, ns.Name)
+	}
+	return names
+}
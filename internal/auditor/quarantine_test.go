@@ -0,0 +1,150 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newQuarantineProcessor returns a processor auditing the expired namespace
+// alice, running the Deployment web with three replicas and the
+// StatefulSet db with the default replicas
+func newQuarantineProcessor(t *testing.T, now time.Time, dryRun bool) *NamespaceProcessor {
+	processor := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{
+			OwnerAnnotation:       "alice@example.com",
+			GracePeriodAnnotation: formatMarkerTime(now.Add(-48 * time.Hour)),
+		}}},
+	}, dryRun)
+	processor.clock = func() time.Time { return now }
+	processor.SetQuarantine(7 * 24 * time.Hour)
+
+	replicas := int32(3)
+	apps := processor.GetClient().AppsV1()
+	if _, err := apps.Deployments("alice").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "alice"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Creating deployment: %v", err)
+	}
+	if _, err := apps.StatefulSets("alice").Create(context.TODO(), &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "alice"},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Creating statefulset: %v", err)
+	}
+	return processor
+}
+
+// quarantineState returns the replicas of web and db in alice, and whether
+// the quarantine NetworkPolicy and ResourceQuota exist
+func quarantineState(t *testing.T, p *NamespaceProcessor) (web, db int32, policy, quota bool) {
+	client := p.GetClient()
+	d, err := client.AppsV1().Deployments("alice").Get(context.TODO(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Getting deployment: %v", err)
+	}
+	s, err := client.AppsV1().StatefulSets("alice").Get(context.TODO(), "db", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Getting statefulset: %v", err)
+	}
+	_, err = client.NetworkingV1().NetworkPolicies("alice").Get(context.TODO(), QuarantineObjectName, metav1.GetOptions{})
+	policy = err == nil
+	_, err = client.CoreV1().ResourceQuotas("alice").Get(context.TODO(), QuarantineObjectName, metav1.GetOptions{})
+	quota = err == nil
+	return replicasOf(d.Spec.Replicas), replicasOf(s.Spec.Replicas), policy, quota
+}
+
+// TestQuarantineLifecycle validates that an expired namespace is
+// quarantined, and deleted once the quarantine period expires
+func TestQuarantineLifecycle(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	processor := newQuarantineProcessor(t, now, false)
+	processor.clock = func() time.Time { return now }
+	notifier := &recordingNotifier{}
+	processor.SetNotifier(notifier)
+
+	process := func() Action {
+		ns, err := processor.GetNamespace(context.TODO(), "alice")
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		var tr *Trace
+		captureLogs(func() { tr = processor.ProcessNamespaceTraced(context.TODO(), *ns) })
+		return tr.Action
+	}
+
+	if action := process(); action != ActionQuarantine {
+		t.Fatalf("Action = %q, want %q", action, ActionQuarantine)
+	}
+	if web, db, policy, quota := quarantineState(t, processor); web != 0 || db != 0 || !policy || !quota {
+		t.Errorf("Replicas = %d/%d, network policy %v, quota %v; want workloads stopped and isolated", web, db, policy, quota)
+	}
+	ns, _ := processor.GetNamespace(context.TODO(), "alice")
+	if got := ns.Annotations[QuarantinedAtAnnotation]; got != formatMarkerTime(now) {
+		t.Errorf("Quarantined at = %q, want %q", got, formatMarkerTime(now))
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Event != EventQuarantined || !notifier.sent[0].DeleteAt.Equal(now.Add(7*24*time.Hour)) {
+		t.Errorf("Expected a quarantined notification with the deletion time, got %+v", notifier.sent)
+	}
+
+	now = now.Add(6 * 24 * time.Hour)
+	if action := process(); action != ActionWait {
+		t.Errorf("Action = %q, want %q within the quarantine period", action, ActionWait)
+	}
+
+	now = now.Add(2 * 24 * time.Hour)
+	if action := process(); action != ActionDelete {
+		t.Errorf("Action = %q, want %q once the quarantine period expired", action, ActionDelete)
+	}
+	if _, err := processor.GetNamespace(context.TODO(), "alice"); err == nil {
+		t.Error("The namespace should be deleted")
+	}
+}
+
+// TestQuarantineLifted validates that a quarantined namespace whose owner is
+// found again is restored
+func TestQuarantineLifted(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	processor := newQuarantineProcessor(t, now, false)
+	ns, _ := processor.GetNamespace(context.TODO(), "alice")
+	captureLogs(func() { processor.ProcessNamespace(context.TODO(), *ns) })
+
+	processor.azureClient = &MockUserChecker{exists: true}
+	ns, _ = processor.GetNamespace(context.TODO(), "alice")
+	var tr *Trace
+	captureLogs(func() { tr = processor.ProcessNamespaceTraced(context.TODO(), *ns) })
+	if tr.Action != ActionUnmark {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionUnmark)
+	}
+	if web, db, policy, quota := quarantineState(t, processor); web != 3 || db != 1 || policy || quota {
+		t.Errorf("Replicas = %d/%d, network policy %v, quota %v; want the namespace restored", web, db, policy, quota)
+	}
+	ns, _ = processor.GetNamespace(context.TODO(), "alice")
+	if _, quarantined := ns.Annotations[QuarantinedAtAnnotation]; quarantined {
+		t.Error("The quarantine annotation should be removed")
+	}
+}
+
+// TestQuarantineDryRun validates that dry runs only plan the quarantine
+func TestQuarantineDryRun(t *testing.T) {
+	processor := newQuarantineProcessor(t, time.Now(), true)
+	ns, _ := processor.GetNamespace(context.TODO(), "alice")
+	captureLogs(func() { processor.ProcessNamespace(context.TODO(), *ns) })
+
+	if web, db, policy, quota := quarantineState(t, processor); web != 3 || db != 1 || policy || quota {
+		t.Errorf("Replicas = %d/%d, network policy %v, quota %v; want nothing changed", web, db, policy, quota)
+	}
+	planned := map[string]bool{}
+	for _, c := range processor.PlannedChanges() {
+		planned[c.Op] = true
+	}
+	for _, op := range []string{OpDeploymentUpdate, OpStatefulSetUpdate, OpNetworkPolicyCreate, OpResourceQuotaCreate} {
+		if !planned[op] {
+			t.Errorf("Expected a planned %s, got %+v", op, processor.PlannedChanges())
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PermissionCheck reports whether the auditor's service account holds a
+// permission that a planned change requires.
+type PermissionCheck struct {
+	Verb        string `json:"verb"`                  // API verb, e.g. "update"
+	Group       string `json:"group,omitempty"`       // API group, empty for the core group
+	Resource    string `json:"resource"`              // Resource, e.g. "namespaces"
+	Subresource string `json:"subresource,omitempty"` // Subresource, e.g. "finalize"
+	Allowed     bool   `json:"allowed"`               // Whether the permission is granted
+	Reason      string `json:"reason,omitempty"`      // Explanation from the authorizer, if any
+}
+
+// String renders the permission as verb and resource, e.g. "update namespaces/finalize"
+func (c PermissionCheck) String() string {
+	resource := c.Resource
+	if c.Subresource != "" {
+		resource += "/" + c.Subresource
+	}
+	if c.Group != "" {
+		resource += "." + c.Group
+	}
+	return c.Verb + " " + resource
+}
+
+// requiredPermission maps a planned change to the permission applying it
+// needs. Notifications need no Kubernetes permission.
+func requiredPermission(c PlannedChange) (PermissionCheck, bool) {
+	switch c.Op {
+	case OpCreate:
+		return PermissionCheck{Verb: "create", Resource: "namespaces"}, true
+	case OpUpdate:
+		return PermissionCheck{Verb: "update", Resource: "namespaces"}, true
+	case OpDelete:
+		return PermissionCheck{Verb: "delete", Resource: "namespaces"}, true
+	case OpFinalize:
+		return PermissionCheck{Verb: "update", Resource: "namespaces", Subresource: "finalize"}, true
+	case OpRoleBindingDelete:
+		return PermissionCheck{Verb: "delete", Group: "rbac.authorization.k8s.io", Resource: "rolebindings"}, true
+	case OpEventCreate:
+		return PermissionCheck{Verb: "create", Resource: "events"}, true
+	default:
+		return PermissionCheck{}, false
+	}
+}
+
+// CheckWritePermissions asks the API server whether the current identity may
+// apply the given planned changes, so that a read-only deployment can show
+// exactly which write access a real run would need. Each distinct
+// permission is checked once, cluster-wide.
+func CheckWritePermissions(ctx context.Context, client kubernetes.Interface, changes []PlannedChange) ([]PermissionCheck, error) {
+	var checks []PermissionCheck
+	seen := make(map[PermissionCheck]bool)
+	for _, c := range changes {
+		check, ok := requiredPermission(c)
+		if !ok || seen[check] {
+			continue
+		}
+		seen[check] = true
+
+		review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Verb:        check.Verb,
+					Group:       check.Group,
+					Resource:    check.Resource,
+					Subresource: check.Subresource,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return checks, fmt.Errorf("failed to check permission to %s: %w", check, err)
+		}
+		check.Allowed = review.Status.Allowed
+		check.Reason = review.Status.Reason
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
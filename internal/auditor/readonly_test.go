@@ -0,0 +1,55 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestCheckWritePermissions validates that each permission needed by the
+// planned changes is checked once, and that the verdict is reported
+func TestCheckWritePermissions(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var reviews int
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		review.Status.Allowed = attrs.Verb == "update" && attrs.Subresource == ""
+		return true, review, nil
+	})
+
+	checks, err := CheckWritePermissions(context.TODO(), client, []PlannedChange{
+		{Op: OpUpdate, Namespace: "a"},
+		{Op: OpUpdate, Namespace: "b"},
+		{Op: ChangeNotify, Namespace: "b"},
+		{Op: OpDelete, Namespace: "c"},
+		{Op: OpRoleBindingDelete, Namespace: "d", Object: "owner"},
+		{Op: OpEventCreate, Namespace: "c"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reviews != 4 {
+		t.Errorf("Access reviews = %d, want 4", reviews)
+	}
+
+	want := map[string]bool{
+		"update namespaces":                             true,
+		"delete namespaces":                             false,
+		"delete rolebindings.rbac.authorization.k8s.io": false,
+		"create events":                                 false,
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("Checks = %+v, want %d", checks, len(want))
+	}
+	for _, c := range checks {
+		if allowed, ok := want[c.String()]; !ok || allowed != c.Allowed {
+			t.Errorf("Unexpected check %s allowed=%t", c, c.Allowed)
+		}
+	}
+}
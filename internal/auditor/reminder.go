@@ -0,0 +1,80 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// FinalReminderLead is how long before deletion the final reminder is sent
+const FinalReminderLead = 24 * time.Hour
+
+// Reminders recorded in ReminderAnnotation
+const (
+	reminderHalfway = "halfway"
+	reminderFinal   = "final"
+)
+
+// SetReminders enables reminders to the owners of marked namespaces halfway
+// through the grace period and FinalReminderLead before deletion, in
+// addition to the notification sent when a namespace is marked.
+func (p *NamespaceProcessor) SetReminders(enabled bool) {
+	p.reminders = enabled
+}
+
+// dueReminder returns the reminder due for a namespace marked at markedAt
+// and expiring at expiry, and records it in ReminderAnnotation so that it is
+// sent once. Returns "" if none is due. The final reminder is only sent for
+// grace periods longer than FinalReminderLead, and replaces the halfway
+// reminder if both are due.
+func (p *NamespaceProcessor) dueReminder(ns corev1.Namespace, markedAt, expiry, now time.Time) string {
+	if !p.reminders {
+		return ""
+	}
+	sent := ns.Annotations[ReminderAnnotation]
+	due := ""
+	switch {
+	case sent == reminderFinal:
+	case expiry.Sub(markedAt) > FinalReminderLead && !now.Before(expiry.Add(-FinalReminderLead)):
+		due = reminderFinal
+	case sent == "" && !now.Before(markedAt.Add(expiry.Sub(markedAt)/2)):
+		due = reminderHalfway
+	}
+	if due != "" {
+		p.trace.add("reminder", "%s reminder due, deletion at %s", due, formatMarkerTime(expiry))
+		ns.Annotations[ReminderAnnotation] = due
+	}
+	return due
+}
+
+// notifyReminder reminds the owner and contributors of a marked namespace
+// that it is deleted at deleteAt.
+func (p *NamespaceProcessor) notifyReminder(ctx context.Context, ns corev1.Namespace, reminder string, deleteAt time.Time) {
+	log.Printf("Sending %s reminder for %s", reminder, ns.Name)
+	owner := ns.Annotations[OwnerAnnotation]
+	n := Notification{
+		Event:     EventReminder,
+		Namespace: ns.Name,
+		Owner:     owner,
+		Message:   fmt.Sprintf("namespace is still marked for deletion, %s could not be validated", describeOwner(ns)),
+		Context:   p.namespaceContext(ns),
+		Contact:   ns.Annotations[SecondaryContactAnnotation],
+		Manager:   ns.Annotations[ManagerAnnotation],
+		DeleteAt:  deleteAt,
+	}
+	if reminder == reminderFinal {
+		n.Event = EventFinalReminder
+		n.Message = fmt.Sprintf("namespace will be deleted within %s, %s could not be validated", FinalReminderLead, describeOwner(ns))
+	}
+
+	contributors, err := p.contributors(ctx, ns)
+	if err != nil {
+		log.Printf("Error looking up contributors of %s: %v", ns.Name, err)
+	}
+	n.Recipients = contributors
+	p.addSecurityContact(ns, &n)
+	p.notify(ctx, n)
+}
@@ -0,0 +1,94 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestReminders validates that the halfway and final reminders are sent once
+// each, with the deletion deadline and secondary contact
+func TestReminders(t *testing.T) {
+	processor := newTestProcessor(false, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{
+			OwnerAnnotation:            "alice@example.com",
+			SecondaryContactAnnotation: "lead@example.com",
+		}}},
+	}, false)
+	processor.gracePeriod = 4 * 24 * time.Hour
+	processor.SetReminders(true)
+	notifier := &recordingNotifier{}
+	processor.SetNotifier(notifier)
+	markedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	deleteAt := markedAt.Add(4 * 24 * time.Hour)
+
+	var events []NotificationEvent
+	for _, elapsed := range []time.Duration{0, 24 * time.Hour, 48 * time.Hour, 60 * time.Hour, 73 * time.Hour, 90 * time.Hour} {
+		now := markedAt.Add(elapsed)
+		processor.clock = func() time.Time { return now }
+		ns, err := processor.GetNamespace(context.TODO(), "alice")
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		sent := len(notifier.sent)
+		captureLogs(func() { processor.ProcessNamespace(context.TODO(), *ns) })
+		for _, n := range notifier.sent[sent:] {
+			events = append(events, n.Event)
+			if n.Contact != "lead@example.com" || !n.DeleteAt.Equal(deleteAt) {
+				t.Errorf("%s: Notification = %+v, want the contact and deadline %s", elapsed, n, deleteAt)
+			}
+		}
+	}
+
+	want := []NotificationEvent{EventMarked, EventReminder, EventFinalReminder}
+	if len(events) != len(want) || events[0] != want[0] || events[1] != want[1] || events[2] != want[2] {
+		t.Errorf("Notifications = %v, want %v", events, want)
+	}
+	ns, _ := processor.GetNamespace(context.TODO(), "alice")
+	if ns.Annotations[ReminderAnnotation] != reminderFinal {
+		t.Errorf("Reminder annotation = %q, want %q", ns.Annotations[ReminderAnnotation], reminderFinal)
+	}
+}
+
+// TestDueReminder validates which reminder is due over a grace period
+func TestDueReminder(t *testing.T) {
+	processor := newTestProcessor(false, nil, false)
+	processor.SetReminders(true)
+	markedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name   string
+		grace  time.Duration
+		sent   string
+		at     time.Duration
+		expect string
+	}{
+		{name: "before halfway", grace: 96 * time.Hour, at: 47 * time.Hour},
+		{name: "halfway", grace: 96 * time.Hour, at: 48 * time.Hour, expect: reminderHalfway},
+		{name: "halfway already sent", grace: 96 * time.Hour, sent: reminderHalfway, at: 60 * time.Hour},
+		{name: "final", grace: 96 * time.Hour, sent: reminderHalfway, at: 72 * time.Hour, expect: reminderFinal},
+		{name: "final replaces halfway", grace: 96 * time.Hour, at: 80 * time.Hour, expect: reminderFinal},
+		{name: "final already sent", grace: 96 * time.Hour, sent: reminderFinal, at: 90 * time.Hour},
+		{name: "short grace period", grace: 12 * time.Hour, at: 10 * time.Hour, expect: reminderHalfway},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			if tt.sent != "" {
+				ns.Annotations[ReminderAnnotation] = tt.sent
+			}
+			got := processor.dueReminder(ns, markedAt, markedAt.Add(tt.grace), markedAt.Add(tt.at))
+			if got != tt.expect {
+				t.Errorf("dueReminder = %q, want %q", got, tt.expect)
+			}
+		})
+	}
+
+	processor.SetReminders(false)
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+	if got := processor.dueReminder(ns, markedAt, markedAt.Add(96*time.Hour), markedAt.Add(90*time.Hour)); got != "" {
+		t.Errorf("Reminders disabled: dueReminder = %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package auditor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// RunReport summarizes a single audit run. It is written at the end of
+// every run so that decisions can be reviewed after the fact.
+type RunReport struct {
+	RunID             string              `json:"runId"`                       // Unique ID of the run, see RunIDAnnotation
+	ConfigHash        string              `json:"configHash"`                  // Hash of the effective configuration
+	StartedAt         time.Time           `json:"startedAt"`                   // When the run began
+	FinishedAt        time.Time           `json:"finishedAt"`                  // When the run completed
+	Config            *ConfigSnapshot     `json:"config,omitempty"`            // Effective configuration of the run
+	ConfigDrift       []ConfigDrift       `json:"configDrift,omitempty"`       // Settings on which other auditor deployments disagree
+	Aborted           string              `json:"aborted,omitempty"`           // Why the run stopped early, if it did
+	SnapshotVersion   string              `json:"snapshotVersion,omitempty"`   // resourceVersion of the namespace list the run evaluated
+	Namespaces        int                 `json:"namespaces"`                  // Number of namespaces evaluated
+	IdentityLookups   int                 `json:"identityLookups,omitempty"`   // Identity-provider calls made, when budgeted
+	IdentityCacheHits int                 `json:"identityCacheHits,omitempty"` // Identity lookups answered from the lookup cache
+	Cached            int                 `json:"cached,omitempty"`            // Namespaces skipped as unchanged since a valid-owner evaluation
+	Deferred          int                 `json:"deferred,omitempty"`          // Namespaces deferred for lack of lookup budget or backoff
+	OverBudget        []BudgetDeferral    `json:"overBudget,omitempty"`        // Deletions and marks deferred by the per-run change budgets
+	SlowStart         int                 `json:"slowStart,omitempty"`         // Percentage of namespaces processed while ramping up after a change
+	Slice             string              `json:"slice,omitempty"`             // Slice of namespaces processed by a sliced run, e.g. "2/4"
+	Actions           map[Action]int      `json:"actions,omitempty"`           // Number of namespaces by decided action
+	Errors            []DecisionError     `json:"errors,omitempty"`            // Namespaces whose evaluation or change failed
+	Marked            []MarkedNamespace   `json:"marked,omitempty"`            // Namespaces marked for deletion, with their age
+	Saved             []SavedNamespace    `json:"saved,omitempty"`             // Namespaces unmarked because their owner was validated again
+	SavedTotal        *SavedTotals        `json:"savedTotal,omitempty"`        // Namespaces saved across runs, when state is kept
+	Ownerless         []string            `json:"ownerless,omitempty"`         // Namespaces without an owner annotation
+	StuckTerminating  []StuckNamespace    `json:"stuckTerminating,omitempty"`  // Deleted namespaces that did not finish terminating in time
+	ForeignMarkers    []ForeignMarker     `json:"foreignMarkers,omitempty"`    // Deletion markers not written by the auditor, left in place
+	Flapping          []FlappingNamespace `json:"flapping,omitempty"`          // Namespaces oscillating between marked and cleared
+	ChangedDuringRun  []string            `json:"changedDuringRun,omitempty"`  // Namespaces modified by others since the snapshot, not acted on
+	ExpiredExemptions []ExpiredExemption  `json:"expiredExemptions,omitempty"` // Exemptions that expired or could not be read, audited again
+	MalformedMarkers  []MalformedMarker   `json:"malformedMarkers,omitempty"`  // Unreadable deletion markers and how they were handled
+	AwaitingApproval  []string            `json:"awaitingApproval,omitempty"`  // Expired sensitive namespaces whose deletion waits for approval
+	Retention         *RetentionResult    `json:"retention,omitempty"`         // Auditor artifacts removed by the retention policy
+	BreakGlass        *BreakGlassRecord   `json:"breakGlass,omitempty"`        // Immediate deletion requested with delete-now
+	Canary            *CanaryResult       `json:"canary,omitempty"`            // Outcome of the synthetic canary namespace check
+	ProposedConfig    *ConfigSnapshot     `json:"proposedConfig,omitempty"`    // Configuration compared against with the compare command
+	PolicyDiff        []PolicyDifference  `json:"policyDiff,omitempty"`        // Namespaces the proposed configuration would decide differently
+	PlannedChanges    []PlannedChange     `json:"plannedChanges,omitempty"`    // Changes a dry run would have made
+	Permissions       []PermissionCheck   `json:"permissions,omitempty"`       // Write permissions the planned changes need, in read-only mode
+	Traces            []*Trace            `json:"traces,omitempty"`            // Per-namespace decision traces, if enabled
+	APIUsage          map[string]OpStats  `json:"apiUsage,omitempty"`          // Kubernetes API calls made, by operation
+
+	decided []Decision // Decisions added with AddDecision, in order, see Summary
+}
+
+// ConfigSnapshot records the effective configuration of a run, so that
+// historical decisions can be interpreted against the settings in force at
+// the time. Secrets are never included.
+type ConfigSnapshot struct {
+	Version              string            `json:"version"`                        // Auditor build version
+	Commit               string            `json:"commit"`                         // Git commit the auditor was built from
+	GracePeriod          string            `json:"gracePeriod"`                    // Grace period, as a duration or in business days
+	GracePeriodByDomain  map[string]string `json:"gracePeriodByDomain,omitempty"`  // Grace periods overriding GracePeriod by owner domain
+	GraceTiers           []string          `json:"graceTiers,omitempty"`           // Grace periods by label selector, first match applies
+	GraceOverrideMax     string            `json:"graceOverrideMax,omitempty"`     // Longest grace period a namespace annotation may set
+	WorkWeek             string            `json:"workWeek,omitempty"`             // Days counted as business days
+	ClockSkew            string            `json:"clockSkew"`                      // Clock-skew tolerance
+	PauseWindows         []string          `json:"pauseWindows,omitempty"`         // Periods during which grace periods are frozen
+	ExpiryAction         string            `json:"expiryAction"`                   // Action taken once the grace period expires
+	OrphanOwner          string            `json:"orphanOwner,omitempty"`          // Fallback owner of orphaned namespaces
+	OrphanPeriod         string            `json:"orphanPeriod,omitempty"`         // How long namespaces stay orphaned before deletion
+	QuarantinePeriod     string            `json:"quarantinePeriod,omitempty"`     // How long expired namespaces are quarantined before deletion
+	InvalidDomainPolicy  string            `json:"invalidDomainPolicy"`            // Handling of owners with disallowed domains
+	InvalidDomainGrace   string            `json:"invalidDomainGrace,omitempty"`   // Grace period for owners with disallowed domains
+	OwnerlessPolicy      string            `json:"ownerlessPolicy"`                // Handling of namespaces without an owner
+	OwnerlessGrace       string            `json:"ownerlessGrace,omitempty"`       // Grace period for namespaces without an owner
+	DisabledUserPolicy   string            `json:"disabledUserPolicy"`             // Handling of disabled owners
+	DisabledUserGrace    string            `json:"disabledUserGrace,omitempty"`    // Grace period for disabled owners
+	DeletedUserGrace     string            `json:"deletedUserGrace,omitempty"`     // Grace period for soft-deleted owners
+	PreDeleteFinalizer   bool              `json:"preDeleteFinalizer,omitempty"`   // Whether deleted namespaces are held for pre-delete steps
+	PreDeleteTimeout     string            `json:"preDeleteTimeout,omitempty"`     // Longest a namespace is held by the pre-delete finalizer
+	MalformedAction      string            `json:"malformedAction"`                // Handling of unreadable deletion markers
+	MalformedMaxAge      string            `json:"malformedMaxAge,omitempty"`      // How long a marker may stay malformed before it expires
+	FlapDamping          int               `json:"flapDamping,omitempty"`          // Consecutive runs a change on a flapping namespace must persist
+	MissingConfirmations int               `json:"missingConfirmations,omitempty"` // Consecutive runs an owner must be missing before marking
+	MissingOwnerAbort    float64           `json:"missingOwnerAbort,omitempty"`    // Percentage of missing owners aborting a run
+	AllowedDomains       []string          `json:"allowedDomains"`                 // Permitted owner email domains
+	OwnerAllowlist       []string          `json:"ownerAllowlist,omitempty"`       // Owners always treated as valid
+	OwnerListFile        string            `json:"ownerListFile,omitempty"`        // File of owners always treated as valid or invalid
+	OwnerSource          string            `json:"ownerSource"`                    // Where namespace owners are read from
+	LinkSuffixes         []string          `json:"linkSuffixes,omitempty"`         // Name suffixes of companion namespaces
+	SlowStart            []int             `json:"slowStart,omitempty"`            // Percentages of namespaces processed by the first runs after a change
+	RunSlices            int               `json:"runSlices,omitempty"`            // Number of slices namespaces are processed in
+	SliceBy              string            `json:"sliceBy,omitempty"`              // How a sliced run selects its slice
+	ContextKeys          []string          `json:"contextKeys,omitempty"`          // Labels or annotations included as namespace context
+	LabelSelector        string            `json:"labelSelector"`                  // Selector identifying audited namespaces
+	NamespaceInclude     []string          `json:"namespaceInclude,omitempty"`     // Name patterns of the namespaces audited
+	NamespaceExclude     []string          `json:"namespaceExclude,omitempty"`     // Name patterns of namespaces never touched
+	AuditPolicies        bool              `json:"auditPolicies,omitempty"`        // Whether NamespaceAuditPolicy objects apply
+	Provider             string            `json:"provider"`                       // Identity provider used for owner lookups
+	AzureTenantID        string            `json:"azureTenantId,omitempty"`        // Azure tenant of owner lookups
+	AzureClientID        string            `json:"azureClientId,omitempty"`        // Azure application of owner lookups
+	DryRun               bool              `json:"dryRun"`                         // Whether mutations were disabled
+	ReadOnly             bool              `json:"readOnly,omitempty"`             // Whether the run used read permissions only
+}
+
+// NewRunReport creates an empty report for a run starting now.
+func NewRunReport(runID, configHash string) *RunReport {
+	return &RunReport{
+		RunID:      runID,
+		ConfigHash: configHash,
+		StartedAt:  time.Now().UTC(),
+	}
+}
+
+// NewRunID generates a unique, time-ordered identifier for an audit run,
+// e.g. "20240101T000000Z-1a2b3c".
+func NewRunID() string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		panic(err) // crypto/rand never fails on supported platforms
+	}
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// AddTrace appends a namespace decision trace to the report.
+func (r *RunReport) AddTrace(t *Trace) {
+	r.Traces = append(r.Traces, t)
+}
+
+// AddOwnerless records a namespace that has no owner annotation.
+func (r *RunReport) AddOwnerless(name string) {
+	r.Ownerless = append(r.Ownerless, name)
+}
+
+// Finish stamps the completion time of the run.
+func (r *RunReport) Finish() {
+	r.FinishedAt = time.Now().UTC()
+}
+
+// WriteJSON encodes the report as indented JSON.
+func (r *RunReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,96 @@
+package auditor
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRunReportJSON validates report serialization including traces
+func TestRunReportJSON(t *testing.T) {
+	report := NewRunReport("", "")
+	report.Namespaces = 1
+	report.AddTrace(&Trace{
+		Namespace: "test-ns",
+		Steps:     []TraceStep{{Step: "owner", Detail: "owner annotation is \"user@example.com\""}},
+		Action:    ActionMark,
+	})
+	report.Finish()
+
+	var buf strings.Builder
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+
+	var decoded RunReport
+	if err := json.Unmarshal([]byte(buf.String()), &decoded); err != nil {
+		t.Fatalf("Report is not valid JSON: %v", err)
+	}
+	if len(decoded.Traces) != 1 || decoded.Traces[0].Action != ActionMark {
+		t.Errorf("Trace not preserved in report: %+v", decoded.Traces)
+	}
+	if decoded.FinishedAt.Before(decoded.StartedAt) {
+		t.Error("Finish time should not precede start time")
+	}
+}
+
+// TestRunReportOmitsEmptyTraces ensures traces are only present when recorded
+func TestRunReportOmitsEmptyTraces(t *testing.T) {
+	var buf strings.Builder
+	if err := NewRunReport("", "").WriteJSON(&buf); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	if strings.Contains(buf.String(), "traces") {
+		t.Errorf("Empty traces should be omitted: %s", buf.String())
+	}
+}
+
+// TestRunReportOwnerless validates the ownerless namespace section
+func TestRunReportOwnerless(t *testing.T) {
+	report := NewRunReport("", "")
+	report.AddOwnerless("orphan")
+
+	var buf strings.Builder
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"ownerless": [`) || !strings.Contains(buf.String(), `"orphan"`) {
+		t.Errorf("Ownerless section missing: %s", buf.String())
+	}
+}
+
+// TestRunReportConfig validates the embedded configuration snapshot
+func TestRunReportConfig(t *testing.T) {
+	report := NewRunReport("", "")
+	report.Config = &ConfigSnapshot{Version: "v1.2.3", GracePeriod: "720h0m0s", DryRun: true}
+
+	var buf strings.Builder
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+
+	var decoded RunReport
+	if err := json.Unmarshal([]byte(buf.String()), &decoded); err != nil {
+		t.Fatalf("Report is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Config, report.Config) {
+		t.Errorf("Config not preserved in report: %+v", decoded.Config)
+	}
+}
+
+// TestNewRunID validates run ID format and uniqueness
+func TestNewRunID(t *testing.T) {
+	a, b := NewRunID(), NewRunID()
+	if a == b {
+		t.Errorf("Run IDs should be unique: %q", a)
+	}
+	if len(a) != len("20060102T150405Z-000000") {
+		t.Errorf("Unexpected run ID format: %q", a)
+	}
+
+	report := NewRunReport(a, "hash")
+	if report.RunID != a || report.ConfigHash != "hash" {
+		t.Errorf("Run info not recorded in report: %+v", report)
+	}
+}
@@ -0,0 +1,139 @@
+package auditor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy bounds how long the auditor keeps its own artifacts, so
+// that the audit system does not become a source of clutter itself. A zero
+// duration keeps the artifact forever.
+type RetentionPolicy struct {
+	Reports     time.Duration // Age after which per-run reports are removed, see ReportCleaner
+	Instances   time.Duration // Time after which deployments that stopped running are forgotten, see State.Instances
+	Evaluations time.Duration // Age after which cached evaluations are dropped, see State.Evaluations
+}
+
+// RetentionResult lists the artifacts removed, or that a dry run would
+// remove, by ApplyRetention.
+type RetentionResult struct {
+	Reports     []string `json:"reports,omitempty"`     // Report files removed
+	Instances   []string `json:"instances,omitempty"`   // Auditor deployments forgotten
+	Evaluations []string `json:"evaluations,omitempty"` // Namespaces whose cached evaluation was dropped
+	Errors      []string `json:"errors,omitempty"`      // Artifacts that could not be removed
+}
+
+// ReportCleaner is optionally implemented by report sinks that keep one
+// artifact per run. RemoveReportsBefore removes the artifacts last written
+// before cutoff, or only lists them if dryRun is set.
+type ReportCleaner interface {
+	RemoveReportsBefore(cutoff time.Time, dryRun bool) ([]string, error)
+}
+
+// ApplyRetention removes the artifacts older than policy allows at now: the
+// per-run reports of sinks implementing ReportCleaner, and the deployments and
+// cached evaluations recorded in state. Dry runs list report files without
+// removing them.
+// Returns nil if nothing was found.
+func ApplyRetention(policy RetentionPolicy, sinks []ReportSink, state *State, now time.Time, dryRun bool) *RetentionResult {
+	result := &RetentionResult{}
+	if policy.Reports > 0 {
+		for _, sink := range sinks {
+			cleaner, ok := sink.(ReportCleaner)
+			if !ok {
+				continue
+			}
+			removed, err := cleaner.RemoveReportsBefore(now.Add(-policy.Reports), dryRun)
+			result.Reports = append(result.Reports, removed...)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", sink, err))
+			}
+		}
+	}
+	if policy.Instances > 0 && state != nil {
+		result.Instances = state.PruneInstances(now.Add(-policy.Instances))
+	}
+	if policy.Evaluations > 0 && state != nil {
+		result.Evaluations = state.PruneEvaluations(now.Add(-policy.Evaluations))
+	}
+	if len(result.Reports) == 0 && len(result.Instances) == 0 && len(result.Evaluations) == 0 && len(result.Errors) == 0 {
+		return nil
+	}
+	return result
+}
+
+// PruneInstances forgets the deployments that last ran before cutoff and
+// returns their names, sorted.
+func (s *State) PruneInstances(cutoff time.Time) []string {
+	var pruned []string
+	for name, instance := range s.Instances {
+		if instance.SeenAt.Before(cutoff) {
+			pruned = append(pruned, name)
+			delete(s.Instances, name)
+		}
+	}
+	sort.Strings(pruned)
+	return pruned
+}
+
+// PruneEvaluations drops the cached evaluations made before cutoff and
+// returns the names of their namespaces, sorted.
+func (s *State) PruneEvaluations(cutoff time.Time) []string {
+	var pruned []string
+	for name, e := range s.Evaluations {
+		if e.EvaluatedAt.Before(cutoff) {
+			pruned = append(pruned, name)
+			delete(s.Evaluations, name)
+		}
+	}
+	sort.Strings(pruned)
+	return pruned
+}
+
+// removeRunFiles removes the files matching a per-run path template, one
+// with "{runId}" in it, that were last modified before cutoff. Templates
+// without "{runId}" name a single file overwritten by every run, which is
+// never removed.
+func removeRunFiles(template string, cutoff time.Time, dryRun bool) ([]string, error) {
+	if !strings.Contains(template, "{runId}") {
+		return nil, nil
+	}
+	matches, err := filepath.Glob(strings.ReplaceAll(filepath.Clean(template), "{runId}", "*"))
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	var failures []error
+	for _, name := range matches {
+		info, err := os.Stat(name)
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(name); err != nil {
+				failures = append(failures, err)
+				continue
+			}
+		}
+		removed = append(removed, name)
+	}
+	return removed, errors.Join(failures...)
+}
+
+// RemoveReportsBefore removes the reports written before cutoff when the
+// path contains "{runId}". Every file matching the path with "{runId}" as a
+// wildcard counts as a report.
+func (s FileSink) RemoveReportsBefore(cutoff time.Time, dryRun bool) ([]string, error) {
+	return removeRunFiles(s.Path, cutoff, dryRun)
+}
+
+// RemoveReportsBefore removes the pages written before cutoff when the path
+// contains "{runId}", see FileSink.RemoveReportsBefore.
+func (s HTMLFileSink) RemoveReportsBefore(cutoff time.Time, dryRun bool) ([]string, error) {
+	return removeRunFiles(s.Path, cutoff, dryRun)
+}
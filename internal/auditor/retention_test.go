@@ -0,0 +1,81 @@
+package auditor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestApplyRetention validates that expired per-run reports and deployments
+// are removed, and only those
+func TestApplyRetention(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	write := func(name string, age time.Duration) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	old := write("run-old.json", 48*time.Hour)
+	recent := write("run-new.json", time.Hour)
+	latest := write("latest.html", 48*time.Hour) // Overwritten by every run, never removed
+
+	sinks := []ReportSink{
+		FileSink{Path: filepath.Join(dir, "run-{runId}.json")},
+		HTMLFileSink{Path: latest},
+		WriterSink{Name: "stdout", W: os.Stdout},
+	}
+	state := &State{
+		Instances: map[string]InstanceConfig{
+			"current": {SeenAt: now},
+			"retired": {SeenAt: now.Add(-30 * 24 * time.Hour)},
+		},
+		Evaluations: map[string]Evaluation{
+			"recent": {EvaluatedAt: now.Add(-time.Hour)},
+			"stale":  {EvaluatedAt: now.Add(-10 * 24 * time.Hour)},
+		},
+	}
+	policy := RetentionPolicy{Reports: 24 * time.Hour, Instances: 7 * 24 * time.Hour, Evaluations: 7 * 24 * time.Hour}
+
+	result := ApplyRetention(policy, sinks, state, now, true)
+	if result == nil || !reflect.DeepEqual(result.Reports, []string{old}) {
+		t.Fatalf("Dry run result = %+v, want %s listed", result, old)
+	}
+	if _, err := os.Stat(old); err != nil {
+		t.Errorf("Dry run removed %s: %v", old, err)
+	}
+
+	// Dry runs do not save state, so forgetting deployments is harmless there
+	state.Instances["retired"] = InstanceConfig{SeenAt: now.Add(-30 * 24 * time.Hour)}
+	state.Evaluations["stale"] = Evaluation{EvaluatedAt: now.Add(-10 * 24 * time.Hour)}
+	result = ApplyRetention(policy, sinks, state, now, false)
+	if !reflect.DeepEqual(result.Reports, []string{old}) || !reflect.DeepEqual(result.Instances, []string{"retired"}) ||
+		!reflect.DeepEqual(result.Evaluations, []string{"stale"}) {
+		t.Errorf("Result = %+v", result)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", old, err)
+	}
+	for _, kept := range []string{recent, latest} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("Expected %s to be kept: %v", kept, err)
+		}
+	}
+	if _, ok := state.Instances["current"]; !ok || len(state.Instances) != 1 {
+		t.Errorf("Instances = %v, want only current", state.Instances)
+	}
+	if _, ok := state.Evaluations["recent"]; !ok || len(state.Evaluations) != 1 {
+		t.Errorf("Evaluations = %v, want only recent", state.Evaluations)
+	}
+
+	if result := ApplyRetention(RetentionPolicy{}, sinks, state, now, false); result != nil {
+		t.Errorf("Expected nothing removed without a policy, got %+v", result)
+	}
+}
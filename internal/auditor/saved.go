@@ -0,0 +1,59 @@
+package auditor
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SavedNamespace describes a namespace whose deletion marker was removed
+// because its owner was validated again before the grace period expired,
+// e.g. after the account was restored or the owner annotation fixed.
+type SavedNamespace struct {
+	Name      string    `json:"name"`                // Namespace name
+	Owner     string    `json:"owner"`               // Owner email validated again
+	MarkedAt  time.Time `json:"markedAt,omitempty"`  // When the deletion marker was set, if readable
+	MarkedFor string    `json:"markedFor,omitempty"` // Time between marking and the owner being validated again
+	Remaining string    `json:"remaining,omitempty"` // Grace period left when the marker was removed
+	MarkedBy  string    `json:"markedBy,omitempty"`  // Provenance of the verdict the owner was missing, see VerdictSourceAnnotation
+	FoundBy   string    `json:"foundBy,omitempty"`   // Provenance of the verdict the owner exists
+}
+
+// SavedTotals counts the namespaces saved across runs, so that the value of
+// the grace period can be shown over time.
+type SavedTotals struct {
+	Total int       `json:"total"` // Namespaces saved since Since
+	Since time.Time `json:"since"` // When counting started
+}
+
+// Saved returns the namespaces saved during this run, in processing order.
+func (p *NamespaceProcessor) Saved() []SavedNamespace {
+	return p.saved.list()
+}
+
+// AddSaved counts the namespaces saved by a run, starting the count at now
+// if it was never started. It returns the updated totals.
+func (t SavedTotals) AddSaved(saved int, now time.Time) SavedTotals {
+	if t.Since.IsZero() {
+		t.Since = now.UTC()
+	}
+	t.Total += saved
+	return t
+}
+
+// savedEntry describes a namespace whose marker is about to be removed because
+// its owner is valid again, for the run report once the marker is gone
+func (p *NamespaceProcessor) savedEntry(ns corev1.Namespace, now time.Time) SavedNamespace {
+	entry := SavedNamespace{Name: ns.Name, Owner: ns.Annotations[OwnerAnnotation], MarkedBy: ns.Annotations[VerdictSourceAnnotation]}
+	if p.trace != nil && p.trace.Source != nil {
+		entry.FoundBy = p.trace.Source.String()
+	}
+	if markedAt, err := parseMarkerTime(ns.Annotations[GracePeriodAnnotation]); err == nil {
+		entry.MarkedAt = markedAt.UTC()
+		entry.MarkedFor = now.Sub(markedAt).Round(time.Second).String()
+		if left := p.graceExpiry(ns, markedAt).Sub(now); left > 0 {
+			entry.Remaining = left.Round(time.Second).String()
+		}
+	}
+	return entry
+}
@@ -0,0 +1,76 @@
+package auditor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestSaved validates that namespaces unmarked because their owner is valid
+// again are reported, and only those
+func TestSaved(t *testing.T) {
+	markedAt := time.Now().Add(-6 * time.Hour).UTC().Truncate(time.Second)
+	p := newTestProcessor(true, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "restored", Annotations: map[string]string{
+			OwnerAnnotation:       "user@example.com",
+			GracePeriodAnnotation: formatMarkerTime(markedAt),
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "unmarked", Annotations: map[string]string{
+			OwnerAnnotation: "user@example.com",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "ownerless", Annotations: map[string]string{
+			GracePeriodAnnotation: formatMarkerTime(markedAt),
+		}}},
+	}, false)
+
+	for _, name := range []string{"restored", "unmarked", "ownerless"} {
+		runOnce(t, p, name, true)
+	}
+	saved := p.Saved()
+	if len(saved) != 1 || saved[0].Name != "restored" || saved[0].Owner != "user@example.com" {
+		t.Fatalf("Saved = %+v, want only restored", saved)
+	}
+	markedFor, _ := time.ParseDuration(saved[0].MarkedFor)
+	remaining, _ := time.ParseDuration(saved[0].Remaining)
+	if !saved[0].MarkedAt.Equal(markedAt) || (markedFor-6*time.Hour).Abs() > time.Minute || (remaining-18*time.Hour).Abs() > time.Minute {
+		t.Errorf("Saved = %+v, want marked 6h ago with 18h of the 24h grace period left", saved[0])
+	}
+}
+
+// TestSavedUpdateFailed validates that a namespace is not reported as saved
+// when removing its marker fails
+func TestSavedUpdateFailed(t *testing.T) {
+	p := newTestProcessor(true, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "restored", Annotations: map[string]string{
+			OwnerAnnotation:       "user@example.com",
+			GracePeriodAnnotation: formatMarkerTime(time.Now().Add(-6 * time.Hour)),
+		}}},
+	}, false)
+	p.k8sClient.(*fake.Clientset).PrependReactor("update", "namespaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("conflict")
+	})
+
+	runOnce(t, p, "restored", true)
+	if saved := p.Saved(); len(saved) != 0 {
+		t.Errorf("Saved = %+v, want none after a failed update", saved)
+	}
+}
+
+// TestSavedTotals validates counting saved namespaces across runs
+func TestSavedTotals(t *testing.T) {
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	totals := SavedTotals{}.AddSaved(0, first)
+	if totals.Total != 0 || !totals.Since.Equal(first) {
+		t.Errorf("First run: %+v, want counting to start", totals)
+	}
+	totals = totals.AddSaved(3, first.Add(time.Hour)).AddSaved(2, first.Add(2*time.Hour))
+	if totals.Total != 5 || !totals.Since.Equal(first) {
+		t.Errorf("Totals = %+v, want 5 since %s", totals, first)
+	}
+}
@@ -0,0 +1,137 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultClassificationLabel is the namespace label whose value selects a
+// SensitivePolicy unless configured otherwise.
+const DefaultClassificationLabel = "data-classification"
+
+// SensitivePolicy applies to namespaces holding data of a given
+// classification, e.g. "protected-b".
+type SensitivePolicy struct {
+	Contact         string // Security contact told about every notification, if set
+	RequireApproval bool   // Whether deletion waits for DeletionApprovedAnnotation
+}
+
+// ParseSensitivePolicies builds the policies per classification from a
+// comma-separated list of "classification=contact" security contacts, e.g.
+// "protected-b=security@example.com", and a comma-separated list of
+// classifications whose namespaces need approval before deletion.
+func ParseSensitivePolicies(contacts, approval string) (map[string]SensitivePolicy, error) {
+	policies := make(map[string]SensitivePolicy)
+	for _, entry := range strings.Split(contacts, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		class, contact, ok := strings.Cut(entry, "=")
+		class, contact = strings.TrimSpace(class), strings.TrimSpace(contact)
+		if !ok || class == "" || !strings.Contains(contact, "@") {
+			return nil, fmt.Errorf("security contact %q must be in classification=email form", entry)
+		}
+		policy := policies[class]
+		policy.Contact = contact
+		policies[class] = policy
+	}
+	for _, class := range strings.Split(approval, ",") {
+		if class = strings.TrimSpace(class); class == "" {
+			continue
+		}
+		policy := policies[class]
+		policy.RequireApproval = true
+		policies[class] = policy
+	}
+	return policies, nil
+}
+
+// SetSensitivePolicies applies policies to namespaces by the value of their
+// label classification label, see DefaultClassificationLabel.
+func (p *NamespaceProcessor) SetSensitivePolicies(label string, policies map[string]SensitivePolicy) {
+	p.classificationLabel = label
+	p.sensitive = policies
+}
+
+// AwaitingApproval returns the expired namespaces whose deletion is held
+// until it is approved.
+func (p *NamespaceProcessor) AwaitingApproval() []string {
+	return p.awaiting.list()
+}
+
+// sensitivePolicy returns the policy applying to ns, if any
+func (p *NamespaceProcessor) sensitivePolicy(ns corev1.Namespace) (string, SensitivePolicy, bool) {
+	class, ok := ns.Labels[p.classificationLabel]
+	if !ok || p.classificationLabel == "" {
+		return "", SensitivePolicy{}, false
+	}
+	policy, ok := p.sensitive[class]
+	return class, policy, ok
+}
+
+// addSecurityContact adds the security contact of a sensitive namespace to
+// the recipients of n
+func (p *NamespaceProcessor) addSecurityContact(ns corev1.Namespace, n *Notification) {
+	_, policy, ok := p.sensitivePolicy(ns)
+	if !ok || policy.Contact == "" || contains(n.Recipients, policy.Contact) {
+		return
+	}
+	n.Recipients = append(n.Recipients, policy.Contact)
+}
+
+// awaitingApproval reports whether the deletion of an expired namespace
+// must wait for approval: one for sensitive namespaces, two distinct ones
+// for namespaces above the two-person threshold. Approval is requested once
+// per marker, from the security contact if there is one.
+func (p *NamespaceProcessor) awaitingApproval(ctx context.Context, ns corev1.Namespace, now time.Time) bool {
+	required, reason := 0, ""
+	class, policy, sensitive := p.sensitivePolicy(ns)
+	if sensitive && policy.RequireApproval {
+		required, reason = 1, fmt.Sprintf("classification %q", class)
+	}
+	if why, ok := p.needsTwoPersons(ctx, ns); ok {
+		required, reason = 2, why
+	}
+	if required == 0 {
+		return false
+	}
+	if names := approvers(ns); len(names) >= required {
+		p.trace.add("approval", "%s, deletion approved by %s", reason, strings.Join(names, " and "))
+		return false
+	}
+
+	needed, instruction := DeletionApprovedAnnotation, fmt.Sprintf("set %s to the approver", DeletionApprovedAnnotation)
+	if required == 2 {
+		needed = "two distinct approvals"
+		instruction = fmt.Sprintf("set %s and %s to two different approvers", DeletionApprovedAnnotation, SecondApprovalAnnotation)
+	}
+	p.trace.add("approval", "%s requires %s before deletion", reason, needed)
+	p.trace.setAction(ActionWait)
+	p.awaiting.add(ns.Name)
+	if _, requested := ns.Annotations[ApprovalRequestedAnnotation]; requested {
+		return true
+	}
+
+	log.Printf("Requesting approval to delete %s (%s)", ns.Name, reason)
+	ns.Annotations[ApprovalRequestedAnnotation] = formatMarkerTime(now)
+	if err := p.updateNamespace(ctx, &ns); err != nil {
+		log.Printf("Error recording approval request on %s: %v", ns.Name, err)
+		return true
+	}
+	n := Notification{
+		Event:     EventApprovalRequired,
+		Namespace: ns.Name,
+		Owner:     ns.Annotations[OwnerAnnotation],
+		Message:   fmt.Sprintf("grace period expired, deletion waits for approval (%s): %s", reason, instruction),
+		Context:   p.namespaceContext(ns),
+	}
+	p.addSecurityContact(ns, &n)
+	p.notify(ctx, n)
+	return true
+}
@@ -0,0 +1,142 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParseSensitivePolicies validates parsing contacts and approval requirements
+func TestParseSensitivePolicies(t *testing.T) {
+	policies, err := ParseSensitivePolicies(" protected-b = security@example.com ,protected-a=infosec@example.com", "protected-b, secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := map[string]SensitivePolicy{
+		"protected-b": {Contact: "security@example.com", RequireApproval: true},
+		"protected-a": {Contact: "infosec@example.com"},
+		"secret":      {RequireApproval: true},
+	}
+	if len(policies) != len(want) {
+		t.Fatalf("Policies = %+v, want %+v", policies, want)
+	}
+	for class, policy := range want {
+		if policies[class] != policy {
+			t.Errorf("Policy of %s = %+v, want %+v", class, policies[class], policy)
+		}
+	}
+
+	for _, contacts := range []string{"protected-b", "protected-b=", "=security@example.com", "protected-b=security"} {
+		if _, err := ParseSensitivePolicies(contacts, ""); err == nil {
+			t.Errorf("Expected an error for %q", contacts)
+		}
+	}
+}
+
+// sensitiveNamespace builds an expired protected-b namespace with the given
+// extra annotations
+func sensitiveNamespace(annotations map[string]string) *corev1.Namespace {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "sensitive",
+		Labels: map[string]string{DefaultClassificationLabel: "protected-b"},
+		Annotations: map[string]string{
+			OwnerAnnotation:       "gone@example.com",
+			GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+		},
+	}}
+	for k, v := range annotations {
+		ns.Annotations[k] = v
+	}
+	return ns
+}
+
+// newSensitiveProcessor returns a processor requiring approval for protected-b namespaces
+func newSensitiveProcessor(ns *corev1.Namespace, notifier Notifier) *NamespaceProcessor {
+	p := newTestProcessor(false, []*corev1.Namespace{ns}, false)
+	p.SetNotifier(notifier)
+	p.SetSensitivePolicies(DefaultClassificationLabel, map[string]SensitivePolicy{
+		"protected-b": {Contact: "security@example.com", RequireApproval: true},
+	})
+	return p
+}
+
+// TestSensitiveDeletionAwaitsApproval validates that an expired sensitive
+// namespace is held, and its security contact asked for approval once
+func TestSensitiveDeletionAwaitsApproval(t *testing.T) {
+	ns := sensitiveNamespace(nil)
+	notifier := &recordingNotifier{}
+	processor := newSensitiveProcessor(ns, notifier)
+
+	for run := 0; run < 2; run++ {
+		current, err := processor.GetNamespace(context.TODO(), ns.Name)
+		if err != nil {
+			t.Fatalf("Namespace must not be deleted without approval: %v", err)
+		}
+		var tr *Trace
+		captureLogs(func() {
+			tr = processor.ProcessNamespaceTraced(context.TODO(), *current)
+		})
+		if tr.Action != ActionWait {
+			t.Errorf("Run %d: action = %q, want %q", run, tr.Action, ActionWait)
+		}
+	}
+
+	if len(notifier.sent) != 1 || notifier.sent[0].Event != EventApprovalRequired ||
+		!contains(notifier.sent[0].Recipients, "security@example.com") {
+		t.Errorf("Expected one approval request to the security contact, got %+v", notifier.sent)
+	}
+	if got := processor.AwaitingApproval(); len(got) != 2 || got[0] != ns.Name {
+		t.Errorf("AwaitingApproval = %v", got)
+	}
+}
+
+// TestSensitiveDeletionApproved validates that an approved namespace is deleted
+func TestSensitiveDeletionApproved(t *testing.T) {
+	ns := sensitiveNamespace(map[string]string{DeletionApprovedAnnotation: "ciso@example.com"})
+	processor := newSensitiveProcessor(ns, &recordingNotifier{})
+
+	var tr *Trace
+	captureLogs(func() {
+		tr = processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+	})
+	if tr.Action != ActionDelete {
+		t.Errorf("Action = %q, want %q", tr.Action, ActionDelete)
+	}
+	if _, err := processor.GetNamespace(context.TODO(), ns.Name); !apierrors.IsNotFound(err) {
+		t.Errorf("Approved namespace should be deleted, got %v", err)
+	}
+}
+
+// TestSecurityContactNotified validates that the security contact of a
+// sensitive namespace is told when it is marked
+func TestSecurityContactNotified(t *testing.T) {
+	ns := sensitiveNamespace(nil)
+	delete(ns.Annotations, GracePeriodAnnotation)
+	notifier := &recordingNotifier{}
+	processor := newSensitiveProcessor(ns, notifier)
+
+	captureLogs(func() {
+		processor.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+	})
+	if len(notifier.sent) != 1 || notifier.sent[0].Event != EventMarked ||
+		!contains(notifier.sent[0].Recipients, "security@example.com") {
+		t.Errorf("Expected the security contact among the recipients, got %+v", notifier.sent)
+	}
+}
+
+// TestClearMarkerRemovesApproval ensures an approval never outlives its marker
+func TestClearMarkerRemovesApproval(t *testing.T) {
+	annotations := map[string]string{
+		GracePeriodAnnotation:       "2020-01-01T00:00:00Z",
+		ApprovalRequestedAnnotation: "2020-01-31T00:00:00Z",
+		DeletionApprovedAnnotation:  "ciso@example.com",
+		SecondApprovalAnnotation:    "dpo@example.com",
+	}
+	clearMarker(annotations)
+	if len(annotations) != 0 {
+		t.Errorf("Approval annotations left behind: %v", annotations)
+	}
+}
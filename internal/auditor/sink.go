@@ -0,0 +1,198 @@
+package auditor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// reportSinkTimeout bounds each upload of a report to a remote sink
+const reportSinkTimeout = 30 * time.Second
+
+// ReportSink is a destination for run reports. Several sinks can be used
+// at once; each receives the complete report.
+type ReportSink interface {
+	WriteReport(ctx context.Context, r *RunReport) error
+	String() string // Describes the destination for logs, without secrets
+}
+
+// ParseReportSinks parses a comma-separated list of report destinations:
+//   - "stdout": standard output (the default)
+//   - "file:<path>": a local file; "{runId}" in the path is replaced by the run ID
+//   - "http://..." or "https://...": POSTed as JSON to the endpoint
+//   - "blob:<container URL with SAS token>": uploaded to Azure Blob Storage as <runId>.json
+//   - "html:<path>": a static HTML page of marked namespaces, see WriteHTML
+func ParseReportSinks(value string) ([]ReportSink, error) {
+	if strings.TrimSpace(value) == "" {
+		return []ReportSink{WriterSink{Name: "stdout", W: os.Stdout}}, nil
+	}
+
+	var sinks []ReportSink
+	for _, spec := range strings.Split(value, ",") {
+		spec = strings.TrimSpace(spec)
+		switch {
+		case spec == "":
+			continue
+		case spec == "stdout":
+			sinks = append(sinks, WriterSink{Name: "stdout", W: os.Stdout})
+		case strings.HasPrefix(spec, "file:"):
+			p := strings.TrimPrefix(spec, "file:")
+			if p == "" {
+				return nil, fmt.Errorf("report sink %q: missing file path", spec)
+			}
+			sinks = append(sinks, FileSink{Path: p})
+		case strings.HasPrefix(spec, "html:"):
+			p := strings.TrimPrefix(spec, "html:")
+			if p == "" {
+				return nil, fmt.Errorf("report sink %q: missing file path", spec)
+			}
+			sinks = append(sinks, HTMLFileSink{Path: p})
+		case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+			u, err := url.Parse(spec)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("report sink %q: invalid URL", spec)
+			}
+			sinks = append(sinks, HTTPSink{URL: u})
+		case strings.HasPrefix(spec, "blob:"):
+			u, err := url.Parse(strings.TrimPrefix(spec, "blob:"))
+			if err != nil || u.Scheme != "https" || u.Host == "" {
+				return nil, fmt.Errorf("report sink %q: expected an https container URL", redact(spec))
+			}
+			sinks = append(sinks, BlobSink{Container: u})
+		default:
+			return nil, fmt.Errorf("unknown report sink %q, expected stdout, file:<path>, html:<path>, an http(s) URL or blob:<container URL>", redact(spec))
+		}
+	}
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("no report sinks configured")
+	}
+	return sinks, nil
+}
+
+// redact strips the query string, which may hold credentials, from a sink spec
+func redact(spec string) string {
+	before, _, _ := strings.Cut(spec, "?")
+	return before
+}
+
+// WriterSink writes reports as indented JSON to a writer.
+type WriterSink struct {
+	Name string
+	W    io.Writer
+}
+
+// WriteReport encodes the report to the writer.
+func (s WriterSink) WriteReport(_ context.Context, r *RunReport) error {
+	return r.WriteJSON(s.W)
+}
+
+// String returns the name of the writer.
+func (s WriterSink) String() string { return s.Name }
+
+// FileSink writes reports to a local file, replacing "{runId}" in the path.
+type FileSink struct {
+	Path string
+}
+
+// WriteReport writes the report, overwriting any existing file.
+func (s FileSink) WriteReport(_ context.Context, r *RunReport) error {
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(strings.ReplaceAll(s.Path, "{runId}", r.RunID), buf.Bytes(), 0o644)
+}
+
+// String describes the file destination.
+func (s FileSink) String() string { return "file:" + s.Path }
+
+// HTMLFileSink writes reports as a static HTML page to a local file,
+// replacing "{runId}" in the path.
+type HTMLFileSink struct {
+	Path string
+}
+
+// WriteReport renders the report, overwriting any existing file.
+func (s HTMLFileSink) WriteReport(_ context.Context, r *RunReport) error {
+	var buf bytes.Buffer
+	if err := r.WriteHTML(&buf, time.Now()); err != nil {
+		return err
+	}
+	return os.WriteFile(strings.ReplaceAll(s.Path, "{runId}", r.RunID), buf.Bytes(), 0o644)
+}
+
+// String describes the file destination.
+func (s HTMLFileSink) String() string { return "html:" + s.Path }
+
+// HTTPSink POSTs reports as JSON to an HTTP endpoint.
+type HTTPSink struct {
+	URL *url.URL
+}
+
+// WriteReport posts the report, failing on non-2xx responses.
+func (s HTTPSink) WriteReport(ctx context.Context, r *RunReport) error {
+	return upload(ctx, http.MethodPost, s.URL.String(), r, nil)
+}
+
+// String returns the endpoint without its query string.
+func (s HTTPSink) String() string { return redact(s.URL.String()) }
+
+// BlobSink uploads reports as <runId>.json block blobs into an Azure Blob
+// Storage container addressed by a SAS URL.
+type BlobSink struct {
+	Container *url.URL
+}
+
+// WriteReport uploads the report.
+func (s BlobSink) WriteReport(ctx context.Context, r *RunReport) error {
+	blob := *s.Container
+	blob.Path = path.Join(blob.Path, r.RunID+".json")
+	return upload(ctx, http.MethodPut, blob.String(), r, map[string]string{"x-ms-blob-type": "BlockBlob"})
+}
+
+// String returns the container URL without its SAS token.
+func (s BlobSink) String() string { return "blob:" + redact(s.Container.String()) }
+
+// upload sends the report as JSON with the given method and extra headers
+func upload(ctx context.Context, method, target string, r *RunReport, headers map[string]string) error {
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reportSinkTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, target, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading report: %w", redactURLError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("uploading report: unexpected response %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return nil
+}
+
+// redactURLError removes credentials carried in the URL from request errors
+func redactURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return fmt.Errorf("%s %s: %w", urlErr.Op, redact(urlErr.URL), urlErr.Err)
+	}
+	return err
+}
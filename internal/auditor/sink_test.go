@@ -0,0 +1,107 @@
+package auditor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseReportSinks validates sink specifications
+func TestParseReportSinks(t *testing.T) {
+	sinks, err := ParseReportSinks("stdout, file:/tmp/{runId}.json, https://reports.example.com/ingest, blob:https://acct.blob.core.windows.net/reports?sig=secret, html:/srv/site/index.html")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var names []string
+	for _, s := range sinks {
+		names = append(names, s.String())
+	}
+	want := "stdout,file:/tmp/{runId}.json,https://reports.example.com/ingest,blob:https://acct.blob.core.windows.net/reports,html:/srv/site/index.html"
+	if strings.Join(names, ",") != want {
+		t.Errorf("Sinks = %v, want %s", names, want)
+	}
+
+	if sinks, err := ParseReportSinks(""); err != nil || len(sinks) != 1 || sinks[0].String() != "stdout" {
+		t.Errorf("Expected stdout default, got %v, %v", sinks, err)
+	}
+
+	for _, bad := range []string{"ftp://x", "file:", "html:", "blob:http://insecure/c", ",", "blob:?sig=secret&x"} {
+		_, err := ParseReportSinks(bad)
+		if err == nil {
+			t.Errorf("Expected error for %q", bad)
+		} else if strings.Contains(err.Error(), "secret") {
+			t.Errorf("Error leaks credentials: %v", err)
+		}
+	}
+}
+
+// TestFileSink validates writing reports to run-specific files
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	sink := FileSink{Path: filepath.Join(dir, "report-{runId}.json")}
+	if err := sink.WriteReport(context.TODO(), NewRunReport("run-1", "abc")); err != nil {
+		t.Fatalf("WriteReport failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "report-run-1.json"))
+	if err != nil {
+		t.Fatalf("Report file missing: %v", err)
+	}
+	var got RunReport
+	if err := json.Unmarshal(data, &got); err != nil || got.RunID != "run-1" {
+		t.Errorf("Unexpected report %+v, %v", got, err)
+	}
+}
+
+// TestRemoteSinks validates HTTP and blob uploads
+func TestRemoteSinks(t *testing.T) {
+	type request struct {
+		method, path, blobType string
+		body                   RunReport
+	}
+	var got []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body RunReport
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		got = append(got, request{r.Method, r.URL.Path, r.Header.Get("x-ms-blob-type"), body})
+		if strings.Contains(r.URL.Path, "broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	sinks, err := ParseReportSinks(server.URL + "/ingest,blob:" + strings.Replace(server.URL, "http://", "https://", 1) + "/reports?sig=x")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	report := NewRunReport("run-1", "abc")
+	if err := sinks[0].WriteReport(context.TODO(), report); err != nil {
+		t.Fatalf("HTTP sink failed: %v", err)
+	}
+
+	// The test server speaks plain HTTP, so exercise the blob sink against it directly
+	blob := sinks[1].(BlobSink)
+	blob.Container.Scheme = "http"
+	if err := blob.WriteReport(context.TODO(), report); err != nil {
+		t.Fatalf("Blob sink failed: %v", err)
+	}
+
+	if len(got) != 2 || got[0].method != http.MethodPost || got[0].path != "/ingest" || got[0].body.RunID != "run-1" {
+		t.Errorf("Unexpected HTTP upload: %+v", got)
+	}
+	if got[1].method != http.MethodPut || got[1].path != "/reports/run-1.json" || got[1].blobType != "BlockBlob" {
+		t.Errorf("Unexpected blob upload: %+v", got[1])
+	}
+
+	broken := HTTPSink{URL: blob.Container.JoinPath("broken")}
+	if err := broken.WriteReport(context.TODO(), report); err == nil {
+		t.Error("Expected error for failing endpoint")
+	}
+}
@@ -0,0 +1,81 @@
+package auditor
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SliceSource selects how a run determines which slice of namespaces it
+// processes, see SetSlice.
+type SliceSource string
+
+const (
+	// SliceByTime derives the slice from the time of the run, for CronJobs
+	// scheduled evenly over a slice period.
+	SliceByTime SliceSource = "time"
+
+	// SliceByRuns processes the slices in turn, counting runs in the state
+	// ConfigMap.
+	SliceByRuns SliceSource = "runs"
+)
+
+// ParseSliceSource parses a slice source name. An empty value selects
+// SliceByTime.
+func ParseSliceSource(value string) (SliceSource, error) {
+	switch source := SliceSource(value); source {
+	case "":
+		return SliceByTime, nil
+	case SliceByTime, SliceByRuns:
+		return source, nil
+	default:
+		return "", fmt.Errorf("unknown slice source %q, expected %q or %q", value, SliceByTime, SliceByRuns)
+	}
+}
+
+// SliceAt returns the slice (0-based) of count slices processed at now,
+// dividing each period, counted from the Unix epoch, into count equal parts.
+// With a daily period and four slices, a run between 06:00 and 12:00 UTC
+// processes the second slice.
+func SliceAt(now time.Time, count int, period time.Duration) int {
+	if count <= 1 || period <= 0 {
+		return 0
+	}
+	elapsed := time.Duration(now.UnixNano() % int64(period))
+	return int(elapsed / (period / time.Duration(count)) % time.Duration(count))
+}
+
+// SliceOfRun returns the slice (0-based) of count slices processed by the
+// given run, 1 for the first.
+func SliceOfRun(run, count int) int {
+	if count <= 1 || run < 1 {
+		return 0
+	}
+	return (run - 1) % count
+}
+
+// SetSlice restricts the run to one of count slices of the namespaces, so
+// that a large fleet is covered by count successive runs. The others are
+// left untouched for the run processing their slice (ActionSliced).
+// Namespaces are assigned to slices by a hash of their name, so each stays
+// in the same slice from run to run. A count of 1 or less processes all.
+func (p *NamespaceProcessor) SetSlice(index, count int) {
+	p.sliceIndex, p.sliceCount = index, count
+}
+
+// slicedOut reports whether ns is outside the slice of the run
+func (p *NamespaceProcessor) slicedOut(ns corev1.Namespace) bool {
+	if p.sliceCount <= 1 {
+		return false
+	}
+	return sliceOf(ns.Name, p.sliceCount) != p.sliceIndex
+}
+
+// sliceOf returns the slice a namespace belongs to
+func sliceOf(name string, count int) int {
+	h := fnv.New64a()
+	h.Write([]byte("slice/" + name)) // Salted to be independent of slow-start sampling
+	return int(h.Sum64() % uint64(count))
+}
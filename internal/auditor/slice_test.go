@@ -0,0 +1,65 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestSliceAt validates that the slice follows the time of day
+func TestSliceAt(t *testing.T) {
+	day := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	for hour, want := range map[int]int{0: 0, 5: 0, 6: 1, 13: 2, 23: 3} {
+		if got := SliceAt(day.Add(time.Duration(hour)*time.Hour), 4, 24*time.Hour); got != want {
+			t.Errorf("SliceAt(%02d:00) = %d, want %d", hour, got, want)
+		}
+	}
+	if got := SliceAt(day.Add(7*time.Hour), 1, 24*time.Hour); got != 0 {
+		t.Errorf("Unsliced: SliceAt = %d, want 0", got)
+	}
+}
+
+// TestSliceOfRun validates that successive runs take the slices in turn
+func TestSliceOfRun(t *testing.T) {
+	var got []int
+	for run := 1; run <= 5; run++ {
+		got = append(got, SliceOfRun(run, 3))
+	}
+	if fmt.Sprint(got) != "[0 1 2 0 1]" {
+		t.Errorf("Slices = %v, want [0 1 2 0 1]", got)
+	}
+}
+
+// TestSlicedRuns validates that every namespace is processed by exactly one
+// of the slices, and left alone by the others
+func TestSlicedRuns(t *testing.T) {
+	var namespaces []*corev1.Namespace
+	for i := 0; i < 20; i++ {
+		namespaces = append(namespaces, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("team-%d", i),
+			Annotations: map[string]string{OwnerAnnotation: "user@example.com"},
+		}})
+	}
+	p := newTestProcessor(true, namespaces, false)
+
+	processed := make(map[string]int)
+	for slice := 0; slice < 3; slice++ {
+		p.SetSlice(slice, 3)
+		for _, ns := range namespaces {
+			var action Action
+			captureLogs(func() { action = p.ProcessNamespace(context.TODO(), *ns).Action })
+			if action != ActionSliced {
+				processed[ns.Name]++
+			}
+		}
+	}
+	for _, ns := range namespaces {
+		if processed[ns.Name] != 1 {
+			t.Errorf("%s processed by %d slices, want 1", ns.Name, processed[ns.Name])
+		}
+	}
+}
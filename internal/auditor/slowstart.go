@@ -0,0 +1,77 @@
+package auditor
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SlowStartState tracks the ramp-up of processing after the auditor's
+// version or configuration changed.
+type SlowStartState struct {
+	Key  string `json:"key"`  // Version and configuration the ramp-up is for
+	Runs int    `json:"runs"` // Runs started with them, this one included
+}
+
+// Advance counts a run with key, starting over if key differs from the one
+// the ramp-up is for, i.e. after a deployment or configuration change.
+func (s SlowStartState) Advance(key string) SlowStartState {
+	if s.Key != key {
+		return SlowStartState{Key: key, Runs: 1}
+	}
+	s.Runs++
+	return s
+}
+
+// SlowStartPercent returns the percentage of namespaces to process in the
+// given run (1 for the first) of a ramp-up through steps, 100 once the
+// steps are exhausted.
+func SlowStartPercent(steps []int, run int) int {
+	if run < 1 || run > len(steps) {
+		return 100
+	}
+	return steps[run-1]
+}
+
+// ParseSlowStart parses a comma-separated list of percentages of namespaces
+// processed by the first runs after a change, e.g. "10,25,50". Each must be
+// between 1 and 99 and none smaller than the one before.
+func ParseSlowStart(value string) ([]int, error) {
+	var steps []int
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		percent, err := strconv.Atoi(strings.TrimSuffix(entry, "%"))
+		if err != nil || percent < 1 || percent > 99 {
+			return nil, fmt.Errorf("expected percentages between 1 and 99, got %q", entry)
+		}
+		if len(steps) > 0 && percent < steps[len(steps)-1] {
+			return nil, fmt.Errorf("percentages must not decrease, got %d after %d", percent, steps[len(steps)-1])
+		}
+		steps = append(steps, percent)
+	}
+	return steps, nil
+}
+
+// SetSlowStart restricts the run to the given percentage of namespaces. The
+// others are left untouched for a later run (ActionRamp). Namespaces are
+// sampled by a hash of their name, so a namespace sampled at some
+// percentage stays sampled at any higher one. 100 or more processes all.
+func (p *NamespaceProcessor) SetSlowStart(percent int) {
+	p.slowStart = percent
+}
+
+// rampedOut reports whether ns is outside the slow-start sample of the run
+func (p *NamespaceProcessor) rampedOut(ns corev1.Namespace) bool {
+	if p.slowStart <= 0 || p.slowStart >= 100 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ns.Name))
+	return int(h.Sum32()%100) >= p.slowStart
+}
@@ -0,0 +1,75 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestSlowStartRamp validates restarting and advancing the ramp-up
+func TestSlowStartRamp(t *testing.T) {
+	steps := []int{10, 50}
+	var state SlowStartState
+	var got []int
+	for _, key := range []string{"v1/a", "v1/a", "v1/a", "v1/a", "v1/b", "v2/b"} {
+		state = state.Advance(key)
+		got = append(got, SlowStartPercent(steps, state.Runs))
+	}
+	if want := []int{10, 50, 100, 100, 10, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Percentages = %v, want %v", got, want)
+	}
+}
+
+// TestParseSlowStart validates parsing ramp-up percentages
+func TestParseSlowStart(t *testing.T) {
+	if steps, err := ParseSlowStart(""); err != nil || steps != nil {
+		t.Errorf("Empty: %v, %v", steps, err)
+	}
+	for _, value := range []string{"0", "100", "ten", "50,10"} {
+		if _, err := ParseSlowStart(value); err == nil {
+			t.Errorf("%q: expected error", value)
+		}
+	}
+}
+
+// TestSlowStartSample validates that a run only processes its sample, and
+// that the sample grows with the percentage
+func TestSlowStartSample(t *testing.T) {
+	var nss []*corev1.Namespace
+	for i := 0; i < 200; i++ {
+		nss = append(nss, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("team-%d", i),
+			Annotations: map[string]string{OwnerAnnotation: "user@example.com"},
+		}})
+	}
+	p := newTestProcessor(false, nss, true)
+
+	sampled := func(percent int) map[string]bool {
+		p.SetSlowStart(percent)
+		names := make(map[string]bool)
+		captureLogs(func() {
+			for _, ns := range nss {
+				if d := p.ProcessNamespace(context.TODO(), *ns); d.Action != ActionRamp {
+					names[ns.Name] = true
+				}
+			}
+		})
+		return names
+	}
+	small, large := sampled(10), sampled(50)
+	if len(small) < 5 || len(small) > 40 || len(large) < 70 || len(large) > 130 {
+		t.Errorf("Sampled %d at 10%% and %d at 50%% of 200 namespaces", len(small), len(large))
+	}
+	for name := range small {
+		if !large[name] {
+			t.Errorf("%s sampled at 10%% but not at 50%%", name)
+		}
+	}
+	if all := sampled(100); len(all) != len(nss) {
+		t.Errorf("Sampled %d at 100%%, want all", len(all))
+	}
+}
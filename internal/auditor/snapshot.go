@@ -0,0 +1,21 @@
+package auditor
+
+import "log"
+
+// ChangedDuringRun returns the namespaces that were modified by others after
+// the run read them. They were not acted on, and are evaluated again by the
+// next run.
+func (p *NamespaceProcessor) ChangedDuringRun() []string {
+	return p.changed.list()
+}
+
+// flagChanged records a namespace whose update or deletion was rejected
+// because it changed since it was read. Acting on a newer state than the
+// rest of the run was evaluated against would make the run report
+// inconsistent, so the namespace is left for the next run.
+func (p *NamespaceProcessor) flagChanged(name string) {
+	log.Printf("Namespace %s changed since it was read, leaving it for the next run", name)
+	p.trace.add("snapshot", "namespace changed since it was read, not acted on")
+	p.trace.setAction(ActionChanged)
+	p.changed.add(name)
+}
@@ -0,0 +1,67 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestDeleteOptionsPreconditions validates that deletions are preconditioned
+// on the namespace state the run read
+func TestDeleteOptionsPreconditions(t *testing.T) {
+	ns := expiredNamespace("expired")
+	ns.UID = "uid-1"
+	ns.ResourceVersion = "42"
+
+	opts := newTestProcessor(false, nil, false).deleteOptions(ns)
+	if opts.Preconditions == nil || *opts.Preconditions.UID != "uid-1" || *opts.Preconditions.ResourceVersion != "42" {
+		t.Errorf("Preconditions = %+v, want UID uid-1 and resourceVersion 42", opts.Preconditions)
+	}
+
+	ns.UID, ns.ResourceVersion = "", ""
+	if opts := newTestProcessor(false, nil, false).deleteOptions(ns); opts.Preconditions != nil {
+		t.Errorf("Preconditions = %+v, want none for a namespace without UID or resourceVersion", opts.Preconditions)
+	}
+}
+
+// TestChangedDuringRun validates that namespaces modified since they were
+// read are flagged instead of acted on
+func TestChangedDuringRun(t *testing.T) {
+	expired, fresh := expiredNamespace("expired"), expiredNamespace("fresh")
+	delete(fresh.Annotations, GracePeriodAnnotation)
+	processor := newTestProcessor(false, []*corev1.Namespace{&expired, &fresh}, false)
+
+	conflict := func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(corev1.Resource("namespaces"), "", errors.New("object was modified"))
+	}
+	client := processor.k8sClient.(*fake.Clientset)
+	client.PrependReactor("delete", "namespaces", conflict)
+	client.PrependReactor("update", "namespaces", conflict)
+
+	var actions []Action
+	captureLogs(func() {
+		actions = append(actions,
+			processor.ProcessNamespaceTraced(context.TODO(), *expired.DeepCopy()).Action,
+			processor.ProcessNamespaceTraced(context.TODO(), *fresh.DeepCopy()).Action)
+	})
+
+	for i, action := range actions {
+		if action != ActionChanged {
+			t.Errorf("Namespace %d: action = %q, want %q", i, action, ActionChanged)
+		}
+	}
+	if got := processor.ChangedDuringRun(); len(got) != 2 {
+		t.Errorf("ChangedDuringRun() = %v, want both namespaces", got)
+	}
+	for _, m := range processor.Marked() {
+		if m.Name == "fresh" {
+			t.Errorf("Marked() = %v, want no entry for the rejected marker on fresh", processor.Marked())
+		}
+	}
+}
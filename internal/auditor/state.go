@@ -0,0 +1,183 @@
+package auditor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultStateConfigMap is the name of the ConfigMap holding auditor state.
+const DefaultStateConfigMap = "namespace-auditor-state"
+
+// ConfigMap keys of the persisted state
+const (
+	stateThrottleLast    = "throttle.lastThrottled"
+	stateThrottleBackoff = "throttle.backoff"
+	stateThrottleUntil   = "throttle.until"
+	stateInstancePrefix  = "instance." // Followed by the instance name
+	stateEvaluations     = "evaluations"
+	stateSavedTotal      = "saved.total"
+	stateSavedSince      = "saved.since"
+	stateSlowStart       = "slowStart"
+	stateSliceRuns       = "slice.runs"
+)
+
+// State is auditor state carried from one run to the next.
+type State struct {
+	Throttle  ThrottleState             // Identity-provider throttling and backoff
+	Instances map[string]InstanceConfig // Configuration of each auditor deployment, by instance name
+
+	Evaluations map[string]Evaluation // Valid-owner evaluations, by namespace name
+	Saved       SavedTotals           // Namespaces saved by their owner's return, across runs
+	SlowStart   SlowStartState        // Ramp-up after the last version or configuration change
+	SliceRuns   int                   // Runs counted to select their slice, see SliceOfRun
+}
+
+// StateStore persists State between runs.
+type StateStore interface {
+	Load(ctx context.Context) (State, error)
+	Save(ctx context.Context, s State) error
+}
+
+// ConfigMapStateStore keeps State in a ConfigMap, one key per field.
+type ConfigMapStateStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapStateStore creates a store backed by the named ConfigMap,
+// which is created on first save.
+func NewConfigMapStateStore(client kubernetes.Interface, namespace, name string) *ConfigMapStateStore {
+	return &ConfigMapStateStore{client: client, namespace: namespace, name: name}
+}
+
+// Load reads the state. A missing ConfigMap yields the zero State.
+func (s *ConfigMapStateStore) Load(ctx context.Context) (State, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+
+	var state State
+	if v := cm.Data[stateThrottleLast]; v != "" {
+		if state.Throttle.LastThrottled, err = parseMarkerTime(v); err != nil {
+			return State{}, fmt.Errorf("%s: %w", stateThrottleLast, err)
+		}
+	}
+	if v := cm.Data[stateThrottleBackoff]; v != "" {
+		if state.Throttle.Backoff, err = time.ParseDuration(v); err != nil {
+			return State{}, fmt.Errorf("%s: %w", stateThrottleBackoff, err)
+		}
+	}
+	if v := cm.Data[stateThrottleUntil]; v != "" {
+		if state.Throttle.Until, err = parseMarkerTime(v); err != nil {
+			return State{}, fmt.Errorf("%s: %w", stateThrottleUntil, err)
+		}
+	}
+	if v := cm.Data[stateSavedTotal]; v != "" {
+		if state.Saved.Total, err = strconv.Atoi(v); err != nil {
+			return State{}, fmt.Errorf("%s: %w", stateSavedTotal, err)
+		}
+	}
+	if v := cm.Data[stateSavedSince]; v != "" {
+		if state.Saved.Since, err = parseMarkerTime(v); err != nil {
+			return State{}, fmt.Errorf("%s: %w", stateSavedSince, err)
+		}
+	}
+	if v := cm.Data[stateSlowStart]; v != "" {
+		if err := json.Unmarshal([]byte(v), &state.SlowStart); err != nil {
+			return State{}, fmt.Errorf("%s: %w", stateSlowStart, err)
+		}
+	}
+	if v := cm.Data[stateSliceRuns]; v != "" {
+		if state.SliceRuns, err = strconv.Atoi(v); err != nil {
+			return State{}, fmt.Errorf("%s: %w", stateSliceRuns, err)
+		}
+	}
+	if v := cm.Data[stateEvaluations]; v != "" {
+		if err := json.Unmarshal([]byte(v), &state.Evaluations); err != nil {
+			return State{}, fmt.Errorf("%s: %w", stateEvaluations, err)
+		}
+	}
+	for key, value := range cm.Data {
+		name, ok := strings.CutPrefix(key, stateInstancePrefix)
+		if !ok {
+			continue
+		}
+		var instance InstanceConfig
+		if err := json.Unmarshal([]byte(value), &instance); err != nil {
+			return State{}, fmt.Errorf("%s: %w", key, err)
+		}
+		if state.Instances == nil {
+			state.Instances = make(map[string]InstanceConfig)
+		}
+		state.Instances[name] = instance
+	}
+	return state, nil
+}
+
+// Save writes the state, creating the ConfigMap if needed.
+func (s *ConfigMapStateStore) Save(ctx context.Context, state State) error {
+	data := map[string]string{}
+	if !state.Throttle.LastThrottled.IsZero() {
+		data[stateThrottleLast] = formatMarkerTime(state.Throttle.LastThrottled)
+	}
+	if state.Throttle.Backoff > 0 {
+		data[stateThrottleBackoff] = state.Throttle.Backoff.String()
+		data[stateThrottleUntil] = formatMarkerTime(state.Throttle.Until)
+	}
+	for name, instance := range state.Instances {
+		value, err := json.Marshal(instance)
+		if err != nil {
+			return fmt.Errorf("encoding instance %s: %w", name, err)
+		}
+		data[stateInstancePrefix+name] = string(value)
+	}
+	if !state.Saved.Since.IsZero() {
+		data[stateSavedTotal] = strconv.Itoa(state.Saved.Total)
+		data[stateSavedSince] = formatMarkerTime(state.Saved.Since)
+	}
+	if state.SlowStart.Key != "" {
+		value, err := json.Marshal(state.SlowStart)
+		if err != nil {
+			return fmt.Errorf("encoding slow start: %w", err)
+		}
+		data[stateSlowStart] = string(value)
+	}
+	if state.SliceRuns > 0 {
+		data[stateSliceRuns] = strconv.Itoa(state.SliceRuns)
+	}
+	if len(state.Evaluations) > 0 {
+		value, err := json.Marshal(state.Evaluations)
+		if err != nil {
+			return fmt.Errorf("encoding evaluations: %w", err)
+		}
+		data[stateEvaluations] = string(value)
+	}
+
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+	cm, err := configMaps.Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace}, Data: data}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{FieldManager: FieldManager})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	cm.Data = data
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{FieldManager: FieldManager})
+	return err
+}
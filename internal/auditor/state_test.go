@@ -0,0 +1,79 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestConfigMapStateStore validates saving and loading state
+func TestConfigMapStateStore(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	store := NewConfigMapStateStore(client, "auditor", DefaultStateConfigMap)
+
+	state, err := store.Load(context.TODO())
+	if err != nil || !reflect.DeepEqual(state, State{}) {
+		t.Fatalf("Missing ConfigMap should load as empty state, got %+v, %v", state, err)
+	}
+
+	last := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	want := State{
+		Throttle: ThrottleState{LastThrottled: last, Backoff: 2 * time.Minute, Until: last.Add(2 * time.Minute)},
+		Instances: map[string]InstanceConfig{
+			"cronjob": {ConfigHash: "abc", SeenAt: last, Config: ConfigSnapshot{GracePeriod: "720h0m0s", AllowedDomains: []string{"example.com"}}},
+		},
+		Evaluations: map[string]Evaluation{
+			"team-a": {ResourceVersion: "42", Owner: "user@example.com", EvaluatedAt: last},
+		},
+		Saved:     SavedTotals{Total: 7, Since: last},
+		SlowStart: SlowStartState{Key: "v1.2.0/abc", Runs: 2},
+		SliceRuns: 5,
+	}
+	for i := 0; i < 2; i++ { // Create, then update
+		if err := store.Save(context.TODO(), want); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	got, err := store.Load(context.TODO())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !got.Throttle.LastThrottled.Equal(want.Throttle.LastThrottled) || got.Throttle.Backoff != want.Throttle.Backoff ||
+		!got.Throttle.Until.Equal(want.Throttle.Until) {
+		t.Errorf("Loaded %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(got.Instances, want.Instances) {
+		t.Errorf("Loaded instances %+v, want %+v", got.Instances, want.Instances)
+	}
+	if !reflect.DeepEqual(got.Evaluations, want.Evaluations) {
+		t.Errorf("Loaded evaluations %+v, want %+v", got.Evaluations, want.Evaluations)
+	}
+	if got.SlowStart != want.SlowStart {
+		t.Errorf("Loaded slow start %+v, want %+v", got.SlowStart, want.SlowStart)
+	}
+	if got.SliceRuns != want.SliceRuns {
+		t.Errorf("Loaded slice runs %d, want %d", got.SliceRuns, want.SliceRuns)
+	}
+	if got.Saved.Total != want.Saved.Total || !got.Saved.Since.Equal(want.Saved.Since) {
+		t.Errorf("Loaded saved totals %+v, want %+v", got.Saved, want.Saved)
+	}
+}
+
+// TestConfigMapStateStoreMalformed validates that corrupt state is reported
+func TestConfigMapStateStoreMalformed(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultStateConfigMap, Namespace: "auditor"},
+		Data:       map[string]string{stateThrottleBackoff: "soon"},
+	})
+	store := NewConfigMapStateStore(client, "auditor", DefaultStateConfigMap)
+
+	if _, err := store.Load(context.TODO()); err == nil {
+		t.Error("Expected error for malformed backoff")
+	}
+}
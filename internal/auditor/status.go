@@ -0,0 +1,112 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// AuditorStatusResource identifies the cluster-scoped AuditorStatus custom
+// resource defined in deploy/crd-auditorstatus.yaml.
+var AuditorStatusResource = schema.GroupVersionResource{
+	Group:    "namespace-auditor.io",
+	Version:  "v1alpha1",
+	Resource: "auditorstatuses",
+}
+
+// AuditorStatusName is the name of the single AuditorStatus object
+// maintained by the auditor.
+const AuditorStatusName = "namespace-auditor"
+
+// AuditorStatus summarizes the most recent run for cluster admins. It is
+// stored as the status of the AuditorStatus object.
+type AuditorStatus struct {
+	LastRunID    string        `json:"lastRunId"`              // ID of the most recent run
+	LastRunTime  time.Time     `json:"lastRunTime"`            // When the most recent run finished
+	ConfigHash   string        `json:"configHash"`             // Hash of the effective configuration
+	Aborted      string        `json:"aborted,omitempty"`      // Why the run stopped early, if it did
+	Frozen       bool          `json:"frozen"`                 // Whether grace periods are currently paused
+	FrozenUntil  *time.Time    `json:"frozenUntil,omitempty"`  // End of the current pause window
+	Counts       AuditorCounts `json:"counts"`                 // Aggregate counts of the run
+	CanaryPassed *bool         `json:"canaryPassed,omitempty"` // Whether the canary namespace check passed, if enabled
+}
+
+// AuditorCounts holds the aggregate counts of a run.
+type AuditorCounts struct {
+	Namespaces       int `json:"namespaces"`       // Namespaces evaluated
+	Marked           int `json:"marked"`           // Namespaces marked for deletion
+	Saved            int `json:"saved"`            // Namespaces unmarked because their owner was validated again
+	SavedTotal       int `json:"savedTotal"`       // Namespaces saved across runs, when state is kept
+	Ownerless        int `json:"ownerless"`        // Namespaces without an owner annotation
+	StuckTerminating int `json:"stuckTerminating"` // Deleted namespaces stuck terminating
+	Deferred         int `json:"deferred"`         // Namespaces deferred for lack of lookup budget or backoff
+}
+
+// NewAuditorStatus builds the status of a finished run. The run is frozen
+// if its finish time falls inside one of the pause windows.
+func NewAuditorStatus(r *RunReport, windows []PauseWindow) AuditorStatus {
+	status := AuditorStatus{
+		LastRunID:   r.RunID,
+		LastRunTime: r.FinishedAt,
+		ConfigHash:  r.ConfigHash,
+		Aborted:     r.Aborted,
+		Counts: AuditorCounts{
+			Namespaces:       r.Namespaces,
+			Marked:           len(r.Marked),
+			Saved:            len(r.Saved),
+			Ownerless:        len(r.Ownerless),
+			StuckTerminating: len(r.StuckTerminating),
+			Deferred:         r.Deferred,
+		},
+	}
+	if r.SavedTotal != nil {
+		status.Counts.SavedTotal = r.SavedTotal.Total
+	}
+	if r.Canary != nil {
+		passed := r.Canary.Passed
+		status.CanaryPassed = &passed
+	}
+	for _, w := range windows {
+		if !r.FinishedAt.Before(w.Start) && r.FinishedAt.Before(w.End) {
+			end := w.End.UTC()
+			status.Frozen = true
+			status.FrozenUntil = &end
+			break
+		}
+	}
+	return status
+}
+
+// PublishStatus creates or updates the AuditorStatus object with status.
+func PublishStatus(ctx context.Context, client dynamic.Interface, status AuditorStatus) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return fmt.Errorf("failed to encode auditor status: %w", err)
+	}
+	resource := client.Resource(AuditorStatusResource)
+
+	obj, err := resource.Get(ctx, AuditorStatusName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		obj = &unstructured.Unstructured{}
+		obj.SetAPIVersion(AuditorStatusResource.GroupVersion().String())
+		obj.SetKind("AuditorStatus")
+		obj.SetName(AuditorStatusName)
+		obj.Object["status"] = content
+		_, err = resource.Create(ctx, obj, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	obj.Object["status"] = content
+	_, err = resource.Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,65 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestNewAuditorStatus validates aggregate counts and freeze state
+func TestNewAuditorStatus(t *testing.T) {
+	finished := time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)
+	report := &RunReport{
+		RunID:      "run-1",
+		ConfigHash: "abc",
+		FinishedAt: finished,
+		Namespaces: 5,
+		Marked:     []MarkedNamespace{{Name: "a"}, {Name: "b"}},
+		Ownerless:  []string{"c"},
+		Deferred:   1,
+		Saved:      []SavedNamespace{{Name: "d"}},
+		SavedTotal: &SavedTotals{Total: 4},
+	}
+	window := PauseWindow{Start: finished.Add(-24 * time.Hour), End: finished.Add(24 * time.Hour)}
+
+	status := NewAuditorStatus(report, []PauseWindow{window})
+	want := AuditorCounts{Namespaces: 5, Marked: 2, Ownerless: 1, Deferred: 1, Saved: 1, SavedTotal: 4}
+	if status.Counts != want {
+		t.Errorf("Counts = %+v, want %+v", status.Counts, want)
+	}
+	if !status.Frozen || status.FrozenUntil == nil || !status.FrozenUntil.Equal(window.End) {
+		t.Errorf("Expected frozen until %s, got %+v", window.End, status)
+	}
+
+	if status := NewAuditorStatus(report, nil); status.Frozen || status.FrozenUntil != nil {
+		t.Errorf("Expected not frozen without pause windows, got %+v", status)
+	}
+}
+
+// TestPublishStatus validates creation and later update of the status object
+func TestPublishStatus(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{AuditorStatusResource: "AuditorStatusList"})
+
+	for _, runID := range []string{"run-1", "run-2"} {
+		status := AuditorStatus{LastRunID: runID, LastRunTime: time.Now().UTC()}
+		if err := PublishStatus(context.TODO(), client, status); err != nil {
+			t.Fatalf("PublishStatus(%s): %v", runID, err)
+		}
+	}
+
+	obj, err := client.Resource(AuditorStatusResource).Get(context.TODO(), AuditorStatusName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Status object not found: %v", err)
+	}
+	got, _, _ := unstructured.NestedString(obj.Object, "status", "lastRunId")
+	if got != "run-2" {
+		t.Errorf("lastRunId = %q, want run-2", got)
+	}
+}
@@ -0,0 +1,115 @@
+package auditor
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// statusPathPrefix is the path under which the status of a namespace is
+// served, followed by its name
+const statusPathPrefix = "/status/"
+
+// statusRequestTimeout bounds the Kubernetes lookup behind a status request
+const statusRequestTimeout = 10 * time.Second
+
+// NamespaceStatus is the deletion schedule of a namespace, as served to
+// pipelines and portals checking a namespace before deploying into it. It
+// holds no personal data: owners and approvers are never included.
+type NamespaceStatus struct {
+	Namespace   string     `json:"namespace"`             // Namespace name
+	Marked      bool       `json:"marked"`                // Whether the namespace is scheduled for deletion
+	DeleteAt    *time.Time `json:"deleteAt,omitempty"`    // When the grace period expires, pauses and clock skew included, if readable
+	Terminating bool       `json:"terminating,omitempty"` // Whether the namespace is being deleted
+}
+
+// NamespaceStatus returns the deletion schedule of the namespace named name
+// at now, computed like the run report's list of marked namespaces and
+// following the audit policy matching the namespace.
+func (p *NamespaceProcessor) NamespaceStatus(ctx context.Context, name string, now time.Time) (NamespaceStatus, error) {
+	ns, err := p.GetNamespace(ctx, name)
+	if err != nil {
+		return NamespaceStatus{}, err
+	}
+	return p.namespaceStatus(*ns, now), nil
+}
+
+// namespaceStatus returns the deletion schedule of ns at now
+func (p *NamespaceProcessor) namespaceStatus(ns corev1.Namespace, now time.Time) NamespaceStatus {
+	p = p.withAuditPolicy(ns)
+	status := NamespaceStatus{Namespace: ns.Name, Terminating: ns.DeletionTimestamp != nil}
+	value, marked := ns.Annotations[GracePeriodAnnotation]
+	if !marked {
+		return status
+	}
+	status.Marked = true
+	if markedAt, err := parseMarkerTime(value); err == nil {
+		deleteAt := p.graceExpiry(ns, markedAt).Add(p.accumulatedPause(ns, markedAt, now) + p.clockSkew).UTC()
+		status.DeleteAt = &deleteAt
+	}
+	return status
+}
+
+// StatusHandler serves the NamespaceStatus of a namespace as JSON at
+// GET /status/{namespace}. If token is set, requests must carry it as a
+// bearer token. Unknown namespaces, and namespaces outside the audit scope
+// because selector or the name filter excludes them, are answered with 404.
+func (p *NamespaceProcessor) StatusHandler(token string, selector labels.Selector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := strings.CutPrefix(r.URL.Path, statusPathPrefix)
+		if !ok || len(validation.IsDNS1123Label(name)) > 0 {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if token != "" {
+			given, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if _, excluded := p.excludedByName(name); excluded {
+			http.NotFound(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), statusRequestTimeout)
+		defer cancel()
+		ns, err := p.GetNamespace(ctx, name)
+		switch {
+		case apierrors.IsNotFound(err):
+			http.NotFound(w, r)
+			return
+		case err != nil:
+			log.Printf("Error reading status of %s: %v", name, err)
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+		if !selector.Matches(labels.Set(ns.Labels)) {
+			http.NotFound(w, r)
+			return
+		}
+		status := p.namespaceStatus(*ns, p.now())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			log.Printf("Error writing status of %s: %v", name, err)
+		}
+	})
+}
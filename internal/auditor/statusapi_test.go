@@ -0,0 +1,129 @@
+package auditor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// TestStatusHandler validates the served deletion schedule and the access
+// rules of the status endpoint
+func TestStatusHandler(t *testing.T) {
+	markedAt := time.Now().Add(-6 * time.Hour).UTC().Truncate(time.Second)
+	p := newTestProcessor(true, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "marked", Annotations: map[string]string{
+			OwnerAnnotation:       "user@example.com",
+			GracePeriodAnnotation: formatMarkerTime(markedAt),
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "unmarked", Annotations: map[string]string{
+			OwnerAnnotation: "user@example.com",
+		}}},
+	}, false)
+	handler := p.StatusHandler("secret", labels.Everything())
+
+	serve := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/status/marked", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want 200", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "user@example.com") {
+		t.Errorf("Status must not include the owner: %s", rec.Body)
+	}
+	var status NamespaceStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Invalid status %s: %v", rec.Body, err)
+	}
+	want := markedAt.Add(p.gracePeriod + p.clockSkew)
+	if !status.Marked || status.DeleteAt == nil || !status.DeleteAt.Equal(want) {
+		t.Errorf("Status = %+v, want marked until %s", status, want)
+	}
+
+	rec = serve(http.MethodGet, "/status/unmarked", "secret")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"namespace":"unmarked","marked":false}` {
+		t.Errorf("Unmarked status = %d %s", rec.Code, rec.Body)
+	}
+
+	for _, tt := range []struct {
+		method, path, token string
+		want                int
+	}{
+		{http.MethodGet, "/status/marked", "", http.StatusUnauthorized},
+		{http.MethodGet, "/status/marked", "wrong", http.StatusUnauthorized},
+		{http.MethodPost, "/status/marked", "secret", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/status/missing", "secret", http.StatusNotFound},
+		{http.MethodGet, "/status/Not_A_Name", "secret", http.StatusNotFound},
+		{http.MethodGet, "/status/a/b", "secret", http.StatusNotFound},
+	} {
+		if rec := serve(tt.method, tt.path, tt.token); rec.Code != tt.want {
+			t.Errorf("%s %s with token %q: status code %d, want %d", tt.method, tt.path, tt.token, rec.Code, tt.want)
+		}
+	}
+}
+
+// TestStatusHandlerScope validates that namespaces outside the audit scope
+// are answered like unknown ones
+func TestStatusHandlerScope(t *testing.T) {
+	profile := map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"}
+	p := newTestProcessor(true, []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: profile}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", Labels: profile}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}},
+	}, false)
+	exclude, err := ParseNamePatterns("kube-*")
+	if err != nil {
+		t.Fatalf("ParseNamePatterns: %v", err)
+	}
+	p.SetNameFilter(nil, exclude)
+	handler := p.StatusHandler("", labels.SelectorFromSet(profile))
+
+	for name, want := range map[string]int{
+		"team-a":      http.StatusOK,
+		"kube-system": http.StatusNotFound,
+		"unlabeled":   http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/"+name, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status code %d, want %d", name, rec.Code, want)
+		}
+	}
+}
+
+// TestStatusHandlerAuditPolicy validates that the served deletion schedule
+// follows the grace period of the audit policy matching the namespace
+func TestStatusHandlerAuditPolicy(t *testing.T) {
+	markedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	sandbox := labeledNamespace("sandbox", "user@example.com", map[string]string{"env": "sandbox"})
+	sandbox.Annotations[GracePeriodAnnotation] = formatMarkerTime(markedAt)
+	p := newTestProcessor(true, []*corev1.Namespace{sandbox}, false)
+	p.SetAuditPolicies([]AuditPolicy{
+		{Name: "sandboxes", Selector: labels.SelectorFromSet(labels.Set{"env": "sandbox"}), Grace: FixedGracePeriod(2 * time.Hour)},
+	})
+
+	rec := httptest.NewRecorder()
+	p.StatusHandler("", labels.Everything()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/sandbox", nil))
+	var status NamespaceStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Invalid status %d %s: %v", rec.Code, rec.Body, err)
+	}
+	want := markedAt.Add(2*time.Hour + p.clockSkew)
+	if status.DeleteAt == nil || !status.DeleteAt.Equal(want) {
+		t.Errorf("Status = %+v, want marked until %s under the policy", status, want)
+	}
+}
@@ -0,0 +1,120 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultStuckThreshold is how long a namespace deleted by the auditor may
+// remain terminating before it is reported as stuck.
+const DefaultStuckThreshold = time.Hour
+
+// StuckNamespace describes a deleted namespace that has not finished
+// terminating.
+type StuckNamespace struct {
+	Name             string            `json:"name"`                 // Namespace name
+	TerminatingSince time.Time         `json:"terminatingSince"`     // When deletion was requested
+	Finalizers       []string          `json:"finalizers,omitempty"` // Finalizers blocking deletion
+	Context          map[string]string `json:"context,omitempty"`    // Selected labels or annotations, see SetContextKeys
+}
+
+// SetStuckRemediation configures detection of namespaces deleted by the
+// auditor that remain terminating for longer than threshold. With
+// forceFinalize, their blocking finalizers are removed so deletion can
+// complete; this bypasses whatever cleanup those finalizers guard.
+func (p *NamespaceProcessor) SetStuckRemediation(threshold time.Duration, forceFinalize bool) {
+	p.stuckThreshold = threshold
+	p.forceFinalize = forceFinalize
+}
+
+// StuckTerminating returns the namespaces found stuck terminating during
+// this run, sorted by name.
+func (p *NamespaceProcessor) StuckTerminating() []StuckNamespace {
+	stuck := p.stuck.list()
+	sort.Slice(stuck, func(i, j int) bool { return stuck[i].Name < stuck[j].Name })
+	return stuck
+}
+
+// blockingFinalizers lists the finalizers holding a namespace, both object
+// finalizers and those of the namespace spec.
+func blockingFinalizers(ns corev1.Namespace) []string {
+	finalizers := append([]string(nil), ns.Finalizers...)
+	for _, f := range ns.Spec.Finalizers {
+		finalizers = append(finalizers, string(f))
+	}
+	return finalizers
+}
+
+// stuckEntry describes a terminating namespace for the run report
+func stuckEntry(ns corev1.Namespace) StuckNamespace {
+	entry := StuckNamespace{Name: ns.Name, Finalizers: blockingFinalizers(ns)}
+	if ns.DeletionTimestamp != nil {
+		entry.TerminatingSince = ns.DeletionTimestamp.UTC()
+	}
+	return entry
+}
+
+// handleStuck reports a namespace deleted by the auditor that has been
+// terminating for longer than the threshold, force-finalizing it if enabled.
+// Returns false if the namespace is not stuck.
+func (p *NamespaceProcessor) handleStuck(ctx context.Context, ns corev1.Namespace) bool {
+	if _, marked := ns.Annotations[GracePeriodAnnotation]; !marked || ns.DeletionTimestamp == nil {
+		return false // Not deleted by the auditor
+	}
+	threshold := p.stuckThreshold
+	if threshold <= 0 {
+		threshold = DefaultStuckThreshold
+	}
+	since := time.Since(ns.DeletionTimestamp.Time)
+	if since < threshold {
+		return false
+	}
+
+	entry := stuckEntry(ns)
+	entry.Context = p.namespaceContext(ns)
+	log.Printf("Namespace %s stuck terminating for %s, blocked by %v", ns.Name, since.Round(time.Second), entry.Finalizers)
+	p.trace.add("finalizer", "terminating for %s, blocked by %v", since.Round(time.Second), entry.Finalizers)
+	p.stuck.add(entry)
+	p.notify(ctx, Notification{
+		Event:     EventStuckTerminating,
+		Namespace: ns.Name,
+		Owner:     ns.Annotations[OwnerAnnotation],
+		Message:   fmt.Sprintf("namespace has been terminating since %s, blocked by finalizers %v", formatMarkerTime(entry.TerminatingSince), entry.Finalizers),
+		Context:   entry.Context,
+	})
+
+	if !p.forceFinalize {
+		p.trace.setAction(ActionStuck)
+		return true
+	}
+
+	log.Printf("Force-finalizing namespace %s", ns.Name)
+	p.trace.setAction(ActionFinalize)
+	if err := p.forceFinalizeNamespace(ctx, ns); err != nil {
+		log.Printf("Error force-finalizing %s: %v", ns.Name, err)
+	}
+	return true
+}
+
+// forceFinalizeNamespace removes all object and spec finalizers from a
+// terminating namespace.
+func (p *NamespaceProcessor) forceFinalizeNamespace(ctx context.Context, ns corev1.Namespace) error {
+	if len(ns.Finalizers) > 0 {
+		ns.Finalizers = nil
+		if err := p.updateNamespace(ctx, &ns); err != nil {
+			return fmt.Errorf("removing finalizers: %w", err)
+		}
+	}
+	if len(ns.Spec.Finalizers) > 0 {
+		ns.Spec.Finalizers = nil
+		if err := p.mutations().finalizeNamespace(ctx, &ns); err != nil {
+			return fmt.Errorf("removing spec finalizers: %w", err)
+		}
+	}
+	return nil
+}
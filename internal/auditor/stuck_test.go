@@ -0,0 +1,107 @@
+package auditor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// stuckNamespace builds a namespace deleted by the auditor at deletedAt
+func stuckNamespace(deletedAt time.Time) corev1.Namespace {
+	ns := terminatingNamespace(deletedAt, map[string]string{
+		OwnerAnnotation:       "gone@example.com",
+		GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+	})
+	ns.Finalizers = []string{"example.com/backup"}
+	ns.Spec.Finalizers = []corev1.FinalizerName{corev1.FinalizerKubernetes}
+	return ns
+}
+
+// TestHandleStuck validates detection and reporting of stuck namespaces
+func TestHandleStuck(t *testing.T) {
+	tests := []struct {
+		name       string
+		ns         corev1.Namespace
+		wantAction Action
+		wantStuck  bool
+	}{
+		{name: "past threshold", ns: stuckNamespace(time.Now().Add(-2 * time.Hour)), wantAction: ActionStuck, wantStuck: true},
+		{name: "within threshold", ns: stuckNamespace(time.Now()), wantAction: ActionSkip},
+		{
+			name: "not deleted by auditor",
+			ns: func() corev1.Namespace {
+				ns := stuckNamespace(time.Now().Add(-2 * time.Hour))
+				delete(ns.Annotations, GracePeriodAnnotation)
+				return ns
+			}(),
+			wantAction: ActionSkip,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &recordingNotifier{}
+			processor := newTestProcessor(true, []*corev1.Namespace{&tt.ns}, false)
+			processor.SetStuckRemediation(time.Hour, false)
+			processor.SetNotifier(notifier)
+
+			var tr *Trace
+			captureLogs(func() {
+				tr = processor.ProcessNamespaceTraced(context.TODO(), *tt.ns.DeepCopy())
+			})
+			if tr.Action != tt.wantAction {
+				t.Errorf("Action = %q, want %q", tr.Action, tt.wantAction)
+			}
+
+			stuck := processor.StuckTerminating()
+			if (len(stuck) == 1) != tt.wantStuck {
+				t.Fatalf("Unexpected stuck namespaces: %+v", stuck)
+			}
+			if !tt.wantStuck {
+				return
+			}
+			if want := []string{"example.com/backup", "kubernetes"}; !reflect.DeepEqual(stuck[0].Finalizers, want) {
+				t.Errorf("Finalizers = %v, want %v", stuck[0].Finalizers, want)
+			}
+			if len(notifier.sent) != 1 || notifier.sent[0].Event != EventStuckTerminating {
+				t.Errorf("Expected stuck notification, got %+v", notifier.sent)
+			}
+		})
+	}
+}
+
+// TestForceFinalize validates guarded removal of blocking finalizers
+func TestForceFinalize(t *testing.T) {
+	ns := stuckNamespace(time.Now().Add(-2 * time.Hour))
+	ns.Spec.Finalizers = nil
+	processor := newTestProcessor(true, []*corev1.Namespace{&ns}, false)
+	processor.SetStuckRemediation(time.Hour, true)
+
+	var tr *Trace
+	captureLogs(func() {
+		tr = processor.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+	})
+	if tr.Action != ActionFinalize {
+		t.Errorf("Expected finalize, got %q", tr.Action)
+	}
+	updatedNs, _ := processor.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+	if len(updatedNs.Finalizers) != 0 {
+		t.Errorf("Finalizers should be removed: %v", updatedNs.Finalizers)
+	}
+
+	// Dry run reports but does not remove finalizers
+	ns = stuckNamespace(time.Now().Add(-2 * time.Hour))
+	dry := newTestProcessor(true, []*corev1.Namespace{&ns}, true)
+	dry.SetStuckRemediation(time.Hour, true)
+	captureLogs(func() {
+		dry.ProcessNamespace(context.TODO(), *ns.DeepCopy())
+	})
+	updatedNs, _ = dry.k8sClient.CoreV1().Namespaces().Get(context.TODO(), ns.Name, metav1.GetOptions{})
+	if len(updatedNs.Finalizers) != 1 {
+		t.Errorf("Dry run must not remove finalizers: %v", updatedNs.Finalizers)
+	}
+}
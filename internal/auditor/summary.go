@@ -0,0 +1,129 @@
+package auditor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ConfigMap keys of the run summary, see WriteSummaryConfigMap
+const (
+	SummaryKey           = "summary.json" // Complete RunSummary as JSON
+	SummaryKeyRunID      = "runId"
+	SummaryKeyFinishedAt = "finishedAt"
+	SummaryKeyMarked     = "marked"  // Number of marked namespaces
+	SummaryKeyRemoved    = "removed" // Number of deleted or cordoned namespaces
+	SummaryKeyErrors     = "errors"  // Number of failed decisions
+)
+
+// RunSummary is the machine-readable outcome of a run: counts and the
+// namespaces marked or removed, without the detail of the full RunReport.
+type RunSummary struct {
+	RunID      string         `json:"runId"`             // ID of the run, see RunIDAnnotation
+	StartedAt  time.Time      `json:"startedAt"`         // When the run began
+	FinishedAt time.Time      `json:"finishedAt"`        // When the run completed
+	DryRun     bool           `json:"dryRun"`            // Whether changes were only planned
+	Aborted    string         `json:"aborted,omitempty"` // Why the run stopped early, if it did
+	Namespaces int            `json:"namespaces"`        // Number of namespaces evaluated
+	Actions    map[Action]int `json:"actions,omitempty"` // Number of namespaces by decided action
+	Errors     int            `json:"errors"`            // Number of namespaces whose evaluation or change failed
+	Marked     []SummaryEntry `json:"marked"`            // Namespaces marked for deletion, newly or earlier
+	Removed    []SummaryEntry `json:"removed"`           // Namespaces deleted or cordoned after their grace period
+}
+
+// SummaryEntry is a namespace listed in a RunSummary.
+type SummaryEntry struct {
+	Namespace string     `json:"namespace"`          // Namespace name
+	Owner     string     `json:"owner,omitempty"`    // Owner email, empty if ownerless
+	Action    Action     `json:"action"`             // What the run did with the namespace
+	Reason    string     `json:"reason,omitempty"`   // Final evaluation step, explaining the action
+	MarkedAt  *time.Time `json:"markedAt,omitempty"` // When the deletion marker was set
+	DeleteAt  *time.Time `json:"deleteAt,omitempty"` // When the grace period expires
+}
+
+// Summary condenses the report. Namespaces deleted or cordoned by the run
+// are listed as removed, all other marked namespaces as marked.
+func (r *RunReport) Summary() RunSummary {
+	s := RunSummary{
+		RunID:      r.RunID,
+		StartedAt:  r.StartedAt,
+		FinishedAt: r.FinishedAt,
+		DryRun:     r.Config != nil && r.Config.DryRun,
+		Aborted:    r.Aborted,
+		Namespaces: r.Namespaces,
+		Actions:    r.Actions,
+		Errors:     len(r.Errors),
+		Marked:     []SummaryEntry{},
+		Removed:    []SummaryEntry{},
+	}
+
+	decisions := make(map[string]Decision, len(r.decided))
+	for _, d := range r.decided {
+		decisions[d.Namespace] = d
+	}
+	listed := make(map[string]bool, len(r.Marked))
+	for _, m := range r.Marked {
+		markedAt, deleteAt := m.MarkedAt, m.DeleteAt
+		entry := SummaryEntry{Namespace: m.Name, Owner: m.Owner, Action: ActionWait, MarkedAt: &markedAt, DeleteAt: &deleteAt}
+		if d, ok := decisions[m.Name]; ok {
+			entry.Action, entry.Reason = d.Action, d.Reason
+		}
+		listed[m.Name] = true
+		if removed(entry.Action) {
+			s.Removed = append(s.Removed, entry)
+			continue
+		}
+		s.Marked = append(s.Marked, entry)
+	}
+	for _, d := range r.decided {
+		if removed(d.Action) && !listed[d.Namespace] {
+			s.Removed = append(s.Removed, SummaryEntry{Namespace: d.Namespace, Action: d.Action, Reason: d.Reason})
+		}
+	}
+	return s
+}
+
+// removed reports whether action removes a namespace or its access
+func removed(action Action) bool {
+	return action == ActionDelete || action == ActionCordon
+}
+
+// WriteSummaryConfigMap writes s to the named ConfigMap, creating it if
+// needed, so that dashboards and other operators can read the outcome of the
+// last run. The complete summary is kept under SummaryKey, and its counts
+// under their own keys for consumers that cannot parse JSON.
+func WriteSummaryConfigMap(ctx context.Context, client kubernetes.Interface, namespace, name string, s RunSummary) error {
+	value, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("encoding summary: %w", err)
+	}
+	data := map[string]string{
+		SummaryKey:           string(value),
+		SummaryKeyRunID:      s.RunID,
+		SummaryKeyFinishedAt: formatMarkerTime(s.FinishedAt),
+		SummaryKeyMarked:     strconv.Itoa(len(s.Marked)),
+		SummaryKeyRemoved:    strconv.Itoa(len(s.Removed)),
+		SummaryKeyErrors:     strconv.Itoa(s.Errors),
+	}
+
+	configMaps := client.CoreV1().ConfigMaps(namespace)
+	cm, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}, Data: data}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{FieldManager: FieldManager})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	cm.Data = data
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{FieldManager: FieldManager})
+	return err
+}
@@ -0,0 +1,67 @@
+package auditor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// summaryReport returns a report with a namespace still waiting, one deleted
+// and one reset
+func summaryReport() *RunReport {
+	markedAt := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	r := &RunReport{RunID: "run-1", Namespaces: 3, Config: &ConfigSnapshot{}}
+	r.Marked = []MarkedNamespace{
+		{Name: "alice", Owner: "alice@example.com", MarkedAt: markedAt, DeleteAt: markedAt.Add(24 * time.Hour)},
+		{Name: "bob", Owner: "bob@example.com", MarkedAt: markedAt, DeleteAt: markedAt.Add(time.Hour)},
+	}
+	r.AddDecision(Decision{Namespace: "alice", Action: ActionWait, Reason: "grace period running"})
+	r.AddDecision(Decision{Namespace: "bob", Action: ActionDelete, Reason: "grace period expired"})
+	r.AddDecision(Decision{Namespace: "carol", Action: ActionReset, Err: errors.New("update failed")})
+	return r
+}
+
+// TestRunSummary validates that marked and removed namespaces are told apart
+func TestRunSummary(t *testing.T) {
+	s := summaryReport().Summary()
+	if s.RunID != "run-1" || s.Namespaces != 3 || s.Errors != 1 || s.Actions[ActionDelete] != 1 {
+		t.Errorf("Summary = %+v", s)
+	}
+	if len(s.Marked) != 1 || s.Marked[0].Namespace != "alice" || s.Marked[0].Reason != "grace period running" {
+		t.Errorf("Marked = %+v", s.Marked)
+	}
+	if len(s.Removed) != 1 || s.Removed[0].Namespace != "bob" || s.Removed[0].Action != ActionDelete ||
+		s.Removed[0].DeleteAt == nil || !s.Removed[0].DeleteAt.Equal(time.Date(2024, time.May, 1, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("Removed = %+v", s.Removed)
+	}
+}
+
+// TestWriteSummaryConfigMap validates that the summary ConfigMap is created
+// and then replaced by later runs
+func TestWriteSummaryConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	report := summaryReport()
+	for _, runID := range []string{"run-1", "run-2"} {
+		report.RunID = runID
+		if err := WriteSummaryConfigMap(context.TODO(), client, "default", "summary", report.Summary()); err != nil {
+			t.Fatalf("WriteSummaryConfigMap: %v", err)
+		}
+	}
+
+	cm, err := client.CoreV1().ConfigMaps("default").Get(context.TODO(), "summary", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if cm.Data[SummaryKeyRunID] != "run-2" || cm.Data[SummaryKeyMarked] != "1" || cm.Data[SummaryKeyRemoved] != "1" || cm.Data[SummaryKeyErrors] != "1" {
+		t.Errorf("Data = %v", cm.Data)
+	}
+	var s RunSummary
+	if err := json.Unmarshal([]byte(cm.Data[SummaryKey]), &s); err != nil || s.RunID != "run-2" || len(s.Removed) != 1 {
+		t.Errorf("Summary = %+v, %v", s, err)
+	}
+}
@@ -0,0 +1,94 @@
+package auditor
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+)
+
+// ErrIdentityBackoff is returned when an identity lookup is skipped because
+// the identity provider throttled the auditor recently.
+var ErrIdentityBackoff = errors.New("identity provider backoff in effect")
+
+// Bounds of the backoff applied after the identity provider throttles lookups.
+// Each further throttled run doubles the backoff.
+const (
+	MinThrottleBackoff = time.Minute
+	MaxThrottleBackoff = time.Hour
+)
+
+// ThrottleState records identity-provider throttling so that consecutive
+// runs respect a backoff window established by earlier ones.
+type ThrottleState struct {
+	LastThrottled time.Time     `json:"lastThrottled"` // When a lookup was last throttled, zero if never
+	Backoff       time.Duration `json:"backoff"`       // Current backoff, zero when not backing off
+	Until         time.Time     `json:"until"`         // End of the current backoff window
+}
+
+// throttleTracker holds the throttle state of a run. It is shared by all
+// copies of a processor and safe for concurrent use. A nil *throttleTracker
+// never backs off.
+type throttleTracker struct {
+	mu        sync.Mutex
+	state     ThrottleState
+	throttled bool // Whether a lookup was throttled during this run
+}
+
+// SetThrottleState restores the throttle state saved by an earlier run.
+// Identity lookups are deferred until its backoff window has passed.
+func (p *NamespaceProcessor) SetThrottleState(s ThrottleState) {
+	p.throttle = &throttleTracker{state: s}
+}
+
+// ThrottleState returns the throttle state to carry into the next run. The
+// backoff is cleared once its window has passed without further throttling.
+func (p *NamespaceProcessor) ThrottleState() ThrottleState {
+	t := p.throttle
+	if t == nil {
+		return ThrottleState{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.state
+	if !t.throttled && !time.Now().Before(s.Until) {
+		s.Backoff = 0
+		s.Until = time.Time{}
+	}
+	return s
+}
+
+// active returns the end of the backoff window if now falls inside it
+func (t *throttleTracker) active(now time.Time) (time.Time, bool) {
+	if t == nil {
+		return time.Time{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state.Until, now.Before(t.state.Until)
+}
+
+// observe extends the backoff when err shows the lookup was throttled
+func (t *throttleTracker) observe(err error, now time.Time) {
+	if t == nil || !errors.Is(err, errs.ErrThrottled) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Before(t.state.Until) {
+		return // Already backing off, concurrent lookups must not compound it
+	}
+
+	backoff := 2 * t.state.Backoff
+	if backoff < MinThrottleBackoff {
+		backoff = MinThrottleBackoff
+	}
+	if backoff > MaxThrottleBackoff {
+		backoff = MaxThrottleBackoff
+	}
+	t.state = ThrottleState{LastThrottled: now.UTC(), Backoff: backoff, Until: now.Add(backoff).UTC()}
+	t.throttled = true
+	log.Printf("Identity provider throttled lookups, backing off for %s", backoff)
+}
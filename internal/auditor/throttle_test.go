@@ -0,0 +1,106 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+)
+
+// throttlingChecker throttles every lookup and counts the calls
+type throttlingChecker struct {
+	calls int
+}
+
+func (c *throttlingChecker) UserExists(context.Context, string) (bool, error) {
+	c.calls++
+	return false, fmt.Errorf("unexpected API response: %w", errs.ErrThrottled)
+}
+
+// TestThrottleBackoff validates doubling and bounds of the backoff
+func TestThrottleBackoff(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := &throttleTracker{}
+
+	tracker.observe(fmt.Errorf("boom"), now)
+	if _, ok := tracker.active(now); ok {
+		t.Fatal("Errors other than throttling must not back off")
+	}
+
+	tracker.observe(errs.ErrThrottled, now)
+	if until, ok := tracker.active(now); !ok || !until.Equal(now.Add(MinThrottleBackoff)) {
+		t.Fatalf("Expected backoff until %s, got %s (%t)", now.Add(MinThrottleBackoff), until, ok)
+	}
+
+	// Throttling during an active window does not compound the backoff
+	tracker.observe(errs.ErrThrottled, now.Add(time.Second))
+	if tracker.state.Backoff != MinThrottleBackoff {
+		t.Errorf("Backoff compounded within window: %s", tracker.state.Backoff)
+	}
+
+	later := now.Add(2 * time.Minute)
+	tracker.observe(errs.ErrThrottled, later)
+	if tracker.state.Backoff != 2*MinThrottleBackoff {
+		t.Errorf("Expected doubled backoff, got %s", tracker.state.Backoff)
+	}
+
+	tracker.state.Backoff = MaxThrottleBackoff
+	tracker.observe(errs.ErrThrottled, later.Add(2*MaxThrottleBackoff))
+	if tracker.state.Backoff != MaxThrottleBackoff {
+		t.Errorf("Backoff exceeded maximum: %s", tracker.state.Backoff)
+	}
+
+	var none *throttleTracker
+	none.observe(errs.ErrThrottled, now) // Must not panic
+	if _, ok := none.active(now); ok {
+		t.Error("Nil tracker must never back off")
+	}
+}
+
+// TestThrottleDefersLookups validates that a throttled lookup defers the rest
+// of the run and that a saved backoff defers the next run
+func TestThrottleDefersLookups(t *testing.T) {
+	checker := &throttlingChecker{}
+	processor := newTestProcessor(true, nil, true)
+	processor.azureClient = checker
+	processor.SetThrottleState(ThrottleState{})
+
+	var actions []Action
+	captureLogs(func() {
+		for _, owner := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			actions = append(actions, processor.ProcessNamespaceTraced(context.TODO(), ownedNamespace(owner, owner)).Action)
+		}
+	})
+	if checker.calls != 1 || actions[0] != ActionError || actions[1] != ActionDefer || actions[2] != ActionDefer {
+		t.Errorf("Expected one throttled lookup then deferrals, got %d calls, %v", checker.calls, actions)
+	}
+
+	saved := processor.ThrottleState()
+	if saved.Backoff != MinThrottleBackoff || saved.LastThrottled.IsZero() {
+		t.Fatalf("Unexpected saved state: %+v", saved)
+	}
+
+	next := newTestProcessor(true, nil, true)
+	next.azureClient = checker
+	next.SetThrottleState(saved)
+	captureLogs(func() {
+		next.ProcessNamespace(context.TODO(), ownedNamespace("a", "a@example.com"))
+	})
+	if checker.calls != 1 || next.Deferred() != 1 {
+		t.Errorf("Next run should defer without lookups, got %d calls, %d deferred", checker.calls, next.Deferred())
+	}
+}
+
+// TestThrottleStateExpires validates that an expired backoff is cleared
+func TestThrottleStateExpires(t *testing.T) {
+	processor := newTestProcessor(true, nil, true)
+	last := time.Now().Add(-time.Hour).UTC()
+	processor.SetThrottleState(ThrottleState{LastThrottled: last, Backoff: time.Minute, Until: last.Add(time.Minute)})
+
+	state := processor.ThrottleState()
+	if state.Backoff != 0 || !state.Until.IsZero() || !state.LastThrottled.Equal(last) {
+		t.Errorf("Expected backoff cleared but history kept, got %+v", state)
+	}
+}
@@ -0,0 +1,35 @@
+package auditor
+
+import (
+	"fmt"
+	"time"
+)
+
+// markerLayouts lists the timestamp formats accepted in deletion markers, in
+// order of preference. Layouts without a zone are interpreted as UTC.
+var markerLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// formatMarkerTime renders a deletion marker timestamp. Markers are always
+// written in UTC so that values are comparable regardless of the local
+// time zone of whoever wrote them.
+func formatMarkerTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseMarkerTime tolerantly parses a deletion marker timestamp written by
+// the auditor or by other tooling. RFC3339 values with any offset are
+// accepted, as are a few common variants; the result is normalized to UTC.
+func parseMarkerTime(value string) (time.Time, error) {
+	for _, layout := range markerLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
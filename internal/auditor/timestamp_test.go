@@ -0,0 +1,53 @@
+package auditor
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseMarkerTime validates tolerant parsing and UTC normalization
+func TestParseMarkerTime(t *testing.T) {
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string    // Test scenario description
+		value   string    // Raw annotation value
+		want    time.Time // Expected UTC time
+		wantErr bool      // Whether parsing should fail
+	}{
+		{name: "UTC", value: "2024-01-02T03:04:05Z", want: want},
+		{name: "positive offset", value: "2024-01-02T05:04:05+02:00", want: want},
+		{name: "negative offset", value: "2024-01-01T22:04:05-05:00", want: want},
+		{name: "fractional seconds", value: "2024-01-02T03:04:05.000Z", want: want},
+		{name: "no zone treated as UTC", value: "2024-01-02T03:04:05", want: want},
+		{name: "space separator", value: "2024-01-02 03:04:05Z", want: want},
+		{name: "date only", value: "2024-01-02", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "garbage", value: "not-a-real-timestamp", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMarkerTime(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("Parse mismatch for %q:\nExpected: %v\nGot: %v", tt.value, tt.want, got)
+			}
+		})
+	}
+}
+
+// TestFormatMarkerTime ensures markers are always written in UTC
+func TestFormatMarkerTime(t *testing.T) {
+	local := time.Date(2024, 1, 2, 5, 4, 5, 0, time.FixedZone("EET", 2*60*60))
+	if got := formatMarkerTime(local); got != "2024-01-02T03:04:05Z" {
+		t.Errorf("Expected UTC timestamp, got %q", got)
+	}
+}
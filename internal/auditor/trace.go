@@ -0,0 +1,148 @@
+package auditor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+)
+
+// Action identifies the outcome of evaluating a namespace.
+type Action string
+
+const (
+	ActionNone       Action = "none"       // Nothing to do (e.g. owner valid, no marker present)
+	ActionSkip       Action = "skip"       // Namespace not eligible for auditing
+	ActionExcluded   Action = "excluded"   // Excluded by the namespace name filter, never touched
+	ActionError      Action = "error"      // Evaluation aborted by an error
+	ActionMark       Action = "mark"       // Deletion marker added
+	ActionUnmark     Action = "unmark"     // Deletion marker removed
+	ActionWait       Action = "wait"       // Marked, grace period still running
+	ActionDelete     Action = "delete"     // Grace period expired, namespace deleted
+	ActionCordon     Action = "cordon"     // Grace period expired, access removed but data kept
+	ActionOrphan     Action = "orphan"     // Grace period expired, kept as orphaned until claimed or the orphan period expires
+	ActionQuarantine Action = "quarantine" // Grace period expired, quarantined until the quarantine period expires
+	ActionReset      Action = "reset"      // Malformed marker removed
+	ActionHold       Action = "hold"       // Malformed marker left in place until fixed or treated as expired
+	ActionClaim      Action = "claim"      // Ownership transferred to a claimant
+	ActionFinalize   Action = "finalize"   // Finalizers released, deletion can complete
+	ActionStuck      Action = "stuck"      // Deleted but still terminating past the threshold
+	ActionDefer      Action = "defer"      // Lookup, deletion or mark budget exhausted, left for the next run
+	ActionForeign    Action = "foreign"    // Deletion marker not written by the auditor, left in place
+	ActionDamp       Action = "damp"       // State change on a flapping namespace held back until stable
+	ActionCached     Action = "cached"     // Unchanged since its owner was confirmed valid, not re-evaluated
+	ActionChanged    Action = "changed"    // Modified by others since it was read, left for the next run
+	ActionExempt     Action = "exempt"     // Exempted from auditing, indefinitely or until a set time
+	ActionConfirm    Action = "confirm"    // Owner missing, marking waits for confirmation on further runs
+	ActionLinked     Action = "linked"     // Companion of another namespace, follows its decisions
+	ActionRamp       Action = "ramp"       // Outside the slow-start sample of this run, left for a later run
+	ActionSliced     Action = "sliced"     // Outside the slice of namespaces of this run, left for the run of its slice
+)
+
+// TraceStep is a single entry in a decision trace.
+type TraceStep struct {
+	Step   string `json:"step"`   // Short name of the evaluation stage
+	Detail string `json:"detail"` // Human-readable outcome of the stage
+}
+
+// Trace records every step taken while evaluating a namespace together
+// with the resulting action. It is only populated when explicitly
+// requested; a nil *Trace silently discards all steps.
+type Trace struct {
+	Namespace string            `json:"namespace"`
+	Context   map[string]string `json:"context,omitempty"` // Selected labels or annotations, see SetContextKeys
+	Steps     []TraceStep       `json:"steps"`
+	Action    Action            `json:"action"`
+	Error     string            `json:"error,omitempty"` // Why the evaluation or its change failed
+
+	Source *identity.Provenance `json:"source,omitempty"` // Where the owner verdict came from, if looked up
+
+	Owner    string `json:"owner,omitempty"`    // Owner checked, see OwnerAnnotation
+	Domain   string `json:"domain,omitempty"`   // Outcome of the domain check, see DomainAllowed
+	Identity string `json:"identity,omitempty"` // Verdict on the owner: a user state, or how it was reached without a lookup
+
+	err error // Failure behind Error, see fail
+}
+
+// add appends a formatted step to the trace
+func (t *Trace) add(step, format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.Steps = append(t.Steps, TraceStep{Step: step, Detail: fmt.Sprintf(format, args...)})
+}
+
+// Outcomes of the domain check recorded in traces
+const (
+	DomainAllowed    = "allowed"
+	DomainDisallowed = "disallowed"
+)
+
+// Identity verdicts reached without an identity-provider lookup
+const (
+	IdentityAllowlisted   = "allowlisted"
+	IdentityListedValid   = "listed-valid"
+	IdentityListedInvalid = "listed-invalid"
+	IdentityCached        = "cached-valid"
+	IdentityError         = "error"
+)
+
+// setOwner records the owner checked for the namespace
+func (t *Trace) setOwner(owner string) {
+	if t == nil {
+		return
+	}
+	t.Owner = owner
+}
+
+// setDomain records the outcome of the domain check
+func (t *Trace) setDomain(allowed bool) {
+	if t == nil {
+		return
+	}
+	t.Domain = DomainDisallowed
+	if allowed {
+		t.Domain = DomainAllowed
+	}
+}
+
+// setIdentity records the verdict on the owner
+func (t *Trace) setIdentity(verdict string) {
+	if t == nil {
+		return
+	}
+	t.Identity = verdict
+}
+
+// setAction records the final action for the namespace
+func (t *Trace) setAction(a Action) {
+	if t == nil {
+		return
+	}
+	t.Action = a
+}
+
+// fail records that evaluating the namespace, or applying the change decided
+// for it, failed with err. The first failure is kept.
+func (t *Trace) fail(err error) {
+	if t == nil || t.err != nil {
+		return
+	}
+	t.Action = ActionError
+	t.err = err
+	t.Error = err.Error()
+}
+
+// String renders the trace in a human-readable multi-line format.
+func (t *Trace) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Namespace: %s\n", t.Namespace)
+	for i, s := range t.Steps {
+		fmt.Fprintf(&b, "  %2d. [%s] %s\n", i+1, s.Step, s.Detail)
+	}
+	fmt.Fprintf(&b, "Action: %s\n", t.Action)
+	if t.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", t.Error)
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SetTwoPersonThreshold requires two distinct approvals before deleting a
+// namespace whose PersistentVolumeClaims request more storage than
+// threshold in total. A zero threshold disables the rule.
+func (p *NamespaceProcessor) SetTwoPersonThreshold(threshold resource.Quantity) {
+	p.twoPersonThreshold = threshold
+}
+
+// requestedStorage returns the storage requested by all PersistentVolumeClaims
+// of a namespace
+func (p *NamespaceProcessor) requestedStorage(ctx context.Context, namespace string) (resource.Quantity, error) {
+	start := time.Now()
+	claims, err := p.k8sClient.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	p.apiStats.observe(OpPVCList, start, err)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("listing persistent volume claims: %w", err)
+	}
+	var total resource.Quantity
+	for _, claim := range claims.Items {
+		if size, ok := claim.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			total.Add(size)
+		}
+	}
+	return total, nil
+}
+
+// needsTwoPersons reports whether deleting ns needs two approvals, with the
+// reason. Namespaces whose storage cannot be determined need them too, so
+// that a listing failure never lets a large deletion through.
+func (p *NamespaceProcessor) needsTwoPersons(ctx context.Context, ns corev1.Namespace) (string, bool) {
+	if p.twoPersonThreshold.IsZero() {
+		return "", false
+	}
+	size, err := p.requestedStorage(ctx, ns.Name)
+	if err != nil {
+		return fmt.Sprintf("storage unknown (%v)", err), true
+	}
+	if size.Cmp(p.twoPersonThreshold) <= 0 {
+		p.trace.add("approval", "%s of storage requested, within the two-person threshold %s", size.String(), p.twoPersonThreshold.String())
+		return "", false
+	}
+	return fmt.Sprintf("%s of storage requested, above %s", size.String(), p.twoPersonThreshold.String()), true
+}
+
+// approvers returns the distinct approvers of the deletion of ns, compared
+// case-insensitively
+func approvers(ns corev1.Namespace) []string {
+	var names []string
+	for _, key := range []string{DeletionApprovedAnnotation, SecondApprovalAnnotation} {
+		name := strings.TrimSpace(ns.Annotations[key])
+		if name == "" {
+			continue
+		}
+		if len(names) > 0 && strings.EqualFold(names[0], name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
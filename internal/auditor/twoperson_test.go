@@ -0,0 +1,121 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// largeNamespace builds an expired namespace with the given extra annotations
+func largeNamespace(annotations map[string]string) *corev1.Namespace {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "large",
+		Annotations: map[string]string{
+			OwnerAnnotation:       "gone@example.com",
+			GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+		},
+	}}
+	for k, v := range annotations {
+		ns.Annotations[k] = v
+	}
+	return ns
+}
+
+// newTwoPersonProcessor returns a processor requiring two approvals above
+// 100Gi, for a namespace with claims of the given sizes
+func newTwoPersonProcessor(t *testing.T, ns *corev1.Namespace, notifier Notifier, sizes ...string) *NamespaceProcessor {
+	p := newTestProcessor(false, []*corev1.Namespace{ns}, false)
+	p.SetNotifier(notifier)
+	p.SetTwoPersonThreshold(resource.MustParse("100Gi"))
+	for i, size := range sizes {
+		claim := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data-" + string(rune('a'+i)), Namespace: ns.Name},
+			Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			}},
+		}
+		if _, err := p.k8sClient.CoreV1().PersistentVolumeClaims(ns.Name).Create(context.TODO(), claim, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Creating claim: %v", err)
+		}
+	}
+	return p
+}
+
+// TestTwoPersonRule validates that deleting a namespace above the storage
+// threshold needs two distinct approvers
+func TestTwoPersonRule(t *testing.T) {
+	tests := []struct {
+		name        string
+		sizes       []string
+		annotations map[string]string
+		expected    Action
+	}{
+		{name: "below threshold", sizes: []string{"50Gi", "50Gi"}, expected: ActionDelete},
+		{name: "above threshold without approval", sizes: []string{"80Gi", "40Gi"}, expected: ActionWait},
+		{name: "single approval", sizes: []string{"200Gi"},
+			annotations: map[string]string{DeletionApprovedAnnotation: "alice@example.com"}, expected: ActionWait},
+		{name: "same approver twice", sizes: []string{"200Gi"},
+			annotations: map[string]string{DeletionApprovedAnnotation: "alice@example.com", SecondApprovalAnnotation: "Alice@example.com"}, expected: ActionWait},
+		{name: "two approvers", sizes: []string{"200Gi"},
+			annotations: map[string]string{DeletionApprovedAnnotation: "alice@example.com", SecondApprovalAnnotation: "bob@example.com"}, expected: ActionDelete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := largeNamespace(tt.annotations)
+			p := newTwoPersonProcessor(t, ns, &recordingNotifier{}, tt.sizes...)
+
+			var tr *Trace
+			captureLogs(func() {
+				tr = p.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+			})
+			if tr.Action != tt.expected {
+				t.Errorf("Action = %q, want %q\n%s", tr.Action, tt.expected, tr)
+			}
+		})
+	}
+}
+
+// TestTwoPersonRuleRequestsApproval validates that approval is requested once
+func TestTwoPersonRuleRequestsApproval(t *testing.T) {
+	ns := largeNamespace(nil)
+	notifier := &recordingNotifier{}
+	p := newTwoPersonProcessor(t, ns, notifier, "1Ti")
+
+	for run := 0; run < 2; run++ {
+		current, err := p.GetNamespace(context.TODO(), ns.Name)
+		if err != nil {
+			t.Fatalf("Namespace must not be deleted without approval: %v", err)
+		}
+		captureLogs(func() {
+			p.ProcessNamespace(context.TODO(), *current)
+		})
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Event != EventApprovalRequired {
+		t.Errorf("Expected one approval request, got %+v", notifier.sent)
+	}
+}
+
+// TestTwoPersonRuleUnknownStorage ensures a failed claim listing holds the deletion
+func TestTwoPersonRuleUnknownStorage(t *testing.T) {
+	ns := largeNamespace(map[string]string{DeletionApprovedAnnotation: "alice@example.com"})
+	p := newTwoPersonProcessor(t, ns, &recordingNotifier{})
+	p.k8sClient.(*fake.Clientset).PrependReactor("list", "persistentvolumeclaims", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(corev1.Resource("persistentvolumeclaims"), "", errors.New("denied"))
+	})
+
+	var tr *Trace
+	captureLogs(func() {
+		tr = p.ProcessNamespaceTraced(context.TODO(), *ns.DeepCopy())
+	})
+	if tr.Action != ActionWait {
+		t.Errorf("Action = %q, want %q\n%s", tr.Action, ActionWait, tr)
+	}
+}
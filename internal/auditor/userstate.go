@@ -0,0 +1,69 @@
+package auditor
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// UserStateReporter is optionally implemented by UserExistenceChecker
+// implementations that can tell disabled and soft-deleted accounts apart
+// from active and missing ones. When available it replaces UserExists, so
+// that each state can be handled by its own policy.
+type UserStateReporter interface {
+	UserState(ctx context.Context, email string) (identity.UserState, error)
+}
+
+// SetDisabledUserPolicy configures how namespaces of disabled owners are
+// handled. With OwnerPolicySkip, the default, the disabled state is not acted
+// on: the account still exists and the owner is treated as valid. With
+// OwnerPolicyExpire they are marked and expire like those of missing owners;
+// gracePeriod overrides the regular grace period for them, zero keeping it.
+func (p *NamespaceProcessor) SetDisabledUserPolicy(policy OwnerPolicy, gracePeriod time.Duration) {
+	p.disabledUserPolicy = policy
+	p.disabledUserGrace = gracePeriod
+}
+
+// SetDeletedUserGracePeriod overrides the grace period for namespaces of
+// soft-deleted owners, whose accounts can still be restored. Zero keeps the
+// regular grace period.
+func (p *NamespaceProcessor) SetDeletedUserGracePeriod(gracePeriod time.Duration) {
+	p.deletedUserGrace = gracePeriod
+}
+
+// handleUserState applies the policy for the account state of a namespace's owner
+func (p *NamespaceProcessor) handleUserState(ns corev1.Namespace, email string, state identity.UserState) {
+	switch state {
+	case identity.UserActive:
+		p.handleValidUser(ns)
+	case identity.UserDisabled:
+		if p.disabledUserPolicy != OwnerPolicyExpire {
+			p.trace.add("policy", "owner is disabled, disabled-user policy %q treats it as valid", p.disabledUserPolicy)
+			p.handleValidUser(ns)
+			return
+		}
+		p.trace.add("policy", "owner is disabled, disabled-user policy %q, grace period override %s", p.disabledUserPolicy, p.disabledUserGrace)
+		log.Printf("Treating %s as unowned: owner %s is disabled", ns.Name, email)
+		p.withGracePeriod(p.disabledUserGrace).handleInvalidUser(ns)
+	case identity.UserDeleted:
+		p.trace.add("policy", "owner is soft-deleted, grace period override %s", p.deletedUserGrace)
+		p.withGracePeriod(p.deletedUserGrace).handleInvalidUser(ns)
+	case identity.UserNotMember:
+		p.trace.add("policy", "owner is not a member of a required group")
+		log.Printf("Treating %s as unowned: owner %s is not a member of a required group", ns.Name, email)
+		p.handleInvalidUser(ns)
+	default:
+		p.handleInvalidUser(ns)
+	}
+}
+
+// stateOf maps the result of a plain existence check to a user state
+func stateOf(exists bool) identity.UserState {
+	if exists {
+		return identity.UserActive
+	}
+	return identity.UserNotFound
+}
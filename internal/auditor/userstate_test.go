@@ -0,0 +1,79 @@
+package auditor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// stateChecker reports a fixed account state for every user
+type stateChecker struct {
+	state identity.UserState
+}
+
+// UserExists implements UserExistenceChecker
+func (c *stateChecker) UserExists(ctx context.Context, email string) (bool, error) {
+	return c.state.Exists(), nil
+}
+
+// UserState implements UserStateReporter
+func (c *stateChecker) UserState(ctx context.Context, email string) (identity.UserState, error) {
+	return c.state, nil
+}
+
+// TestUserStatePolicies validates the handling of each owner account state
+func TestUserStatePolicies(t *testing.T) {
+	markedAt := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name     string
+		state    identity.UserState
+		policy   OwnerPolicy
+		marker   string
+		expected Action
+	}{
+		{name: "active owner", state: identity.UserActive, expected: ActionNone},
+		{name: "disabled owner treated as valid", state: identity.UserDisabled, marker: markedAt, expected: ActionUnmark},
+		{name: "disabled owner marked", state: identity.UserDisabled, policy: OwnerPolicyExpire, expected: ActionMark},
+		{name: "disabled owner with short grace", state: identity.UserDisabled, policy: OwnerPolicyExpire, marker: markedAt, expected: ActionDelete},
+		{name: "soft-deleted owner with long grace", state: identity.UserDeleted, marker: markedAt, expected: ActionWait},
+		{name: "missing owner", state: identity.UserNotFound, marker: markedAt, expected: ActionDelete},
+		{name: "owner outside required groups", state: identity.UserNotMember, expected: ActionMark},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := ownedNamespace("team", "owner@example.com")
+			if tt.marker != "" {
+				ns.Annotations[GracePeriodAnnotation] = tt.marker
+			}
+			p := newTestProcessor(false, []*corev1.Namespace{ns.DeepCopy()}, false)
+			p.azureClient = &stateChecker{state: tt.state}
+			p.SetDisabledUserPolicy(tt.policy, time.Hour)
+			p.SetDeletedUserGracePeriod(30 * 24 * time.Hour)
+
+			var tr *Trace
+			captureLogs(func() {
+				tr = p.ProcessNamespaceTraced(context.TODO(), ns)
+			})
+			if tr.Action != tt.expected {
+				t.Errorf("Action = %q, want %q\n%s", tr.Action, tt.expected, tr)
+			}
+		})
+	}
+}
+
+// TestPrefetchUserState validates that prefetched lookups keep the account state
+func TestPrefetchUserState(t *testing.T) {
+	ns := ownedNamespace("team", "owner@example.com")
+	p := newTestProcessor(false, []*corev1.Namespace{ns.DeepCopy()}, false)
+	p.azureClient = &stateChecker{state: identity.UserDeleted}
+
+	captureLogs(func() {
+		p.Prefetch(context.TODO(), []corev1.Namespace{ns})
+	})
+	if got := p.resolved["owner@example.com"].state; got != identity.UserDeleted {
+		t.Errorf("Prefetched state = %q, want %q", got, identity.UserDeleted)
+	}
+}
@@ -0,0 +1,52 @@
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// VolumeSnapshotter snapshots the persistent volumes of a namespace and
+// returns the names of the snapshots kept once the namespace is deleted.
+// Implemented by backup.Snapshotter.
+type VolumeSnapshotter interface {
+	SnapshotVolumes(ctx context.Context, namespace string) ([]string, error)
+}
+
+// SetVolumeSnapshots snapshots the volumes of every namespace with s before
+// it is deleted. A namespace whose volumes cannot be snapshotted is not
+// deleted.
+func (p *NamespaceProcessor) SetVolumeSnapshots(s VolumeSnapshotter) {
+	p.snapshots = s
+}
+
+// snapshotVolumes snapshots the volumes of ns if enabled, and records the
+// snapshots in VolumeSnapshotsAnnotation. Dry runs only trace the step.
+func (p *NamespaceProcessor) snapshotVolumes(ctx context.Context, ns *corev1.Namespace) error {
+	if p.snapshots == nil {
+		return nil
+	}
+	if p.dryRun {
+		p.trace.add("snapshot", "volumes would be snapshotted before deletion")
+		return nil
+	}
+
+	contents, err := p.snapshots.SnapshotVolumes(ctx, ns.Name)
+	if err != nil {
+		return fmt.Errorf("snapshotting volumes: %w", err)
+	}
+	if len(contents) == 0 {
+		p.trace.add("snapshot", "no bound volumes to snapshot")
+		return nil
+	}
+	log.Printf("Snapshotted %d volumes of namespace %s: %s", len(contents), ns.Name, strings.Join(contents, ", "))
+	p.trace.add("snapshot", "volumes snapshotted to %s", strings.Join(contents, ", "))
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	ns.Annotations[VolumeSnapshotsAnnotation] = strings.Join(contents, ",")
+	return p.updateNamespace(ctx, ns)
+}
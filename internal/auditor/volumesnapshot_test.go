@@ -0,0 +1,72 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordingSnapshotter records the namespaces whose volumes it snapshots
+type recordingSnapshotter struct {
+	namespaces []string
+	err        error
+}
+
+// SnapshotVolumes implements VolumeSnapshotter
+func (s *recordingSnapshotter) SnapshotVolumes(_ context.Context, namespace string) ([]string, error) {
+	s.namespaces = append(s.namespaces, namespace)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []string{"snapcontent-1", "snapcontent-2"}, nil
+}
+
+// TestVolumeSnapshotsBeforeDeletion validates that volumes are snapshotted
+// before deletion, and namespaces kept if snapshots fail
+func TestVolumeSnapshotsBeforeDeletion(t *testing.T) {
+	expired := func() []*corev1.Namespace {
+		return []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{
+			OwnerAnnotation:       "alice@example.com",
+			GracePeriodAnnotation: "2020-01-01T00:00:00Z",
+		}}}}
+	}
+	process := func(p *NamespaceProcessor) *Trace {
+		ns, err := p.GetNamespace(context.TODO(), "alice")
+		if err != nil {
+			t.Fatalf("GetNamespace: %v", err)
+		}
+		var tr *Trace
+		captureLogs(func() { tr = p.ProcessNamespaceTraced(context.TODO(), *ns) })
+		return tr
+	}
+
+	snapshotter := &recordingSnapshotter{err: errors.New("snapshot data not ready within 10m0s")}
+	p := newTestProcessor(false, expired(), false)
+	p.SetVolumeSnapshots(snapshotter)
+	if tr := process(p); tr.Action != ActionError || !strings.Contains(tr.Error, "not ready") {
+		t.Errorf("Trace = %+v, want failed snapshots", tr)
+	}
+	if _, err := p.GetNamespace(context.TODO(), "alice"); err != nil {
+		t.Fatalf("A namespace whose volumes could not be snapshotted should be kept: %v", err)
+	}
+
+	snapshotter.err = nil
+	if tr := process(p); tr.Action != ActionDelete {
+		t.Fatalf("Action = %q, want %q", tr.Action, ActionDelete)
+	}
+	if len(snapshotter.namespaces) != 2 {
+		t.Errorf("Snapshots = %v, want two attempts", snapshotter.namespaces)
+	}
+
+	// Dry runs do not snapshot anything
+	dry := newTestProcessor(false, expired(), true)
+	dry.SetVolumeSnapshots(snapshotter)
+	process(dry)
+	if len(snapshotter.namespaces) != 2 {
+		t.Errorf("Dry runs should not snapshot volumes: %v", snapshotter.namespaces)
+	}
+}
@@ -0,0 +1,142 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// benchFleet describes the size of a synthetic fleet used for benchmarking
+type benchFleet struct {
+	namespaces int // Number of Kubeflow profile namespaces
+	users      int // Number of distinct owners; every other owner is missing
+}
+
+// benchFleets returns the fleet sizes to benchmark. Defaults cover small and
+// 10k+ fleets; BENCH_NAMESPACES and BENCH_USERS select a single custom size.
+func benchFleets(b *testing.B) []benchFleet {
+	if v := os.Getenv("BENCH_NAMESPACES"); v != "" {
+		namespaces, err := strconv.Atoi(v)
+		if err != nil {
+			b.Fatalf("Invalid BENCH_NAMESPACES: %v", err)
+		}
+		users := namespaces
+		if u := os.Getenv("BENCH_USERS"); u != "" {
+			if users, err = strconv.Atoi(u); err != nil {
+				b.Fatalf("Invalid BENCH_USERS: %v", err)
+			}
+		}
+		return []benchFleet{{namespaces: namespaces, users: users}}
+	}
+	return []benchFleet{
+		{namespaces: 1000, users: 500},
+		{namespaces: 10000, users: 5000},
+	}
+}
+
+// newFakeGraphServer starts a Graph API stand-in where user-<n>@example.com
+// exists for even n. It counts the lookups it serves.
+func newFakeGraphServer(calls *int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(calls, 1)
+		var n int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/v1.0/users/"), "user-%d@example.com", &n); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if n%2 == 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+}
+
+// newBenchClientset creates a fake cluster populated with the fleet. A third
+// of the namespaces already carry an expired deletion marker.
+func newBenchClientset(fleet benchFleet) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	for i := 0; i < fleet.namespaces; i++ {
+		annotations := map[string]string{
+			auditor.OwnerAnnotation: fmt.Sprintf("user-%d@example.com", i%fleet.users),
+		}
+		if i%3 == 0 {
+			annotations[auditor.GracePeriodAnnotation] = "2020-01-01T00:00:00Z"
+		}
+		client.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        fmt.Sprintf("profile-%d", i),
+				Labels:      map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+				Annotations: annotations,
+			},
+		}, metav1.CreateOptions{})
+	}
+	return client
+}
+
+// BenchmarkAuditRun measures a complete audit run (list, identity lookups,
+// mutations) against a fake cluster and fake Graph server, reporting run
+// duration, allocations, and API calls per run.
+//
+// Run with: go test -run=XXX -bench=AuditRun -benchmem ./internal/azure
+func BenchmarkAuditRun(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, fleet := range benchFleets(b) {
+		b.Run(fmt.Sprintf("namespaces=%d/users=%d", fleet.namespaces, fleet.users), func(b *testing.B) {
+			var graphCalls int64
+			server := newFakeGraphServer(&graphCalls)
+			defer server.Close()
+
+			origUserURL := userURLFormat
+			userURLFormat = server.URL + "/v1.0/users/%s"
+			defer func() { userURLFormat = origUserURL }()
+
+			graph := &GraphClient{cred: &mockTokenCredential{token: "bench-token"}}
+
+			var k8sCalls int
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				processor := auditor.NewNamespaceProcessor(
+					newBenchClientset(fleet),
+					graph,
+					auditor.WithGracePeriod(24*time.Hour),
+					auditor.WithAllowedDomains([]string{"example.com"}),
+				)
+				b.StartTimer()
+
+				nsList, err := processor.ListNamespaces(context.TODO(), auditor.KubeflowLabel)
+				if err != nil {
+					b.Fatalf("Listing namespaces failed: %v", err)
+				}
+				for _, ns := range nsList.Items {
+					processor.ProcessNamespace(context.TODO(), ns)
+				}
+
+				for _, st := range processor.APIStats().Snapshot() {
+					k8sCalls += st.Count
+				}
+			}
+
+			b.ReportMetric(float64(k8sCalls)/float64(b.N), "k8s-calls/run")
+			b.ReportMetric(float64(atomic.LoadInt64(&graphCalls))/float64(b.N), "graph-calls/run")
+		})
+	}
+}
@@ -1,16 +1,76 @@
 package azure
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
 )
 
+// userURLFormat defines the Microsoft Graph API endpoint template for user lookups.
+// Overridden in tests to point at a fake Graph server.
+var userURLFormat = publicGraphEndpoint + "/v1.0/users/%s"
+
+// deletedUsersURL defines the Microsoft Graph API endpoint listing deleted users.
+// Overridden in tests to point at a fake Graph server.
+var deletedUsersURL = publicGraphEndpoint + "/v1.0/directory/deletedItems/microsoft.graph.user"
+
+// batchURL defines the Microsoft Graph JSON batching endpoint.
+// Overridden in tests to point at a fake Graph server.
+var batchURL = publicGraphEndpoint + "/v1.0/$batch"
+
+// BatchSize is the maximum number of requests Microsoft Graph accepts in a
+// single JSON batch.
+const BatchSize = 20
+
+// PermissionError reports that Microsoft Graph rejected a lookup because the
+// application lacks the required permissions (HTTP 403). Unlike other lookup
+// errors it affects every lookup, so callers should stop rather than retry.
+type PermissionError struct {
+	StatusCode int // HTTP status code returned by Graph
+}
+
+// Error describes the missing permission and how to grant it.
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("Microsoft Graph denied the user lookup (%d %s): the application registration "+
+		"needs the User.Read.All application permission with admin consent",
+		e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Unwrap classifies the error as errs.ErrPermission.
+func (e *PermissionError) Unwrap() error {
+	return errs.ErrPermission
+}
+
+// StatusError reports an unexpected Microsoft Graph response. Graph throttles
+// per tenant and application, so a 429 here slows down every lookup.
+type StatusError struct {
+	StatusCode int // HTTP status code returned by Graph
+}
+
+// Error describes the unexpected response.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected API response: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Unwrap classifies the Graph status code, see errs.FromHTTPStatus.
+func (e *StatusError) Unwrap() error {
+	return errs.FromHTTPStatus(e.StatusCode)
+}
+
 // TokenCredential defines the interface required for Azure token acquisition.
 // This matches the azcore.TokenCredential interface from the Azure SDK.
 type TokenCredential interface {
@@ -20,7 +80,12 @@ type TokenCredential interface {
 // GraphClient provides authentication and operations for Microsoft Graph API.
 // Handles token acquisition and user existence checks.
 type GraphClient struct {
-	cred TokenCredential // Azure authentication credential
+	cred       TokenCredential // Azure authentication credential
+	httpClient *http.Client    // Client for Graph requests, http.DefaultClient if nil
+	retry      RetryPolicy     // Retries of throttled and failed requests
+	groups     []string        // Group object IDs a user must belong to, none to check existence only
+
+	graphEndpoint string // Graph endpoint of the client's cloud, the global one if empty
 }
 
 // NewGraphClient creates a new authenticated client for Microsoft Graph API.
@@ -31,18 +96,51 @@ type GraphClient struct {
 // - clientID: Application client ID
 // - clientSecret: Client secret value
 //
+// Graph and token requests share a transport with explicit dial, TLS
+// handshake and response timeouts (see newHTTPClient). Throttled and failed
+// Graph requests are retried according to DefaultRetryPolicy.
+//
 // Panics if credential creation fails to ensure invalid configurations fail fast.
 func NewGraphClient(tenantID, clientID, clientSecret string) *GraphClient {
+	client, err := newGraphClient(tenantID, clientID, clientSecret, PublicEnvironment, nil)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create Azure credentials: %v", err))
+	}
+	return client
+}
+
+// NewGraphClientWithHTTPClient creates a client like NewGraphClient whose
+// Graph and token requests go through httpClient, e.g. one created with
+// NewHTTPClient to use a proxy or a custom CA bundle. Returns credential
+// errors instead of panicking.
+func NewGraphClientWithHTTPClient(tenantID, clientID, clientSecret string, httpClient *http.Client) (*GraphClient, error) {
+	return newGraphClient(tenantID, clientID, clientSecret, PublicEnvironment, httpClient)
+}
+
+// newGraphClient creates a GraphClient signing in and looking users up in
+// env through httpClient, the default client if nil, returning credential
+// errors
+func newGraphClient(tenantID, clientID, clientSecret string, env Environment, httpClient *http.Client) (*GraphClient, error) {
+	if httpClient == nil {
+		httpClient = newHTTPClient()
+	}
 	cred, err := azidentity.NewClientSecretCredential(
 		tenantID,
 		clientID,
 		clientSecret,
-		nil, // Optional configuration
+		&azidentity.ClientSecretCredentialOptions{
+			ClientOptions: azcore.ClientOptions{Transport: httpClient, Cloud: env.Cloud},
+		},
 	)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to create Azure credentials: %v", err))
+		return nil, err
 	}
-	return &GraphClient{cred: cred}
+	return &GraphClient{
+		cred:          cred,
+		httpClient:    httpClient,
+		retry:         DefaultRetryPolicy(),
+		graphEndpoint: env.GraphEndpoint,
+	}, nil
 }
 
 // UserExists checks if a user exists in Azure Active Directory.
@@ -56,46 +154,331 @@ func NewGraphClient(tenantID, clientID, clientSecret string) *GraphClient {
 // - bool: True if user exists
 // - error: Authentication, network, or API errors
 //
+// With required groups (see SetRequiredGroups) only members of one of them
+// count as existing.
+//
 // Note: Handles Microsoft Graph API response codes:
 // - 200 OK: User exists
 // - 404 Not Found: User doesn't exist
-// - Other status codes: Returned as errors
+// - 403 Forbidden: Returned as *PermissionError
+// - Other status codes: Returned as *StatusError
 func (g *GraphClient) UserExists(ctx context.Context, email string) (bool, error) {
-	// Acquire OAuth2 token for Microsoft Graph API
-	token, err := g.cred.GetToken(ctx, policy.TokenRequestOptions{
-		Scopes: []string{"https://graph.microsoft.com/.default"},
-	})
-	if err != nil {
-		return false, fmt.Errorf("failed to get access token: %w", err)
+	exists, _, err := g.UserExistsWithStatus(ctx, email)
+	return exists, err
+}
+
+// UserExistsWithStatus performs the same lookup as UserExists but also
+// returns the raw HTTP status code of the Graph API response.
+// The status code is 0 if no response was received.
+func (g *GraphClient) UserExistsWithStatus(ctx context.Context, email string) (bool, int, error) {
+	if len(g.groups) > 0 {
+		return g.isMember(ctx, email)
 	}
 
 	// Safely construct user lookup URL
 	escapedEmail := url.PathEscape(email) // Prevent injection/encoding issues
-	userURL := fmt.Sprintf("https://graph.microsoft.com/v1.0/users/%s", escapedEmail)
+	resp, err := g.get(ctx, fmt.Sprintf(userURLFormat, escapedEmail))
+	if err != nil {
+		return false, 0, err
+	}
+	defer resp.Body.Close() // Ensure response body cleanup
 
-	// Create authenticated HTTP request
-	req, err := http.NewRequestWithContext(ctx, "GET", userURL, nil)
+	// Interpret API response
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, resp.StatusCode, nil // Valid user found
+	case http.StatusNotFound:
+		return false, resp.StatusCode, nil // User not found
+	case http.StatusForbidden:
+		return false, resp.StatusCode, &PermissionError{StatusCode: resp.StatusCode}
+	default:
+		// Handle unexpected responses
+		return false, resp.StatusCode, &StatusError{StatusCode: resp.StatusCode}
+	}
+}
+
+// UsersExist checks many users at once through the Microsoft Graph $batch
+// endpoint, sending up to BatchSize lookups per request. Each lookup is
+// interpreted like UserExists, checking group membership when groups are
+// required.
+//
+// Emails whose lookup failed are absent from the returned map, and the first
+// failure is returned as the error, so that callers can keep the lookups
+// that succeeded. Lookups are not retried.
+func (g *GraphClient) UsersExist(ctx context.Context, emails []string) (map[string]bool, error) {
+	found := make(map[string]bool, len(emails))
+	var firstErr error
+	for start := 0; start < len(emails); start += BatchSize {
+		end := min(start+BatchSize, len(emails))
+		if err := g.usersExistBatch(ctx, emails[start:end], found); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if errors.Is(firstErr, errs.ErrThrottled) || errs.Fatal(firstErr) {
+			break // Further batches would fail the same way
+		}
+	}
+	return found, firstErr
+}
+
+// batchRequest is a single request of a Microsoft Graph JSON batch
+type batchRequest struct {
+	ID      string            `json:"id"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    any               `json:"body,omitempty"`
+}
+
+// batchResponse is the response to a single request of a JSON batch
+type batchResponse struct {
+	ID     string          `json:"id"`
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// usersExistBatch looks up at most BatchSize users in one $batch request,
+// recording the results in found. It returns the first lookup failure.
+func (g *GraphClient) usersExistBatch(ctx context.Context, emails []string, found map[string]bool) error {
+	var body struct {
+		Requests []batchRequest `json:"requests"`
+	}
+	for i, email := range emails {
+		req := batchRequest{
+			ID:     strconv.Itoa(i),
+			Method: http.MethodGet,
+			URL:    "/users/" + url.PathEscape(email) + "?$select=id",
+		}
+		if len(g.groups) > 0 {
+			req.Method = http.MethodPost
+			req.URL = "/users/" + url.PathEscape(email) + "/checkMemberGroups"
+			req.Headers = map[string]string{"Content-Type": "application/json"}
+			req.Body = memberGroupsRequest{GroupIDs: g.groups}
+		}
+		body.Requests = append(body.Requests, req)
+	}
+	payload, err := json.Marshal(body)
 	if err != nil {
-		return false, fmt.Errorf("failed to create HTTP request: %w", err)
+		return fmt.Errorf("failed to encode batch: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+token.Token)
 
-	// Execute API request
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := g.do(ctx, http.MethodPost, batchURL, payload)
 	if err != nil {
-		return false, fmt.Errorf("HTTP request failed: %w", err)
+		return err
 	}
-	defer resp.Body.Close() // Ensure response body cleanup
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden:
+		return &PermissionError{StatusCode: resp.StatusCode}
+	default:
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var result struct {
+		Responses []batchResponse `json:"responses"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode batch: %w", err)
+	}
+
+	var firstErr error
+	for _, r := range result.Responses {
+		i, err := strconv.Atoi(r.ID)
+		if err != nil || i < 0 || i >= len(emails) {
+			continue // Not one of ours
+		}
+		switch r.Status {
+		case http.StatusOK:
+			found[emails[i]] = true
+			if len(g.groups) > 0 {
+				var groups memberGroupsResponse
+				if err = json.Unmarshal(r.Body, &groups); err != nil {
+					delete(found, emails[i])
+					err = fmt.Errorf("failed to decode group check: %w", err)
+					break
+				}
+				found[emails[i]] = len(groups.Value) > 0
+			}
+		case http.StatusNotFound:
+			found[emails[i]] = false
+		case http.StatusForbidden:
+			err = &PermissionError{StatusCode: r.Status}
+		default:
+			err = &StatusError{StatusCode: r.Status}
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil && len(result.Responses) < len(emails) {
+		firstErr = fmt.Errorf("batch answered %d of %d lookups", len(result.Responses), len(emails))
+	}
+	return firstErr
+}
+
+// UserState reports the account state of a user. Existing users are active
+// or disabled according to accountEnabled, or not members if they belong to
+// none of the required groups; missing users are looked up among recently
+// deleted users, which Graph keeps restorable for 30 days.
+func (g *GraphClient) UserState(ctx context.Context, email string) (identity.UserState, error) {
+	state, _, err := g.UserStateWithProvenance(ctx, email)
+	return state, err
+}
+
+// UserStateWithProvenance performs the same lookup as UserState and also
+// reports the Graph endpoint queried and how the user was matched: by user
+// principal name, or by mail among recently deleted users for soft-deleted
+// accounts.
+func (g *GraphClient) UserStateWithProvenance(ctx context.Context, email string) (identity.UserState, identity.Provenance, error) {
+	source := identity.Provenance{Provider: ProviderName, Endpoint: g.endpoint(), Method: identity.LookupUPN}
+	resp, err := g.get(ctx, fmt.Sprintf(userURLFormat, url.PathEscape(email))+"?$select=accountEnabled")
+	if err != nil {
+		return "", source, err
+	}
+	defer resp.Body.Close()
 
-	// Interpret API response
 	switch resp.StatusCode {
 	case http.StatusOK:
-		return true, nil // Valid user found
 	case http.StatusNotFound:
-		return false, nil // User not found
+		_, deleted, err := g.UserStateChangedAt(ctx, email)
+		if err != nil {
+			return "", source, err
+		}
+		if deleted {
+			source.Method = identity.LookupMail
+			return identity.UserDeleted, source, nil
+		}
+		return identity.UserNotFound, source, nil
+	case http.StatusForbidden:
+		return "", source, &PermissionError{StatusCode: resp.StatusCode}
 	default:
-		// Handle unexpected responses
-		return false, fmt.Errorf("unexpected API response: %d %s",
-			resp.StatusCode, http.StatusText(resp.StatusCode))
+		return "", source, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var user struct {
+		AccountEnabled *bool `json:"accountEnabled"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return "", source, fmt.Errorf("failed to decode user: %w", err)
+	}
+	if len(g.groups) > 0 {
+		member, _, err := g.isMember(ctx, email)
+		if err != nil {
+			return "", source, err
+		}
+		if !member {
+			return identity.UserNotMember, source, nil
+		}
+	}
+	if user.AccountEnabled != nil && !*user.AccountEnabled {
+		return identity.UserDisabled, source, nil
+	}
+	return identity.UserActive, source, nil
+}
+
+// UserStateChangedAt reports when a user's account was deleted, using the
+// Microsoft Graph deleted items collection. The boolean is false if the user
+// is not among recently deleted users (Graph keeps them for 30 days).
+func (g *GraphClient) UserStateChangedAt(ctx context.Context, email string) (time.Time, bool, error) {
+	query := url.Values{}
+	query.Set("$filter", fmt.Sprintf("mail eq '%s'", strings.ReplaceAll(email, "'", "''")))
+	query.Set("$select", "deletedDateTime")
+
+	resp, err := g.get(ctx, deletedUsersURL+"?"+query.Encode())
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden:
+		return time.Time{}, false, &PermissionError{StatusCode: resp.StatusCode}
+	default:
+		return time.Time{}, false, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var body struct {
+		Value []struct {
+			DeletedDateTime time.Time `json:"deletedDateTime"`
+		} `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to decode deleted users: %w", err)
+	}
+	if len(body.Value) == 0 {
+		return time.Time{}, false, nil
+	}
+	return body.Value[0].DeletedDateTime, true, nil
+}
+
+// get performs an authenticated GET request against Microsoft Graph.
+// The caller must close the response body.
+func (g *GraphClient) get(ctx context.Context, requestURL string) (*http.Response, error) {
+	return g.do(ctx, http.MethodGet, requestURL, nil)
+}
+
+// do performs an authenticated request against Microsoft Graph, sending body
+// as JSON unless it is nil. Throttled (429) and failed (5xx) requests are
+// retried according to the client's RetryPolicy; once it is exhausted, or
+// the next wait would exceed its timeout, the last response is returned.
+// The caller must close the response body.
+func (g *GraphClient) do(ctx context.Context, method, requestURL string, body []byte) (*http.Response, error) {
+	deadline := time.Now().Add(g.retry.Timeout)
+	for attempt := 0; ; attempt++ {
+		resp, err := g.send(ctx, method, requestURL, body)
+		if err != nil || !retryable(resp.StatusCode) || attempt >= g.retry.MaxRetries {
+			return resp, err
+		}
+		wait := retryDelay(resp, attempt)
+		if g.retry.Timeout > 0 && time.Now().Add(wait).After(deadline) {
+			return resp, nil
+		}
+		discard(resp)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("HTTP request failed: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// send performs a single authenticated request against Microsoft Graph, see do
+func (g *GraphClient) send(ctx context.Context, method, requestURL string, body []byte) (*http.Response, error) {
+	// Acquire OAuth2 token for Microsoft Graph API
+	token, err := g.cred.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{g.graphScope()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get access token: %w", errs.ErrAuth, err)
+	}
+
+	// Create authenticated HTTP request
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, g.graphURL(requestURL), reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// Execute API request
+	client := g.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
+	return resp, nil
 }
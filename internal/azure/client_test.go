@@ -2,15 +2,19 @@ package azure
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
 	"github.com/stretchr/testify/require"
 )
 
@@ -79,6 +83,10 @@ func TestUserExists(t *testing.T) {
 			w.WriteHeader(http.StatusNotFound)
 		case "/v1.0/users/error@example.com":
 			w.WriteHeader(http.StatusInternalServerError)
+		case "/v1.0/users/forbidden@example.com":
+			w.WriteHeader(http.StatusForbidden)
+		case "/v1.0/users/throttled@example.com":
+			w.WriteHeader(http.StatusTooManyRequests)
 		default:
 			w.WriteHeader(http.StatusBadRequest)
 		}
@@ -93,6 +101,7 @@ func TestUserExists(t *testing.T) {
 		email       string
 		wantExists  bool
 		expectError bool
+		wantClass   error
 	}{
 		{
 			name:       "valid user exists",
@@ -109,6 +118,18 @@ func TestUserExists(t *testing.T) {
 			email:       "error@example.com",
 			expectError: true,
 		},
+		{
+			name:        "permission denied",
+			email:       "forbidden@example.com",
+			expectError: true,
+			wantClass:   errs.ErrPermission,
+		},
+		{
+			name:        "throttled",
+			email:       "throttled@example.com",
+			expectError: true,
+			wantClass:   errs.ErrThrottled,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -129,6 +150,9 @@ func TestUserExists(t *testing.T) {
 
 			if tt.expectError {
 				require.Error(t, err, "Expected error for case: "+tt.name)
+				if tt.wantClass != nil {
+					require.ErrorIs(t, err, tt.wantClass, "Error class mismatch for case: "+tt.name)
+				}
 				return
 			}
 
@@ -153,6 +177,7 @@ func TestTokenAcquisitionError(t *testing.T) {
 	require.Error(t, err, "Should propagate token acquisition error")
 	require.Contains(t, err.Error(), "failed to get access token",
 		"Error message should mention token failure")
+	require.ErrorIs(t, err, errs.ErrAuth, "Token failure should be classified as an auth error")
 }
 
 // TestNetworkError validates error handling for network failures
@@ -171,5 +196,146 @@ func TestNetworkError(t *testing.T) {
 	require.Error(t, err, "Should detect network connectivity issues")
 }
 
-// userURLFormat defines the Microsoft Graph API endpoint template for user lookups
-var userURLFormat = "https://graph.microsoft.com/v1.0/users/%s"
+// TestUserStateChangedAt validates deleted-user lookups against mock Graph API
+func TestUserStateChangedAt(t *testing.T) {
+	skipIfIntegrationDisabled(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("$filter") {
+		case "mail eq 'gone@example.com'":
+			fmt.Fprint(w, `{"value":[{"deletedDateTime":"2024-03-01T12:00:00Z"}]}`)
+		case "mail eq 'active@example.com'":
+			fmt.Fprint(w, `{"value":[]}`)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer testServer.Close()
+
+	origClient := http.DefaultClient
+	http.DefaultClient = testServer.Client()
+	defer func() { http.DefaultClient = origClient }()
+
+	origURL := deletedUsersURL
+	deletedUsersURL = testServer.URL + "/v1.0/directory/deletedItems/microsoft.graph.user"
+	defer func() { deletedUsersURL = origURL }()
+
+	client := &GraphClient{cred: &mockTokenCredential{token: "test-token"}}
+
+	changed, known, err := client.UserStateChangedAt(context.Background(), "gone@example.com")
+	require.NoError(t, err)
+	require.True(t, known, "Deleted user should be known")
+	require.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), changed.UTC())
+
+	_, known, err = client.UserStateChangedAt(context.Background(), "active@example.com")
+	require.NoError(t, err)
+	require.False(t, known, "Active user should not be known as deleted")
+
+	_, _, err = client.UserStateChangedAt(context.Background(), "denied@example.com")
+	require.ErrorIs(t, err, errs.ErrPermission)
+}
+
+// TestUserState validates account state lookups against mock Graph API
+func TestUserState(t *testing.T) {
+	skipIfIntegrationDisabled(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.0/users/active@example.com":
+			fmt.Fprint(w, `{"accountEnabled":true}`)
+		case "/v1.0/users/disabled@example.com":
+			fmt.Fprint(w, `{"accountEnabled":false}`)
+		case "/v1.0/directory/deletedItems/microsoft.graph.user":
+			if r.URL.Query().Get("$filter") == "mail eq 'gone@example.com'" {
+				fmt.Fprint(w, `{"value":[{"deletedDateTime":"2024-03-01T12:00:00Z"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"value":[]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	origClient := http.DefaultClient
+	http.DefaultClient = testServer.Client()
+	defer func() { http.DefaultClient = origClient }()
+
+	origUserURL, origDeletedURL := userURLFormat, deletedUsersURL
+	userURLFormat = testServer.URL + "/v1.0/users/%s"
+	deletedUsersURL = testServer.URL + "/v1.0/directory/deletedItems/microsoft.graph.user"
+	defer func() { userURLFormat, deletedUsersURL = origUserURL, origDeletedURL }()
+
+	client := &GraphClient{cred: &mockTokenCredential{token: "test-token"}}
+	for email, want := range map[string]identity.UserState{
+		"active@example.com":   identity.UserActive,
+		"disabled@example.com": identity.UserDisabled,
+		"gone@example.com":     identity.UserDeleted,
+		"missing@example.com":  identity.UserNotFound,
+	} {
+		state, err := client.UserState(context.Background(), email)
+		require.NoError(t, err)
+		require.Equal(t, want, state, email)
+	}
+
+	// Soft-deleted accounts are found by mail, all others by principal name
+	_, source, err := client.UserStateWithProvenance(context.Background(), "gone@example.com")
+	require.NoError(t, err)
+	require.Equal(t, identity.Provenance{Provider: ProviderName, Endpoint: publicGraphEndpoint, Method: identity.LookupMail}, source)
+	_, source, err = client.UserStateWithProvenance(context.Background(), "missing@example.com")
+	require.NoError(t, err)
+	require.Equal(t, identity.LookupUPN, source.Method)
+}
+
+// TestUsersExist validates batched user lookups against mock Graph API
+func TestUsersExist(t *testing.T) {
+	skipIfIntegrationDisabled(t)
+
+	var batches int
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Requests []batchRequest `json:"requests"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.LessOrEqual(t, len(body.Requests), BatchSize, "Batch exceeds the Graph limit")
+		batches++
+
+		var result struct {
+			Responses []batchResponse `json:"responses"`
+		}
+		for _, req := range body.Requests {
+			status := http.StatusNotFound
+			switch {
+			case strings.HasPrefix(req.URL, "/users/valid"):
+				status = http.StatusOK
+			case strings.HasPrefix(req.URL, "/users/error"):
+				status = http.StatusInternalServerError
+			}
+			result.Responses = append(result.Responses, batchResponse{ID: req.ID, Status: status})
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(result))
+	}))
+	defer testServer.Close()
+
+	origClient := http.DefaultClient
+	http.DefaultClient = testServer.Client()
+	defer func() { http.DefaultClient = origClient }()
+
+	origURL := batchURL
+	batchURL = testServer.URL + "/v1.0/$batch"
+	defer func() { batchURL = origURL }()
+
+	emails := []string{"error@example.com"}
+	for i := 0; i < BatchSize; i++ {
+		emails = append(emails, fmt.Sprintf("valid%d@example.com", i), fmt.Sprintf("missing%d@example.com", i))
+	}
+
+	client := &GraphClient{cred: &mockTokenCredential{token: "test-token"}}
+	found, err := client.UsersExist(context.Background(), emails)
+	require.Error(t, err, "Failed lookup should be reported")
+	require.Equal(t, 3, batches, "Lookups should be split into batches of BatchSize")
+	require.Len(t, found, len(emails)-1, "Successful lookups should be kept")
+	require.True(t, found["valid0@example.com"])
+	require.False(t, found["missing0@example.com"])
+	require.NotContains(t, found, "error@example.com")
+}
@@ -0,0 +1,92 @@
+package azure
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+// publicGraphEndpoint is the Microsoft Graph endpoint of the global cloud,
+// which the request URLs of this package are written against.
+const publicGraphEndpoint = "https://graph.microsoft.com"
+
+// Environment is a Microsoft cloud, with its own Graph endpoint and sign-in
+// authority.
+type Environment struct {
+	Name          string              // Name used in settings, e.g. "usgov"
+	GraphEndpoint string              // Base URL of Microsoft Graph, without the version
+	Cloud         cloud.Configuration // Authority tokens are requested from
+}
+
+// PublicEnvironment is the global Microsoft cloud.
+var PublicEnvironment = Environment{Name: "public", GraphEndpoint: publicGraphEndpoint, Cloud: cloud.AzurePublic}
+
+// Environments are the known Microsoft clouds by name.
+var Environments = map[string]Environment{
+	"public":   PublicEnvironment,
+	"usgov":    {Name: "usgov", GraphEndpoint: "https://graph.microsoft.us", Cloud: cloud.AzureGovernment},
+	"usgovdod": {Name: "usgovdod", GraphEndpoint: "https://dod-graph.microsoft.us", Cloud: cloud.AzureGovernment},
+	"china":    {Name: "china", GraphEndpoint: "https://microsoftgraph.chinacloudapi.cn", Cloud: cloud.AzureChina},
+}
+
+// ParseEnvironment returns the environment called name, the global cloud if
+// name is empty.
+func ParseEnvironment(name string) (Environment, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return PublicEnvironment, nil
+	}
+	env, ok := Environments[name]
+	if !ok {
+		var names []string
+		for known := range Environments {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return Environment{}, fmt.Errorf("unknown environment %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return env, nil
+}
+
+// WithGraphEndpoint returns a copy of env looking users up at endpoint, for
+// clouds this package does not know or a proxy in front of Graph.
+func (env Environment) WithGraphEndpoint(endpoint string) (Environment, error) {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil || u.Scheme != "https" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+		return Environment{}, fmt.Errorf("invalid Graph endpoint %q, expected an https URL without a path", endpoint)
+	}
+	env.GraphEndpoint = "https://" + u.Host
+	return env, nil
+}
+
+// graphURL rewrites a request URL of the global cloud to the client's Graph
+// endpoint. Other URLs, such as those of test servers, are left unchanged.
+func (g *GraphClient) graphURL(requestURL string) string {
+	if g.graphEndpoint == "" || g.graphEndpoint == publicGraphEndpoint {
+		return requestURL
+	}
+	if rest, ok := strings.CutPrefix(requestURL, publicGraphEndpoint+"/"); ok {
+		return g.graphEndpoint + "/" + rest
+	}
+	return requestURL
+}
+
+// endpoint returns the client's Graph endpoint
+func (g *GraphClient) endpoint() string {
+	if g.graphEndpoint == "" {
+		return publicGraphEndpoint
+	}
+	return g.graphEndpoint
+}
+
+// graphScope returns the scope of the access tokens for the client's Graph
+// endpoint
+func (g *GraphClient) graphScope() string {
+	if g.graphEndpoint == "" {
+		return publicGraphEndpoint + "/.default"
+	}
+	return g.graphEndpoint + "/.default"
+}
@@ -0,0 +1,69 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/require"
+)
+
+// scopeRecorder records the scopes tokens are requested for
+type scopeRecorder struct {
+	mockTokenCredential
+	scopes []string
+}
+
+// GetToken records the requested scopes and returns a mock token
+func (s *scopeRecorder) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	s.scopes = append(s.scopes, options.Scopes...)
+	return s.mockTokenCredential.GetToken(ctx, options)
+}
+
+// TestParseEnvironment validates selecting national clouds and endpoints
+func TestParseEnvironment(t *testing.T) {
+	env, err := ParseEnvironment("")
+	require.NoError(t, err)
+	require.Equal(t, PublicEnvironment, env)
+
+	env, err = ParseEnvironment(" USGov ")
+	require.NoError(t, err)
+	require.Equal(t, "https://graph.microsoft.us", env.GraphEndpoint)
+
+	env, err = env.WithGraphEndpoint("https://graph.example.us/")
+	require.NoError(t, err)
+	require.Equal(t, "https://graph.example.us", env.GraphEndpoint)
+	require.Equal(t, "usgov", env.Name, "Only the Graph endpoint should change")
+
+	_, err = ParseEnvironment("moon")
+	require.ErrorContains(t, err, "expected one of china, public, usgov, usgovdod")
+	for _, endpoint := range []string{"http://graph.example.us", "https://graph.example.us/v1.0", "graph.example.us"} {
+		_, err = PublicEnvironment.WithGraphEndpoint(endpoint)
+		require.Error(t, err, endpoint)
+	}
+}
+
+// TestGraphEndpoint validates that lookups and tokens target the client's
+// Graph endpoint
+func TestGraphEndpoint(t *testing.T) {
+	var paths []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	cred := &scopeRecorder{mockTokenCredential: mockTokenCredential{token: "test-token"}}
+	client := &GraphClient{cred: cred, httpClient: testServer.Client(), graphEndpoint: testServer.URL}
+	exists, err := client.UserExists(context.Background(), "user@example.com")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, []string{"/v1.0/users/user@example.com"}, paths)
+	require.Equal(t, []string{testServer.URL + "/.default"}, cred.scopes)
+
+	require.Equal(t, "https://graph.microsoft.com/.default", (&GraphClient{}).graphScope())
+	require.Equal(t, "http://other/x", client.graphURL("http://other/x"), "Foreign URLs should be left alone")
+}
@@ -0,0 +1,95 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// memberGroupsURLFormat defines the Microsoft Graph endpoint checking a
+// user's transitive group memberships. Overridden in tests to point at a
+// fake Graph server.
+var memberGroupsURLFormat = publicGraphEndpoint + "/v1.0/users/%s/checkMemberGroups"
+
+// MaxRequiredGroups is the number of groups Microsoft Graph checks in a
+// single checkMemberGroups request.
+const MaxRequiredGroups = 20
+
+// groupIDPattern matches Entra ID group object IDs
+var groupIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ParseGroupIDs parses a comma-separated list of Entra ID group object IDs.
+// Whitespace around entries is ignored and empty entries are dropped.
+func ParseGroupIDs(list string) ([]string, error) {
+	var ids, invalid []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !groupIDPattern.MatchString(entry) {
+			invalid = append(invalid, entry)
+			continue
+		}
+		ids = append(ids, strings.ToLower(entry))
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid group object IDs: %q", invalid)
+	}
+	if len(ids) > MaxRequiredGroups {
+		return nil, fmt.Errorf("at most %d groups can be required, got %d", MaxRequiredGroups, len(ids))
+	}
+	return ids, nil
+}
+
+// SetRequiredGroups switches the client to validating owners by group
+// membership: a user only counts as existing if it is a direct or
+// transitive member of at least one of the groups, given by object ID.
+// Without groups, which is the default, existence alone is checked.
+func (g *GraphClient) SetRequiredGroups(ids []string) {
+	g.groups = ids
+}
+
+// memberGroupsRequest is the body of a checkMemberGroups request
+type memberGroupsRequest struct {
+	GroupIDs []string `json:"groupIds"`
+}
+
+// memberGroupsResponse is the body of a checkMemberGroups response
+type memberGroupsResponse struct {
+	Value []string `json:"value"` // Required groups the user is a member of
+}
+
+// isMember checks whether a user is a member of any required group, using
+// checkMemberGroups. A missing user is reported as not a member.
+func (g *GraphClient) isMember(ctx context.Context, email string) (bool, int, error) {
+	payload, err := json.Marshal(memberGroupsRequest{GroupIDs: g.groups})
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to encode group check: %w", err)
+	}
+	resp, err := g.do(ctx, http.MethodPost, fmt.Sprintf(memberGroupsURLFormat, url.PathEscape(email)), payload)
+	if err != nil {
+		return false, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, resp.StatusCode, nil
+	case http.StatusForbidden:
+		return false, resp.StatusCode, &PermissionError{StatusCode: resp.StatusCode}
+	default:
+		return false, resp.StatusCode, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var groups memberGroupsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&groups); err != nil {
+		return false, resp.StatusCode, fmt.Errorf("failed to decode group check: %w", err)
+	}
+	return len(groups.Value) > 0, resp.StatusCode, nil
+}
@@ -0,0 +1,151 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"github.com/stretchr/testify/require"
+)
+
+const testGroupID = "3f2504e0-4f89-11d3-9a0c-0305e82c3301"
+
+// TestParseGroupIDs validates parsing of required group lists
+func TestParseGroupIDs(t *testing.T) {
+	ids, err := ParseGroupIDs("")
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	ids, err = ParseGroupIDs(" " + strings.ToUpper(testGroupID) + ",," + testGroupID)
+	require.NoError(t, err)
+	require.Equal(t, []string{testGroupID, testGroupID}, ids)
+
+	_, err = ParseGroupIDs(testGroupID + ",admins")
+	require.ErrorContains(t, err, "admins")
+
+	_, err = ParseGroupIDs(strings.Repeat(testGroupID+",", MaxRequiredGroups+1))
+	require.ErrorContains(t, err, "at most")
+}
+
+// TestGroupMembership validates owner checks by group membership against mock Graph API
+func TestGroupMembership(t *testing.T) {
+	skipIfIntegrationDisabled(t)
+
+	// member answers checkMemberGroups, nil for users missing from the directory
+	member := func(email string) (bool, bool) {
+		switch {
+		case strings.HasPrefix(email, "member"):
+			return true, true
+		case strings.HasPrefix(email, "outsider"):
+			return false, true
+		}
+		return false, false
+	}
+	writeGroups := func(w http.ResponseWriter, isMember bool) {
+		groups := memberGroupsResponse{Value: []string{}}
+		if isMember {
+			groups.Value = append(groups.Value, testGroupID)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(groups))
+	}
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1.0/$batch":
+			var body struct {
+				Requests []batchRequest `json:"requests"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			var result struct {
+				Responses []batchResponse `json:"responses"`
+			}
+			for _, req := range body.Requests {
+				require.Equal(t, http.MethodPost, req.Method)
+				email := strings.TrimSuffix(strings.TrimPrefix(req.URL, "/users/"), "/checkMemberGroups")
+				isMember, exists := member(email)
+				if !exists {
+					result.Responses = append(result.Responses, batchResponse{ID: req.ID, Status: http.StatusNotFound})
+					continue
+				}
+				payload := `{"value":[]}`
+				if isMember {
+					payload = fmt.Sprintf(`{"value":[%q]}`, testGroupID)
+				}
+				result.Responses = append(result.Responses, batchResponse{ID: req.ID, Status: http.StatusOK, Body: json.RawMessage(payload)})
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(result))
+		case strings.HasSuffix(r.URL.Path, "/checkMemberGroups"):
+			require.Equal(t, http.MethodPost, r.Method)
+			var body memberGroupsRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, []string{testGroupID}, body.GroupIDs)
+			email := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1.0/users/"), "/checkMemberGroups")
+			isMember, exists := member(email)
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeGroups(w, isMember)
+		case strings.HasPrefix(r.URL.Path, "/v1.0/users/"):
+			if _, exists := member(strings.TrimPrefix(r.URL.Path, "/v1.0/users/")); !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, `{"accountEnabled":true}`)
+		case r.URL.Path == "/v1.0/directory/deletedItems/microsoft.graph.user":
+			fmt.Fprint(w, `{"value":[]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	origClient := http.DefaultClient
+	http.DefaultClient = testServer.Client()
+	defer func() { http.DefaultClient = origClient }()
+
+	origUserURL, origGroupsURL, origDeletedURL, origBatchURL := userURLFormat, memberGroupsURLFormat, deletedUsersURL, batchURL
+	userURLFormat = testServer.URL + "/v1.0/users/%s"
+	memberGroupsURLFormat = testServer.URL + "/v1.0/users/%s/checkMemberGroups"
+	deletedUsersURL = testServer.URL + "/v1.0/directory/deletedItems/microsoft.graph.user"
+	batchURL = testServer.URL + "/v1.0/$batch"
+	defer func() {
+		userURLFormat, memberGroupsURLFormat, deletedUsersURL, batchURL = origUserURL, origGroupsURL, origDeletedURL, origBatchURL
+	}()
+
+	client := &GraphClient{cred: &mockTokenCredential{token: "test-token"}}
+	client.SetRequiredGroups([]string{testGroupID})
+
+	for email, want := range map[string]bool{
+		"member@example.com":   true,
+		"outsider@example.com": false,
+		"missing@example.com":  false,
+	} {
+		exists, err := client.UserExists(context.Background(), email)
+		require.NoError(t, err)
+		require.Equal(t, want, exists, email)
+	}
+
+	for email, want := range map[string]identity.UserState{
+		"member@example.com":   identity.UserActive,
+		"outsider@example.com": identity.UserNotMember,
+		"missing@example.com":  identity.UserNotFound,
+	} {
+		state, err := client.UserState(context.Background(), email)
+		require.NoError(t, err)
+		require.Equal(t, want, state, email)
+	}
+
+	found, err := client.UsersExist(context.Background(), []string{"member@example.com", "outsider@example.com", "missing@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{
+		"member@example.com":   true,
+		"outsider@example.com": false,
+		"missing@example.com":  false,
+	}, found)
+}
@@ -0,0 +1,94 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// sendMailURLFormat defines the Microsoft Graph endpoint sending mail as a
+// user. Overridden in tests to point at a fake Graph server.
+var sendMailURLFormat = publicGraphEndpoint + "/v1.0/users/%s/sendMail"
+
+// MailPermissionError reports that Microsoft Graph refused to send mail
+// because the application lacks the Mail.Send permission for the sender's
+// mailbox (HTTP 403).
+type MailPermissionError struct {
+	Sender string // Mailbox the application tried to send as
+}
+
+// Error describes the missing permission and how to grant it.
+func (e *MailPermissionError) Error() string {
+	return fmt.Sprintf("Microsoft Graph denied sending mail as %s: the application registration needs the "+
+		"Mail.Send application permission with admin consent, optionally restricted to the sender's mailbox", e.Sender)
+}
+
+// mailAddress is a recipient of a Graph message
+type mailAddress struct {
+	EmailAddress struct {
+		Address string `json:"address"`
+	} `json:"emailAddress"`
+}
+
+// sendMailRequest is the body of a sendMail request
+type sendMailRequest struct {
+	Message struct {
+		Subject string `json:"subject"`
+		Body    struct {
+			ContentType string `json:"contentType"`
+			Content     string `json:"content"`
+		} `json:"body"`
+		ToRecipients []mailAddress `json:"toRecipients"`
+		CcRecipients []mailAddress `json:"ccRecipients,omitempty"`
+	} `json:"message"`
+	SaveToSentItems bool `json:"saveToSentItems"`
+}
+
+// addresses converts email addresses to Graph recipients
+func addresses(emails []string) []mailAddress {
+	var recipients []mailAddress
+	for _, email := range emails {
+		var r mailAddress
+		r.EmailAddress.Address = email
+		recipients = append(recipients, r)
+	}
+	return recipients
+}
+
+// SendMail sends a plain-text message from the mailbox of from to the to
+// and cc recipients through Microsoft Graph. The message is not kept in the
+// sender's Sent Items.
+func (g *GraphClient) SendMail(ctx context.Context, from string, to, cc []string, subject, body string) error {
+	var req sendMailRequest
+	req.Message.Subject = subject
+	req.Message.Body.ContentType = "Text"
+	req.Message.Body.Content = body
+	req.Message.ToRecipients = addresses(to)
+	req.Message.CcRecipients = addresses(cc)
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	resp, err := g.do(ctx, http.MethodPost, fmt.Sprintf(sendMailURLFormat, url.PathEscape(from)), payload)
+	if err != nil {
+		return err
+	}
+	defer discard(resp)
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK:
+		return nil
+	case http.StatusForbidden:
+		return &MailPermissionError{Sender: from}
+	default:
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+}
+
+// SendMail sends mail with the default client, see GraphClient.SendMail.
+// The sender's mailbox is in the default tenant.
+func (p *ClientPool) SendMail(ctx context.Context, from string, to, cc []string, subject, body string) error {
+	return p.fallback.SendMail(ctx, from, to, cc, subject, body)
+}
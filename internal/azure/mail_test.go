@@ -0,0 +1,48 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSendMail validates sendMail requests against a mock Graph API
+func TestSendMail(t *testing.T) {
+	var received sendMailRequest
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		if r.URL.Path != "/v1.0/users/auditor@example.com/sendMail" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer testServer.Close()
+
+	origURL := sendMailURLFormat
+	sendMailURLFormat = testServer.URL + "/v1.0/users/%s/sendMail"
+	defer func() { sendMailURLFormat = origURL }()
+
+	client := &GraphClient{cred: &mockTokenCredential{token: "test-token"}, httpClient: testServer.Client()}
+	err := client.SendMail(context.Background(), "auditor@example.com",
+		[]string{"alice@example.com"}, []string{"lead@example.com"}, "Namespace marked", "Claim it")
+	require.NoError(t, err)
+	require.Equal(t, "Namespace marked", received.Message.Subject)
+	require.Equal(t, "Text", received.Message.Body.ContentType)
+	require.Equal(t, "Claim it", received.Message.Body.Content)
+	require.Equal(t, addresses([]string{"alice@example.com"}), received.Message.ToRecipients)
+	require.Equal(t, addresses([]string{"lead@example.com"}), received.Message.CcRecipients)
+	require.False(t, received.SaveToSentItems)
+
+	pool := NewClientPool(client)
+	err = pool.SendMail(context.Background(), "other@example.com", []string{"alice@example.com"}, nil, "s", "b")
+	var permErr *MailPermissionError
+	require.ErrorAs(t, err, &permErr)
+	require.Equal(t, "other@example.com", permErr.Sender)
+}
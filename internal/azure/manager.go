@@ -0,0 +1,53 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// managerURLFormat defines the Microsoft Graph endpoint returning a user's
+// manager. Overridden in tests to point at a fake Graph server.
+var managerURLFormat = publicGraphEndpoint + "/v1.0/users/%s/manager"
+
+// UserManager returns the email address of the user's manager, falling back
+// to the manager's user principal name. It is empty if the user has no
+// manager or no longer exists: Graph does not keep the manager of deleted
+// users. Needs the same User.Read.All permission as user lookups.
+func (g *GraphClient) UserManager(ctx context.Context, email string) (string, error) {
+	resp, err := g.get(ctx, fmt.Sprintf(managerURLFormat, url.PathEscape(email))+"?$select=mail,userPrincipalName")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", nil
+	case http.StatusForbidden:
+		return "", &PermissionError{StatusCode: resp.StatusCode}
+	default:
+		return "", &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var manager struct {
+		Mail              string `json:"mail"`
+		UserPrincipalName string `json:"userPrincipalName"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&manager); err != nil {
+		return "", fmt.Errorf("failed to decode manager: %w", err)
+	}
+	if manager.Mail != "" {
+		return manager.Mail, nil
+	}
+	return manager.UserPrincipalName, nil
+}
+
+// UserManager returns the manager of the user from the tenant of its domain,
+// see GraphClient.UserManager.
+func (p *ClientPool) UserManager(ctx context.Context, email string) (string, error) {
+	return p.client(email).UserManager(ctx, email)
+}
@@ -0,0 +1,49 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserManager validates manager lookups against a mock Graph API
+func TestUserManager(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "mail,userPrincipalName", r.URL.Query().Get("$select"))
+		switch strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1.0/users/"), "/manager") {
+		case "alice@example.com":
+			fmt.Fprint(w, `{"mail":"boss@example.com","userPrincipalName":"boss_upn@example.com"}`)
+		case "bob@example.com":
+			fmt.Fprint(w, `{"mail":null,"userPrincipalName":"boss_upn@example.com"}`)
+		case "denied@example.com":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	origURL := managerURLFormat
+	managerURLFormat = testServer.URL + "/v1.0/users/%s/manager"
+	defer func() { managerURLFormat = origURL }()
+
+	client := &GraphClient{cred: &mockTokenCredential{token: "test-token"}, httpClient: testServer.Client()}
+	for email, want := range map[string]string{
+		"alice@example.com": "boss@example.com",
+		"bob@example.com":   "boss_upn@example.com",
+		"gone@example.com":  "",
+	} {
+		manager, err := client.UserManager(context.Background(), email)
+		require.NoError(t, err)
+		require.Equal(t, want, manager, email)
+	}
+
+	var permErr *PermissionError
+	_, err := NewClientPool(client).UserManager(context.Background(), "denied@example.com")
+	require.ErrorAs(t, err, &permErr)
+}
@@ -0,0 +1,113 @@
+package azure
+
+import (
+	"context"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+)
+
+// TenantRoute sends the lookups of owners in Domains to the client of their
+// Azure tenant.
+type TenantRoute struct {
+	Name    string       // Name of the tenant, used in settings and logs
+	Domains []string     // Owner domains whose users live in the tenant
+	Client  *GraphClient // Client authenticated against the tenant
+}
+
+// ClientPool looks users up in the Azure tenant their email domain belongs
+// to, for organizations whose allowed domains are spread over several
+// tenants. Owners of domains no route lists are looked up with the default
+// client. It implements the same optional interfaces as GraphClient.
+type ClientPool struct {
+	routes   []TenantRoute
+	fallback *GraphClient
+}
+
+// NewClientPool creates a pool routing owners to the first route listing
+// their domain, and all others to fallback.
+func NewClientPool(fallback *GraphClient, routes ...TenantRoute) *ClientPool {
+	return &ClientPool{routes: routes, fallback: fallback}
+}
+
+// client returns the client of the tenant email belongs to
+func (p *ClientPool) client(email string) *GraphClient {
+	client, _ := p.route(email)
+	return client
+}
+
+// route returns the client of the tenant email belongs to and the name of
+// its route, empty for the default client
+func (p *ClientPool) route(email string) (*GraphClient, string) {
+	for _, route := range p.routes {
+		if auditor.IsAllowedOwner(email, route.Domains) {
+			return route.Client, route.Name
+		}
+	}
+	return p.fallback, ""
+}
+
+// UserExists checks the user in the tenant of its domain, see
+// GraphClient.UserExists.
+func (p *ClientPool) UserExists(ctx context.Context, email string) (bool, error) {
+	return p.client(email).UserExists(ctx, email)
+}
+
+// UserExistsWithStatus checks the user in the tenant of its domain, see
+// GraphClient.UserExistsWithStatus.
+func (p *ClientPool) UserExistsWithStatus(ctx context.Context, email string) (bool, int, error) {
+	return p.client(email).UserExistsWithStatus(ctx, email)
+}
+
+// UserState checks the user in the tenant of its domain, see
+// GraphClient.UserState.
+func (p *ClientPool) UserState(ctx context.Context, email string) (identity.UserState, error) {
+	return p.client(email).UserState(ctx, email)
+}
+
+// UserStateWithProvenance checks the user in the tenant of its domain, see
+// GraphClient.UserStateWithProvenance. The provider of routed lookups is
+// qualified with the route name, e.g. "azure/partner".
+func (p *ClientPool) UserStateWithProvenance(ctx context.Context, email string) (identity.UserState, identity.Provenance, error) {
+	client, name := p.route(email)
+	state, source, err := client.UserStateWithProvenance(ctx, email)
+	if name != "" {
+		source.Provider += "/" + name
+	}
+	return state, source, err
+}
+
+// UserStateChangedAt checks the user in the tenant of its domain, see
+// GraphClient.UserStateChangedAt.
+func (p *ClientPool) UserStateChangedAt(ctx context.Context, email string) (time.Time, bool, error) {
+	return p.client(email).UserStateChangedAt(ctx, email)
+}
+
+// UsersExist splits emails by tenant and batches the lookups of each tenant,
+// see GraphClient.UsersExist. Lookups that succeeded are kept when another
+// tenant fails, and the first failure is returned as the error.
+func (p *ClientPool) UsersExist(ctx context.Context, emails []string) (map[string]bool, error) {
+	var clients []*GraphClient
+	byClient := make(map[*GraphClient][]string)
+	for _, email := range emails {
+		client := p.client(email)
+		if _, ok := byClient[client]; !ok {
+			clients = append(clients, client)
+		}
+		byClient[client] = append(byClient[client], email)
+	}
+
+	found := make(map[string]bool, len(emails))
+	var firstErr error
+	for _, client := range clients {
+		results, err := client.UsersExist(ctx, byClient[client])
+		for email, exists := range results {
+			found[email] = exists
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return found, firstErr
+}
@@ -0,0 +1,100 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClientPoolRouting validates that owners are looked up in the tenant of
+// their domain
+func TestClientPoolRouting(t *testing.T) {
+	var batches []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		var body struct {
+			Requests []batchRequest `json:"requests"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		batches = append(batches, tenant)
+
+		var result struct {
+			Responses []batchResponse `json:"responses"`
+		}
+		for _, req := range body.Requests {
+			// Each tenant only knows the users of its own domain
+			status := http.StatusNotFound
+			if strings.Contains(req.URL, "@"+tenant) {
+				status = http.StatusOK
+			}
+			result.Responses = append(result.Responses, batchResponse{ID: req.ID, Status: status})
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(result))
+	}))
+	defer testServer.Close()
+
+	origURL := batchURL
+	batchURL = testServer.URL + "/v1.0/$batch"
+	defer func() { batchURL = origURL }()
+
+	client := func(tenant string) *GraphClient {
+		return &GraphClient{cred: &mockTokenCredential{token: tenant}, httpClient: testServer.Client()}
+	}
+	statcan, partner := client("statcan.gc.ca"), client("partner.org")
+	pool := NewClientPool(statcan, TenantRoute{Name: "PARTNER", Domains: []string{"partner.org", "partner.ca"}, Client: partner})
+
+	require.Same(t, partner, pool.client("user@Partner.org"))
+	require.Same(t, partner, pool.client("user@partner.ca"))
+	require.Same(t, statcan, pool.client("user@statcan.gc.ca"))
+	require.Same(t, statcan, pool.client("malformed"))
+
+	found, err := pool.UsersExist(context.Background(), []string{
+		"a@statcan.gc.ca", "b@partner.org", "c@statcan.gc.ca", "d@partner.ca",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"statcan.gc.ca", "partner.org"}, batches, "Lookups should be batched once per tenant")
+	require.Equal(t, map[string]bool{
+		"a@statcan.gc.ca": true,
+		"b@partner.org":   true,
+		"c@statcan.gc.ca": true,
+		"d@partner.ca":    false, // Looked up in the partner tenant, which only knows partner.org
+	}, found)
+}
+
+// TestClientPoolProvenance validates that routed lookups name their tenant
+func TestClientPoolProvenance(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1.0/users/"):
+			w.Write([]byte(`{"accountEnabled":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	origURL := userURLFormat
+	userURLFormat = testServer.URL + "/v1.0/users/%s"
+	defer func() { userURLFormat = origURL }()
+
+	client := func() *GraphClient {
+		return &GraphClient{cred: &mockTokenCredential{token: "token"}, httpClient: testServer.Client()}
+	}
+	pool := NewClientPool(client(), TenantRoute{Name: "PARTNER", Domains: []string{"partner.org"}, Client: client()})
+
+	for email, want := range map[string]identity.Provenance{
+		"user@statcan.gc.ca": {Provider: "azure", Endpoint: "https://graph.microsoft.com", Method: identity.LookupUPN},
+		"user@partner.org":   {Provider: "azure/PARTNER", Endpoint: "https://graph.microsoft.com", Method: identity.LookupUPN},
+	} {
+		state, source, err := pool.UserStateWithProvenance(context.Background(), email)
+		require.NoError(t, err)
+		require.Equal(t, identity.UserActive, state, email)
+		require.Equal(t, want, source, email)
+	}
+}
@@ -0,0 +1,209 @@
+package azure
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+)
+
+// ProviderName is the name the Microsoft Graph provider is registered under.
+const ProviderName = "azure"
+
+// tenantNamePattern matches tenant names, which prefix the tenant settings
+var tenantNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+func init() {
+	identity.Register(ProviderName, newProvider)
+}
+
+// newProvider creates a GraphClient from the AZURE_TENANT_ID,
+// AZURE_CLIENT_ID and AZURE_CLIENT_SECRET settings. AZURE_MAX_RETRIES and
+// AZURE_RETRY_TIMEOUT optionally override DefaultRetryPolicy, and
+// AZURE_REQUIRED_GROUPS switches to validating owners by group membership.
+// AZURE_ENVIRONMENT selects a national cloud (see Environments), and
+// AZURE_GRAPH_ENDPOINT optionally overrides its Graph endpoint.
+// AZURE_PROXY_URL, AZURE_CA_FILE, AZURE_CONNECT_TIMEOUT and
+// AZURE_REQUEST_TIMEOUT customize the HTTP client, see HTTPOptions.
+//
+// AZURE_DOMAIN_TENANTS optionally routes owner domains to other tenants as
+// a comma-separated list of "domain=name" entries, e.g.
+// "partner.org=partner". Each named tenant reads its credentials from
+// AZURE_<NAME>_TENANT_ID, AZURE_<NAME>_CLIENT_ID and
+// AZURE_<NAME>_CLIENT_SECRET, and the provider is then a ClientPool. Tenants
+// are in the same cloud unless AZURE_<NAME>_ENVIRONMENT or
+// AZURE_<NAME>_GRAPH_ENDPOINT say otherwise.
+func newProvider(getenv func(string) string) (identity.Provider, error) {
+	var errs []error
+	credentials := func(prefix string) map[string]string {
+		settings := map[string]string{}
+		for _, name := range []string{"TENANT_ID", "CLIENT_ID", "CLIENT_SECRET"} {
+			key := prefix + name
+			settings[name] = getenv(key)
+			if settings[name] == "" {
+				errs = append(errs, fmt.Errorf("%s: required for Microsoft Graph authentication", key))
+			}
+		}
+		return settings
+	}
+	settings := credentials("AZURE_")
+	environment := func(prefix string, fallback Environment) Environment {
+		env := fallback
+		if name := getenv(prefix + "ENVIRONMENT"); name != "" {
+			var err error
+			if env, err = ParseEnvironment(name); err != nil {
+				errs = append(errs, fmt.Errorf("%sENVIRONMENT: %w", prefix, err))
+				return fallback
+			}
+		}
+		if endpoint := getenv(prefix + "GRAPH_ENDPOINT"); endpoint != "" {
+			withEndpoint, err := env.WithGraphEndpoint(endpoint)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%sGRAPH_ENDPOINT: %w", prefix, err))
+				return env
+			}
+			env = withEndpoint
+		}
+		return env
+	}
+	env := environment("AZURE_", PublicEnvironment)
+
+	retry := DefaultRetryPolicy()
+	if value := getenv("AZURE_MAX_RETRIES"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			errs = append(errs, fmt.Errorf("AZURE_MAX_RETRIES: expected a non-negative number, got %q", value))
+		}
+		retry.MaxRetries = n
+	}
+	if value := getenv("AZURE_RETRY_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			errs = append(errs, fmt.Errorf("AZURE_RETRY_TIMEOUT: expected a non-negative duration, got %q", value))
+		}
+		retry.Timeout = timeout
+	}
+
+	var httpOpts HTTPOptions
+	var err error
+	if value := getenv("AZURE_PROXY_URL"); value != "" {
+		if httpOpts.ProxyURL, err = ParseProxyURL(value); err != nil {
+			errs = append(errs, fmt.Errorf("AZURE_PROXY_URL: %w", err))
+		}
+	}
+	if path := getenv("AZURE_CA_FILE"); path != "" {
+		if httpOpts.RootCAs, err = LoadCABundle(path); err != nil {
+			errs = append(errs, fmt.Errorf("AZURE_CA_FILE: %w", err))
+		}
+	}
+	for _, setting := range []struct {
+		key     string
+		timeout *time.Duration
+	}{
+		{"AZURE_CONNECT_TIMEOUT", &httpOpts.ConnectTimeout},
+		{"AZURE_REQUEST_TIMEOUT", &httpOpts.RequestTimeout},
+	} {
+		if value := getenv(setting.key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("%s: expected a positive duration, got %q", setting.key, value))
+			}
+			*setting.timeout = d
+		}
+	}
+
+	groups, err := ParseGroupIDs(getenv("AZURE_REQUIRED_GROUPS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("AZURE_REQUIRED_GROUPS: %w", err))
+	}
+
+	domainTenants, err := parseDomainTenants(getenv("AZURE_DOMAIN_TENANTS"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("AZURE_DOMAIN_TENANTS: %w", err))
+	}
+	tenantSettings := make(map[string]map[string]string)
+	tenantEnvs := make(map[string]Environment)
+	for _, route := range domainTenants {
+		prefix := "AZURE_" + route.Name + "_"
+		tenantSettings[route.Name] = credentials(prefix)
+		tenantEnvs[route.Name] = environment(prefix, env)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	httpClient := NewHTTPClient(httpOpts) // Shared by all tenants
+	newClient := func(prefix string, settings map[string]string, env Environment) (*GraphClient, error) {
+		client, err := newGraphClient(settings["TENANT_ID"], settings["CLIENT_ID"], settings["CLIENT_SECRET"], env, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("%sTENANT_ID: invalid Azure credentials: %w", prefix, err)
+		}
+		client.SetRetryPolicy(retry)
+		client.SetRequiredGroups(groups)
+		return client, nil
+	}
+	client, err := newClient("AZURE_", settings, env)
+	if err != nil {
+		return nil, err
+	}
+	if len(domainTenants) == 0 {
+		return client, nil
+	}
+
+	for i, route := range domainTenants {
+		if domainTenants[i].Client, err = newClient("AZURE_"+route.Name+"_", tenantSettings[route.Name], tenantEnvs[route.Name]); err != nil {
+			return nil, err
+		}
+	}
+	return NewClientPool(client, domainTenants...), nil
+}
+
+// parseDomainTenants parses AZURE_DOMAIN_TENANTS into one route without a
+// client per tenant, listing its domains in the order they were given.
+// Tenant names are upper-cased.
+func parseDomainTenants(value string) ([]TenantRoute, error) {
+	var routes []TenantRoute
+	var problems []error
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		domain, name, found := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !found || !tenantNamePattern.MatchString(name) {
+			problems = append(problems, fmt.Errorf(`invalid entry %q, expected "domain=name" with a name of letters and digits`, entry))
+			continue
+		}
+		domains, err := auditor.ParseAllowedDomains(domain)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("entry %q: %w", entry, err))
+			continue
+		}
+		if seen[domains[0]] {
+			problems = append(problems, fmt.Errorf("duplicate domain %q", domains[0]))
+			continue
+		}
+		seen[domains[0]] = true
+
+		name = strings.ToUpper(name)
+		if i, ok := index[name]; ok {
+			routes[i].Domains = append(routes[i].Domains, domains[0])
+			continue
+		}
+		index[name] = len(routes)
+		routes = append(routes, TenantRoute{Name: name, Domains: domains})
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	return routes, nil
+}
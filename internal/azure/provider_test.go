@@ -0,0 +1,179 @@
+package azure
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProviderRegistration validates creating the Graph client through the identity registry
+func TestProviderRegistration(t *testing.T) {
+	env := map[string]string{
+		"AZURE_TENANT_ID":     "test-tenant",
+		"AZURE_CLIENT_ID":     "test-client",
+		"AZURE_CLIENT_SECRET": "test-secret",
+	}
+	provider, err := identity.New(ProviderName, func(key string) string { return env[key] })
+	require.NoError(t, err)
+	require.IsType(t, &GraphClient{}, provider)
+
+	delete(env, "AZURE_TENANT_ID")
+	delete(env, "AZURE_CLIENT_SECRET")
+	_, err = identity.New(ProviderName, func(key string) string { return env[key] })
+	require.ErrorContains(t, err, "AZURE_TENANT_ID: required")
+	require.ErrorContains(t, err, "AZURE_CLIENT_SECRET: required")
+	require.NotContains(t, err.Error(), "AZURE_CLIENT_ID")
+}
+
+// TestProviderRetrySettings validates the optional retry settings
+func TestProviderRetrySettings(t *testing.T) {
+	env := map[string]string{
+		"AZURE_TENANT_ID":     "test-tenant",
+		"AZURE_CLIENT_ID":     "test-client",
+		"AZURE_CLIENT_SECRET": "test-secret",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	provider, err := identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRetryPolicy(), provider.(*GraphClient).retry)
+
+	env["AZURE_MAX_RETRIES"] = "0"
+	env["AZURE_RETRY_TIMEOUT"] = "30s"
+	provider, err = identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+	require.Equal(t, RetryPolicy{MaxRetries: 0, Timeout: 30 * time.Second}, provider.(*GraphClient).retry)
+
+	env["AZURE_MAX_RETRIES"] = "-1"
+	env["AZURE_RETRY_TIMEOUT"] = "soon"
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "AZURE_MAX_RETRIES")
+	require.ErrorContains(t, err, "AZURE_RETRY_TIMEOUT")
+}
+
+// TestProviderRequiredGroups validates the optional group membership setting
+func TestProviderRequiredGroups(t *testing.T) {
+	env := map[string]string{
+		"AZURE_TENANT_ID":       "test-tenant",
+		"AZURE_CLIENT_ID":       "test-client",
+		"AZURE_CLIENT_SECRET":   "test-secret",
+		"AZURE_REQUIRED_GROUPS": "3F2504E0-4F89-11D3-9A0C-0305E82C3301, ",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	provider, err := identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+	require.Equal(t, []string{"3f2504e0-4f89-11d3-9a0c-0305e82c3301"}, provider.(*GraphClient).groups)
+
+	env["AZURE_REQUIRED_GROUPS"] = "data-scientists"
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "AZURE_REQUIRED_GROUPS")
+}
+
+// TestProviderDomainTenants validates routing owner domains to other tenants
+func TestProviderDomainTenants(t *testing.T) {
+	env := map[string]string{
+		"AZURE_TENANT_ID":             "test-tenant",
+		"AZURE_CLIENT_ID":             "test-client",
+		"AZURE_CLIENT_SECRET":         "test-secret",
+		"AZURE_DOMAIN_TENANTS":        "partner.org=partner, Partner.CA=Partner,cloud.statcan.ca=cloud",
+		"AZURE_PARTNER_TENANT_ID":     "partner-tenant",
+		"AZURE_PARTNER_CLIENT_ID":     "partner-client",
+		"AZURE_PARTNER_CLIENT_SECRET": "partner-secret",
+		"AZURE_CLOUD_TENANT_ID":       "cloud-tenant",
+		"AZURE_CLOUD_CLIENT_ID":       "cloud-client",
+		"AZURE_CLOUD_CLIENT_SECRET":   "cloud-secret",
+		"AZURE_REQUIRED_GROUPS":       "3f2504e0-4f89-11d3-9a0c-0305e82c3301",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	provider, err := identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+	pool, ok := provider.(*ClientPool)
+	require.True(t, ok, "Expected a ClientPool, got %T", provider)
+	require.Len(t, pool.routes, 2)
+	require.Equal(t, "PARTNER", pool.routes[0].Name)
+	require.Equal(t, []string{"partner.org", "partner.ca"}, pool.routes[0].Domains)
+	require.Equal(t, []string{"cloud.statcan.ca"}, pool.routes[1].Domains)
+	require.Equal(t, pool.fallback.groups, pool.routes[1].Client.groups, "Tenants should share the required groups")
+
+	delete(env, "AZURE_CLOUD_CLIENT_SECRET")
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "AZURE_CLOUD_CLIENT_SECRET: required")
+
+	for value, want := range map[string]string{
+		"partner.org":                 "invalid entry",
+		"partner.org=part-ner":        "invalid entry",
+		"user@partner.org=partner":    "invalid allowed domains",
+		"partner.org=a,Partner.org=b": "duplicate domain",
+	} {
+		env["AZURE_DOMAIN_TENANTS"] = value
+		_, err = identity.New(ProviderName, getenv)
+		require.ErrorContains(t, err, want, value)
+	}
+}
+
+// TestProviderEnvironment validates the national cloud settings
+func TestProviderEnvironment(t *testing.T) {
+	env := map[string]string{
+		"AZURE_TENANT_ID":             "test-tenant",
+		"AZURE_CLIENT_ID":             "test-client",
+		"AZURE_CLIENT_SECRET":         "test-secret",
+		"AZURE_ENVIRONMENT":           "usgov",
+		"AZURE_DOMAIN_TENANTS":        "partner.cn=partner,other.us=other",
+		"AZURE_PARTNER_TENANT_ID":     "partner-tenant",
+		"AZURE_PARTNER_CLIENT_ID":     "partner-client",
+		"AZURE_PARTNER_CLIENT_SECRET": "partner-secret",
+		"AZURE_PARTNER_ENVIRONMENT":   "china",
+		"AZURE_OTHER_TENANT_ID":       "other-tenant",
+		"AZURE_OTHER_CLIENT_ID":       "other-client",
+		"AZURE_OTHER_CLIENT_SECRET":   "other-secret",
+		"AZURE_OTHER_GRAPH_ENDPOINT":  "https://graph.example.us",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	provider, err := identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+	pool := provider.(*ClientPool)
+	require.Equal(t, "https://graph.microsoft.us", pool.fallback.graphEndpoint)
+	require.Equal(t, "https://microsoftgraph.chinacloudapi.cn", pool.routes[0].Client.graphEndpoint)
+	require.Equal(t, "https://graph.example.us", pool.routes[1].Client.graphEndpoint)
+
+	env["AZURE_ENVIRONMENT"] = "moon"
+	env["AZURE_PARTNER_GRAPH_ENDPOINT"] = "http://insecure"
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "AZURE_ENVIRONMENT: unknown environment")
+	require.ErrorContains(t, err, "AZURE_PARTNER_GRAPH_ENDPOINT")
+}
+
+// TestProviderHTTPSettings validates the proxy, CA bundle and timeout settings
+func TestProviderHTTPSettings(t *testing.T) {
+	env := map[string]string{
+		"AZURE_TENANT_ID":       "test-tenant",
+		"AZURE_CLIENT_ID":       "test-client",
+		"AZURE_CLIENT_SECRET":   "test-secret",
+		"AZURE_PROXY_URL":       "http://proxy.example.com:3128",
+		"AZURE_REQUEST_TIMEOUT": "20s",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	provider, err := identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+	httpClient := provider.(*GraphClient).httpClient
+	require.Equal(t, 20*time.Second, httpClient.Timeout)
+	proxy, err := httpClient.Transport.(*http.Transport).Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "graph.microsoft.com"}})
+	require.NoError(t, err)
+	require.Equal(t, "proxy.example.com:3128", proxy.Host)
+
+	env["AZURE_PROXY_URL"] = "proxy.example.com"
+	env["AZURE_CA_FILE"] = "/nonexistent/ca.pem"
+	env["AZURE_CONNECT_TIMEOUT"] = "0s"
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "AZURE_PROXY_URL")
+	require.ErrorContains(t, err, "AZURE_CA_FILE")
+	require.ErrorContains(t, err, "AZURE_CONNECT_TIMEOUT")
+}
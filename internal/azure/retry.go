@@ -0,0 +1,84 @@
+package azure
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Defaults of the retry policy applied to Microsoft Graph requests.
+const (
+	DefaultMaxRetries   = 3               // Retries after the first attempt
+	DefaultRetryTimeout = 2 * time.Minute // Longest a request is retried for
+)
+
+// Bounds of the exponential backoff used when Graph sends no Retry-After.
+const (
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy controls how throttled (429) and failed (5xx) Microsoft Graph
+// requests are retried. The zero value never retries.
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt, 0 to never retry
+	Timeout    time.Duration // Longest a request is retried for, waits included; 0 for no limit
+}
+
+// DefaultRetryPolicy returns the retry policy of clients created with
+// NewGraphClient.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxRetries: DefaultMaxRetries, Timeout: DefaultRetryTimeout}
+}
+
+// SetRetryPolicy replaces the retry policy of the client.
+func (g *GraphClient) SetRetryPolicy(policy RetryPolicy) {
+	g.retry = policy
+}
+
+// retryable reports whether a response status is worth retrying
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryDelay returns how long to wait before retrying after resp, the
+// response to the given zero-based attempt. Graph's Retry-After is honored;
+// without it the delay grows exponentially with full jitter, so that
+// concurrent lookups do not retry in lockstep.
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		return delay
+	}
+	backoff := retryMaxDelay
+	if attempt < 16 {
+		backoff = min(retryBaseDelay<<attempt, retryMaxDelay)
+	}
+	return time.Duration(rand.Int63n(int64(backoff))) + 1
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or
+// as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(at.Sub(now), 0), true
+}
+
+// discard drains and closes a response body so that its connection can be reused
+func discard(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
@@ -0,0 +1,101 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseRetryAfter validates both Retry-After formats
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Duration{
+		"7":                             7 * time.Second,
+		"0":                             0,
+		"Fri, 01 Mar 2024 12:00:30 GMT": 30 * time.Second,
+		"Fri, 01 Mar 2024 11:00:00 GMT": 0,
+	} {
+		got, ok := parseRetryAfter(value, now)
+		require.True(t, ok, value)
+		require.Equal(t, want, got, value)
+	}
+	for _, value := range []string{"", "-1", "soon"} {
+		_, ok := parseRetryAfter(value, now)
+		require.False(t, ok, value)
+	}
+}
+
+// TestRetryDelayBackoff validates jittered exponential backoff without Retry-After
+func TestRetryDelayBackoff(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	for attempt := 0; attempt < 40; attempt++ {
+		delay := retryDelay(resp, attempt)
+		require.Positive(t, delay)
+		require.LessOrEqual(t, delay, min(retryBaseDelay<<min(attempt, 16), retryMaxDelay))
+	}
+}
+
+// TestRetryThrottled validates that throttled and failed lookups are retried
+// until they succeed or the policy is exhausted
+func TestRetryThrottled(t *testing.T) {
+	var attempts int
+	failures := 2
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch {
+		case attempts > failures:
+			w.WriteHeader(http.StatusOK)
+		case attempts%2 == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer testServer.Close()
+
+	origUserURL := userURLFormat
+	userURLFormat = testServer.URL + "/v1.0/users/%s"
+	defer func() { userURLFormat = origUserURL }()
+
+	client := &GraphClient{cred: &mockTokenCredential{token: "test-token"}, httpClient: testServer.Client()}
+	client.SetRetryPolicy(RetryPolicy{MaxRetries: 3, Timeout: time.Minute})
+	exists, err := client.UserExists(context.Background(), "user@example.com")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, 3, attempts)
+
+	attempts, failures = 0, 10
+	_, err = client.UserExists(context.Background(), "user@example.com")
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr, "Last failed response should be reported once retries are exhausted")
+	require.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	require.Equal(t, 4, attempts)
+}
+
+// TestRetryTimeout validates that a Retry-After beyond the retry timeout is not waited for
+func TestRetryTimeout(t *testing.T) {
+	var attempts int
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer testServer.Close()
+
+	origUserURL := userURLFormat
+	userURLFormat = testServer.URL + "/v1.0/users/%s"
+	defer func() { userURLFormat = origUserURL }()
+
+	client := &GraphClient{cred: &mockTokenCredential{token: "test-token"}, httpClient: testServer.Client()}
+	client.SetRetryPolicy(RetryPolicy{MaxRetries: 3, Timeout: time.Minute})
+	_, err := client.UserExists(context.Background(), "user@example.com")
+	require.ErrorIs(t, err, errs.ErrThrottled)
+	require.Equal(t, 1, attempts)
+}
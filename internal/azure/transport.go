@@ -0,0 +1,108 @@
+package azure
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Timeouts of the Microsoft Graph HTTP transport. Each bounds one phase of a
+// request, so a stalled connection attempt fails fast instead of hanging the
+// run, e.g. on an unreachable IPv6 route in a dual-stack cluster.
+const (
+	DialTimeout           = 10 * time.Second       // Establishing a TCP connection, per address
+	FallbackDelay         = 300 * time.Millisecond // Head start of IPv6 before IPv4 is tried in parallel (happy eyeballs)
+	TLSHandshakeTimeout   = 10 * time.Second       // Completing the TLS handshake
+	ResponseHeaderTimeout = 30 * time.Second       // Receiving response headers once the request is sent
+	RequestTimeout        = 60 * time.Second       // Whole request, including reading the body
+)
+
+// HTTPOptions customize the HTTP client of Microsoft Graph and token
+// requests, e.g. for egress through a TLS-intercepting proxy. Zero values
+// keep the defaults.
+type HTTPOptions struct {
+	ProxyURL       *url.URL       // Proxy for all requests, the HTTPS_PROXY and NO_PROXY environment if nil
+	RootCAs        *x509.CertPool // Certificates trusted for TLS, the system roots if nil
+	ConnectTimeout time.Duration  // Bound of the dial and of the TLS handshake each, see DialTimeout
+	RequestTimeout time.Duration  // Bound of a whole request, see RequestTimeout
+}
+
+// NewHTTPClient returns an HTTP client for Microsoft Graph and token
+// requests configured with opts, to pass to NewGraphClientWithHTTPClient.
+func NewHTTPClient(opts HTTPOptions) *http.Client {
+	dialTimeout, handshakeTimeout := DialTimeout, TLSHandshakeTimeout
+	if opts.ConnectTimeout > 0 {
+		dialTimeout, handshakeTimeout = opts.ConnectTimeout, opts.ConnectTimeout
+	}
+	requestTimeout := RequestTimeout
+	if opts.RequestTimeout > 0 {
+		requestTimeout = opts.RequestTimeout
+	}
+	proxy := http.ProxyFromEnvironment
+	if opts.ProxyURL != nil {
+		proxy = http.ProxyURL(opts.ProxyURL)
+	}
+	var tlsConfig *tls.Config
+	if opts.RootCAs != nil {
+		tlsConfig = &tls.Config{RootCAs: opts.RootCAs, MinVersion: tls.VersionTLS12}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:       dialTimeout,
+		KeepAlive:     30 * time.Second,
+		FallbackDelay: FallbackDelay,
+	}
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			Proxy:                 proxy,
+			DialContext:           dialer.DialContext,
+			TLSClientConfig:       tlsConfig,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   handshakeTimeout,
+			ResponseHeaderTimeout: ResponseHeaderTimeout,
+			ExpectContinueTimeout: time.Second,
+			IdleConnTimeout:       90 * time.Second,
+			MaxIdleConns:          100,
+		},
+	}
+}
+
+// newHTTPClient returns the default HTTP client used for Microsoft Graph and
+// token requests. Unlike http.DefaultClient, every phase of a request is
+// bounded and dual-stack dialing races both address families (RFC 6555).
+func newHTTPClient() *http.Client {
+	return NewHTTPClient(HTTPOptions{})
+}
+
+// ParseProxyURL parses the URL of an HTTP or HTTPS proxy, e.g.
+// "http://proxy.example.com:3128".
+func ParseProxyURL(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("expected an http:// or https:// proxy URL, got %q", value)
+	}
+	return u, nil
+}
+
+// LoadCABundle returns the system roots plus the PEM-encoded certificates in
+// path, e.g. the CA of a TLS-intercepting proxy.
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
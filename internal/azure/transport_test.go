@@ -0,0 +1,91 @@
+package azure
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestHTTPClientTimeouts validates that every phase of a Graph request is bounded
+func TestHTTPClientTimeouts(t *testing.T) {
+	client := newHTTPClient()
+	require.Equal(t, RequestTimeout, client.Timeout)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok, "Expected an *http.Transport")
+	require.Equal(t, TLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	require.Equal(t, ResponseHeaderTimeout, transport.ResponseHeaderTimeout)
+	require.NotNil(t, transport.DialContext, "Dialing must use the bounded dialer")
+	require.NotNil(t, transport.Proxy, "Proxy settings from the environment must be honored")
+}
+
+// TestStalledResponse validates that a lookup against a server that never
+// answers fails instead of hanging
+func TestStalledResponse(t *testing.T) {
+	release := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer testServer.Close()
+	defer close(release)
+
+	httpClient := newHTTPClient()
+	httpClient.Transport.(*http.Transport).ResponseHeaderTimeout = 50 * time.Millisecond
+	client := &GraphClient{cred: &mockTokenCredential{token: "test-token"}, httpClient: httpClient}
+
+	origUserURL := userURLFormat
+	userURLFormat = testServer.URL + "/%s"
+	defer func() { userURLFormat = origUserURL }()
+
+	start := time.Now()
+	_, err := client.UserExists(context.Background(), "user@example.com")
+	require.Error(t, err, "Stalled response should fail")
+	require.Less(t, time.Since(start), 5*time.Second, "Lookup should fail fast")
+}
+
+// TestHTTPOptions validates routing Graph requests through a proxy and
+// trusting a custom CA, as for a TLS-intercepting egress proxy
+func TestHTTPOptions(t *testing.T) {
+	client := NewHTTPClient(HTTPOptions{ConnectTimeout: time.Second, RequestTimeout: 5 * time.Second})
+	transport := client.Transport.(*http.Transport)
+	require.Equal(t, 5*time.Second, client.Timeout)
+	require.Equal(t, time.Second, transport.TLSHandshakeTimeout)
+
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+	resp, err := NewHTTPClient(HTTPOptions{ProxyURL: proxyURL}).Get("http://graph.invalid/v1.0/users/x")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, []string{"http://graph.invalid/v1.0/users/x"}, proxied)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	_, err = newHTTPClient().Get(server.URL)
+	require.Error(t, err, "The test server's CA should not be trusted by default")
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	pool, err := LoadCABundle(path)
+	require.NoError(t, err)
+	resp, err = NewHTTPClient(HTTPOptions{RootCAs: pool}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+	_, err = LoadCABundle(path)
+	require.ErrorContains(t, err, "no PEM certificates")
+}
@@ -0,0 +1,143 @@
+// Package backup archives the contents of namespaces before the auditor
+// deletes them, so that data removed by mistake can be recovered. Resources
+// are exported as multi-document YAML, compressed, and uploaded to a
+// pluggable Store: Amazon S3 (or a compatible service), Google Cloud
+// Storage or Azure Blob Storage.
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultResources lists the resources exported unless configured
+// otherwise: workloads, their configuration and access, and Kubeflow
+// notebooks. Secrets are left out so that credentials are not copied to
+// object storage; add "v1/secrets" to include them.
+var DefaultResources = []schema.GroupVersionResource{
+	{Version: "v1", Resource: "configmaps"},
+	{Version: "v1", Resource: "persistentvolumeclaims"},
+	{Version: "v1", Resource: "services"},
+	{Version: "v1", Resource: "serviceaccounts"},
+	{Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "apps", Version: "v1", Resource: "statefulsets"},
+	{Group: "batch", Version: "v1", Resource: "jobs"},
+	{Group: "batch", Version: "v1", Resource: "cronjobs"},
+	{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"},
+	{Group: "kubeflow.org", Version: "v1", Resource: "notebooks"},
+}
+
+// ParseResources parses a comma-separated list of resources, each written
+// as group/version/resource, or version/resource for the core group, e.g.
+// "v1/configmaps,apps/v1/deployments". An empty value selects
+// DefaultResources.
+func ParseResources(value string) ([]schema.GroupVersionResource, error) {
+	if strings.TrimSpace(value) == "" {
+		return DefaultResources, nil
+	}
+	var resources []schema.GroupVersionResource
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, "/")
+		var gvr schema.GroupVersionResource
+		switch len(parts) {
+		case 2:
+			gvr = schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}
+		case 3:
+			gvr = schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}
+		default:
+			return nil, fmt.Errorf("invalid resource %q, expected group/version/resource or version/resource", entry)
+		}
+		if gvr.Version == "" || gvr.Resource == "" || strings.ToLower(gvr.Resource) != gvr.Resource {
+			return nil, fmt.Errorf("invalid resource %q, expected a lowercase plural resource such as apps/v1/deployments", entry)
+		}
+		resources = append(resources, gvr)
+	}
+	return resources, nil
+}
+
+// Exporter archives namespaces to a Store. It implements auditor.Backuper.
+type Exporter struct {
+	client    dynamic.Interface
+	resources []schema.GroupVersionResource
+	store     Store
+	clock     func() time.Time
+}
+
+// NewExporter creates an exporter listing resources through client and
+// uploading archives to store.
+func NewExporter(client dynamic.Interface, resources []schema.GroupVersionResource, store Store) *Exporter {
+	return &Exporter{client: client, resources: resources, store: store, clock: time.Now}
+}
+
+// Backup exports the resources of namespace as gzip-compressed YAML to
+// "<namespace>/<time>.yaml.gz" in the store and returns the archive's
+// location. Resources the cluster does not serve, such as Kubeflow
+// notebooks without Kubeflow, are skipped; any other failure aborts the
+// backup.
+func (e *Exporter) Backup(ctx context.Context, namespace string) (string, error) {
+	var archive bytes.Buffer
+	zw := gzip.NewWriter(&archive)
+	for _, gvr := range e.resources {
+		list, err := e.client.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("listing %s: %w", describe(gvr), err)
+		}
+		for i := range list.Items {
+			data, err := yaml.Marshal(exported(list.Items[i]).Object)
+			if err != nil {
+				return "", fmt.Errorf("encoding %s %s: %w", describe(gvr), list.Items[i].GetName(), err)
+			}
+			fmt.Fprintf(zw, "---\n%s", data)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	key := path.Join(namespace, e.clock().UTC().Format("20060102T150405Z")+".yaml.gz")
+	location, err := e.store.Put(ctx, key, archive.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("uploading to %s: %w", e.store, err)
+	}
+	return location, nil
+}
+
+// exported strips the fields of obj that the API server assigns, so that
+// the archive can be applied to recreate it
+func exported(obj unstructured.Unstructured) unstructured.Unstructured {
+	obj = *obj.DeepCopy()
+	for _, field := range []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+	return obj
+}
+
+// describe names a resource as it is configured
+func describe(gvr schema.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return gvr.Version + "/" + gvr.Resource
+	}
+	return gvr.Group + "/" + gvr.Version + "/" + gvr.Resource
+}
@@ -0,0 +1,113 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// memoryStore keeps archives in memory for test validation
+type memoryStore struct {
+	objects map[string][]byte
+	err     error
+}
+
+func (s *memoryStore) Put(_ context.Context, key string, data []byte) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	s.objects[key] = data
+	return "mem://" + key, nil
+}
+
+func (s *memoryStore) String() string { return "memory" }
+
+var (
+	configMaps = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	notebooks  = schema.GroupVersionResource{Group: "kubeflow.org", Version: "v1", Resource: "notebooks"}
+)
+
+// newDynamicClient returns a fake client holding objs, serving configmaps
+// but not notebooks
+func newDynamicClient(objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{configMaps: "ConfigMapList", notebooks: "NotebookList"}, objs...)
+	client.PrependReactor("list", "notebooks", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(notebooks.GroupResource(), "")
+	})
+	return client
+}
+
+// configMap returns a ConfigMap with server-assigned fields
+func configMap(namespace, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":            name,
+			"namespace":       namespace,
+			"uid":             "1234",
+			"resourceVersion": "42",
+			"managedFields":   []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"data": map[string]interface{}{"key": "value"},
+	}}
+}
+
+// TestBackup validates the exported archive and its location
+func TestBackup(t *testing.T) {
+	client := newDynamicClient(configMap("team-a", "settings"), configMap("team-b", "other"))
+	store := &memoryStore{objects: map[string][]byte{}}
+	exporter := NewExporter(client, []schema.GroupVersionResource{configMaps, notebooks}, store)
+	exporter.clock = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	location, err := exporter.Backup(context.TODO(), "team-a")
+	require.NoError(t, err)
+	require.Equal(t, "mem://team-a/20240501T120000Z.yaml.gz", location)
+
+	zr, err := gzip.NewReader(bytes.NewReader(store.objects["team-a/20240501T120000Z.yaml.gz"]))
+	require.NoError(t, err)
+	archive, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	require.Contains(t, string(archive), "---\napiVersion: v1\ndata:\n  key: value\nkind: ConfigMap\n")
+	require.Contains(t, string(archive), "name: settings")
+	require.NotContains(t, string(archive), "other", "only the namespace is exported")
+	for _, field := range []string{"uid", "resourceVersion", "managedFields"} {
+		require.NotContains(t, string(archive), field)
+	}
+
+	store.err = errors.New("bucket unavailable")
+	_, err = exporter.Backup(context.TODO(), "team-a")
+	require.ErrorContains(t, err, "bucket unavailable")
+}
+
+// TestParseResources validates resource lists
+func TestParseResources(t *testing.T) {
+	resources, err := ParseResources("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultResources, resources)
+
+	resources, err = ParseResources("v1/secrets, apps/v1/deployments")
+	require.NoError(t, err)
+	require.Equal(t, []schema.GroupVersionResource{
+		{Version: "v1", Resource: "secrets"},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+	}, resources)
+
+	for _, value := range []string{"secrets", "apps/v1/Deployment", "a/b/c/d", "v1/"} {
+		_, err := ParseResources(value)
+		require.Error(t, err, value)
+	}
+}
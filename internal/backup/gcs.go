@@ -0,0 +1,72 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/bryanpaget/namespace-auditor/internal/google"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+// StorageScope is the OAuth2 scope the service account's tokens are
+// requested with. The service account needs permission to create objects
+// in the bucket, e.g. the Storage Object Creator role.
+const StorageScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// defaultGCSURL is the Cloud Storage JSON API endpoint
+const defaultGCSURL = "https://storage.googleapis.com"
+
+// defaultTokenURL is used when the service account key names no token endpoint
+const defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+// GCSStore uploads archives to a Google Cloud Storage bucket.
+type GCSStore struct {
+	bucket     string
+	prefix     string
+	baseURL    string
+	httpClient *http.Client // Client adding access tokens to requests
+}
+
+// NewGCSStore creates a store uploading below prefix in bucket,
+// authenticating as the service account of key.
+func NewGCSStore(key google.ServiceAccountKey, bucket, prefix string) *GCSStore {
+	tokenURL := key.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+	config := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Scopes:       []string{StorageScope},
+		TokenURL:     tokenURL,
+	}
+	base := &http.Client{Timeout: uploadTimeout}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
+	httpClient := config.Client(ctx)
+	httpClient.Timeout = uploadTimeout
+	return &GCSStore{bucket: bucket, prefix: prefix, baseURL: defaultGCSURL, httpClient: httpClient}
+}
+
+// Put uploads data as the object prefix/key.
+func (s *GCSStore) Put(ctx context.Context, key string, data []byte) (string, error) {
+	object := path.Join(s.prefix, key)
+	endpoint := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s", s.baseURL, url.PathEscape(s.bucket), url.QueryEscape(object))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+	if err := send(s.httpClient, req); err != nil {
+		return "", err
+	}
+	return "gs://" + s.bucket + "/" + object, nil
+}
+
+// String describes the bucket and prefix.
+func (s *GCSStore) String() string { return "gs://" + path.Join(s.bucket, s.prefix) }
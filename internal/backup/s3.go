@@ -0,0 +1,141 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// defaultS3Region is used unless AWS_REGION is set
+const defaultS3Region = "us-east-1"
+
+// S3Credentials are the static credentials requests are signed with.
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set for temporary credentials
+}
+
+// S3Store uploads archives to an Amazon S3 bucket, or to a bucket of an
+// S3-compatible service such as MinIO, signing requests with AWS
+// Signature Version 4.
+type S3Store struct {
+	bucket     string
+	prefix     string
+	region     string
+	endpoint   *url.URL // Path-style endpoint of a compatible service, nil for AWS
+	creds      S3Credentials
+	httpClient *http.Client
+	clock      func() time.Time
+}
+
+// NewS3Store creates a store uploading below prefix in bucket. Requests go
+// to the regional AWS endpoint unless endpoint is set.
+func NewS3Store(bucket, prefix, region string, endpoint *url.URL, creds S3Credentials) *S3Store {
+	if region == "" {
+		region = defaultS3Region
+	}
+	return &S3Store{
+		bucket:     bucket,
+		prefix:     prefix,
+		region:     region,
+		endpoint:   endpoint,
+		creds:      creds,
+		httpClient: &http.Client{Timeout: uploadTimeout},
+		clock:      time.Now,
+	}
+}
+
+// Put uploads data as the object prefix/key.
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) (string, error) {
+	object := path.Join(s.prefix, key)
+	u := url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", s.bucket, s.region), Path: "/" + object}
+	if s.endpoint != nil {
+		u = *s.endpoint
+		u.Path = path.Join("/", u.Path, s.bucket, object)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+	s.sign(req, data, s.clock())
+	if err := send(s.httpClient, req); err != nil {
+		return "", err
+	}
+	return "s3://" + s.bucket + "/" + object, nil
+}
+
+// String describes the bucket and prefix.
+func (s *S3Store) String() string { return "s3://" + path.Join(s.bucket, s.prefix) }
+
+// sign adds a Signature Version 4 Authorization header to req
+func (s *S3Store) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := hashHex(payload)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if s.creds.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", s.creds.SessionToken)
+	}
+
+	// Signed headers, in lexical order
+	names := []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	if s.creds.SessionToken != "" {
+		names = append(names, "x-amz-security-token")
+	}
+	var headers strings.Builder
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		fmt.Fprintf(&headers, "%s:%s\n", name, strings.TrimSpace(value))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.creds.SecretAccessKey, date, s.region, "s3"), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// signingKey derives the Signature Version 4 key of a day, region and service
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// hashHex returns the hex-encoded SHA-256 of data
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,186 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// Labels of the snapshots taken before deletion
+const (
+	// RetainUntilLabel holds the day after which a snapshot may be garbage
+	// collected, formatted as YYYY-MM-DD.
+	RetainUntilLabel = "namespace-auditor/retain-until"
+
+	// SourceNamespaceLabel names the namespace a snapshot was taken from,
+	// which is gone once the snapshot's VolumeSnapshot is deleted with it.
+	SourceNamespaceLabel = "namespace-auditor/source-namespace"
+)
+
+// Defaults of the snapshot step
+const (
+	DefaultSnapshotRetention = 30 * 24 * time.Hour
+	DefaultSnapshotTimeout   = 10 * time.Minute
+)
+
+// snapshotPrefix names the VolumeSnapshots taken by the auditor, followed
+// by the name of their PersistentVolumeClaim
+const snapshotPrefix = "auditor-"
+
+var (
+	persistentVolumeClaims = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	volumeSnapshots        = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}
+	volumeSnapshotContents = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshotcontents"}
+)
+
+// Snapshotter takes a CSI VolumeSnapshot of every bound
+// PersistentVolumeClaim of a namespace. It implements
+// auditor.VolumeSnapshotter.
+type Snapshotter struct {
+	client       dynamic.Interface
+	class        string        // VolumeSnapshotClass, empty for the cluster default
+	retention    time.Duration // How long snapshots are labeled to be kept
+	timeout      time.Duration // How long to wait for snapshots to be ready
+	pollInterval time.Duration
+	clock        func() time.Time
+}
+
+// NewSnapshotter creates a snapshotter using the VolumeSnapshotClass class,
+// or the cluster's default if empty. Zero durations select
+// DefaultSnapshotRetention and DefaultSnapshotTimeout.
+func NewSnapshotter(client dynamic.Interface, class string, retention, timeout time.Duration) *Snapshotter {
+	if retention <= 0 {
+		retention = DefaultSnapshotRetention
+	}
+	if timeout <= 0 {
+		timeout = DefaultSnapshotTimeout
+	}
+	return &Snapshotter{
+		client:       client,
+		class:        class,
+		retention:    retention,
+		timeout:      timeout,
+		pollInterval: 5 * time.Second,
+		clock:        time.Now,
+	}
+}
+
+// SnapshotVolumes snapshots the bound claims of namespace, waits until every
+// snapshot is ready to use, and returns the names of their
+// VolumeSnapshotContents. The contents are labeled with RetainUntilLabel
+// and SourceNamespaceLabel, and switched to the Retain deletion policy so
+// that they outlive the namespace. Snapshots left by an earlier attempt
+// are reused.
+func (s *Snapshotter) SnapshotVolumes(ctx context.Context, namespace string) ([]string, error) {
+	claims, err := s.client.Resource(persistentVolumeClaims).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing persistent volume claims: %w", err)
+	}
+	labels := map[string]interface{}{
+		RetainUntilLabel:     s.clock().Add(s.retention).UTC().Format("2006-01-02"),
+		SourceNamespaceLabel: namespace,
+	}
+
+	var names []string
+	for _, claim := range claims.Items {
+		if phase, _, _ := unstructured.NestedString(claim.Object, "status", "phase"); phase != "Bound" {
+			continue
+		}
+		name, err := s.create(ctx, namespace, claim.GetName(), labels)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	var contents []string
+	for _, name := range names {
+		content, err := s.waitReady(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.retain(ctx, content, labels); err != nil {
+			return nil, err
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
+
+// create creates the VolumeSnapshot of a claim, unless it already exists,
+// and returns its name
+func (s *Snapshotter) create(ctx context.Context, namespace, claim string, labels map[string]interface{}) (string, error) {
+	name := snapshotPrefix + claim
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": claim},
+	}
+	if s.class != "" {
+		spec["volumeSnapshotClassName"] = s.class
+	}
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": volumeSnapshots.GroupVersion().String(),
+		"kind":       "VolumeSnapshot",
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace, "labels": labels},
+		"spec":       spec,
+	}}
+	_, err := s.client.Resource(volumeSnapshots).Namespace(namespace).Create(ctx, snapshot, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("snapshotting %s: %w", claim, err)
+	}
+	return name, nil
+}
+
+// waitReady polls a VolumeSnapshot until it is ready to use and returns the
+// name of its VolumeSnapshotContent
+func (s *Snapshotter) waitReady(ctx context.Context, namespace, name string) (string, error) {
+	for {
+		snapshot, err := s.client.Resource(volumeSnapshots).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("waiting for snapshot %s: %w", name, err)
+		}
+		if message, failed, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); failed {
+			return "", fmt.Errorf("snapshot %s failed: %s", name, strings.TrimSpace(message))
+		}
+		ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+		content, _, _ := unstructured.NestedString(snapshot.Object, "status", "boundVolumeSnapshotContentName")
+		if ready && content != "" {
+			return content, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("snapshot %s not ready within %s", name, s.timeout)
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// retain labels a VolumeSnapshotContent and keeps it once its
+// VolumeSnapshot is deleted with the namespace
+func (s *Snapshotter) retain(ctx context.Context, content string, labels map[string]interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+		"spec":     map[string]interface{}{"deletionPolicy": "Retain"},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.Resource(volumeSnapshotContents).Patch(ctx, content, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("retaining snapshot content %s: %w", content, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// claim returns a PersistentVolumeClaim in phase
+func claim(namespace, name, phase string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolumeClaim",
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"status":     map[string]interface{}{"phase": phase},
+	}}
+}
+
+// snapshotContent returns a VolumeSnapshotContent deleted with its snapshot
+func snapshotContent(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "snapshot.storage.k8s.io/v1",
+		"kind":       "VolumeSnapshotContent",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec":       map[string]interface{}{"deletionPolicy": "Delete"},
+	}}
+}
+
+// newSnapshotClient returns a fake client whose snapshots become ready
+// when created, bound to the content "content-<claim>", unless ready is
+// false
+func newSnapshotClient(ready bool, objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		persistentVolumeClaims: "PersistentVolumeClaimList",
+		volumeSnapshots:        "VolumeSnapshotList",
+		volumeSnapshotContents: "VolumeSnapshotContentList",
+	}, objs...)
+	client.PrependReactor("create", "volumesnapshots", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		snapshot := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		source, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+		unstructured.SetNestedField(snapshot.Object, ready, "status", "readyToUse")
+		unstructured.SetNestedField(snapshot.Object, "content-"+source, "status", "boundVolumeSnapshotContentName")
+		return false, nil, nil // Stored by the default reactor
+	})
+	return client
+}
+
+// TestSnapshotVolumes validates the snapshots taken of bound claims and the
+// retention of their contents
+func TestSnapshotVolumes(t *testing.T) {
+	client := newSnapshotClient(true,
+		claim("team-a", "data", "Bound"), claim("team-a", "pending", "Pending"), claim("team-b", "other", "Bound"),
+		snapshotContent("content-data"))
+	snapshotter := NewSnapshotter(client, "csi-retain", 7*24*time.Hour, 0)
+	snapshotter.clock = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	contents, err := snapshotter.SnapshotVolumes(context.TODO(), "team-a")
+	require.NoError(t, err)
+	require.Equal(t, []string{"content-data"}, contents)
+
+	snapshots, err := client.Resource(volumeSnapshots).Namespace("team-a").List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, snapshots.Items, 1, "only bound claims are snapshotted")
+	snapshot := snapshots.Items[0]
+	require.Equal(t, "auditor-data", snapshot.GetName())
+	class, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+	require.Equal(t, "csi-retain", class)
+	require.Equal(t, "2024-05-08", snapshot.GetLabels()[RetainUntilLabel])
+
+	content, err := client.Resource(volumeSnapshotContents).Get(context.TODO(), "content-data", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{RetainUntilLabel: "2024-05-08", SourceNamespaceLabel: "team-a"}, content.GetLabels())
+	policy, _, _ := unstructured.NestedString(content.Object, "spec", "deletionPolicy")
+	require.Equal(t, "Retain", policy, "contents outlive the namespace")
+
+	// Snapshots left by an earlier attempt are reused
+	contents, err = snapshotter.SnapshotVolumes(context.TODO(), "team-a")
+	require.NoError(t, err)
+	require.Equal(t, []string{"content-data"}, contents)
+}
+
+// TestSnapshotNotReady validates that snapshots not ready in time and failed
+// snapshots are reported
+func TestSnapshotNotReady(t *testing.T) {
+	client := newSnapshotClient(false, claim("team-a", "data", "Bound"))
+	snapshotter := NewSnapshotter(client, "", 0, 50*time.Millisecond)
+	snapshotter.pollInterval = 10 * time.Millisecond
+
+	_, err := snapshotter.SnapshotVolumes(context.TODO(), "team-a")
+	require.ErrorContains(t, err, "snapshot auditor-data not ready within 50ms")
+
+	snapshot, err := client.Resource(volumeSnapshots).Namespace("team-a").Get(context.TODO(), "auditor-data", metav1.GetOptions{})
+	require.NoError(t, err)
+	_, found, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+	require.False(t, found, "the cluster's default class is used")
+	require.NoError(t, unstructured.SetNestedField(snapshot.Object, "volume not found", "status", "error", "message"))
+	_, err = client.Resource(volumeSnapshots).Namespace("team-a").Update(context.TODO(), snapshot, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	_, err = snapshotter.SnapshotVolumes(context.TODO(), "team-a")
+	require.ErrorContains(t, err, "snapshot auditor-data failed: volume not found")
+}
@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/google"
+)
+
+// uploadTimeout bounds the upload of a single archive
+const uploadTimeout = 5 * time.Minute
+
+// Store keeps backup archives.
+type Store interface {
+	// Put stores data under key and returns the location of the object,
+	// without credentials, e.g. "s3://bucket/prefix/key".
+	Put(ctx context.Context, key string, data []byte) (string, error)
+	String() string // Describes the destination for logs, without secrets
+}
+
+// ParseStore parses the destination of backups:
+//   - "s3://<bucket>/<prefix>": Amazon S3, or a compatible service with
+//     BACKUP_S3_ENDPOINT, authenticated with AWS_ACCESS_KEY_ID,
+//     AWS_SECRET_ACCESS_KEY and optionally AWS_SESSION_TOKEN, in AWS_REGION
+//   - "gs://<bucket>/<prefix>": Google Cloud Storage, authenticated with the
+//     service account key at GOOGLE_APPLICATION_CREDENTIALS
+//   - "blob:<container URL with SAS token>": Azure Blob Storage
+func ParseStore(spec string, getenv func(string) string) (Store, error) {
+	switch {
+	case strings.HasPrefix(spec, "s3://"):
+		bucket, prefix := splitBucket(strings.TrimPrefix(spec, "s3://"))
+		if bucket == "" {
+			return nil, fmt.Errorf("store %q: missing bucket", spec)
+		}
+		creds := S3Credentials{
+			AccessKeyID:     getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    getenv("AWS_SESSION_TOKEN"),
+		}
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY: required for s3 backups")
+		}
+		var endpoint *url.URL
+		if value := getenv("BACKUP_S3_ENDPOINT"); value != "" {
+			u, err := url.Parse(value)
+			if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return nil, fmt.Errorf("BACKUP_S3_ENDPOINT: invalid URL %q", value)
+			}
+			endpoint = u
+		}
+		return NewS3Store(bucket, prefix, getenv("AWS_REGION"), endpoint, creds), nil
+	case strings.HasPrefix(spec, "gs://"):
+		bucket, prefix := splitBucket(strings.TrimPrefix(spec, "gs://"))
+		if bucket == "" {
+			return nil, fmt.Errorf("store %q: missing bucket", spec)
+		}
+		keyPath := getenv("GOOGLE_APPLICATION_CREDENTIALS")
+		if keyPath == "" {
+			return nil, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS: required for gs backups, path of a service account JSON key")
+		}
+		data, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		key, err := google.ParseServiceAccountKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		return NewGCSStore(key, bucket, prefix), nil
+	case strings.HasPrefix(spec, "blob:"):
+		u, err := url.Parse(strings.TrimPrefix(spec, "blob:"))
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("store %q: expected an https container URL", redact(spec))
+		}
+		return NewAzureBlobStore(u), nil
+	default:
+		return nil, fmt.Errorf("unknown store %q, expected s3://<bucket>, gs://<bucket> or blob:<container URL>", redact(spec))
+	}
+}
+
+// splitBucket splits "bucket/prefix" into its parts
+func splitBucket(value string) (bucket, prefix string) {
+	bucket, prefix, _ = strings.Cut(value, "/")
+	return bucket, strings.Trim(prefix, "/")
+}
+
+// redact strips the query string, which may hold credentials, from a URL
+func redact(value string) string {
+	before, _, _ := strings.Cut(value, "?")
+	return before
+}
+
+// AzureBlobStore uploads archives as block blobs into an Azure Blob Storage
+// container addressed by a SAS URL, which must allow creating blobs.
+type AzureBlobStore struct {
+	container  *url.URL
+	httpClient *http.Client
+}
+
+// NewAzureBlobStore creates a store uploading into container.
+func NewAzureBlobStore(container *url.URL) *AzureBlobStore {
+	return &AzureBlobStore{container: container, httpClient: &http.Client{Timeout: uploadTimeout}}
+}
+
+// Put uploads data as the blob key.
+func (s *AzureBlobStore) Put(ctx context.Context, key string, data []byte) (string, error) {
+	blob := *s.container
+	blob.Path = path.Join(blob.Path, key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, blob.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	if err := send(s.httpClient, req); err != nil {
+		return "", err
+	}
+	blob.RawQuery = ""
+	return blob.String(), nil
+}
+
+// String returns the container URL without its SAS token.
+func (s *AzureBlobStore) String() string { return "blob:" + redact(s.container.String()) }
+
+// send performs an upload request, failing on non-2xx responses. Errors
+// do not include the request URL, which may carry credentials.
+func send(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			return fmt.Errorf("%s %s: %w", urlErr.Op, redact(urlErr.URL), urlErr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response %d %s: %s", resp.StatusCode, http.StatusText(resp.StatusCode), strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package backup
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/google"
+	"github.com/stretchr/testify/require"
+)
+
+// upload is a request received by an upload server
+type upload struct {
+	method, path, query string
+	header              http.Header
+	body                string
+}
+
+// newUploadServer records the requests it receives
+func newUploadServer(t *testing.T, status int) (*[]upload, *httptest.Server) {
+	var uploads []upload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"access-token","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		uploads = append(uploads, upload{r.Method, r.URL.Path, r.URL.RawQuery, r.Header, string(body)})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return &uploads, server
+}
+
+// TestAzureBlobStore validates uploads to a container SAS URL
+func TestAzureBlobStore(t *testing.T) {
+	uploads, server := newUploadServer(t, http.StatusCreated)
+	store, err := ParseStore("blob:https://account.blob.core.windows.net/backups?sv=2022&sig=secret", os.Getenv)
+	require.NoError(t, err)
+	require.Equal(t, "blob:https://account.blob.core.windows.net/backups", store.String())
+
+	container, _ := url.Parse(server.URL + "/backups?sig=secret")
+	blobStore := NewAzureBlobStore(container)
+	blobStore.httpClient = server.Client()
+	location, err := blobStore.Put(context.TODO(), "team-a/archive.yaml.gz", []byte("data"))
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/backups/team-a/archive.yaml.gz", location, "the SAS token is not part of the location")
+	require.Len(t, *uploads, 1)
+	require.Equal(t, "BlockBlob", (*uploads)[0].header.Get("x-ms-blob-type"))
+	require.Equal(t, "sig=secret", (*uploads)[0].query)
+}
+
+// TestS3Store validates signed uploads to an S3-compatible endpoint
+func TestS3Store(t *testing.T) {
+	uploads, server := newUploadServer(t, http.StatusOK)
+	endpoint, _ := url.Parse(server.URL)
+	store := NewS3Store("backups", "audit", "ca-central-1", endpoint, S3Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"})
+	store.httpClient = server.Client()
+	store.clock = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	location, err := store.Put(context.TODO(), "team-a/archive.yaml.gz", []byte("data"))
+	require.NoError(t, err)
+	require.Equal(t, "s3://backups/audit/team-a/archive.yaml.gz", location)
+	require.Len(t, *uploads, 1)
+	got := (*uploads)[0]
+	require.Equal(t, "/backups/audit/team-a/archive.yaml.gz", got.path)
+	require.Equal(t, "data", got.body)
+	require.Equal(t, "20240501T120000Z", got.header.Get("x-amz-date"))
+	require.Equal(t, "session", got.header.Get("x-amz-security-token"))
+	require.True(t, strings.HasPrefix(got.header.Get("Authorization"),
+		"AWS4-HMAC-SHA256 Credential=AKID/20240501/ca-central-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token, Signature="))
+
+	store.region = "us-east-1"
+	uploadsFailing, failing := newUploadServer(t, http.StatusForbidden)
+	store.endpoint, _ = url.Parse(failing.URL)
+	_, err = store.Put(context.TODO(), "team-a/archive.yaml.gz", []byte("data"))
+	require.ErrorContains(t, err, "403 Forbidden")
+	require.Len(t, *uploadsFailing, 1)
+}
+
+// TestSigningKey validates key derivation against the example of the AWS
+// Signature Version 4 documentation
+func TestSigningKey(t *testing.T) {
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+	require.Equal(t, "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9", hex.EncodeToString(key))
+}
+
+// TestGCSStore validates uploads authenticated as a service account
+func TestGCSStore(t *testing.T) {
+	uploads, server := newUploadServer(t, http.StatusOK)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+	keyFile, err := json.Marshal(map[string]string{
+		"client_email": "auditor@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    server.URL + "/token",
+	})
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(keyPath, keyFile, 0o600))
+
+	store, err := ParseStore("gs://backups/audit/", func(key string) string {
+		return map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": keyPath}[key]
+	})
+	require.NoError(t, err)
+	gcs := store.(*GCSStore)
+	gcs.baseURL = server.URL
+
+	location, err := gcs.Put(context.TODO(), "team-a/archive.yaml.gz", []byte("data"))
+	require.NoError(t, err)
+	require.Equal(t, "gs://backups/audit/team-a/archive.yaml.gz", location)
+	require.Len(t, *uploads, 1)
+	require.Equal(t, "/upload/storage/v1/b/backups/o", (*uploads)[0].path)
+	require.Equal(t, "uploadType=media&name=audit%2Fteam-a%2Farchive.yaml.gz", (*uploads)[0].query)
+	require.Equal(t, "Bearer access-token", (*uploads)[0].header.Get("Authorization"))
+
+	key, err := google.ParseServiceAccountKey(keyFile)
+	require.NoError(t, err)
+	require.Equal(t, "gs://backups", NewGCSStore(key, "backups", "").String())
+}
+
+// TestParseStore validates store settings
+func TestParseStore(t *testing.T) {
+	env := map[string]string{"AWS_ACCESS_KEY_ID": "AKID", "AWS_SECRET_ACCESS_KEY": "secret"}
+	getenv := func(key string) string { return env[key] }
+
+	store, err := ParseStore("s3://backups/audit", getenv)
+	require.NoError(t, err)
+	require.Equal(t, "s3://backups/audit", store.String())
+	require.Equal(t, defaultS3Region, store.(*S3Store).region)
+
+	for spec, want := range map[string]string{
+		"s3://":                          "missing bucket",
+		"gs://backups":                   "GOOGLE_APPLICATION_CREDENTIALS",
+		"blob:http://example.com/c?sig=": "https container URL",
+		"ftp://backups?token=secret":     "unknown store",
+	} {
+		_, err := ParseStore(spec, getenv)
+		require.ErrorContains(t, err, want, spec)
+		require.NotContains(t, err.Error(), "secret", "credentials are not reported")
+	}
+
+	env["BACKUP_S3_ENDPOINT"] = "minio:9000"
+	_, err = ParseStore("s3://backups", getenv)
+	require.ErrorContains(t, err, "BACKUP_S3_ENDPOINT")
+
+	delete(env, "AWS_SECRET_ACCESS_KEY")
+	_, err = ParseStore("s3://backups", getenv)
+	require.ErrorContains(t, err, "AWS_SECRET_ACCESS_KEY")
+}
@@ -0,0 +1,190 @@
+// Package chain validates namespace owners against an ordered list of
+// identity providers, for organizations whose users live in more than one
+// directory (e.g. employees in Entra ID and external users in B2C).
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+)
+
+// ProviderName is the name the chain provider is registered under.
+const ProviderName = "chain"
+
+// memberNamePattern matches member names, which prefix the member settings
+var memberNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+func init() {
+	identity.Register(ProviderName, newProvider)
+}
+
+// Member is one identity provider of a chain.
+type Member struct {
+	Name     string            // Name of the member, used in errors and setting prefixes
+	Provider identity.Provider // Provider looking up users
+	Domains  []string          // Owner domains routed to the provider, all if empty
+}
+
+// Provider looks users up with each member whose domains include the
+// owner's, in order. A user exists if any of them confirms it. It
+// implements auditor.UserStateReporter.
+type Provider struct {
+	members []Member
+}
+
+// New creates a chain of members, consulted in the given order.
+func New(members ...Member) *Provider {
+	return &Provider{members: members}
+}
+
+// routes reports whether m is consulted for email
+func (m Member) routes(email string) bool {
+	return len(m.Domains) == 0 || auditor.IsAllowedOwner(email, m.Domains)
+}
+
+// UserExists reports whether any member routed for the owner's domain
+// confirms the user. Lookup errors are only returned when no member
+// confirmed the user, so that an outage of one directory cannot make its
+// users look missing. A domain no member is routed for has no users.
+func (p *Provider) UserExists(ctx context.Context, email string) (bool, error) {
+	state, err := p.UserState(ctx, email)
+	return state.Exists(), err
+}
+
+// UserState returns the state reported by the first member that knows the
+// user, preferring an active account over a disabled one in a later member.
+// Among members that do not know the user, a deleted account or missing
+// group membership is reported over a plain miss.
+func (p *Provider) UserState(ctx context.Context, email string) (identity.UserState, error) {
+	state, _, err := p.UserStateWithProvenance(ctx, email)
+	return state, err
+}
+
+// UserStateWithProvenance performs the same lookup as UserState and also
+// reports which member produced the state, its provider qualified with the
+// member name, e.g. "staff/azure". A user no routed member knows is
+// attributed to the last member consulted, and to "chain" if none was.
+func (p *Provider) UserStateWithProvenance(ctx context.Context, email string) (identity.UserState, identity.Provenance, error) {
+	best := identity.UserNotFound
+	bestSource := identity.Provenance{Provider: ProviderName}
+	var failures []error
+	for _, m := range p.members {
+		if !m.routes(email) {
+			continue
+		}
+		state, source, err := m.lookup(ctx, email)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", m.Name, err))
+			continue
+		}
+		if state == identity.UserActive {
+			return state, source, nil
+		}
+		if rank(state) > rank(best) || rank(best) == 0 {
+			best, bestSource = state, source
+		}
+	}
+	if best.Exists() {
+		return best, bestSource, nil
+	}
+	if len(failures) > 0 {
+		return identity.UserNotFound, identity.Provenance{}, errors.Join(failures...)
+	}
+	return best, bestSource, nil
+}
+
+// lookup returns the state of a user from a single member and where it came
+// from
+func (m Member) lookup(ctx context.Context, email string) (identity.UserState, identity.Provenance, error) {
+	source := identity.Provenance{Provider: m.Name}
+	if reporter, ok := m.Provider.(auditor.ProvenanceReporter); ok {
+		state, inner, err := reporter.UserStateWithProvenance(ctx, email)
+		inner.Provider = m.Name + "/" + inner.Provider
+		return state, inner, err
+	}
+	if reporter, ok := m.Provider.(auditor.UserStateReporter); ok {
+		state, err := reporter.UserState(ctx, email)
+		return state, source, err
+	}
+	exists, err := m.Provider.UserExists(ctx, email)
+	if err != nil {
+		return identity.UserNotFound, source, err
+	}
+	if exists {
+		return identity.UserActive, source, nil
+	}
+	return identity.UserNotFound, source, nil
+}
+
+// rank orders states by how much they tell about a user
+func rank(state identity.UserState) int {
+	switch state {
+	case identity.UserDisabled:
+		return 3
+	case identity.UserDeleted:
+		return 2
+	case identity.UserNotMember:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// newProvider creates a chain from IDENTITY_CHAIN, an ordered comma-separated
+// list of "name:provider" members, e.g. "staff:azure,guests:azure". Each
+// member reads its settings with its upper-cased name and an underscore as
+// prefix, e.g. STAFF_AZURE_TENANT_ID, and is routed the owner domains listed
+// in the optional <NAME>_DOMAINS.
+func newProvider(getenv func(string) string) (identity.Provider, error) {
+	value := getenv("IDENTITY_CHAIN")
+	if strings.TrimSpace(value) == "" {
+		return nil, errors.New(`IDENTITY_CHAIN: required, e.g. "staff:azure,guests:azure"`)
+	}
+
+	var members []Member
+	var problems []error
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		name, providerName, found := strings.Cut(strings.TrimSpace(entry), ":")
+		name, providerName = strings.TrimSpace(name), strings.TrimSpace(providerName)
+		switch {
+		case !found || !memberNamePattern.MatchString(name):
+			problems = append(problems, fmt.Errorf(`IDENTITY_CHAIN: invalid member %q, expected "name:provider" with a name of letters and digits`, entry))
+			continue
+		case seen[strings.ToUpper(name)]:
+			problems = append(problems, fmt.Errorf("IDENTITY_CHAIN: duplicate member %q", name))
+			continue
+		case providerName == ProviderName:
+			problems = append(problems, fmt.Errorf("IDENTITY_CHAIN: member %q cannot be a chain itself", name))
+			continue
+		}
+		seen[strings.ToUpper(name)] = true
+
+		prefix := strings.ToUpper(name) + "_"
+		prefixed := func(key string) string { return getenv(prefix + key) }
+		provider, err := identity.New(providerName, prefixed)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("IDENTITY_CHAIN: member %q (settings prefixed %s): %w", name, prefix, err))
+			continue
+		}
+		member := Member{Name: name, Provider: provider}
+		if domains := getenv(prefix + "DOMAINS"); domains != "" {
+			member.Domains, err = auditor.ParseAllowedDomains(domains)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%sDOMAINS: %w", prefix, err))
+				continue
+			}
+		}
+		members = append(members, member)
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	return New(members...), nil
+}
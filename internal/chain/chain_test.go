@@ -0,0 +1,183 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+)
+
+// fakeProvider answers lookups from a fixed set of users and counts calls
+type fakeProvider struct {
+	users map[string]identity.UserState
+	err   error
+	calls int
+}
+
+// UserExists implements identity.Provider
+func (f *fakeProvider) UserExists(ctx context.Context, email string) (bool, error) {
+	state, err := f.UserState(ctx, email)
+	return state.Exists(), err
+}
+
+// UserState implements auditor.UserStateReporter
+func (f *fakeProvider) UserState(_ context.Context, email string) (identity.UserState, error) {
+	f.calls++
+	if f.err != nil {
+		return identity.UserNotFound, f.err
+	}
+	if state, ok := f.users[email]; ok {
+		return state, nil
+	}
+	return identity.UserNotFound, nil
+}
+
+// existsOnly hides the UserState method of a provider
+type existsOnly struct{ identity.Provider }
+
+// TestChainUserState validates the order and routing of lookups
+func TestChainUserState(t *testing.T) {
+	staff := &fakeProvider{users: map[string]identity.UserState{
+		"active@statcan.gc.ca":   identity.UserActive,
+		"disabled@statcan.gc.ca": identity.UserDisabled,
+		"moved@statcan.gc.ca":    identity.UserDeleted,
+	}}
+	guests := &fakeProvider{users: map[string]identity.UserState{
+		"guest@partner.org":      identity.UserActive,
+		"disabled@statcan.gc.ca": identity.UserActive,
+		"staff@partner.org":      identity.UserActive,
+	}}
+	p := New(
+		Member{Name: "staff", Provider: staff, Domains: []string{"statcan.gc.ca"}},
+		Member{Name: "guests", Provider: existsOnly{guests}},
+	)
+
+	tests := []struct {
+		email       string
+		want        identity.UserState
+		staffCalled bool
+	}{
+		{"active@statcan.gc.ca", identity.UserActive, true},
+		{"disabled@statcan.gc.ca", identity.UserActive, true}, // Active in a later member wins
+		{"moved@statcan.gc.ca", identity.UserDeleted, true},   // Deleted is reported over not found
+		{"guest@partner.org", identity.UserActive, false},     // Not routed to staff
+		{"nobody@partner.org", identity.UserNotFound, false},
+	}
+	for _, tt := range tests {
+		staff.calls = 0
+		got, err := p.UserState(context.TODO(), tt.email)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: UserState = %q, %v, want %q", tt.email, got, err, tt.want)
+		}
+		if called := staff.calls > 0; called != tt.staffCalled {
+			t.Errorf("%s: staff consulted = %t, want %t", tt.email, called, tt.staffCalled)
+		}
+	}
+}
+
+// sourcedProvider reports a fixed provenance for every lookup
+type sourcedProvider struct {
+	*fakeProvider
+	source identity.Provenance
+}
+
+// UserStateWithProvenance implements auditor.ProvenanceReporter
+func (s sourcedProvider) UserStateWithProvenance(ctx context.Context, email string) (identity.UserState, identity.Provenance, error) {
+	state, err := s.UserState(ctx, email)
+	return state, s.source, err
+}
+
+// TestChainProvenance validates that verdicts name the member that produced
+// them
+func TestChainProvenance(t *testing.T) {
+	staff := sourcedProvider{
+		fakeProvider: &fakeProvider{users: map[string]identity.UserState{"moved@statcan.gc.ca": identity.UserDeleted}},
+		source:       identity.Provenance{Provider: "azure", Endpoint: "https://graph.microsoft.com", Method: identity.LookupUPN},
+	}
+	guests := &fakeProvider{users: map[string]identity.UserState{"guest@statcan.gc.ca": identity.UserActive}}
+	p := New(Member{Name: "staff", Provider: staff}, Member{Name: "guests", Provider: guests})
+
+	tests := []struct {
+		email string
+		want  identity.Provenance
+	}{
+		{"guest@statcan.gc.ca", identity.Provenance{Provider: "guests"}},
+		{"moved@statcan.gc.ca", identity.Provenance{Provider: "staff/azure", Endpoint: "https://graph.microsoft.com", Method: identity.LookupUPN}},
+		{"nobody@statcan.gc.ca", identity.Provenance{Provider: "guests"}}, // Last member consulted
+	}
+	for _, tt := range tests {
+		_, got, err := p.UserStateWithProvenance(context.TODO(), tt.email)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: provenance = %+v, %v, want %+v", tt.email, got, err, tt.want)
+		}
+	}
+}
+
+// TestChainErrors validates that lookup errors only surface when no member
+// confirms the user
+func TestChainErrors(t *testing.T) {
+	down := &fakeProvider{err: errs.ErrThrottled}
+	up := &fakeProvider{users: map[string]identity.UserState{"user@example.com": identity.UserActive}}
+	p := New(Member{Name: "down", Provider: down}, Member{Name: "up", Provider: up})
+
+	if exists, err := p.UserExists(context.TODO(), "user@example.com"); !exists || err != nil {
+		t.Errorf("UserExists = %t, %v, want confirmed by the second member", exists, err)
+	}
+
+	exists, err := p.UserExists(context.TODO(), "missing@example.com")
+	if exists || !errors.Is(err, errs.ErrThrottled) || !strings.Contains(err.Error(), "down") {
+		t.Errorf("UserExists = %t, %v, want the throttling error of member down", exists, err)
+	}
+}
+
+// TestNewProvider validates configuring a chain from settings
+func TestNewProvider(t *testing.T) {
+	identity.Register("chain-test", func(getenv func(string) string) (identity.Provider, error) {
+		if getenv("TEST_TOKEN") == "" {
+			return nil, errors.New("TEST_TOKEN: required")
+		}
+		return &fakeProvider{}, nil
+	})
+
+	env := map[string]string{
+		"IDENTITY_CHAIN":    "staff:chain-test, guests:chain-test",
+		"STAFF_TEST_TOKEN":  "a",
+		"STAFF_DOMAINS":     "StatCan.gc.ca",
+		"GUESTS_TEST_TOKEN": "b",
+	}
+	getenv := func(key string) string { return env[key] }
+	provider, err := newProvider(getenv)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	members := provider.(*Provider).members
+	if len(members) != 2 || members[0].Name != "staff" || members[1].Name != "guests" {
+		t.Fatalf("Unexpected members %+v", members)
+	}
+	if len(members[0].Domains) != 1 || members[0].Domains[0] != "statcan.gc.ca" || members[1].Domains != nil {
+		t.Errorf("Unexpected domains %v / %v", members[0].Domains, members[1].Domains)
+	}
+
+	for value, want := range map[string]string{
+		"":                                  "IDENTITY_CHAIN: required",
+		"staff":                             "invalid member",
+		"staff:chain-test,Staff:chain-test": "duplicate member",
+		"loop:chain":                        "cannot be a chain",
+		"other:chain-test":                  "OTHER_",
+		"staff:nope":                        "unknown identity provider",
+	} {
+		env["IDENTITY_CHAIN"] = value
+		if _, err := newProvider(getenv); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got %v", value, want, err)
+		}
+	}
+
+	env["IDENTITY_CHAIN"] = "staff:chain-test"
+	env["STAFF_DOMAINS"] = "not a domain"
+	if _, err := newProvider(getenv); err == nil || !strings.Contains(err.Error(), "STAFF_DOMAINS") {
+		t.Errorf("Expected STAFF_DOMAINS error, got %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+// Package config gathers the auditor's settings from command-line flags,
+// environment variables and configuration files. Settings are named like
+// environment variables, e.g. GRACE_PERIOD; the flag and file key of a
+// setting is its name in lower case with dashes, e.g. --grace-period and
+// grace-period. Values stay strings here: parsing them into a typed
+// configuration, with errors naming the offending setting, is up to the
+// program, which reads them through Loader.Getenv.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Setting declares a setting of the program.
+type Setting struct {
+	Name     string // Environment variable, e.g. GRACE_PERIOD
+	Usage    string // Help text of the flag
+	Default  string // Value when no source sets one, empty for none
+	Required bool   // Whether a value must be set
+	Secret   bool   // Whether the value is redacted from the effective configuration and kept off the command line
+}
+
+// Key returns the flag and file key of the setting named name, e.g.
+// grace-period for GRACE_PERIOD.
+func Key(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+// Name returns the name of the setting with the flag or file key key, see
+// Key.
+func Name(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// Settings declares all settings of a program.
+type Settings []Setting
+
+// Validate returns an error for each required setting getenv has no value
+// for.
+func (s Settings) Validate(getenv func(string) string) []error {
+	var errs []error
+	for _, setting := range s {
+		if setting.Required && strings.TrimSpace(getenv(setting.Name)) == "" {
+			errs = append(errs, fmt.Errorf("%s: required", setting.Name))
+		}
+	}
+	return errs
+}
+
+// Source provides the values of settings, by setting name.
+type Source struct {
+	Name   string                           // Where values come from, e.g. "env", shown in the effective configuration
+	Lookup func(name string) (string, bool) // Returns the value of a setting and whether the source sets it
+}
+
+// Env returns a source reading environment variables with lookupEnv,
+// usually os.LookupEnv. Empty variables are treated as unset.
+func Env(lookupEnv func(string) (string, bool)) Source {
+	return Source{Name: "env", Lookup: func(name string) (string, bool) {
+		value, ok := lookupEnv(name)
+		return value, ok && value != ""
+	}}
+}
+
+// Values returns a source holding values, by setting name.
+func Values(name string, values map[string]string) Source {
+	return Source{Name: name, Lookup: func(setting string) (string, bool) {
+		value, ok := values[setting]
+		return value, ok
+	}}
+}
+
+// Flags defines a string flag for each setting in fs and returns a source
+// holding the flags set on the command line. Flag defaults are the defaults
+// of the settings, for the help text only: unset flags are left to the
+// other sources. Secret settings get no flag, since command lines are
+// visible to other processes and in the pod spec.
+func Flags(fs *flag.FlagSet, settings Settings) Source {
+	for _, setting := range settings {
+		if setting.Secret {
+			continue
+		}
+		fs.String(Key(setting.Name), setting.Default, setting.Usage)
+	}
+	return Source{Name: "flag", Lookup: func(name string) (string, bool) {
+		key := Key(name)
+		var value string
+		set := false
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == key {
+				value, set = f.Value.String(), true
+			}
+		})
+		return value, set
+	}}
+}
+
+// fileKeyPattern matches the keys of configuration files
+var fileKeyPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ReadFile returns a source holding the settings of a YAML file mapping
+// keys to values, e.g. "grace-period: 720h". Lists of values are joined
+// with commas, so list settings can be written either way.
+func ReadFile(path string) (Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Source{}, err
+	}
+	var entries map[string]interface{}
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return Source{}, fmt.Errorf("%s: %w", path, err)
+	}
+	values := make(map[string]string, len(entries))
+	var errs []error
+	for key, entry := range entries {
+		if !fileKeyPattern.MatchString(key) {
+			errs = append(errs, fmt.Errorf("%s: invalid key %q, expected lower case with dashes, e.g. grace-period", path, key))
+			continue
+		}
+		value, err := fileValue(entry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", path, key, err))
+			continue
+		}
+		values[Name(key)] = value
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return Source{}, errors.Join(errs...)
+	}
+	return Values("file", values), nil
+}
+
+// fileValue returns the setting value of a YAML value
+func fileValue(entry interface{}) (string, error) {
+	switch v := entry.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, nested := item.([]interface{}); nested {
+				return "", errors.New("expected a value or a list of values")
+			}
+			value, err := fileValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, value)
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", errors.New("expected a value or a list of values")
+}
+
+// secretSuffixes end the names of settings redacted even if undeclared,
+// such as the credentials of additional tenants or identity-chain members
+var secretSuffixes = []string{"SECRET", "SECRET_ACCESS_KEY", "TOKEN", "PASSWORD", "PRIVATE_KEY"}
+
+// Loader reads settings from its sources in order, the first source
+// setting a value taking precedence, and falls back to the defaults of the
+// declared settings. It records the settings read, so that the effective
+// configuration includes undeclared settings the program looked up. It is
+// safe for concurrent use.
+type Loader struct {
+	settings map[string]Setting
+	sources  []Source
+
+	mu   sync.Mutex
+	read map[string]bool
+}
+
+// NewLoader returns a loader of settings reading sources in order of
+// precedence.
+func NewLoader(settings Settings, sources ...Source) *Loader {
+	byName := make(map[string]Setting, len(settings))
+	for _, setting := range settings {
+		byName[setting.Name] = setting
+	}
+	return &Loader{settings: byName, sources: sources, read: map[string]bool{}}
+}
+
+// With returns a loader in which source replaces the source of the same
+// name, or takes precedence over all sources if there is none.
+func (l *Loader) With(source Source) *Loader {
+	sources := make([]Source, 0, len(l.sources)+1)
+	replaced := false
+	for _, s := range l.sources {
+		if s.Name == source.Name {
+			s, replaced = source, true
+		}
+		sources = append(sources, s)
+	}
+	if !replaced {
+		sources = append([]Source{source}, sources...)
+	}
+	return &Loader{settings: l.settings, sources: sources, read: map[string]bool{}}
+}
+
+// Lookup returns the value of the setting named name and the name of the
+// source it came from: "default" for the default of the setting, empty if
+// the setting has no value.
+func (l *Loader) Lookup(name string) (value, source string) {
+	l.mu.Lock()
+	l.read[name] = true
+	l.mu.Unlock()
+	for _, s := range l.sources {
+		if value, ok := s.Lookup(name); ok {
+			return value, s.Name
+		}
+	}
+	if setting, ok := l.settings[name]; ok && setting.Default != "" {
+		return setting.Default, "default"
+	}
+	return "", ""
+}
+
+// Getenv returns the value of the setting named name, empty if unset. It
+// stands in for os.Getenv.
+func (l *Loader) Getenv(name string) string {
+	value, _ := l.Lookup(name)
+	return value
+}
+
+// Effective returns the settings with a value, one "NAME=value (source)"
+// line each, sorted by name. Secrets are redacted.
+func (l *Loader) Effective() []string {
+	l.mu.Lock()
+	names := make([]string, 0, len(l.settings)+len(l.read))
+	for name := range l.settings {
+		names = append(names, name)
+	}
+	for name := range l.read {
+		if _, declared := l.settings[name]; !declared {
+			names = append(names, name)
+		}
+	}
+	l.mu.Unlock()
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		value, source := l.Lookup(name)
+		if value == "" {
+			continue
+		}
+		if l.secret(name) {
+			value = "<redacted>"
+		}
+		lines = append(lines, fmt.Sprintf("%s=%s (%s)", name, value, source))
+	}
+	return lines
+}
+
+// secret reports whether the value of the setting named name is redacted
+func (l *Loader) secret(name string) bool {
+	if setting, ok := l.settings[name]; ok && setting.Secret {
+		return true
+	}
+	for _, suffix := range secretSuffixes {
+		if name == suffix || strings.HasSuffix(name, "_"+suffix) {
+			return true
+		}
+	}
+	return false
+}
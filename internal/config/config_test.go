@@ -0,0 +1,146 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testSettings = Settings{
+	{Name: "GRACE_PERIOD", Usage: "Grace period", Required: true},
+	{Name: "ALLOWED_DOMAINS", Usage: "Allowed domains", Required: true},
+	{Name: "NAMESPACE_SELECTOR", Usage: "Selector", Default: "team"},
+	{Name: "NOTIFY_WEBHOOKS", Usage: "Webhooks", Secret: true},
+	{Name: "CLIENT_SECRET", Usage: "Secret"},
+}
+
+// writeFile writes a configuration file into a temporary directory
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoaderPrecedence validates that flags take precedence over the
+// environment, which takes precedence over the file and defaults
+func TestLoaderPrecedence(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := Flags(fs, testSettings)
+	if err := fs.Parse([]string{"--grace-period", "48h"}); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"GRACE_PERIOD": "72h", "ALLOWED_DOMAINS": "example.com", "CLIENT_SECRET": ""}
+	file, err := ReadFile(writeFile(t, "grace-period: 720h\nallowed-domains: [a.com, b.com]\nclient-secret: s3cret\nrun-slices: 4\n"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse([]string{"--notify-webhooks", "https://hooks.example.com"}); err == nil {
+		t.Error("Expected no flag for a secret setting")
+	}
+	loader := NewLoader(testSettings, flags, Env(func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}), file)
+
+	for _, tc := range []struct{ name, value, source string }{
+		{"GRACE_PERIOD", "48h", "flag"},
+		{"ALLOWED_DOMAINS", "example.com", "env"},
+		{"CLIENT_SECRET", "s3cret", "file"}, // Empty variables are unset
+		{"RUN_SLICES", "4", "file"},         // Undeclared settings are read too
+		{"NAMESPACE_SELECTOR", "team", "default"},
+		{"NOTIFY_WEBHOOKS", "", ""},
+	} {
+		if value, source := loader.Lookup(tc.name); value != tc.value || source != tc.source {
+			t.Errorf("Lookup(%s) = %q from %q, want %q from %q", tc.name, value, source, tc.value, tc.source)
+		}
+	}
+
+	override := loader.With(Values("file", map[string]string{"GRACE_PERIOD": "1h"}))
+	if value, source := override.Lookup("CLIENT_SECRET"); value != "" || source != "" {
+		t.Errorf("With should replace the source of the same name, got %q from %q", value, source)
+	}
+	if value := loader.With(Values("overlay", map[string]string{"GRACE_PERIOD": "1h"})).Getenv("GRACE_PERIOD"); value != "1h" {
+		t.Errorf("With should add new sources first, got %q", value)
+	}
+}
+
+// TestReadFile validates the values and keys accepted in files
+func TestReadFile(t *testing.T) {
+	file, err := ReadFile(writeFile(t, "send-reminders: true\nidentity-rate-limit: 2.5\ncontext-keys:\n  - team\n  - cost-center\nstate-namespace:\n"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	for name, want := range map[string]string{
+		"SEND_REMINDERS":      "true",
+		"IDENTITY_RATE_LIMIT": "2.5",
+		"CONTEXT_KEYS":        "team,cost-center",
+		"STATE_NAMESPACE":     "",
+	} {
+		if value, _ := file.Lookup(name); value != want {
+			t.Errorf("%s = %q, want %q", name, value, want)
+		}
+	}
+
+	for _, content := range []string{
+		"GRACE_PERIOD: 720h\n",
+		"grace-period: {days: 30}\n",
+		"context-keys: [[team]]\n",
+		"grace-period: [720h\n",
+	} {
+		if _, err := ReadFile(writeFile(t, content)); err == nil {
+			t.Errorf("Expected an error for %q", content)
+		}
+	}
+	if _, err := ReadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+// TestValidate validates that missing required settings are reported
+func TestValidate(t *testing.T) {
+	errs := testSettings.Validate(func(name string) string {
+		return map[string]string{"ALLOWED_DOMAINS": " "}[name]
+	})
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	want := []string{"GRACE_PERIOD: required", "ALLOWED_DOMAINS: required"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate = %q, want %q", got, want)
+	}
+}
+
+// TestEffective validates the printed configuration and its redaction
+func TestEffective(t *testing.T) {
+	loader := NewLoader(testSettings, Values("env", map[string]string{
+		"GRACE_PERIOD":                "720h",
+		"NOTIFY_WEBHOOKS":             "https://hooks.example.com/T0/B0/abc",
+		"CLIENT_SECRET":               "s3cret",
+		"AZURE_PARTNER_CLIENT_SECRET": "other",
+		"LDAP_BIND_PASSWORD":          "pw",
+		"UNREAD":                      "ignored",
+	}))
+	loader.Getenv("AZURE_PARTNER_CLIENT_SECRET")
+	loader.Getenv("LDAP_BIND_PASSWORD")
+
+	want := []string{
+		"AZURE_PARTNER_CLIENT_SECRET=<redacted> (env)",
+		"CLIENT_SECRET=<redacted> (env)",
+		"GRACE_PERIOD=720h (env)",
+		"LDAP_BIND_PASSWORD=<redacted> (env)",
+		"NAMESPACE_SELECTOR=team (default)",
+		"NOTIFY_WEBHOOKS=<redacted> (env)",
+	}
+	if got := loader.Effective(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Effective =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,53 @@
+// Package errs defines the error classes shared by the auditor packages.
+// Errors returned by the identity-provider clients and the processor wrap
+// one of these sentinels, so callers can react to the class of a failure
+// with errors.Is instead of matching messages.
+package errs
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrUserNotFound means the identity provider has no such user.
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrThrottled means the identity provider rejected a request because
+	// a rate limit was exceeded. The request may succeed later.
+	ErrThrottled = errors.New("throttled")
+
+	// ErrAuth means the auditor could not authenticate to the identity
+	// provider. It affects every request, not just the current one.
+	ErrAuth = errors.New("authentication failed")
+
+	// ErrPermission means the auditor authenticated but lacks the
+	// permissions required for the request. It affects every request.
+	ErrPermission = errors.New("permission denied")
+
+	// ErrConflict means a Kubernetes update lost a race with another
+	// writer. Retrying with a fresh copy of the object may succeed.
+	ErrConflict = errors.New("conflict")
+)
+
+// FromHTTPStatus returns the error class of an HTTP status code returned by
+// an identity provider: ErrThrottled for 429, ErrAuth for 401 and
+// ErrPermission for 403. Other codes have no class and return nil.
+func FromHTTPStatus(code int) error {
+	switch code {
+	case http.StatusTooManyRequests:
+		return ErrThrottled
+	case http.StatusUnauthorized:
+		return ErrAuth
+	case http.StatusForbidden:
+		return ErrPermission
+	default:
+		return nil
+	}
+}
+
+// Fatal reports whether err belongs to a class that makes every further
+// identity lookup fail, so that a run should stop instead of retrying.
+func Fatal(err error) bool {
+	return errors.Is(err, ErrAuth) || errors.Is(err, ErrPermission)
+}
@@ -0,0 +1,42 @@
+package errs
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestFatal validates which error classes end a run
+func TestFatal(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("token: %w", ErrAuth), true},
+		{fmt.Errorf("graph: %w", ErrPermission), true},
+		{fmt.Errorf("graph: %w", ErrThrottled), false},
+		{ErrUserNotFound, false},
+		{fmt.Errorf("plain error"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := Fatal(tt.err); got != tt.want {
+			t.Errorf("Fatal(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestFromHTTPStatus validates the error classes of HTTP status codes
+func TestFromHTTPStatus(t *testing.T) {
+	for code, want := range map[int]error{
+		http.StatusTooManyRequests:     ErrThrottled,
+		http.StatusUnauthorized:        ErrAuth,
+		http.StatusForbidden:           ErrPermission,
+		http.StatusNotFound:            nil,
+		http.StatusInternalServerError: nil,
+	} {
+		if got := FromHTTPStatus(code); got != want {
+			t.Errorf("FromHTTPStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// Package google validates namespace owners against the Google Workspace
+// Admin SDK Directory API, for Kubeflow deployments on GCP.
+package google
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+// ProviderName is the name the Google Workspace provider is registered under.
+const ProviderName = "google"
+
+// DirectoryScope is the OAuth2 scope the service account must be granted
+// through domain-wide delegation.
+const DirectoryScope = "https://www.googleapis.com/auth/admin.directory.user.readonly"
+
+// defaultBaseURL is the Admin SDK endpoint
+const defaultBaseURL = "https://admin.googleapis.com"
+
+// defaultTokenURL is used when the service account key names no token endpoint
+const defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+// requestTimeout bounds a single token or Directory API request
+const requestTimeout = 60 * time.Second
+
+func init() {
+	identity.Register(ProviderName, newProvider)
+}
+
+// ServiceAccountKey holds the fields of a service account JSON key file
+// needed to obtain access tokens.
+type ServiceAccountKey struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// ParseServiceAccountKey decodes a service account JSON key file and checks
+// that its private key can be used.
+func ParseServiceAccountKey(data []byte) (ServiceAccountKey, error) {
+	var key ServiceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return key, fmt.Errorf("invalid service account key: %w", err)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return key, errors.New("service account key lacks client_email or private_key")
+	}
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return key, errors.New("service account private_key is not PEM-encoded")
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		if _, err1 := x509.ParsePKCS1PrivateKey(block.Bytes); err1 != nil {
+			return key, fmt.Errorf("invalid service account private_key: %w", err)
+		}
+	}
+	return key, nil
+}
+
+// StatusError reports an unexpected Directory API response. Google signals
+// some rate limits with a 403 whose reason ends in "rateLimitExceeded".
+type StatusError struct {
+	StatusCode int    // HTTP status code returned by the Directory API
+	Reason     string // Reason of the first error in the response body, if any
+}
+
+// Error describes the unexpected response.
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("unexpected Directory API response: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Reason != "" {
+		msg += " (" + e.Reason + ")"
+	}
+	return msg
+}
+
+// Unwrap treats rate-limit reasons as errs.ErrThrottled whatever the status
+// code, and otherwise classifies the status code like errs.FromHTTPStatus.
+func (e *StatusError) Unwrap() error {
+	if strings.HasSuffix(strings.ToLower(e.Reason), "ratelimitexceeded") {
+		return errs.ErrThrottled
+	}
+	return errs.FromHTTPStatus(e.StatusCode)
+}
+
+// Client looks up users in a Google Workspace directory.
+type Client struct {
+	baseURL    string       // Admin SDK endpoint
+	httpClient *http.Client // Client adding access tokens to requests
+}
+
+// NewClient creates a client authenticating as the service account of key,
+// impersonating admin through domain-wide delegation. The service account
+// must be granted DirectoryScope in the Workspace admin console, and admin
+// must be allowed to read users.
+func NewClient(key ServiceAccountKey, admin string) *Client {
+	tokenURL := key.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+	config := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Subject:      admin,
+		Scopes:       []string{DirectoryScope},
+		TokenURL:     tokenURL,
+	}
+	base := &http.Client{Timeout: requestTimeout}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
+	httpClient := config.Client(ctx)
+	httpClient.Timeout = requestTimeout
+	return &Client{baseURL: defaultBaseURL, httpClient: httpClient}
+}
+
+// UserExists reports whether a user with the given primary email or alias
+// exists and may sign in. Suspended and archived users count as missing.
+func (c *Client) UserExists(ctx context.Context, email string) (bool, error) {
+	endpoint := c.baseURL + "/admin/directory/v1/users/" + url.PathEscape(email) + "?fields=suspended,archived"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		var retrieve *oauth2.RetrieveError
+		if errors.As(err, &retrieve) {
+			return false, fmt.Errorf("access token: %w", tokenError(retrieve))
+		}
+		return false, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, &StatusError{StatusCode: resp.StatusCode, Reason: errorReason(resp.Body)}
+	}
+
+	var user struct {
+		Suspended bool `json:"suspended"`
+		Archived  bool `json:"archived"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return false, fmt.Errorf("failed to decode Directory user: %w", err)
+	}
+	return !user.Suspended && !user.Archived, nil
+}
+
+// tokenError classifies a rejected token request. Rejected assertions and
+// missing delegation are authentication failures.
+func tokenError(err *oauth2.RetrieveError) error {
+	status := http.StatusUnauthorized
+	if err.Response != nil && (err.Response.StatusCode == http.StatusTooManyRequests || err.Response.StatusCode >= 500) {
+		status = err.Response.StatusCode
+	}
+	reason := err.ErrorCode
+	if reason == "" {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(err.Body, &body) == nil {
+			reason = body.Error
+		}
+	}
+	return &StatusError{StatusCode: status, Reason: reason}
+}
+
+// errorReason extracts the reason of the first error in a Google API error body
+func errorReason(body io.Reader) string {
+	var apiErr struct {
+		Error struct {
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	if json.NewDecoder(io.LimitReader(body, 64<<10)).Decode(&apiErr) != nil || len(apiErr.Error.Errors) == 0 {
+		return ""
+	}
+	return apiErr.Error.Errors[0].Reason
+}
+
+// newProvider creates a Client from GOOGLE_APPLICATION_CREDENTIALS, the path
+// of a service account JSON key, and GOOGLE_ADMIN_EMAIL, the Workspace
+// administrator to impersonate.
+func newProvider(getenv func(string) string) (identity.Provider, error) {
+	var problems []error
+	var key ServiceAccountKey
+	path := getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		problems = append(problems, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS: required, path of a service account JSON key"))
+	} else if data, err := os.ReadFile(path); err != nil {
+		problems = append(problems, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS: %w", err))
+	} else if key, err = ParseServiceAccountKey(data); err != nil {
+		problems = append(problems, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS: %w", err))
+	}
+
+	admin := getenv("GOOGLE_ADMIN_EMAIL")
+	if !strings.Contains(admin, "@") {
+		problems = append(problems, fmt.Errorf("GOOGLE_ADMIN_EMAIL: required for domain-wide delegation, got %q", admin))
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	return NewClient(key, admin), nil
+}
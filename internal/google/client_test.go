@@ -0,0 +1,142 @@
+package google
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeyFile returns a service account JSON key using tokenURI
+func testKeyFile(t *testing.T, tokenURI string) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "auditor@project.iam.gserviceaccount.com",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"private_key_id": "key-1",
+		"token_uri":      tokenURI,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+// TestUserExists validates user lookups against a mock Directory API
+func TestUserExists(t *testing.T) {
+	var tokenRequests int
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"access-token","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		require.Equal(t, "Bearer access-token", r.Header.Get("Authorization"))
+		switch strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/users/") {
+		case "active@example.com":
+			w.Write([]byte(`{"suspended":false}`))
+		case "suspended@example.com":
+			w.Write([]byte(`{"suspended":true}`))
+		case "archived@example.com":
+			w.Write([]byte(`{"archived":true}`))
+		case "busy@example.com":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":403,"errors":[{"reason":"userRateLimitExceeded"}]}}`))
+		case "denied@example.com":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":403,"errors":[{"reason":"forbidden"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	key, err := ParseServiceAccountKey(testKeyFile(t, testServer.URL+"/token"))
+	require.NoError(t, err)
+	client := NewClient(key, "admin@example.com")
+	client.baseURL = testServer.URL
+
+	tests := []struct {
+		email   string
+		exists  bool
+		errKind error
+	}{
+		{email: "active@example.com", exists: true},
+		{email: "suspended@example.com"},
+		{email: "archived@example.com"},
+		{email: "missing@example.com"},
+		{email: "busy@example.com", errKind: errs.ErrThrottled},
+		{email: "denied@example.com", errKind: errs.ErrPermission},
+	}
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			exists, err := client.UserExists(context.Background(), tt.email)
+			if tt.errKind != nil {
+				require.ErrorIs(t, err, tt.errKind)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.exists, exists)
+		})
+	}
+	require.Equal(t, 1, tokenRequests, "access token should be reused")
+}
+
+// TestUserExistsDelegationDenied validates that a token request rejected for
+// missing domain-wide delegation is an authentication failure
+func TestUserExistsDelegationDenied(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"unauthorized_client","error_description":"Client is unauthorized to retrieve access tokens"}`))
+	}))
+	defer testServer.Close()
+
+	key, err := ParseServiceAccountKey(testKeyFile(t, testServer.URL+"/token"))
+	require.NoError(t, err)
+	client := NewClient(key, "admin@example.com")
+	client.baseURL = testServer.URL
+
+	_, err = client.UserExists(context.Background(), "user@example.com")
+	require.ErrorIs(t, err, errs.ErrAuth)
+	require.ErrorContains(t, err, "unauthorized_client")
+}
+
+// TestProviderRegistration validates creating the client through the identity registry
+func TestProviderRegistration(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(keyFile, testKeyFile(t, ""), 0o600))
+	env := map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": keyFile, "GOOGLE_ADMIN_EMAIL": "admin@example.com"}
+	getenv := func(key string) string { return env[key] }
+
+	provider, err := identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+	require.IsType(t, &Client{}, provider)
+
+	require.NoError(t, os.WriteFile(keyFile, []byte(`{"client_email":"a@b","private_key":"garbage"}`), 0o600))
+	delete(env, "GOOGLE_ADMIN_EMAIL")
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "GOOGLE_APPLICATION_CREDENTIALS: service account private_key is not PEM-encoded")
+	require.ErrorContains(t, err, "GOOGLE_ADMIN_EMAIL: required")
+
+	delete(env, "GOOGLE_APPLICATION_CREDENTIALS")
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "GOOGLE_APPLICATION_CREDENTIALS: required")
+}
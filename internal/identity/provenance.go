@@ -0,0 +1,38 @@
+package identity
+
+import "strings"
+
+// Lookup methods, recorded in Provenance.Method, telling how a provider
+// matched an owner email to a directory entry.
+const (
+	LookupUPN      = "upn"      // By user principal name
+	LookupMail     = "mail"     // By the mail attribute
+	LookupObjectID = "objectId" // By directory object ID
+)
+
+// Provenance records which provider, directory endpoint and lookup method
+// produced an identity verdict, so that contradictory verdicts of
+// deployments using several providers or tenants can be traced back to
+// their source. Fields a provider cannot tell are left empty.
+type Provenance struct {
+	Provider string `json:"provider"`           // Provider name, e.g. "azure", or "staff/azure" for a chain member
+	Endpoint string `json:"endpoint,omitempty"` // Directory endpoint queried, e.g. "https://graph.microsoft.com"
+	Method   string `json:"method,omitempty"`   // How the user was matched, e.g. LookupUPN
+}
+
+// String formats the provenance as space-separated key=value pairs, e.g.
+// "provider=azure endpoint=https://graph.microsoft.com method=upn", leaving
+// out empty fields. The zero Provenance formats as the empty string.
+func (p Provenance) String() string {
+	var fields []string
+	for _, f := range []struct{ key, value string }{
+		{"provider", p.Provider},
+		{"endpoint", p.Endpoint},
+		{"method", p.Method},
+	} {
+		if f.value != "" {
+			fields = append(fields, f.key+"="+f.value)
+		}
+	}
+	return strings.Join(fields, " ")
+}
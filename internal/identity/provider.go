@@ -0,0 +1,71 @@
+// Package identity defines the interface owner validation is performed
+// through and a registry of the available identity providers, selected by
+// name (e.g. IDENTITY_PROVIDER=azure).
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultProvider is the provider used when none is configured.
+const DefaultProvider = "azure"
+
+// ErrUnknownProvider is returned by New for names that were never registered.
+var ErrUnknownProvider = errors.New("unknown identity provider")
+
+// Provider checks whether namespace owners exist in an identity backend.
+// Errors should be classified with the errs package (errs.ErrThrottled,
+// errs.ErrAuth, errs.ErrPermission) so that callers can back off or abort.
+type Provider interface {
+	UserExists(ctx context.Context, email string) (bool, error)
+}
+
+// Factory creates a provider from its settings, looked up by name with
+// getenv. It reports every missing or invalid setting, each prefixed with
+// the name of the setting.
+type Factory func(getenv func(string) string) (Provider, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{}
+)
+
+// Register makes a provider available under name. It is meant to be called
+// from the init function of the package implementing the provider, and
+// panics if name is already taken.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, taken := factories[name]; taken {
+		panic(fmt.Sprintf("identity provider %q registered twice", name))
+	}
+	factories[name] = factory
+}
+
+// New creates the provider registered under name.
+func New(name string, getenv func(string) string) (Provider, error) {
+	mu.RLock()
+	factory, ok := factories[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q, expected one of %s", ErrUnknownProvider, name, strings.Join(Names(), ", "))
+	}
+	return factory(getenv)
+}
+
+// Names returns the names of the registered providers, sorted.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
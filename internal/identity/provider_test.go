@@ -0,0 +1,51 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// staticProvider reports every user as existing
+type staticProvider struct{}
+
+// UserExists implements Provider
+func (staticProvider) UserExists(ctx context.Context, email string) (bool, error) {
+	return true, nil
+}
+
+// TestRegistry validates provider registration and lookup
+func TestRegistry(t *testing.T) {
+	Register("static-test", func(getenv func(string) string) (Provider, error) {
+		if getenv("STATIC_TOKEN") == "" {
+			return nil, errors.New("STATIC_TOKEN: required")
+		}
+		return staticProvider{}, nil
+	})
+
+	env := map[string]string{"STATIC_TOKEN": "secret"}
+	p, err := New("static-test", func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if exists, _ := p.UserExists(context.Background(), "user@example.com"); !exists {
+		t.Error("Expected the registered provider")
+	}
+
+	if _, err := New("static-test", func(string) string { return "" }); err == nil || !strings.Contains(err.Error(), "STATIC_TOKEN") {
+		t.Errorf("Expected the factory's settings error, got %v", err)
+	}
+
+	_, err = New("nope", func(string) string { return "" })
+	if !errors.Is(err, ErrUnknownProvider) || !strings.Contains(err.Error(), "static-test") {
+		t.Errorf("Expected unknown provider error listing registered names, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Duplicate registration should panic")
+		}
+	}()
+	Register("static-test", nil)
+}
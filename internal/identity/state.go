@@ -0,0 +1,18 @@
+package identity
+
+// UserState is the account state of a user, for providers able to tell
+// disabled and soft-deleted accounts apart from active and missing ones.
+type UserState string
+
+const (
+	UserActive    UserState = "active"     // Account exists and may sign in
+	UserDisabled  UserState = "disabled"   // Account exists but sign-in is blocked
+	UserDeleted   UserState = "deleted"    // Account was deleted but can still be restored
+	UserNotFound  UserState = "not-found"  // No such account
+	UserNotMember UserState = "not-member" // Account exists but lacks a required group membership
+)
+
+// Exists reports whether the account exists, enabled or not.
+func (s UserState) Exists() bool {
+	return s == UserActive || s == UserDisabled
+}
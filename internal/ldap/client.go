@@ -0,0 +1,257 @@
+// Package ldap validates namespace owners against an LDAP directory such as
+// an on-premises Active Directory, for clusters that cannot reach a cloud
+// identity provider.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// ProviderName is the name the LDAP provider is registered under.
+const ProviderName = "ldap"
+
+// EmailPlaceholder is replaced in the user filter with the escaped owner email
+const EmailPlaceholder = "{email}"
+
+// DefaultUserFilter finds enabled Active Directory users by mail address or
+// user principal name.
+const DefaultUserFilter = "(&(objectClass=user)(|(mail={email})(userPrincipalName={email}))" +
+	"(!(userAccountControl:1.2.840.113556.1.4.803:=2)))"
+
+// requestTimeout bounds connecting, binding and searching for one lookup
+const requestTimeout = 30 * time.Second
+
+func init() {
+	identity.Register(ProviderName, newProvider)
+}
+
+// Config describes how to reach and search the directory.
+type Config struct {
+	URL          string      // Directory URL, ldaps://host:636 or ldap://host:389
+	StartTLS     bool        // Upgrade ldap:// connections with StartTLS
+	TLS          *tls.Config // TLS settings for ldaps:// and StartTLS, system roots if nil
+	BindDN       string      // DN of the service account to bind as
+	BindPassword string      // Password of the service account
+	BaseDN       string      // Subtree searched for users
+	UserFilter   string      // Search filter containing EmailPlaceholder, DefaultUserFilter if empty
+}
+
+// conn is the part of an LDAP connection used for lookups
+type conn interface {
+	Bind(username, password string) error
+	Search(request *goldap.SearchRequest) (*goldap.SearchResult, error)
+	Close() error
+}
+
+// Client looks up users in an LDAP directory. Each lookup uses its own
+// connection, so that concurrent lookups do not contend for one.
+type Client struct {
+	config Config
+	dial   func(ctx context.Context) (conn, error)
+}
+
+// NewClient creates a client for the directory described by config.
+func NewClient(config Config) *Client {
+	if config.UserFilter == "" {
+		config.UserFilter = DefaultUserFilter
+	}
+	c := &Client{config: config}
+	c.dial = c.dialDirectory
+	return c
+}
+
+// UserExists reports whether the user filter matches an entry for email
+// below the base DN.
+func (c *Client) UserExists(ctx context.Context, email string) (bool, error) {
+	l, err := c.dial(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to %s: %w", c.config.URL, err)
+	}
+	defer l.Close()
+
+	if err := l.Bind(c.config.BindDN, c.config.BindPassword); err != nil {
+		return false, fmt.Errorf("failed to bind as %s: %w", c.config.BindDN, classify(err))
+	}
+
+	result, err := l.Search(goldap.NewSearchRequest(
+		c.config.BaseDN,
+		goldap.ScopeWholeSubtree,
+		goldap.NeverDerefAliases,
+		1, // Any match is enough
+		int(requestTimeout/time.Second),
+		false,
+		userFilter(c.config.UserFilter, email),
+		[]string{"dn"},
+		nil,
+	))
+	if goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+		return true, nil // More than one entry matched
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to search for %s: %w", email, classify(err))
+	}
+	return len(result.Entries) > 0, nil
+}
+
+// dialDirectory connects to the directory, upgrading to TLS if configured
+func (c *Client) dialDirectory(ctx context.Context) (conn, error) {
+	timeout := requestTimeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	tlsConfig := c.config.TLS
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+
+	l, err := goldap.DialURL(c.config.URL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		goldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	l.SetTimeout(timeout)
+	if c.config.StartTLS {
+		if err := l.StartTLS(tlsConfig); err != nil {
+			l.Close()
+			return nil, fmt.Errorf("StartTLS failed: %w", err)
+		}
+	}
+	return l, nil
+}
+
+// userFilter substitutes the escaped email into filter, so that an owner
+// annotation cannot alter the search
+func userFilter(filter, email string) string {
+	return strings.ReplaceAll(filter, EmailPlaceholder, goldap.EscapeFilter(email))
+}
+
+// ResultError reports an LDAP operation rejected by the directory. Invalid
+// credentials, insufficient access rights and busy or unavailable servers
+// are classified like the HTTP status codes of other providers, see
+// httpStatus.
+type ResultError struct {
+	Err error // Error returned by the LDAP library
+}
+
+// Error describes the rejected operation.
+func (e *ResultError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error class of the result code, if any, and the
+// underlying error.
+func (e *ResultError) Unwrap() []error {
+	if class := errs.FromHTTPStatus(httpStatus(e.Err)); class != nil {
+		return []error{class, e.Err}
+	}
+	return []error{e.Err}
+}
+
+// httpStatus returns the HTTP status code matching the LDAP result code of
+// err, or 0 if there is none
+func httpStatus(err error) int {
+	switch {
+	case goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials):
+		return http.StatusUnauthorized
+	case goldap.IsErrorWithCode(err, goldap.LDAPResultInsufficientAccessRights):
+		return http.StatusForbidden
+	case goldap.IsErrorWithCode(err, goldap.LDAPResultBusy), goldap.IsErrorWithCode(err, goldap.LDAPResultUnavailable):
+		return http.StatusTooManyRequests
+	default:
+		return 0
+	}
+}
+
+// classify wraps err in a ResultError
+func classify(err error) error {
+	return &ResultError{Err: err}
+}
+
+// newProvider creates a Client from the LDAP_* settings: LDAP_URL,
+// LDAP_BIND_DN, LDAP_BIND_PASSWORD and LDAP_BASE_DN are required, while
+// LDAP_USER_FILTER, LDAP_START_TLS and LDAP_CA_FILE are optional.
+func newProvider(getenv func(string) string) (identity.Provider, error) {
+	var problems []error
+	config := Config{
+		URL:          getenv("LDAP_URL"),
+		BindDN:       getenv("LDAP_BIND_DN"),
+		BindPassword: getenv("LDAP_BIND_PASSWORD"),
+		BaseDN:       getenv("LDAP_BASE_DN"),
+		UserFilter:   getenv("LDAP_USER_FILTER"),
+	}
+
+	u, err := url.Parse(config.URL)
+	if config.URL == "" || err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		problems = append(problems, fmt.Errorf("LDAP_URL: required, expected e.g. ldaps://dc.example.com:636, got %q", config.URL))
+	}
+	for _, name := range []string{"LDAP_BIND_DN", "LDAP_BIND_PASSWORD", "LDAP_BASE_DN"} {
+		if getenv(name) == "" {
+			problems = append(problems, fmt.Errorf("%s: required for LDAP authentication", name))
+		}
+	}
+
+	if config.UserFilter != "" {
+		if !strings.Contains(config.UserFilter, EmailPlaceholder) {
+			problems = append(problems, fmt.Errorf("LDAP_USER_FILTER: must contain %s", EmailPlaceholder))
+		} else if _, err := goldap.CompileFilter(userFilter(config.UserFilter, "user@example.com")); err != nil {
+			problems = append(problems, fmt.Errorf("LDAP_USER_FILTER: %w", err))
+		}
+	}
+
+	if value := getenv("LDAP_START_TLS"); value != "" {
+		startTLS, err := strconv.ParseBool(value)
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Errorf("LDAP_START_TLS: expected true or false, got %q", value))
+		case startTLS && u != nil && u.Scheme == "ldaps":
+			problems = append(problems, fmt.Errorf("LDAP_START_TLS: cannot be used with an ldaps:// URL"))
+		}
+		config.StartTLS = startTLS
+	}
+
+	if path := getenv("LDAP_CA_FILE"); path != "" {
+		pool, err := loadCAFile(path)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("LDAP_CA_FILE: %w", err))
+		}
+		config.TLS = &tls.Config{RootCAs: pool}
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	if u.Scheme == "ldap" && !config.StartTLS {
+		// Simple binds over plain LDAP expose the service account password
+		return nil, fmt.Errorf("LDAP_URL: ldap:// requires LDAP_START_TLS=true, use ldaps:// otherwise")
+	}
+	return NewClient(config), nil
+}
+
+// loadCAFile reads PEM-encoded certificates trusted for the directory
+func loadCAFile(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,137 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn simulates a directory holding the given user entries
+type fakeConn struct {
+	bindErr error
+	entries map[string]int // Matching entries per search filter
+	filters []string       // Filters searched, in order
+	closed  bool
+}
+
+func (f *fakeConn) Bind(string, string) error { return f.bindErr }
+
+func (f *fakeConn) Search(request *goldap.SearchRequest) (*goldap.SearchResult, error) {
+	f.filters = append(f.filters, request.Filter)
+	n := f.entries[request.Filter]
+	if request.SizeLimit > 0 && n > request.SizeLimit {
+		return nil, goldap.NewError(goldap.LDAPResultSizeLimitExceeded, errors.New("size limit exceeded"))
+	}
+	result := &goldap.SearchResult{}
+	for i := 0; i < n; i++ {
+		result.Entries = append(result.Entries, &goldap.Entry{DN: "cn=user"})
+	}
+	return result, nil
+}
+
+func (f *fakeConn) Close() error {
+	f.closed = true
+	return nil
+}
+
+// newTestClient returns a client whose connections go to directory
+func newTestClient(directory *fakeConn) *Client {
+	c := NewClient(Config{URL: "ldaps://dc.example.com", BaseDN: "dc=example,dc=com", UserFilter: "(mail={email})"})
+	c.dial = func(context.Context) (conn, error) { return directory, nil }
+	return c
+}
+
+// TestUserExists validates lookups against a fake directory
+func TestUserExists(t *testing.T) {
+	directory := &fakeConn{entries: map[string]int{
+		"(mail=user@example.com)":   1,
+		"(mail=shared@example.com)": 2,
+	}}
+	client := newTestClient(directory)
+
+	for email, want := range map[string]bool{
+		"user@example.com":    true,
+		"shared@example.com":  true,
+		"missing@example.com": false,
+	} {
+		exists, err := client.UserExists(context.Background(), email)
+		require.NoError(t, err)
+		require.Equal(t, want, exists, email)
+	}
+	require.True(t, directory.closed)
+}
+
+// TestUserFilterEscapesEmail ensures an owner annotation cannot alter the search filter
+func TestUserFilterEscapesEmail(t *testing.T) {
+	directory := &fakeConn{}
+	client := newTestClient(directory)
+
+	_, err := client.UserExists(context.Background(), "*)(mail=*")
+	require.NoError(t, err)
+	require.Equal(t, []string{`(mail=\2a\29\28mail=\2a)`}, directory.filters)
+}
+
+// TestUserExistsErrors validates the classification of directory errors
+func TestUserExistsErrors(t *testing.T) {
+	tests := map[uint16]error{
+		goldap.LDAPResultInvalidCredentials:       errs.ErrAuth,
+		goldap.LDAPResultInsufficientAccessRights: errs.ErrPermission,
+		goldap.LDAPResultBusy:                     errs.ErrThrottled,
+		goldap.LDAPResultUnavailable:              errs.ErrThrottled,
+	}
+	for code, want := range tests {
+		client := newTestClient(&fakeConn{bindErr: goldap.NewError(code, errors.New("rejected"))})
+		_, err := client.UserExists(context.Background(), "user@example.com")
+		require.ErrorIs(t, err, want)
+	}
+
+	client := newTestClient(&fakeConn{bindErr: goldap.NewError(goldap.LDAPResultOther, errors.New("rejected"))})
+	_, err := client.UserExists(context.Background(), "user@example.com")
+	require.Error(t, err)
+	require.False(t, errors.Is(err, errs.ErrAuth) || errors.Is(err, errs.ErrPermission) || errors.Is(err, errs.ErrThrottled))
+}
+
+// TestProviderRegistration validates creating the client through the identity registry
+func TestProviderRegistration(t *testing.T) {
+	env := map[string]string{
+		"LDAP_URL":           "ldaps://dc.example.com:636",
+		"LDAP_BIND_DN":       "cn=auditor,ou=services,dc=example,dc=com",
+		"LDAP_BIND_PASSWORD": "secret",
+		"LDAP_BASE_DN":       "ou=people,dc=example,dc=com",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	provider, err := identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+	require.Equal(t, DefaultUserFilter, provider.(*Client).config.UserFilter)
+
+	env["LDAP_URL"] = "ldap://dc.example.com"
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "requires LDAP_START_TLS=true")
+	env["LDAP_START_TLS"] = "true"
+	_, err = identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+	env["LDAP_URL"] = "https://dc.example.com"
+	env["LDAP_USER_FILTER"] = "(mail=user)"
+	env["LDAP_CA_FILE"] = caFile
+	delete(env, "LDAP_BIND_PASSWORD")
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "LDAP_URL: required")
+	require.ErrorContains(t, err, "LDAP_BIND_PASSWORD: required")
+	require.ErrorContains(t, err, "LDAP_USER_FILTER: must contain {email}")
+	require.ErrorContains(t, err, "LDAP_CA_FILE: no PEM certificates")
+
+	env["LDAP_USER_FILTER"] = "(mail={email}"
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "LDAP_USER_FILTER:")
+}
@@ -0,0 +1,278 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+)
+
+// Mailer sends plain-text email. It is implemented by SMTPMailer and by
+// the Microsoft Graph clients of the azure package.
+type Mailer interface {
+	SendMail(ctx context.Context, from string, to, cc []string, subject, body string) error
+}
+
+// DefaultSMTPPort is the submission port used unless configured otherwise
+const DefaultSMTPPort = 587
+
+// SMTPMailer sends email through an SMTP relay, upgrading the connection
+// with STARTTLS whenever the relay offers it.
+type SMTPMailer struct {
+	host string
+	port int
+	auth smtp.Auth // Nil to send without authentication
+}
+
+// NewSMTPMailer creates a mailer relaying through host:port. Without a
+// username mail is sent unauthenticated, e.g. to an internal relay.
+// Credentials are only sent over TLS.
+func NewSMTPMailer(host string, port int, username, password string) *SMTPMailer {
+	m := &SMTPMailer{host: host, port: port}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// SendMail sends a plain-text message to the to and cc recipients.
+func (m *SMTPMailer) SendMail(ctx context.Context, from string, to, cc []string, subject, body string) error {
+	dialer := net.Dialer{Timeout: requestTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.host, fmt.Sprint(m.port)))
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range append(append([]string{}, to...), cc...) {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(formatMessage(from, to, cc, subject, body, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// formatMessage renders a plain-text RFC 5322 message
+func formatMessage(from string, to, cc []string, subject, body string, date time.Time) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	if len(cc) > 0 {
+		fmt.Fprintf(&msg, "Cc: %s\r\n", strings.Join(cc, ", "))
+	}
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return msg.Bytes()
+}
+
+// EmailTemplate renders the subject and body of the email sent for an event
+type EmailTemplate struct {
+	Subject *template.Template
+	Body    *template.Template
+}
+
+// EmailTemplates holds the email template of each event. Events without a
+// template are not emailed.
+type EmailTemplates map[auditor.NotificationEvent]EmailTemplate
+
+// defaultEmailTemplates are the subjects and bodies emailed to owners unless
+// replaced, see LoadEmailTemplates
+var defaultEmailTemplates = map[auditor.NotificationEvent][2]string{
+	auditor.EventMarked: {
+		`Namespace {{.Namespace}} is scheduled for deletion`,
+		`Hello,
+
+The Kubernetes namespace {{.Namespace}} is scheduled for deletion on {{.DeleteAt.UTC.Format "Monday, January 2, 2006 at 15:04 MST"}}.
+
+{{.Message}}.
+{{if .Manager}}
+You are copied as the manager of the owner, {{.Owner}}.
+{{end}}
+If you still need this namespace, ask a cluster administrator to transfer it to a current owner before that date. Otherwise no action is needed.
+`,
+	},
+	auditor.EventReminder: {
+		`Reminder: namespace {{.Namespace}} will be deleted on {{.DeleteAt.UTC.Format "January 2"}}`,
+		`Hello,
+
+This is a reminder that the Kubernetes namespace {{.Namespace}} will be deleted on {{.DeleteAt.UTC.Format "Monday, January 2, 2006 at 15:04 MST"}}.
+
+{{.Message}}.
+
+If you still need this namespace, ask a cluster administrator to transfer it to a current owner before that date.
+`,
+	},
+	auditor.EventFinalReminder: {
+		`Final notice: namespace {{.Namespace}} will be deleted within 24 hours`,
+		`Hello,
+
+The Kubernetes namespace {{.Namespace}} will be deleted on {{.DeleteAt.UTC.Format "Monday, January 2, 2006 at 15:04 MST"}}, together with everything it contains.
+
+{{.Message}}.
+
+This is the last notice before deletion.
+`,
+	},
+}
+
+// DefaultEmailTemplates returns the built-in templates, emailing owners
+// when their namespace is marked, halfway through the grace period and
+// before deletion.
+func DefaultEmailTemplates() EmailTemplates {
+	templates := make(EmailTemplates, len(defaultEmailTemplates))
+	for event, text := range defaultEmailTemplates {
+		templates[event] = EmailTemplate{
+			Subject: template.Must(ParseTemplate(text[0])),
+			Body:    template.Must(ParseTemplate(text[1])),
+		}
+	}
+	return templates
+}
+
+// LoadEmailTemplates returns the default templates, replaced or extended by
+// the files of dir, e.g. a mounted ConfigMap. Files are named after the
+// event and the part they render, such as "marked.subject" or
+// "deleted.body". Events without a default template need both parts.
+func LoadEmailTemplates(dir string) (EmailTemplates, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	templates := DefaultEmailTemplates()
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "..") || entry.IsDir() {
+			continue // Internals of mounted ConfigMaps
+		}
+		event, part, _ := strings.Cut(name, ".")
+		if part != "subject" && part != "body" {
+			return nil, fmt.Errorf("unexpected file %q, expected <event>.subject or <event>.body", name)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := ParseTemplate(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		t := templates[auditor.NotificationEvent(event)]
+		if part == "subject" {
+			t.Subject = tmpl
+		} else {
+			t.Body = tmpl
+		}
+		templates[auditor.NotificationEvent(event)] = t
+	}
+	for event, t := range templates {
+		if t.Subject == nil || t.Body == nil {
+			return nil, fmt.Errorf("event %q needs both %s.subject and %s.body", event, event, event)
+		}
+	}
+	return templates, nil
+}
+
+// EmailNotifier emails namespace owners, with their secondary contact and
+// manager in copy, about the events it has a template for.
+type EmailNotifier struct {
+	mailer    Mailer
+	from      string
+	templates EmailTemplates
+}
+
+// NewEmailNotifier creates a notifier sending email from the from address
+// through mailer, rendered with templates or DefaultEmailTemplates if nil.
+func NewEmailNotifier(mailer Mailer, from string, templates EmailTemplates) *EmailNotifier {
+	if templates == nil {
+		templates = DefaultEmailTemplates()
+	}
+	return &EmailNotifier{mailer: mailer, from: from, templates: templates}
+}
+
+// Notify emails the owner, secondary contact and owner's manager of the
+// namespace. Events without a template and namespaces without a valid
+// address are skipped.
+func (e *EmailNotifier) Notify(ctx context.Context, n auditor.Notification) error {
+	tmpl, ok := e.templates[n.Event]
+	if !ok {
+		return nil
+	}
+	to, cc := emailAddresses(n.Owner), append(emailAddresses(n.Contact), emailAddresses(n.Manager)...)
+	if len(to) == 0 && len(cc) > 0 {
+		to, cc = cc[:1], cc[1:]
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	msg := Message{Notification: n, Severity: SeverityOf(n.Event)}
+	var subject, body bytes.Buffer
+	if err := tmpl.Subject.Execute(&subject, msg); err != nil {
+		return fmt.Errorf("rendering %s email subject: %w", n.Event, err)
+	}
+	if err := tmpl.Body.Execute(&body, msg); err != nil {
+		return fmt.Errorf("rendering %s email body: %w", n.Event, err)
+	}
+	if err := e.mailer.SendMail(ctx, e.from, to, cc, strings.TrimSpace(subject.String()), body.String()); err != nil {
+		return fmt.Errorf("emailing %s: %w", strings.Join(append(to, cc...), ", "), err)
+	}
+	return nil
+}
+
+// emailAddresses returns the bare address of value, nothing if it is not
+// a valid email address, e.g. an empty owner or a service account
+func emailAddresses(value string) []string {
+	if value == "" {
+		return nil
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil {
+		return nil
+	}
+	return []string{addr.Address}
+}
@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	"github.com/stretchr/testify/require"
+)
+
+// sentMail is a message received by recordingMailer
+type sentMail struct {
+	from          string
+	to, cc        []string
+	subject, body string
+}
+
+// recordingMailer collects sent messages for test validation
+type recordingMailer struct {
+	sent []sentMail
+	err  error
+}
+
+func (m *recordingMailer) SendMail(_ context.Context, from string, to, cc []string, subject, body string) error {
+	m.sent = append(m.sent, sentMail{from, to, cc, subject, body})
+	return m.err
+}
+
+// TestEmailNotifier validates who is emailed about which events
+func TestEmailNotifier(t *testing.T) {
+	mailer := &recordingMailer{}
+	notifier := NewEmailNotifier(mailer, "auditor@example.com", nil)
+	deleteAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, notifier.Notify(context.TODO(), auditor.Notification{
+		Event: auditor.EventMarked, Namespace: "team-a", Owner: "alice@example.com", Contact: "Lead <lead@example.com>",
+		Message: "owner alice@example.com could not be validated", DeleteAt: deleteAt,
+	}))
+	require.Len(t, mailer.sent, 1)
+	mail := mailer.sent[0]
+	require.Equal(t, "auditor@example.com", mail.from)
+	require.Equal(t, []string{"alice@example.com"}, mail.to)
+	require.Equal(t, []string{"lead@example.com"}, mail.cc)
+	require.Equal(t, "Namespace team-a is scheduled for deletion", mail.subject)
+	require.Contains(t, mail.body, "Wednesday, May 1, 2024 at 12:00 UTC")
+
+	// Without a valid owner the contact is the recipient
+	require.NoError(t, notifier.Notify(context.TODO(), auditor.Notification{
+		Event: auditor.EventFinalReminder, Namespace: "team-b", Owner: "system:serviceaccount:ci:bot", Contact: "lead@example.com", DeleteAt: deleteAt,
+	}))
+	require.Len(t, mailer.sent, 2)
+	require.Equal(t, []string{"lead@example.com"}, mailer.sent[1].to)
+	require.Empty(t, mailer.sent[1].cc)
+
+	// The manager of the owner is copied and named in the body
+	require.NoError(t, notifier.Notify(context.TODO(), auditor.Notification{
+		Event: auditor.EventMarked, Namespace: "team-c", Owner: "carol@example.com", Manager: "boss@example.com", DeleteAt: deleteAt,
+	}))
+	require.Equal(t, []string{"carol@example.com"}, mailer.sent[2].to)
+	require.Equal(t, []string{"boss@example.com"}, mailer.sent[2].cc)
+	require.Contains(t, mailer.sent[2].body, "manager of the owner, carol@example.com")
+	require.NotContains(t, mailer.sent[0].body, "manager of the owner")
+
+	// Events without a template and namespaces without an address are skipped
+	require.NoError(t, notifier.Notify(context.TODO(), auditor.Notification{Event: auditor.EventDeleted, Namespace: "team-a", Owner: "alice@example.com"}))
+	require.NoError(t, notifier.Notify(context.TODO(), auditor.Notification{Event: auditor.EventOwnerlessMarked, Namespace: "orphan"}))
+	require.Len(t, mailer.sent, 3)
+
+	mailer.err = errors.New("relay unavailable")
+	err := notifier.Notify(context.TODO(), auditor.Notification{Event: auditor.EventReminder, Namespace: "team-a", Owner: "alice@example.com", DeleteAt: deleteAt})
+	require.ErrorContains(t, err, "alice@example.com")
+}
+
+// TestLoadEmailTemplates validates templates read from a mounted ConfigMap
+func TestLoadEmailTemplates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..2024_05_01"), 0o755))
+	write("marked.subject", "[{{.Severity}}] {{.Namespace}} marked")
+	write("deleted.subject", "{{.Namespace}} deleted")
+	write("deleted.body", "{{.Namespace}} was deleted.")
+
+	templates, err := LoadEmailTemplates(dir)
+	require.NoError(t, err)
+	mailer := &recordingMailer{}
+	notifier := NewEmailNotifier(mailer, "auditor@example.com", templates)
+	for _, event := range []auditor.NotificationEvent{auditor.EventMarked, auditor.EventDeleted, auditor.EventReminder} {
+		require.NoError(t, notifier.Notify(context.TODO(), auditor.Notification{Event: event, Namespace: "team-a", Owner: "alice@example.com", DeleteAt: time.Now()}))
+	}
+	require.Len(t, mailer.sent, 3)
+	require.Equal(t, "[warning] team-a marked", mailer.sent[0].subject)
+	require.Contains(t, mailer.sent[0].body, "scheduled for deletion", "the default body is kept")
+	require.Equal(t, "team-a was deleted.", mailer.sent[1].body)
+
+	write("stuck-terminating.subject", "stuck")
+	_, err = LoadEmailTemplates(dir)
+	require.ErrorContains(t, err, "stuck-terminating.body")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "stuck-terminating.subject")))
+	write("marked.txt", "")
+	_, err = LoadEmailTemplates(dir)
+	require.ErrorContains(t, err, "marked.txt")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "marked.txt")))
+	write("marked.body", "{{.Deadline}}")
+	_, err = LoadEmailTemplates(dir)
+	require.ErrorContains(t, err, "marked.body")
+}
+
+// TestSMTPMailer validates the SMTP dialogue and message against a minimal
+// relay
+func TestSMTPMailer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	var commands []string
+	var data strings.Builder
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 relay ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			commands = append(commands, line)
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 relay")
+			case line == "DATA":
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				reply("250 queued")
+			case line == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	mailer := NewSMTPMailer("127.0.0.1", addr.Port, "", "")
+	err = mailer.SendMail(context.TODO(), "auditor@example.com", []string{"alice@example.com"}, []string{"lead@example.com"},
+		"Namespace team-a\r\nBcc: evil@example.com", "Line one\nLine two\n")
+	require.NoError(t, err)
+	<-done
+
+	require.Contains(t, commands, "MAIL FROM:<auditor@example.com>")
+	require.Contains(t, commands, "RCPT TO:<alice@example.com>")
+	require.Contains(t, commands, "RCPT TO:<lead@example.com>")
+	message := data.String()
+	require.Contains(t, message, "To: alice@example.com\r\nCc: lead@example.com\r\n")
+	require.NotContains(t, message, "\r\nBcc:", "subjects cannot inject headers")
+	require.Contains(t, message, "\r\n\r\nLine one\r\nLine two\r\n")
+}
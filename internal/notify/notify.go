@@ -0,0 +1,280 @@
+// Package notify delivers auditor notifications to Slack and Microsoft Teams
+// incoming webhooks, routing each notification to the channels configured
+// for its severity, and emails namespace owners.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+)
+
+// requestTimeout bounds a single webhook request
+const requestTimeout = 10 * time.Second
+
+// Severity ranks notifications so that channels only receive the ones that
+// matter to them.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"     // Informational or an early reminder
+	SeverityWarning  Severity = "warning"  // A namespace will be deleted unless someone acts
+	SeverityCritical Severity = "critical" // A namespace is being or was deleted
+)
+
+// rank orders severities from least to most severe
+var rank = map[Severity]int{SeverityInfo: 0, SeverityWarning: 1, SeverityCritical: 2}
+
+// ParseSeverity parses a severity name.
+func ParseSeverity(value string) (Severity, error) {
+	s := Severity(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := rank[s]; !ok {
+		return "", fmt.Errorf("unknown severity %q, expected info, warning or critical", value)
+	}
+	return s, nil
+}
+
+// SeverityOf returns the severity of a notification event. Events still
+// leaving time to act are warnings, deletions are critical.
+func SeverityOf(event auditor.NotificationEvent) Severity {
+	switch event {
+	case auditor.EventMarked, auditor.EventOwnerlessMarked, auditor.EventApprovalRequired, auditor.EventFinalReminder, auditor.EventOrphaned, auditor.EventQuarantined:
+		return SeverityWarning
+	case auditor.EventDeleting, auditor.EventDeleted, auditor.EventBreakGlass, auditor.EventStuckTerminating, auditor.EventMissingOwners:
+		return SeverityCritical
+	default:
+		return SeverityInfo
+	}
+}
+
+// Kind identifies the webhook payload format of a channel.
+type Kind string
+
+const (
+	KindSlack Kind = "slack" // Slack incoming webhook
+	KindTeams Kind = "teams" // Microsoft Teams incoming webhook
+)
+
+// Channel is a webhook receiving notifications of at least MinSeverity.
+type Channel struct {
+	Kind        Kind     // Payload format
+	URL         string   // Incoming webhook URL
+	MinSeverity Severity // Least severe notification sent to the channel
+}
+
+// ParseChannels parses a comma-separated list of channels, each written as
+// severity=kind:url, e.g. "warning=slack:https://hooks.slack.com/services/...".
+// A channel receives notifications of its severity and above.
+func ParseChannels(value string) ([]Channel, error) {
+	var channels []Channel
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		severity, target, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid channel %q, expected severity=kind:url", redact(entry))
+		}
+		minSeverity, err := ParseSeverity(severity)
+		if err != nil {
+			return nil, err
+		}
+		kind, rawURL, ok := strings.Cut(target, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid channel %q, expected severity=kind:url", redact(entry))
+		}
+		switch Kind(kind) {
+		case KindSlack, KindTeams:
+		default:
+			return nil, fmt.Errorf("unknown webhook kind %q, expected slack or teams", kind)
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s webhook URL for severity %s", kind, minSeverity)
+		}
+		channels = append(channels, Channel{Kind: Kind(kind), URL: rawURL, MinSeverity: minSeverity})
+	}
+	return channels, nil
+}
+
+// redact drops the URL of a channel entry from error messages, since
+// webhook URLs embed their credentials
+func redact(entry string) string {
+	if i := strings.Index(entry, "://"); i >= 0 {
+		return entry[:i] + "://..."
+	}
+	return entry
+}
+
+// Message is the data webhook templates are rendered with: the notification
+// and its severity.
+type Message struct {
+	auditor.Notification
+	Severity Severity
+}
+
+// DefaultTemplate renders the text of webhook messages unless another
+// template is configured.
+const DefaultTemplate = `{{.Message}}` +
+	`{{if .Owner}}
+Owner: {{.Owner}}{{end}}` +
+	`{{if .Manager}}
+Manager: {{.Manager}}{{end}}` +
+	`{{if not .DeleteAt.IsZero}}
+Deletion after: {{.DeleteAt.UTC.Format "2006-01-02 15:04 MST"}}{{end}}` +
+	`{{range $key, $value := .Context}}
+{{$key}}: {{$value}}{{end}}`
+
+// ParseTemplate parses a message template and checks that it renders a
+// sample notification, so that unknown fields are reported on startup
+// rather than on the first notification.
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	sample := Message{
+		Notification: auditor.Notification{
+			Event:     auditor.EventMarked,
+			Namespace: "sample",
+			Owner:     "owner@example.com",
+			Manager:   "manager@example.com",
+			Message:   "sample notification",
+			Context:   map[string]string{"cost-center": "sample"},
+			DeleteAt:  time.Now(),
+		},
+		Severity: SeverityWarning,
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// Fanout delivers each notification to all of its notifiers, e.g. to the
+// log, webhooks and email. A failing notifier does not prevent delivery
+// by the others; all failures are returned joined.
+type Fanout []auditor.Notifier
+
+// Notify delivers the notification to every notifier.
+func (f Fanout) Notify(ctx context.Context, n auditor.Notification) error {
+	var errs []error
+	for _, notifier := range f {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WebhookNotifier posts notifications to Slack and Teams webhooks.
+type WebhookNotifier struct {
+	channels   []Channel
+	template   *template.Template
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to channels, rendering
+// messages with tmpl or DefaultTemplate if it is nil.
+func NewWebhookNotifier(channels []Channel, tmpl *template.Template) *WebhookNotifier {
+	if tmpl == nil {
+		tmpl = template.Must(ParseTemplate(DefaultTemplate))
+	}
+	return &WebhookNotifier{
+		channels:   channels,
+		template:   tmpl,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Notify posts the notification to every channel accepting its severity. A
+// failing channel does not prevent delivery to the others; all failures are
+// returned joined.
+func (w *WebhookNotifier) Notify(ctx context.Context, n auditor.Notification) error {
+	msg := Message{Notification: n, Severity: SeverityOf(n.Event)}
+	var text bytes.Buffer
+	if err := w.template.Execute(&text, msg); err != nil {
+		return fmt.Errorf("rendering notification: %w", err)
+	}
+
+	var errs []error
+	for _, channel := range w.channels {
+		if rank[msg.Severity] < rank[channel.MinSeverity] {
+			continue
+		}
+		if err := w.post(ctx, channel, payload(channel.Kind, msg, text.String())); err != nil {
+			errs = append(errs, fmt.Errorf("%s webhook (%s): %w", channel.Kind, channel.MinSeverity, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post sends a JSON payload to a channel
+func (w *WebhookNotifier) post(ctx context.Context, channel Channel, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		// The error names the URL, which embeds the webhook's credentials
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response: %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return nil
+}
+
+// title summarizes a notification in one line
+func title(msg Message) string {
+	if msg.Namespace == "" {
+		return fmt.Sprintf("[%s] Namespace auditor: %s", msg.Severity, msg.Event)
+	}
+	return fmt.Sprintf("[%s] Namespace %s: %s", msg.Severity, msg.Namespace, msg.Event)
+}
+
+// themeColors highlights Teams cards by severity
+var themeColors = map[Severity]string{
+	SeverityInfo:     "0078D7",
+	SeverityWarning:  "FFA500",
+	SeverityCritical: "D13438",
+}
+
+// payload builds the webhook body of a channel kind
+func payload(kind Kind, msg Message, text string) interface{} {
+	if kind == KindTeams {
+		return map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    title(msg),
+			"title":      title(msg),
+			"themeColor": themeColors[msg.Severity],
+			// Teams renders text as Markdown, where single newlines are ignored
+			"text": strings.ReplaceAll(text, "\n", "\n\n"),
+		}
+	}
+	return map[string]string{"text": "*" + title(msg) + "*\n" + text}
+}
@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookServer records the payloads posted to each path
+type webhookServer struct {
+	mu       sync.Mutex
+	payloads map[string][]map[string]string
+}
+
+func newWebhookServer(t *testing.T) (*webhookServer, *httptest.Server) {
+	s := &webhookServer{payloads: make(map[string][]map[string]string)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		s.mu.Lock()
+		s.payloads[r.URL.Path] = append(s.payloads[r.URL.Path], body)
+		s.mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+// TestRouting validates that channels receive notifications of their
+// severity and above, in their payload format
+func TestRouting(t *testing.T) {
+	received, server := newWebhookServer(t)
+	channels, err := ParseChannels("info=slack:" + server.URL + "/all, critical=teams:" + server.URL + "/critical")
+	require.NoError(t, err)
+	notifier := NewWebhookNotifier(channels, nil)
+
+	deleteAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, notifier.Notify(context.TODO(), auditor.Notification{
+		Event: auditor.EventMarked, Namespace: "team-a", Owner: "alice@example.com",
+		Message: "owner could not be validated", DeleteAt: deleteAt,
+	}))
+	require.NoError(t, notifier.Notify(context.TODO(), auditor.Notification{
+		Event: auditor.EventDeleted, Namespace: "team-b", Message: "namespace deleted",
+	}))
+
+	require.Len(t, received.payloads["/all"], 2)
+	marked := received.payloads["/all"][0]["text"]
+	require.Contains(t, marked, "*[warning] Namespace team-a: marked*")
+	require.Contains(t, marked, "Owner: alice@example.com")
+	require.Contains(t, marked, "Deletion after: 2024-05-01 12:00 UTC")
+
+	require.Len(t, received.payloads["/critical"], 1)
+	card := received.payloads["/critical"][0]
+	require.Equal(t, "MessageCard", card["@type"])
+	require.Equal(t, "[critical] Namespace team-b: deleted", card["title"])
+	require.Equal(t, themeColors[SeverityCritical], card["themeColor"])
+}
+
+// TestDeliveryFailure validates that a failing channel does not prevent
+// delivery to the others and that its URL is not reported
+func TestDeliveryFailure(t *testing.T) {
+	received, server := newWebhookServer(t)
+	notifier := NewWebhookNotifier([]Channel{
+		{Kind: KindSlack, URL: server.URL + "/broken", MinSeverity: SeverityInfo},
+		{Kind: KindSlack, URL: server.URL + "/ok", MinSeverity: SeverityInfo},
+		{Kind: KindSlack, URL: "http://127.0.0.1:1/secret-token", MinSeverity: SeverityInfo},
+	}, nil)
+
+	err := notifier.Notify(context.TODO(), auditor.Notification{Event: auditor.EventDeleted, Namespace: "team-a"})
+	require.ErrorContains(t, err, "410 Gone")
+	require.NotContains(t, err.Error(), "secret-token")
+	require.Len(t, received.payloads["/ok"], 1)
+}
+
+// TestTemplate validates custom message templates
+func TestTemplate(t *testing.T) {
+	received, server := newWebhookServer(t)
+	tmpl, err := ParseTemplate(`{{.Severity}}: {{.Namespace}} owned by {{.Owner}} ({{index .Context "cost-center"}})`)
+	require.NoError(t, err)
+	notifier := NewWebhookNotifier([]Channel{{Kind: KindSlack, URL: server.URL, MinSeverity: SeverityInfo}}, tmpl)
+
+	require.NoError(t, notifier.Notify(context.TODO(), auditor.Notification{
+		Event: auditor.EventMarked, Namespace: "team-a", Owner: "alice@example.com",
+		Context: map[string]string{"cost-center": "cc-42"},
+	}))
+	require.True(t, strings.HasSuffix(received.payloads["/"][0]["text"], "\nwarning: team-a owned by alice@example.com (cc-42)"))
+
+	_, err = ParseTemplate(`{{.Deadline}}`)
+	require.Error(t, err, "unknown fields are reported when the template is parsed")
+}
+
+// TestParseChannels validates channel settings
+func TestParseChannels(t *testing.T) {
+	channels, err := ParseChannels("warning=slack:https://hooks.slack.com/services/T/B/X,critical=teams:https://example.webhook.office.com/x")
+	require.NoError(t, err)
+	require.Equal(t, []Channel{
+		{Kind: KindSlack, URL: "https://hooks.slack.com/services/T/B/X", MinSeverity: SeverityWarning},
+		{Kind: KindTeams, URL: "https://example.webhook.office.com/x", MinSeverity: SeverityCritical},
+	}, channels)
+
+	for _, value := range []string{
+		"slack:https://hooks.slack.com/services/T/B/X",
+		"urgent=slack:https://hooks.slack.com/services/T/B/X",
+		"warning=email:https://example.com",
+		"warning=slack:hooks.slack.com",
+	} {
+		_, err := ParseChannels(value)
+		require.Error(t, err, value)
+		require.NotContains(t, err.Error(), "/services/T/B/X", "webhook URLs are not reported")
+	}
+}
+
+// TestSeverityOf validates the severity of notification events
+func TestSeverityOf(t *testing.T) {
+	require.Equal(t, SeverityWarning, SeverityOf(auditor.EventMarked))
+	require.Equal(t, SeverityWarning, SeverityOf(auditor.EventApprovalRequired))
+	require.Equal(t, SeverityInfo, SeverityOf(auditor.EventReminder))
+	require.Equal(t, SeverityWarning, SeverityOf(auditor.EventFinalReminder))
+	require.Equal(t, SeverityWarning, SeverityOf(auditor.EventOrphaned))
+	require.Equal(t, SeverityCritical, SeverityOf(auditor.EventDeleted))
+	require.Equal(t, SeverityCritical, SeverityOf(auditor.EventBreakGlass))
+	require.Equal(t, SeverityCritical, SeverityOf(auditor.EventMissingOwners))
+	require.Equal(t, SeverityInfo, SeverityOf("unknown"))
+}
+
+// TestFanout validates that every notifier is reached despite failures
+func TestFanout(t *testing.T) {
+	failing, mailer := &recordingMailer{err: errors.New("relay unavailable")}, &recordingMailer{}
+	fanout := Fanout{
+		NewEmailNotifier(failing, "auditor@example.com", nil),
+		NewEmailNotifier(mailer, "auditor@example.com", nil),
+	}
+	err := fanout.Notify(context.TODO(), auditor.Notification{Event: auditor.EventMarked, Namespace: "team-a", Owner: "alice@example.com"})
+	require.ErrorContains(t, err, "relay unavailable")
+	require.Len(t, mailer.sent, 1)
+}
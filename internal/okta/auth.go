@@ -0,0 +1,176 @@
+package okta
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UsersReadScope is the OAuth2 scope requested for user lookups
+const UsersReadScope = "okta.users.read"
+
+// assertionLifetime is how long a signed client assertion stays valid.
+// Okta rejects assertions valid for more than an hour.
+const assertionLifetime = 5 * time.Minute
+
+// tokenRefreshMargin renews access tokens this long before they expire
+const tokenRefreshMargin = time.Minute
+
+// authorizer produces the Authorization header for Okta API requests
+type authorizer interface {
+	authorization(ctx context.Context, httpClient *http.Client) (string, error)
+	reset()
+}
+
+// apiToken authenticates with a static Okta API token
+type apiToken string
+
+func (t apiToken) authorization(context.Context, *http.Client) (string, error) {
+	return "SSWS " + string(t), nil
+}
+
+func (apiToken) reset() {}
+
+// privateKeyJWT authenticates an Okta service app with the OAuth2 client
+// credentials flow, proving its identity with a client assertion signed by
+// its private key. Access tokens are cached until shortly before they expire.
+type privateKeyJWT struct {
+	tokenURL string          // Org authorization server token endpoint
+	clientID string          // Client ID of the service app
+	key      *rsa.PrivateKey // Key registered with the service app
+	keyID    string          // Key ID sent in the assertion header, optional
+
+	mu      sync.Mutex
+	token   string    // Cached access token
+	expires time.Time // When the cached access token expires
+}
+
+func (a *privateKeyJWT) authorization(ctx context.Context, httpClient *http.Client) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Now().Add(tokenRefreshMargin).Before(a.expires) {
+		return "Bearer " + a.token, nil
+	}
+
+	assertion, err := a.assertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"scope":                 {UsersReadScope},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// Okta answers a rejected client assertion with 400 or 401
+		if resp.StatusCode == http.StatusBadRequest {
+			return "", fmt.Errorf("access token: %w", &StatusError{StatusCode: http.StatusUnauthorized})
+		}
+		return "", fmt.Errorf("access token: %w", &StatusError{StatusCode: resp.StatusCode})
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode access token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("token response contained no access token")
+	}
+	a.token = token.AccessToken
+	a.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return "Bearer " + a.token, nil
+}
+
+// reset discards the cached access token, e.g. after it was rejected
+func (a *privateKeyJWT) reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+// assertion signs a client assertion issued at now with RS256
+func (a *privateKeyJWT) assertion(now time.Time) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", fmt.Errorf("failed to generate assertion ID: %w", err)
+	}
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	if a.keyID != "" {
+		header["kid"] = a.keyID
+	}
+	claims := map[string]interface{}{
+		"iss": a.clientID,
+		"sub": a.clientID,
+		"aud": a.tokenURL,
+		"iat": now.Unix(),
+		"exp": now.Add(assertionLifetime).Unix(),
+		"jti": hex.EncodeToString(jti),
+	}
+
+	var parts []string
+	for _, v := range []interface{}{header, claims} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, base64.RawURLEncoding.EncodeToString(b))
+	}
+	signingInput := strings.Join(parts, ".")
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign client assertion: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// ParsePrivateKey decodes a PEM-encoded RSA private key in PKCS #1 or
+// PKCS #8 form, as downloaded when generating a key for an Okta service app.
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM-encoded key found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T, expected RSA", parsed)
+	}
+	return key, nil
+}
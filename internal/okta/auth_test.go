@@ -0,0 +1,169 @@
+package okta
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey generates an RSA key for signing client assertions
+func testKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+// verifyAssertion checks the signature and claims of a client assertion
+func verifyAssertion(t *testing.T, assertion string, key *rsa.PublicKey, clientID, audience string) {
+	parts := strings.Split(assertion, ".")
+	require.Len(t, parts, 3)
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature))
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims struct {
+		Iss string `json:"iss"`
+		Sub string `json:"sub"`
+		Aud string `json:"aud"`
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+		Jti string `json:"jti"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &claims))
+	require.Equal(t, clientID, claims.Iss)
+	require.Equal(t, clientID, claims.Sub)
+	require.Equal(t, audience, claims.Aud)
+	require.NotEmpty(t, claims.Jti)
+	require.Greater(t, claims.Exp, claims.Iat)
+}
+
+// TestPrivateKeyJWT validates lookups authenticated with OAuth2 access tokens
+// obtained through a signed client assertion
+func TestPrivateKeyJWT(t *testing.T) {
+	key := testKey(t)
+	var tokenRequests int
+	var testServer *httptest.Server
+	testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/v1/token":
+			tokenRequests++
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			require.Equal(t, UsersReadScope, r.PostForm.Get("scope"))
+			verifyAssertion(t, r.PostForm.Get("client_assertion"), &key.PublicKey, "service-app", testServer.URL+"/oauth2/v1/token")
+			w.Write([]byte(`{"token_type":"Bearer","access_token":"access-token","expires_in":3600}`))
+		case "/api/v1/users/active@example.com":
+			require.Equal(t, "Bearer access-token", r.Header.Get("Authorization"))
+			w.Write([]byte(`{"status":"ACTIVE"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+	client := NewClientWithPrivateKey(testServer.URL, "service-app", key, "key-1")
+
+	for i := 0; i < 2; i++ {
+		exists, err := client.UserExists(context.Background(), "active@example.com")
+		require.NoError(t, err)
+		require.True(t, exists)
+	}
+	require.Equal(t, 1, tokenRequests, "access token should be reused until it expires")
+}
+
+// TestPrivateKeyJWTRejected validates that rejected credentials are reported
+// as authentication failures and that a rejected access token is renewed
+func TestPrivateKeyJWTRejected(t *testing.T) {
+	tokenStatus := http.StatusBadRequest
+	var tokenRequests int
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth2/v1/token" {
+			tokenRequests++
+			w.WriteHeader(tokenStatus)
+			if tokenStatus == http.StatusOK {
+				w.Write([]byte(`{"access_token":"revoked","expires_in":3600}`))
+			}
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer testServer.Close()
+	client := NewClientWithPrivateKey(testServer.URL, "service-app", testKey(t), "")
+
+	_, err := client.UserExists(context.Background(), "user@example.com")
+	require.ErrorIs(t, err, errs.ErrAuth)
+
+	tokenStatus = http.StatusOK
+	for i := 0; i < 2; i++ {
+		_, err = client.UserExists(context.Background(), "user@example.com")
+		require.ErrorIs(t, err, errs.ErrAuth)
+	}
+	require.Equal(t, 3, tokenRequests, "a rejected access token should not be reused")
+}
+
+// TestParsePrivateKey validates both PEM encodings Okta keys come in
+func TestParsePrivateKey(t *testing.T) {
+	key := testKey(t)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	for name, block := range map[string]*pem.Block{
+		"pkcs1": {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		"pkcs8": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := ParsePrivateKey(pem.EncodeToMemory(block))
+			require.NoError(t, err)
+			require.True(t, key.Equal(parsed))
+		})
+	}
+
+	_, err = ParsePrivateKey([]byte("not a key"))
+	require.Error(t, err)
+}
+
+// TestProviderPrivateKey validates selecting private key JWT authentication
+// through the identity registry
+func TestProviderPrivateKey(t *testing.T) {
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(testKey(t))})
+	env := map[string]string{
+		"OKTA_ORG_URL":     "https://example.okta.com",
+		"OKTA_CLIENT_ID":   "service-app",
+		"OKTA_PRIVATE_KEY": string(pemKey),
+	}
+	getenv := func(key string) string { return env[key] }
+
+	provider, err := identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+	require.IsType(t, &privateKeyJWT{}, provider.(*Client).auth)
+
+	env["OKTA_API_TOKEN"] = "token"
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "cannot be combined")
+
+	delete(env, "OKTA_API_TOKEN")
+	delete(env, "OKTA_CLIENT_ID")
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "OKTA_CLIENT_ID: required")
+
+	env["OKTA_CLIENT_ID"] = "service-app"
+	env["OKTA_PRIVATE_KEY"] = "garbage"
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "OKTA_PRIVATE_KEY:")
+}
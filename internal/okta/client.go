@@ -0,0 +1,158 @@
+// Package okta validates namespace owners against the Okta Users API.
+package okta
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+)
+
+// ProviderName is the name the Okta provider is registered under.
+const ProviderName = "okta"
+
+// requestTimeout bounds a single Okta API request
+const requestTimeout = 60 * time.Second
+
+func init() {
+	identity.Register(ProviderName, newProvider)
+}
+
+// StatusError reports an unexpected Okta API response, such as a 429 once the
+// organization's rate limit for the Users API is used up.
+type StatusError struct {
+	StatusCode int // HTTP status code returned by Okta
+}
+
+// Error describes the unexpected response.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected Okta API response: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Unwrap lets errors.Is match the class errs.FromHTTPStatus gives the Okta
+// status code.
+func (e *StatusError) Unwrap() error {
+	return errs.FromHTTPStatus(e.StatusCode)
+}
+
+// Client looks up users in an Okta organization.
+type Client struct {
+	orgURL     string       // Base URL of the organization, e.g. https://example.okta.com
+	auth       authorizer   // Credentials for API requests
+	httpClient *http.Client // Client for API and token requests
+}
+
+// NewClient creates a client for the Okta organization at orgURL,
+// authenticating with an API token.
+func NewClient(orgURL, token string) *Client {
+	return &Client{
+		orgURL:     strings.TrimSuffix(orgURL, "/"),
+		auth:       apiToken(token),
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// NewClientWithPrivateKey creates a client for the Okta organization at
+// orgURL, authenticating as the service app clientID with OAuth2 access
+// tokens obtained through a client assertion signed by key. keyID, if set,
+// selects the matching public key registered with the app. The app needs
+// the okta.users.read scope.
+func NewClientWithPrivateKey(orgURL, clientID string, key *rsa.PrivateKey, keyID string) *Client {
+	orgURL = strings.TrimSuffix(orgURL, "/")
+	return &Client{
+		orgURL: orgURL,
+		auth: &privateKeyJWT{
+			tokenURL: orgURL + "/oauth2/v1/token",
+			clientID: clientID,
+			key:      key,
+			keyID:    keyID,
+		},
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// UserExists reports whether a user with the given login exists and has not
+// been deactivated. Deprovisioned users count as missing, since their owners
+// can no longer sign in.
+func (c *Client) UserExists(ctx context.Context, email string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.orgURL+"/api/v1/users/"+url.PathEscape(email), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	authorization, err := c.auth.authorization(ctx, c.httpClient)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	case http.StatusUnauthorized:
+		c.auth.reset() // Fetch a new access token on the next lookup
+		return false, &StatusError{StatusCode: resp.StatusCode}
+	default:
+		return false, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var user struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return false, fmt.Errorf("failed to decode Okta user: %w", err)
+	}
+	return user.Status != "DEPROVISIONED", nil
+}
+
+// newProvider creates a Client from the OKTA_* settings. OKTA_ORG_URL is
+// always required, together with either OKTA_API_TOKEN or OKTA_CLIENT_ID and
+// OKTA_PRIVATE_KEY (PEM) for a service app, with optional OKTA_PRIVATE_KEY_ID.
+func newProvider(getenv func(string) string) (identity.Provider, error) {
+	var problems []error
+	orgURL := getenv("OKTA_ORG_URL")
+	if u, err := url.Parse(orgURL); orgURL == "" || err != nil || u.Scheme != "https" || u.Host == "" {
+		problems = append(problems, fmt.Errorf("OKTA_ORG_URL: required, expected e.g. https://example.okta.com, got %q", orgURL))
+	}
+
+	token, clientID, pemKey := getenv("OKTA_API_TOKEN"), getenv("OKTA_CLIENT_ID"), getenv("OKTA_PRIVATE_KEY")
+	var key *rsa.PrivateKey
+	switch {
+	case token != "" && (clientID != "" || pemKey != ""):
+		problems = append(problems, fmt.Errorf("OKTA_API_TOKEN: cannot be combined with OKTA_CLIENT_ID and OKTA_PRIVATE_KEY"))
+	case token != "":
+	case clientID == "" && pemKey == "":
+		problems = append(problems, fmt.Errorf("OKTA_API_TOKEN: required for Okta authentication unless OKTA_CLIENT_ID and OKTA_PRIVATE_KEY are set"))
+	case clientID == "":
+		problems = append(problems, fmt.Errorf("OKTA_CLIENT_ID: required with OKTA_PRIVATE_KEY"))
+	case pemKey == "":
+		problems = append(problems, fmt.Errorf("OKTA_PRIVATE_KEY: required with OKTA_CLIENT_ID"))
+	default:
+		var err error
+		if key, err = ParsePrivateKey([]byte(pemKey)); err != nil {
+			problems = append(problems, fmt.Errorf("OKTA_PRIVATE_KEY: %w", err))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	if key != nil {
+		return NewClientWithPrivateKey(orgURL, clientID, key, getenv("OKTA_PRIVATE_KEY_ID")), nil
+	}
+	return NewClient(orgURL, token), nil
+}
@@ -0,0 +1,70 @@
+package okta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserExists validates user lookups against a mock Okta API
+func TestUserExists(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "SSWS test-token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/v1/users/active@example.com":
+			w.Write([]byte(`{"status":"ACTIVE"}`))
+		case "/api/v1/users/gone@example.com":
+			w.Write([]byte(`{"status":"DEPROVISIONED"}`))
+		case "/api/v1/users/busy@example.com":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/api/v1/users/denied@example.com":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+	client := NewClient(testServer.URL+"/", "test-token")
+
+	tests := []struct {
+		email   string
+		exists  bool
+		errKind error
+	}{
+		{email: "active@example.com", exists: true},
+		{email: "gone@example.com"},
+		{email: "missing@example.com"},
+		{email: "busy@example.com", errKind: errs.ErrThrottled},
+		{email: "denied@example.com", errKind: errs.ErrPermission},
+	}
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			exists, err := client.UserExists(context.Background(), tt.email)
+			if tt.errKind != nil {
+				require.ErrorIs(t, err, tt.errKind)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.exists, exists)
+		})
+	}
+}
+
+// TestProviderRegistration validates creating the client through the identity registry
+func TestProviderRegistration(t *testing.T) {
+	env := map[string]string{"OKTA_ORG_URL": "https://example.okta.com", "OKTA_API_TOKEN": "token"}
+	provider, err := identity.New(ProviderName, func(key string) string { return env[key] })
+	require.NoError(t, err)
+	require.IsType(t, &Client{}, provider)
+
+	env["OKTA_ORG_URL"] = "example.okta.com"
+	delete(env, "OKTA_API_TOKEN")
+	_, err = identity.New(ProviderName, func(key string) string { return env[key] })
+	require.ErrorContains(t, err, "OKTA_ORG_URL: required")
+	require.ErrorContains(t, err, "OKTA_API_TOKEN: required")
+}
@@ -0,0 +1,162 @@
+// Package scim validates namespace owners against any SCIM 2.0 service
+// provider (RFC 7644), such as Keycloak, OneLogin or JumpCloud.
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+)
+
+// ProviderName is the name the SCIM provider is registered under.
+const ProviderName = "scim"
+
+// DefaultUserAttribute is the user attribute matched against owner emails
+// unless configured otherwise.
+const DefaultUserAttribute = "userName"
+
+// requestTimeout bounds a single SCIM request
+const requestTimeout = 60 * time.Second
+
+// attributePattern matches SCIM attribute paths such as "userName" or "emails.value"
+var attributePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$-]*(\.[A-Za-z][A-Za-z0-9_$-]*)?$`)
+
+func init() {
+	identity.Register(ProviderName, newProvider)
+}
+
+// StatusError reports an unexpected SCIM response, along with the detail
+// the service provider gave for it.
+type StatusError struct {
+	StatusCode int    // HTTP status code returned by the service provider
+	Detail     string // Detail of the SCIM error response, if any
+}
+
+// Error describes the unexpected response.
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("unexpected SCIM response: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// Unwrap maps the status code to errs.ErrThrottled, errs.ErrAuth or
+// errs.ErrPermission, see errs.FromHTTPStatus.
+func (e *StatusError) Unwrap() error {
+	return errs.FromHTTPStatus(e.StatusCode)
+}
+
+// Client looks up users through a SCIM 2.0 /Users endpoint.
+type Client struct {
+	baseURL    string       // SCIM base URL, e.g. https://idp.example.com/scim/v2
+	token      string       // Bearer token
+	attribute  string       // User attribute compared with owner emails
+	httpClient *http.Client // Client for SCIM requests
+}
+
+// NewClient creates a client for the SCIM service provider at baseURL,
+// authenticating with a bearer token. Owners are matched against attribute,
+// DefaultUserAttribute if empty.
+func NewClient(baseURL, token, attribute string) *Client {
+	if attribute == "" {
+		attribute = DefaultUserAttribute
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		attribute:  attribute,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// UserExists reports whether a user whose attribute equals email exists and
+// is active. Users without an active attribute count as active.
+func (c *Client) UserExists(ctx context.Context, email string) (bool, error) {
+	query := url.Values{
+		"filter":     {c.filter(email)},
+		"attributes": {"active"},
+		"count":      {"1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/Users?"+query.Encode(), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/scim+json, application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, &StatusError{StatusCode: resp.StatusCode, Detail: errorDetail(resp.Body)}
+	}
+
+	var list struct {
+		TotalResults int `json:"totalResults"`
+		Resources    []struct {
+			Active *bool `json:"active"`
+		} `json:"Resources"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return false, fmt.Errorf("failed to decode SCIM list response: %w", err)
+	}
+	for _, user := range list.Resources {
+		if user.Active == nil || *user.Active {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// filter builds the SCIM filter matching email. The value is quoted as a
+// JSON string, so that an owner annotation cannot alter the filter.
+func (c *Client) filter(email string) string {
+	value, _ := json.Marshal(email) // Marshalling a string never fails
+	return c.attribute + " eq " + string(value)
+}
+
+// errorDetail extracts the detail of a SCIM error response
+func errorDetail(body io.Reader) string {
+	var scimErr struct {
+		Detail string `json:"detail"`
+	}
+	if json.NewDecoder(io.LimitReader(body, 64<<10)).Decode(&scimErr) != nil {
+		return ""
+	}
+	return scimErr.Detail
+}
+
+// newProvider creates a Client from the SCIM_BASE_URL and SCIM_TOKEN
+// settings, and the optional SCIM_USER_ATTRIBUTE.
+func newProvider(getenv func(string) string) (identity.Provider, error) {
+	var problems []error
+	baseURL := getenv("SCIM_BASE_URL")
+	if u, err := url.Parse(baseURL); baseURL == "" || err != nil || u.Scheme != "https" || u.Host == "" {
+		problems = append(problems, fmt.Errorf("SCIM_BASE_URL: required, expected e.g. https://idp.example.com/scim/v2, got %q", baseURL))
+	}
+	token := getenv("SCIM_TOKEN")
+	if token == "" {
+		problems = append(problems, fmt.Errorf("SCIM_TOKEN: required for SCIM authentication"))
+	}
+	attribute := getenv("SCIM_USER_ATTRIBUTE")
+	if attribute != "" && !attributePattern.MatchString(attribute) {
+		problems = append(problems, fmt.Errorf("SCIM_USER_ATTRIBUTE: invalid attribute %q, expected e.g. userName or emails.value", attribute))
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	return NewClient(baseURL, token, attribute), nil
+}
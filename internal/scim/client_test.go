@@ -0,0 +1,85 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bryanpaget/namespace-auditor/internal/errs"
+	"github.com/bryanpaget/namespace-auditor/internal/identity"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserExists validates user lookups against a mock SCIM service provider
+func TestUserExists(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/scim/v2/Users", r.URL.Path)
+		require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/scim+json")
+		switch r.URL.Query().Get("filter") {
+		case `userName eq "active@example.com"`:
+			w.Write([]byte(`{"totalResults":1,"Resources":[{"id":"1","active":true}]}`))
+		case `userName eq "implicit@example.com"`:
+			w.Write([]byte(`{"totalResults":1,"Resources":[{"id":"2"}]}`))
+		case `userName eq "inactive@example.com"`:
+			w.Write([]byte(`{"totalResults":1,"Resources":[{"id":"3","active":false}]}`))
+		case `userName eq "busy@example.com"`:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case `userName eq "denied@example.com"`:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"detail":"insufficient scope","status":"403"}`))
+		default:
+			w.Write([]byte(`{"totalResults":0,"Resources":[]}`))
+		}
+	}))
+	defer testServer.Close()
+	client := NewClient(testServer.URL+"/scim/v2/", "test-token", "")
+
+	tests := []struct {
+		email   string
+		exists  bool
+		errKind error
+	}{
+		{email: "active@example.com", exists: true},
+		{email: "implicit@example.com", exists: true},
+		{email: "inactive@example.com"},
+		{email: "missing@example.com"},
+		{email: "busy@example.com", errKind: errs.ErrThrottled},
+		{email: "denied@example.com", errKind: errs.ErrPermission},
+	}
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			exists, err := client.UserExists(context.Background(), tt.email)
+			if tt.errKind != nil {
+				require.ErrorIs(t, err, tt.errKind)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.exists, exists)
+		})
+	}
+}
+
+// TestFilterQuotesEmail ensures an owner annotation cannot alter the filter
+func TestFilterQuotesEmail(t *testing.T) {
+	client := NewClient("https://idp.example.com/scim/v2", "token", "emails.value")
+	require.Equal(t, `emails.value eq "a\" or userName pr \"@example.com"`, client.filter(`a" or userName pr "@example.com`))
+}
+
+// TestProviderRegistration validates creating the client through the identity registry
+func TestProviderRegistration(t *testing.T) {
+	env := map[string]string{"SCIM_BASE_URL": "https://idp.example.com/scim/v2", "SCIM_TOKEN": "token"}
+	getenv := func(key string) string { return env[key] }
+	provider, err := identity.New(ProviderName, getenv)
+	require.NoError(t, err)
+	require.Equal(t, DefaultUserAttribute, provider.(*Client).attribute)
+
+	env["SCIM_BASE_URL"] = "http://idp.example.com/scim/v2"
+	env["SCIM_USER_ATTRIBUTE"] = "userName pr or"
+	delete(env, "SCIM_TOKEN")
+	_, err = identity.New(ProviderName, getenv)
+	require.ErrorContains(t, err, "SCIM_BASE_URL: required")
+	require.ErrorContains(t, err, "SCIM_TOKEN: required")
+	require.ErrorContains(t, err, "SCIM_USER_ATTRIBUTE: invalid attribute")
+}
@@ -0,0 +1,99 @@
+// Package ownercheck exposes the namespace auditor's owner validation so
+// that other automation, such as profile provisioning, can check an owner
+// before creating a namespace instead of duplicating the rules.
+package ownercheck
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bryanpaget/namespace-auditor/internal/auditor"
+)
+
+// DefaultTTL is how long validation results are cached unless configured
+// otherwise.
+const DefaultTTL = 5 * time.Minute
+
+// Verdict is the outcome of validating an owner.
+type Verdict string
+
+// Owner validation outcomes
+const (
+	Valid         Verdict = "valid"          // Allowed domain and the user exists
+	InvalidDomain Verdict = "invalid-domain" // Malformed address or domain not allowed
+	NotFound      Verdict = "not-found"      // The identity provider does not know the user
+)
+
+// UserChecker looks up users in the identity provider. The auditor's Graph
+// client implements it.
+type UserChecker interface {
+	UserExists(ctx context.Context, email string) (bool, error)
+}
+
+// Validator validates owners with the auditor's rules, caching results.
+// It is safe for concurrent use.
+type Validator struct {
+	checker        UserChecker
+	allowedDomains []string
+	ttl            time.Duration
+	now            func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+// cached is a validation result and when it expires
+type cached struct {
+	verdict Verdict
+	expires time.Time
+}
+
+// NewValidator creates a validator for owners in allowedDomains, which are
+// given in the same form as the auditor's ALLOWED_DOMAINS setting. Results
+// are cached for ttl; zero or negative means DefaultTTL.
+func NewValidator(checker UserChecker, allowedDomains string, ttl time.Duration) (*Validator, error) {
+	domains, err := auditor.ParseAllowedDomains(allowedDomains)
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Validator{
+		checker:        checker,
+		allowedDomains: domains,
+		ttl:            ttl,
+		now:            time.Now,
+		cache:          make(map[string]cached),
+	}, nil
+}
+
+// ValidateOwner checks whether email would be accepted as a namespace owner
+// by the auditor. Lookup errors are returned and never cached.
+func (v *Validator) ValidateOwner(ctx context.Context, email string) (Verdict, error) {
+	if !auditor.IsAllowedOwner(email, v.allowedDomains) {
+		return InvalidDomain, nil
+	}
+
+	v.mu.Lock()
+	entry, ok := v.cache[email]
+	v.mu.Unlock()
+	if ok && v.now().Before(entry.expires) {
+		return entry.verdict, nil
+	}
+
+	exists, err := v.checker.UserExists(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	verdict := NotFound
+	if exists {
+		verdict = Valid
+	}
+
+	v.mu.Lock()
+	v.cache[email] = cached{verdict: verdict, expires: v.now().Add(v.ttl)}
+	v.mu.Unlock()
+	return verdict, nil
+}
@@ -0,0 +1,81 @@
+package ownercheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingChecker reports the users in existing and counts lookups
+type countingChecker struct {
+	existing map[string]bool
+	calls    int
+	err      error
+}
+
+func (c *countingChecker) UserExists(_ context.Context, email string) (bool, error) {
+	c.calls++
+	return c.existing[email], c.err
+}
+
+// TestValidateOwner validates verdicts and caching
+func TestValidateOwner(t *testing.T) {
+	checker := &countingChecker{existing: map[string]bool{"alice@example.com": true, "carol@EXAMPLE.com.": true}}
+	v, err := NewValidator(checker, "example.com", time.Minute)
+	if err != nil {
+		t.Fatalf("NewValidator failed: %v", err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	v.now = func() time.Time { return now }
+
+	tests := []struct {
+		email string
+		want  Verdict
+	}{
+		{"alice@example.com", Valid},
+		{"bob@example.com", NotFound},
+		{"carol@EXAMPLE.com.", Valid},
+		{"mallory@evil.com", InvalidDomain},
+		{"not-an-email", InvalidDomain},
+	}
+	for _, tt := range tests {
+		got, err := v.ValidateOwner(context.TODO(), tt.email)
+		if err != nil || got != tt.want {
+			t.Errorf("ValidateOwner(%q) = %q, %v; want %q", tt.email, got, err, tt.want)
+		}
+	}
+	if checker.calls != 3 {
+		t.Errorf("Expected 3 lookups, got %d", checker.calls)
+	}
+
+	// Cached until the TTL passes
+	_, _ = v.ValidateOwner(context.TODO(), "alice@example.com")
+	if checker.calls != 3 {
+		t.Errorf("Expected cached result, got %d lookups", checker.calls)
+	}
+	now = now.Add(2 * time.Minute)
+	_, _ = v.ValidateOwner(context.TODO(), "alice@example.com")
+	if checker.calls != 4 {
+		t.Errorf("Expected lookup after expiry, got %d lookups", checker.calls)
+	}
+}
+
+// TestValidateOwnerErrors validates that lookup errors are returned and not cached
+func TestValidateOwnerErrors(t *testing.T) {
+	checker := &countingChecker{err: errors.New("graph unavailable")}
+	v, _ := NewValidator(checker, "example.com", 0)
+
+	for i := 0; i < 2; i++ {
+		if _, err := v.ValidateOwner(context.TODO(), "alice@example.com"); err == nil {
+			t.Fatal("Expected lookup error")
+		}
+	}
+	if checker.calls != 2 {
+		t.Errorf("Errors must not be cached, got %d lookups", checker.calls)
+	}
+
+	if _, err := NewValidator(checker, "", 0); err == nil {
+		t.Error("Expected error without allowed domains")
+	}
+}